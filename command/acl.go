@@ -0,0 +1,174 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var aclGetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument [argument]
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the ACL of an object
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/object
+
+	2. Print the ACLs of all objects with a prefix as JSON
+		 > s5cmd --json {{.HelpName}} "s3://bucket/prefix/*"
+`
+
+var aclSetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument [argument]
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Make all objects with a prefix publicly readable
+		 > s5cmd {{.HelpName}} --canned public-read "s3://bucket/prefix/*"
+
+	2. Make an object private
+		 > s5cmd {{.HelpName}} --canned private s3://bucket/prefix/object
+`
+
+func NewACLCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "acl",
+		HelpName: "acl",
+		Usage:    "print or replace access control lists of objects",
+		Subcommands: []*cli.Command{
+			newACLGetCommand(),
+			newACLSetCommand(),
+		},
+	}
+}
+
+func newACLGetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "get",
+		HelpName:           "acl get",
+		Usage:              "print access control lists of objects",
+		CustomHelpTemplate: aclGetHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateObjectBatch(c, "acl")
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return newObjectBatch(c).run(c.Context, func(ctx context.Context, client *storage.S3, u *url.URL) error {
+				acl, err := client.ACL(ctx, u)
+				if err != nil {
+					return err
+				}
+				log.Info(ACLMessage{Source: u, ACL: acl})
+				return nil
+			})
+		},
+	}
+}
+
+func newACLSetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "set",
+		HelpName:           "acl set",
+		Usage:              "replace access control lists of objects with a canned ACL",
+		CustomHelpTemplate: aclSetHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "canned",
+				Usage: fmt.Sprintf("canned ACL replacing the ACLs of the objects: (%s)", strings.Join(s3.ObjectCannedACL_Values(), ", ")),
+			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateACLSetCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			canned := c.String("canned")
+			op := c.Command.HelpName
+			return newObjectBatch(c).run(c.Context, func(ctx context.Context, client *storage.S3, u *url.URL) error {
+				if err := client.SetACL(ctx, u, canned); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: u})
+				return nil
+			})
+		},
+	}
+}
+
+// ACLMessage is a structure for logging the access control list of an
+// object.
+type ACLMessage struct {
+	Source *url.URL `json:"source"`
+	*storage.ACL
+}
+
+// String returns the string representation of ACLMessage, the grants are
+// printed as permission:grantee pairs.
+func (m ACLMessage) String() string {
+	grants := make([]string, 0, len(m.Grants))
+	for _, grant := range m.Grants {
+		grants = append(grants, fmt.Sprintf("%s:%s", grant.Permission, grant.Grantee))
+	}
+	return fmt.Sprintf("%v owner=%s %s", m.Source, m.Owner, strings.Join(grants, " "))
+}
+
+// JSON returns the JSON representation of ACLMessage.
+func (m ACLMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validateACLSetCommand(c *cli.Context) error {
+	if err := validateObjectBatch(c, "acl"); err != nil {
+		return err
+	}
+
+	canned := c.String("canned")
+	if canned == "" {
+		return fmt.Errorf("expected a canned ACL with --canned")
+	}
+	for _, value := range s3.ObjectCannedACL_Values() {
+		if canned == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("canned ACL must be one of %s", strings.Join(s3.ObjectCannedACL_Values(), ", "))
+}
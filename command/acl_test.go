@@ -0,0 +1,31 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestACLMessage(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.New("s3://bucket/key")
+	assert.NoError(t, err)
+
+	msg := ACLMessage{
+		Source: u,
+		ACL: &storage.ACL{
+			Owner: "owner-id",
+			Grants: []storage.Grant{
+				{Grantee: "owner-id", Type: "CanonicalUser", Permission: "FULL_CONTROL"},
+				{Grantee: "http://acs.amazonaws.com/groups/global/AllUsers", Type: "Group", Permission: "READ"},
+			},
+		},
+	}
+
+	assert.Equal(t, "s3://bucket/key owner=owner-id FULL_CONTROL:owner-id READ:http://acs.amazonaws.com/groups/global/AllUsers", msg.String())
+	assert.Equal(t, `{"source":"s3://bucket/key","owner":"owner-id","grants":[{"grantee":"owner-id","type":"CanonicalUser","permission":"FULL_CONTROL"},{"grantee":"http://acs.amazonaws.com/groups/global/AllUsers","type":"Group","permission":"READ"}]}`, msg.JSON())
+}
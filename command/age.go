@@ -0,0 +1,131 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+// errObjectAgeMismatch indicates the modification time of an object is out
+// of the range given by --older-than and --newer-than flags.
+var errObjectAgeMismatch = errors.New("object age does not match")
+
+// ageFilter filters objects by their modification times relative to the
+// time the command started. Zero times are unset.
+type ageFilter struct {
+	// modifiedBefore is the time the objects must be modified before.
+	modifiedBefore time.Time
+	// modifiedAfter is the time the objects must be modified after.
+	modifiedAfter time.Time
+}
+
+// parseAgeFilter parses --older-than and --newer-than flags of the command.
+func parseAgeFilter(c *cli.Context) (ageFilter, error) {
+	var filter ageFilter
+	now := time.Now()
+
+	var olderThan, newerThan time.Duration
+	if value := c.String("older-than"); value != "" {
+		age, err := parseAge(value)
+		if err != nil {
+			return ageFilter{}, fmt.Errorf("older-than: %v", err)
+		}
+		olderThan = age
+		filter.modifiedBefore = now.Add(-age)
+	}
+
+	if value := c.String("newer-than"); value != "" {
+		age, err := parseAge(value)
+		if err != nil {
+			return ageFilter{}, fmt.Errorf("newer-than: %v", err)
+		}
+		newerThan = age
+		filter.modifiedAfter = now.Add(-age)
+	}
+
+	if olderThan > 0 && newerThan > 0 && olderThan >= newerThan {
+		return ageFilter{}, fmt.Errorf("newer-than must be greater than older-than")
+	}
+	return filter, nil
+}
+
+// parseAge parses ages like 30d, 2w or 12h. Units of time.ParseDuration are
+// accepted along with days (d) and weeks (w).
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("age is empty")
+	}
+
+	var age time.Duration
+	if unit := strings.ToLower(s[len(s)-1:]); unit == "d" || unit == "w" {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q, e.g. 30d, 2w or 12h", s)
+		}
+
+		day := 24 * time.Hour
+		if unit == "w" {
+			day *= 7
+		}
+		age = time.Duration(n * float64(day))
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q, e.g. 30d, 2w or 12h", s)
+		}
+		age = d
+	}
+
+	if age <= 0 {
+		return 0, fmt.Errorf("age must be positive")
+	}
+	return age, nil
+}
+
+// isSet reports whether any age is given.
+func (f ageFilter) isSet() bool {
+	return !f.modifiedBefore.IsZero() || !f.modifiedAfter.IsZero()
+}
+
+// match reports whether the object is modified in the range of the filter.
+// The modification time of the object is fetched if it is not listed, e.g.
+// if the object is given without wildcards.
+func (f ageFilter) match(ctx context.Context, client storage.Storage, object *storage.Object) (bool, error) {
+	if !f.isSet() {
+		return true, nil
+	}
+
+	modTime := object.ModTime
+	if modTime == nil {
+		obj, err := client.Stat(ctx, object.URL)
+		if err != nil {
+			return false, err
+		}
+		modTime = obj.ModTime
+	}
+	if modTime == nil {
+		return false, nil
+	}
+
+	return f.matchTime(*modTime), nil
+}
+
+// matchTime reports whether the given modification time is in the range of
+// the filter.
+func (f ageFilter) matchTime(modTime time.Time) bool {
+	if !f.modifiedBefore.IsZero() && !modTime.Before(f.modifiedBefore) {
+		return false
+	}
+	if !f.modifiedAfter.IsZero() && !modTime.After(f.modifiedAfter) {
+		return false
+	}
+	return true
+}
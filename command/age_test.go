@@ -0,0 +1,56 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "30d", expected: 30 * 24 * time.Hour},
+		{value: "1.5D", expected: 36 * time.Hour},
+		{value: "2w", expected: 14 * 24 * time.Hour},
+		{value: "12h", expected: 12 * time.Hour},
+		{value: "1h30m", expected: 90 * time.Minute},
+		{value: " 45s ", expected: 45 * time.Second},
+	}
+	for _, tc := range tests {
+		age, err := parseAge(tc.value)
+		assert.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, age, tc.value)
+	}
+
+	for _, value := range []string{"", "d", "30", "30y", "-1d", "0h", "abc"} {
+		_, err := parseAge(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestAgeFilterMatchTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	filter := ageFilter{
+		modifiedBefore: now.Add(-24 * time.Hour),
+		modifiedAfter:  now.Add(-72 * time.Hour),
+	}
+
+	assert.True(t, filter.matchTime(now.Add(-48*time.Hour)))
+	assert.False(t, filter.matchTime(now.Add(-time.Hour)))
+	assert.False(t, filter.matchTime(now.Add(-96*time.Hour)))
+
+	// only older objects are matched.
+	filter = ageFilter{modifiedBefore: now.Add(-24 * time.Hour)}
+	assert.True(t, filter.matchTime(now.Add(-96*time.Hour)))
+	assert.False(t, filter.matchTime(now))
+
+	assert.False(t, ageFilter{}.isSet())
+	assert.True(t, filter.isSet())
+}
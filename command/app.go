@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	cmpinstall "github.com/posener/complete/cmd/install"
 	"github.com/urfave/cli/v2"
@@ -12,6 +14,7 @@ import (
 	"github.com/peak/s5cmd/log/stat"
 	"github.com/peak/s5cmd/parallel"
 	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
 )
 
 const (
@@ -21,6 +24,10 @@ const (
 	appName = "s5cmd"
 )
 
+// transferConfig holds the S3 transfer settings of the AWS shared config
+// file. They are used unless the corresponding flags are given.
+var transferConfig storage.TransferConfig
+
 var app = &cli.App{
 	Name:  appName,
 	Usage: "Blazing fast S3 and local filesystem execution tool",
@@ -40,11 +47,27 @@ var app = &cli.App{
 			Value:   defaultRetryCount,
 			Usage:   "number of times that a request will be retried for failures",
 		},
+		&cli.DurationFlag{
+			Name:  "retry-max-delay",
+			Usage: "maximum delay between the retries of a request, the delay grows exponentially up to 5m by default",
+		},
+		&cli.StringFlag{
+			Name:  "retry-policy",
+			Usage: "number of retries of the error classes overriding --retry-count, e.g. 'throttling=20,kms-throttling=30,server=3,connection=10,timeout=5'",
+		},
+		&cli.Float64Flag{
+			Name:  "kms-rate",
+			Usage: "limit the requests which may call KMS, e.g. uploads and downloads of SSE-KMS encrypted objects, to given number per second across all commands (0 means no limit)",
+		},
 		&cli.StringFlag{
 			Name:    "endpoint-url",
 			Usage:   "override default S3 host for custom services",
 			EnvVars: []string{"S3_ENDPOINT_URL"},
 		},
+		&cli.BoolFlag{
+			Name:  "use-accelerate-endpoint",
+			Usage: "use S3 Transfer Acceleration endpoints of buckets, falls back to the regular endpoints if acceleration is not enabled",
+		},
 		&cli.BoolFlag{
 			Name:  "no-verify-ssl",
 			Usage: "disable SSL certificate verification",
@@ -57,6 +80,18 @@ var app = &cli.App{
 			},
 			Usage: "log level: (trace, debug, info, error)",
 		},
+		&cli.StringFlag{
+			Name:  "log-sample",
+			Usage: "log only a sample of the successful operations, e.g. 1% or 0.01, and print their exact counts at the end; failures are always logged",
+		},
+		&cli.GenericFlag{
+			Name: "diagnostics",
+			Value: &EnumValue{
+				Enum:    []string{"stderr", "stdout"},
+				Default: "stderr",
+			},
+			Usage: "where to print debug logs and statistics: (stderr, stdout)",
+		},
 		&cli.BoolFlag{
 			Name:  "install-completion",
 			Usage: "install completion for your shell",
@@ -65,6 +100,24 @@ var app = &cli.App{
 			Name:  "dry-run",
 			Usage: "fake run; show what commands will be executed without actually executing them",
 		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "estimate the requests, duration and cost of the command and print them at the end, implies --dry-run",
+		},
+		&cli.StringFlag{
+			Name:  "simulate-throughput",
+			Value: defaultSimulateThroughput,
+			Usage: "bytes transferred per second to estimate the duration of the command with --simulate",
+		},
+		&cli.DurationFlag{
+			Name:  "simulate-latency",
+			Value: defaultSimulateLatency,
+			Usage: "duration of a request to estimate the duration of the command with --simulate",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "do not prompt for confirmation of destructive operations, overrides --interactive flags of commands",
+		},
 		&cli.BoolFlag{
 			Name:  "stat",
 			Usage: "collect statistics of program execution and display it at the end",
@@ -73,6 +126,14 @@ var app = &cli.App{
 			Name:  "no-sign-request",
 			Usage: "do not sign requests: credentials will not be loaded if --no-sign-request is provided",
 		},
+		&cli.BoolFlag{
+			Name:  "streaming-signature",
+			Usage: "sign uploaded content chunk by chunk with aws-chunked encoding while it is sent, instead of hashing each part before sending it",
+		},
+		&cli.BoolFlag{
+			Name:  "io-uring",
+			Usage: "(experimental) read and write the parts of local files with io_uring on Linux, the regular system calls are used if it is not available",
+		},
 		&cli.BoolFlag{
 			Name:  "use-list-objects-v1",
 			Usage: "use ListObjectsV1 API for services that don't support ListObjectsV2",
@@ -81,6 +142,37 @@ var app = &cli.App{
 			Name:  "request-payer",
 			Usage: "who pays for request (access requester pays buckets)",
 		},
+		&cli.IntFlag{
+			Name:  "key-shard-length",
+			Usage: "store objects under a hash prefix of the given number of hex digits, e.g. ab/key, to spread the request load over key prefixes (0 disables it)",
+		},
+		&cli.StringSliceFlag{
+			Name:    "concurrency-limit",
+			Usage:   "cap concurrent requests sent to given endpoint or bucket across all commands, e.g. --concurrency-limit ceph.local:7480=16 --concurrency-limit s3://bucket=64",
+			EnvVars: []string{"S5CMD_CONCURRENCY_LIMITS"},
+		},
+		&cli.StringFlag{
+			Name:    "bandwidth-schedule",
+			Usage:   "cap the bandwidth of the transfers of all commands by the local time of the day, adjusted as the time passes, e.g. '09:00-18:00=50MB,unlimited'",
+			EnvVars: []string{"S5CMD_BANDWIDTH_SCHEDULE"},
+		},
+		&cli.IntFlag{
+			Name:  "preconnect",
+			Usage: "open and handshake given number of connections to the endpoint of each bucket before the transfers start",
+		},
+		&cli.StringFlag{
+			Name:  "lister-cmd",
+			Usage: "list remote source objects of ls, cp, mv and sync commands with given shell command instead of ListObjects API, e.g. to list them from a catalog; the command prints a key or an ls --json line per object of $S5CMD_LIST_URL",
+		},
+		&cli.StringSliceFlag{
+			Name:  "failover",
+			Usage: "fail over reads of buckets to given replica after persistent server or connection errors, tried in the given order, e.g. --failover https://dr.example.com --failover s3://bucket=s3://replica-bucket",
+		},
+		&cli.StringFlag{
+			Name:    "url-rules",
+			Usage:   "resolve logical URL prefixes, e.g. store://dataset/, to concrete URLs using the rules in given file",
+			EnvVars: []string{"S5CMD_URL_RULES"},
+		},
 	},
 	Before: func(c *cli.Context) error {
 		retryCount := c.Int("retry-count")
@@ -89,8 +181,24 @@ var app = &cli.App{
 		logLevel := c.String("log")
 		isStat := c.Bool("stat")
 
+		if c.String("diagnostics") == "stdout" {
+			log.SetDiagnosticsOutput(os.Stdout)
+		}
 		log.Init(logLevel, printJSON)
+
+		// an invalid transfer config shouldn't fail the commands which don't
+		// transfer any objects, fall back to the defaults instead.
+		cfg, err := storage.LoadTransferConfig()
+		if err != nil {
+			log.Warning(log.WarningMessage{
+				Warning: fmt.Sprintf("ignoring transfer settings: %v", err),
+			})
+		}
+		if !c.IsSet("numworkers") && cfg.MaxConcurrentRequests > 0 {
+			workerCount = cfg.MaxConcurrentRequests
+		}
 		parallel.Init(workerCount)
+		transferConfig = cfg
 
 		if retryCount < 0 {
 			err := fmt.Errorf("retry count cannot be a negative value")
@@ -98,6 +206,113 @@ var app = &cli.App{
 			return err
 		}
 
+		if c.Duration("retry-max-delay") < 0 {
+			err := fmt.Errorf("retry max delay cannot be a negative value")
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
+		if _, err := storage.ParseRetryPolicy(c.String("retry-policy")); err != nil {
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
+		sampleRate, err := parseSampleRate(c.String("log-sample"))
+		if err != nil {
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		log.SetSampleRate(sampleRate)
+
+		if n := c.Int("key-shard-length"); n < 0 || n > storage.MaxKeyShardLength {
+			err := fmt.Errorf("key shard length must be between 0 and %d", storage.MaxKeyShardLength)
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
+		var limits []storage.ConcurrencyLimit
+		for _, value := range c.StringSlice("concurrency-limit") {
+			limit, err := storage.ParseConcurrencyLimit(value)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+			limits = append(limits, limit)
+		}
+		storage.SetConcurrencyLimits(limits)
+
+		if c.Float64("kms-rate") < 0 {
+			err := fmt.Errorf("kms rate cannot be a negative value")
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+		storage.SetKMSRate(c.Float64("kms-rate"))
+
+		if value := c.String("bandwidth-schedule"); value != "" {
+			schedule, err := parseBandwidthSchedule(value)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+			storage.SetBandwidthSchedule(schedule)
+		}
+
+		var failovers []storage.Failover
+		for _, value := range c.StringSlice("failover") {
+			failover, err := storage.ParseFailover(value)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+			failovers = append(failovers, failover)
+		}
+		storage.SetFailovers(failovers)
+
+		if path := c.String("url-rules"); path != "" {
+			if err := loadURLRewriteRules(path); err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+		}
+
+		if c.Int("preconnect") < 0 {
+			err := fmt.Errorf("preconnect cannot be a negative value")
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
+		if c.Bool("streaming-signature") && c.Bool("no-sign-request") {
+			err := fmt.Errorf("streaming-signature can not be used with no-sign-request")
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
+		if c.Bool("use-accelerate-endpoint") && c.String("endpoint-url") != "" {
+			err := fmt.Errorf("use-accelerate-endpoint can not be used with endpoint-url")
+			printError(commandFromContext(c), c.Command.Name, err)
+			return err
+		}
+
+		if c.Bool("simulate") {
+			throughput, err := parseByteSize(c.String("simulate-throughput"))
+			if err == nil && throughput <= 0 {
+				err = fmt.Errorf("simulate-throughput must be positive")
+			}
+			if err == nil && c.Duration("simulate-latency") < 0 {
+				err = fmt.Errorf("simulate-latency cannot be a negative value")
+			}
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+
+			// nothing is changed on simulations.
+			if err := c.Set("dry-run", "true"); err != nil {
+				return err
+			}
+			storage.EnableSimulation()
+		}
+
 		if isStat {
 			stat.InitStat()
 		}
@@ -146,28 +361,113 @@ var app = &cli.App{
 			log.Stat(stat.Statistics())
 		}
 
+		if summary := log.SampleSummary(); summary != nil {
+			log.Stat(summary)
+		}
+
+		if totals, ok := storage.SimulationResult(); ok {
+			// the throughput is validated before the command runs.
+			throughput, _ := parseByteSize(c.String("simulate-throughput"))
+			log.Info(newSimulationMessage(totals, c.Int("numworkers"), c.Duration("simulate-latency"), throughput))
+		}
+
+		if n := storage.PartSizeDowngrades(); n > 0 {
+			log.Warning(log.WarningMessage{
+				Warning: fmt.Sprintf("part sizes of transfers were halved %d times after server or connection errors", n),
+			})
+		}
+
+		if n := storage.KMSThrottles(); n > 0 {
+			log.Warning(log.WarningMessage{
+				Warning: fmt.Sprintf("%d requests were throttled by KMS, enable S3 Bucket Keys with --bucket-key-enabled or limit the requests with --kms-rate to stay in the KMS request quota", n),
+			})
+		}
+
 		parallel.Close()
 		log.Close()
 		return nil
 	},
 }
 
+// parseSampleRate parses the fraction of the results to be logged, given as
+// a percentage, e.g. 1%, or as a fraction, e.g. 0.01. All of the results are
+// logged if it is empty.
+func parseSampleRate(value string) (float64, error) {
+	if value == "" {
+		return 1, nil
+	}
+
+	number, divisor := value, 1.0
+	if strings.HasSuffix(value, "%") {
+		number, divisor = strings.TrimSuffix(value, "%"), 100
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || rate/divisor <= 0 || rate/divisor > 1 {
+		return 0, fmt.Errorf("log sample %q must be a percentage or a fraction between 0 and 1", value)
+	}
+	return rate / divisor, nil
+}
+
+// loadURLRewriteRules reads the URL rewrite rules from the given file and
+// applies them to the URLs of the commands.
+func loadURLRewriteRules(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rules, err := url.ParseRewriteRules(f)
+	if err != nil {
+		return err
+	}
+
+	url.SetRewriteRules(rules)
+	return nil
+}
+
 // NewStorageOpts creates storage.Options object from the given context.
 func NewStorageOpts(c *cli.Context) storage.Options {
+	// the key is validated before the command runs.
+	sseCustomerKey, _ := readSSECustomerKey(c)
+
 	return storage.Options{
 		DryRun:           c.Bool("dry-run"),
 		Endpoint:         c.String("endpoint-url"),
 		MaxRetries:       c.Int("retry-count"),
+		RetryMaxDelay:    c.Duration("retry-max-delay"),
+		RetryPolicy:      c.String("retry-policy"),
+		KeyShardLength:   c.Int("key-shard-length"),
 		NoSignRequest:    c.Bool("no-sign-request"),
 		NoVerifySSL:      c.Bool("no-verify-ssl"),
 		RequestPayer:     c.String("request-payer"),
 		UseListObjectsV1: c.Bool("use-list-objects-v1"),
+		UseAccelerate:    c.Bool("use-accelerate-endpoint"),
+		SSECustomerKey:   sseCustomerKey,
+
+		StreamingSignature: c.Bool("streaming-signature"),
+		IOUring:            c.Bool("io-uring"),
+		Preconnect:         c.Int("preconnect"),
+
+		ChecksumAlgorithm: storage.ChecksumAlgorithm(strings.ToUpper(c.String("checksum-algorithm"))),
 	}
 }
 
+// newSourceLister returns the external lister of the source listings of the
+// commands, if it is given. The other listings, e.g. the ones of the
+// destinations of sync, list the buckets.
+func newSourceLister(c *cli.Context) storage.Lister {
+	if command := c.String("lister-cmd"); command != "" {
+		return storage.NewCommandLister(command)
+	}
+	return nil
+}
+
 func Commands() []*cli.Command {
 	return []*cli.Command{
 		NewListCommand(),
+		NewFindCommand(),
 		NewCopyCommand(),
 		NewDeleteCommand(),
 		NewMoveCommand(),
@@ -176,8 +476,28 @@ func Commands() []*cli.Command {
 		NewSelectCommand(),
 		NewSizeCommand(),
 		NewCatCommand(),
+		NewTarCommand(),
+		NewUntarCommand(),
+		NewPresignCommand(),
+		NewServeCommand(),
+		NewMountCommand(),
+		NewHeadCommand(),
+		NewChecksumCommand(),
+		NewTagCommand(),
+		NewACLCommand(),
+		NewMultipartUploadCommand(),
 		NewRunCommand(),
 		NewSyncCommand(),
+		NewWatchCommand(),
+		NewPlanCommand(),
+		NewBucketCommand(),
+		NewLifecycleCommand(),
+		NewPolicyCommand(),
+		NewRestoreCommand(),
+		NewRestoreStatusCommand(),
+		NewUndeleteCommand(),
+		NewDiffCommand(),
+		NewSetAttributesCommand(),
 		NewVersionCommand(),
 	}
 }
@@ -192,7 +512,10 @@ func AppCommand(name string) *cli.Command {
 	return nil
 }
 
-// Main is the entrypoint function to run given commands.
+// Main is the entrypoint function to run given commands. Canceling the given
+// context stops the command: no new transfers are started, the multipart
+// uploads in progress are aborted unless they are resumable, and cp and mv
+// commands report the number of completed transfers.
 func Main(ctx context.Context, args []string) error {
 	app.Commands = Commands()
 
@@ -0,0 +1,337 @@
+package command
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// archiveFormatTar is the only archive format supported on uploads.
+const archiveFormatTar = "tar"
+
+// archiveIndexSuffix is the suffix of the keys of the index objects written
+// next to the archives.
+const archiveIndexSuffix = ".index"
+
+// archiveMemberSeparator separates the key of an archive from the path of a
+// member inside it, e.g. s3://bucket/archive.tar::path/inside.txt.
+const archiveMemberSeparator = "::"
+
+// archiveIndex lists the members of an archive and their locations, so that
+// a single member can be fetched with a ranged GET request.
+type archiveIndex struct {
+	Format  string          `json:"format"`
+	Members []archiveMember `json:"members"`
+}
+
+// archiveMember is a file inside an archive. Offset is the position of the
+// content of the file in the archive, not of its header.
+type archiveMember struct {
+	Name   string `json:"name"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+
+	source *url.URL
+}
+
+// find returns the member with the given name.
+func (i *archiveIndex) find(name string) (archiveMember, bool) {
+	for _, member := range i.Members {
+		if member.Name == name {
+			return member, true
+		}
+	}
+	return archiveMember{}, false
+}
+
+// archiveEntry is a local file to be added to an archive with the given name.
+type archiveEntry struct {
+	name   string
+	source *url.URL
+}
+
+// archiveIndexURL returns the URL of the index object of the given archive.
+func archiveIndexURL(archiveurl *url.URL) *url.URL {
+	indexurl := archiveurl.Clone()
+	indexurl.Path += archiveIndexSuffix
+	return indexurl
+}
+
+// splitArchiveMember splits the URL of an archive member into the URL of the
+// archive and the name of the member.
+func splitArchiveMember(srcurl *url.URL) (*url.URL, string, bool) {
+	i := strings.Index(srcurl.Path, archiveMemberSeparator)
+	if !srcurl.IsRemote() || i == -1 {
+		return nil, "", false
+	}
+
+	archiveurl := srcurl.Clone()
+	archiveurl.Path = srcurl.Path[:i]
+	return archiveurl, srcurl.Path[i+len(archiveMemberSeparator):], true
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// writeTarArchive writes the given entries to w as a tar archive and returns
+// the index of the archive. The entries channel is drained on errors, so the
+// sender never blocks.
+func writeTarArchive(w io.Writer, entries <-chan archiveEntry) (*archiveIndex, error) {
+	defer func() {
+		for range entries {
+		}
+	}()
+
+	cw := &countingWriter{w: w}
+	tw := tar.NewWriter(cw)
+
+	index := &archiveIndex{Format: archiveFormatTar}
+	for entry := range entries {
+		member, err := writeTarMember(tw, cw, entry)
+		if err != nil {
+			return nil, err
+		}
+		index.Members = append(index.Members, member)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func writeTarMember(tw *tar.Writer, cw *countingWriter, entry archiveEntry) (archiveMember, error) {
+	f, err := os.Open(entry.source.Absolute())
+	if err != nil {
+		return archiveMember{}, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return archiveMember{}, err
+	}
+
+	header, err := tar.FileInfoHeader(st, "")
+	if err != nil {
+		return archiveMember{}, err
+	}
+	header.Name = entry.name
+
+	// the header is written as a whole, the content of the file starts
+	// right after it.
+	if err := tw.WriteHeader(header); err != nil {
+		return archiveMember{}, err
+	}
+	offset := cw.n
+
+	if _, err := io.Copy(tw, f); err != nil {
+		return archiveMember{}, err
+	}
+
+	return archiveMember{
+		Name:   entry.name,
+		Offset: offset,
+		Size:   header.Size,
+		source: entry.source,
+	}, nil
+}
+
+// archiveMemberName returns the name of the given file inside the archive,
+// following the naming of the objects of the regular uploads.
+func (c Copy) archiveMemberName(srcurl *url.URL, isBatch bool) string {
+	name := srcurl.Base()
+	if isBatch && !c.flatten {
+		name = srcurl.Relative()
+	}
+	return filepath.ToSlash(name)
+}
+
+// archiveMetadata returns the metadata of the archive and index objects.
+func (c Copy) archiveMetadata(contentType string) storage.Metadata {
+	metadata := storage.NewMetadata().
+		SetContentType(contentType).
+		SetStorageClass(string(c.storageClass)).
+		SetSSE(c.encryptionMethod).
+		SetSSEKeyID(c.encryptionKeyID).
+		SetBucketKeyEnabled(c.bucketKeyEnabled).
+		SetACL(c.acl).
+		SetCacheControl(c.cacheControl).
+		SetContentDisposition(c.contentDisposition).
+		SetContentLanguage(c.contentLanguage).
+		SetExpires(c.expires)
+
+	if len(c.tags) > 0 {
+		metadata.SetTagging(storage.EncodeTags(c.tags))
+	}
+	return metadata
+}
+
+// uploadArchive uploads the files sent to the entries channel as a single
+// tar archive to the destination, and writes the index of the archive next
+// to it.
+func (c Copy) uploadArchive(ctx context.Context, dsturl *url.URL, entries <-chan archiveEntry) error {
+	if c.dstRegion != "" {
+		c.storageOpts.SetRegion(c.dstRegion)
+	}
+
+	dstClient, err := storage.NewRemoteClient(ctx, dsturl, c.storageOpts)
+	if err != nil {
+		for range entries {
+		}
+		return err
+	}
+
+	var index *archiveIndex
+	if c.storageOpts.DryRun {
+		index, err = writeTarArchive(ioutil.Discard, entries)
+		if err != nil {
+			return err
+		}
+	} else {
+		pr, pw := io.Pipe()
+		indexch := make(chan *archiveIndex, 1)
+		go func() {
+			index, err := writeTarArchive(pw, entries)
+			pw.CloseWithError(err)
+			indexch <- index
+		}()
+
+		err = dstClient.Put(ctx, pr, dsturl, c.archiveMetadata("application/x-tar"), c.concurrency, c.partSize)
+		// unblock the writer if the upload is stopped before the whole
+		// archive is read.
+		pr.CloseWithError(io.ErrClosedPipe)
+		index = <-indexch
+		if err != nil {
+			return err
+		}
+		if index == nil {
+			return fmt.Errorf("archive %v is not written completely", dsturl)
+		}
+
+		data, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+
+		indexurl := archiveIndexURL(dsturl)
+		err = dstClient.Put(ctx, bytes.NewReader(data), indexurl, c.archiveMetadata("application/json"), c.concurrency, c.partSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, member := range index.Members {
+		memberurl, err := url.New(dsturl.String() + archiveMemberSeparator + member.Name)
+		if err != nil {
+			return err
+		}
+
+		log.Info(log.InfoMessage{
+			Operation:   c.op,
+			Source:      member.source,
+			Destination: memberurl,
+			Object: &storage.Object{
+				Size: member.Size,
+			},
+		})
+	}
+	return nil
+}
+
+// readArchiveIndex fetches the index of the given archive.
+func readArchiveIndex(ctx context.Context, client *storage.S3, archiveurl *url.URL) (*archiveIndex, error) {
+	indexurl := archiveIndexURL(archiveurl)
+
+	rc, err := client.Read(ctx, indexurl)
+	if err != nil {
+		return nil, fmt.Errorf("could not read index of archive %v: %v", archiveurl, err)
+	}
+	defer rc.Close()
+
+	var index archiveIndex
+	if err := json.NewDecoder(rc).Decode(&index); err != nil {
+		return nil, fmt.Errorf("invalid index of archive %v: %v", archiveurl, err)
+	}
+	return &index, nil
+}
+
+// downloadArchiveMember extracts a single member of an archive to the local
+// destination by fetching only its content with a ranged GET request.
+func (c Copy) downloadArchiveMember(ctx context.Context, srcurl, dsturl *url.URL) error {
+	archiveurl, name, _ := splitArchiveMember(srcurl)
+
+	if c.srcRegion != "" {
+		c.storageOpts.SetRegion(c.srcRegion)
+	}
+
+	srcClient, err := storage.NewRemoteClient(ctx, archiveurl, c.storageOpts)
+	if err != nil {
+		return err
+	}
+
+	index, err := readArchiveIndex(ctx, srcClient, archiveurl)
+	if err != nil {
+		return err
+	}
+
+	member, ok := index.find(name)
+	if !ok {
+		return fmt.Errorf("%q is not found in archive %v", name, archiveurl)
+	}
+
+	dsturl, err = prepareLocalDestination(ctx, srcurl, dsturl, c.flatten, false, "", c.storageOpts)
+	if err != nil {
+		return err
+	}
+
+	if !c.storageOpts.DryRun {
+		rc, err := srcClient.ReadRange(ctx, archiveurl, member.Offset, member.Size)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		dstClient := storage.NewLocalClient(c.storageOpts)
+		file, err := dstClient.Create(dsturl.Absolute())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if _, err := io.Copy(file, rc); err != nil {
+			_ = dstClient.Delete(ctx, dsturl)
+			return err
+		}
+	}
+
+	log.Info(log.InfoMessage{
+		Operation:   c.op,
+		Source:      srcurl,
+		Destination: dsturl,
+		Object: &storage.Object{
+			Size: member.Size,
+		},
+	})
+	return nil
+}
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestWriteTarArchive(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"a.txt": "first",
+		"empty": "",
+		// names longer than 100 characters need extra header blocks.
+		strings.Repeat("d", 120) + ".txt": strings.Repeat("x", 1000),
+	}
+
+	var ops []fs.PathOp
+	for name, content := range files {
+		ops = append(ops, fs.WithFile(name, content))
+	}
+	dir := fs.NewDir(t, "archive", ops...)
+	defer dir.Remove()
+
+	entries := make(chan archiveEntry)
+	go func() {
+		defer close(entries)
+		for name := range files {
+			source, err := url.New(dir.Join(name))
+			assert.NoError(t, err)
+			entries <- archiveEntry{name: "prefix/" + name, source: source}
+		}
+	}()
+
+	var buf bytes.Buffer
+	index, err := writeTarArchive(&buf, entries)
+	assert.NoError(t, err)
+	assert.Equal(t, archiveFormatTar, index.Format)
+	assert.Len(t, index.Members, len(files))
+
+	archive := buf.Bytes()
+	for name, content := range files {
+		member, ok := index.find("prefix/" + name)
+		assert.True(t, ok)
+		assert.Equal(t, int64(len(content)), member.Size)
+		assert.Equal(t, content, string(archive[member.Offset:member.Offset+member.Size]))
+	}
+
+	// the archive is still readable by the standard tools.
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		content, err := ioutil.ReadAll(tr)
+		assert.NoError(t, err)
+		assert.Equal(t, files[strings.TrimPrefix(header.Name, "prefix/")], string(content))
+	}
+}
+
+func TestWriteTarArchiveDrainsEntriesOnError(t *testing.T) {
+	t.Parallel()
+
+	missing, err := url.New("/path/to/missing/file")
+	assert.NoError(t, err)
+
+	entries := make(chan archiveEntry)
+	go func() {
+		defer close(entries)
+		for i := 0; i < 3; i++ {
+			entries <- archiveEntry{name: "file", source: missing}
+		}
+	}()
+
+	_, err = writeTarArchive(ioutil.Discard, entries)
+	assert.Error(t, err)
+}
+
+func TestSplitArchiveMember(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		src         string
+		wantArchive string
+		wantMember  string
+		wantOK      bool
+	}{
+		{
+			src:         "s3://bucket/archive.tar::path/inside.txt",
+			wantArchive: "s3://bucket/archive.tar",
+			wantMember:  "path/inside.txt",
+			wantOK:      true,
+		},
+		{
+			src: "s3://bucket/archive.tar",
+		},
+		{
+			src: "dir/archive.tar::path/inside.txt",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.src, func(t *testing.T) {
+			t.Parallel()
+
+			srcurl, err := url.New(tc.src)
+			assert.NoError(t, err)
+
+			archiveurl, member, ok := splitArchiveMember(srcurl)
+			assert.Equal(t, tc.wantOK, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tc.wantArchive, archiveurl.String())
+			assert.Equal(t, tc.wantMember, member)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+// parseBandwidthSchedule parses the comma separated bandwidth caps of the
+// times of the day, e.g. "09:00-18:00=50MB,18:00-09:00=unlimited". A cap
+// without a time range applies to the rest of the day, e.g.
+// "09:00-18:00=50MB,200MB". The caps are bytes per second.
+func parseBandwidthSchedule(value string) (storage.BandwidthSchedule, error) {
+	var schedule storage.BandwidthSchedule
+	for _, rule := range strings.Split(value, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		var window storage.BandwidthWindow
+		rate := rule
+		if i := strings.Index(rule, "="); i >= 0 {
+			start, end, err := parseTimeRange(rule[:i])
+			if err != nil {
+				return nil, err
+			}
+			window.Start, window.End = start, end
+			rate = rule[i+1:]
+		}
+
+		if !strings.EqualFold(strings.TrimSpace(rate), "unlimited") {
+			n, err := parseByteSize(rate)
+			if err != nil {
+				return nil, fmt.Errorf("bandwidth of %q: %v", rule, err)
+			}
+			if n <= 0 {
+				return nil, fmt.Errorf("bandwidth of %q must be positive, or unlimited", rule)
+			}
+			window.Rate = n
+		}
+		schedule = append(schedule, window)
+	}
+
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("bandwidth schedule is empty")
+	}
+	return schedule, nil
+}
+
+// parseTimeRange parses a range of the times of the day, e.g. 09:00-18:00.
+func parseTimeRange(s string) (time.Duration, time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid time range %q, e.g. 09:00-18:00", s)
+	}
+
+	start, err := parseTimeOfDay(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTimeOfDay(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// the whole day is 00:00-24:00.
+	if end == 24*time.Hour {
+		end = 0
+	}
+	if start == end && start != 0 {
+		return 0, 0, fmt.Errorf("time range %q is empty", s)
+	}
+	return start, end, nil
+}
+
+// parseTimeOfDay parses a time of the day in HH:MM format, 24:00 is the end
+// of the day.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || len(parts[1]) != 2 {
+		return 0, invalid
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, invalid
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, invalid
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
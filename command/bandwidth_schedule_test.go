@@ -0,0 +1,58 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestParseBandwidthSchedule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected storage.BandwidthSchedule
+	}{
+		{
+			value: "09:00-18:00=50MB,18:00-09:00=unlimited",
+			expected: storage.BandwidthSchedule{
+				{Start: 9 * time.Hour, End: 18 * time.Hour, Rate: 50 << 20},
+				{Start: 18 * time.Hour, End: 9 * time.Hour},
+			},
+		},
+		{
+			value: "08:30-24:00=1MB, 200KB",
+			expected: storage.BandwidthSchedule{
+				{Start: 8*time.Hour + 30*time.Minute, Rate: 1 << 20},
+				{Rate: 200 << 10},
+			},
+		},
+		{
+			value: "00:00-00:00=10MB",
+			expected: storage.BandwidthSchedule{
+				{Rate: 10 << 20},
+			},
+		},
+	}
+	for _, tc := range tests {
+		schedule, err := parseBandwidthSchedule(tc.value)
+		assert.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, schedule, tc.value)
+	}
+
+	for _, value := range []string{
+		"",
+		"09:00=50MB",
+		"9-18=50MB",
+		"09:00-25:00=50MB",
+		"09:00-09:00=50MB",
+		"09:00-18:00=0",
+		"09:00-18:00=50XB",
+	} {
+		_, err := parseBandwidthSchedule(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,142 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var bucketInfoHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} s3://bucketname
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print region, versioning, encryption, lifecycle, public access block, object lock and acceleration status of a bucket
+		 > s5cmd {{.HelpName}} s3://bucketname
+
+	2. Audit all buckets of an account
+		 > s5cmd ls | awk '{print $3}' | xargs -n1 s5cmd {{.HelpName}}
+`
+
+func NewBucketCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "bucket",
+		HelpName: "bucket",
+		Usage:    "show bucket configuration",
+		Subcommands: []*cli.Command{
+			newBucketInfoCommand(),
+		},
+	}
+}
+
+func newBucketInfoCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "info",
+		HelpName:           "bucket info",
+		Usage:              "print the configuration summary of a bucket as JSON",
+		CustomHelpTemplate: bucketInfoHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateBucketCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return BucketInfo{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// BucketInfo holds bucket info operation flags and states.
+type BucketInfo struct {
+	src         string
+	op          string
+	fullCommand string
+
+	storageOpts storage.Options
+}
+
+// Run prints the configuration summary of a bucket.
+func (b BucketInfo) Run(ctx context.Context) error {
+	bucket, err := url.New(b.src)
+	if err != nil {
+		printError(b.fullCommand, b.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, bucket, b.storageOpts)
+	if err != nil {
+		printError(b.fullCommand, b.op, err)
+		return err
+	}
+
+	info, err := client.BucketInfo(ctx, bucket.Bucket)
+	if err != nil {
+		printError(b.fullCommand, b.op, err)
+		return err
+	}
+
+	log.Info(info)
+
+	return nil
+}
+
+// runBucketOperation runs the given operation on the bucket given as the
+// argument of the command.
+func runBucketOperation(c *cli.Context, fn func(context.Context, *storage.S3, *url.URL) error) error {
+	fullCommand := commandFromContext(c)
+
+	bucket, err := url.New(c.Args().First())
+	if err != nil {
+		printError(fullCommand, c.Command.Name, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(c.Context, bucket, NewStorageOpts(c))
+	if err != nil {
+		printError(fullCommand, c.Command.Name, err)
+		return err
+	}
+
+	if err := fn(c.Context, client, bucket); err != nil {
+		printError(fullCommand, c.Command.Name, err)
+		return err
+	}
+	return nil
+}
+
+func validateBucketCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	bucket, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+	if !bucket.IsBucket() {
+		return fmt.Errorf("invalid s3 bucket")
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteRangePrefix is the unit of the byte ranges, as in HTTP Range headers.
+const byteRangePrefix = "bytes="
+
+// parseByteRange validates the given byte range and returns it in HTTP Range
+// header format. Ranges can be given with or without the unit, e.g.
+// "bytes=0-1023", "1024-" for the bytes starting from an offset or "-1024"
+// for the last 1024 bytes.
+func parseByteRange(value string) (string, error) {
+	spec := strings.TrimPrefix(strings.TrimSpace(value), byteRangePrefix)
+
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 || (parts[0] == "" && parts[1] == "") {
+		return "", fmt.Errorf("range %q must be in bytes=<start>-<end> format", value)
+	}
+
+	parse := func(s string) (int64, error) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("range %q must consist of non-negative integers", value)
+		}
+		return n, nil
+	}
+
+	var start, end int64 = 0, -1
+	var err error
+	if parts[0] != "" {
+		if start, err = parse(parts[0]); err != nil {
+			return "", err
+		}
+	}
+	if parts[1] != "" {
+		if end, err = parse(parts[1]); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case parts[0] == "" && end == 0:
+		return "", fmt.Errorf("range %q is empty", value)
+	case parts[0] != "" && end >= 0 && end < start:
+		return "", fmt.Errorf("end of range %q is before its start", value)
+	}
+	return byteRangePrefix + spec, nil
+}
+
+// byteRangeLength returns the number of the bytes of an object of the given
+// size in the given valid byte range.
+func byteRangeLength(byteRange string, size int64) int64 {
+	spec := strings.TrimPrefix(byteRange, byteRangePrefix)
+	parts := strings.SplitN(spec, "-", 2)
+
+	// the last bytes of the object.
+	if parts[0] == "" {
+		n, _ := strconv.ParseInt(parts[1], 10, 64)
+		if n > size {
+			return size
+		}
+		return n
+	}
+
+	start, _ := strconv.ParseInt(parts[0], 10, 64)
+	end := size - 1
+	if parts[1] != "" {
+		if n, _ := strconv.ParseInt(parts[1], 10, 64); n < end {
+			end = n
+		}
+	}
+	if end < start {
+		return 0
+	}
+	return end - start + 1
+}
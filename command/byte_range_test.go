@@ -0,0 +1,45 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteRange(t *testing.T) {
+	t.Parallel()
+
+	for value, expected := range map[string]string{
+		"bytes=0-1048575": "bytes=0-1048575",
+		"0-1023":          "bytes=0-1023",
+		"1024-":           "bytes=1024-",
+		"bytes=-8":        "bytes=-8",
+		"5-5":             "bytes=5-5",
+	} {
+		got, err := parseByteRange(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, got, value)
+	}
+
+	for _, value := range []string{"", "-", "bytes=", "10", "a-b", "10-5", "bytes=-0", "0-1,5-6", "bytes=-1-2"} {
+		_, err := parseByteRange(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestByteRangeLength(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	for byteRange, expected := range map[string]int64{
+		"bytes=0-9":    10,
+		"bytes=90-199": 10,
+		"bytes=10-":    90,
+		"bytes=-8":     8,
+		"bytes=-200":   100,
+		"bytes=100-":   0,
+		"bytes=5-5":    1,
+	} {
+		assert.Equal(t, expected, byteRangeLength(byteRange, size), byteRange)
+	}
+}
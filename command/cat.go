@@ -25,6 +25,15 @@ Options:
 Examples:
 	1. Print a remote object's content to stdout
 		 > s5cmd {{.HelpName}} s3://bucket/prefix/object
+
+	2. Print content of an object encrypted with a customer provided key
+		 > s5cmd {{.HelpName}} --sse-c --sse-c-key-file key.bin s3://bucket/prefix/object
+
+	3. Print the first 1KB of a remote object
+		 > s5cmd {{.HelpName}} --range bytes=0-1023 s3://bucket/prefix/object
+
+	4. Stream a large object to another program, fetching 10 parts of 100MiB concurrently
+		 > s5cmd {{.HelpName}} --concurrency 10 --part-size 100 s3://bucket/prefix/object | restore-tool
 `
 
 func NewCatCommand() *cli.Command {
@@ -33,6 +42,32 @@ func NewCatCommand() *cli.Command {
 		HelpName:           "cat",
 		Usage:              "print remote object content",
 		CustomHelpTemplate: catHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "sse-c",
+				Usage: "use server side encryption with customer provided key (SSE-C); the key is read from --sse-c-key-file or S5CMD_SSE_C_KEY environment variable",
+			},
+			&cli.StringFlag{
+				Name:  "sse-c-key-file",
+				Usage: "read the 256-bit customer provided key of SSE-C from given file, either raw or base64 encoded",
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"c"},
+				Value:   defaultCopyConcurrency,
+				Usage:   "number of parts of the object fetched concurrently, the output order is preserved; the object is streamed with a single request unless this flag or --part-size is given",
+			},
+			&cli.IntFlag{
+				Name:    "part-size",
+				Aliases: []string{"p"},
+				Value:   defaultPartSize,
+				Usage:   "size of each part fetched concurrently, in MiB; the object is streamed with a single request unless this flag or --concurrency is given",
+			},
+			&cli.StringFlag{
+				Name:  "range",
+				Usage: "print only the given byte range of the object, e.g. bytes=0-1023, bytes=1024- or bytes=-1024 for the last 1024 bytes",
+			},
+		},
 		Before: func(c *cli.Context) error {
 			err := validateCatCommand(c)
 			if err != nil {
@@ -51,10 +86,28 @@ func NewCatCommand() *cli.Command {
 				return err
 			}
 
+			// the range is validated before the command runs.
+			byteRange, _ := parseByteRange(c.String("range"))
+
+			// the parts are fetched ahead only if asked, since they are
+			// buffered in memory and delay the output until the first part
+			// is fetched.
+			concurrency := 1
+			partSize := c.Int64("part-size") * megabytes
+			if c.IsSet("concurrency") || c.IsSet("part-size") {
+				concurrency = c.Int("concurrency")
+				if !c.IsSet("part-size") && transferConfig.MultipartChunkSize > 0 {
+					partSize = transferConfig.MultipartChunkSize
+				}
+			}
+
 			return Cat{
 				src:         src,
 				op:          op,
 				fullCommand: fullCommand,
+				byteRange:   byteRange,
+				concurrency: concurrency,
+				partSize:    partSize,
 
 				storageOpts: NewStorageOpts(c),
 			}.Run(c.Context)
@@ -67,11 +120,16 @@ type Cat struct {
 	src         *url.URL
 	op          string
 	fullCommand string
+	byteRange   string
 
+	// s3 options
+	concurrency int
+	partSize    int64
 	storageOpts storage.Options
 }
 
-// Run prints content of given source to standard output.
+// Run prints content of given source to standard output. The object is
+// streamed with a single request, unless the concurrency is greater than 1.
 func (c Cat) Run(ctx context.Context) error {
 	client, err := storage.NewRemoteClient(ctx, c.src, c.storageOpts)
 	if err != nil {
@@ -79,7 +137,12 @@ func (c Cat) Run(ctx context.Context) error {
 		return err
 	}
 
-	rc, err := client.Read(ctx, c.src)
+	var rc io.ReadCloser
+	if c.byteRange != "" {
+		rc, err = client.ReadByteRange(ctx, c.src, c.byteRange)
+	} else {
+		rc, err = client.ReadParallel(ctx, c.src, c.concurrency, c.partSize)
+	}
 	if err != nil {
 		printError(c.fullCommand, c.op, err)
 		return err
@@ -117,5 +180,15 @@ func validateCatCommand(c *cli.Context) error {
 	if src.IsWildcard() {
 		return fmt.Errorf("remote source %q can not contain glob characters", src)
 	}
+
+	if _, err := readSSECustomerKey(c); err != nil {
+		return err
+	}
+
+	if c.String("range") != "" {
+		if _, err := parseByteRange(c.String("range")); err != nil {
+			return err
+		}
+	}
 	return nil
 }
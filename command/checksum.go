@@ -0,0 +1,164 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var checksumHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument [argument]
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the ETag and the checksum of an object
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/object.gz
+
+	2. Print the ETags and the checksums of all objects with a prefix as JSON
+		 > s5cmd --json {{.HelpName}} "s3://bucket/prefix/*"
+
+	3. Verify a local copy of an object without downloading the object
+		 > s5cmd {{.HelpName}} --compare object.gz s3://bucket/prefix/object.gz
+`
+
+func NewChecksumCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "checksum",
+		HelpName:           "checksum",
+		Usage:              "print checksums of objects or compare them with local files",
+		CustomHelpTemplate: checksumHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "compare",
+				Usage: "compare the object with given local file instead of printing its checksum, fails on mismatch",
+			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateChecksumCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			compare := c.String("compare")
+			return newObjectBatch(c).run(c.Context, func(ctx context.Context, client *storage.S3, u *url.URL) error {
+				if compare != "" {
+					return compareChecksum(ctx, client, u, compare)
+				}
+
+				checksum, err := client.Checksum(ctx, u)
+				if err != nil {
+					return err
+				}
+				log.Info(ChecksumMessage{Source: u, Checksum: checksum})
+				return nil
+			})
+		},
+	}
+}
+
+// compareChecksum compares the object with the local file, using the ETag or
+// the checksum of the object.
+func compareChecksum(ctx context.Context, client *storage.S3, u *url.URL, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := client.VerifyObject(ctx, u, f, info.Size()); err != nil {
+		return err
+	}
+	log.Info(ChecksumCompareMessage{Source: u, Local: path, Match: true})
+	return nil
+}
+
+// ChecksumMessage is a structure for logging the checksum of an object.
+type ChecksumMessage struct {
+	Source *url.URL `json:"source"`
+	*storage.Checksum
+}
+
+// String returns the string representation of ChecksumMessage, the checksum
+// is printed with its algorithm, or as "-" if the object has none.
+func (m ChecksumMessage) String() string {
+	checksum := "-"
+	if m.Algorithm != "" {
+		checksum = fmt.Sprintf("%v:%s", m.Algorithm, m.Value)
+	}
+	return fmt.Sprintf("%s %s %v", m.ETag, checksum, m.Source)
+}
+
+// JSON returns the JSON representation of ChecksumMessage.
+func (m ChecksumMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+// ChecksumCompareMessage is a structure for logging the comparison of an
+// object with a local file.
+type ChecksumCompareMessage struct {
+	Source *url.URL `json:"source"`
+	Local  string   `json:"local"`
+	Match  bool     `json:"match"`
+}
+
+// String returns the string representation of ChecksumCompareMessage.
+func (m ChecksumCompareMessage) String() string {
+	return fmt.Sprintf("%v matches %s", m.Source, m.Local)
+}
+
+// JSON returns the JSON representation of ChecksumCompareMessage.
+func (m ChecksumCompareMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validateChecksumCommand(c *cli.Context) error {
+	if err := validateObjectBatch(c, "checksum"); err != nil {
+		return err
+	}
+
+	if !c.IsSet("compare") {
+		return nil
+	}
+
+	if c.String("compare") == "" {
+		return fmt.Errorf("expected a local file with --compare")
+	}
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("compare expects only 1 object")
+	}
+
+	srcurl, err := url.New(c.Args().First(), url.WithRaw(c.Bool("raw")))
+	if err != nil {
+		return err
+	}
+	if srcurl.IsWildcard() {
+		return fmt.Errorf("compare does not support wildcards")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// compressionGzip is the only compression supported on uploads.
+const compressionGzip = "gzip"
+
+// gzipSuffix is the suffix of the keys of gzip compressed objects.
+const gzipSuffix = ".gz"
+
+// gzipReader returns a reader which compresses the content of the given reader
+// on the fly. The returned reader must be closed to release the compressing
+// goroutine if it is not read until EOF.
+func gzipReader(r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		gw := gzip.NewWriter(pw)
+		_, err := io.Copy(gw, r)
+		if err == nil {
+			err = gw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// isGzipObject checks if the object is compressed with gzip, either by its
+// key or by its content encoding. The object is only fetched if its key
+// doesn't have the gzip suffix.
+func isGzipObject(ctx context.Context, client storage.Storage, srcurl *url.URL) (bool, error) {
+	if strings.HasSuffix(srcurl.Path, gzipSuffix) {
+		return true, nil
+	}
+
+	obj, err := client.Stat(ctx, srcurl)
+	if err != nil {
+		return false, err
+	}
+	return obj.ContentEncoding == compressionGzip, nil
+}
+
+// gunzip decompresses the content of the given reader to the writer and
+// returns the number of decompressed bytes.
+func gunzip(w io.Writer, r io.Reader) (int64, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gr.Close()
+
+	return io.Copy(w, gr)
+}
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzipReader(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("this is a log line\n", 1000)
+
+	reader := gzipReader(strings.NewReader(content))
+	defer reader.Close()
+
+	gr, err := gzip.NewReader(reader)
+	assert.NoError(t, err)
+
+	decompressed, err := ioutil.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(decompressed))
+}
+
+func TestGunzip(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("this is a log line\n", 1000)
+
+	var buf bytes.Buffer
+	size, err := gunzip(&buf, gzipReader(strings.NewReader(content)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.Equal(t, content, buf.String())
+
+	_, err = gunzip(&buf, strings.NewReader(content))
+	assert.Error(t, err)
+}
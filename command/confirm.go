@@ -0,0 +1,74 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// confirmInput is where the answers to the confirmation prompts are read
+	// from. It is shared by all commands, so that the buffered answers are
+	// not lost between them.
+	confirmInput = bufio.NewReader(os.Stdin)
+
+	// confirmOutput is where the confirmation prompts are written to.
+	confirmOutput io.Writer = os.Stderr
+
+	// confirmMu serializes the prompts of concurrent operations and commands.
+	confirmMu sync.Mutex
+)
+
+// confirmer asks for a confirmation before each destructive action of a
+// command. A nil confirmer confirms all actions without asking.
+type confirmer struct {
+	// all is set if all the remaining actions are confirmed.
+	all bool
+}
+
+// newConfirmer returns a confirmer if the command is asked to be
+// interactive. Nothing is asked on dry-runs or if --force flag is given.
+func newConfirmer(c *cli.Context) *confirmer {
+	if !c.Bool("interactive") || c.Bool("force") || c.Bool("dry-run") {
+		return nil
+	}
+	return &confirmer{}
+}
+
+// confirm asks whether the action should be done, e.g. "rm s3://bucket/key".
+// Anything other than "y", "yes", "a" or "all" declines the action, as well as
+// the end of the input.
+func (c *confirmer) confirm(action string) bool {
+	if c == nil {
+		return true
+	}
+
+	confirmMu.Lock()
+	defer confirmMu.Unlock()
+
+	if c.all {
+		return true
+	}
+
+	fmt.Fprintf(confirmOutput, "%v? [y/N/all] ", action)
+	answer, err := confirmInput.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(confirmOutput)
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "a", "all":
+		c.all = true
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmerConfirm(t *testing.T) {
+	var output bytes.Buffer
+
+	defer func(input *bufio.Reader) { confirmInput = input }(confirmInput)
+	defer func(output io.Writer) { confirmOutput = output }(confirmOutput)
+	confirmOutput = &output
+
+	confirmInput = bufio.NewReader(strings.NewReader("y\nN\n\nyes\nall\n"))
+
+	c := &confirmer{}
+	assert.True(t, c.confirm("rm s3://bucket/a"))
+	assert.False(t, c.confirm("rm s3://bucket/b"))
+	assert.False(t, c.confirm("rm s3://bucket/c"))
+	assert.True(t, c.confirm("rm s3://bucket/d"))
+	assert.True(t, c.confirm("rm s3://bucket/e"))
+
+	// the rest is confirmed without prompting.
+	assert.True(t, c.confirm("rm s3://bucket/f"))
+	assert.Equal(t, 5, strings.Count(output.String(), "? [y/N/all] "))
+	assert.True(t, strings.HasPrefix(output.String(), "rm s3://bucket/a? [y/N/all] "))
+
+	// the end of the input declines the actions.
+	confirmInput = bufio.NewReader(strings.NewReader("y"))
+	c = &confirmer{}
+	assert.True(t, c.confirm("rm s3://bucket/a"))
+	assert.False(t, c.confirm("rm s3://bucket/b"))
+
+	// nil confirmer confirms all actions.
+	var noConfirmer *confirmer
+	assert.True(t, noConfirmer.confirm("rm s3://bucket/a"))
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -97,6 +98,89 @@ Examples:
 
 	20. Download an S3 object from a requester pays bucket
 		 > s5cmd --request-payer=requester {{.HelpName}} s3://bucket/prefix/object.gz .
+
+	21. Upload a source tree to S3 bucket but skip the files ignored by git
+		 > s5cmd {{.HelpName}} --respect-gitignore dir/ s3://bucket/prefix/
+
+	22. Upload a large file to S3 bucket and resume from the last completed part if the upload is interrupted
+		 > s5cmd {{.HelpName}} --resume bigfile.tar s3://bucket/
+
+	23. Upload a file to S3 bucket with a SHA256 checksum, verified by S3 on arrival
+		 > s5cmd {{.HelpName}} --checksum-algorithm SHA256 myfile.gz s3://bucket/
+
+	24. Download an S3 object and verify its content against its CRC32C checksum
+		 > s5cmd {{.HelpName}} --checksum-algorithm CRC32C s3://bucket/object.gz .
+
+	25. Copy an S3 object to another bucket, keeping its metadata but overriding the cache control header
+		 > s5cmd {{.HelpName}} --cache-control "public, max-age=3600" s3://bucket/object.gz s3://target-bucket/
+
+	26. Copy an S3 object to another bucket, dropping its metadata except the headers given in the flags
+		 > s5cmd {{.HelpName}} --metadata-directive REPLACE --cache-control "no-cache" s3://bucket/object.gz s3://target-bucket/
+
+	27. Upload a static website, serving markdown files as text/markdown
+		 > s5cmd {{.HelpName}} --content-type-map .md=text/markdown site/ s3://bucket/
+
+	28. Upload a file with a given content type
+		 > s5cmd {{.HelpName}} --content-type application/json data.txt s3://bucket/
+
+	29. Upload log files compressed with gzip, appending .gz to the object keys
+		 > s5cmd {{.HelpName}} --compress gzip --compress-suffix .gz "logs/*.log" s3://bucket/logs/
+
+	30. Download gzip compressed log files decompressed, removing .gz from the file names
+		 > s5cmd {{.HelpName}} --decompress "s3://bucket/logs/*.log.gz" logs/
+
+	31. Upload files with tags
+		 > s5cmd {{.HelpName}} --tags "team=data,env=prod" "reports/*" s3://bucket/reports/
+
+	32. Copy objects with their tags, adding a new tag
+		 > s5cmd {{.HelpName}} --copy-tags --tags "archived=true" "s3://bucket/reports/*" s3://archive/reports/
+
+	33. Download objects, committing each file to the disk before it is reported as downloaded
+		 > s5cmd {{.HelpName}} --fsync "s3://bucket/prefix/*" folder/
+
+	34. Copy an object encrypted with a customer provided key, keeping it encrypted with the same key
+		 > S5CMD_SSE_C_KEY=<base64-encoded-key> s5cmd {{.HelpName}} --sse-c s3://bucket/object s3://target-bucket/prefix/object
+
+	35. Perform KMS-SSE of the uploaded files using S3 Bucket Key to reduce the KMS requests
+		 > s5cmd {{.HelpName}} --sse aws:kms --sse-kms-key-id <your-kms-key-arn> --bucket-key-enabled "dir/*" s3://bucket/prefix/
+
+	36. Upload files smaller than 128KB with STANDARD and the others with STANDARD_IA storage class
+		 > s5cmd {{.HelpName}} --storage-class-rule "<128KB=STANDARD,>=128KB=STANDARD_IA" "dir/*" s3://bucket/prefix/
+
+	37. Upload a file to S3 bucket to be downloaded as an attachment with the given file name
+		 > s5cmd {{.HelpName}} --content-disposition "attachment; filename=report.pdf" report-2021.pdf s3://bucket/
+
+	38. Upload a directory tree from a network filesystem, reading the files of each directory together
+		 > s5cmd {{.HelpName}} --group-by-directory /mnt/nfs/dataset/ s3://bucket/dataset/
+
+	39. Upload a directory as a single tar archive with an index, and later extract a single file from it
+		 > s5cmd {{.HelpName}} --archive tar dir/ s3://bucket/archive.tar
+		 > s5cmd {{.HelpName}} --archive-member s3://bucket/archive.tar::path/inside.txt .
+
+	40. Create a redirect object for S3 static website hosting from an empty file
+		 > s5cmd {{.HelpName}} --website-redirect "/new/page.html" empty.html s3://bucket/old/page.html
+
+	41. Download only the last 64KB of a Parquet file to read its footer
+		 > s5cmd {{.HelpName}} --range bytes=-65536 s3://bucket/data.parquet footer.bin
+
+	42. Copy objects to another bucket and verify the copies against the source objects
+		 > s5cmd {{.HelpName}} --verify-copy "s3://bucket/*" s3://target-bucket/
+
+	43. Upload a VM image skipping the holes in it, and download it back as a sparse file
+		 > s5cmd {{.HelpName}} --sparse disk.img s3://bucket/images/
+		 > s5cmd {{.HelpName}} --sparse s3://bucket/images/disk.img .
+
+	44. Download CSV files of all subdirectories into a single directory, renaming the files of the same name
+		 > s5cmd {{.HelpName}} --flatten --flatten-collision rename "s3://bucket/a/b/*.csv" out/
+
+	45. Upload photos, changing the extensions of JPEG files
+		 > s5cmd {{.HelpName}} --rename 's/\.jpeg$/.jpg/' "photos/*" s3://bucket/photos/
+
+	46. Upload files under a prefix of their modification dates with lowercase names
+		 > s5cmd {{.HelpName}} --rename '{{"{{"}}.ModTime.Format "2006/01/02"{{"}}"}}/{{"{{"}}.Name | lower{{"}}"}}' "dir/*" s3://bucket/
+
+	47. Upload files to a prefix of a tenant unless the prefix would exceed 100GB or 10000 objects
+		 > s5cmd {{.HelpName}} --quota-size 100GB --quota-count 10000 --quota-prefix s3://bucket/tenant/ "dir/*" s3://bucket/tenant/uploads/
 `
 
 func NewSharedFlags() []cli.Flag {
@@ -109,6 +193,10 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "storage-class",
 			Usage: "set storage class for target ('STANDARD','REDUCED_REDUNDANCY','GLACIER','STANDARD_IA','ONEZONE_IA','INTELLIGENT_TIERING','DEEP_ARCHIVE')",
 		},
+		&cli.StringFlag{
+			Name:  "storage-class-rule",
+			Usage: "set storage class for target by object size, the first matching rule wins and --storage-class is used if none matches, e.g. '<128KB=STANDARD,>=128KB=STANDARD_IA'",
+		},
 		&cli.IntFlag{
 			Name:    "concurrency",
 			Aliases: []string{"c"},
@@ -129,6 +217,18 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "sse-kms-key-id",
 			Usage: "customer master key (CMK) id for SSE-KMS encryption; leave it out if server-side generated key is desired",
 		},
+		&cli.BoolFlag{
+			Name:  "bucket-key-enabled",
+			Usage: "use S3 Bucket Key for SSE-KMS encryption to reduce the requests made to KMS, requires --sse aws:kms",
+		},
+		&cli.BoolFlag{
+			Name:  "sse-c",
+			Usage: "use server side encryption with customer provided key (SSE-C); the key is read from --sse-c-key-file or S5CMD_SSE_C_KEY environment variable",
+		},
+		&cli.StringFlag{
+			Name:  "sse-c-key-file",
+			Usage: "read the 256-bit customer provided key of SSE-C from given file, either raw or base64 encoded",
+		},
 		&cli.StringFlag{
 			Name:  "acl",
 			Usage: "set acl for target: defines granted accesses and their types on different accounts/groups, e.g. cp --acl 'public-read'",
@@ -141,6 +241,18 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "expires",
 			Usage: "set expires for target (uses RFC3339 format): defines expires header for object, e.g. cp  --expires '2024-10-01T20:30:00Z'",
 		},
+		&cli.StringFlag{
+			Name:  "content-disposition",
+			Usage: "set content disposition for target: defines content disposition header for object, e.g. cp --content-disposition 'attachment; filename=report.pdf'",
+		},
+		&cli.StringFlag{
+			Name:  "content-language",
+			Usage: "set content language for target: defines content language header for object, e.g. cp --content-language 'en-US'",
+		},
+		&cli.StringFlag{
+			Name:  "website-redirect",
+			Usage: "set website redirect location for target: redirects the requests to the object to given URL on S3 static website hosting, e.g. cp --website-redirect '/new/page.html'",
+		},
 		&cli.BoolFlag{
 			Name:  "force-glacier-transfer",
 			Usage: "force transfer of glacier objects whether they are restored or not",
@@ -161,10 +273,110 @@ func NewSharedFlags() []cli.Flag {
 			Name:  "exclude",
 			Usage: "exclude objects with given pattern",
 		},
+		&cli.StringFlag{
+			Name:  "older-than",
+			Usage: "only process objects modified more than given time ago, e.g. 30d, 2w or 12h",
+		},
+		&cli.StringFlag{
+			Name:  "newer-than",
+			Usage: "only process objects modified less than given time ago, e.g. 30d, 2w or 12h",
+		},
+		&cli.StringFlag{
+			Name:  "min-size",
+			Usage: "only process objects whose sizes are greater than or equal to given size, e.g. 1GB or 512KiB",
+		},
+		&cli.StringFlag{
+			Name:  "max-size",
+			Usage: "only process objects whose sizes are less than or equal to given size, e.g. 10MB or 512KiB",
+		},
 		&cli.BoolFlag{
 			Name:  "raw",
 			Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
 		},
+		&cli.BoolFlag{
+			Name:  "respect-gitignore",
+			Usage: "do not upload local files that are ignored by the .gitignore rules of their repository",
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "keep track of multipart uploads in a local journal to resume interrupted uploads on the next run",
+		},
+		&cli.StringFlag{
+			Name:  "checksum-algorithm",
+			Usage: "send checksums of uploaded objects and verify checksums of downloaded objects using given algorithm ('CRC32','CRC32C','SHA1','SHA256')",
+		},
+		&cli.StringFlag{
+			Name:  "metadata-directive",
+			Usage: "copy the metadata of the source object ('COPY') or replace it with the metadata given in the flags ('REPLACE') on server-side copies; headers given in the flags override the copied ones",
+		},
+		&cli.StringFlag{
+			Name:  "content-type",
+			Usage: "set content type for target: defines content type header for object, e.g. --content-type text/plain",
+		},
+		&cli.StringSliceFlag{
+			Name:  "content-type-map",
+			Usage: "detect content type of uploaded files with given extension, e.g. --content-type-map .md=text/markdown",
+		},
+		&cli.BoolFlag{
+			Name:  "no-content-sniffing",
+			Usage: "do not detect content type of uploaded files from their content if their extension is unknown",
+		},
+		&cli.StringFlag{
+			Name:  "compress",
+			Usage: "compress files on upload with given algorithm and set content encoding of objects accordingly: (gzip)",
+		},
+		&cli.StringFlag{
+			Name:  "compress-suffix",
+			Usage: "append given suffix to the keys of compressed objects, e.g. --compress-suffix .gz",
+		},
+		&cli.BoolFlag{
+			Name:  "decompress",
+			Usage: "decompress objects with .gz suffix or gzip content encoding on download",
+		},
+		&cli.GenericFlag{
+			Name: "changing-files",
+			Value: &EnumValue{
+				Enum:    []string{changingFilesIgnore, changingFilesFail, changingFilesSkip, changingFilesRetry},
+				Default: changingFilesIgnore,
+			},
+			Usage: "action to take if a file changes during its upload: (ignore, fail, skip, retry)",
+		},
+		&cli.BoolFlag{
+			Name:  "fsync",
+			Usage: "commit downloaded files and their directory entries to the disk before reporting success",
+		},
+		&cli.BoolFlag{
+			Name:  "sparse",
+			Usage: "create sparse files on download by skipping the blocks of zeros, and skip reading the holes of sparse files on upload",
+		},
+		&cli.StringFlag{
+			Name:  "tags",
+			Usage: "set tags of uploaded and copied objects, e.g. --tags \"key1=value1,key2=value2\"",
+		},
+		&cli.BoolFlag{
+			Name:  "copy-tags",
+			Usage: "carry tags of source objects on server-side copies, merged with --tags",
+		},
+		&cli.BoolFlag{
+			Name:  "verify-copy",
+			Usage: "compare ETags or checksums of source and destination objects after server-side copies and fail on mismatch, or if they can't be compared",
+		},
+		&cli.StringFlag{
+			Name:  "rename",
+			Usage: "rewrite names of objects in destination with a sed-style substitution, e.g. 's/\\.log$/.log.gz/', or a Go template, e.g. '{{.Name | lower}}'",
+		},
+		&cli.StringFlag{
+			Name:  "quota-size",
+			Usage: "refuse to upload or copy objects if the total size of the objects under the quota prefix would exceed given size, e.g. 100GB",
+		},
+		&cli.Int64Flag{
+			Name:  "quota-count",
+			Usage: "refuse to upload or copy objects if the number of the objects under the quota prefix would exceed given number",
+		},
+		&cli.StringFlag{
+			Name:  "quota-prefix",
+			Usage: "remote prefix which --quota-size and --quota-count apply to, defaults to the destination prefix, e.g. s3://bucket/tenant/",
+		},
 	}
 }
 
@@ -175,10 +387,18 @@ func NewCopyCommandFlags() []cli.Flag {
 			Aliases: []string{"f"},
 			Usage:   "flatten directory structure of source, starting from the first wildcard",
 		},
+		&cli.GenericFlag{
+			Name: "flatten-collision",
+			Value: &EnumValue{
+				Enum:    []string{flattenCollisionOverwrite, flattenCollisionSkip, flattenCollisionFail, flattenCollisionRename},
+				Default: flattenCollisionOverwrite,
+			},
+			Usage: "action to take if objects of the same name are copied into a flattened destination: (overwrite, skip, fail, rename)",
+		},
 		&cli.BoolFlag{
 			Name:    "no-clobber",
 			Aliases: []string{"n"},
-			Usage:   "do not overwrite destination if already exists",
+			Usage:   "do not overwrite destination if already exists, uploads are conditional on the key not existing",
 		},
 		&cli.BoolFlag{
 			Name:    "if-size-differ",
@@ -190,6 +410,22 @@ func NewCopyCommandFlags() []cli.Flag {
 			Aliases: []string{"u"},
 			Usage:   "only overwrite destination if source modtime is newer",
 		},
+		&cli.BoolFlag{
+			Name:  "group-by-directory",
+			Usage: "process local files of a directory before the files of its subdirectories, improving read locality on spinning disks and network filesystems",
+		},
+		&cli.StringFlag{
+			Name:  "archive",
+			Usage: "upload the files as a single archive object with an index object next to it, so that a member can be extracted later with --archive-member flag: (tar)",
+		},
+		&cli.BoolFlag{
+			Name:  "archive-member",
+			Usage: "download a single member of an archive uploaded with --archive flag, given as 'archive::member' source",
+		},
+		&cli.StringFlag{
+			Name:  "range",
+			Usage: "download only the given byte range of the object, e.g. bytes=0-1048575, bytes=1048576- or bytes=-1024 for the last 1024 bytes",
+		},
 	}
 	sharedFlags := NewSharedFlags()
 	return append(copyFlags, sharedFlags...)
@@ -232,17 +468,48 @@ type Copy struct {
 	ifSizeDiffer          bool
 	ifSourceNewer         bool
 	flatten               bool
+	flattenCollision      string
 	followSymlinks        bool
 	storageClass          storage.StorageClass
+	storageClassRules     []storageClassRule
 	encryptionMethod      string
 	encryptionKeyID       string
+	bucketKeyEnabled      bool
 	acl                   string
 	forceGlacierTransfer  bool
 	ignoreGlacierWarnings bool
 	exclude               []string
 	raw                   bool
+	respectGitignore      bool
+	resume                bool
 	cacheControl          string
 	expires               string
+	contentDisposition    string
+	contentLanguage       string
+	websiteRedirect       string
+	archive               string
+	archiveMember         bool
+	byteRange             string
+	metadataDirective     storage.MetadataDirective
+	contentType           string
+	contentTypeMap        map[string]string
+	noContentSniffing     bool
+	compress              string
+	compressSuffix        string
+	decompress            bool
+	tags                  map[string]string
+	copyTags              bool
+	verifyCopy            bool
+	verifyMove            bool
+	renamer               *keyRenamer
+	changingFiles         string
+	fsync                 bool
+	sparse                bool
+	quota                 *quota
+	lister                storage.Lister
+	confirmer             *confirmer
+	age                   ageFilter
+	sizeRange             sizeFilter
 
 	// region settings
 	srcRegion string
@@ -252,10 +519,43 @@ type Copy struct {
 	concurrency int
 	partSize    int64
 	storageOpts storage.Options
+
+	// multipartThreshold is the size of the files to be uploaded with
+	// multipart uploads, if set.
+	multipartThreshold int64
 }
 
 // NewCopy creates Copy from cli.Context.
 func NewCopy(c *cli.Context, deleteSource bool) Copy {
+	// the mapping is validated before the command runs.
+	contentTypeMap, _ := parseContentTypeMap(c.StringSlice("content-type-map"))
+	tags, _ := parseTags(c.String("tags"))
+	storageClassRules, _ := parseStorageClassRules(c.String("storage-class-rule"))
+	byteRange, _ := parseByteRange(c.String("range"))
+	dstQuota, _ := parseQuota(c)
+	age, _ := parseAgeFilter(c)
+	sizeRange, _ := parseSizeFilter(c)
+
+	// only the overwrites of moves are confirmed. The flag is looked up in
+	// the parent commands as well, so the copies of sync are excluded.
+	var overwriteConfirmer *confirmer
+	if deleteSource {
+		overwriteConfirmer = newConfirmer(c)
+	}
+
+	var renamer *keyRenamer
+	if expr := c.String("rename"); expr != "" {
+		renamer, _ = parseRename(expr)
+	}
+
+	partSize := c.Int64("part-size") * megabytes
+	if !c.IsSet("part-size") && transferConfig.MultipartChunkSize > 0 {
+		partSize = transferConfig.MultipartChunkSize
+	}
+
+	storageOpts := NewStorageOpts(c)
+	storageOpts.GroupByDirectory = c.Bool("group-by-directory")
+
 	return Copy{
 		src:          c.Args().Get(0),
 		dst:          c.Args().Get(1),
@@ -267,25 +567,110 @@ func NewCopy(c *cli.Context, deleteSource bool) Copy {
 		ifSizeDiffer:          c.Bool("if-size-differ"),
 		ifSourceNewer:         c.Bool("if-source-newer"),
 		flatten:               c.Bool("flatten"),
+		flattenCollision:      c.String("flatten-collision"),
 		followSymlinks:        !c.Bool("no-follow-symlinks"),
 		storageClass:          storage.StorageClass(c.String("storage-class")),
+		storageClassRules:     storageClassRules,
 		concurrency:           c.Int("concurrency"),
-		partSize:              c.Int64("part-size") * megabytes,
+		partSize:              partSize,
 		encryptionMethod:      c.String("sse"),
 		encryptionKeyID:       c.String("sse-kms-key-id"),
+		bucketKeyEnabled:      c.Bool("bucket-key-enabled"),
 		acl:                   c.String("acl"),
 		forceGlacierTransfer:  c.Bool("force-glacier-transfer"),
 		ignoreGlacierWarnings: c.Bool("ignore-glacier-warnings"),
 		exclude:               c.StringSlice("exclude"),
 		raw:                   c.Bool("raw"),
+		respectGitignore:      c.Bool("respect-gitignore"),
+		resume:                c.Bool("resume"),
 		cacheControl:          c.String("cache-control"),
+		metadataDirective:     storage.MetadataDirective(strings.ToUpper(c.String("metadata-directive"))),
+		contentType:           c.String("content-type"),
+		contentTypeMap:        contentTypeMap,
+		noContentSniffing:     c.Bool("no-content-sniffing"),
+		compress:              c.String("compress"),
+		compressSuffix:        c.String("compress-suffix"),
+		decompress:            c.Bool("decompress"),
+		tags:                  tags,
+		copyTags:              c.Bool("copy-tags"),
+		verifyCopy:            c.Bool("verify-copy"),
+		verifyMove:            c.Bool("verify"),
+		renamer:               renamer,
+		changingFiles:         c.String("changing-files"),
+		fsync:                 c.Bool("fsync"),
+		sparse:                c.Bool("sparse"),
+		quota:                 dstQuota,
+		lister:                newSourceLister(c),
+		confirmer:             overwriteConfirmer,
+		age:                   age,
+		sizeRange:             sizeRange,
 		expires:               c.String("expires"),
+		contentDisposition:    c.String("content-disposition"),
+		contentLanguage:       c.String("content-language"),
+		websiteRedirect:       c.String("website-redirect"),
+		archive:               c.String("archive"),
+		archiveMember:         c.Bool("archive-member"),
+		byteRange:             byteRange,
 		// region settings
 		srcRegion: c.String("source-region"),
 		dstRegion: c.String("destination-region"),
 
-		storageOpts: NewStorageOpts(c),
+		storageOpts:        storageOpts,
+		multipartThreshold: transferConfig.MultipartThreshold,
+	}
+}
+
+// Actions to take if a file changes during its upload.
+const (
+	changingFilesIgnore = "ignore"
+	changingFilesFail   = "fail"
+	changingFilesSkip   = "skip"
+	changingFilesRetry  = "retry"
+)
+
+// maxChangingFileAttempts is the number of times a file is uploaded with
+// --changing-files=retry before failing.
+const maxChangingFileAttempts = 3
+
+var errFileChanged = fmt.Errorf("file changed during upload")
+
+// fileVerifier verifies that the file is not changed since the upload has
+// started, by comparing its size and modification time.
+type fileVerifier struct {
+	file   *os.File
+	before os.FileInfo
+}
+
+// VerifySource implements storage.SourceVerifier interface.
+func (v fileVerifier) VerifySource() error {
+	after, err := v.file.Stat()
+	if err != nil {
+		return err
+	}
+	if after.Size() != v.before.Size() || !after.ModTime().Equal(v.before.ModTime()) {
+		return errFileChanged
+	}
+	return nil
+}
+
+// verifiedReader returns a reader which is verified by the given verifier
+// before the upload is completed. The returned reader doesn't implement
+// io.ReaderAt, so that the uploaded parts are read before they are sent,
+// instead of while they are sent after the verification.
+func verifiedReader(r io.Reader, verifier storage.SourceVerifier) io.Reader {
+	if verifier == nil {
+		return r
+	}
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		return struct {
+			io.ReadSeeker
+			storage.SourceVerifier
+		}{seeker, verifier}
 	}
+	return struct {
+		io.Reader
+		storage.SourceVerifier
+	}{r, verifier}
 }
 
 const fdlimitWarning = `
@@ -308,12 +693,29 @@ func (c Copy) Run(ctx context.Context) error {
 		return err
 	}
 
+	if c.archiveMember {
+		err := c.downloadArchiveMember(ctx, srcurl, dsturl)
+		if err != nil {
+			err = &errorpkg.Error{
+				Op:  c.op,
+				Src: srcurl,
+				Dst: dsturl,
+				Err: err,
+			}
+			printError(c.fullCommand, c.op, err)
+		}
+		return err
+	}
+
 	// override source region if set
 	if c.srcRegion != "" {
 		c.storageOpts.SetRegion(c.srcRegion)
 	}
 
-	client, err := storage.NewClient(ctx, srcurl, c.storageOpts)
+	// the objects are listed with the external lister, if it is given.
+	srcOpts := c.storageOpts
+	srcOpts.Lister = c.lister
+	client, err := storage.NewClient(ctx, srcurl, srcOpts)
 	if err != nil {
 		printError(c.fullCommand, c.op, err)
 		return err
@@ -338,8 +740,8 @@ func (c Copy) Run(ctx context.Context) error {
 		defer close(errDoneCh)
 		for err := range waiter.Err() {
 			if strings.Contains(err.Error(), "too many open files") {
-				fmt.Println(strings.TrimSpace(fdlimitWarning))
-				fmt.Printf("ERROR %v\n", err)
+				fmt.Fprintln(os.Stderr, strings.TrimSpace(fdlimitWarning))
+				fmt.Fprintf(os.Stderr, "ERROR %v\n", err)
 
 				os.Exit(1)
 			}
@@ -360,7 +762,41 @@ func (c Copy) Run(ctx context.Context) error {
 		return err
 	}
 
+	var gitignore *gitignoreMatcher
+	if c.respectGitignore && !srcurl.IsRemote() {
+		gitignore, err = newGitignoreMatcher(srcurl)
+		if err != nil {
+			printError(c.fullCommand, c.op, err)
+			return err
+		}
+	}
+
+	// the files are sent to a single upload instead of the workers in
+	// archive mode.
+	var (
+		archivech    chan archiveEntry
+		archiveErrCh = make(chan error, 1)
+	)
+	if c.archive != "" {
+		archivech = make(chan archiveEntry)
+		go func() {
+			archiveErrCh <- c.uploadArchive(ctx, dsturl, archivech)
+		}()
+	}
+
+	var collisions *flattenCollisions
+	if c.flatten && isBatch {
+		collisions = newFlattenCollisions(c.flattenCollision)
+	}
+
+	var summary transferSummary
 	for object := range objch {
+		// no new tasks are started once the command is canceled, the objects
+		// are drained to let the listing stop.
+		if ctx.Err() != nil {
+			continue
+		}
+
 		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
 			continue
 		}
@@ -384,26 +820,112 @@ func (c Copy) Run(ctx context.Context) error {
 			continue
 		}
 
+		if gitignore != nil && gitignore.IsIgnored(object.URL.Absolute()) {
+			continue
+		}
+
+		if ok, err := c.age.match(ctx, client, object); err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(c.fullCommand, c.op, err)
+			continue
+		} else if !ok {
+			printDebug(c.op, errObjectAgeMismatch, object.URL)
+			continue
+		}
+
+		if ok, err := c.sizeRange.match(ctx, client, object); err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(c.fullCommand, c.op, err)
+			continue
+		} else if !ok {
+			printDebug(c.op, errObjectSizeMismatch, object.URL)
+			continue
+		}
+
 		srcurl := object.URL
+
+		if archivech != nil {
+			archivech <- archiveEntry{
+				name:   c.archiveMemberName(srcurl, isBatch),
+				source: srcurl,
+			}
+			continue
+		}
+
+		// name is the name of the object relative to the destination if it
+		// is renamed to avoid a collision or with --rename flag.
+		var name string
+		if collisions != nil {
+			var err error
+			name, err = collisions.resolve(srcurl)
+			if errors.Is(err, errFlattenCollisionSkipped) {
+				printWarning(c.op, err.Error(), srcurl, dsturl)
+				continue
+			}
+			if err != nil {
+				merrorObjects = multierror.Append(merrorObjects, err)
+				printError(c.fullCommand, c.op, err)
+				continue
+			}
+		}
+
+		if c.renamer != nil {
+			if name == "" {
+				name = filepath.ToSlash(destinationName(srcurl, c.flatten, isBatch))
+			}
+
+			var err error
+			name, err = c.renamer.rename(name, object)
+			if err != nil {
+				err = &errorpkg.Error{
+					Op:  c.op,
+					Src: srcurl,
+					Dst: dsturl,
+					Err: err,
+				}
+				merrorObjects = multierror.Append(merrorObjects, err)
+				printError(c.fullCommand, c.op, err)
+				continue
+			}
+		}
+
 		var task parallel.Task
 
 		switch {
 		case srcurl.Type == dsturl.Type: // local->local or remote->remote
-			task = c.prepareCopyTask(ctx, srcurl, dsturl, isBatch)
+			task = c.prepareCopyTask(ctx, srcurl, dsturl, isBatch, name, object.Size)
 		case srcurl.IsRemote(): // remote->local
-			task = c.prepareDownloadTask(ctx, srcurl, dsturl, isBatch)
+			task = c.prepareDownloadTask(ctx, srcurl, dsturl, isBatch, name, object.Size)
 		case dsturl.IsRemote(): // local->remote
-			task = c.prepareUploadTask(ctx, srcurl, dsturl, isBatch)
+			task = c.prepareUploadTask(ctx, srcurl, dsturl, isBatch, name)
 		default:
 			panic("unexpected src-dst pair")
 		}
 
-		parallel.Run(task, waiter)
+		parallel.Run(summary.track(task), waiter)
+	}
+
+	if archivech != nil {
+		close(archivech)
+		if err := <-archiveErrCh; err != nil {
+			err = &errorpkg.Error{
+				Op:  c.op,
+				Src: srcurl,
+				Dst: dsturl,
+				Err: err,
+			}
+			printError(c.fullCommand, c.op, err)
+			merrorObjects = multierror.Append(merrorObjects, err)
+		}
 	}
 
 	waiter.Wait()
 	<-errDoneCh
 
+	if ctx.Err() != nil {
+		printWarning(c.op, summary.String(), srcurl, dsturl)
+	}
+
 	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
 }
 
@@ -412,10 +934,12 @@ func (c Copy) prepareCopyTask(
 	srcurl *url.URL,
 	dsturl *url.URL,
 	isBatch bool,
+	name string,
+	size int64,
 ) func() error {
 	return func() error {
-		dsturl = prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch)
-		err := c.doCopy(ctx, srcurl, dsturl)
+		dsturl = prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch, name)
+		err := c.doCopy(ctx, srcurl, dsturl, size)
 		if err != nil {
 			return &errorpkg.Error{
 				Op:  c.op,
@@ -433,13 +957,15 @@ func (c Copy) prepareDownloadTask(
 	srcurl *url.URL,
 	dsturl *url.URL,
 	isBatch bool,
+	name string,
+	size int64,
 ) func() error {
 	return func() error {
-		dsturl, err := prepareLocalDestination(ctx, srcurl, dsturl, c.flatten, isBatch, c.storageOpts)
+		dsturl, err := prepareLocalDestination(ctx, srcurl, dsturl, c.flatten, isBatch, name, c.storageOpts)
 		if err != nil {
 			return err
 		}
-		err = c.doDownload(ctx, srcurl, dsturl)
+		err = c.doDownload(ctx, srcurl, dsturl, size)
 		if err != nil {
 			return &errorpkg.Error{
 				Op:  c.op,
@@ -457,9 +983,10 @@ func (c Copy) prepareUploadTask(
 	srcurl *url.URL,
 	dsturl *url.URL,
 	isBatch bool,
+	name string,
 ) func() error {
 	return func() error {
-		dsturl = prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch)
+		dsturl = prepareRemoteDestination(srcurl, dsturl, c.flatten, isBatch, name)
 		err := c.doUpload(ctx, srcurl, dsturl)
 		if err != nil {
 			return &errorpkg.Error{
@@ -474,7 +1001,8 @@ func (c Copy) prepareUploadTask(
 }
 
 // doDownload is used to fetch a remote object and save as a local object.
-func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL) error {
+// The size of the object is only used to plan the requests on simulations.
+func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL, size int64) error {
 	srcClient, err := storage.NewRemoteClient(ctx, srcurl, c.storageOpts)
 	if err != nil {
 		return err
@@ -482,6 +1010,19 @@ func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL)
 
 	dstClient := storage.NewLocalClient(c.storageOpts)
 
+	var decompress bool
+	if c.decompress {
+		decompress, err = isGzipObject(ctx, srcClient, srcurl)
+		if err != nil {
+			return err
+		}
+		// only strip the suffix if the file name is derived from the key.
+		if decompress && dsturl.Base() == srcurl.Base() {
+			dsturl = dsturl.Clone()
+			dsturl.Path = strings.TrimSuffix(dsturl.Path, gzipSuffix)
+		}
+	}
+
 	err = c.shouldOverride(ctx, srcurl, dsturl)
 	if err != nil {
 		// FIXME(ig): rename
@@ -498,13 +1039,46 @@ func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL)
 	}
 	defer file.Close()
 
-	size, err := srcClient.Get(ctx, srcurl, file, c.concurrency, c.partSize)
+	if c.storageOpts.DryRun {
+		size, err := c.sourceSize(ctx, srcurl, size)
+		if err != nil {
+			return err
+		}
+
+		// compressed objects and byte ranges are read in a single request.
+		partSize := c.partSize
+		if decompress || c.byteRange != "" {
+			partSize = 0
+		}
+		if c.byteRange != "" {
+			size = byteRangeLength(c.byteRange, size)
+		}
+		storage.PlanDownload(size, partSize)
+	}
+
+	switch {
+	case decompress:
+		size, err = c.downloadDecompressed(ctx, srcClient, srcurl, file)
+	case c.byteRange != "":
+		size, err = c.downloadByteRange(ctx, srcClient, srcurl, file)
+	case c.sparse:
+		size, err = srcClient.Get(ctx, srcurl, storage.NewSparseWriter(file), c.concurrency, c.partSize)
+	default:
+		size, err = srcClient.Get(ctx, srcurl, file, c.concurrency, c.partSize)
+	}
+	if err == nil && c.fsync {
+		err = dstClient.Sync(file)
+	}
 	if err != nil {
 		_ = dstClient.Delete(ctx, dsturl)
 		return err
 	}
 
-	if c.deleteSource {
+	deleteSource, err := c.canDeleteSource(ctx, srcurl, dsturl)
+	if err != nil {
+		return err
+	}
+	if deleteSource {
 		_ = srcClient.Delete(ctx, srcurl)
 	}
 
@@ -521,7 +1095,40 @@ func (c Copy) doDownload(ctx context.Context, srcurl *url.URL, dsturl *url.URL)
 	return nil
 }
 
+// downloadByteRange writes the given byte range of the object to the given
+// writer and returns the number of bytes written.
+func (c Copy) downloadByteRange(ctx context.Context, client *storage.S3, srcurl *url.URL, w io.Writer) (int64, error) {
+	if c.storageOpts.DryRun {
+		return 0, nil
+	}
+
+	rc, err := client.ReadByteRange(ctx, srcurl, c.byteRange)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	return io.Copy(w, rc)
+}
+
+// downloadDecompressed streams the gzip compressed object to the given writer
+// decompressing it on the fly and returns the number of decompressed bytes.
+func (c Copy) downloadDecompressed(ctx context.Context, client *storage.S3, srcurl *url.URL, w io.Writer) (int64, error) {
+	rc, err := client.Read(ctx, srcurl)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	return gunzip(w, rc)
+}
+
 func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL) error {
+	if c.compress != "" && c.compressSuffix != "" {
+		dsturl = dsturl.Clone()
+		dsturl.Path += c.compressSuffix
+	}
+
 	srcClient := storage.NewLocalClient(c.storageOpts)
 
 	file, err := srcClient.Open(srcurl.Absolute())
@@ -548,38 +1155,116 @@ func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL) er
 		return err
 	}
 
+	contentType := c.contentType
+	if contentType == "" {
+		contentType = guessContentType(file, c.contentTypeMap, !c.noContentSniffing)
+	}
+
+	st, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	storageClass := c.objectStorageClass(st.Size())
+
 	metadata := storage.NewMetadata().
-		SetContentType(guessContentType(file)).
-		SetStorageClass(string(c.storageClass)).
+		SetContentType(contentType).
+		SetStorageClass(string(storageClass)).
 		SetSSE(c.encryptionMethod).
 		SetSSEKeyID(c.encryptionKeyID).
+		SetBucketKeyEnabled(c.bucketKeyEnabled).
 		SetACL(c.acl).
 		SetCacheControl(c.cacheControl).
+		SetContentDisposition(c.contentDisposition).
+		SetContentLanguage(c.contentLanguage).
+		SetWebsiteRedirectLocation(c.websiteRedirect).
 		SetExpires(c.expires)
 
-	err = dstClient.Put(ctx, file, dsturl, metadata, c.concurrency, c.partSize)
-	if err != nil {
-		return err
+	// the destination may be created after the check of shouldOverride, the
+	// upload is made conditional where the storage supports it.
+	if c.noClobber {
+		metadata.SetIfNoneMatch("*")
 	}
 
-	obj, _ := srcClient.Stat(ctx, srcurl)
-	size := obj.Size
+	if len(c.tags) > 0 {
+		metadata.SetTagging(storage.EncodeTags(c.tags))
+	}
 
-	if c.deleteSource {
-		// close the file before deleting
-		file.Close()
-		if err := srcClient.Delete(ctx, srcurl); err != nil {
-			return err
+	if err := c.quota.reserve(ctx, dsturl, st.Size()); err != nil {
+		return err
+	}
+
+	partSize := c.partSize
+	if c.multipartThreshold > partSize {
+		// files smaller than the threshold are uploaded in a single part.
+		if st, err := file.Stat(); err == nil && st.Size() < c.multipartThreshold {
+			partSize = c.multipartThreshold
 		}
 	}
 
-	msg := log.InfoMessage{
-		Operation:   c.op,
-		Source:      srcurl,
-		Destination: dsturl,
-		Object: &storage.Object{
-			Size:         size,
-			StorageClass: c.storageClass,
+	if c.storageOpts.DryRun {
+		storage.PlanUpload(st.Size(), partSize)
+	}
+
+	for attempt := 1; ; attempt++ {
+		var verifier storage.SourceVerifier
+		if c.changingFiles != changingFilesIgnore {
+			before, err := file.Stat()
+			if err != nil {
+				return err
+			}
+			verifier = fileVerifier{file: file, before: before}
+		}
+
+		// the upload is aborted before it is completed if the file changes,
+		// the destination object is left intact.
+		err = c.put(ctx, dstClient, file, dsturl, metadata, partSize, verifier)
+		if err == errFileChanged {
+			switch {
+			case c.changingFiles == changingFilesSkip:
+				c.quota.release(dsturl, st.Size())
+				printWarning(c.op, "skipped: file changed during upload", srcurl, dsturl)
+				return nil
+			case c.changingFiles == changingFilesRetry && attempt < maxChangingFileAttempts:
+				if _, err := file.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if errorpkg.IsWarning(err) {
+			c.quota.release(dsturl, st.Size())
+			printDebug(c.op, err, srcurl, dsturl)
+			return nil
+		}
+		if err != nil {
+			c.quota.release(dsturl, st.Size())
+			return err
+		}
+		break
+	}
+
+	obj, _ := srcClient.Stat(ctx, srcurl)
+	size := obj.Size
+
+	deleteSource, err := c.canDeleteSource(ctx, srcurl, dsturl)
+	if err != nil {
+		return err
+	}
+	if deleteSource {
+		// close the file before deleting
+		file.Close()
+		if err := srcClient.Delete(ctx, srcurl); err != nil {
+			return err
+		}
+	}
+
+	msg := log.InfoMessage{
+		Operation:   c.op,
+		Source:      srcurl,
+		Destination: dsturl,
+		Object: &storage.Object{
+			Size:         size,
+			StorageClass: storageClass,
 		},
 	}
 	log.Info(msg)
@@ -587,7 +1272,75 @@ func (c Copy) doUpload(ctx context.Context, srcurl *url.URL, dsturl *url.URL) er
 	return nil
 }
 
-func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL) error {
+// sourceSize returns the size of the remote source object. The sizes of the
+// objects are only known if they are listed with a wildcard, the sizes of the
+// others, e.g. the objects copied by sync, are fetched.
+func (c Copy) sourceSize(ctx context.Context, srcurl *url.URL, size int64) (int64, error) {
+	if size > 0 {
+		return size, nil
+	}
+
+	// the options may be of the destination region.
+	srcOpts := c.storageOpts
+	srcOpts.SetRegion(c.srcRegion)
+	client, err := storage.NewRemoteClient(ctx, srcurl, srcOpts)
+	if err != nil {
+		return 0, err
+	}
+	obj, err := client.Head(ctx, srcurl)
+	if err != nil {
+		return 0, err
+	}
+	return obj.Size, nil
+}
+
+// objectStorageClass returns the storage class of an object of the given size
+// at the destination.
+func (c Copy) objectStorageClass(size int64) storage.StorageClass {
+	for _, rule := range c.storageClassRules {
+		if rule.match(size) {
+			return rule.storageClass
+		}
+	}
+	return c.storageClass
+}
+
+// put uploads the file to the destination. If the verifier is not nil, the
+// file is verified before the upload is completed.
+func (c Copy) put(
+	ctx context.Context,
+	dstClient *storage.S3,
+	file *os.File,
+	dsturl *url.URL,
+	metadata storage.Metadata,
+	partSize int64,
+	verifier storage.SourceVerifier,
+) error {
+	switch {
+	case c.compress == compressionGzip:
+		reader := gzipReader(file)
+		defer reader.Close()
+
+		metadata.SetContentEncoding(compressionGzip)
+		return dstClient.Put(ctx, verifiedReader(reader, verifier), dsturl, metadata, c.concurrency, partSize)
+	case c.resume:
+		journal, err := storage.NewUploadJournal("")
+		if err != nil {
+			return err
+		}
+		return dstClient.PutResumable(ctx, file, dsturl, metadata, c.concurrency, partSize, journal, verifier)
+	case c.sparse:
+		reader, err := storage.NewSparseReader(file)
+		if err != nil {
+			return err
+		}
+		return dstClient.Put(ctx, verifiedReader(reader, verifier), dsturl, metadata, c.concurrency, partSize)
+	default:
+		return dstClient.Put(ctx, verifiedReader(file, verifier), dsturl, metadata, c.concurrency, partSize)
+	}
+}
+
+func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL, size int64) error {
 	// override destination region if set
 	if c.dstRegion != "" {
 		c.storageOpts.SetRegion(c.dstRegion)
@@ -597,13 +1350,29 @@ func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL) error {
 		return err
 	}
 
+	// the size of the source is needed to pick its storage class, to count
+	// it in the quota and to plan the requests of the copy on dry-runs.
+	if len(c.storageClassRules) > 0 || (c.quota != nil && c.quota.contains(dsturl)) || c.storageOpts.DryRun {
+		size, err = c.sourceSize(ctx, srcurl, size)
+		if err != nil {
+			return err
+		}
+	}
+	storageClass := c.objectStorageClass(size)
+
 	metadata := storage.NewMetadata().
-		SetStorageClass(string(c.storageClass)).
+		SetContentType(c.contentType).
+		SetStorageClass(string(storageClass)).
 		SetSSE(c.encryptionMethod).
 		SetSSEKeyID(c.encryptionKeyID).
+		SetBucketKeyEnabled(c.bucketKeyEnabled).
 		SetACL(c.acl).
 		SetCacheControl(c.cacheControl).
-		SetExpires(c.expires)
+		SetContentDisposition(c.contentDisposition).
+		SetContentLanguage(c.contentLanguage).
+		SetWebsiteRedirectLocation(c.websiteRedirect).
+		SetExpires(c.expires).
+		SetDirective(c.metadataDirective)
 
 	err = c.shouldOverride(ctx, srcurl, dsturl)
 	if err != nil {
@@ -614,12 +1383,39 @@ func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL) error {
 		return err
 	}
 
+	tags, err := c.objectTags(ctx, srcurl)
+	if err != nil {
+		return err
+	}
+	if len(tags) > 0 {
+		metadata.SetTagging(storage.EncodeTags(tags))
+	}
+
+	if err := c.quota.reserve(ctx, dsturl, size); err != nil {
+		return err
+	}
+
+	if c.storageOpts.DryRun && dsturl.IsRemote() {
+		storage.PlanCopy(size)
+	}
+
 	err = dstClient.Copy(ctx, srcurl, dsturl, metadata)
 	if err != nil {
+		c.quota.release(dsturl, size)
 		return err
 	}
 
-	if c.deleteSource {
+	if c.verifyCopy {
+		if err := c.verify(ctx, srcurl, dsturl); err != nil {
+			return err
+		}
+	}
+
+	deleteSource, err := c.canDeleteSource(ctx, srcurl, dsturl)
+	if err != nil {
+		return err
+	}
+	if deleteSource {
 		srcClient, err := storage.NewClient(ctx, srcurl, c.storageOpts)
 		if err != nil {
 			return err
@@ -635,7 +1431,7 @@ func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL) error {
 		Destination: dsturl,
 		Object: &storage.Object{
 			URL:          dsturl,
-			StorageClass: c.storageClass,
+			StorageClass: storageClass,
 		},
 	}
 	log.Info(msg)
@@ -643,14 +1439,106 @@ func (c Copy) doCopy(ctx context.Context, srcurl, dsturl *url.URL) error {
 	return nil
 }
 
+// verify compares the source object with its server-side copy. Copies which
+// can't be compared fail as well as the mismatching ones.
+func (c Copy) verify(ctx context.Context, srcurl, dsturl *url.URL) error {
+	client, err := storage.NewRemoteClient(ctx, dsturl, c.storageOpts)
+	if err != nil {
+		return err
+	}
+
+	// the source bucket may be in another region than the destination.
+	srcOpts := c.storageOpts
+	srcOpts.SetRegion(c.srcRegion)
+	srcClient, err := storage.NewRemoteClient(ctx, srcurl, srcOpts)
+	if err != nil {
+		return err
+	}
+
+	return client.VerifyCopy(ctx, srcClient, srcurl, dsturl)
+}
+
+// canDeleteSource reports whether the source of a move can be deleted. With
+// --verify, the source is deleted only if the destination is verified to
+// match it. Sources of copies which can't be compared are kept with a warning.
+func (c Copy) canDeleteSource(ctx context.Context, srcurl, dsturl *url.URL) (bool, error) {
+	if !c.deleteSource {
+		return false, nil
+	}
+	// nothing is copied to be verified on dry runs.
+	if !c.verifyMove || c.storageOpts.DryRun {
+		return true, nil
+	}
+
+	var err error
+	switch {
+	case srcurl.IsRemote() && dsturl.IsRemote():
+		err = c.verify(ctx, srcurl, dsturl)
+	case srcurl.IsRemote():
+		err = c.verifyLocalCopy(ctx, srcurl, dsturl)
+	default:
+		err = c.verifyLocalCopy(ctx, dsturl, srcurl)
+	}
+
+	if errors.Is(err, storage.ErrCopyNotVerified) {
+		printWarning(c.op, fmt.Sprintf("source is not deleted: %v", err), srcurl, dsturl)
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// verifyLocalCopy compares the remote object with the local file, which is
+// either its source or its downloaded copy.
+func (c Copy) verifyLocalCopy(ctx context.Context, remote, local *url.URL) error {
+	client, err := storage.NewRemoteClient(ctx, remote, c.storageOpts)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(local.Absolute())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return client.VerifyObject(ctx, remote, file, info.Size())
+}
+
+// objectTags returns the tags of the server-side copy of the source object.
+// Tags of the source object are merged with the given tags if asked,
+// otherwise S3 carries them only when no tags are given.
+func (c Copy) objectTags(ctx context.Context, srcurl *url.URL) (map[string]string, error) {
+	if !c.copyTags || !srcurl.IsRemote() {
+		return c.tags, nil
+	}
+
+	srcClient, err := storage.NewRemoteClient(ctx, srcurl, c.storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := srcClient.Tags(ctx, srcurl)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range c.tags {
+		tags[key] = value
+	}
+	return tags, nil
+}
+
 // shouldOverride function checks if the destination should be overridden if
 // the source-destination pair and given copy flags conform to the
 // override criteria. For example; "cp -n -s <src> <dst>" should not override
 // the <dst> if <src> and <dst> filenames are the same, except if the size
 // differs.
 func (c Copy) shouldOverride(ctx context.Context, srcurl *url.URL, dsturl *url.URL) error {
-	// if not asked to override, ignore.
-	if !c.noClobber && !c.ifSizeDiffer && !c.ifSourceNewer {
+	// if not asked to override or confirm overrides, ignore.
+	if !c.noClobber && !c.ifSizeDiffer && !c.ifSourceNewer && c.confirmer == nil {
 		return nil
 	}
 
@@ -702,20 +1590,35 @@ func (c Copy) shouldOverride(ctx context.Context, srcurl *url.URL, dsturl *url.U
 		}
 	}
 
+	if stickyErr == nil && !c.confirmer.confirm(fmt.Sprintf("overwrite %v", dsturl)) {
+		stickyErr = errorpkg.ErrNotConfirmed
+	}
+
 	return stickyErr
 }
 
+// destinationName returns the name of the source object relative to the
+// destination directory.
+func destinationName(srcurl *url.URL, flatten, isBatch bool) string {
+	if isBatch && !flatten {
+		return srcurl.Relative()
+	}
+	return srcurl.Base()
+}
+
 // prepareRemoteDestination will return a new destination URL for
-// remote->remote and local->remote copy operations.
+// remote->remote and local->remote copy operations. The name overrides the
+// name of the object relative to the destination directory if it is set.
 func prepareRemoteDestination(
 	srcurl *url.URL,
 	dsturl *url.URL,
 	flatten bool,
 	isBatch bool,
+	name string,
 ) *url.URL {
-	objname := srcurl.Base()
-	if isBatch && !flatten {
-		objname = srcurl.Relative()
+	objname := destinationName(srcurl, flatten, isBatch)
+	if name != "" {
+		objname = name
 	}
 
 	if dsturl.IsPrefix() || dsturl.IsBucket() {
@@ -725,18 +1628,20 @@ func prepareRemoteDestination(
 }
 
 // prepareDownloadDestination will return a new destination URL for
-// remote->local copy operations.
+// remote->local copy operations. The name overrides the name of the object
+// relative to the destination directory if it is set.
 func prepareLocalDestination(
 	ctx context.Context,
 	srcurl *url.URL,
 	dsturl *url.URL,
 	flatten bool,
 	isBatch bool,
+	name string,
 	storageOpts storage.Options,
 ) (*url.URL, error) {
-	objname := srcurl.Base()
-	if isBatch && !flatten {
-		objname = srcurl.Relative()
+	objname := destinationName(srcurl, flatten, isBatch)
+	if name != "" {
+		objname = name
 	}
 
 	client := storage.NewLocalClient(storageOpts)
@@ -775,6 +1680,13 @@ func prepareLocalDestination(
 		}
 	}
 
+	// renamed objects may be placed under directories which don't exist.
+	if name != "" {
+		if err := client.MkdirAll(dsturl.Dir()); err != nil {
+			return nil, err
+		}
+	}
+
 	return dsturl, nil
 }
 
@@ -808,6 +1720,128 @@ func validateCopyCommand(c *cli.Context) error {
 		return err
 	}
 
+	if algorithm := c.String("checksum-algorithm"); algorithm != "" {
+		if !storage.ChecksumAlgorithm(strings.ToUpper(algorithm)).IsValid() {
+			return fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+		}
+		// the parts of the resumed uploads are sent without checksums.
+		if c.Bool("resume") {
+			return fmt.Errorf("checksum-algorithm can not be used with resume flag")
+		}
+	}
+
+	if _, err := parseContentTypeMap(c.StringSlice("content-type-map")); err != nil {
+		return err
+	}
+
+	if _, err := parseTags(c.String("tags")); err != nil {
+		return err
+	}
+
+	if _, err := readSSECustomerKey(c); err != nil {
+		return err
+	}
+
+	if _, err := parseStorageClassRules(c.String("storage-class-rule")); err != nil {
+		return err
+	}
+
+	if _, err := parseQuota(c); err != nil {
+		return err
+	}
+
+	if _, err := parseAgeFilter(c); err != nil {
+		return err
+	}
+
+	if _, err := parseSizeFilter(c); err != nil {
+		return err
+	}
+
+	if c.String("storage-class-rule") != "" && !dsturl.IsRemote() {
+		return fmt.Errorf("storage-class-rule is only supported for remote destinations")
+	}
+
+	if c.Bool("bucket-key-enabled") && c.String("sse") != "aws:kms" {
+		return fmt.Errorf("bucket-key-enabled requires sse aws:kms")
+	}
+
+	if c.Bool("copy-tags") && (!srcurl.IsRemote() || !dsturl.IsRemote()) {
+		return fmt.Errorf("copy-tags is only supported for server-side copies")
+	}
+
+	if c.IsSet("flatten-collision") && !c.Bool("flatten") {
+		return fmt.Errorf("flatten-collision requires flatten flag")
+	}
+
+	if expr := c.String("rename"); expr != "" {
+		if _, err := parseRename(expr); err != nil {
+			return err
+		}
+		if c.String("archive") != "" {
+			return fmt.Errorf("rename can not be used with archive flag")
+		}
+	}
+
+	if c.Bool("verify-copy") && (!srcurl.IsRemote() || !dsturl.IsRemote()) {
+		return fmt.Errorf("verify-copy is only supported for server-side copies")
+	}
+
+	if c.Bool("verify") && (c.String("compress") != "" || c.Bool("decompress")) {
+		return fmt.Errorf("verify can not be used with compress or decompress flags")
+	}
+
+	if c.Bool("fsync") && (!srcurl.IsRemote() || dsturl.IsRemote()) {
+		return fmt.Errorf("fsync is only supported for downloads")
+	}
+
+	if c.String("tags") != "" && !dsturl.IsRemote() {
+		return fmt.Errorf("tags are only supported for remote destinations")
+	}
+
+	if c.String("website-redirect") != "" && !dsturl.IsRemote() {
+		return fmt.Errorf("website-redirect is only supported for remote destinations")
+	}
+
+	if c.Bool("sparse") {
+		if err := validateSparse(c, srcurl, dsturl); err != nil {
+			return err
+		}
+	}
+
+	if err := validateCompression(c, srcurl, dsturl); err != nil {
+		return err
+	}
+
+	if err := validateDecompression(c, srcurl, dsturl); err != nil {
+		return err
+	}
+
+	archive := c.String("archive") != ""
+	if archive {
+		if err := validateArchive(c, srcurl, dsturl); err != nil {
+			return err
+		}
+	}
+
+	if c.Bool("archive-member") {
+		if err := validateArchiveMember(c, srcurl, dsturl); err != nil {
+			return err
+		}
+	}
+
+	if c.String("range") != "" {
+		if err := validateByteRange(c, srcurl, dsturl); err != nil {
+			return err
+		}
+	}
+
+	if directive := c.String("metadata-directive"); directive != "" {
+		if !storage.MetadataDirective(strings.ToUpper(directive)).IsValid() {
+			return fmt.Errorf("unsupported metadata directive %q, expected COPY or REPLACE", directive)
+		}
+	}
+
 	// wildcard destination doesn't mean anything
 	if dsturl.IsWildcard() {
 		return fmt.Errorf("target %q can not contain glob characters", dst)
@@ -819,8 +1853,8 @@ func validateCopyCommand(c *cli.Context) error {
 	}
 
 	// 'cp dir/* s3://bucket/prefix': expect a trailing slash to avoid any
-	// surprises.
-	if srcurl.IsWildcard() && dsturl.IsRemote() && !dsturl.IsPrefix() && !dsturl.IsBucket() {
+	// surprises. The archives are single objects.
+	if srcurl.IsWildcard() && dsturl.IsRemote() && !dsturl.IsPrefix() && !dsturl.IsBucket() && !archive {
 		return fmt.Errorf("target %q must be a bucket or a prefix", dsturl)
 	}
 
@@ -828,12 +1862,138 @@ func validateCopyCommand(c *cli.Context) error {
 	case srcurl.Type == dsturl.Type:
 		return validateCopy(srcurl, dsturl)
 	case dsturl.IsRemote():
-		return validateUpload(ctx, srcurl, dsturl, NewStorageOpts(c))
+		return validateUpload(ctx, srcurl, dsturl, archive, NewStorageOpts(c))
 	default:
 		return nil
 	}
 }
 
+func validateCompression(c *cli.Context, srcurl, dsturl *url.URL) error {
+	compress := c.String("compress")
+	if compress == "" {
+		if c.String("compress-suffix") != "" {
+			return fmt.Errorf("compress-suffix requires compress flag")
+		}
+		return nil
+	}
+
+	if compress != compressionGzip {
+		return fmt.Errorf("unsupported compression %q, expected gzip", compress)
+	}
+
+	if srcurl.IsRemote() || !dsturl.IsRemote() {
+		return fmt.Errorf("compress is only supported for uploads")
+	}
+
+	if c.Bool("resume") {
+		return fmt.Errorf("compressed uploads can not be resumed")
+	}
+
+	// destination keys wouldn't match the source files, every file would be
+	// uploaded on each run and deleted with --delete.
+	if c.Command.Name == "sync" && c.String("compress-suffix") != "" {
+		return fmt.Errorf("compress-suffix is not supported by sync command")
+	}
+	return nil
+}
+
+func validateArchive(c *cli.Context, srcurl, dsturl *url.URL) error {
+	if archive := c.String("archive"); archive != archiveFormatTar {
+		return fmt.Errorf("unsupported archive format %q, expected tar", archive)
+	}
+
+	if srcurl.IsRemote() || !dsturl.IsRemote() {
+		return fmt.Errorf("archive is only supported for uploads")
+	}
+
+	if dsturl.IsBucket() || dsturl.IsPrefix() {
+		return fmt.Errorf("target %q must be an object for archive", dsturl)
+	}
+
+	if c.Command.Name != "cp" {
+		return fmt.Errorf("archive is only supported by cp command")
+	}
+
+	if c.String("compress") != "" || c.Bool("resume") {
+		return fmt.Errorf("archive can not be used with compress or resume flags")
+	}
+	return nil
+}
+
+func validateArchiveMember(c *cli.Context, srcurl, dsturl *url.URL) error {
+	if c.Command.Name != "cp" {
+		return fmt.Errorf("archive-member is only supported by cp command")
+	}
+
+	if !srcurl.IsRemote() || dsturl.IsRemote() {
+		return fmt.Errorf("archive-member is only supported for downloads")
+	}
+
+	if srcurl.IsWildcard() {
+		return fmt.Errorf("source %q can not contain glob characters with archive-member", srcurl)
+	}
+
+	if _, member, ok := splitArchiveMember(srcurl); !ok || member == "" {
+		return fmt.Errorf("source %q must be in 'archive::member' form", srcurl)
+	}
+
+	if c.String("compress") != "" || c.Bool("decompress") || c.Bool("resume") || c.String("range") != "" {
+		return fmt.Errorf("archive-member can not be used with compress, decompress, resume or range flags")
+	}
+	return nil
+}
+
+func validateByteRange(c *cli.Context, srcurl, dsturl *url.URL) error {
+	if _, err := parseByteRange(c.String("range")); err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() || dsturl.IsRemote() {
+		return fmt.Errorf("range is only supported for downloads")
+	}
+
+	if srcurl.IsWildcard() {
+		return fmt.Errorf("range is only supported for single object downloads")
+	}
+
+	if c.Command.Name != "cp" {
+		return fmt.Errorf("range is only supported by cp command")
+	}
+
+	if c.Bool("decompress") {
+		return fmt.Errorf("range can not be used with decompress flag")
+	}
+	return nil
+}
+
+func validateSparse(c *cli.Context, srcurl, dsturl *url.URL) error {
+	if srcurl.IsRemote() == dsturl.IsRemote() {
+		return fmt.Errorf("sparse is only supported for uploads and downloads")
+	}
+
+	if c.String("compress") != "" || c.Bool("decompress") || c.Bool("resume") || c.String("range") != "" {
+		return fmt.Errorf("sparse can not be used with compress, decompress, resume or range flags")
+	}
+	return nil
+}
+
+func validateDecompression(c *cli.Context, srcurl, dsturl *url.URL) error {
+	if !c.Bool("decompress") {
+		return nil
+	}
+
+	if !srcurl.IsRemote() || dsturl.IsRemote() {
+		return fmt.Errorf("decompress is only supported for downloads")
+	}
+
+	// sizes and names of the local files wouldn't match the objects, every
+	// object would be downloaded on each run and deleted with --delete.
+	if c.Command.Name == "sync" {
+		return fmt.Errorf("decompress is not supported by sync command")
+	}
+	return nil
+}
+
 func validateCopy(srcurl, dsturl *url.URL) error {
 	if srcurl.IsRemote() || dsturl.IsRemote() {
 		return nil
@@ -843,7 +2003,7 @@ func validateCopy(srcurl, dsturl *url.URL) error {
 	return fmt.Errorf("local->local copy operations are not permitted")
 }
 
-func validateUpload(ctx context.Context, srcurl, dsturl *url.URL, storageOpts storage.Options) error {
+func validateUpload(ctx context.Context, srcurl, dsturl *url.URL, archive bool, storageOpts storage.Options) error {
 	srcclient := storage.NewLocalClient(storageOpts)
 
 	if srcurl.IsWildcard() {
@@ -856,18 +2016,26 @@ func validateUpload(ctx context.Context, srcurl, dsturl *url.URL, storageOpts st
 	}
 
 	// 'cp dir/ s3://bucket/prefix-without-slash': expect a trailing slash to
-	// avoid any surprises.
-	if obj.Type.IsDir() && !dsturl.IsBucket() && !dsturl.IsPrefix() {
+	// avoid any surprises. The directories are archived to single objects.
+	if obj.Type.IsDir() && !dsturl.IsBucket() && !dsturl.IsPrefix() && !archive {
 		return fmt.Errorf("target %q must be a bucket or a prefix", dsturl)
 	}
 
 	return nil
 }
 
-// guessContentType gets content type of the file.
-func guessContentType(file *os.File) string {
-	contentType := mime.TypeByExtension(filepath.Ext(file.Name()))
-	if contentType == "" {
+// guessContentType gets content type of the file. The extension is looked up
+// in the given mapping first, and then in the system MIME types. If the
+// extension is unknown and sniff is set, the content type is detected from
+// the first 512 bytes of the file.
+func guessContentType(file *os.File, mapping map[string]string, sniff bool) string {
+	ext := strings.ToLower(filepath.Ext(file.Name()))
+	if contentType, ok := mapping[ext]; ok {
+		return contentType
+	}
+
+	contentType := mime.TypeByExtension(ext)
+	if contentType == "" && sniff {
 		defer file.Seek(0, io.SeekStart)
 
 		const bufsize = 512
@@ -880,3 +2048,59 @@ func guessContentType(file *os.File) string {
 	}
 	return contentType
 }
+
+// parseContentTypeMap parses the extension to content type mappings given in
+// "ext=type" form. Extensions are case-insensitive and the leading dot is
+// optional.
+func parseContentTypeMap(mappings []string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, mapping := range mappings {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid content type mapping %q, expected ext=type", mapping)
+		}
+
+		ext, contentType := parts[0], parts[1]
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return nil, fmt.Errorf("invalid content type %q: %v", contentType, err)
+		}
+
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		m[strings.ToLower(ext)] = contentType
+	}
+	return m, nil
+}
+
+// maxObjectTags is the maximum number of tags S3 allows on an object.
+const maxObjectTags = 10
+
+// parseTags parses tags in "key1=value1,key2=value2" format.
+func parseTags(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	tags := map[string]string{}
+	for _, tag := range strings.Split(value, ",") {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid tag %q, expected key=value", tag)
+		}
+
+		key, value := parts[0], parts[1]
+		if len(key) > 128 || len(value) > 256 {
+			return nil, fmt.Errorf("tag %q exceeds maximum length of 128 characters for keys and 256 characters for values", tag)
+		}
+		if _, ok := tags[key]; ok {
+			return nil, fmt.Errorf("duplicate tag key %q", key)
+		}
+		tags[key] = value
+	}
+
+	if len(tags) > maxObjectTags {
+		return nil, fmt.Errorf("number of tags exceeds the maximum of %d", maxObjectTags)
+	}
+	return tags, nil
+}
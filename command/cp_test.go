@@ -15,6 +15,8 @@ func TestGuessContentType(t *testing.T) {
 	testcases := []struct {
 		filename string
 		content  string
+		mapping  map[string]string
+		noSniff  bool
 
 		expectedContentType string
 	}{
@@ -41,6 +43,22 @@ func TestGuessContentType(t *testing.T) {
 					`,
 			expectedContentType: "text/html; charset=utf-8",
 		},
+		{
+			filename:            "*.MD",
+			mapping:             map[string]string{".md": "text/markdown"},
+			expectedContentType: "text/markdown",
+		},
+		{
+			filename:            "*.css",
+			mapping:             map[string]string{".css": "text/plain"},
+			expectedContentType: "text/plain",
+		},
+		{
+			filename:            "index",
+			content:             "<html></html>",
+			noSniff:             true,
+			expectedContentType: "",
+		},
 	}
 
 	for _, tc := range testcases {
@@ -56,9 +74,47 @@ func TestGuessContentType(t *testing.T) {
 			f.Seek(0, io.SeekStart)
 		}
 
-		assert.Equal(t, tc.expectedContentType, guessContentType(f))
+		assert.Equal(t, tc.expectedContentType, guessContentType(f, tc.mapping, !tc.noSniff))
 
 		f.Close()
 		os.Remove(f.Name())
 	}
 }
+
+func TestParseContentTypeMap(t *testing.T) {
+	t.Parallel()
+
+	m, err := parseContentTypeMap([]string{".md=text/markdown", "WASM=application/wasm"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		".md":   "text/markdown",
+		".wasm": "application/wasm",
+	}, m)
+
+	for _, mapping := range []string{".md", "=text/plain", ".md=", ".md=not a type"} {
+		_, err := parseContentTypeMap([]string{mapping})
+		assert.Error(t, err, mapping)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	t.Parallel()
+
+	tags, err := parseTags("team=data,env=prod,empty=")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"team":  "data",
+		"env":   "prod",
+		"empty": "",
+	}, tags)
+
+	tags, err = parseTags("")
+	assert.NoError(t, err)
+	assert.Nil(t, tags)
+
+	tooMany := "1=a,2=a,3=a,4=a,5=a,6=a,7=a,8=a,9=a,10=a,11=a"
+	for _, value := range []string{"team", "=data", "team=data,team=ops", tooMany} {
+		_, err := parseTags(value)
+		assert.Error(t, err, value)
+	}
+}
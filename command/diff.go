@@ -0,0 +1,365 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var diffHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] old-listing new-listing
+	{{.HelpName}} [options] --from time [--to time] source
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the objects added, changed and deleted between two listings saved with ls --json
+		 > s5cmd {{.HelpName}} yesterday.json today.json
+
+	2. Print the objects changed on a versioned bucket since the given time as JSON
+		 > s5cmd --json {{.HelpName}} --from 2021-06-01T00:00:00Z "s3://bucket/prefix/*"
+
+	3. Print the objects changed on a versioned bucket between the given times
+		 > s5cmd {{.HelpName}} --from 2021-06-01T00:00:00Z --to 2021-06-02T00:00:00Z "s3://bucket/*"
+`
+
+func NewDiffCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "diff",
+		HelpName:           "diff",
+		Usage:              "print the objects added, changed and deleted between two listings or two times of a versioned bucket",
+		CustomHelpTemplate: diffHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "compare the versions of the objects which were current at the given time on versioned buckets, e.g. 2021-06-01T00:00:00Z",
+			},
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "compare to the versions of the objects which were current at the given time instead of the current versions",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateDiffCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			// the times are validated before the command runs.
+			var from, to time.Time
+			if c.IsSet("from") {
+				from, _ = time.Parse(time.RFC3339, c.String("from"))
+			}
+			if c.IsSet("to") {
+				to, _ = time.Parse(time.RFC3339, c.String("to"))
+			}
+
+			return Diff{
+				args:        c.Args().Slice(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				from:        from,
+				to:          to,
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Diff holds diff operation flags and states.
+type Diff struct {
+	args        []string
+	op          string
+	fullCommand string
+
+	// from and to are the times of the versions compared on versioned
+	// buckets. A zero to time is the current versions.
+	from time.Time
+	to   time.Time
+
+	storageOpts storage.Options
+}
+
+// Run prints the objects which were added, changed or deleted between the
+// two listings.
+func (d Diff) Run(ctx context.Context) error {
+	var (
+		before, after []*storage.Object
+		err           error
+	)
+	if d.from.IsZero() {
+		before, after, err = d.readListings()
+	} else {
+		before, after, err = d.listVersions(ctx)
+	}
+	if err != nil {
+		printError(d.fullCommand, d.op, err)
+		return err
+	}
+
+	for _, msg := range diffObjects(before, after) {
+		msg.Operation = d.op
+		log.Info(msg)
+	}
+	return nil
+}
+
+// readListings reads the listings saved to the files of the arguments.
+func (d Diff) readListings() (before, after []*storage.Object, err error) {
+	before, err = readListingFile(d.args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err = readListingFile(d.args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// listVersions lists the versions of the objects of the source which were
+// current at the from and to times.
+func (d Diff) listVersions(ctx context.Context) (before, after []*storage.Object, err error) {
+	srcurl, err := url.New(d.args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, d.storageOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	collect := func(at time.Time) ([]*storage.Object, error) {
+		var objects []*storage.Object
+		for object := range client.ListAtTime(ctx, srcurl, at) {
+			// none of the objects existed at that time.
+			if object.Err == storage.ErrNoObjectFound {
+				continue
+			}
+			if object.Err != nil {
+				return nil, object.Err
+			}
+			objects = append(objects, object)
+		}
+		return objects, nil
+	}
+
+	before, err = collect(d.from)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err = collect(d.to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// listingObject is an object of a listing saved with ls --json.
+type listingObject struct {
+	Key          string     `json:"key"`
+	Type         string     `json:"type"`
+	Etag         string     `json:"etag"`
+	ModTime      *time.Time `json:"last_modified"`
+	Size         int64      `json:"size"`
+	StorageClass string     `json:"storage_class"`
+	VersionID    string     `json:"version_id"`
+}
+
+// readListingFile reads the objects of a listing saved with ls --json.
+// Directories and the lines without keys, e.g. the totals of ls --summarize,
+// are skipped.
+func readListingFile(path string) ([]*storage.Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	objects, err := readListing(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listing %q: %v", path, err)
+	}
+	return objects, nil
+}
+
+func readListing(r io.Reader) ([]*storage.Object, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var objects []*storage.Object
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var lo listingObject
+		if err := json.Unmarshal([]byte(line), &lo); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+		if lo.Key == "" || lo.Type == "directory" {
+			continue
+		}
+
+		objurl, err := url.New(lo.Key, url.WithRaw(true))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+
+		objects = append(objects, &storage.Object{
+			URL:          objurl,
+			Etag:         strings.Trim(lo.Etag, `"`),
+			ModTime:      lo.ModTime,
+			Size:         lo.Size,
+			StorageClass: storage.StorageClass(lo.StorageClass),
+			VersionID:    lo.VersionID,
+		})
+	}
+	return objects, scanner.Err()
+}
+
+// Changes of the objects between two listings.
+const (
+	diffAdded   = "added"
+	diffChanged = "changed"
+	diffDeleted = "deleted"
+)
+
+// DiffMessage is a change of an object between two listings. The object is
+// the new one for added and changed objects, and the old one for deleted
+// objects.
+type DiffMessage struct {
+	Operation string          `json:"operation"`
+	Change    string          `json:"change"`
+	Object    *storage.Object `json:"object"`
+}
+
+// String returns the string representation of DiffMessage.
+func (d DiffMessage) String() string {
+	return fmt.Sprintf("%v %v", d.Change, d.Object.URL)
+}
+
+// JSON returns the JSON representation of DiffMessage.
+func (d DiffMessage) JSON() string {
+	return strutil.JSON(d)
+}
+
+// diffObjects returns the changes between the objects of the old and the new
+// listings in the order of their keys. Objects are changed if their version
+// ids differ, or if their ETags or sizes differ when they have no version
+// ids.
+func diffObjects(before, after []*storage.Object) []DiffMessage {
+	index := func(objects []*storage.Object) map[string]*storage.Object {
+		m := make(map[string]*storage.Object, len(objects))
+		for _, object := range objects {
+			m[object.URL.Absolute()] = object
+		}
+		return m
+	}
+	oldObjects, newObjects := index(before), index(after)
+
+	var msgs []DiffMessage
+	for key, n := range newObjects {
+		o, ok := oldObjects[key]
+		switch {
+		case !ok:
+			msgs = append(msgs, DiffMessage{Change: diffAdded, Object: n})
+		case isObjectChanged(o, n):
+			msgs = append(msgs, DiffMessage{Change: diffChanged, Object: n})
+		}
+	}
+	for key, o := range oldObjects {
+		if _, ok := newObjects[key]; !ok {
+			msgs = append(msgs, DiffMessage{Change: diffDeleted, Object: o})
+		}
+	}
+
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Object.URL.Absolute() < msgs[j].Object.URL.Absolute()
+	})
+	return msgs
+}
+
+func isObjectChanged(before, after *storage.Object) bool {
+	if before.VersionID != "" && after.VersionID != "" {
+		return before.VersionID != after.VersionID
+	}
+	return before.Etag != after.Etag || before.Size != after.Size
+}
+
+func validateDiffCommand(c *cli.Context) error {
+	if !c.IsSet("from") {
+		if c.IsSet("to") {
+			return fmt.Errorf("to can only be used with from")
+		}
+		if c.Args().Len() != 2 {
+			return fmt.Errorf("expected old and new listing files")
+		}
+		for _, arg := range c.Args().Slice() {
+			if u, err := url.New(arg); err == nil && u.IsRemote() {
+				return fmt.Errorf("listings must be local files, use --from to compare the versions of remote objects")
+			}
+		}
+		return nil
+	}
+
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument with from")
+	}
+
+	var from, to time.Time
+	for _, flag := range []string{"from", "to"} {
+		if !c.IsSet(flag) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, c.String(flag))
+		if err != nil {
+			return fmt.Errorf("%v %q must be in RFC3339 format, e.g. 2021-06-01T00:00:00Z", flag, c.String(flag))
+		}
+		if flag == "from" {
+			from = t
+		} else {
+			to = t
+		}
+	}
+	if !to.IsZero() && !to.After(from) {
+		return fmt.Errorf("to must be after from")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be remote")
+	}
+	if !srcurl.IsWildcard() && (srcurl.IsBucket() || srcurl.IsPrefix()) {
+		return fmt.Errorf("source requires a wildcard or an object key, e.g. s3://bucket/prefix/*")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffListings(t *testing.T) {
+	t.Parallel()
+
+	before, err := readListing(strings.NewReader(`
+{"key":"s3://bucket/a.txt","type":"file","size":1,"etag":"a1"}
+{"key":"s3://bucket/b.txt","type":"file","size":2,"etag":"b1"}
+{"key":"s3://bucket/c.txt","type":"file","size":3,"etag":"c1"}
+{"key":"s3://bucket/dir/","type":"directory"}
+`))
+	assert.NoError(t, err)
+	assert.Len(t, before, 3)
+
+	after, err := readListing(strings.NewReader(`
+{"key":"s3://bucket/a.txt","type":"file","size":1,"etag":"a1"}
+{"key":"s3://bucket/b.txt","type":"file","size":2,"etag":"b2"}
+{"key":"s3://bucket/d.txt","type":"file","size":4,"etag":"d1"}
+{"source":"s3://bucket/*","count":3,"size":7}
+`))
+	assert.NoError(t, err)
+	assert.Len(t, after, 3)
+
+	var got []string
+	for _, msg := range diffObjects(before, after) {
+		got = append(got, msg.String())
+	}
+	assert.Equal(t, []string{
+		"changed s3://bucket/b.txt",
+		"deleted s3://bucket/c.txt",
+		"added s3://bucket/d.txt",
+	}, got)
+}
+
+func TestDiffVersions(t *testing.T) {
+	t.Parallel()
+
+	before, err := readListing(strings.NewReader(`{"key":"s3://bucket/a.txt","size":1,"etag":"a1","version_id":"v1"}`))
+	assert.NoError(t, err)
+
+	// the object is overwritten with the same content.
+	after, err := readListing(strings.NewReader(`{"key":"s3://bucket/a.txt","size":1,"etag":"a1","version_id":"v2"}`))
+	assert.NoError(t, err)
+
+	msgs := diffObjects(before, after)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, diffChanged, msgs[0].Change)
+	assert.Equal(t, "v2", msgs[0].Object.VersionID)
+}
+
+func TestReadListingInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := readListing(strings.NewReader("{\"key\":\"s3://bucket/a.txt\"}\nfoo"))
+	assert.EqualError(t, err, "line 2: invalid character 'o' in literal false (expecting 'a')")
+}
@@ -3,6 +3,12 @@ package command
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
@@ -33,8 +39,24 @@ Examples:
 
 	3. Show disk usage of all objects in a bucket but exclude the ones with py extension or starts with main
 		 > s5cmd {{.HelpName}} --exclude "*.py" --exclude "main*" s3://bucket/*
+
+	4. Show disk usage of all objects in a bucket in SI units, with thousands separators in object count
+		 > s5cmd {{.HelpName}} --humanize --si s3://bucket/*
+
+	5. Show disk usage of all objects in a bucket in powers of 1024 with 3 decimal places
+		 > s5cmd {{.HelpName}} --humanize=iec --precision 3 s3://bucket/*
+
+	6. Show disk usage of a huge prefix, listing 64 of its common prefixes concurrently and printing the progress every 30 seconds
+		 > s5cmd {{.HelpName}} --concurrency 64 --progress --progress-interval 30s "s3://bucket/logs/*"
+
+	7. Show the 20 largest prefixes of a bucket up to 2 levels deep, followed by the total
+		 > s5cmd {{.HelpName}} --depth 2 --top 20 "s3://bucket/*"
 `
 
+// defaultSizeConcurrency is the default number of the common prefixes of the
+// wildcard sources of du listed concurrently.
+const defaultSizeConcurrency = 16
+
 func NewSizeCommand() *cli.Command {
 	return &cli.Command{
 		Name:               "du",
@@ -45,16 +67,49 @@ func NewSizeCommand() *cli.Command {
 			&cli.BoolFlag{
 				Name:    "group",
 				Aliases: []string{"g"},
-				Usage:   "group sizes by storage class",
+				Usage:   "group sizes and object counts by storage class, followed by their total if there are multiple storage classes",
 			},
-			&cli.BoolFlag{
+			&cli.GenericFlag{
 				Name:    "humanize",
 				Aliases: []string{"H"},
-				Usage:   "human-readable output for object sizes",
+				Value:   &humanizeValue{},
+				Usage:   "human-readable output for object sizes and counts in iec (powers of 1024), si (powers of 1000) or raw units, e.g. --humanize=si; iec if given without a value",
+			},
+			&cli.BoolFlag{
+				Name:  "si",
+				Usage: "use powers of 1000 instead of 1024 for human-readable sizes, same as --humanize=si",
+			},
+			&cli.IntFlag{
+				Name:  "precision",
+				Value: defaultSizePrecision,
+				Usage: "number of decimal places of human-readable sizes",
 			},
 			&cli.StringSliceFlag{
 				Name:  "exclude",
-				Usage: "exclude objects with given pattern",
+				Usage: "exclude objects with given pattern from the totals, e.g. \"_tmp/*\" or \"*.partial\"; the common prefixes of wildcard sources whose objects are all excluded are not listed",
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"c"},
+				Value:   defaultSizeConcurrency,
+				Usage:   "number of common prefixes of wildcard sources listed concurrently",
+			},
+			&cli.BoolFlag{
+				Name:  "progress",
+				Usage: "periodically print the number and size of the objects counted so far to stderr",
+			},
+			&cli.DurationFlag{
+				Name:  "progress-interval",
+				Value: defaultSizeProgressInterval,
+				Usage: "duration between progress reports, requires --progress",
+			},
+			&cli.IntFlag{
+				Name:  "depth",
+				Usage: "aggregate sizes and object counts by key prefixes up to the given number of levels under the source, followed by their total",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "print only the given number of the largest prefixes, implies --depth 1 if depth is not given",
 			},
 		},
 		Before: func(c *cli.Context) error {
@@ -67,14 +122,24 @@ func NewSizeCommand() *cli.Command {
 		Action: func(c *cli.Context) (err error) {
 			defer stat.Collect(c.Command.FullName(), &err)()
 
+			depth := c.Int("depth")
+			if depth == 0 && c.Int("top") > 0 {
+				depth = 1
+			}
+
 			return Size{
 				src:         c.Args().First(),
 				op:          c.Command.Name,
 				fullCommand: commandFromContext(c),
 				// flags
 				groupByClass: c.Bool("group"),
-				humanize:     c.Bool("humanize"),
+				sizes:        newSizeFormat(c.String("humanize"), c.Bool("si"), c.Int("precision")),
 				exclude:      c.StringSlice("exclude"),
+				concurrency:  c.Int("concurrency"),
+				progress:     c.Bool("progress"),
+				interval:     c.Duration("progress-interval"),
+				depth:        depth,
+				top:          c.Int("top"),
 
 				storageOpts: NewStorageOpts(c),
 			}.Run(c.Context)
@@ -90,8 +155,13 @@ type Size struct {
 
 	// flags
 	groupByClass bool
-	humanize     bool
+	sizes        sizeFormat
 	exclude      []string
+	concurrency  int
+	progress     bool
+	interval     time.Duration
+	depth        int
+	top          int
 
 	storageOpts storage.Options
 }
@@ -109,80 +179,243 @@ func (sz Size) Run(ctx context.Context) error {
 		return err
 	}
 
-	storageTotal := map[string]sizeAndCount{}
-	total := sizeAndCount{}
-
-	var merror error
-
 	excludePatterns, err := createExcludesFromWildcard(sz.exclude)
 	if err != nil {
 		printError(sz.fullCommand, sz.op, err)
 		return err
 	}
 
-	for object := range client.List(ctx, srcurl, false) {
-		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
-			continue
-		}
-
-		if err := object.Err; err != nil {
-			merror = multierror.Append(merror, err)
-			printError(sz.fullCommand, sz.op, err)
-			continue
-		}
+	progress := &sizeProgress{source: srcurl.String(), sizes: sz.sizes}
+	if sz.progress {
+		defer progress.report(sz.interval)()
+	}
 
-		if isURLExcluded(excludePatterns, object.URL.Path, srcurl.Prefix) {
-			continue
-		}
+	totals := sz.count(ctx, client, srcurl, excludePatterns, progress)
+	merror := totals.err
 
-		storageClass := string(object.StorageClass)
-		s := storageTotal[storageClass]
-		s.addObject(object)
-		storageTotal[storageClass] = s
+	// the totals of an interrupted listing are printed, but they are
+	// partial, and the command fails with the cancellation error.
+	if err := ctx.Err(); err != nil {
+		printWarning(sz.op, progress.interrupted(), srcurl)
+		merror = multierror.Append(merror, err)
+	}
 
-		total.addObject(object)
+	if sz.depth > 0 {
+		for _, msg := range totals.messagesByPrefix(sz.top, sz.sizes) {
+			log.Info(msg)
+		}
+		log.Info(SizeMessage{
+			Source: srcurl.String(),
+			Count:  totals.total.count,
+			Size:   totals.total.size,
+			Total:  true,
+			sizes:  sz.sizes,
+		})
+		return merror
 	}
 
 	if !sz.groupByClass {
 		msg := SizeMessage{
-			Source:        srcurl.String(),
-			Count:         total.count,
-			Size:          total.size,
-			showHumanized: sz.humanize,
+			Source: srcurl.String(),
+			Count:  totals.total.count,
+			Size:   totals.total.size,
+			sizes:  sz.sizes,
 		}
 		log.Info(msg)
-		return nil
+		return ctx.Err()
 	}
 
-	for k, v := range storageTotal {
-		msg := SizeMessage{
-			Source:        srcurl.String(),
-			StorageClass:  k,
-			Count:         v.count,
-			Size:          v.size,
-			showHumanized: sz.humanize,
-		}
+	for _, msg := range totals.messagesByClass(srcurl.String(), sz.sizes) {
 		log.Info(msg)
 	}
 	return merror
 }
 
+// count lists the objects of the source and returns their totals. The
+// wildcard sources are listed under each of the common prefixes of their
+// literal prefix concurrently, and the objects right under the prefix are
+// counted while the common prefixes are listed.
+func (sz Size) count(
+	ctx context.Context,
+	client storage.Storage,
+	srcurl *url.URL,
+	excludePatterns []*regexp.Regexp,
+	progress *sizeProgress,
+) *sizeTotals {
+	totals := newSizeTotals()
+
+	if !isPartitionable(srcurl) {
+		progress.addPrefix()
+		for object := range client.List(ctx, srcurl, false) {
+			sz.countObject(object, srcurl, excludePatterns, totals, progress)
+		}
+		progress.prefixDone()
+		return totals
+	}
+
+	prefixurl, err := srcurl.PrefixURL()
+	if err != nil {
+		totals.addError(err)
+		printError(sz.fullCommand, sz.op, err)
+		return totals
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		found    int32
+		prefixCh = make(chan string)
+	)
+
+	// countListing counts the objects of the given listing. The listings
+	// without objects are not errors unless all of them are.
+	countListing := func(objects <-chan *storage.Object, partial *sizeTotals) {
+		for object := range objects {
+			if object.Err == storage.ErrNoObjectFound {
+				continue
+			}
+			if object.Err == nil && !object.Type.IsDir() {
+				atomic.StoreInt32(&found, 1)
+			}
+			sz.countObject(object, srcurl, excludePatterns, partial, progress)
+		}
+
+		mu.Lock()
+		totals.merge(partial)
+		mu.Unlock()
+	}
+
+	for i := 0; i < sz.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for prefix := range prefixCh {
+				partition := srcurl.WithListPrefix(prefix)
+				countListing(client.List(ctx, partition, false), newSizeTotals())
+				progress.prefixDone()
+			}
+		}()
+	}
+
+	// the common prefixes are sent to the workers, and the objects right
+	// under the prefix which match the source are counted.
+	matcher := srcurl.Clone()
+	objects := make(chan *storage.Object)
+	go func() {
+		defer close(objects)
+		defer close(prefixCh)
+		for object := range client.List(ctx, prefixurl, false) {
+			if object.Err != nil {
+				objects <- object
+				continue
+			}
+			if object.Type.IsDir() && object.URL.Path != prefixurl.Prefix {
+				// the prefixes whose objects are all excluded, e.g.
+				// temporary ones, are not listed at all.
+				if isPrefixExcluded(excludePatterns, object.URL.Path, srcurl.Prefix) {
+					atomic.StoreInt32(&found, 1)
+					continue
+				}
+				progress.addPrefix()
+				prefixCh <- object.URL.Path
+				continue
+			}
+			if matcher.Match(object.URL.Path) {
+				objects <- object
+			}
+		}
+	}()
+	countListing(objects, newSizeTotals())
+
+	wg.Wait()
+
+	if atomic.LoadInt32(&found) == 0 && totals.err == nil && ctx.Err() == nil {
+		totals.addError(storage.ErrNoObjectFound)
+		printError(sz.fullCommand, sz.op, storage.ErrNoObjectFound)
+	}
+	return totals
+}
+
+// countObject adds the given object of a listing of the source to the totals
+// unless it is excluded. The errors of the listing are printed.
+func (sz Size) countObject(
+	object *storage.Object,
+	srcurl *url.URL,
+	excludePatterns []*regexp.Regexp,
+	totals *sizeTotals,
+	progress *sizeProgress,
+) {
+	if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
+		return
+	}
+
+	if err := object.Err; err != nil {
+		totals.addError(err)
+		printError(sz.fullCommand, sz.op, err)
+		return
+	}
+
+	if isURLExcluded(excludePatterns, object.URL.Path, srcurl.Prefix) {
+		return
+	}
+
+	totals.addObject(object)
+	if sz.depth > 0 {
+		totals.addPrefix(objectPrefix(object.URL, srcurl.Prefix, sz.depth), object)
+	}
+	progress.addObject(object)
+}
+
+// isPartitionable reports whether the objects of the URL can be listed under
+// each of the common prefixes of its literal prefix. The brace groups and the
+// bucket wildcards are listed under multiple prefixes or buckets already.
+func isPartitionable(u *url.URL) bool {
+	return u.IsRemote() && u.IsWildcard() && !u.IsBucketWildcard() && len(u.ListPrefixes()) == 1
+}
+
+// objectPrefix returns the URL of the key prefix of the object up to the given
+// number of levels under the literal prefix of the source. The objects right
+// under the literal prefix are grouped under the literal prefix itself.
+func objectPrefix(u *url.URL, base string, depth int) string {
+	dirs := strings.Split(strings.TrimPrefix(u.Path, base), "/")
+	dirs = dirs[:len(dirs)-1]
+	if len(dirs) > depth {
+		dirs = dirs[:depth]
+	}
+
+	prefix := u.Clone()
+	prefix.Path = base
+	if len(dirs) > 0 {
+		prefix.Path += strings.Join(dirs, "/") + "/"
+	}
+	prefix.VersionID = ""
+	return prefix.String()
+}
+
 // SizeMessage is the structure for logging disk usage.
 type SizeMessage struct {
 	Source       string `json:"source"`
 	StorageClass string `json:"storage_class,omitempty"`
 	Count        int64  `json:"count"`
 	Size         int64  `json:"size"`
+	// Total marks the total of the storage classes or the prefixes.
+	Total bool `json:"total,omitempty"`
 
-	showHumanized bool
+	sizes sizeFormat
 }
 
 // humanize is a helper method to humanize bytes.
 func (s SizeMessage) humanize() string {
-	if s.showHumanized {
-		return strutil.HumanizeBytes(s.Size)
+	return s.sizes.format(s.Size)
+}
+
+// humanizeCount is a helper method to add thousands separators to the
+// object count.
+func (s SizeMessage) humanizeCount() string {
+	if s.sizes.humanized() {
+		return strutil.HumanizeCount(s.Count)
 	}
-	return fmt.Sprintf("%d", s.Size)
+	return fmt.Sprintf("%d", s.Count)
 }
 
 // String returns the string representation of SizeMessage.
@@ -192,9 +425,9 @@ func (s SizeMessage) String() string {
 		storageCls = fmt.Sprintf(" [%s]", s.StorageClass)
 	}
 	return fmt.Sprintf(
-		"%s bytes in %d objects: %s%s",
+		"%s bytes in %s objects: %s%s",
 		s.humanize(),
-		s.Count,
+		s.humanizeCount(),
 		s.Source,
 		storageCls,
 	)
@@ -215,9 +448,149 @@ func (s *sizeAndCount) addObject(obj *storage.Object) {
 	s.count++
 }
 
+// sizeTotals is the total size and count of the objects of a listing, and by
+// their storage classes and key prefixes.
+type sizeTotals struct {
+	total    sizeAndCount
+	byClass  map[string]sizeAndCount
+	byPrefix map[string]sizeAndCount
+	err      error
+}
+
+func newSizeTotals() *sizeTotals {
+	return &sizeTotals{
+		byClass:  map[string]sizeAndCount{},
+		byPrefix: map[string]sizeAndCount{},
+	}
+}
+
+// addPrefix adds the object to the totals of the given key prefix.
+func (t *sizeTotals) addPrefix(prefix string, obj *storage.Object) {
+	s := t.byPrefix[prefix]
+	s.addObject(obj)
+	t.byPrefix[prefix] = s
+}
+
+func (t *sizeTotals) addObject(obj *storage.Object) {
+	storageClass := string(obj.StorageClass)
+	s := t.byClass[storageClass]
+	s.addObject(obj)
+	t.byClass[storageClass] = s
+
+	t.total.addObject(obj)
+}
+
+// messagesByClass returns the totals of the storage classes in a stable
+// order, followed by the total of all of them if there are multiple storage
+// classes.
+func (t *sizeTotals) messagesByClass(source string, sizes sizeFormat) []SizeMessage {
+	storageClasses := make([]string, 0, len(t.byClass))
+	for storageClass := range t.byClass {
+		storageClasses = append(storageClasses, storageClass)
+	}
+	sort.Strings(storageClasses)
+
+	var msgs []SizeMessage
+	for _, storageClass := range storageClasses {
+		v := t.byClass[storageClass]
+		msgs = append(msgs, SizeMessage{
+			Source:       source,
+			StorageClass: storageClass,
+			Count:        v.count,
+			Size:         v.size,
+			sizes:        sizes,
+		})
+	}
+
+	if len(storageClasses) > 1 {
+		msgs = append(msgs, SizeMessage{
+			Source: source,
+			Count:  t.total.count,
+			Size:   t.total.size,
+			Total:  true,
+			sizes:  sizes,
+		})
+	}
+	return msgs
+}
+
+// messagesByPrefix returns the totals of the key prefixes from the largest to
+// the smallest, limited to the given number of the prefixes if it is
+// positive.
+func (t *sizeTotals) messagesByPrefix(top int, sizes sizeFormat) []SizeMessage {
+	msgs := make([]SizeMessage, 0, len(t.byPrefix))
+	for prefix, v := range t.byPrefix {
+		msgs = append(msgs, SizeMessage{
+			Source: prefix,
+			Count:  v.count,
+			Size:   v.size,
+			sizes:  sizes,
+		})
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if msgs[i].Size != msgs[j].Size {
+			return msgs[i].Size > msgs[j].Size
+		}
+		return msgs[i].Source < msgs[j].Source
+	})
+
+	if top > 0 && len(msgs) > top {
+		msgs = msgs[:top]
+	}
+	return msgs
+}
+
+func (t *sizeTotals) addError(err error) {
+	t.err = multierror.Append(t.err, err)
+}
+
+// merge adds the totals of another listing.
+func (t *sizeTotals) merge(other *sizeTotals) {
+	for storageClass, v := range other.byClass {
+		s := t.byClass[storageClass]
+		s.size += v.size
+		s.count += v.count
+		t.byClass[storageClass] = s
+	}
+	for prefix, v := range other.byPrefix {
+		s := t.byPrefix[prefix]
+		s.size += v.size
+		s.count += v.count
+		t.byPrefix[prefix] = s
+	}
+	t.total.size += other.total.size
+	t.total.count += other.total.count
+	if other.err != nil {
+		t.err = multierror.Append(t.err, other.err)
+	}
+}
+
 func validateDUCommand(c *cli.Context) error {
 	if c.Args().Len() != 1 {
 		return fmt.Errorf("expected only 1 argument")
 	}
+	if c.Int("concurrency") < 1 {
+		return fmt.Errorf("concurrency must be a positive integer")
+	}
+	if c.IsSet("progress-interval") {
+		if !c.Bool("progress") {
+			return fmt.Errorf("progress-interval requires --progress")
+		}
+		if c.Duration("progress-interval") <= 0 {
+			return fmt.Errorf("progress-interval must be positive")
+		}
+	}
+	if c.IsSet("depth") && c.Int("depth") < 1 {
+		return fmt.Errorf("depth must be a positive integer")
+	}
+	if c.IsSet("top") && c.Int("top") < 1 {
+		return fmt.Errorf("top must be a positive integer")
+	}
+	if c.Bool("group") && (c.IsSet("depth") || c.IsSet("top")) {
+		return fmt.Errorf("depth and top can not be used with --group")
+	}
+	if err := validateSizeFormat(c.String("humanize"), c.Int("precision")); err != nil {
+		return err
+	}
 	return nil
 }
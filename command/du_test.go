@@ -0,0 +1,72 @@
+package command
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestSizeRunInterrupted(t *testing.T) {
+	log.Init("error", false)
+
+	dir, err := ioutil.TempDir("", "du")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("content"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, groupByClass := range []bool{false, true} {
+		sz := Size{
+			src:          filepath.Join(dir, "*"),
+			op:           "du",
+			fullCommand:  "du",
+			groupByClass: groupByClass,
+			concurrency:  1,
+		}
+		err := sz.Run(ctx)
+		assert.True(t, errorpkg.IsCancelation(err), "unexpected error %v", err)
+	}
+}
+
+func TestSizeTotalsMessagesByClass(t *testing.T) {
+	t.Parallel()
+
+	totals := newSizeTotals()
+	totals.addObject(&storage.Object{Size: 10, StorageClass: "STANDARD_IA"})
+	totals.addObject(&storage.Object{Size: 20, StorageClass: "STANDARD"})
+	totals.addObject(&storage.Object{Size: 30, StorageClass: "GLACIER"})
+	totals.addObject(&storage.Object{Size: 40, StorageClass: "STANDARD_IA"})
+
+	var lines []string
+	for _, msg := range totals.messagesByClass("s3://bucket/*", sizeFormat{}) {
+		lines = append(lines, msg.String())
+	}
+	assert.Equal(t, []string{
+		"30 bytes in 1 objects: s3://bucket/* [GLACIER]",
+		"20 bytes in 1 objects: s3://bucket/* [STANDARD]",
+		"50 bytes in 2 objects: s3://bucket/* [STANDARD_IA]",
+		"100 bytes in 4 objects: s3://bucket/*",
+	}, lines)
+
+	// the total is marked in JSON.
+	msgs := totals.messagesByClass("s3://bucket/*", sizeFormat{})
+	assert.JSONEq(t, `{"source":"s3://bucket/*","storage_class":"GLACIER","count":1,"size":30}`, msgs[0].JSON())
+	assert.JSONEq(t, `{"source":"s3://bucket/*","count":4,"size":100,"total":true}`, msgs[3].JSON())
+
+	// the total is not repeated for a single storage class.
+	totals = newSizeTotals()
+	totals.addObject(&storage.Object{Size: 10, StorageClass: "STANDARD"})
+	msgs = totals.messagesByClass("s3://bucket/*", sizeFormat{})
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, "STANDARD", msgs[0].StorageClass)
+}
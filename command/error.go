@@ -25,6 +25,22 @@ func printDebug(op string, err error, urls ...*url.URL) {
 	log.Debug(msg)
 }
 
+// printWarning is the helper function to log warning messages of operations
+// that are skipped without failing the command.
+func printWarning(op string, warning string, urls ...*url.URL) {
+	command := op
+	for _, url := range urls {
+		command += fmt.Sprintf(" %s", url)
+	}
+
+	msg := log.WarningMessage{
+		Command:   command,
+		Operation: op,
+		Warning:   warning,
+	}
+	log.Warning(msg)
+}
+
 // printError is the helper function to log error messages.
 func printError(command, op string, err error) {
 	// dont print cancelation errors
@@ -30,19 +30,56 @@ func createExcludesFromWildcard(inputExcludes []string) ([]*regexp.Regexp, error
 	return result, nil
 }
 
+// createIncludesFromWildcard creates regex strings from include wildcards.
+func createIncludesFromWildcard(inputIncludes []string) ([]*regexp.Regexp, error) {
+	return createExcludesFromWildcard(inputIncludes)
+}
+
 // isURLExcluded checks whether given urlPath matches any of the exclude patterns.
 func isURLExcluded(excludePatterns []*regexp.Regexp, urlPath, sourcePrefix string) bool {
 	if len(excludePatterns) == 0 {
 		return false
 	}
+	return matchesAnyPattern(excludePatterns, urlPath, sourcePrefix)
+}
+
+// isURLIncluded checks whether given urlPath matches any of the include
+// patterns. All paths are included if there is no include pattern.
+func isURLIncluded(includePatterns []*regexp.Regexp, urlPath, sourcePrefix string) bool {
+	if len(includePatterns) == 0 {
+		return true
+	}
+	return matchesAnyPattern(includePatterns, urlPath, sourcePrefix)
+}
+
+// matchesAnyPattern checks whether given urlPath, relative to the source
+// prefix, matches any of the patterns.
+func matchesAnyPattern(patterns []*regexp.Regexp, urlPath, sourcePrefix string) bool {
 	if !strings.HasSuffix(sourcePrefix, "/") {
 		sourcePrefix += "/"
 	}
 	sourcePrefix = filepath.ToSlash(sourcePrefix)
-	for _, excludePattern := range excludePatterns {
-		if excludePattern.MatchString(strings.TrimPrefix(urlPath, sourcePrefix)) {
+	for _, pattern := range patterns {
+		if pattern.MatchString(strings.TrimPrefix(urlPath, sourcePrefix)) {
 			return true
 		}
 	}
 	return false
 }
+
+// isPrefixExcluded reports whether all of the paths under the given prefix
+// are excluded, e.g. "_tmp/" by "_tmp/*", so that the prefix doesn't need to
+// be listed. Only the patterns ending with a "*" exclude the paths of any
+// suffix.
+func isPrefixExcluded(excludePatterns []*regexp.Regexp, prefix, sourcePrefix string) bool {
+	var patterns []*regexp.Regexp
+	for _, pattern := range excludePatterns {
+		if strings.HasSuffix(pattern.String(), ".*$") {
+			patterns = append(patterns, pattern)
+		}
+	}
+	if len(patterns) == 0 {
+		return false
+	}
+	return matchesAnyPattern(patterns, prefix, sourcePrefix)
+}
@@ -33,3 +33,111 @@ func Test_wildCardToRegexp(t *testing.T) {
 		})
 	}
 }
+
+func Test_isURLIncluded(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		includes []string
+		urlPath  string
+		prefix   string
+		wanted   bool
+	}{
+		{
+			name:    "no include pattern",
+			urlPath: "prefix/file.txt",
+			prefix:  "prefix",
+			wanted:  true,
+		},
+		{
+			name:     "matching pattern",
+			includes: []string{"*.tmp"},
+			urlPath:  "prefix/dir/file.tmp",
+			prefix:   "prefix/",
+			wanted:   true,
+		},
+		{
+			name:     "not matching pattern",
+			includes: []string{"*.tmp"},
+			urlPath:  "prefix/file.txt",
+			prefix:   "prefix/",
+			wanted:   false,
+		},
+		{
+			name:     "pattern is relative to prefix",
+			includes: []string{"file*"},
+			urlPath:  "prefix/file.txt",
+			prefix:   "prefix",
+			wanted:   true,
+		},
+		{
+			name:     "empty pattern",
+			includes: []string{""},
+			urlPath:  "prefix/file.txt",
+			prefix:   "prefix",
+			wanted:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := createIncludesFromWildcard(tt.includes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isURLIncluded(patterns, tt.urlPath, tt.prefix); got != tt.wanted {
+				t.Errorf("isURLIncluded() = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
+
+func Test_isPrefixExcluded(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		excludes     []string
+		prefix       string
+		sourcePrefix string
+		wanted       bool
+	}{
+		{
+			name:         "prefix with trailing wildcard",
+			excludes:     []string{"_tmp/*"},
+			prefix:       "data/_tmp/",
+			sourcePrefix: "data/",
+			wanted:       true,
+		},
+		{
+			name:         "any prefix with trailing wildcard",
+			excludes:     []string{"*_tmp*"},
+			prefix:       "data/2020_tmp/",
+			sourcePrefix: "data/",
+			wanted:       true,
+		},
+		{
+			name:         "extension",
+			excludes:     []string{"*.partial"},
+			prefix:       "data/a.partial/",
+			sourcePrefix: "data/",
+			wanted:       false,
+		},
+		{
+			name:         "other prefix",
+			excludes:     []string{"_tmp/*"},
+			prefix:       "data/logs/",
+			sourcePrefix: "data/",
+			wanted:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := createExcludesFromWildcard(tt.excludes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isPrefixExcluded(patterns, tt.prefix, tt.sourcePrefix); got != tt.wanted {
+				t.Errorf("isPrefixExcluded() = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kballard/go-shellquote"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var findHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Find all parquet files under a prefix
+		 > s5cmd {{.HelpName}} --name "*.parquet" s3://bucket/prefix/
+
+	2. Find the objects larger than 1GB which are modified in the last 7 days
+		 > s5cmd {{.HelpName}} --size +1G --mtime -7d s3://bucket/
+
+	3. Find the objects between 1MB and 10MB in size
+		 > s5cmd {{.HelpName}} --size +1M --size -10M s3://bucket/
+
+	4. Find the archived objects which are modified more than 30 days ago
+		 > s5cmd {{.HelpName}} --storage-class GLACIER --mtime +30d s3://bucket/logs/
+
+	5. Download the parquet files under a prefix, the matched objects replace {} in the command
+		 > s5cmd {{.HelpName}} --name "*.parquet" --exec "cp {} ./out/" s3://bucket/prefix/
+
+	6. Find the log files in a local directory
+		 > s5cmd {{.HelpName}} --name "*.log" dir/
+`
+
+func NewFindCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "find",
+		HelpName:           "find",
+		Usage:              "find objects matching all of the given predicates",
+		CustomHelpTemplate: findHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "match the base names of the objects against given wildcard, e.g. '*.parquet'",
+			},
+			&cli.StringSliceFlag{
+				Name:  "size",
+				Usage: "match the objects larger (+) or smaller (-) than, or exactly of given size, e.g. +1G or -512K",
+			},
+			&cli.StringSliceFlag{
+				Name:  "mtime",
+				Usage: "match the objects modified more (+) or less (-) than given time ago, e.g. +30d or -12h",
+			},
+			&cli.StringFlag{
+				Name:  "storage-class",
+				Usage: "match the objects of given storage class, e.g. GLACIER",
+			},
+			&cli.StringFlag{
+				Name:  "exec",
+				Usage: "run given command for each of the matched objects instead of printing them, {} is replaced by the object, e.g. 'cp {} ./out/'",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateFindCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			// the predicates and the command are validated before the command
+			// runs.
+			expr, _ := parseFindExpression(c, time.Now())
+
+			var exec []string
+			if c.IsSet("exec") {
+				exec, _ = shellquote.Split(c.String("exec"))
+			}
+
+			return Find{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				c:           c,
+				expr:        expr,
+				exec:        exec,
+				numWorkers:  c.Int("numworkers"),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Find holds find operation flags and states.
+type Find struct {
+	src         string
+	op          string
+	fullCommand string
+	c           *cli.Context
+
+	// flags
+	expr       findExpression
+	exec       []string
+	numWorkers int
+
+	storageOpts storage.Options
+}
+
+// Run prints the objects at given source which match the expression, or runs
+// the command for each of them.
+func (f Find) Run(ctx context.Context) error {
+	srcurl, err := url.New(findSource(f.src))
+	if err != nil {
+		printError(f.fullCommand, f.op, err)
+		return err
+	}
+
+	client, err := storage.NewClient(ctx, srcurl, f.storageOpts)
+	if err != nil {
+		printError(f.fullCommand, f.op, err)
+		return err
+	}
+
+	// the commands run by a separate pool, since they run their own tasks in
+	// the shared one.
+	pm := parallel.New(f.numWorkers)
+	defer pm.Close()
+
+	waiter := parallel.NewWaiter()
+
+	var (
+		merrorWaiter  error
+		merrorObjects error
+		errDone       = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			// the commands print their own errors.
+			merrorWaiter = multierror.Append(merrorWaiter, err)
+		}
+	}()
+
+	for object := range client.List(ctx, srcurl, false) {
+		if errorpkg.IsCancelation(object.Err) {
+			continue
+		}
+
+		if err := object.Err; err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(f.fullCommand, f.op, err)
+			continue
+		}
+
+		if object.Type.IsDir() || !f.expr.match(object) {
+			continue
+		}
+
+		if len(f.exec) == 0 {
+			log.Info(FindMessage{Object: object})
+			continue
+		}
+
+		fields, err := execFields(f.exec, object.URL)
+		if err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(f.fullCommand, f.op, err)
+			continue
+		}
+		task := func() error {
+			return runCommandLine(f.c, fields)
+		}
+		pm.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+}
+
+// findSource returns the source which lists all of the objects under the
+// given prefix or directory, unless the source has wildcards already.
+func findSource(src string) string {
+	if strings.ContainsAny(src, "*?[") {
+		return src
+	}
+	if strings.HasSuffix(src, "/") {
+		return src + "*"
+	}
+	return src + "/*"
+}
+
+// execFields returns the fields of the command to run for the object, with
+// {} replaced by the object. The objects with glob characters in their keys
+// are passed with --raw, so that the command doesn't expand them again.
+func execFields(exec []string, object *url.URL) ([]string, error) {
+	fields := make([]string, 0, len(exec)+1)
+	fields = append(fields, exec[0])
+
+	if u, err := url.New(object.String()); err == nil && u.IsWildcard() {
+		if !hasFlag(AppCommand(exec[0]), "raw") {
+			return nil, fmt.Errorf("exec: %q command can not be run for %q, its key contains glob characters", exec[0], object)
+		}
+		fields = append(fields, "--raw")
+	}
+
+	for _, field := range exec[1:] {
+		fields = append(fields, strings.Replace(field, "{}", object.String(), -1))
+	}
+	return fields, nil
+}
+
+// hasFlag reports whether the command has a flag with the given name.
+func hasFlag(cmd *cli.Command, name string) bool {
+	if cmd == nil {
+		return false
+	}
+	for _, cmdFlag := range cmd.Flags {
+		for _, flagName := range cmdFlag.Names() {
+			if flagName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FindMessage is a structure for logging the matched objects.
+type FindMessage struct {
+	Object *storage.Object `json:"object"`
+}
+
+// String returns the string representation of FindMessage.
+func (m FindMessage) String() string {
+	return m.Object.URL.String()
+}
+
+// JSON returns the JSON representation of FindMessage.
+func (m FindMessage) JSON() string {
+	return strutil.JSON(m.Object)
+}
+
+// findExpression is the conjunction of the predicates of find command. Unset
+// predicates match all of the objects.
+type findExpression struct {
+	name         *regexp.Regexp
+	sizes        []sizeFilter
+	ages         []ageFilter
+	storageClass string
+}
+
+// parseFindExpression parses the predicates of find command, the ages are
+// relative to the given time.
+func parseFindExpression(c *cli.Context, now time.Time) (findExpression, error) {
+	var expr findExpression
+
+	if c.IsSet("name") {
+		name, err := regexp.Compile(wildCardToRegexp(c.String("name")))
+		if err != nil {
+			return findExpression{}, fmt.Errorf("name: %v", err)
+		}
+		expr.name = name
+	}
+
+	for _, value := range c.StringSlice("size") {
+		size, err := parseSizePredicate(value)
+		if err != nil {
+			return findExpression{}, fmt.Errorf("size: %v", err)
+		}
+		expr.sizes = append(expr.sizes, size)
+	}
+
+	for _, value := range c.StringSlice("mtime") {
+		age, err := parseMtimePredicate(value, now)
+		if err != nil {
+			return findExpression{}, fmt.Errorf("mtime: %v", err)
+		}
+		expr.ages = append(expr.ages, age)
+	}
+
+	expr.storageClass = strings.ToUpper(c.String("storage-class"))
+	return expr, nil
+}
+
+// parseSizePredicate parses sizes like +1G, -512K or 100MB, which match the
+// objects larger than, smaller than or exactly of the size, respectively.
+func parseSizePredicate(s string) (sizeFilter, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return sizeFilter{}, fmt.Errorf("size is empty")
+	}
+
+	sign := s[0]
+	if sign == '+' || sign == '-' {
+		s = s[1:]
+	}
+
+	n, err := parseByteSize(s)
+	if err != nil {
+		return sizeFilter{}, err
+	}
+
+	switch sign {
+	case '+':
+		return sizeFilter{min: n + 1, max: -1}, nil
+	case '-':
+		if n == 0 {
+			return sizeFilter{}, fmt.Errorf("no object is smaller than 0 bytes")
+		}
+		return sizeFilter{min: -1, max: n - 1}, nil
+	default:
+		return sizeFilter{min: n, max: n}, nil
+	}
+}
+
+// parseMtimePredicate parses ages like +30d or -12h, which match the objects
+// modified more or less than the time ago, respectively.
+func parseMtimePredicate(s string, now time.Time) (ageFilter, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '+' && s[0] != '-') {
+		return ageFilter{}, fmt.Errorf("invalid age %q, must start with + or -, e.g. +30d or -12h", s)
+	}
+
+	age, err := parseAge(s[1:])
+	if err != nil {
+		return ageFilter{}, err
+	}
+
+	if s[0] == '+' {
+		return ageFilter{modifiedBefore: now.Add(-age)}, nil
+	}
+	return ageFilter{modifiedAfter: now.Add(-age)}, nil
+}
+
+// match reports whether the listed object matches all of the predicates.
+func (e findExpression) match(object *storage.Object) bool {
+	if e.name != nil && !e.name.MatchString(object.URL.Base()) {
+		return false
+	}
+
+	for _, size := range e.sizes {
+		if !size.matchSize(object.Size) {
+			return false
+		}
+	}
+
+	for _, age := range e.ages {
+		if object.ModTime == nil || !age.matchTime(*object.ModTime) {
+			return false
+		}
+	}
+
+	if e.storageClass != "" && !strings.EqualFold(string(object.StorageClass), e.storageClass) {
+		return false
+	}
+	return true
+}
+
+func validateFindCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if srcurl.IsBucketWildcard() {
+		return fmt.Errorf("source bucket can not contain glob characters")
+	}
+
+	if c.IsSet("storage-class") && !srcurl.IsRemote() {
+		return fmt.Errorf("storage-class can only be used with remote sources")
+	}
+
+	if _, err := parseFindExpression(c, time.Now()); err != nil {
+		return err
+	}
+
+	if c.IsSet("exec") {
+		fields, err := shellquote.Split(c.String("exec"))
+		if err != nil {
+			return fmt.Errorf("exec: %v", err)
+		}
+		if len(fields) == 0 {
+			return fmt.Errorf("exec: command is empty")
+		}
+		if fields[0] == "run" || fields[0] == "find" {
+			return fmt.Errorf("exec: %q command is not permitted", fields[0])
+		}
+		if AppCommand(fields[0]) == nil {
+			return fmt.Errorf("exec: %q command not found", fields[0])
+		}
+		if !strings.Contains(c.String("exec"), "{}") {
+			return fmt.Errorf("exec: command must contain {} to be replaced by the objects")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestParseSizePredicate(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		value    string
+		expected sizeFilter
+		wantErr  bool
+	}{
+		{value: "+1G", expected: sizeFilter{min: 1<<30 + 1, max: -1}},
+		{value: "-512K", expected: sizeFilter{min: -1, max: 512<<10 - 1}},
+		{value: "100", expected: sizeFilter{min: 100, max: 100}},
+		{value: "-0", wantErr: true},
+		{value: "+1X", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tc := range testcases {
+		got, err := parseSizePredicate(tc.value)
+		if tc.wantErr {
+			assert.Error(t, err, tc.value)
+			continue
+		}
+		assert.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, got, tc.value)
+	}
+}
+
+func TestParseMtimePredicate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	got, err := parseMtimePredicate("+7d", now)
+	assert.NoError(t, err)
+	assert.Equal(t, ageFilter{modifiedBefore: now.Add(-7 * 24 * time.Hour)}, got)
+
+	got, err = parseMtimePredicate("-12h", now)
+	assert.NoError(t, err)
+	assert.Equal(t, ageFilter{modifiedAfter: now.Add(-12 * time.Hour)}, got)
+
+	_, err = parseMtimePredicate("7d", now)
+	assert.Error(t, err)
+
+	_, err = parseMtimePredicate("-7x", now)
+	assert.Error(t, err)
+}
+
+func TestFindExpressionMatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, time.March, 10, 0, 0, 0, 0, time.UTC)
+	object := func(key string, size int64, modTime time.Time, class storage.StorageClass) *storage.Object {
+		u, err := url.New("s3://bucket/" + key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &storage.Object{URL: u, Size: size, ModTime: &modTime, StorageClass: class}
+	}
+
+	expr := findExpression{
+		name:         regexp.MustCompile(wildCardToRegexp("*.parquet")),
+		sizes:        []sizeFilter{{min: 1025, max: -1}, {min: -1, max: 1<<20 - 1}},
+		ages:         []ageFilter{{modifiedAfter: now.Add(-7 * 24 * time.Hour)}},
+		storageClass: "GLACIER",
+	}
+
+	recent := now.Add(-time.Hour)
+	old := now.Add(-30 * 24 * time.Hour)
+
+	assert.True(t, expr.match(object("dir/a.parquet", 4096, recent, "GLACIER")))
+	assert.False(t, expr.match(object("dir/a.parquet/b.csv", 4096, recent, "GLACIER")))
+	assert.False(t, expr.match(object("a.parquet", 1024, recent, "GLACIER")))
+	assert.False(t, expr.match(object("a.parquet", 1<<20, recent, "GLACIER")))
+	assert.False(t, expr.match(object("a.parquet", 4096, old, "GLACIER")))
+	assert.False(t, expr.match(object("a.parquet", 4096, recent, "STANDARD")))
+
+	// unset predicates match all of the objects.
+	assert.True(t, findExpression{}.match(object("a.csv", 0, old, "")))
+}
+
+func TestFindSource(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "s3://bucket/*", findSource("s3://bucket"))
+	assert.Equal(t, "s3://bucket/prefix/*", findSource("s3://bucket/prefix/"))
+	assert.Equal(t, "dir/*", findSource("dir"))
+	assert.Equal(t, "s3://bucket/*.gz", findSource("s3://bucket/*.gz"))
+}
+
+func TestExecFields(t *testing.T) {
+	t.Parallel()
+
+	exec := []string{"cp", "{}", "./out/"}
+
+	u, err := url.New("s3://bucket/a b.txt", url.WithRaw(true))
+	assert.NoError(t, err)
+	fields, err := execFields(exec, u)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cp", "s3://bucket/a b.txt", "./out/"}, fields)
+	// the template is not modified.
+	assert.Equal(t, []string{"cp", "{}", "./out/"}, exec)
+
+	// the keys with glob characters are not expanded by the command.
+	u, err = url.New("s3://bucket/a*[1].txt", url.WithRaw(true))
+	assert.NoError(t, err)
+	fields, err = execFields(exec, u)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cp", "--raw", "s3://bucket/a*[1].txt", "./out/"}, fields)
+
+	_, err = execFields([]string{"cat", "{}"}, u)
+	assert.Error(t, err)
+}
@@ -28,3 +28,9 @@ func (e EnumValue) String() string {
 	}
 	return e.selected
 }
+
+// Get implements flag.Getter, so that the selected value can be looked up
+// from the context.
+func (e EnumValue) Get() interface{} {
+	return e.String()
+}
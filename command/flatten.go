@@ -0,0 +1,70 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// Policies of --flatten-collision flag for the objects which have the same
+// name in a flattened destination.
+const (
+	flattenCollisionOverwrite = "overwrite"
+	flattenCollisionSkip      = "skip"
+	flattenCollisionFail      = "fail"
+	flattenCollisionRename    = "rename"
+)
+
+// errFlattenCollisionSkipped indicates an object is not copied since an
+// object of the same name is copied into the flattened destination before.
+var errFlattenCollisionSkipped = fmt.Errorf("skipped")
+
+// flattenCollisions tracks the names of the objects copied into a flattened
+// destination to apply the collision policy to the objects of the same name.
+type flattenCollisions struct {
+	policy string
+	// sources holds the first source of each name in the destination.
+	sources map[string]*url.URL
+}
+
+func newFlattenCollisions(policy string) *flattenCollisions {
+	return &flattenCollisions{
+		policy:  policy,
+		sources: map[string]*url.URL{},
+	}
+}
+
+// resolve returns the name of the source object in the flattened
+// destination. An empty name means the name of the object is used as is.
+// errFlattenCollisionSkipped is returned if the object is to be skipped.
+func (f *flattenCollisions) resolve(srcurl *url.URL) (string, error) {
+	name := srcurl.Base()
+
+	first, ok := f.sources[name]
+	if !ok {
+		f.sources[name] = srcurl
+		return "", nil
+	}
+
+	switch f.policy {
+	case flattenCollisionSkip:
+		return "", fmt.Errorf("%w: %q is copied from %v", errFlattenCollisionSkipped, name, first)
+	case flattenCollisionFail:
+		return "", fmt.Errorf("%q is copied from both %v and %v", name, first, srcurl)
+	case flattenCollisionRename:
+		// "report.csv" is renamed to "report-1.csv", "report-2.csv" and so on.
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for i := 1; ; i++ {
+			renamed := fmt.Sprintf("%v-%d%v", stem, i, ext)
+			if _, ok := f.sources[renamed]; !ok {
+				f.sources[renamed] = srcurl
+				return renamed, nil
+			}
+		}
+	default:
+		return "", nil
+	}
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestFlattenCollisionsRename(t *testing.T) {
+	t.Parallel()
+
+	collisions := newFlattenCollisions(flattenCollisionRename)
+
+	var names []string
+	for _, src := range []string{
+		"s3://bucket/a/report.csv",
+		"s3://bucket/b/report.csv",
+		"s3://bucket/c/report-1.csv",
+		"s3://bucket/d/README",
+		"s3://bucket/e/README",
+	} {
+		srcurl, err := url.New(src)
+		assert.NoError(t, err)
+
+		name, err := collisions.resolve(srcurl)
+		assert.NoError(t, err)
+		names = append(names, name)
+	}
+
+	// only the objects which collide with the names before are renamed.
+	assert.Equal(t, []string{"", "report-1.csv", "report-1-1.csv", "", "README-1"}, names)
+}
+
+func TestPrepareRemoteDestinationWithName(t *testing.T) {
+	t.Parallel()
+
+	srcurl, err := url.New("s3://bucket/a/report.csv")
+	assert.NoError(t, err)
+
+	dsturl, err := url.New("s3://target/out/")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "s3://target/out/report.csv", prepareRemoteDestination(srcurl, dsturl, true, true, "").String())
+	assert.Equal(t, "s3://target/out/report-1.csv", prepareRemoteDestination(srcurl, dsturl, true, true, "report-1.csv").String())
+}
@@ -0,0 +1,261 @@
+package command
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+const gitignoreFilename = ".gitignore"
+
+// gitignorePattern is a single compiled rule of a .gitignore file.
+type gitignorePattern struct {
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// gitignoreFile holds the rules of a .gitignore file. Patterns are matched
+// against paths relative to the directory the file resides in.
+type gitignoreFile struct {
+	patterns []gitignorePattern
+}
+
+// gitignoreMatcher reports whether local files are ignored by the gitignore
+// rules of the repository they reside in. Rules of nested .gitignore files
+// take precedence over the rules of their parents, as git does.
+type gitignoreMatcher struct {
+	root string
+
+	mu    sync.Mutex
+	files map[string]*gitignoreFile
+}
+
+// newGitignoreMatcher creates a matcher for the files of the given local
+// source. If the source is in a git repository, rules defined in the parent
+// directories up to the repository root are also respected.
+func newGitignoreMatcher(srcurl *url.URL) (*gitignoreMatcher, error) {
+	srcdir := srcurl.Absolute()
+	if srcurl.IsWildcard() {
+		srcdir = filepath.Dir(srcurl.Prefix)
+	} else if st, err := os.Stat(srcdir); err == nil && !st.IsDir() {
+		srcdir = filepath.Dir(srcdir)
+	}
+
+	abs, err := filepath.Abs(srcdir)
+	if err != nil {
+		return nil, err
+	}
+
+	root := abs
+	for dir := abs; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			root = dir
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return &gitignoreMatcher{
+		root:  root,
+		files: map[string]*gitignoreFile{},
+	}, nil
+}
+
+// IsIgnored reports whether the file at the given path is ignored. A file is
+// ignored if any of its parent directories is ignored, since git does not
+// descend into ignored directories.
+func (m *gitignoreMatcher) IsIgnored(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	dir := m.root
+	for i, part := range parts {
+		isDir := i < len(parts)-1
+		// git never tracks its own metadata directory.
+		if isDir && part == ".git" {
+			return true
+		}
+
+		if m.match(filepath.Join(dir, part), isDir) {
+			return true
+		}
+		dir = filepath.Join(dir, part)
+	}
+	return false
+}
+
+// match evaluates the rules of all .gitignore files from the repository root
+// down to the parent of path. The last matching rule decides.
+func (m *gitignoreMatcher) match(path string, isDir bool) bool {
+	dirs := []string{m.root}
+	if rel, _ := filepath.Rel(m.root, filepath.Dir(path)); rel != "." {
+		dir := m.root
+		for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+			dir = filepath.Join(dir, part)
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var ignored bool
+	for _, dir := range dirs {
+		file := m.load(dir)
+		if file == nil {
+			continue
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		for _, p := range file.patterns {
+			if p.dirOnly && !isDir {
+				continue
+			}
+			if p.regex.MatchString(rel) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// load reads and caches the .gitignore file in the given directory. It
+// returns nil if there is no such file.
+func (m *gitignoreMatcher) load(dir string) *gitignoreFile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if file, ok := m.files[dir]; ok {
+		return file
+	}
+
+	file, err := parseGitignore(dir)
+	if err != nil {
+		file = nil
+	}
+	m.files[dir] = file
+	return file
+}
+
+// parseGitignore parses the .gitignore file in the given directory.
+func parseGitignore(dir string) (*gitignoreFile, error) {
+	f, err := os.Open(filepath.Join(dir, gitignoreFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := &gitignoreFile{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		pattern, ok := compileGitignorePattern(scanner.Text())
+		if ok {
+			file.patterns = append(file.patterns, pattern)
+		}
+	}
+	return file, scanner.Err()
+}
+
+// compileGitignorePattern converts a single line of a .gitignore file into a
+// regular expression. It returns false for blank lines and comments.
+//
+// See: https://git-scm.com/docs/gitignore#_pattern_format
+func compileGitignorePattern(line string) (gitignorePattern, bool) {
+	var p gitignorePattern
+
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " \t")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return p, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	// a leading backslash escapes '#' and '!'
+	line = strings.TrimPrefix(line, `\`)
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// a pattern without a slash matches at any level below the .gitignore
+	// file, otherwise it is relative to it.
+	if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+	line = strings.TrimPrefix(line, "/")
+
+	if line == "" {
+		return p, false
+	}
+
+	regex, err := regexp.Compile("^" + gitignoreGlobToRegexp(line) + "$")
+	if err != nil {
+		return p, false
+	}
+	p.regex = regex
+	return p, true
+}
+
+// gitignoreGlobToRegexp converts gitignore glob syntax to regular expression
+// syntax.
+func gitignoreGlobToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
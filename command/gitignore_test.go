@@ -0,0 +1,157 @@
+package command
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/fs"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestGitignoreMatcher(t *testing.T) {
+	t.Parallel()
+
+	workdir := fs.NewDir(t, "gitignore",
+		fs.WithDir(".git", fs.WithFile("HEAD", "ref: refs/heads/master")),
+		fs.WithFile(".gitignore", "# build outputs\n*.o\nbuild/\n/venv\n!keep.o\ndocs/**/*.tmp\n"),
+		fs.WithFile("main.c", ""),
+		fs.WithFile("main.o", ""),
+		fs.WithFile("keep.o", ""),
+		fs.WithDir("build", fs.WithFile("app", "")),
+		fs.WithDir("venv", fs.WithFile("python", "")),
+		fs.WithDir("docs",
+			fs.WithDir("a", fs.WithFile("b.tmp", ""), fs.WithFile("b.md", "")),
+		),
+		fs.WithDir("src",
+			fs.WithFile(".gitignore", "!main.o\ngenerated.go\n"),
+			fs.WithFile("main.o", ""),
+			fs.WithFile("util.o", ""),
+			fs.WithFile("generated.go", ""),
+			fs.WithDir("venv", fs.WithFile("python", "")),
+		),
+	)
+	defer workdir.Remove()
+
+	srcurl, err := url.New(workdir.Join("src") + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := newGitignoreMatcher(srcurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{path: "main.c", ignored: false},
+		{path: "main.o", ignored: true},
+		{path: "keep.o", ignored: false},
+		{path: "build/app", ignored: true},
+		{path: "venv/python", ignored: true},
+		{path: "docs/a/b.tmp", ignored: true},
+		{path: "docs/a/b.md", ignored: false},
+		{path: ".git/HEAD", ignored: true},
+		{path: "src/main.o", ignored: false},
+		{path: "src/util.o", ignored: true},
+		{path: "src/generated.go", ignored: true},
+		{path: "src/venv/python", ignored: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.path, func(t *testing.T) {
+			got := matcher.IsIgnored(filepath.Join(workdir.Path(), tc.path))
+			if got != tc.ignored {
+				t.Errorf("expected ignored=%v for %q, got %v", tc.ignored, tc.path, got)
+			}
+		})
+	}
+}
+
+func TestCompileGitignorePattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line    string
+		ok      bool
+		negate  bool
+		dirOnly bool
+		matches []string
+		misses  []string
+	}{
+		{line: "", ok: false},
+		{line: "   ", ok: false},
+		{line: "# comment", ok: false},
+		{
+			line:    "*.log",
+			ok:      true,
+			matches: []string{"a.log", "dir/a.log"},
+			misses:  []string{"a.logs", "a.log/b"},
+		},
+		{
+			line:    "/root.txt",
+			ok:      true,
+			matches: []string{"root.txt"},
+			misses:  []string{"dir/root.txt"},
+		},
+		{
+			line:    "!important.log",
+			ok:      true,
+			negate:  true,
+			matches: []string{"important.log", "a/important.log"},
+		},
+		{
+			line:    "node_modules/",
+			ok:      true,
+			dirOnly: true,
+			matches: []string{"node_modules", "a/node_modules"},
+		},
+		{
+			line:    "a/**/b",
+			ok:      true,
+			matches: []string{"a/b", "a/x/b", "a/x/y/b"},
+			misses:  []string{"x/a/b"},
+		},
+		{
+			line:    "file[0-9].txt",
+			ok:      true,
+			matches: []string{"file1.txt"},
+			misses:  []string{"filea.txt"},
+		},
+		{
+			line:    `\#hash`,
+			ok:      true,
+			matches: []string{"#hash"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.line, func(t *testing.T) {
+			p, ok := compileGitignorePattern(tc.line)
+			if ok != tc.ok {
+				t.Fatalf("expected ok=%v, got %v", tc.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if p.negate != tc.negate || p.dirOnly != tc.dirOnly {
+				t.Errorf("expected negate=%v dirOnly=%v, got negate=%v dirOnly=%v", tc.negate, tc.dirOnly, p.negate, p.dirOnly)
+			}
+			for _, m := range tc.matches {
+				if !p.regex.MatchString(m) {
+					t.Errorf("expected %q to match %q", tc.line, m)
+				}
+			}
+			for _, m := range tc.misses {
+				if p.regex.MatchString(m) {
+					t.Errorf("expected %q not to match %q", tc.line, m)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var headHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the size, ETag, content type, storage class, encryption and restore status of an object
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/object
+
+	2. Print the metadata of an object as JSON, including its tags and user-defined metadata
+		 > s5cmd --json {{.HelpName}} s3://bucket/prefix/object
+
+	3. Print the metadata of an object encrypted with a customer provided key
+		 > s5cmd {{.HelpName}} --sse-c --sse-c-key-file key.bin s3://bucket/prefix/object
+
+	4. Print the region of a bucket, or fail if the bucket doesn't exist
+		 > s5cmd {{.HelpName}} s3://bucket
+`
+
+func NewHeadCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "head",
+		HelpName:           "head",
+		Usage:              "print metadata of an object or a bucket",
+		CustomHelpTemplate: headHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "sse-c",
+				Usage: "use server side encryption with customer provided key (SSE-C); the key is read from --sse-c-key-file or S5CMD_SSE_C_KEY environment variable",
+			},
+			&cli.StringFlag{
+				Name:  "sse-c-key-file",
+				Usage: "read the 256-bit customer provided key of SSE-C from given file, either raw or base64 encoded",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateHeadCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			src, err := url.New(c.Args().Get(0))
+			op := c.Command.Name
+			fullCommand := commandFromContext(c)
+			if err != nil {
+				printError(fullCommand, op, err)
+				return err
+			}
+
+			return Head{
+				src:         src,
+				op:          op,
+				fullCommand: fullCommand,
+				// tags are fetched with a separate request, only if they
+				// are printed.
+				fetchTags: c.Bool("json"),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Head holds head operation flags and states.
+type Head struct {
+	src         *url.URL
+	op          string
+	fullCommand string
+	fetchTags   bool
+
+	storageOpts storage.Options
+}
+
+// Run prints the metadata of the object, or the region of the bucket.
+func (h Head) Run(ctx context.Context) error {
+	client, err := storage.NewRemoteClient(ctx, h.src, h.storageOpts)
+	// the region of the bucket is detected while the client is created if
+	// it is not given.
+	if h.src.IsBucket() && storage.IsNotFoundError(err) {
+		err = storage.ErrGivenBucketNotFound
+	}
+	if err != nil {
+		printError(h.fullCommand, h.op, err)
+		return err
+	}
+
+	if h.src.IsBucket() {
+		region, err := client.BucketRegion(ctx, h.src.Bucket)
+		if err != nil {
+			printError(h.fullCommand, h.op, err)
+			return err
+		}
+		log.Info(HeadBucketMessage{
+			Bucket: h.src.Bucket,
+			Region: region,
+		})
+		return nil
+	}
+
+	object, err := client.Head(ctx, h.src)
+	if err != nil {
+		printError(h.fullCommand, h.op, err)
+		return err
+	}
+
+	if h.fetchTags {
+		object.Tags, err = client.Tags(ctx, h.src)
+		if err != nil {
+			printError(h.fullCommand, h.op, err)
+			return err
+		}
+	}
+
+	log.Info(HeadObjectMessage{Object: object})
+	return nil
+}
+
+// HeadObjectMessage is a structure for logging the metadata of an object.
+type HeadObjectMessage struct {
+	Object *storage.Object
+}
+
+// String returns the metadata of the object as a field per line, leaving out
+// the fields which are not set.
+func (m HeadObjectMessage) String() string {
+	obj := m.Object
+	metadata := obj.Metadata
+	if metadata == nil {
+		metadata = &storage.ObjectMetadata{}
+	}
+
+	fields := [][2]string{
+		{"key", obj.URL.String()},
+		{"size", fmt.Sprintf("%d", obj.Size)},
+		{"etag", obj.Etag},
+		{"last_modified", obj.ModTime.Format(dateFormat)},
+		{"version_id", obj.VersionID},
+		{"content_type", metadata.ContentType},
+		{"content_encoding", metadata.ContentEncoding},
+		{"cache_control", metadata.CacheControl},
+		{"storage_class", string(obj.StorageClass)},
+		{"sse", headSSE(metadata)},
+		{"restore", headRestore(obj)},
+	}
+
+	keys := make([]string, 0, len(metadata.UserMetadata))
+	for key := range metadata.UserMetadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, [2]string{"metadata." + key, metadata.UserMetadata[key]})
+	}
+
+	var lines []string
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-17s %s", field[0]+":", field[1]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON returns the JSON representation of HeadObjectMessage.
+func (m HeadObjectMessage) JSON() string {
+	return m.Object.JSON()
+}
+
+// headSSE returns the server side encryption of the object along with its
+// key.
+func headSSE(metadata *storage.ObjectMetadata) string {
+	if metadata.SSECustomerAlgorithm != "" {
+		return fmt.Sprintf("%s (customer provided key)", metadata.SSECustomerAlgorithm)
+	}
+
+	sse := metadata.ServerSideEncryption
+	if metadata.SSEKMSKeyID != "" {
+		sse += " " + metadata.SSEKMSKeyID
+	}
+	if metadata.BucketKeyEnabled {
+		sse += " (bucket key enabled)"
+	}
+	return sse
+}
+
+// headRestore returns the restore status of the object, empty if the object
+// is not archived. The restore header is printed as is if it can't be parsed.
+func headRestore(obj *storage.Object) string {
+	state, expiry, err := restoreState(obj.StorageClass, obj.Restore)
+	if err != nil {
+		return obj.Restore
+	}
+	if state == restoreStateNotArchived {
+		return ""
+	}
+	if expiry != nil {
+		return fmt.Sprintf("%s, expires %s", state, expiry.Format(dateFormat))
+	}
+	return state
+}
+
+// HeadBucketMessage is a structure for logging the region of an existing
+// bucket.
+type HeadBucketMessage struct {
+	Bucket string `json:"bucket"`
+	Region string `json:"region"`
+}
+
+// String returns the string representation of HeadBucketMessage.
+func (m HeadBucketMessage) String() string {
+	return fmt.Sprintf("s3://%s %s", m.Bucket, m.Region)
+}
+
+// JSON returns the JSON representation of HeadBucketMessage.
+func (m HeadBucketMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validateHeadCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	src, err := url.New(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	if !src.IsRemote() {
+		return fmt.Errorf("source must be a remote object or bucket")
+	}
+
+	if src.IsPrefix() {
+		return fmt.Errorf("remote source must be an object or a bucket")
+	}
+
+	if src.IsWildcard() {
+		return fmt.Errorf("remote source %q can not contain glob characters", src)
+	}
+
+	if _, err := readSSECustomerKey(c); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestHeadObjectMessageString(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.New("s3://bucket/key")
+	assert.NoError(t, err)
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := HeadObjectMessage{Object: &storage.Object{
+		URL:          u,
+		Size:         7,
+		Etag:         "etag",
+		ModTime:      &modTime,
+		StorageClass: storage.StorageClass("GLACIER"),
+		Restore:      `ongoing-request="false", expiry-date="Thu, 09 Jan 2020 00:00:00 GMT"`,
+		Metadata: &storage.ObjectMetadata{
+			ContentType:          "text/plain",
+			UserMetadata:         map[string]string{"owner": "s5cmd", "app": "test"},
+			ServerSideEncryption: "aws:kms",
+			SSEKMSKeyID:          "key-id",
+			BucketKeyEnabled:     true,
+		},
+	}}
+
+	assert.Equal(t, `key:              s3://bucket/key
+size:             7
+etag:             etag
+last_modified:    2020/01/02 03:04:05
+content_type:     text/plain
+storage_class:    GLACIER
+sse:              aws:kms key-id (bucket key enabled)
+restore:          completed, expires 2020/01/09 00:00:00
+metadata.app:     test
+metadata.owner:   s5cmd`, msg.String())
+}
@@ -0,0 +1,503 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var lifecycleGetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} s3://bucketname
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the lifecycle rules of a bucket
+		 > s5cmd {{.HelpName}} s3://bucketname
+
+	2. Save the lifecycle rules of a bucket to a file, to be applied later with "lifecycle set --file"
+		 > s5cmd --json {{.HelpName}} s3://bucketname > rules.json
+`
+
+var lifecycleSetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] s3://bucketname
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Rules:
+	The rules are given in key1=value1,key2=value2 format with the following keys:
+
+	id=<name>                             name of the rule
+	status=<enabled|disabled>             whether the rule is applied, enabled by default
+	prefix=<prefix>                       prefix of the objects, all objects of the bucket by default
+	tag=<key>=<value>                     tag of the objects, can be given multiple times
+	expire=<days|yyyy-mm-dd>              days after which, or date on which the objects are deleted
+	expire-delete-markers=true            delete the delete markers which have no noncurrent versions left
+	transition=<days|yyyy-mm-dd>:<class>  days after which, or date on which the objects are moved to the storage class, can be given multiple times
+	noncurrent-expire=<days>              days after which the noncurrent versions of the objects are deleted
+	noncurrent-transition=<days>:<class>  days after which the noncurrent versions of the objects are moved to the storage class, can be given multiple times
+	abort-upload=<days>                   days after which the incomplete multipart uploads are aborted
+
+	The rules with object size filters or the number of newer noncurrent versions to keep are not supported.
+
+Examples:
+	1. Delete the logs after 90 days, moving them to GLACIER after 30 days
+		 > s5cmd {{.HelpName}} --rule "id=logs,prefix=logs/,transition=30:GLACIER,expire=90" s3://bucketname
+
+	2. Replace the lifecycle rules with multiple rules
+		 > s5cmd {{.HelpName}} --rule "prefix=tmp/,expire=1" --rule "abort-upload=7" s3://bucketname
+
+	3. Copy the lifecycle rules of a bucket to another bucket
+		 > s5cmd --json lifecycle get s3://bucketname > rules.json
+		 > s5cmd {{.HelpName}} --file rules.json s3://otherbucket
+`
+
+var lifecycleRemoveHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} s3://bucketname
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Remove the lifecycle rules of a bucket
+		 > s5cmd {{.HelpName}} s3://bucketname
+`
+
+func NewLifecycleCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "lifecycle",
+		HelpName: "lifecycle",
+		Usage:    "print, replace or remove lifecycle rules of a bucket",
+		Subcommands: []*cli.Command{
+			newLifecycleGetCommand(),
+			newLifecycleSetCommand(),
+			newLifecycleRemoveCommand(),
+		},
+	}
+}
+
+func newLifecycleGetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "get",
+		HelpName:           "lifecycle get",
+		Usage:              "print lifecycle rules of a bucket",
+		CustomHelpTemplate: lifecycleGetHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateBucketCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return runBucketOperation(c, func(ctx context.Context, client *storage.S3, bucket *url.URL) error {
+				rules, err := client.Lifecycle(ctx, bucket.Bucket)
+				if err != nil {
+					return err
+				}
+				log.Info(LifecycleMessage{Source: bucket, Rules: rules})
+				return nil
+			})
+		},
+	}
+}
+
+func newLifecycleSetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "set",
+		HelpName:           "lifecycle set",
+		Usage:              "replace lifecycle rules of a bucket",
+		CustomHelpTemplate: lifecycleSetHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "rule",
+				Usage: "lifecycle rule in key1=value1,key2=value2 format, see the rules below; can be given multiple times",
+			},
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: `JSON file with the lifecycle rules, in the format printed by "s5cmd --json lifecycle get"`,
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateLifecycleSetCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			rules, err := lifecycleRulesFromContext(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+
+			op := c.Command.HelpName
+			return runBucketOperation(c, func(ctx context.Context, client *storage.S3, bucket *url.URL) error {
+				if err := client.SetLifecycle(ctx, bucket.Bucket, rules); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: bucket})
+				return nil
+			})
+		},
+	}
+}
+
+func newLifecycleRemoveCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "rm",
+		HelpName:           "lifecycle rm",
+		Usage:              "remove lifecycle rules of a bucket",
+		CustomHelpTemplate: lifecycleRemoveHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateBucketCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			op := c.Command.HelpName
+			return runBucketOperation(c, func(ctx context.Context, client *storage.S3, bucket *url.URL) error {
+				if err := client.DeleteLifecycle(ctx, bucket.Bucket); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: bucket})
+				return nil
+			})
+		},
+	}
+}
+
+// LifecycleMessage is a structure for logging the lifecycle rules of a
+// bucket. Its JSON representation can be given to "lifecycle set --file".
+type LifecycleMessage struct {
+	Source *url.URL                `json:"source"`
+	Rules  []storage.LifecycleRule `json:"rules"`
+}
+
+// String returns the string representation of LifecycleMessage, a line for
+// each rule in the format of "lifecycle set --rule".
+func (m LifecycleMessage) String() string {
+	lines := make([]string, 0, len(m.Rules))
+	for _, rule := range m.Rules {
+		lines = append(lines, fmt.Sprintf("%v %v", m.Source, formatLifecycleRule(rule)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON returns the JSON representation of LifecycleMessage.
+func (m LifecycleMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+// parseLifecycleRule parses a lifecycle rule in key1=value1,key2=value2
+// format.
+func parseLifecycleRule(value string) (storage.LifecycleRule, error) {
+	rule := storage.LifecycleRule{Status: s3.ExpirationStatusEnabled}
+	for _, field := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 {
+			return rule, fmt.Errorf("invalid lifecycle rule field %q, expected key=value", field)
+		}
+
+		key, value := parts[0], parts[1]
+		switch key {
+		case "id":
+			rule.ID = value
+		case "status":
+			switch strings.ToLower(value) {
+			case "enabled":
+				rule.Status = s3.ExpirationStatusEnabled
+			case "disabled":
+				rule.Status = s3.ExpirationStatusDisabled
+			default:
+				return rule, fmt.Errorf("lifecycle rule status must be enabled or disabled")
+			}
+		case "prefix":
+			rule.Prefix = value
+		case "tag":
+			tag := strings.SplitN(value, "=", 2)
+			if len(tag) != 2 || tag[0] == "" {
+				return rule, fmt.Errorf("invalid lifecycle rule tag %q, expected tag=key=value", value)
+			}
+			if rule.Tags == nil {
+				rule.Tags = map[string]string{}
+			}
+			rule.Tags[tag[0]] = tag[1]
+		case "transition", "noncurrent-transition":
+			transition := strings.SplitN(value, ":", 2)
+			if len(transition) != 2 {
+				return rule, fmt.Errorf("invalid lifecycle rule %s %q, expected %s=days:class", key, value, key)
+			}
+			class := strings.ToUpper(transition[1])
+			if key == "noncurrent-transition" {
+				days, err := parseLifecycleDays(key, transition[0])
+				if err != nil {
+					return rule, err
+				}
+				rule.NoncurrentTransitions = append(rule.NoncurrentTransitions, storage.LifecycleNoncurrentTransition{
+					Days:         days,
+					StorageClass: class,
+				})
+				continue
+			}
+			days, date, err := parseLifecycleDaysOrDate(key, transition[0])
+			if err != nil {
+				return rule, err
+			}
+			rule.Transitions = append(rule.Transitions, storage.LifecycleTransition{
+				Days:         days,
+				Date:         date,
+				StorageClass: class,
+			})
+		case "expire":
+			days, date, err := parseLifecycleDaysOrDate(key, value)
+			if err != nil {
+				return rule, err
+			}
+			rule.ExpirationDays, rule.ExpirationDate = days, date
+		case "expire-delete-markers":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return rule, fmt.Errorf("lifecycle rule %s must be true or false", key)
+			}
+			rule.ExpiredObjectDeleteMarker = enabled
+		case "noncurrent-expire", "abort-upload":
+			days, err := parseLifecycleDays(key, value)
+			if err != nil {
+				return rule, err
+			}
+			switch key {
+			case "noncurrent-expire":
+				rule.NoncurrentExpirationDays = days
+			case "abort-upload":
+				rule.AbortIncompleteUploadDays = days
+			}
+		default:
+			return rule, fmt.Errorf("unknown lifecycle rule key %q", key)
+		}
+	}
+	return rule, validateLifecycleRule(rule)
+}
+
+func parseLifecycleDays(key, value string) (int64, error) {
+	days, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("lifecycle rule %s must be a positive number of days", key)
+	}
+	return days, nil
+}
+
+// lifecycleDateLayout is the layout of the dates of the lifecycle rules,
+// which are at midnight UTC.
+const lifecycleDateLayout = "2006-01-02"
+
+// parseLifecycleDaysOrDate parses a positive number of days or a date.
+func parseLifecycleDaysOrDate(key, value string) (int64, *time.Time, error) {
+	if date, err := time.Parse(lifecycleDateLayout, value); err == nil {
+		return 0, &date, nil
+	}
+	days, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || days < 1 {
+		return 0, nil, fmt.Errorf("lifecycle rule %s must be a positive number of days or a date in yyyy-mm-dd format", key)
+	}
+	return days, nil, nil
+}
+
+// formatLifecycleDaysOrDate formats the date if it is given, or the days.
+func formatLifecycleDaysOrDate(days int64, date *time.Time) string {
+	if date != nil {
+		return date.UTC().Format(lifecycleDateLayout)
+	}
+	return strconv.FormatInt(days, 10)
+}
+
+// formatLifecycleRule formats the lifecycle rule in the format parsed by
+// parseLifecycleRule.
+func formatLifecycleRule(rule storage.LifecycleRule) string {
+	var fields []string
+	if rule.ID != "" {
+		fields = append(fields, "id="+rule.ID)
+	}
+	fields = append(fields, "status="+strings.ToLower(rule.Status))
+	fields = append(fields, "prefix="+rule.Prefix)
+
+	keys := make([]string, 0, len(rule.Tags))
+	for key := range rule.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, fmt.Sprintf("tag=%s=%s", key, rule.Tags[key]))
+	}
+
+	for _, transition := range rule.Transitions {
+		fields = append(fields, fmt.Sprintf("transition=%s:%s", formatLifecycleDaysOrDate(transition.Days, transition.Date), transition.StorageClass))
+	}
+	if rule.ExpirationDays > 0 || rule.ExpirationDate != nil {
+		fields = append(fields, "expire="+formatLifecycleDaysOrDate(rule.ExpirationDays, rule.ExpirationDate))
+	}
+	if rule.ExpiredObjectDeleteMarker {
+		fields = append(fields, "expire-delete-markers=true")
+	}
+	for _, transition := range rule.NoncurrentTransitions {
+		fields = append(fields, fmt.Sprintf("noncurrent-transition=%d:%s", transition.Days, transition.StorageClass))
+	}
+	if rule.NoncurrentExpirationDays > 0 {
+		fields = append(fields, fmt.Sprintf("noncurrent-expire=%d", rule.NoncurrentExpirationDays))
+	}
+	if rule.AbortIncompleteUploadDays > 0 {
+		fields = append(fields, fmt.Sprintf("abort-upload=%d", rule.AbortIncompleteUploadDays))
+	}
+	return strings.Join(fields, ",")
+}
+
+// validateLifecycleRule validates the rules given with --rule or --file.
+func validateLifecycleRule(rule storage.LifecycleRule) error {
+	switch rule.Status {
+	case "", s3.ExpirationStatusEnabled, s3.ExpirationStatusDisabled:
+	default:
+		return fmt.Errorf("lifecycle rule status must be %s or %s", s3.ExpirationStatusEnabled, s3.ExpirationStatusDisabled)
+	}
+
+	for _, transition := range rule.Transitions {
+		if transition.Date != nil && transition.Days != 0 {
+			return fmt.Errorf("lifecycle rule transition can have either days or a date")
+		}
+		if transition.Date == nil && transition.Days < 1 {
+			return fmt.Errorf("lifecycle rule transition must be a positive number of days or a date")
+		}
+		if !isTransitionStorageClass(transition.StorageClass) {
+			return fmt.Errorf("lifecycle rule transition storage class must be one of %s", strings.Join(s3.TransitionStorageClass_Values(), ", "))
+		}
+	}
+	for _, transition := range rule.NoncurrentTransitions {
+		if transition.Days < 1 {
+			return fmt.Errorf("lifecycle rule noncurrent transition must be a positive number of days")
+		}
+		if !isTransitionStorageClass(transition.StorageClass) {
+			return fmt.Errorf("lifecycle rule noncurrent transition storage class must be one of %s", strings.Join(s3.TransitionStorageClass_Values(), ", "))
+		}
+	}
+
+	if rule.ExpirationDays < 0 || rule.NoncurrentExpirationDays < 0 || rule.AbortIncompleteUploadDays < 0 {
+		return fmt.Errorf("lifecycle rule days can not be negative")
+	}
+
+	// an expiration has a single condition.
+	expirations := 0
+	if rule.ExpirationDays > 0 {
+		expirations++
+	}
+	if rule.ExpirationDate != nil {
+		expirations++
+	}
+	if rule.ExpiredObjectDeleteMarker {
+		expirations++
+	}
+	if expirations > 1 {
+		return fmt.Errorf("lifecycle rule can have only one of expire days, expire date or expire-delete-markers")
+	}
+
+	if expirations == 0 && len(rule.Transitions) == 0 && rule.NoncurrentExpirationDays == 0 &&
+		len(rule.NoncurrentTransitions) == 0 && rule.AbortIncompleteUploadDays == 0 {
+		return fmt.Errorf("lifecycle rule must have at least one of expire, expire-delete-markers, transition, noncurrent-expire, noncurrent-transition or abort-upload")
+	}
+	return nil
+}
+
+func isTransitionStorageClass(class string) bool {
+	for _, value := range s3.TransitionStorageClass_Values() {
+		if class == value {
+			return true
+		}
+	}
+	return false
+}
+
+// lifecycleRulesFromContext returns the lifecycle rules given with --rule or
+// --file.
+func lifecycleRulesFromContext(c *cli.Context) ([]storage.LifecycleRule, error) {
+	if path := c.String("file"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var config struct {
+			Rules []storage.LifecycleRule `json:"rules"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid lifecycle file %q: %v", path, err)
+		}
+		if len(config.Rules) == 0 {
+			return nil, fmt.Errorf("expected at least 1 rule in lifecycle file %q", path)
+		}
+		for _, rule := range config.Rules {
+			if err := validateLifecycleRule(rule); err != nil {
+				return nil, err
+			}
+		}
+		return config.Rules, nil
+	}
+
+	var rules []storage.LifecycleRule
+	for _, value := range c.StringSlice("rule") {
+		rule, err := parseLifecycleRule(value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func validateLifecycleSetCommand(c *cli.Context) error {
+	if err := validateBucketCommand(c); err != nil {
+		return err
+	}
+
+	rules, file := c.StringSlice("rule"), c.String("file")
+	if len(rules) > 0 && file != "" {
+		return fmt.Errorf("--rule and --file can not be used together")
+	}
+	if len(rules) == 0 && file == "" {
+		return fmt.Errorf("expected at least 1 rule with --rule or --file, use lifecycle rm to remove the rules")
+	}
+
+	_, err := lifecycleRulesFromContext(c)
+	return err
+}
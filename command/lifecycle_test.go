@@ -0,0 +1,90 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestParseLifecycleRule(t *testing.T) {
+	t.Parallel()
+
+	rule, err := parseLifecycleRule("id=logs,prefix=logs/,tag=env=prod,transition=30:standard_ia,transition=60:GLACIER,expire=90")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.LifecycleRule{
+		ID:     "logs",
+		Status: "Enabled",
+		Prefix: "logs/",
+		Tags:   map[string]string{"env": "prod"},
+		Transitions: []storage.LifecycleTransition{
+			{Days: 30, StorageClass: "STANDARD_IA"},
+			{Days: 60, StorageClass: "GLACIER"},
+		},
+		ExpirationDays: 90,
+	}, rule)
+
+	rule, err = parseLifecycleRule("status=disabled,noncurrent-expire=7,abort-upload=1")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.LifecycleRule{
+		Status:                    "Disabled",
+		NoncurrentExpirationDays:  7,
+		AbortIncompleteUploadDays: 1,
+	}, rule)
+
+	rule, err = parseLifecycleRule("transition=2030-01-01:glacier,expire=2031-01-01,noncurrent-transition=30:STANDARD_IA")
+	assert.NoError(t, err)
+	transitionDate := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	expirationDate := time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, storage.LifecycleRule{
+		Status:                "Enabled",
+		Transitions:           []storage.LifecycleTransition{{Date: &transitionDate, StorageClass: "GLACIER"}},
+		ExpirationDate:        &expirationDate,
+		NoncurrentTransitions: []storage.LifecycleNoncurrentTransition{{Days: 30, StorageClass: "STANDARD_IA"}},
+	}, rule)
+
+	rule, err = parseLifecycleRule("expire-delete-markers=true")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.LifecycleRule{
+		Status:                    "Enabled",
+		ExpiredObjectDeleteMarker: true,
+	}, rule)
+
+	for _, value := range []string{
+		"",
+		"prefix=logs/",
+		"expire",
+		"expire=0",
+		"expire=ten",
+		"unknown=1",
+		"status=paused,expire=1",
+		"tag=env,expire=1",
+		"transition=30,expire=1",
+		"transition=30:COLD",
+		"transition=2030-13-01:GLACIER",
+		"noncurrent-transition=0:GLACIER",
+		"noncurrent-transition=2030-01-01:GLACIER",
+		"expire=90,expire-delete-markers=true",
+		"expire-delete-markers=yes",
+		"expire-delete-markers=false",
+	} {
+		_, err := parseLifecycleRule(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFormatLifecycleRule(t *testing.T) {
+	t.Parallel()
+
+	for _, value := range []string{
+		"id=logs,status=enabled,prefix=logs/,tag=a=1,tag=b=2,transition=30:GLACIER,expire=90,noncurrent-expire=7,abort-upload=1",
+		"status=enabled,prefix=,transition=2030-01-01:GLACIER,expire=2031-01-01,noncurrent-transition=30:STANDARD_IA",
+		"status=disabled,prefix=tmp/,expire-delete-markers=true",
+	} {
+		rule, err := parseLifecycleRule(value)
+		assert.NoError(t, err)
+		assert.Equal(t, value, formatLifecycleRule(rule))
+	}
+}
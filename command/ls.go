@@ -3,6 +3,8 @@ package command
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
@@ -45,6 +47,51 @@ Examples:
 
 	7. List all object in a requester pays bucket
 		 > s5cmd --request-payer=requester {{.HelpName}} s3://bucket/*
+
+	8. List all objects in a bucket with object sizes in SI units, e.g. 1.5M for 1500000 bytes
+		 > s5cmd {{.HelpName}} --humanize --si s3://bucket/*
+
+	9. List all objects in a huge bucket, continuing from where the previous interrupted listing left off
+		 > s5cmd {{.HelpName}} --resume-token-file ls.state s3://bucket/*
+
+	10. List the versions of the objects which were current at the given time on a versioned bucket
+		 > s5cmd {{.HelpName}} --at-time 2021-06-01T12:00:00Z "s3://bucket/prefix/*"
+
+	11. List all objects under a prefix with their tags
+		 > s5cmd {{.HelpName}} --show-tags "s3://bucket/prefix/*"
+
+	12. List all objects in a bucket which are modified in the last 12 hours
+		 > s5cmd {{.HelpName}} --newer-than 12h "s3://bucket/*"
+
+	13. List all objects under a prefix and print their total count and size at the end
+		 > s5cmd {{.HelpName}} --summarize "s3://bucket/prefix/*"
+
+	14. List only the keys, sizes and storage classes of the objects, separated by tabs
+		 > s5cmd {{.HelpName}} --format "{key}\t{size}\t{storage_class}" "s3://bucket/*"
+
+	15. List the storage classes, ETags and version ids of the current versions of objects on a versioned bucket
+		 > s5cmd {{.HelpName}} --show-storage-class --show-etag --show-version-id "s3://bucket/*"
+
+	16. List all versions and delete markers of objects under a prefix on a versioned bucket
+		 > s5cmd {{.HelpName}} --all-versions "s3://bucket/prefix/*"
+
+	17. List all objects in a bucket with object sizes in SI units with 3 decimal places
+		 > s5cmd {{.HelpName}} --humanize=si --precision 3 "s3://bucket/*"
+
+	18. List all objects under a prefix without grouping them by directories
+		 > s5cmd {{.HelpName}} --delimiter "" s3://bucket/prefix/
+
+	19. List the objects and the common prefixes of a bucket whose keys are separated by "|"
+		 > s5cmd {{.HelpName}} --delimiter "|" "s3://bucket/logs|"
+
+	20. List the first 100 objects under a prefix whose keys come after the given key
+		 > s5cmd {{.HelpName}} --limit 100 --start-after prefix/2021/06/data.csv "s3://bucket/prefix/*"
+
+	21. List all objects in a bucket which are larger than 1GB
+		 > s5cmd {{.HelpName}} --min-size 1GB "s3://bucket/*"
+
+	22. Export the content types, user-defined metadata, encryption and tags of all objects under a prefix as JSON
+		 > s5cmd --json {{.HelpName}} --fetch-metadata "s3://bucket/prefix/*"
 `
 
 func NewListCommand() *cli.Command {
@@ -56,23 +103,93 @@ func NewListCommand() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "etag",
-				Aliases: []string{"e"},
+				Aliases: []string{"e", "show-etag"},
 				Usage:   "show entity tag (ETag) in the output",
 			},
-			&cli.BoolFlag{
+			&cli.GenericFlag{
 				Name:    "humanize",
 				Aliases: []string{"H"},
-				Usage:   "human-readable output for object sizes",
+				Value:   &humanizeValue{},
+				Usage:   "human-readable output for object sizes in iec (powers of 1024), si (powers of 1000) or raw units, e.g. --humanize=si; iec if given without a value",
+			},
+			&cli.BoolFlag{
+				Name:  "si",
+				Usage: "use powers of 1000 instead of 1024 for human-readable sizes, same as --humanize=si",
+			},
+			&cli.IntFlag{
+				Name:  "precision",
+				Value: defaultSizePrecision,
+				Usage: "number of decimal places of human-readable sizes",
 			},
 			&cli.BoolFlag{
 				Name:    "storage-class",
-				Aliases: []string{"s"},
+				Aliases: []string{"s", "show-storage-class"},
 				Usage:   "display full name of the object class",
 			},
 			&cli.StringSliceFlag{
 				Name:  "exclude",
 				Usage: "exclude objects with given pattern",
 			},
+			&cli.StringFlag{
+				Name:  "resume-token-file",
+				Usage: "persist the listing progress to given file and resume the listing from it, the file is removed when the listing completes",
+			},
+			&cli.StringFlag{
+				Name:  "at-time",
+				Usage: "list the versions of the objects which were current at the given time on versioned buckets, e.g. 2021-06-01T12:00:00Z",
+			},
+			&cli.StringFlag{
+				Name:  "older-than",
+				Usage: "only list objects modified more than given time ago, e.g. 30d, 2w or 12h",
+			},
+			&cli.StringFlag{
+				Name:  "newer-than",
+				Usage: "only list objects modified less than given time ago, e.g. 30d, 2w or 12h",
+			},
+			&cli.StringFlag{
+				Name:  "min-size",
+				Usage: "only list objects whose sizes are greater than or equal to given size, e.g. 1GB or 512KiB",
+			},
+			&cli.StringFlag{
+				Name:  "max-size",
+				Usage: "only list objects whose sizes are less than or equal to given size, e.g. 10MB or 512KiB",
+			},
+			&cli.BoolFlag{
+				Name:  "show-version-id",
+				Usage: "list the current versions of the objects on versioned buckets and show their version ids",
+			},
+			&cli.BoolFlag{
+				Name:  "all-versions",
+				Usage: "list all of the versions and the delete markers of the objects on versioned buckets, latest versions are marked",
+			},
+			&cli.BoolFlag{
+				Name:  "show-tags",
+				Usage: "fetch and show the tags of the objects",
+			},
+			&cli.BoolFlag{
+				Name:  "fetch-metadata",
+				Usage: "fetch the content types, user-defined metadata, server side encryption and tags of the objects with HEAD requests and include them in JSON output, requires --json",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "print given fields of the objects instead of the columns, e.g. '{key}\\t{size}'; fields are " + listFormatFieldNames(),
+			},
+			&cli.BoolFlag{
+				Name:  "summarize",
+				Usage: "print the total number and size of the listed objects at the end",
+			},
+			&cli.StringFlag{
+				Name:  "delimiter",
+				Usage: "group the keys by the given delimiter instead of '/', an empty delimiter lists all of the objects under the prefix",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "stop the listing after printing given number of objects and prefixes",
+			},
+			&cli.StringFlag{
+				Name:  "start-after",
+				Usage: "list the objects whose keys come after given key in lexicographical order, e.g. the last key of the previous page",
+			},
 		},
 		Before: func(c *cli.Context) error {
 			err := validateLSCommand(c)
@@ -91,17 +208,54 @@ func NewListCommand() *cli.Command {
 				return err
 			}
 
+			// the timestamp, the ages and the sizes are validated before the
+			// command runs.
+			age, _ := parseAgeFilter(c)
+			sizeRange, _ := parseSizeFilter(c)
+
+			// the format is validated before the command runs.
+			var format *listFormat
+			if c.IsSet("format") {
+				format, _ = parseListFormat(c.String("format"))
+			}
+
+			var atTime *time.Time
+			if c.IsSet("at-time") {
+				t, _ := time.Parse(time.RFC3339, c.String("at-time"))
+				atTime = &t
+			}
+
+			var delimiter *string
+			if c.IsSet("delimiter") {
+				d := c.String("delimiter")
+				delimiter = &d
+			}
+
 			return List{
 				src:         c.Args().First(),
 				op:          c.Command.Name,
 				fullCommand: commandFromContext(c),
 				// flags
 				showEtag:         c.Bool("etag"),
-				humanize:         c.Bool("humanize"),
+				sizes:            newSizeFormat(c.String("humanize"), c.Bool("si"), c.Int("precision")),
 				showStorageClass: c.Bool("storage-class"),
 				exclude:          c.StringSlice("exclude"),
+				resumeTokenFile:  c.String("resume-token-file"),
+				atTime:           atTime,
+				showVersionID:    c.Bool("show-version-id"),
+				allVersions:      c.Bool("all-versions"),
+				showTags:         c.Bool("show-tags") || format.hasTags(),
+				fetchMetadata:    c.Bool("fetch-metadata"),
+				summarize:        c.Bool("summarize"),
+				format:           format,
+				age:              age,
+				sizeRange:        sizeRange,
+				delimiter:        delimiter,
+				limit:            c.Int("limit"),
+				startAfter:       c.String("start-after"),
 
 				storageOpts: NewStorageOpts(c),
+				lister:      newSourceLister(c),
 			}.Run(c.Context)
 		},
 	}
@@ -115,11 +269,31 @@ type List struct {
 
 	// flags
 	showEtag         bool
-	humanize         bool
+	sizes            sizeFormat
 	showStorageClass bool
 	exclude          []string
+	resumeTokenFile  string
+	atTime           *time.Time
+	showVersionID    bool
+	allVersions      bool
+	showTags         bool
+	fetchMetadata    bool
+	summarize        bool
+	format           *listFormat
+	age              ageFilter
+	sizeRange        sizeFilter
+
+	// delimiter overrides the delimiter the keys are grouped by if it is
+	// set. An empty delimiter lists the objects without grouping.
+	delimiter *string
+
+	// limit is the maximum number of the printed objects and prefixes if it
+	// is positive.
+	limit      int
+	startAfter string
 
 	storageOpts storage.Options
+	lister      storage.Lister
 }
 
 // ListBuckets prints all buckets.
@@ -151,7 +325,19 @@ func (l List) Run(ctx context.Context) error {
 		return err
 	}
 
-	client, err := storage.NewClient(ctx, srcurl, l.storageOpts)
+	if l.delimiter != nil {
+		srcurl.Delimiter = *l.delimiter
+	}
+	srcurl.StartAfter = l.startAfter
+
+	// the listing is stopped when the limit is reached.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the objects are listed with the external lister, if it is given.
+	srcOpts := l.storageOpts
+	srcOpts.Lister = l.lister
+	client, err := storage.NewClient(ctx, srcurl, srcOpts)
 	if err != nil {
 		printError(l.fullCommand, l.op, err)
 		return err
@@ -165,8 +351,58 @@ func (l List) Run(ctx context.Context) error {
 		return err
 	}
 
-	for object := range client.List(ctx, srcurl, false) {
+	var objch <-chan *storage.Object
+	if l.resumeTokenFile != "" {
+		objch, err = l.listResumable(ctx, srcurl)
+		if err != nil {
+			printError(l.fullCommand, l.op, err)
+			return err
+		}
+	} else if l.allVersions {
+		objch, err = l.listVersions(ctx, srcurl)
+		if err != nil {
+			printError(l.fullCommand, l.op, err)
+			return err
+		}
+	} else if l.atTime != nil || l.showVersionID {
+		objch, err = l.listAtTime(ctx, srcurl)
+		if err != nil {
+			printError(l.fullCommand, l.op, err)
+			return err
+		}
+	} else {
+		objch = client.List(ctx, srcurl, false)
+	}
+
+	if l.showTags || l.fetchMetadata {
+		remoteClient, err := storage.NewRemoteClient(ctx, srcurl, l.storageOpts)
+		if err != nil {
+			printError(l.fullCommand, l.op, err)
+			return err
+		}
+
+		// the details of the excluded objects are not fetched.
+		skip := func(object *storage.Object) bool {
+			return isURLExcluded(excludePatterns, object.URL.Path, srcurl.Prefix)
+		}
+		if l.fetchMetadata {
+			objch = fetchMetadata(ctx, objch, remoteClient, skip)
+		} else {
+			objch = fetchTags(ctx, objch, remoteClient.Tags, skip)
+		}
+	}
+
+	// total of the listed objects, printed at the end with --summarize.
+	var total SizeMessage
+
+	var (
+		canceled bool
+		limited  bool
+		printed  int
+	)
+	for object := range objch {
 		if errorpkg.IsCancelation(object.Err) {
+			canceled = true
 			continue
 		}
 
@@ -180,37 +416,245 @@ func (l List) Run(ctx context.Context) error {
 			continue
 		}
 
+		// prefixes don't have modification times and sizes.
+		if !object.Type.IsDir() {
+			if ok, err := l.age.match(ctx, client, object); err != nil {
+				merror = multierror.Append(merror, err)
+				printError(l.fullCommand, l.op, err)
+				continue
+			} else if !ok {
+				continue
+			}
+
+			if ok, err := l.sizeRange.match(ctx, client, object); err != nil {
+				merror = multierror.Append(merror, err)
+				printError(l.fullCommand, l.op, err)
+				continue
+			} else if !ok {
+				continue
+			}
+		}
+
 		msg := ListMessage{
 			Object:           object,
 			showEtag:         l.showEtag,
-			showHumanized:    l.humanize,
+			sizes:            l.sizes,
 			showStorageClass: l.showStorageClass,
+			showVersionID:    l.atTime != nil || l.showVersionID || l.allVersions,
+			showLatest:       l.allVersions,
+			showTags:         l.showTags,
+			format:           l.format,
 		}
 
 		log.Info(msg)
+
+		// delete markers are not objects.
+		if !object.Type.IsDir() && !object.DeleteMarker {
+			total.Count++
+			total.Size += object.Size
+		}
+
+		printed++
+		if l.limit > 0 && printed >= l.limit {
+			limited = true
+			break
+		}
+	}
+
+	// the objects received after the limit is reached are discarded.
+	if limited {
+		cancel()
+		for range objch {
+		}
+	}
+
+	if l.summarize && !canceled {
+		total.Source = srcurl.String()
+		total.sizes = l.sizes
+		log.Info(total)
+	}
+
+	// keep the resume token file unless the listing is completed.
+	if l.resumeTokenFile != "" && merror == nil && !canceled && !limited {
+		if err := os.Remove(l.resumeTokenFile); err != nil && !os.IsNotExist(err) {
+			printError(l.fullCommand, l.op, err)
+			return err
+		}
 	}
 
 	return merror
 }
 
+// listResumable lists the objects at given source starting from the
+// continuation token recorded in the resume token file, and records the
+// progress of the listing to the file.
+func (l List) listResumable(ctx context.Context, srcurl *url.URL) (<-chan *storage.Object, error) {
+	client, err := storage.NewRemoteClient(ctx, srcurl, l.storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := readResumeToken(l.resumeTokenFile, l.src)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := func(token string) error {
+		return writeResumeToken(l.resumeTokenFile, l.src, token)
+	}
+	return client.ListResumable(ctx, srcurl, token, checkpoint), nil
+}
+
+// listAtTime lists the versions of the objects at given source which were
+// current at the given time, or which are current if no time is given.
+func (l List) listAtTime(ctx context.Context, srcurl *url.URL) (<-chan *storage.Object, error) {
+	client, err := storage.NewRemoteClient(ctx, srcurl, l.storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var at time.Time
+	if l.atTime != nil {
+		at = *l.atTime
+	}
+	return client.ListAtTime(ctx, srcurl, at), nil
+}
+
+// listVersions lists all of the versions and the delete markers of the
+// objects at given source.
+func (l List) listVersions(ctx context.Context, srcurl *url.URL) (<-chan *storage.Object, error) {
+	client, err := storage.NewRemoteClient(ctx, srcurl, l.storageOpts)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListVersions(ctx, srcurl), nil
+}
+
+// maxConcurrentFetches is the number of the objects whose tags or metadata
+// are fetched concurrently while listing.
+const maxConcurrentFetches = 32
+
+// fetchTags sets the tags of the objects received from the given channel.
+func fetchTags(
+	ctx context.Context,
+	objch <-chan *storage.Object,
+	getTags func(context.Context, *url.URL) (map[string]string, error),
+	skip func(*storage.Object) bool,
+) <-chan *storage.Object {
+	fetch := func(ctx context.Context, object *storage.Object) error {
+		tags, err := getTags(ctx, object.URL)
+		if err != nil {
+			return err
+		}
+		object.Tags = tags
+		return nil
+	}
+	return fetchDetails(ctx, objch, fetch, skip)
+}
+
+// fetchMetadata sets the metadata and the tags of the objects received from
+// the given channel.
+func fetchMetadata(
+	ctx context.Context,
+	objch <-chan *storage.Object,
+	client *storage.S3,
+	skip func(*storage.Object) bool,
+) <-chan *storage.Object {
+	fetch := func(ctx context.Context, object *storage.Object) error {
+		metadata, err := client.ObjectMetadata(ctx, object.URL)
+		if err != nil {
+			return err
+		}
+		tags, err := client.Tags(ctx, object.URL)
+		if err != nil {
+			return err
+		}
+		object.Metadata = metadata
+		object.Tags = tags
+		return nil
+	}
+	return fetchDetails(ctx, objch, fetch, skip)
+}
+
+// fetchDetails fills in the objects received from the given channel with the
+// given function, fetching the details of up to maxConcurrentFetches objects
+// concurrently. The objects are sent in the order they are received. Objects
+// whose details can't be fetched are sent with the error instead.
+func fetchDetails(
+	ctx context.Context,
+	objch <-chan *storage.Object,
+	fetch func(context.Context, *storage.Object) error,
+	skip func(*storage.Object) bool,
+) <-chan *storage.Object {
+	type pending struct {
+		object *storage.Object
+		done   chan struct{}
+	}
+
+	// the buffer of the pending objects bounds the number of the concurrent
+	// fetches, while the objects are still sent in order.
+	pendingch := make(chan *pending, maxConcurrentFetches)
+	go func() {
+		defer close(pendingch)
+
+		for object := range objch {
+			p := &pending{object: object, done: make(chan struct{})}
+			if object.Err != nil || object.Type.IsDir() || skip(object) {
+				close(p.done)
+			} else {
+				go func() {
+					defer close(p.done)
+
+					if err := fetch(ctx, p.object); err != nil {
+						p.object = &storage.Object{
+							Err: fmt.Errorf("%v: %w", p.object.URL, err),
+						}
+					}
+				}()
+			}
+
+			select {
+			case pendingch <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ch := make(chan *storage.Object)
+	go func() {
+		defer close(ch)
+
+		// the consumer may stop receiving, e.g. when the limit is reached,
+		// and cancel the context.
+		for p := range pendingch {
+			<-p.done
+			select {
+			case ch <- p.object:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 // ListMessage is a structure for logging ls results.
 type ListMessage struct {
 	Object *storage.Object `json:"object"`
 
 	showEtag         bool
-	showHumanized    bool
+	sizes            sizeFormat
 	showStorageClass bool
+	showVersionID    bool
+	showLatest       bool
+	showTags         bool
+	format           *listFormat
 }
 
 // humanize is a helper function to humanize bytes.
 func (l ListMessage) humanize() string {
-	var size string
-	if l.showHumanized {
-		size = strutil.HumanizeBytes(l.Object.Size)
-	} else {
-		size = fmt.Sprintf("%d", l.Object.Size)
-	}
-	return size
+	return l.sizes.format(l.Object.Size)
 }
 
 const (
@@ -219,16 +663,20 @@ const (
 
 // String returns the string representation of ListMessage.
 func (l ListMessage) String() string {
-	var listFormat = "%19s %2s %-1s %12s %s"
+	if l.format != nil {
+		return l.format.format(l)
+	}
+
+	var columns = "%19s %2s %-1s %12s %s"
 	var etag string
 	if l.showEtag {
 		etag = l.Object.Etag
-		listFormat = "%19s %2s %-38s %12s %s"
+		columns = "%19s %2s %-38s %12s %s"
 	}
 
 	if l.Object.Type.IsDir() {
 		s := fmt.Sprintf(
-			listFormat,
+			columns,
 			"",
 			"",
 			"",
@@ -243,13 +691,39 @@ func (l ListMessage) String() string {
 		stclass = fmt.Sprintf("%v", l.Object.StorageClass)
 	}
 
+	// the version id is put before the key, so that the key is still the
+	// last column.
+	key := l.Object.URL.Relative()
+	if l.showLatest {
+		latest := ""
+		if l.Object.IsLatest {
+			latest = "LATEST"
+		}
+		key = fmt.Sprintf("%-6s %s", latest, key)
+	}
+	if l.showVersionID {
+		key = fmt.Sprintf("%-32s %s", l.Object.VersionID, key)
+	}
+	if l.showTags {
+		tags := "-"
+		if len(l.Object.Tags) > 0 {
+			tags = storage.EncodeTags(l.Object.Tags)
+		}
+		key = fmt.Sprintf("%s %s", tags, key)
+	}
+
+	size := l.humanize()
+	if l.Object.DeleteMarker {
+		size = "DEL"
+	}
+
 	s := fmt.Sprintf(
-		listFormat,
+		columns,
 		l.Object.ModTime.Format(dateFormat),
 		stclass,
 		etag,
-		l.humanize(),
-		l.Object.URL.Relative(),
+		size,
+		key,
 	)
 	return s
 }
@@ -263,5 +737,222 @@ func validateLSCommand(c *cli.Context) error {
 	if c.Args().Len() > 1 {
 		return fmt.Errorf("expected only 1 argument")
 	}
+
+	if err := validateSizeFormat(c.String("humanize"), c.Int("precision")); err != nil {
+		return err
+	}
+
+	if c.IsSet("resume-token-file") {
+		if !c.Args().Present() {
+			return fmt.Errorf("resume-token-file can not be used while listing buckets")
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("resume-token-file can only be used with remote sources")
+		}
+		// the continuation tokens are of the listing of a single prefix.
+		if srcurl.IsBucketWildcard() || len(srcurl.ListPrefixes()) > 1 {
+			return fmt.Errorf("resume-token-file can not be used with bucket wildcards or brace groups")
+		}
+	}
+
+	if c.IsSet("limit") {
+		if !c.Args().Present() {
+			return fmt.Errorf("limit can not be used while listing buckets")
+		}
+		if c.Int("limit") < 1 {
+			return fmt.Errorf("limit must be a positive integer")
+		}
+	}
+
+	if c.IsSet("start-after") {
+		if !c.Args().Present() {
+			return fmt.Errorf("start-after can not be used while listing buckets")
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("start-after can only be used with remote sources")
+		}
+		// the versions are listed from the first key.
+		if c.IsSet("at-time") || c.Bool("all-versions") || c.Bool("show-version-id") {
+			return fmt.Errorf("start-after can not be used with at-time, all-versions or show-version-id")
+		}
+	}
+
+	if c.IsSet("delimiter") {
+		if !c.Args().Present() {
+			return fmt.Errorf("delimiter can not be used while listing buckets")
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("delimiter can only be used with remote sources")
+		}
+		// the wildcard operations list the objects without grouping.
+		if srcurl.IsWildcard() {
+			return fmt.Errorf("delimiter can not be used with wildcards")
+		}
+		// the versions are listed without grouping.
+		if c.IsSet("at-time") || c.Bool("all-versions") || c.Bool("show-version-id") {
+			return fmt.Errorf("delimiter can not be used with at-time, all-versions or show-version-id")
+		}
+	}
+
+	if c.IsSet("format") {
+		if !c.Args().Present() {
+			return fmt.Errorf("format can not be used while listing buckets")
+		}
+		if c.Bool("json") {
+			return fmt.Errorf("format can not be used with json")
+		}
+		format, err := parseListFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if format.hasTags() && !srcurl.IsRemote() {
+			return fmt.Errorf("tags field of format can only be used with remote sources")
+		}
+	}
+
+	if c.Bool("summarize") && !c.Args().Present() {
+		return fmt.Errorf("summarize can not be used while listing buckets")
+	}
+
+	if c.IsSet("at-time") {
+		if !c.Args().Present() {
+			return fmt.Errorf("at-time can not be used while listing buckets")
+		}
+
+		if c.IsSet("resume-token-file") {
+			return fmt.Errorf("at-time can not be used with resume-token-file")
+		}
+
+		if _, err := time.Parse(time.RFC3339, c.String("at-time")); err != nil {
+			return fmt.Errorf("at-time %q must be in RFC3339 format, e.g. 2021-06-01T12:00:00Z", c.String("at-time"))
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("at-time can only be used with remote sources")
+		}
+		if !srcurl.IsWildcard() && (srcurl.IsBucket() || srcurl.IsPrefix()) {
+			return fmt.Errorf("at-time requires a wildcard or an object key, e.g. s3://bucket/prefix/*")
+		}
+	}
+
+	if c.Bool("all-versions") {
+		if !c.Args().Present() {
+			return fmt.Errorf("all-versions can not be used while listing buckets")
+		}
+
+		for _, flag := range []string{"at-time", "resume-token-file", "show-tags", "fetch-metadata"} {
+			if c.IsSet(flag) {
+				return fmt.Errorf("all-versions can not be used with %v", flag)
+			}
+		}
+		if format, err := parseListFormat(c.String("format")); err == nil && format.hasTags() {
+			return fmt.Errorf("all-versions can not be used with tags field of format")
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("all-versions can only be used with remote sources")
+		}
+		if !srcurl.IsWildcard() && (srcurl.IsBucket() || srcurl.IsPrefix()) {
+			return fmt.Errorf("all-versions requires a wildcard or an object key, e.g. s3://bucket/prefix/*")
+		}
+	}
+
+	if c.Bool("show-version-id") {
+		if !c.Args().Present() {
+			return fmt.Errorf("show-version-id can not be used while listing buckets")
+		}
+
+		if c.IsSet("resume-token-file") {
+			return fmt.Errorf("show-version-id can not be used with resume-token-file")
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("show-version-id can only be used with remote sources")
+		}
+		if !srcurl.IsWildcard() && (srcurl.IsBucket() || srcurl.IsPrefix()) {
+			return fmt.Errorf("show-version-id requires a wildcard or an object key, e.g. s3://bucket/prefix/*")
+		}
+	}
+
+	if c.IsSet("older-than") || c.IsSet("newer-than") {
+		if !c.Args().Present() {
+			return fmt.Errorf("older-than and newer-than can not be used while listing buckets")
+		}
+		if _, err := parseAgeFilter(c); err != nil {
+			return err
+		}
+	}
+
+	if c.IsSet("min-size") || c.IsSet("max-size") {
+		if !c.Args().Present() {
+			return fmt.Errorf("min-size and max-size can not be used while listing buckets")
+		}
+		if _, err := parseSizeFilter(c); err != nil {
+			return err
+		}
+	}
+
+	if c.Bool("show-tags") {
+		if !c.Args().Present() {
+			return fmt.Errorf("show-tags can not be used while listing buckets")
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("show-tags can only be used with remote sources")
+		}
+	}
+
+	if c.Bool("fetch-metadata") {
+		if !c.Args().Present() {
+			return fmt.Errorf("fetch-metadata can not be used while listing buckets")
+		}
+		if !c.Bool("json") {
+			return fmt.Errorf("fetch-metadata requires --json")
+		}
+
+		srcurl, err := url.New(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("fetch-metadata can only be used with remote sources")
+		}
+	}
 	return nil
 }
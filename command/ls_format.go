@@ -0,0 +1,151 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+// listFormatFields are the fields of the objects which can be used in the
+// format of ls output.
+var listFormatFields = map[string]func(l ListMessage) string{
+	"key": func(l ListMessage) string {
+		return l.Object.URL.Relative()
+	},
+	"url": func(l ListMessage) string {
+		return l.Object.URL.String()
+	},
+	"size": func(l ListMessage) string {
+		if l.Object.Type.IsDir() {
+			return ""
+		}
+		return l.humanize()
+	},
+	"last_modified": func(l ListMessage) string {
+		if l.Object.ModTime == nil {
+			return ""
+		}
+		return l.Object.ModTime.UTC().Format(time.RFC3339)
+	},
+	"storage_class": func(l ListMessage) string {
+		return string(l.Object.StorageClass)
+	},
+	"etag": func(l ListMessage) string {
+		return l.Object.Etag
+	},
+	"version_id": func(l ListMessage) string {
+		return l.Object.VersionID
+	},
+	"type": func(l ListMessage) string {
+		return l.Object.Type.String()
+	},
+	"tags": func(l ListMessage) string {
+		return storage.EncodeTags(l.Object.Tags)
+	},
+}
+
+// listFormat is the format of ls output, e.g. "{key}\t{size}". Fields are
+// given in braces, and \t, \n and \\ escapes are interpreted.
+type listFormat struct {
+	// parts are either literal texts or fields.
+	parts []listFormatPart
+}
+
+type listFormatPart struct {
+	text  string
+	field string
+}
+
+// parseListFormat parses the format of ls output.
+func parseListFormat(format string) (*listFormat, error) {
+	var (
+		f    listFormat
+		text strings.Builder
+	)
+
+	flush := func() {
+		if text.Len() > 0 {
+			f.parts = append(f.parts, listFormatPart{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; c {
+		case '\\':
+			if i+1 == len(format) {
+				text.WriteByte(c)
+				continue
+			}
+			i++
+			switch format[i] {
+			case 't':
+				text.WriteByte('\t')
+			case 'n':
+				text.WriteByte('\n')
+			case '\\':
+				text.WriteByte('\\')
+			default:
+				text.WriteByte(c)
+				text.WriteByte(format[i])
+			}
+		case '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("format %q has an unclosed brace", format)
+			}
+			name := format[i+1 : i+end]
+			if _, ok := listFormatFields[name]; !ok {
+				return nil, fmt.Errorf("unknown field %q in format, expected one of %v", name, listFormatFieldNames())
+			}
+			flush()
+			f.parts = append(f.parts, listFormatPart{field: name})
+			i += end
+		default:
+			text.WriteByte(c)
+		}
+	}
+	flush()
+
+	return &f, nil
+}
+
+// listFormatFieldNames returns the sorted names of the fields.
+func listFormatFieldNames() string {
+	var names []string
+	for name := range listFormatFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// hasTags reports whether the tags of the objects are shown, so that they
+// must be fetched.
+func (f *listFormat) hasTags() bool {
+	if f == nil {
+		return false
+	}
+	for _, part := range f.parts {
+		if part.field == "tags" {
+			return true
+		}
+	}
+	return false
+}
+
+// format formats the object of the message.
+func (f *listFormat) format(l ListMessage) string {
+	var s strings.Builder
+	for _, part := range f.parts {
+		if part.field != "" {
+			s.WriteString(listFormatFields[part.field](l))
+		} else {
+			s.WriteString(part.text)
+		}
+	}
+	return s.String()
+}
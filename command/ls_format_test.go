@@ -0,0 +1,110 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestListFormat(t *testing.T) {
+	t.Parallel()
+
+	srcurl, err := url.New("s3://bucket/prefix/*")
+	assert.NoError(t, err)
+	assert.True(t, srcurl.Match("prefix/dir/file.txt"))
+
+	objurl := srcurl.Clone()
+	objurl.Path = "prefix/dir/file.txt"
+
+	modTime := time.Date(2021, 1, 2, 15, 4, 5, 0, time.UTC)
+	object := &storage.Object{
+		URL:          objurl,
+		Etag:         "abc",
+		ModTime:      &modTime,
+		Size:         1536,
+		StorageClass: storage.StorageClass("GLACIER"),
+		VersionID:    "v1",
+		Tags:         map[string]string{"env": "prod"},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		humanize bool
+		want     string
+	}{
+		{
+			name:   "tab separated",
+			format: `{key}\t{size}\t{storage_class}\t{etag}`,
+			want:   "dir/file.txt\t1536\tGLACIER\tabc",
+		},
+		{
+			name:     "humanized",
+			format:   "{size} {url}",
+			humanize: true,
+			want:     "1.5K s3://bucket/prefix/dir/file.txt",
+		},
+		{
+			name:   "other fields",
+			format: "{last_modified},{version_id},{type},{tags}",
+			want:   "2021-01-02T15:04:05Z,v1,file,env=prod",
+		},
+		{
+			name:   "escapes",
+			format: `{key}\n\\t\x`,
+			want:   "dir/file.txt\n\\t\\x",
+		},
+		{
+			name:   "literal text only",
+			format: "text",
+			want:   "text",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			format, err := parseListFormat(tc.format)
+			assert.NoError(t, err)
+
+			sizes := newSizeFormat(sizeUnitRaw, false, defaultSizePrecision)
+			if tc.humanize {
+				sizes = newSizeFormat(sizeUnitIEC, false, defaultSizePrecision)
+			}
+
+			msg := ListMessage{Object: object, sizes: sizes, format: format}
+			assert.Equal(t, tc.want, msg.String())
+		})
+	}
+}
+
+func TestParseListFormatInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseListFormat("{key}\t{name}")
+	assert.EqualError(t, err, `unknown field "name" in format, expected one of etag, key, last_modified, size, storage_class, tags, type, url, version_id`)
+
+	_, err = parseListFormat("{key")
+	assert.EqualError(t, err, `format "{key" has an unclosed brace`)
+}
+
+func TestListFormatHasTags(t *testing.T) {
+	t.Parallel()
+
+	var format *listFormat
+	assert.False(t, format.hasTags())
+
+	format, err := parseListFormat("{key} tags")
+	assert.NoError(t, err)
+	assert.False(t, format.hasTags())
+
+	format, err = parseListFormat("{key} {tags}")
+	assert.NoError(t, err)
+	assert.True(t, format.hasTags())
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestFetchTags(t *testing.T) {
+	t.Parallel()
+
+	const count = 100
+
+	objch := make(chan *storage.Object)
+	go func() {
+		defer close(objch)
+		for i := 0; i < count; i++ {
+			u, _ := url.New(fmt.Sprintf("s3://bucket/%03d", i))
+			objch <- &storage.Object{URL: u}
+		}
+	}()
+
+	errAccessDenied := errors.New("access denied")
+	getTags := func(ctx context.Context, u *url.URL) (map[string]string, error) {
+		// the earlier objects are fetched later, so that the order of the
+		// fetches differs from the order of the listing.
+		var i int
+		fmt.Sscanf(u.Path, "%d", &i)
+		time.Sleep(time.Duration(count-i) * 10 * time.Microsecond)
+
+		if i == 42 {
+			return nil, errAccessDenied
+		}
+		return map[string]string{"index": u.Path}, nil
+	}
+	skip := func(object *storage.Object) bool {
+		return object.URL.Path == "007"
+	}
+
+	var i int
+	for object := range fetchTags(context.Background(), objch, getTags, skip) {
+		switch i {
+		case 7:
+			assert.Equal(t, "007", object.URL.Path)
+			assert.Nil(t, object.Tags)
+		case 42:
+			assert.True(t, errors.Is(object.Err, errAccessDenied))
+		default:
+			assert.Equal(t, fmt.Sprintf("%03d", i), object.URL.Path)
+			assert.Equal(t, map[string]string{"index": object.URL.Path}, object.Tags)
+		}
+		i++
+	}
+	assert.Equal(t, count, i)
+}
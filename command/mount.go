@@ -0,0 +1,719 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var mountHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] source mountpoint
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Mount a prefix read-only until interrupted or unmounted
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/ /mnt/prefix
+
+	2. Mount a bucket, caching the directory listings for 5 minutes
+		 > s5cmd {{.HelpName}} --cache-ttl 5m s3://bucket /mnt/bucket
+`
+
+// defaultMountCacheTTL is the default duration the directory listings and the
+// attributes of the objects are cached for.
+const defaultMountCacheTTL = time.Minute
+
+const (
+	// mountReadAheadParts is the number of the parts of an opened object
+	// which are fetched concurrently ahead of the sequential reads.
+	mountReadAheadParts = 4
+
+	// mountReadAheadPartSize is the size of the parts fetched ahead.
+	mountReadAheadPartSize = 4 * 1024 * 1024
+
+	// mountReadBehind is the number of the bytes kept behind the last read
+	// of an opened object, since the kernel may send the reads slightly out
+	// of order.
+	mountReadBehind = 1024 * 1024
+)
+
+func NewMountCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "mount",
+		HelpName:           "mount",
+		Usage:              "mount a bucket or a prefix as a read-only filesystem",
+		CustomHelpTemplate: mountHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "cache-ttl",
+				Value: defaultMountCacheTTL,
+				Usage: "cache the directory listings and the attributes of the objects for given duration, the changes in the bucket are visible after it",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateMountCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return Mount{
+				src:         c.Args().Get(0),
+				dst:         c.Args().Get(1),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				cacheTTL:    c.Duration("cache-ttl"),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Mount holds mount operation flags and states.
+type Mount struct {
+	src         string
+	dst         string
+	op          string
+	fullCommand string
+
+	// flags
+	cacheTTL time.Duration
+
+	storageOpts storage.Options
+}
+
+// Run serves the objects under the source prefix as a filesystem at the
+// mountpoint until the context is done or the filesystem is unmounted.
+func (m Mount) Run(ctx context.Context) error {
+	srcurl, err := url.New(m.src)
+	if err != nil {
+		printError(m.fullCommand, m.op, err)
+		return err
+	}
+
+	mountpoint, err := filepath.Abs(m.dst)
+	if err != nil {
+		printError(m.fullCommand, m.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, m.storageOpts)
+	if err != nil {
+		printError(m.fullCommand, m.op, err)
+		return err
+	}
+
+	prefix := srcurl.Path
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	fsys := newObjectFS(ctx, client, srcurl.Bucket, prefix, m.cacheTTL)
+	fsys.onError = func(err error) {
+		printError(m.fullCommand, m.op, err)
+	}
+
+	err = mountFS(ctx, mountpoint, fsys, func() {
+		log.Info(MountMessage{Source: srcurl, Mountpoint: mountpoint})
+	})
+	if err != nil {
+		printError(m.fullCommand, m.op, err)
+		return err
+	}
+	return nil
+}
+
+// MountMessage is a structure for logging the mountpoint the objects are
+// served at.
+type MountMessage struct {
+	Source     *url.URL `json:"source"`
+	Mountpoint string   `json:"mountpoint"`
+}
+
+// String returns the string representation of MountMessage.
+func (m MountMessage) String() string {
+	return fmt.Sprintf("mounted %v at %s", m.Source, m.Mountpoint)
+}
+
+// JSON returns the JSON representation of MountMessage.
+func (m MountMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+var (
+	// errNotDirectory indicates a directory operation on an object.
+	errNotDirectory = fmt.Errorf("not a directory")
+
+	// errIsDirectory indicates an object operation on a directory.
+	errIsDirectory = fmt.Errorf("is a directory")
+)
+
+// mountRootIno is the inode number of the root directory of the mounted
+// filesystems.
+const mountRootIno = 1
+
+// mountClient is the storage the mounted filesystems read from.
+type mountClient interface {
+	List(ctx context.Context, src *url.URL, followSymlinks bool) <-chan *storage.Object
+	ReadRange(ctx context.Context, src *url.URL, offset, length int64) (io.ReadCloser, error)
+	ReadParallel(ctx context.Context, src *url.URL, concurrency int, partSize int64) (io.ReadCloser, error)
+}
+
+// fsEntry is an object or a directory of a mounted filesystem. The paths of
+// the entries are relative to the mounted prefix, without trailing slashes.
+type fsEntry struct {
+	ino     uint64
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+// fsNode is an entry known by the kernel, until it is forgotten.
+type fsNode struct {
+	fsEntry
+	path    string
+	lookups uint64
+}
+
+// dirListing is a cached listing of a directory.
+type dirListing struct {
+	// ready is closed once the directory is listed, the concurrent lookups
+	// in the directory wait for the same listing.
+	ready   chan struct{}
+	entries []fsEntry
+	byName  map[string]int
+	err     error
+	expires time.Time
+}
+
+// fileHandle is an opened object. The object is read ahead with concurrent
+// requests while it is read sequentially from the start, and it is read
+// with a ranged request per read otherwise.
+type fileHandle struct {
+	ino uint64
+
+	mu     sync.Mutex
+	stream io.ReadCloser
+	// buf holds the bytes of the object from the offset start, which are
+	// read from the stream but not dropped yet.
+	buf   []byte
+	start int64
+	// ranged is set once the object is not read sequentially anymore.
+	ranged bool
+}
+
+// close stops reading the object ahead.
+func (h *fileHandle) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopReadAhead()
+}
+
+func (h *fileHandle) stopReadAhead() {
+	if h.stream != nil {
+		h.stream.Close()
+		h.stream = nil
+	}
+	h.buf = nil
+	h.ranged = true
+}
+
+// objectFS is a read-only filesystem of the objects under a prefix of a
+// bucket. The prefixes are the directories, and the directory listings are
+// cached for the TTL.
+type objectFS struct {
+	ctx      context.Context
+	client   mountClient
+	bucket   string
+	prefix   string
+	cacheTTL time.Duration
+	onError  func(error)
+
+	// mountTime is the modification time of the directories, which is not
+	// known for the prefixes.
+	mountTime time.Time
+
+	mu       sync.Mutex
+	nodes    map[uint64]*fsNode
+	listings map[string]*dirListing
+}
+
+func newObjectFS(ctx context.Context, client mountClient, bucket, prefix string, cacheTTL time.Duration) *objectFS {
+	now := time.Now()
+	return &objectFS{
+		ctx:       ctx,
+		client:    client,
+		bucket:    bucket,
+		prefix:    prefix,
+		cacheTTL:  cacheTTL,
+		onError:   func(error) {},
+		mountTime: now,
+		nodes: map[uint64]*fsNode{
+			mountRootIno: {
+				fsEntry: fsEntry{ino: mountRootIno, dir: true, modTime: now},
+			},
+		},
+		listings: map[string]*dirListing{},
+	}
+}
+
+// inode returns the inode number of the given path. The inode numbers are
+// the hashes of the paths, so that the entries have the same numbers in the
+// listings and the lookups.
+func inode(path string) uint64 {
+	if path == "" {
+		return mountRootIno
+	}
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	ino := h.Sum64()
+	// the lower numbers are reserved for the root.
+	if ino <= mountRootIno {
+		ino += mountRootIno + 1
+	}
+	return ino
+}
+
+// parentPath returns the path of the directory of the given path.
+func parentPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// node returns the node of the given inode number.
+func (f *objectFS) node(ino uint64) (fsNode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	node, ok := f.nodes[ino]
+	if !ok {
+		return fsNode{}, os.ErrNotExist
+	}
+	return *node, nil
+}
+
+// lookup returns the entry of the given name in the directory, and
+// increments its lookup count.
+func (f *objectFS) lookup(ctx context.Context, parent uint64, name string) (fsEntry, error) {
+	dir, err := f.node(parent)
+	if err != nil {
+		return fsEntry{}, err
+	}
+	if !dir.dir {
+		return fsEntry{}, errNotDirectory
+	}
+
+	listing, err := f.list(ctx, dir.path)
+	if err != nil {
+		return fsEntry{}, err
+	}
+
+	i, ok := listing.byName[name]
+	if !ok {
+		return fsEntry{}, os.ErrNotExist
+	}
+	entry := listing.entries[i]
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	node, ok := f.nodes[entry.ino]
+	if !ok {
+		node = &fsNode{path: joinPath(dir.path, name)}
+		f.nodes[entry.ino] = node
+	}
+	node.fsEntry = entry
+	node.lookups++
+	return entry, nil
+}
+
+// forget decrements the lookup count of the node, the nodes which are not
+// known by the kernel anymore are removed.
+func (f *objectFS) forget(ino, n uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	node, ok := f.nodes[ino]
+	if !ok || ino == mountRootIno {
+		return
+	}
+	if n >= node.lookups {
+		delete(f.nodes, ino)
+		return
+	}
+	node.lookups -= n
+}
+
+// getattr returns the entry of the given inode number.
+func (f *objectFS) getattr(ino uint64) (fsEntry, error) {
+	node, err := f.node(ino)
+	if err != nil {
+		return fsEntry{}, err
+	}
+	return node.fsEntry, nil
+}
+
+// readDir returns the entries of the directory, including "." and "..".
+func (f *objectFS) readDir(ctx context.Context, ino uint64) ([]fsEntry, error) {
+	dir, err := f.node(ino)
+	if err != nil {
+		return nil, err
+	}
+	if !dir.dir {
+		return nil, errNotDirectory
+	}
+
+	listing, err := f.list(ctx, dir.path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]fsEntry, 0, len(listing.entries)+2)
+	entries = append(entries,
+		fsEntry{ino: dir.ino, name: ".", dir: true},
+		fsEntry{ino: inode(parentPath(dir.path)), name: "..", dir: true},
+	)
+	return append(entries, listing.entries...), nil
+}
+
+// open returns a handle of the object to read it.
+func (f *objectFS) open(ino uint64) (*fileHandle, error) {
+	node, err := f.node(ino)
+	if err != nil {
+		return nil, err
+	}
+	if node.dir {
+		return nil, errIsDirectory
+	}
+	return &fileHandle{ino: ino}, nil
+}
+
+// objectURL returns the URL of the object of the given node.
+func (f *objectFS) objectURL(node fsNode) (*url.URL, error) {
+	return url.New(fmt.Sprintf("s3://%s/%s%s", f.bucket, f.prefix, node.path), url.WithRaw(true))
+}
+
+// readLength returns the node of the object and the number of the bytes to
+// read at the given offset.
+func (f *objectFS) readLength(ino uint64, offset int64, size int) (fsNode, int64, error) {
+	node, err := f.node(ino)
+	if err != nil {
+		return fsNode{}, 0, err
+	}
+	if node.dir {
+		return fsNode{}, 0, errIsDirectory
+	}
+	if offset >= node.size {
+		return node, 0, nil
+	}
+
+	length := node.size - offset
+	if int64(size) < length {
+		length = int64(size)
+	}
+	return node, length, nil
+}
+
+// readHandle reads up to size bytes of the opened object at the given
+// offset. The sequential reads are served from the bytes read ahead, the
+// others fall back to the ranged reads.
+func (f *objectFS) readHandle(ctx context.Context, h *fileHandle, offset int64, size int) ([]byte, error) {
+	node, length, err := f.readLength(h.ino, offset, size)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.ranged {
+		if data, ok := f.readAhead(h, node, offset, length); ok {
+			return data, nil
+		}
+		h.stopReadAhead()
+	}
+	return f.read(ctx, h.ino, offset, size)
+}
+
+// readAhead returns length bytes of the object at the given offset from the
+// stream of the handle. It reports false if the bytes can't be read from
+// the stream, e.g. the offset is behind the bytes kept or the stream fails.
+func (f *objectFS) readAhead(h *fileHandle, node fsNode, offset, length int64) ([]byte, bool) {
+	if h.stream == nil {
+		if offset != 0 {
+			return nil, false
+		}
+		u, err := f.objectURL(node)
+		if err != nil {
+			return nil, false
+		}
+		// the stream outlives the read request, it is closed with the
+		// handle.
+		stream, err := f.client.ReadParallel(f.ctx, u, mountReadAheadParts, mountReadAheadPartSize)
+		if err != nil {
+			return nil, false
+		}
+		h.stream = stream
+	}
+
+	end := h.start + int64(len(h.buf))
+	if offset < h.start || offset > end+mountReadAheadPartSize {
+		return nil, false
+	}
+
+	if need := offset + length - end; need > 0 {
+		n := len(h.buf)
+		h.buf = append(h.buf, make([]byte, need)...)
+		m, err := io.ReadFull(h.stream, h.buf[n:])
+		h.buf = h.buf[:n+m]
+		// the object is replaced by a smaller one since it is looked up.
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, false
+		}
+		end = h.start + int64(len(h.buf))
+	}
+
+	var data []byte
+	if offset < end {
+		last := offset + length
+		if last > end {
+			last = end
+		}
+		data = append(data, h.buf[offset-h.start:last-h.start]...)
+	}
+
+	// the bytes far behind the read are dropped, the buffer is compacted
+	// only once enough bytes are dropped.
+	if drop := offset - mountReadBehind - h.start; drop > mountReadBehind {
+		h.buf = append([]byte(nil), h.buf[drop:]...)
+		h.start += drop
+	}
+	return data, true
+}
+
+// read reads up to size bytes of the object at the given offset.
+func (f *objectFS) read(ctx context.Context, ino uint64, offset int64, size int) ([]byte, error) {
+	node, length, err := f.readLength(ino, offset, size)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+
+	u, err := f.objectURL(node)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := f.client.ReadRange(ctx, u, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	buf := make([]byte, length)
+	n, err := io.ReadFull(body, buf)
+	// the object is replaced by a smaller one since it is looked up.
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:n], err
+}
+
+// list returns the listing of the directory, from the cache if it is not
+// expired.
+func (f *objectFS) list(ctx context.Context, dir string) (*dirListing, error) {
+	now := time.Now()
+
+	f.mu.Lock()
+	listing, ok := f.listings[dir]
+	if ok {
+		select {
+		case <-listing.ready:
+			if now.After(listing.expires) {
+				ok = false
+			}
+		default:
+		}
+	}
+
+	if ok {
+		f.mu.Unlock()
+		select {
+		case <-listing.ready:
+			return listing, listing.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// the expired listings of the other directories are removed, so that
+	// the cache doesn't grow with the walks of the tree.
+	for path, cached := range f.listings {
+		select {
+		case <-cached.ready:
+			if now.After(cached.expires) {
+				delete(f.listings, path)
+			}
+		default:
+		}
+	}
+
+	listing = &dirListing{ready: make(chan struct{})}
+	f.listings[dir] = listing
+	f.mu.Unlock()
+
+	// the directory is listed with the context of the filesystem, since the
+	// listing is shared by the lookups which may be interrupted.
+	listing.entries, listing.err = f.fetch(dir)
+	listing.byName = make(map[string]int, len(listing.entries))
+	for i, entry := range listing.entries {
+		listing.byName[entry.name] = i
+	}
+	listing.expires = time.Now().Add(f.cacheTTL)
+
+	// the failed listings are not cached.
+	if listing.err != nil {
+		f.mu.Lock()
+		if f.listings[dir] == listing {
+			delete(f.listings, dir)
+		}
+		f.mu.Unlock()
+	}
+	close(listing.ready)
+
+	return listing, listing.err
+}
+
+// fetch lists the objects and the prefixes of the directory. The objects
+// which have the same names as the prefixes are shadowed by the directories.
+// The prefix is listed literally, so the names may contain glob characters.
+func (f *objectFS) fetch(dir string) ([]fsEntry, error) {
+	listPrefix := f.prefix
+	if dir != "" {
+		listPrefix += dir + "/"
+	}
+
+	u, err := url.New(fmt.Sprintf("s3://%s/%s", f.bucket, listPrefix), url.WithRaw(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fsEntry
+	byName := map[string]int{}
+	for object := range f.client.List(f.ctx, u, false) {
+		if errors.Is(object.Err, storage.ErrNoObjectFound) {
+			continue
+		}
+		if err := object.Err; err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(object.URL.Path, listPrefix)
+		isDir := object.Type.IsDir() || strings.HasSuffix(name, "/")
+		name = strings.TrimSuffix(name, "/")
+		if !isValidEntryName(name) {
+			continue
+		}
+
+		entry := fsEntry{
+			ino:     inode(joinPath(dir, name)),
+			name:    name,
+			dir:     isDir,
+			modTime: f.mountTime,
+		}
+		if !isDir {
+			entry.size = object.Size
+			if object.ModTime != nil {
+				entry.modTime = *object.ModTime
+			}
+		}
+
+		if i, ok := byName[name]; ok {
+			if isDir {
+				entries[i] = entry
+			}
+			continue
+		}
+		byName[name] = len(entries)
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return entries, nil
+}
+
+// isValidEntryName reports whether the name can be a name of a file in a
+// directory.
+func isValidEntryName(name string) bool {
+	if name == "" || name == "." || name == ".." || len(name) > 255 {
+		return false
+	}
+	return !strings.ContainsAny(name, "/\x00")
+}
+
+func validateMountCommand(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("expected source and mountpoint arguments")
+	}
+
+	srcurl, err := url.New(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be a bucket or a prefix")
+	}
+	if srcurl.IsWildcard() {
+		return fmt.Errorf("source can not contain glob characters")
+	}
+
+	info, err := os.Stat(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("mountpoint must be a directory")
+	}
+
+	if c.Duration("cache-ttl") < 0 {
+		return fmt.Errorf("cache-ttl can not be negative")
+	}
+	return nil
+}
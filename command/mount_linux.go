@@ -0,0 +1,761 @@
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/storage"
+)
+
+// the version of the FUSE kernel protocol the filesystems are served with.
+const (
+	fuseKernelVersion      = 7
+	fuseKernelMinorVersion = 31
+)
+
+// the opcodes of the FUSE requests which are handled, the others are replied
+// with ENOSYS.
+const (
+	fuseLookup      = 1
+	fuseForget      = 2
+	fuseGetattr     = 3
+	fuseOpen        = 14
+	fuseRead        = 15
+	fuseStatfs      = 17
+	fuseRelease     = 18
+	fuseFlush       = 25
+	fuseInit        = 26
+	fuseOpendir     = 27
+	fuseReaddir     = 28
+	fuseReleasedir  = 29
+	fuseInterrupt   = 36
+	fuseDestroy     = 38
+	fuseBatchForget = 42
+)
+
+// the capabilities of the kernel which are enabled. The reads are sent
+// concurrently and up to fuseMaxPages pages, so that the objects are read
+// with parallel ranged reads.
+const (
+	fuseAsyncRead       = 1 << 0
+	fuseParallelDirops  = 1 << 18
+	fuseMaxPagesEnabled = 1 << 22
+)
+
+const (
+	fuseMaxWrite      = 128 * 1024
+	fuseMaxPages      = 256
+	fuseMaxBackground = 64
+	fuseBlockSize     = 4096
+
+	// fuseReadBufferSize is the size of the buffer the requests are read
+	// into, which must hold the largest write request.
+	fuseReadBufferSize = fuseMaxWrite + 4096
+
+	// fuseCompatInitOutSize is the size of the init reply of the kernels
+	// older than 7.23.
+	fuseCompatInitOutSize = 24
+)
+
+// the structures of the FUSE kernel protocol, see linux/fuse.h.
+type (
+	fuseInHeader struct {
+		Len    uint32
+		Opcode uint32
+		Unique uint64
+		Nodeid uint64
+		UID    uint32
+		GID    uint32
+		PID    uint32
+		_      uint32
+	}
+
+	fuseOutHeader struct {
+		Len    uint32
+		Error  int32
+		Unique uint64
+	}
+
+	fuseInitIn struct {
+		Major        uint32
+		Minor        uint32
+		MaxReadahead uint32
+		Flags        uint32
+	}
+
+	fuseInitOut struct {
+		Major               uint32
+		Minor               uint32
+		MaxReadahead        uint32
+		Flags               uint32
+		MaxBackground       uint16
+		CongestionThreshold uint16
+		MaxWrite            uint32
+		TimeGran            uint32
+		MaxPages            uint16
+		MapAlignment        uint16
+		Flags2              uint32
+		_                   [7]uint32
+	}
+
+	fuseAttr struct {
+		Ino       uint64
+		Size      uint64
+		Blocks    uint64
+		Atime     uint64
+		Mtime     uint64
+		Ctime     uint64
+		Atimensec uint32
+		Mtimensec uint32
+		Ctimensec uint32
+		Mode      uint32
+		Nlink     uint32
+		UID       uint32
+		GID       uint32
+		Rdev      uint32
+		Blksize   uint32
+		Flags     uint32
+	}
+
+	fuseEntryOut struct {
+		Nodeid         uint64
+		Generation     uint64
+		EntryValid     uint64
+		AttrValid      uint64
+		EntryValidNsec uint32
+		AttrValidNsec  uint32
+		Attr           fuseAttr
+	}
+
+	fuseAttrOut struct {
+		AttrValid     uint64
+		AttrValidNsec uint32
+		_             uint32
+		Attr          fuseAttr
+	}
+
+	fuseOpenIn struct {
+		Flags     uint32
+		OpenFlags uint32
+	}
+
+	fuseOpenOut struct {
+		Fh        uint64
+		OpenFlags uint32
+		_         uint32
+	}
+
+	fuseReadIn struct {
+		Fh        uint64
+		Offset    uint64
+		Size      uint32
+		ReadFlags uint32
+		LockOwner uint64
+		Flags     uint32
+		_         uint32
+	}
+
+	fuseReleaseIn struct {
+		Fh           uint64
+		Flags        uint32
+		ReleaseFlags uint32
+		LockOwner    uint64
+	}
+
+	fuseForgetIn struct {
+		Nlookup uint64
+	}
+
+	fuseBatchForgetIn struct {
+		Count uint32
+		_     uint32
+	}
+
+	fuseForgetOne struct {
+		Nodeid  uint64
+		Nlookup uint64
+	}
+
+	fuseInterruptIn struct {
+		Unique uint64
+	}
+
+	fuseKstatfs struct {
+		Blocks  uint64
+		Bfree   uint64
+		Bavail  uint64
+		Files   uint64
+		Ffree   uint64
+		Bsize   uint32
+		Namelen uint32
+		Frsize  uint32
+		_       uint32
+		_       [6]uint32
+	}
+
+	fuseDirent struct {
+		Ino     uint64
+		Off     uint64
+		Namelen uint32
+		Type    uint32
+	}
+)
+
+// structBytes returns the memory of the structure of the given size.
+func structBytes(p unsafe.Pointer, size uintptr) []byte {
+	return (*[1 << 20]byte)(p)[:size:size]
+}
+
+// mountFS mounts the filesystem at the mountpoint and serves it until the
+// context is done or the filesystem is unmounted. mounted is called once the
+// filesystem is mounted.
+func mountFS(ctx context.Context, mountpoint string, fsys *objectFS, mounted func()) error {
+	file, err := mountFUSE(mountpoint)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	mounted()
+
+	s := &fuseServer{
+		fs:       fsys,
+		file:     file,
+		uid:      uint32(os.Getuid()),
+		gid:      uint32(os.Getgid()),
+		dirs:     map[uint64][]fsEntry{},
+		files:    map[uint64]*fileHandle{},
+		requests: map[uint64]context.CancelFunc{},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// the mount is detached, so that it is removed once it is not in
+			// use anymore, and the connection is aborted by closing the
+			// device.
+			if err := unmountFUSE(mountpoint); err != nil {
+				fsys.onError(err)
+			}
+			file.Close()
+		case <-done:
+		}
+	}()
+
+	err = s.serve()
+	close(done)
+	s.wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// mountFUSE mounts a FUSE filesystem at the mountpoint, and returns the
+// device the requests of the filesystem are read from. The filesystems are
+// mounted by fusermount unless the process is privileged.
+func mountFUSE(mountpoint string) (*os.File, error) {
+	if os.Geteuid() != 0 {
+		return fusermount(mountpoint)
+	}
+
+	fd, err := syscall.Open("/dev/fuse", syscall.O_RDWR|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: "/dev/fuse", Err: err}
+	}
+
+	options := fmt.Sprintf("fd=%d,rootmode=%o,user_id=%d,group_id=%d", fd, syscall.S_IFDIR, os.Getuid(), os.Getgid())
+	flags := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_RDONLY)
+	if err := syscall.Mount("s5cmd", mountpoint, "fuse.s5cmd", flags, options); err != nil {
+		syscall.Close(fd)
+		return nil, &os.PathError{Op: "mount", Path: mountpoint, Err: err}
+	}
+
+	// the reads of non-blocking files are interrupted when they are closed.
+	return os.NewFile(uintptr(fd), "/dev/fuse"), nil
+}
+
+// fusermountBinary returns the path of the fusermount binary.
+func fusermountBinary() (string, error) {
+	for _, name := range []string{"fusermount3", "fusermount"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("fusermount is not found, it is required to mount filesystems as an unprivileged user")
+}
+
+// fusermount mounts a FUSE filesystem at the mountpoint with the setuid
+// fusermount binary, which sends the opened device over a unix socket.
+func fusermount(mountpoint string) (*os.File, error) {
+	bin, err := fusermountBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("socketpair", err)
+	}
+	defer syscall.Close(fds[0])
+
+	remote := os.NewFile(uintptr(fds[1]), "fusermount")
+	defer remote.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, "-o", "ro,nosuid,nodev,fsname=s5cmd,subtype=s5cmd", "--", mountpoint)
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	cmd.ExtraFiles = []*os.File{remote}
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("fusermount: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	buf := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := syscall.Recvmsg(fds[0], buf, oob, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("recvmsg", err)
+	}
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) != 1 {
+		return nil, fmt.Errorf("fusermount: device is not received")
+	}
+	rights, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(rights) != 1 {
+		return nil, fmt.Errorf("fusermount: device is not received")
+	}
+
+	fd := rights[0]
+	syscall.CloseOnExec(fd)
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("setnonblock", err)
+	}
+	return os.NewFile(uintptr(fd), "/dev/fuse"), nil
+}
+
+// unmountFUSE lazily unmounts the FUSE filesystem at the mountpoint.
+func unmountFUSE(mountpoint string) error {
+	if os.Geteuid() == 0 {
+		if err := syscall.Unmount(mountpoint, syscall.MNT_DETACH); err != nil {
+			return &os.PathError{Op: "unmount", Path: mountpoint, Err: err}
+		}
+		return nil
+	}
+
+	bin, err := fusermountBinary()
+	if err != nil {
+		return err
+	}
+	output, err := exec.Command(bin, "-u", "-z", "--", mountpoint).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fusermount: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// fuseServer serves the requests of the kernel for a mounted filesystem.
+// Each of the requests is handled in its own goroutine.
+type fuseServer struct {
+	fs   *objectFS
+	file *os.File
+	uid  uint32
+	gid  uint32
+	wg   sync.WaitGroup
+
+	mu sync.Mutex
+	// dirs are the listings of the opened directories by their handles.
+	dirs map[uint64][]fsEntry
+	// files are the opened objects by their handles.
+	files      map[uint64]*fileHandle
+	nextHandle uint64
+	// requests are the cancel functions of the requests in progress by
+	// their unique ids, to be called when the requests are interrupted.
+	requests map[uint64]context.CancelFunc
+}
+
+// serve reads the requests until the filesystem is unmounted or the device
+// is closed.
+func (s *fuseServer) serve() error {
+	buf := make([]byte, fuseReadBufferSize)
+	for {
+		n, err := s.file.Read(buf)
+		switch {
+		// the request is interrupted before it is read.
+		case errors.Is(err, syscall.ENOENT), errors.Is(err, syscall.EINTR):
+			continue
+		case errors.Is(err, syscall.ENODEV), errors.Is(err, os.ErrClosed), err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		req := make([]byte, n)
+		copy(req, buf[:n])
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(req)
+		}()
+	}
+}
+
+// handle handles the request and writes its reply.
+func (s *fuseServer) handle(req []byte) {
+	const headerSize = unsafe.Sizeof(fuseInHeader{})
+	if uintptr(len(req)) < headerSize {
+		return
+	}
+
+	header := *(*fuseInHeader)(unsafe.Pointer(&req[0]))
+	if int(header.Len) < len(req) {
+		req = req[:header.Len]
+	}
+	body := req[headerSize:]
+
+	// the forgets and the interrupts are not replied.
+	switch header.Opcode {
+	case fuseForget:
+		if len(body) >= int(unsafe.Sizeof(fuseForgetIn{})) {
+			in := (*fuseForgetIn)(unsafe.Pointer(&body[0]))
+			s.fs.forget(header.Nodeid, in.Nlookup)
+		}
+		return
+	case fuseBatchForget:
+		s.batchForget(body)
+		return
+	case fuseInterrupt:
+		if len(body) >= int(unsafe.Sizeof(fuseInterruptIn{})) {
+			in := (*fuseInterruptIn)(unsafe.Pointer(&body[0]))
+			s.interrupt(in.Unique)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.fs.ctx)
+	s.mu.Lock()
+	s.requests[header.Unique] = cancel
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.requests, header.Unique)
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	out, err := s.dispatch(ctx, header, body)
+	s.reply(header.Unique, out, err)
+}
+
+func (s *fuseServer) batchForget(body []byte) {
+	if len(body) < int(unsafe.Sizeof(fuseBatchForgetIn{})) {
+		return
+	}
+	in := (*fuseBatchForgetIn)(unsafe.Pointer(&body[0]))
+	forgets := body[unsafe.Sizeof(fuseBatchForgetIn{}):]
+
+	size := int(unsafe.Sizeof(fuseForgetOne{}))
+	for i := 0; i < int(in.Count) && (i+1)*size <= len(forgets); i++ {
+		one := (*fuseForgetOne)(unsafe.Pointer(&forgets[i*size]))
+		s.fs.forget(one.Nodeid, one.Nlookup)
+	}
+}
+
+// interrupt cancels the request in progress. The requests which are not
+// started yet are not interrupted.
+func (s *fuseServer) interrupt(unique uint64) {
+	s.mu.Lock()
+	cancel, ok := s.requests[unique]
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// dispatch handles the request of the opcode, and returns the body of its
+// reply.
+func (s *fuseServer) dispatch(ctx context.Context, header fuseInHeader, body []byte) ([]byte, error) {
+	switch header.Opcode {
+	case fuseInit:
+		if len(body) < int(unsafe.Sizeof(fuseInitIn{})) {
+			return nil, syscall.EINVAL
+		}
+		return s.init((*fuseInitIn)(unsafe.Pointer(&body[0])))
+	case fuseLookup:
+		name := string(bytes.TrimRight(body, "\x00"))
+		return s.lookup(ctx, header.Nodeid, name)
+	case fuseGetattr:
+		entry, err := s.fs.getattr(header.Nodeid)
+		if err != nil {
+			return nil, err
+		}
+		valid, validNsec := s.ttl()
+		out := fuseAttrOut{AttrValid: valid, AttrValidNsec: validNsec, Attr: s.attr(entry)}
+		return structBytes(unsafe.Pointer(&out), unsafe.Sizeof(out)), nil
+	case fuseOpen:
+		if len(body) < int(unsafe.Sizeof(fuseOpenIn{})) {
+			return nil, syscall.EINVAL
+		}
+		in := (*fuseOpenIn)(unsafe.Pointer(&body[0]))
+		if in.Flags&syscall.O_ACCMODE != syscall.O_RDONLY {
+			return nil, syscall.EROFS
+		}
+		h, err := s.fs.open(header.Nodeid)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.nextHandle++
+		out := fuseOpenOut{Fh: s.nextHandle}
+		s.files[out.Fh] = h
+		s.mu.Unlock()
+		return structBytes(unsafe.Pointer(&out), unsafe.Sizeof(out)), nil
+	case fuseRead:
+		if len(body) < int(unsafe.Sizeof(fuseReadIn{})) {
+			return nil, syscall.EINVAL
+		}
+		in := (*fuseReadIn)(unsafe.Pointer(&body[0]))
+		s.mu.Lock()
+		h, ok := s.files[in.Fh]
+		s.mu.Unlock()
+		if !ok {
+			return s.fs.read(ctx, header.Nodeid, int64(in.Offset), int(in.Size))
+		}
+		return s.fs.readHandle(ctx, h, int64(in.Offset), int(in.Size))
+	case fuseOpendir:
+		entries, err := s.fs.readDir(ctx, header.Nodeid)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.nextHandle++
+		out := fuseOpenOut{Fh: s.nextHandle}
+		s.dirs[out.Fh] = entries
+		s.mu.Unlock()
+		return structBytes(unsafe.Pointer(&out), unsafe.Sizeof(out)), nil
+	case fuseReaddir:
+		if len(body) < int(unsafe.Sizeof(fuseReadIn{})) {
+			return nil, syscall.EINVAL
+		}
+		return s.readDir((*fuseReadIn)(unsafe.Pointer(&body[0])))
+	case fuseReleasedir:
+		if len(body) < int(unsafe.Sizeof(fuseReleaseIn{})) {
+			return nil, syscall.EINVAL
+		}
+		in := (*fuseReleaseIn)(unsafe.Pointer(&body[0]))
+		s.mu.Lock()
+		delete(s.dirs, in.Fh)
+		s.mu.Unlock()
+		return nil, nil
+	case fuseStatfs:
+		out := fuseKstatfs{Bsize: fuseBlockSize, Frsize: fuseBlockSize, Namelen: 255}
+		return structBytes(unsafe.Pointer(&out), unsafe.Sizeof(out)), nil
+	case fuseRelease:
+		if len(body) < int(unsafe.Sizeof(fuseReleaseIn{})) {
+			return nil, syscall.EINVAL
+		}
+		in := (*fuseReleaseIn)(unsafe.Pointer(&body[0]))
+		s.mu.Lock()
+		h, ok := s.files[in.Fh]
+		delete(s.files, in.Fh)
+		s.mu.Unlock()
+		if ok {
+			h.close()
+		}
+		return nil, nil
+	case fuseFlush, fuseDestroy:
+		return nil, nil
+	default:
+		return nil, syscall.ENOSYS
+	}
+}
+
+// init negotiates the version of the protocol and the capabilities.
+func (s *fuseServer) init(in *fuseInitIn) ([]byte, error) {
+	if in.Major < fuseKernelVersion {
+		return nil, syscall.EPROTO
+	}
+
+	out := fuseInitOut{
+		Major: fuseKernelVersion,
+		Minor: fuseKernelMinorVersion,
+	}
+	// the newer kernels send the init request again with the replied major
+	// version.
+	if in.Major > fuseKernelVersion {
+		return structBytes(unsafe.Pointer(&out), unsafe.Sizeof(out)), nil
+	}
+
+	out.MaxReadahead = in.MaxReadahead
+	out.Flags = in.Flags & (fuseAsyncRead | fuseParallelDirops | fuseMaxPagesEnabled)
+	out.MaxBackground = fuseMaxBackground
+	out.CongestionThreshold = fuseMaxBackground * 3 / 4
+	out.MaxWrite = fuseMaxWrite
+	out.TimeGran = 1
+	out.MaxPages = fuseMaxPages
+
+	size := unsafe.Sizeof(out)
+	if in.Minor < 23 {
+		size = fuseCompatInitOutSize
+	}
+	return structBytes(unsafe.Pointer(&out), size), nil
+}
+
+// lookup replies the entry of the name in the directory. The entries which
+// are not found are cached by the kernel for the TTL too.
+func (s *fuseServer) lookup(ctx context.Context, parent uint64, name string) ([]byte, error) {
+	valid, validNsec := s.ttl()
+	out := fuseEntryOut{EntryValid: valid, EntryValidNsec: validNsec}
+
+	entry, err := s.fs.lookup(ctx, parent, name)
+	if errors.Is(err, os.ErrNotExist) {
+		return structBytes(unsafe.Pointer(&out), unsafe.Sizeof(out)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out.Nodeid = entry.ino
+	out.AttrValid = valid
+	out.AttrValidNsec = validNsec
+	out.Attr = s.attr(entry)
+	return structBytes(unsafe.Pointer(&out), unsafe.Sizeof(out)), nil
+}
+
+// readDir replies the entries of the opened directory starting from the
+// offset, as many as they fit in the size of the request.
+func (s *fuseServer) readDir(in *fuseReadIn) ([]byte, error) {
+	s.mu.Lock()
+	entries, ok := s.dirs[in.Fh]
+	s.mu.Unlock()
+	if !ok {
+		return nil, syscall.EBADF
+	}
+
+	const direntSize = int(unsafe.Sizeof(fuseDirent{}))
+
+	var out []byte
+	for i := int(in.Offset); i < len(entries); i++ {
+		entry := entries[i]
+
+		// the entries are aligned to 8 bytes.
+		size := (direntSize + len(entry.name) + 7) &^ 7
+		if len(out)+size > int(in.Size) {
+			break
+		}
+
+		dirent := fuseDirent{
+			Ino:     entry.ino,
+			Off:     uint64(i + 1),
+			Namelen: uint32(len(entry.name)),
+			Type:    syscall.DT_REG,
+		}
+		if entry.dir {
+			dirent.Type = syscall.DT_DIR
+		}
+
+		record := make([]byte, size)
+		copy(record, structBytes(unsafe.Pointer(&dirent), unsafe.Sizeof(dirent)))
+		copy(record[direntSize:], entry.name)
+		out = append(out, record...)
+	}
+	return out, nil
+}
+
+// ttl returns the duration the entries and the attributes are cached by the
+// kernel for.
+func (s *fuseServer) ttl() (uint64, uint32) {
+	ttl := s.fs.cacheTTL
+	return uint64(ttl / time.Second), uint32(ttl % time.Second)
+}
+
+// attr returns the attributes of the entry. The objects are readable by
+// anyone, and owned by the user who mounted the filesystem.
+func (s *fuseServer) attr(entry fsEntry) fuseAttr {
+	attr := fuseAttr{
+		Ino:     entry.ino,
+		Size:    uint64(entry.size),
+		Blocks:  uint64(entry.size+511) / 512,
+		Mode:    syscall.S_IFREG | 0444,
+		Nlink:   1,
+		UID:     s.uid,
+		GID:     s.gid,
+		Blksize: fuseBlockSize,
+	}
+	if entry.dir {
+		attr.Mode = syscall.S_IFDIR | 0555
+		attr.Nlink = 2
+	}
+
+	sec, nsec := uint64(entry.modTime.Unix()), uint32(entry.modTime.Nanosecond())
+	attr.Atime, attr.Mtime, attr.Ctime = sec, sec, sec
+	attr.Atimensec, attr.Mtimensec, attr.Ctimensec = nsec, nsec, nsec
+	return attr
+}
+
+// reply writes the reply of the request. The errors are replied with their
+// error numbers, the unexpected ones are printed and replied with EIO.
+func (s *fuseServer) reply(unique uint64, out []byte, err error) {
+	header := fuseOutHeader{Unique: unique}
+	if err != nil {
+		header.Error = -int32(s.errno(err))
+		out = nil
+	}
+	headerSize := int(unsafe.Sizeof(header))
+	header.Len = uint32(headerSize + len(out))
+
+	buf := make([]byte, headerSize+len(out))
+	copy(buf, structBytes(unsafe.Pointer(&header), unsafe.Sizeof(header)))
+	copy(buf[headerSize:], out)
+
+	// the requests may be interrupted, or the filesystem may be unmounted,
+	// before they are replied.
+	s.file.Write(buf)
+}
+
+// errno returns the error number the error is replied with.
+func (s *fuseServer) errno(err error) syscall.Errno {
+	var errno syscall.Errno
+	switch {
+	case errors.As(err, &errno):
+		return errno
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, storage.ErrGivenObjectNotFound):
+		return syscall.ENOENT
+	case errors.Is(err, errNotDirectory):
+		return syscall.ENOTDIR
+	case errors.Is(err, errIsDirectory):
+		return syscall.EISDIR
+	case errorpkg.IsCancelation(err):
+		return syscall.EINTR
+	default:
+		s.fs.onError(err)
+		return syscall.EIO
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// mountFS mounts the filesystem at the mountpoint. FUSE filesystems are only
+// supported on Linux.
+func mountFS(ctx context.Context, mountpoint string, fsys *objectFS, mounted func()) error {
+	return fmt.Errorf("mount is not supported on %s", runtime.GOOS)
+}
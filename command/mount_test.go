@@ -0,0 +1,313 @@
+package command
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// fakeMountClient lists and reads the objects of a bucket from a map, with
+// the delimiter listing semantics of S3.
+type fakeMountClient struct {
+	objects map[string]string
+
+	mu        sync.Mutex
+	lists     int
+	ranges    int
+	streams   int
+	wildcards bool
+}
+
+func (c *fakeMountClient) List(ctx context.Context, src *url.URL, _ bool) <-chan *storage.Object {
+	c.mu.Lock()
+	c.lists++
+	c.wildcards = c.wildcards || src.IsWildcard()
+	c.mu.Unlock()
+
+	var keys []string
+	for key := range c.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ch := make(chan *storage.Object, len(keys)+1)
+	defer close(ch)
+
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, src.Prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, src.Prefix)
+		if i := strings.Index(rest, "/"); i >= 0 && i < len(rest)-1 {
+			dir := src.Prefix + rest[:i+1]
+			if !seen[dir] {
+				seen[dir] = true
+				u, _ := url.New("s3://"+src.Bucket+"/"+dir, url.WithRaw(true))
+				ch <- &storage.Object{URL: u}
+			}
+			continue
+		}
+
+		u, _ := url.New("s3://"+src.Bucket+"/"+key, url.WithRaw(true))
+		modTime := time.Unix(1600000000, 0)
+		ch <- &storage.Object{URL: u, Size: int64(len(c.objects[key])), ModTime: &modTime}
+	}
+
+	if len(seen) == 0 && len(ch) == 0 {
+		ch <- &storage.Object{Err: storage.ErrNoObjectFound}
+	}
+	return ch
+}
+
+func (c *fakeMountClient) ReadRange(ctx context.Context, src *url.URL, offset, length int64) (io.ReadCloser, error) {
+	c.mu.Lock()
+	c.ranges++
+	c.mu.Unlock()
+
+	content, ok := c.objects[src.Path]
+	if !ok {
+		return nil, storage.ErrGivenObjectNotFound
+	}
+	return ioutil.NopCloser(strings.NewReader(content[offset : offset+length])), nil
+}
+
+func (c *fakeMountClient) ReadParallel(ctx context.Context, src *url.URL, concurrency int, partSize int64) (io.ReadCloser, error) {
+	c.mu.Lock()
+	c.streams++
+	c.mu.Unlock()
+
+	content, ok := c.objects[src.Path]
+	if !ok {
+		return nil, storage.ErrGivenObjectNotFound
+	}
+	return ioutil.NopCloser(strings.NewReader(content)), nil
+}
+
+func newTestObjectFS(objects map[string]string) (*objectFS, *fakeMountClient) {
+	client := &fakeMountClient{objects: objects}
+	return newObjectFS(context.Background(), client, "bucket", "prefix/", time.Minute), client
+}
+
+func TestObjectFSLookup(t *testing.T) {
+	t.Parallel()
+
+	fsys, _ := newTestObjectFS(map[string]string{
+		"prefix/file.txt":       "0123456789",
+		"prefix/dir/nested.txt": "nested",
+		"other.txt":             "other",
+	})
+	ctx := context.Background()
+
+	file, err := fsys.lookup(ctx, mountRootIno, "file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "file.txt", file.name)
+	assert.False(t, file.dir)
+	assert.Equal(t, int64(10), file.size)
+	assert.Equal(t, inode("file.txt"), file.ino)
+
+	dir, err := fsys.lookup(ctx, mountRootIno, "dir")
+	assert.NoError(t, err)
+	assert.True(t, dir.dir)
+
+	nested, err := fsys.lookup(ctx, dir.ino, "nested.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, inode("dir/nested.txt"), nested.ino)
+
+	_, err = fsys.lookup(ctx, mountRootIno, "other.txt")
+	assert.Equal(t, os.ErrNotExist, err)
+
+	_, err = fsys.lookup(ctx, file.ino, "child")
+	assert.Equal(t, errNotDirectory, err)
+
+	entry, err := fsys.getattr(nested.ino)
+	assert.NoError(t, err)
+	assert.Equal(t, nested, entry)
+}
+
+func TestObjectFSForget(t *testing.T) {
+	t.Parallel()
+
+	fsys, _ := newTestObjectFS(map[string]string{"prefix/file.txt": "content"})
+	ctx := context.Background()
+
+	file, err := fsys.lookup(ctx, mountRootIno, "file.txt")
+	assert.NoError(t, err)
+	_, err = fsys.lookup(ctx, mountRootIno, "file.txt")
+	assert.NoError(t, err)
+
+	fsys.forget(file.ino, 1)
+	_, err = fsys.getattr(file.ino)
+	assert.NoError(t, err)
+
+	fsys.forget(file.ino, 1)
+	_, err = fsys.getattr(file.ino)
+	assert.Equal(t, os.ErrNotExist, err)
+
+	// the root is never forgotten.
+	fsys.forget(mountRootIno, 1)
+	_, err = fsys.getattr(mountRootIno)
+	assert.NoError(t, err)
+}
+
+func TestObjectFSReadDir(t *testing.T) {
+	t.Parallel()
+
+	fsys, client := newTestObjectFS(map[string]string{
+		"prefix/b.txt":         "b",
+		"prefix/a.txt":         "a",
+		"prefix/dir":           "shadowed by the directory",
+		"prefix/dir/child.txt": "child",
+		"prefix/glob*/x.txt":   "listed literally",
+	})
+	ctx := context.Background()
+
+	entries, err := fsys.readDir(ctx, mountRootIno)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.name)
+	}
+	assert.Equal(t, []string{".", "..", "a.txt", "b.txt", "dir", "glob*"}, names)
+	assert.True(t, entries[4].dir)
+
+	// the lookups use the cached listing.
+	_, err = fsys.lookup(ctx, mountRootIno, "a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.lists)
+
+	dir, err := fsys.lookup(ctx, mountRootIno, "dir")
+	assert.NoError(t, err)
+	entries, err = fsys.readDir(ctx, dir.ino)
+	assert.NoError(t, err)
+	assert.Equal(t, dir.ino, entries[0].ino)
+	assert.Equal(t, uint64(mountRootIno), entries[1].ino)
+	assert.Equal(t, "child.txt", entries[2].name)
+	assert.Equal(t, 2, client.lists)
+}
+
+func TestObjectFSRead(t *testing.T) {
+	t.Parallel()
+
+	fsys, _ := newTestObjectFS(map[string]string{"prefix/file.txt": "0123456789"})
+	ctx := context.Background()
+
+	file, err := fsys.lookup(ctx, mountRootIno, "file.txt")
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		offset   int64
+		size     int
+		expected string
+	}{
+		{offset: 0, size: 4096, expected: "0123456789"},
+		{offset: 2, size: 4, expected: "2345"},
+		{offset: 8, size: 4, expected: "89"},
+		{offset: 10, size: 4, expected: ""},
+	}
+	for _, tc := range testcases {
+		data, err := fsys.read(ctx, file.ino, tc.offset, tc.size)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, string(data))
+	}
+
+	_, err = fsys.read(ctx, mountRootIno, 0, 10)
+	assert.Equal(t, errIsDirectory, err)
+}
+
+func TestObjectFSReadHandle(t *testing.T) {
+	t.Parallel()
+
+	fsys, client := newTestObjectFS(map[string]string{"prefix/file.txt": "0123456789"})
+	ctx := context.Background()
+
+	file, err := fsys.lookup(ctx, mountRootIno, "file.txt")
+	assert.NoError(t, err)
+
+	h, err := fsys.open(file.ino)
+	assert.NoError(t, err)
+
+	// the sequential reads and the reads slightly out of order are served
+	// from the stream.
+	testcases := []struct {
+		offset   int64
+		size     int
+		expected string
+	}{
+		{offset: 0, size: 4, expected: "0123"},
+		{offset: 6, size: 2, expected: "67"},
+		{offset: 4, size: 2, expected: "45"},
+		{offset: 8, size: 4, expected: "89"},
+		{offset: 10, size: 4, expected: ""},
+	}
+	for _, tc := range testcases {
+		data, err := fsys.readHandle(ctx, h, tc.offset, tc.size)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, string(data))
+	}
+	assert.Equal(t, 1, client.streams)
+	assert.Equal(t, 0, client.ranges)
+	h.close()
+
+	// the objects which are not read from the start are read with ranged
+	// requests.
+	h, err = fsys.open(file.ino)
+	assert.NoError(t, err)
+
+	data, err := fsys.readHandle(ctx, h, 2, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "2345", string(data))
+
+	data, err = fsys.readHandle(ctx, h, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "01", string(data))
+	assert.Equal(t, 1, client.streams)
+	assert.Equal(t, 2, client.ranges)
+	h.close()
+
+	_, err = fsys.open(mountRootIno)
+	assert.Equal(t, errIsDirectory, err)
+}
+
+func TestObjectFSReadDirGlobNames(t *testing.T) {
+	t.Parallel()
+
+	fsys, client := newTestObjectFS(map[string]string{
+		"prefix/[draft]/notes.txt": "notes",
+		"prefix/d/decoy.txt":       "decoy",
+	})
+	ctx := context.Background()
+
+	dir, err := fsys.lookup(ctx, mountRootIno, "[draft]")
+	assert.NoError(t, err)
+
+	entries, err := fsys.readDir(ctx, dir.ino)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "notes.txt", entries[2].name)
+	assert.False(t, client.wildcards)
+}
+
+func TestIsValidEntryName(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isValidEntryName("file.txt"))
+	assert.True(t, isValidEntryName("..file"))
+	assert.False(t, isValidEntryName(""))
+	assert.False(t, isValidEntryName("."))
+	assert.False(t, isValidEntryName(".."))
+	assert.False(t, isValidEntryName("a/b"))
+	assert.False(t, isValidEntryName(strings.Repeat("a", 256)))
+}
@@ -0,0 +1,288 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var mpuListHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. List the multipart uploads in progress of a bucket
+		 > s5cmd {{.HelpName}} s3://bucket
+
+	2. List the multipart uploads in progress with a prefix, started more than 7 days ago
+		 > s5cmd {{.HelpName}} --older-than 7d s3://bucket/prefix/
+`
+
+var mpuAbortHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Abort the multipart uploads of a bucket started more than 7 days ago
+		 > s5cmd {{.HelpName}} --older-than 7d s3://bucket
+
+	2. Abort all multipart uploads of a bucket without a confirmation prompt
+		 > s5cmd --force {{.HelpName}} s3://bucket
+
+	3. Abort all multipart uploads of the objects matching a wildcard
+		 > s5cmd {{.HelpName}} "s3://bucket/backups/*.tar"
+
+	4. Print the multipart uploads which would be aborted, without aborting them
+		 > s5cmd --dry-run {{.HelpName}} --older-than 7d s3://bucket
+`
+
+func NewMultipartUploadCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "mpu",
+		HelpName: "mpu",
+		Usage:    "list or abort multipart uploads in progress",
+		Subcommands: []*cli.Command{
+			newMultipartUploadListCommand(),
+			newMultipartUploadAbortCommand(),
+		},
+	}
+}
+
+func multipartUploadFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "older-than",
+			Usage: "only the multipart uploads started more than given time ago, e.g. 7d, 2w or 12h",
+		},
+		&cli.StringFlag{
+			Name:  "newer-than",
+			Usage: "only the multipart uploads started less than given time ago, e.g. 7d, 2w or 12h",
+		},
+	}
+}
+
+func newMultipartUploadListCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "list",
+		HelpName:           "mpu list",
+		Usage:              "list multipart uploads in progress",
+		CustomHelpTemplate: mpuListHelpTemplate,
+		Flags:              multipartUploadFlags(),
+		Before: func(c *cli.Context) error {
+			err := validateMultipartUploadCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			// the ages are validated before the command runs.
+			age, _ := parseAgeFilter(c)
+			return MultipartUploads{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				age:         age,
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context, func(ctx context.Context, client *storage.S3, upload *storage.MultipartUpload) error {
+				log.Info(MultipartUploadMessage{upload})
+				return nil
+			})
+		},
+	}
+}
+
+func newMultipartUploadAbortCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "abort",
+		HelpName:           "mpu abort",
+		Usage:              "abort multipart uploads in progress and delete their parts",
+		CustomHelpTemplate: mpuAbortHelpTemplate,
+		Flags:              multipartUploadFlags(),
+		Before: func(c *cli.Context) error {
+			err := validateMultipartUploadCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			if err := confirmBucketAbort(c); err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+
+			age, _ := parseAgeFilter(c)
+			op := c.Command.HelpName
+			return MultipartUploads{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				age:         age,
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context, func(ctx context.Context, client *storage.S3, upload *storage.MultipartUpload) error {
+				if err := client.AbortMultipartUpload(ctx, upload); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: upload.URL, Object: upload})
+				return nil
+			})
+		},
+	}
+}
+
+// MultipartUploads holds mpu operation flags and states.
+type MultipartUploads struct {
+	src         string
+	op          string
+	fullCommand string
+	age         ageFilter
+
+	storageOpts storage.Options
+}
+
+// Run calls the given function for each of the multipart uploads in progress
+// of the source concurrently.
+func (m MultipartUploads) Run(
+	ctx context.Context,
+	fn func(context.Context, *storage.S3, *storage.MultipartUpload) error,
+) error {
+	srcurl, err := url.New(m.src)
+	if err != nil {
+		printError(m.fullCommand, m.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, m.storageOpts)
+	if err != nil {
+		printError(m.fullCommand, m.op, err)
+		return err
+	}
+
+	waiter := parallel.NewWaiter()
+
+	var (
+		merrorWaiter  error
+		merrorObjects error
+		errDone       = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			if errorpkg.IsCancelation(err) {
+				continue
+			}
+			printError(m.fullCommand, m.op, err)
+			merrorWaiter = multierror.Append(merrorWaiter, err)
+		}
+	}()
+
+	for upload := range client.ListMultipartUploads(ctx, srcurl) {
+		if errorpkg.IsCancelation(upload.Err) {
+			continue
+		}
+
+		if err := upload.Err; err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(m.fullCommand, m.op, err)
+			continue
+		}
+
+		if upload.Initiated != nil && !m.age.matchTime(*upload.Initiated) {
+			continue
+		}
+
+		upload := upload
+		task := func() error {
+			return fn(ctx, client, upload)
+		}
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+}
+
+// MultipartUploadMessage is a structure for logging multipart uploads in
+// progress.
+type MultipartUploadMessage struct {
+	*storage.MultipartUpload
+}
+
+// String returns the string representation of MultipartUploadMessage.
+func (m MultipartUploadMessage) String() string {
+	var initiated string
+	if m.Initiated != nil {
+		initiated = m.Initiated.Format(dateFormat)
+	}
+	return fmt.Sprintf("%19s %s %s", initiated, m.UploadID, m.URL)
+}
+
+// confirmBucketAbort asks for a confirmation before all multipart uploads of
+// a bucket are aborted, which may abort the uploads of other clients still in
+// progress. Nothing is asked if --older-than flag, --force flag or --dry-run
+// flag is given.
+func confirmBucketAbort(c *cli.Context) error {
+	// the source is validated before the command runs.
+	srcurl, _ := url.New(c.Args().First())
+	if !srcurl.IsBucket() || c.String("older-than") != "" || c.Bool("force") || c.Bool("dry-run") {
+		return nil
+	}
+
+	if (&confirmer{}).confirm(fmt.Sprintf("abort all multipart uploads of %v", srcurl)) {
+		return nil
+	}
+	return fmt.Errorf("aborting all multipart uploads of the bucket is not confirmed, use --older-than flag or --force flag")
+}
+
+func validateMultipartUploadCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be remote")
+	}
+
+	if srcurl.IsBucketWildcard() {
+		return fmt.Errorf("source bucket can not contain glob characters")
+	}
+
+	if _, err := parseAgeFilter(c); err != nil {
+		return err
+	}
+	return nil
+}
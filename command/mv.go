@@ -36,14 +36,37 @@ Examples:
 
 	7. Move all files from S3 bucket to another S3 bucket but exclude the ones starts with log
 		 > s5cmd {{.HelpName}} --exclude "log*" s3://bucket/* s3://destbucket
+
+	8. Move files to S3 bucket and delete only the files whose uploaded objects are verified to match them
+		 > s5cmd {{.HelpName}} --verify "dir/*" s3://bucket/
+
+	9. Move files to S3 bucket, confirming each overwrite of an existing object
+		 > s5cmd {{.HelpName}} --interactive "dir/*" s3://bucket/
 `
 
+// NewMoveCommandFlags returns the flags of move command, which are the flags
+// of copy command and the flags specific to moves.
+func NewMoveCommandFlags() []cli.Flag {
+	moveFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: "compare sizes and ETags or checksums of source and destination before deleting the source, sources of mismatching copies are kept",
+		},
+		&cli.BoolFlag{
+			Name:    "interactive",
+			Aliases: []string{"i"},
+			Usage:   "prompt before overwriting each existing destination, answering 'all' overwrites the rest without prompting",
+		},
+	}
+	return append(NewCopyCommandFlags(), moveFlags...)
+}
+
 func NewMoveCommand() *cli.Command {
 	return &cli.Command{
 		Name:               "mv",
 		HelpName:           "mv",
 		Usage:              "move/rename objects",
-		Flags:              NewCopyCommandFlags(), // move and copy commands share the same flags
+		Flags:              NewMoveCommandFlags(),
 		CustomHelpTemplate: moveHelpTemplate,
 		Before: func(c *cli.Context) error {
 			return NewCopyCommand().Before(c)
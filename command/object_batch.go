@@ -0,0 +1,125 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// objectBatch holds the sources of the operations applied to each of the
+// matching objects, e.g. tag and acl operations.
+type objectBatch struct {
+	srcs        []string
+	op          string
+	fullCommand string
+	raw         bool
+
+	storageOpts storage.Options
+}
+
+func newObjectBatch(c *cli.Context) objectBatch {
+	return objectBatch{
+		srcs:        c.Args().Slice(),
+		op:          c.Command.Name,
+		fullCommand: commandFromContext(c),
+		raw:         c.Bool("raw"),
+
+		storageOpts: NewStorageOpts(c),
+	}
+}
+
+// run calls the given function for each of the objects matching the sources
+// concurrently.
+func (b objectBatch) run(
+	ctx context.Context,
+	fn func(context.Context, *storage.S3, *url.URL) error,
+) error {
+	srcurls, err := newURLs(b.raw, b.srcs...)
+	if err != nil {
+		printError(b.fullCommand, b.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurls[0], b.storageOpts)
+	if err != nil {
+		printError(b.fullCommand, b.op, err)
+		return err
+	}
+
+	objch := expandSources(ctx, client, false, srcurls...)
+
+	waiter := parallel.NewWaiter()
+
+	var (
+		merrorWaiter  error
+		merrorObjects error
+		errDone       = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			if errorpkg.IsCancelation(err) {
+				continue
+			}
+			printError(b.fullCommand, b.op, err)
+			merrorWaiter = multierror.Append(merrorWaiter, err)
+		}
+	}()
+
+	for object := range objch {
+		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
+			continue
+		}
+
+		if err := object.Err; err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(b.fullCommand, b.op, err)
+			continue
+		}
+
+		object := object
+		task := func() error {
+			return fn(ctx, client, object.URL)
+		}
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+}
+
+// validateObjectBatch validates the sources of the operations of the given
+// name applied to each of the matching objects.
+func validateObjectBatch(c *cli.Context, name string) error {
+	if !c.Args().Present() {
+		return fmt.Errorf("expected at least 1 object")
+	}
+
+	srcurls, err := newURLs(c.Bool("raw"), c.Args().Slice()...)
+	if err != nil {
+		return err
+	}
+
+	for _, srcurl := range srcurls {
+		if !srcurl.IsRemote() {
+			return fmt.Errorf("source must be remote")
+		}
+		if srcurl.IsBucket() || srcurl.IsPrefix() {
+			return fmt.Errorf("s3 bucket/prefix cannot be used for %s operations (forgot wildcard character?)", name)
+		}
+		if srcurl.Bucket != srcurls[0].Bucket {
+			return fmt.Errorf("%s operations on objects of different buckets in a single command are not allowed", name)
+		}
+	}
+	return nil
+}
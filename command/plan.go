@@ -0,0 +1,83 @@
+package command
+
+import (
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log/stat"
+)
+
+var planSyncHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] source destination
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the commands to sync S3 bucket to local folder
+		 > s5cmd {{.HelpName}} s3://bucket/* folder/
+
+	2. Write the commands to sync local folder to S3, deleting the extra objects, to a run file
+		 > s5cmd {{.HelpName}} --delete --output plan.txt folder/ s3://bucket/
+
+	3. Execute the reviewed commands
+		 > s5cmd run plan.txt
+`
+
+func NewPlanCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "plan",
+		HelpName: "plan",
+		Usage:    "print the commands a command would execute as a run file",
+		Subcommands: []*cli.Command{
+			newPlanSyncCommand(),
+		},
+	}
+}
+
+func newPlanSyncCommand() *cli.Command {
+	flags := append(NewSyncCommandFlags(), &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "write the commands to given file instead of standard output",
+	})
+
+	return &cli.Command{
+		Name:               "sync",
+		HelpName:           "plan sync",
+		Usage:              "print the commands sync would execute",
+		Flags:              flags,
+		CustomHelpTemplate: planSyncHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateCopyCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			var output io.Writer = os.Stdout
+			if path := c.String("output"); path != "" {
+				f, err := os.Create(path)
+				if err != nil {
+					printError(commandFromContext(c), c.Command.Name, err)
+					return err
+				}
+				defer f.Close()
+
+				output = f
+			}
+
+			sync := NewSync(c)
+			sync.planOutput = output
+			return sync.Run(c)
+		},
+	}
+}
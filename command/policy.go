@@ -0,0 +1,238 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var policyGetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} s3://bucketname
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the policy of a bucket
+		 > s5cmd {{.HelpName}} s3://bucketname
+
+	2. Save the policy of a bucket to a file
+		 > s5cmd {{.HelpName}} s3://bucketname > policy.json
+`
+
+var policySetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] s3://bucketname
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Replace the policy of a bucket with the policy in a file
+		 > s5cmd {{.HelpName}} --file policy.json s3://bucketname
+
+	2. Create a bucket and attach a policy read from stdin
+		 > s5cmd mb s3://bucketname
+		 > generate-policy bucketname | s5cmd {{.HelpName}} --file - s3://bucketname
+
+	3. Copy the policy of a bucket to another bucket
+		 > s5cmd policy get s3://bucketname | s5cmd {{.HelpName}} --file - s3://otherbucket
+`
+
+var policyRemoveHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} s3://bucketname
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Remove the policy of a bucket
+		 > s5cmd {{.HelpName}} s3://bucketname
+`
+
+func NewPolicyCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "policy",
+		HelpName: "policy",
+		Usage:    "print, replace or remove the policy of a bucket",
+		Subcommands: []*cli.Command{
+			newPolicyGetCommand(),
+			newPolicySetCommand(),
+			newPolicyRemoveCommand(),
+		},
+	}
+}
+
+func newPolicyGetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "get",
+		HelpName:           "policy get",
+		Usage:              "print the policy of a bucket",
+		CustomHelpTemplate: policyGetHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateBucketCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return runBucketOperation(c, func(ctx context.Context, client *storage.S3, bucket *url.URL) error {
+				policy, err := client.Policy(ctx, bucket.Bucket)
+				if err != nil {
+					return err
+				}
+				log.Info(PolicyMessage{Source: bucket, Policy: json.RawMessage(policy)})
+				return nil
+			})
+		},
+	}
+}
+
+func newPolicySetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "set",
+		HelpName:           "policy set",
+		Usage:              "replace the policy of a bucket",
+		CustomHelpTemplate: policySetHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "JSON file with the policy document, or - to read it from stdin",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validatePolicySetCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			// stdin can be read once, so the policy is read and validated
+			// when the command runs rather than before.
+			policy, err := readPolicy(c.String("file"))
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+				return err
+			}
+
+			op := c.Command.HelpName
+			return runBucketOperation(c, func(ctx context.Context, client *storage.S3, bucket *url.URL) error {
+				if err := client.SetPolicy(ctx, bucket.Bucket, policy); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: bucket})
+				return nil
+			})
+		},
+	}
+}
+
+func newPolicyRemoveCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "rm",
+		HelpName:           "policy rm",
+		Usage:              "remove the policy of a bucket",
+		CustomHelpTemplate: policyRemoveHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateBucketCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			op := c.Command.HelpName
+			return runBucketOperation(c, func(ctx context.Context, client *storage.S3, bucket *url.URL) error {
+				if err := client.DeletePolicy(ctx, bucket.Bucket); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: bucket})
+				return nil
+			})
+		},
+	}
+}
+
+// PolicyMessage is a structure for logging the policy of a bucket.
+type PolicyMessage struct {
+	Source *url.URL        `json:"source"`
+	Policy json.RawMessage `json:"policy"`
+}
+
+// String returns the string representation of PolicyMessage, the policy
+// document as it is, so that it can be given to "policy set --file".
+func (m PolicyMessage) String() string {
+	return string(m.Policy)
+}
+
+// JSON returns the JSON representation of PolicyMessage.
+func (m PolicyMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+// readPolicy reads the policy document from the file at the given path, or
+// from stdin if the path is "-".
+func readPolicy(path string) (string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if !json.Valid(data) {
+		return "", fmt.Errorf("policy must be a valid JSON document")
+	}
+	return string(data), nil
+}
+
+func validatePolicySetCommand(c *cli.Context) error {
+	if err := validateBucketCommand(c); err != nil {
+		return err
+	}
+
+	file := c.String("file")
+	if file == "" {
+		return fmt.Errorf("expected a policy document with --file, use - to read it from stdin")
+	}
+	if file != "-" {
+		if _, err := os.Stat(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var presignHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print a URL to download an object, valid for an hour
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/object
+
+	2. Print a URL to download an object, valid for a day
+		 > s5cmd {{.HelpName}} --expire 24h s3://bucket/prefix/object
+
+	3. Print a URL to upload an object, valid for 15 minutes
+		 > s5cmd {{.HelpName}} --method PUT --expire 15m s3://bucket/prefix/object
+
+	4. Print a URL to download an object with the credentials of a profile
+		 > s5cmd --profile readonly {{.HelpName}} s3://bucket/prefix/object
+`
+
+// defaultPresignExpiry is the default duration the presigned URLs are valid
+// for.
+const defaultPresignExpiry = time.Hour
+
+func NewPresignCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "presign",
+		HelpName:           "presign",
+		Usage:              "print a presigned URL of an object",
+		CustomHelpTemplate: presignHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "expire",
+				Value: defaultPresignExpiry,
+				Usage: "duration the URL is valid for, at most 168h (7 days)",
+			},
+			&cli.StringFlag{
+				Name:  "method",
+				Value: http.MethodGet,
+				Usage: "HTTP method the URL is signed for: GET to download the object or PUT to upload it",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validatePresignCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			src, err := url.New(c.Args().Get(0))
+			op := c.Command.Name
+			fullCommand := commandFromContext(c)
+			if err != nil {
+				printError(fullCommand, op, err)
+				return err
+			}
+
+			return Presign{
+				src:         src,
+				op:          op,
+				fullCommand: fullCommand,
+				method:      strings.ToUpper(c.String("method")),
+				expire:      c.Duration("expire"),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Presign holds presign operation flags and states.
+type Presign struct {
+	src         *url.URL
+	op          string
+	fullCommand string
+
+	method string
+	expire time.Duration
+
+	storageOpts storage.Options
+}
+
+// Run prints the presigned URL of the source.
+func (p Presign) Run(ctx context.Context) error {
+	client, err := storage.NewRemoteClient(ctx, p.src, p.storageOpts)
+	if err != nil {
+		printError(p.fullCommand, p.op, err)
+		return err
+	}
+
+	expires := time.Now().Add(p.expire).UTC()
+	presigned, err := client.Presign(ctx, p.src, p.method, p.expire)
+	if err != nil {
+		printError(p.fullCommand, p.op, err)
+		return err
+	}
+
+	log.Info(PresignMessage{
+		Source:  p.src,
+		Method:  p.method,
+		URL:     presigned,
+		Expires: expires,
+	})
+	return nil
+}
+
+// PresignMessage is the structure for logging presigned URLs.
+type PresignMessage struct {
+	Source  *url.URL  `json:"source"`
+	Method  string    `json:"method"`
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+// String returns the presigned URL, so that it can be used by the scripts
+// as is.
+func (m PresignMessage) String() string {
+	return m.URL
+}
+
+// JSON returns the JSON representation of PresignMessage.
+func (m PresignMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validatePresignCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	src, err := url.New(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	if !src.IsRemote() {
+		return fmt.Errorf("source must be a remote object")
+	}
+
+	if src.IsBucket() || src.IsPrefix() {
+		return fmt.Errorf("remote source must be an object")
+	}
+
+	if src.IsWildcard() {
+		return fmt.Errorf("remote source %q can not contain glob characters", src)
+	}
+
+	if c.Bool("no-sign-request") {
+		return fmt.Errorf("presign can not be used with --no-sign-request")
+	}
+
+	switch strings.ToUpper(c.String("method")) {
+	case http.MethodGet, http.MethodPut:
+	default:
+		return fmt.Errorf("method must be one of GET or PUT")
+	}
+
+	expire := c.Duration("expire")
+	if expire <= 0 {
+		return fmt.Errorf("expire must be positive")
+	}
+	if expire > storage.MaxPresignExpiry {
+		return fmt.Errorf("expire can not be longer than %v", storage.MaxPresignExpiry)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package command
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+// logProofKeyEnv is the environment variable that holds the key to sign the
+// deletion proof log. It is not accepted as a flag to keep it out of the
+// command lines printed in the logs.
+const logProofKeyEnv = "S5CMD_LOG_PROOF_KEY"
+
+// deletionProof is a single entry of the deletion proof log. VersionID is the
+// erased version of the object. It is empty if the deletion only hid the
+// object behind the delete marker with DeleteMarkerVersionID.
+type deletionProof struct {
+	Timestamp             string `json:"timestamp"`
+	Operation             string `json:"operation"`
+	Key                   string `json:"key"`
+	VersionID             string `json:"version_id,omitempty"`
+	DeleteMarker          bool   `json:"delete_marker,omitempty"`
+	DeleteMarkerVersionID string `json:"delete_marker_version_id,omitempty"`
+	PreviousSignature     string `json:"previous_signature"`
+	Signature             string `json:"signature,omitempty"`
+}
+
+// deletionProofLog writes a JSON line for each deleted object. Each entry is
+// signed with HMAC-SHA256 over its content and the signature of the previous
+// entry, so that removing, reordering or modifying entries is detectable by
+// anyone who holds the key.
+type deletionProofLog struct {
+	mu            sync.Mutex
+	f             *os.File
+	key           []byte
+	lastSignature string
+}
+
+// newDeletionProofLog opens the proof log at the given path. Entries are
+// appended to an existing log, continuing its signature chain.
+func newDeletionProofLog(path string, key string) (*deletionProofLog, error) {
+	lastSignature, err := lastProofSignature(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deletionProofLog{
+		f:             f,
+		key:           []byte(key),
+		lastSignature: lastSignature,
+	}, nil
+}
+
+// Record appends a signed entry for the given deleted object.
+func (l *deletionProofLog) Record(op string, obj *storage.Object) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := deletionProof{
+		Timestamp:             time.Now().UTC().Format(time.RFC3339Nano),
+		Operation:             op,
+		Key:                   obj.URL.String(),
+		VersionID:             obj.VersionID,
+		DeleteMarker:          obj.DeleteMarker,
+		DeleteMarkerVersionID: obj.DeleteMarkerVersionID,
+		PreviousSignature:     l.lastSignature,
+	}
+
+	signature, err := signDeletionProof(entry, l.key)
+	if err != nil {
+		return err
+	}
+	entry.Signature = signature
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	l.lastSignature = signature
+	return nil
+}
+
+// Close syncs the proof log to the disk and closes it.
+func (l *deletionProofLog) Close() error {
+	if err := l.f.Sync(); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// signDeletionProof returns the hex encoded HMAC-SHA256 signature of the
+// given entry, excluding its signature field.
+func signDeletionProof(entry deletionProof, key []byte) (string, error) {
+	entry.Signature = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// lastProofSignature returns the signature of the last entry of the proof log
+// at the given path. It returns an empty string if the log doesn't exist.
+func lastProofSignature(path string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var last string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			last = line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if last == "" {
+		return "", nil
+	}
+
+	var entry deletionProof
+	if err := json.Unmarshal([]byte(last), &entry); err != nil {
+		return "", err
+	}
+	return entry.Signature, nil
+}
@@ -0,0 +1,78 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestDeletionProofLog(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "proof")
+	defer dir.Remove()
+
+	path := dir.Join("proof.log")
+	key := "secret"
+
+	record := func(keys ...string) {
+		t.Helper()
+
+		proof, err := newDeletionProofLog(path, key)
+		assert.NilError(t, err)
+
+		for _, k := range keys {
+			u, err := url.New(k)
+			assert.NilError(t, err)
+			assert.NilError(t, proof.Record("rm", &storage.Object{URL: u, VersionID: "v1", DeleteMarker: true}))
+		}
+		assert.NilError(t, proof.Close())
+	}
+
+	// appending to an existing log must continue the signature chain.
+	record("s3://bucket/a", "s3://bucket/b")
+	record("s3://bucket/c")
+
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close()
+
+	var entries []deletionProof
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry deletionProof
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	assert.NilError(t, scanner.Err())
+	assert.Equal(t, len(entries), 3)
+
+	var previous string
+	for i, entry := range entries {
+		assert.Equal(t, entry.Operation, "rm")
+		assert.Equal(t, entry.VersionID, "v1")
+		assert.Assert(t, entry.DeleteMarker)
+		assert.Equal(t, entry.PreviousSignature, previous, "entry %d", i)
+
+		signature, err := signDeletionProof(entry, []byte(key))
+		assert.NilError(t, err)
+		assert.Equal(t, entry.Signature, signature, "entry %d", i)
+
+		// a different key must not produce the same signature.
+		forged, err := signDeletionProof(entry, []byte("forged"))
+		assert.NilError(t, err)
+		assert.Assert(t, forged != entry.Signature)
+
+		previous = entry.Signature
+	}
+
+	assert.Equal(t, entries[0].Key, "s3://bucket/a")
+	assert.Equal(t, entries[2].Key, "s3://bucket/c")
+}
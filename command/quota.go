@@ -0,0 +1,220 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+// errQuotaExceeded indicates an object is not uploaded since the objects
+// under the quota prefix would exceed the quota.
+var errQuotaExceeded = errors.New("quota exceeded")
+
+// quota limits the total size and the number of the objects under a remote
+// prefix. Zero values are unlimited.
+type quota struct {
+	prefix   *url.URL
+	maxSize  int64
+	maxCount int64
+
+	storageOpts storage.Options
+}
+
+// parseQuota parses the quota flags of the command. The quota applies to the
+// destination of the command unless a prefix is given. A nil quota is
+// returned if no quota is set.
+func parseQuota(c *cli.Context) (*quota, error) {
+	if !c.IsSet("quota-size") && !c.IsSet("quota-count") {
+		if c.String("quota-prefix") != "" {
+			return nil, fmt.Errorf("quota-prefix requires quota-size or quota-count")
+		}
+		return nil, nil
+	}
+
+	q := &quota{storageOpts: NewStorageOpts(c)}
+
+	if c.IsSet("quota-size") {
+		size, err := parseByteSize(c.String("quota-size"))
+		if err != nil {
+			return nil, fmt.Errorf("quota-size: %v", err)
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("quota-size must be a positive size")
+		}
+		q.maxSize = size
+	}
+
+	if c.IsSet("quota-count") {
+		if c.Int64("quota-count") <= 0 {
+			return nil, fmt.Errorf("quota-count must be a positive number")
+		}
+		q.maxCount = c.Int64("quota-count")
+	}
+
+	prefix := c.String("quota-prefix")
+	if prefix == "" {
+		prefix = c.Args().Get(1)
+	}
+	prefixurl, err := quotaPrefix(prefix, c.Bool("raw") && c.String("quota-prefix") == "")
+	if err != nil {
+		return nil, err
+	}
+	q.prefix = prefixurl
+
+	return q, nil
+}
+
+// quotaPrefix returns the prefix of the given destination which the quota
+// applies to. The prefix of an object is its directory.
+func quotaPrefix(dst string, raw bool) (*url.URL, error) {
+	dsturl, err := url.New(dst, url.WithRaw(raw))
+	if err != nil {
+		return nil, err
+	}
+	if !dsturl.IsRemote() {
+		return nil, fmt.Errorf("quotas can only be used with remote destinations")
+	}
+	if dsturl.IsWildcard() {
+		return nil, fmt.Errorf("quota prefix %q can not contain wildcards", dst)
+	}
+
+	if dsturl.IsBucket() || dsturl.IsPrefix() {
+		return dsturl, nil
+	}
+
+	dir := ""
+	if i := strings.LastIndex(dsturl.Path, "/"); i >= 0 {
+		dir = dsturl.Path[:i+1]
+	}
+	return url.New(fmt.Sprintf("s3://%v/%v", dsturl.Bucket, dir))
+}
+
+// reserve reserves the space of an object of the given size under the
+// quota prefix, if the destination is under the prefix. The objects are
+// counted as new objects even if they overwrite existing ones.
+func (q *quota) reserve(ctx context.Context, dsturl *url.URL, size int64) error {
+	if q == nil || !q.contains(dsturl) {
+		return nil
+	}
+
+	usage := quotaUsageOf(q.prefix)
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+
+	if err := usage.load(ctx, q.prefix, q.storageOpts); err != nil {
+		return err
+	}
+
+	if q.maxCount > 0 && usage.count+1 > q.maxCount {
+		return fmt.Errorf(
+			"%w: %v would have more than %d objects",
+			errQuotaExceeded, q.prefix, q.maxCount,
+		)
+	}
+	if q.maxSize > 0 && usage.size+size > q.maxSize {
+		return fmt.Errorf(
+			"%w: %v would have more than %v, it has %v",
+			errQuotaExceeded, q.prefix, strutil.HumanizeBytes(q.maxSize), strutil.HumanizeBytes(usage.size),
+		)
+	}
+
+	usage.count++
+	usage.size += size
+	return nil
+}
+
+// release releases the space reserved for an object which isn't uploaded.
+func (q *quota) release(dsturl *url.URL, size int64) {
+	if q == nil || !q.contains(dsturl) {
+		return
+	}
+
+	usage := quotaUsageOf(q.prefix)
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+
+	usage.count--
+	usage.size -= size
+}
+
+// contains reports whether the given destination is under the quota prefix.
+func (q *quota) contains(dsturl *url.URL) bool {
+	return dsturl.IsRemote() && dsturl.Bucket == q.prefix.Bucket && strings.HasPrefix(dsturl.Path, q.prefix.Path)
+}
+
+// prefixUsage is the total size and the number of the objects under a
+// prefix. It is calculated once, like du, and kept up to date with the
+// uploads of the process.
+type prefixUsage struct {
+	mu     sync.Mutex
+	loaded bool
+	size   int64
+	count  int64
+}
+
+// quotaUsages caches the usages of the quota prefixes, so that the commands
+// of run and sync share them instead of listing the prefixes again.
+var quotaUsages = struct {
+	sync.Mutex
+	usages map[string]*prefixUsage
+}{usages: map[string]*prefixUsage{}}
+
+func quotaUsageOf(prefix *url.URL) *prefixUsage {
+	quotaUsages.Lock()
+	defer quotaUsages.Unlock()
+
+	key := prefix.String()
+	usage, ok := quotaUsages.usages[key]
+	if !ok {
+		usage = &prefixUsage{}
+		quotaUsages.usages[key] = usage
+	}
+	return usage
+}
+
+// load calculates the usage of the prefix unless it is already calculated.
+func (u *prefixUsage) load(ctx context.Context, prefix *url.URL, storageOpts storage.Options) error {
+	if u.loaded {
+		return nil
+	}
+
+	client, err := storage.NewRemoteClient(ctx, prefix, storageOpts)
+	if err != nil {
+		return err
+	}
+
+	listurl, err := url.New(fmt.Sprintf("s3://%v/%v*", prefix.Bucket, prefix.Path))
+	if err != nil {
+		return err
+	}
+
+	var size, count int64
+	for object := range client.List(ctx, listurl, false) {
+		if err := object.Err; err != nil {
+			if err == storage.ErrNoObjectFound {
+				continue
+			}
+			if errorpkg.IsCancelation(err) {
+				return err
+			}
+			return fmt.Errorf("calculating usage of %v: %w", prefix, err)
+		}
+		if object.Type.IsDir() {
+			continue
+		}
+		size += object.Size
+		count++
+	}
+
+	u.size, u.count, u.loaded = size, count, true
+	return nil
+}
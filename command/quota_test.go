@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestQuotaPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dst      string
+		expected string
+	}{
+		{dst: "s3://bucket", expected: "s3://bucket"},
+		{dst: "s3://bucket/", expected: "s3://bucket"},
+		{dst: "s3://bucket/tenant/", expected: "s3://bucket/tenant/"},
+		{dst: "s3://bucket/object", expected: "s3://bucket"},
+		{dst: "s3://bucket/tenant/a/object", expected: "s3://bucket/tenant/a/"},
+	}
+	for _, tc := range tests {
+		prefix, err := quotaPrefix(tc.dst, false)
+		assert.NoError(t, err, tc.dst)
+		assert.Equal(t, tc.expected, prefix.String(), tc.dst)
+	}
+
+	for _, dst := range []string{"dir/", "s3://bucket/tenant/*"} {
+		_, err := quotaPrefix(dst, false)
+		assert.Error(t, err, dst)
+	}
+}
+
+func TestQuotaReserve(t *testing.T) {
+	t.Parallel()
+
+	prefix, _ := url.New("s3://quota-reserve-bucket/tenant/")
+
+	// the usage is loaded as if the prefix is already listed.
+	usage := quotaUsageOf(prefix)
+	usage.loaded, usage.size, usage.count = true, 100, 2
+
+	q := &quota{prefix: prefix, maxSize: 200, maxCount: 4}
+
+	dst, _ := url.New("s3://quota-reserve-bucket/tenant/a/object")
+	other, _ := url.New("s3://quota-reserve-bucket/other/object")
+	ctx := context.Background()
+
+	assert.NoError(t, q.reserve(ctx, dst, 60))
+
+	// the objects would exceed the size.
+	err := q.reserve(ctx, dst, 41)
+	assert.True(t, errors.Is(err, errQuotaExceeded))
+
+	// objects out of the prefix are not counted.
+	assert.NoError(t, q.reserve(ctx, other, 1000))
+
+	// the space of the objects which aren't uploaded is released.
+	q.release(dst, 60)
+	assert.NoError(t, q.reserve(ctx, dst, 100))
+
+	// the objects would exceed the count.
+	assert.NoError(t, q.reserve(ctx, dst, 0))
+	err = q.reserve(ctx, dst, 0)
+	assert.True(t, errors.Is(err, errQuotaExceeded))
+
+	// no quota is set.
+	var noQuota *quota
+	assert.NoError(t, noQuota.reserve(ctx, dst, 1000))
+}
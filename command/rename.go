@@ -0,0 +1,192 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+// keyRenamer rewrites the names of the objects in the destination with the
+// sed-style substitution or the Go template given by --rename flag.
+type keyRenamer struct {
+	pattern     *regexp.Regexp
+	replacement string
+	global      bool
+
+	template *template.Template
+}
+
+// renameData is the data the rename templates are executed with.
+type renameData struct {
+	// Name is the name of the object relative to the destination, e.g.
+	// "logs/app.log".
+	Name    string
+	Dir     string
+	Base    string
+	Ext     string
+	Size    int64
+	ModTime time.Time
+}
+
+var renameFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+}
+
+// parseRename parses a rename expression, which is either a sed-style
+// substitution such as "s/\.log$/.log.gz/" or a Go template such as
+// "{{.ModTime.Format "2006/01/02"}}/{{.Name | lower}}".
+func parseRename(expr string) (*keyRenamer, error) {
+	if strings.Contains(expr, "{{") {
+		tmpl, err := template.New("rename").Funcs(renameFuncs).Option("missingkey=error").Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename template %q: %w", expr, err)
+		}
+		return &keyRenamer{template: tmpl}, nil
+	}
+
+	invalid := fmt.Errorf("invalid rename expression %q, expected s/<regexp>/<replacement>/[gi] or a Go template", expr)
+
+	if len(expr) < 2 || expr[0] != 's' {
+		return nil, invalid
+	}
+
+	// any character following "s" is the delimiter, as in sed.
+	delim := expr[1]
+	parts := splitUnescaped(expr[2:], delim)
+	if len(parts) != 3 || parts[0] == "" {
+		return nil, invalid
+	}
+
+	pattern, replacement, flags := parts[0], parts[1], parts[2]
+
+	var global bool
+	for _, flag := range flags {
+		switch flag {
+		case 'g':
+			global = true
+		case 'i':
+			pattern = "(?i)" + pattern
+		default:
+			return nil, fmt.Errorf("unsupported rename flag %q", flag)
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rename expression %q: %w", expr, err)
+	}
+
+	return &keyRenamer{
+		pattern:     re,
+		replacement: sedReplacement(replacement),
+		global:      global,
+	}, nil
+}
+
+// rename returns the new name of the object whose name relative to the
+// destination is given.
+func (r *keyRenamer) rename(name string, object *storage.Object) (string, error) {
+	var renamed string
+	if r.template != nil {
+		data := renameData{
+			Name: name,
+			Dir:  path.Dir(name),
+			Base: path.Base(name),
+			Ext:  path.Ext(name),
+		}
+		if object != nil {
+			data.Size = object.Size
+			if object.ModTime != nil {
+				data.ModTime = *object.ModTime
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := r.template.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		renamed = buf.String()
+	} else if r.global {
+		renamed = r.pattern.ReplaceAllString(name, r.replacement)
+	} else {
+		renamed = r.replaceFirst(name)
+	}
+
+	if renamed == "" || strings.HasSuffix(renamed, "/") {
+		return "", fmt.Errorf("%q is renamed to an invalid name %q", name, renamed)
+	}
+	return renamed, nil
+}
+
+func (r *keyRenamer) replaceFirst(name string) string {
+	match := r.pattern.FindStringSubmatchIndex(name)
+	if match == nil {
+		return name
+	}
+
+	replaced := r.pattern.ExpandString(nil, r.replacement, name, match)
+	return name[:match[0]] + string(replaced) + name[match[1]:]
+}
+
+// splitUnescaped splits s by the delimiter, skipping the escaped delimiters.
+// The escaped delimiters are unescaped, other escapes are kept as is.
+func splitUnescaped(s string, delim byte) []string {
+	var (
+		parts []string
+		part  strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			part.WriteByte(delim)
+			i++
+		case s[i] == '\\' && i+1 < len(s):
+			part.WriteByte(s[i])
+			part.WriteByte(s[i+1])
+			i++
+		case s[i] == delim:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(s[i])
+		}
+	}
+	return append(parts, part.String())
+}
+
+// sedReplacement converts a sed replacement to the template of
+// regexp.Expand: "\1" refers to the first group and "&" to the whole match,
+// other escaped characters and "$" are literals.
+func sedReplacement(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			if next := s[i]; next >= '0' && next <= '9' {
+				fmt.Fprintf(&b, "${%c}", next)
+			} else if next == '$' {
+				b.WriteString("$$")
+			} else {
+				b.WriteByte(next)
+			}
+		case c == '&':
+			b.WriteString("${0}")
+		case c == '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
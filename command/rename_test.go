@@ -0,0 +1,131 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	object := &storage.Object{Size: 42, ModTime: &modTime}
+
+	testcases := []struct {
+		expr     string
+		name     string
+		expected string
+	}{
+		{
+			expr:     `s/\.log$/.log.gz/`,
+			name:     "app/server.log",
+			expected: "app/server.log.gz",
+		},
+		{
+			expr:     `s/\.log$/\.log.gz/`,
+			name:     "app/server.txt",
+			expected: "app/server.txt",
+		},
+		{
+			expr:     `s/a/_/`,
+			name:     "banana",
+			expected: "b_nana",
+		},
+		{
+			expr:     `s/a/_/g`,
+			name:     "banana",
+			expected: "b_n_n_",
+		},
+		{
+			expr:     `s/^(\w+)-(\d+)/\2-\1/`,
+			name:     "report-2024.csv",
+			expected: "2024-report.csv",
+		},
+		{
+			expr:     `s/REPORT/[&]/i`,
+			name:     "report.csv",
+			expected: "[report].csv",
+		},
+		{
+			expr:     `s|^|archive/$|`,
+			name:     "report.csv",
+			expected: "archive/$report.csv",
+		},
+		{
+			expr:     `s/\//-/g`,
+			name:     "a/b/c.txt",
+			expected: "a-b-c.txt",
+		},
+		{
+			expr:     `{{.ModTime.Format "2006/01/02"}}/{{.Name | lower}}`,
+			name:     "logs/App.LOG",
+			expected: "2024/03/09/logs/app.log",
+		},
+		{
+			expr:     `{{.Dir}}/{{.Base | trimSuffix .Ext}}-{{.Size}}{{.Ext | upper}}`,
+			name:     "logs/app.log",
+			expected: "logs/app-42.LOG",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			renamer, err := parseRename(tc.expr)
+			assert.NoError(t, err)
+
+			got, err := renamer.rename(tc.name, object)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestRenameFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		expr        string
+		expectedErr string
+	}{
+		{
+			expr:        "report.csv",
+			expectedErr: `invalid rename expression "report.csv", expected s/<regexp>/<replacement>/[gi] or a Go template`,
+		},
+		{
+			expr:        "s/a/b",
+			expectedErr: `invalid rename expression "s/a/b", expected s/<regexp>/<replacement>/[gi] or a Go template`,
+		},
+		{
+			expr:        "s/a/b/x",
+			expectedErr: `unsupported rename flag 'x'`,
+		},
+		{
+			expr:        "s/(/b/",
+			expectedErr: "invalid rename expression \"s/(/b/\": error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseRename(tc.expr)
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+
+	// names can't be renamed to an empty name.
+	renamer, err := parseRename("s/.*//")
+	assert.NoError(t, err)
+
+	_, err = renamer.rename("report.csv", nil)
+	assert.EqualError(t, err, `"report.csv" is renamed to an invalid name ""`)
+}
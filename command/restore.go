@@ -0,0 +1,234 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var restoreHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Restore an archived object for a day
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/object
+
+	2. Restore archived objects with a prefix for 7 days with the bulk retrieval tier
+		 > s5cmd {{.HelpName}} --days 7 --tier Bulk "s3://bucket/prefix/*"
+
+	3. Restore archived objects with a prefix and wait until the restorations are completed
+		 > s5cmd {{.HelpName}} --days 7 "s3://bucket/prefix/*" && s5cmd restore-status --wait "s3://bucket/prefix/*"
+`
+
+// restoreStateAccepted is the state of the objects whose restorations are
+// requested by the restore command.
+const restoreStateAccepted = "accepted"
+
+// restoreTiers are the retrieval tiers of the restorations.
+var restoreTiers = []string{"Standard", "Bulk", "Expedited"}
+
+func NewRestoreCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "restore",
+		HelpName:           "restore",
+		Usage:              "restore archived objects",
+		CustomHelpTemplate: restoreHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "days",
+				Value: 1,
+				Usage: "number of days the restored copies of the objects are kept",
+			},
+			&cli.StringFlag{
+				Name:  "tier",
+				Value: "Standard",
+				Usage: "retrieval tier of the restorations: (Standard, Bulk, Expedited)",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateRestoreCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			tier, _ := parseRestoreTier(c.String("tier"))
+			return Restore{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				// flags
+				days: int64(c.Int("days")),
+				tier: tier,
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Restore holds restore operation flags and states.
+type Restore struct {
+	src         string
+	op          string
+	fullCommand string
+
+	// flags
+	days int64
+	tier string
+
+	storageOpts storage.Options
+}
+
+// Run requests the restorations of the archived objects matching the source
+// concurrently, and prints whether each of them is accepted, in progress
+// already or completed already.
+func (r Restore) Run(ctx context.Context) error {
+	srcurl, err := url.New(r.src)
+	if err != nil {
+		printError(r.fullCommand, r.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, r.storageOpts)
+	if err != nil {
+		printError(r.fullCommand, r.op, err)
+		return err
+	}
+
+	objch, err := expandSource(ctx, client, false, srcurl)
+	if err != nil {
+		printError(r.fullCommand, r.op, err)
+		return err
+	}
+
+	waiter := parallel.NewWaiter()
+
+	var (
+		merrorWaiter  error
+		merrorObjects error
+		errDone       = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			if errorpkg.IsCancelation(err) {
+				continue
+			}
+			printError(r.fullCommand, r.op, err)
+			merrorWaiter = multierror.Append(merrorWaiter, err)
+		}
+	}()
+
+	for object := range objch {
+		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
+			continue
+		}
+
+		if err := object.Err; err != nil {
+			merrorObjects = multierror.Append(merrorObjects, err)
+			printError(r.fullCommand, r.op, err)
+			continue
+		}
+
+		// the storage classes of the objects are only known if they are
+		// listed, the others are asked to be restored anyway.
+		if object.StorageClass != "" && !object.StorageClass.IsArchived() {
+			continue
+		}
+
+		object := object
+		task := func() error {
+			return r.restore(ctx, client, object.URL)
+		}
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+}
+
+// restore requests the restoration of the object and prints its state.
+func (r Restore) restore(ctx context.Context, client *storage.S3, u *url.URL) error {
+	restored, err := client.Restore(ctx, u, r.days, r.tier)
+
+	state := restoreStateAccepted
+	switch {
+	case err == storage.ErrRestoreInProgress:
+		state = restoreStateInProgress
+	case err == storage.ErrObjectNotArchived:
+		state = restoreStateNotArchived
+	case err != nil:
+		return err
+	case restored:
+		state = restoreStateCompleted
+	}
+
+	log.Info(RestoreStatusMessage{
+		Source: u,
+		State:  state,
+	})
+	return nil
+}
+
+// parseRestoreTier returns the retrieval tier of the given name, ignoring
+// its case.
+func parseRestoreTier(name string) (string, error) {
+	for _, tier := range restoreTiers {
+		if strings.EqualFold(name, tier) {
+			return tier, nil
+		}
+	}
+	return "", fmt.Errorf("tier must be one of %v", strings.Join(restoreTiers, ", "))
+}
+
+func validateRestoreCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be remote")
+	}
+
+	if srcurl.IsBucket() {
+		return fmt.Errorf("source argument must contain wildcard if it is a bucket")
+	}
+
+	if c.Int("days") < 1 {
+		return fmt.Errorf("days must be a positive integer")
+	}
+
+	if _, err := parseRestoreTier(c.String("tier")); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var restoreStatusHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print restoration status of archived objects with a prefix
+		 > s5cmd {{.HelpName}} "s3://bucket/prefix/*"
+
+	2. Wait until the restorations in progress are completed
+		 > s5cmd {{.HelpName}} --wait "s3://bucket/prefix/*"
+
+	3. Wait at most 12 hours for the restorations, checking every 10 minutes
+		 > s5cmd {{.HelpName}} --wait --timeout 12h --interval 10m "s3://bucket/prefix/*"
+`
+
+// Restoration states of objects.
+const (
+	restoreStateNotArchived = "not-archived"
+	restoreStateNotRestored = "not-restored"
+	restoreStateInProgress  = "in-progress"
+	restoreStateCompleted   = "completed"
+)
+
+func NewRestoreStatusCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "restore-status",
+		HelpName:           "restore-status",
+		Usage:              "print restoration status of archived objects",
+		CustomHelpTemplate: restoreStatusHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "wait",
+				Usage: "wait until the restorations in progress are completed",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "fail if the restorations are not completed in given duration, requires --wait",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Value: time.Minute,
+				Usage: "duration between status checks of the restorations in progress",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateRestoreStatusCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return RestoreStatus{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				// flags
+				wait:     c.Bool("wait"),
+				timeout:  c.Duration("timeout"),
+				interval: c.Duration("interval"),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// RestoreStatus holds restore-status operation flags and states.
+type RestoreStatus struct {
+	src         string
+	op          string
+	fullCommand string
+
+	// flags
+	wait     bool
+	timeout  time.Duration
+	interval time.Duration
+
+	storageOpts storage.Options
+}
+
+// Run prints restoration status of the objects matching the source, and
+// optionally waits for the restorations in progress to complete.
+func (r RestoreStatus) Run(ctx context.Context) error {
+	srcurl, err := url.New(r.src)
+	if err != nil {
+		printError(r.fullCommand, r.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, r.storageOpts)
+	if err != nil {
+		printError(r.fullCommand, r.op, err)
+		return err
+	}
+
+	objch, err := expandSource(ctx, client, false, srcurl)
+	if err != nil {
+		printError(r.fullCommand, r.op, err)
+		return err
+	}
+
+	var (
+		merror  error
+		objects []*storage.Object
+	)
+	for object := range objch {
+		if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
+			continue
+		}
+
+		if err := object.Err; err != nil {
+			merror = multierror.Append(merror, err)
+			printError(r.fullCommand, r.op, err)
+			continue
+		}
+
+		objects = append(objects, object)
+	}
+
+	var timeout <-chan time.Time
+	if r.timeout > 0 {
+		timeout = time.After(r.timeout)
+	}
+
+	pending, err := r.check(ctx, client, objects, true)
+	if err != nil {
+		merror = multierror.Append(merror, err)
+	}
+
+	for r.wait && len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			err := fmt.Errorf("timed out waiting for restoration of %d objects", len(pending))
+			printError(r.fullCommand, r.op, err)
+			return multierror.Append(merror, err).ErrorOrNil()
+		case <-time.After(r.interval):
+		}
+
+		pending, err = r.check(ctx, client, pending, false)
+		if err != nil {
+			merror = multierror.Append(merror, err)
+		}
+	}
+
+	return merror
+}
+
+// check fetches the restoration status of the given objects concurrently and
+// returns the objects whose restorations are in progress. The status of the
+// objects in progress are only printed on the first check.
+func (r RestoreStatus) check(
+	ctx context.Context,
+	client *storage.S3,
+	objects []*storage.Object,
+	first bool,
+) ([]*storage.Object, error) {
+	waiter := parallel.NewWaiter()
+
+	var (
+		mu      sync.Mutex
+		pending []*storage.Object
+		merror  error
+		errDone = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			printError(r.fullCommand, r.op, err)
+			merror = multierror.Append(merror, err)
+		}
+	}()
+
+	for _, object := range objects {
+		object := object
+		task := func() error {
+			// the storage class of the objects are not known unless they
+			// are listed by a wildcard.
+			obj, err := client.Head(ctx, object.URL)
+			if err != nil {
+				return err
+			}
+
+			state, expiry, err := restoreState(obj.StorageClass, obj.Restore)
+			if err != nil {
+				return err
+			}
+			if state == restoreStateInProgress {
+				mu.Lock()
+				pending = append(pending, object)
+				mu.Unlock()
+
+				if !first {
+					return nil
+				}
+			}
+
+			log.Info(RestoreStatusMessage{
+				Source: object.URL,
+				State:  state,
+				Expiry: expiry,
+			})
+			return nil
+		}
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	return pending, merror
+}
+
+// restoreState returns the restoration state of an object of the given
+// storage class, and the expiry of its restored copy, from the value of its
+// x-amz-restore header.
+func restoreState(class storage.StorageClass, header string) (string, *time.Time, error) {
+	if header == "" {
+		if class.IsArchived() {
+			return restoreStateNotRestored, nil, nil
+		}
+		return restoreStateNotArchived, nil, nil
+	}
+
+	status, err := storage.ParseRestoreStatus(header)
+	if err != nil {
+		return "", nil, err
+	}
+	if status.Ongoing {
+		return restoreStateInProgress, nil, nil
+	}
+	return restoreStateCompleted, status.Expiry, nil
+}
+
+// RestoreStatusMessage is a structure for logging restoration status of
+// objects.
+type RestoreStatusMessage struct {
+	Source *url.URL   `json:"source"`
+	State  string     `json:"state"`
+	Expiry *time.Time `json:"expiry,omitempty"`
+}
+
+// String returns the string representation of RestoreStatusMessage.
+func (m RestoreStatusMessage) String() string {
+	if m.Expiry == nil {
+		return fmt.Sprintf("%-12s %v", m.State, m.Source)
+	}
+	return fmt.Sprintf("%-12s %v expires %v", m.State, m.Source, m.Expiry.Format(dateFormat))
+}
+
+// JSON returns the JSON representation of RestoreStatusMessage.
+func (m RestoreStatusMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validateRestoreStatusCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be remote")
+	}
+
+	if srcurl.IsBucket() {
+		return fmt.Errorf("source argument must contain wildcard if it is a bucket")
+	}
+
+	if c.IsSet("timeout") && !c.Bool("wait") {
+		return fmt.Errorf("timeout requires wait flag")
+	}
+
+	if c.Duration("interval") <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestParseRestoreTier(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{
+		"Standard":  "Standard",
+		"bulk":      "Bulk",
+		"EXPEDITED": "Expedited",
+	} {
+		tier, err := parseRestoreTier(name)
+		assert.NoError(t, err)
+		assert.Equal(t, want, tier)
+	}
+
+	_, err := parseRestoreTier("Fast")
+	assert.EqualError(t, err, "tier must be one of Standard, Bulk, Expedited")
+}
+
+func TestRestoreState(t *testing.T) {
+	t.Parallel()
+
+	glacier := storage.StorageClass("GLACIER")
+	expiry := time.Date(2012, 12, 21, 0, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		name           string
+		class          storage.StorageClass
+		header         string
+		expectedState  string
+		expectedExpiry *time.Time
+		expectedErr    bool
+	}{
+		{
+			name:          "standard",
+			class:         storage.StorageClass("STANDARD"),
+			expectedState: restoreStateNotArchived,
+		},
+		{
+			name:          "archived",
+			class:         glacier,
+			expectedState: restoreStateNotRestored,
+		},
+		{
+			name:          "in progress",
+			class:         glacier,
+			header:        `ongoing-request="true"`,
+			expectedState: restoreStateInProgress,
+		},
+		{
+			name:           "completed",
+			class:          glacier,
+			header:         `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`,
+			expectedState:  restoreStateCompleted,
+			expectedExpiry: &expiry,
+		},
+		{
+			name:        "invalid header",
+			class:       glacier,
+			header:      `ongoing-request`,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			state, expiry, err := restoreState(tc.class, tc.header)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedState, state)
+			assert.Equal(t, tc.expectedExpiry, expiry)
+		})
+	}
+}
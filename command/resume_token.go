@@ -0,0 +1,65 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// resumeToken is the listing state persisted to the resume token file.
+type resumeToken struct {
+	Source string `json:"source"`
+	Token  string `json:"token"`
+}
+
+// readResumeToken reads the continuation token recorded by a previous
+// listing of the given source from the given file. A missing file yields an
+// empty token, so that the listing starts from the beginning.
+func readResumeToken(path, source string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var state resumeToken
+	if err := json.Unmarshal(data, &state); err != nil {
+		return "", fmt.Errorf("invalid resume token file %q: %v", path, err)
+	}
+
+	if state.Source != source {
+		return "", fmt.Errorf(
+			"resume token file %q belongs to listing of %q, not %q",
+			path, state.Source, source,
+		)
+	}
+	return state.Token, nil
+}
+
+// writeResumeToken atomically records the continuation token of the listing
+// of the given source to the given file.
+func writeResumeToken(path, source, token string) error {
+	data, err := json.Marshal(resumeToken{Source: source, Token: token})
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(data, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
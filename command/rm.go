@@ -1,8 +1,13 @@
 package command
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
@@ -38,6 +43,42 @@ Examples:
 
 	5. Delete all matching objects but exclude the ones with .txt extension or starts with "main"
 		 > s5cmd {{.HelpName}} --exclude "*.txt" --exclude "main*" s3://bucketname/prefix/*
+
+	6. Delete all objects with a prefix, at most 50 objects per second
+		 > s5cmd {{.HelpName}} --rate 50 s3://bucketname/prefix/*
+
+	7. Delete all objects of a user and keep a signed proof of the deletion
+		 > S5CMD_LOG_PROOF_KEY=<secret> s5cmd {{.HelpName}} --log-proof erasure.log s3://bucketname/users/42/*
+
+	8. Undelete all objects with a prefix on a versioned bucket by removing their delete markers
+		 > s5cmd {{.HelpName}} --delete-markers-only s3://bucketname/prefix/*
+
+	9. Delete only the objects with .tmp extension under a prefix
+		 > s5cmd {{.HelpName}} --include "*.tmp" s3://bucketname/prefix/*
+
+	10. Delete all matching objects with .log extension but exclude the ones starts with "audit"
+		 > s5cmd {{.HelpName}} --include "*.log" --exclude "audit*" s3://bucketname/prefix/*
+
+	11. Delete all objects with a prefix, including the ones under governance-mode object lock retention
+		 > s5cmd {{.HelpName}} --bypass-governance-retention s3://bucketname/prefix/*
+
+	12. Delete all matching objects, confirming each of them
+		 > s5cmd {{.HelpName}} --interactive s3://bucketname/prefix/*
+
+	13. Delete all objects with a prefix which are modified more than 30 days ago
+		 > s5cmd {{.HelpName}} --older-than 30d s3://bucketname/tmp/*
+
+	14. Delete the keys listed in a file, relative to a prefix
+		 > s5cmd {{.HelpName}} --files-from keys.txt s3://bucketname/prefix/
+
+	15. Delete the NUL separated keys read from standard input
+		 > s5cmd {{.HelpName}} --files-from - --null s3://bucketname/ < keys.nul
+
+	16. Delete all objects with a prefix on an S3 compatible service, 100 objects per request and at most 500 objects per second
+		 > s5cmd --endpoint-url https://ceph.local:7480 {{.HelpName}} --batch-size 100 --rate 500 s3://bucketname/prefix/*
+
+	17. Delete all empty objects with a prefix
+		 > s5cmd {{.HelpName}} --max-size 0 s3://bucketname/prefix/*
 `
 
 func NewDeleteCommand() *cli.Command {
@@ -54,6 +95,69 @@ func NewDeleteCommand() *cli.Command {
 				Name:  "exclude",
 				Usage: "exclude objects with given pattern",
 			},
+			&cli.StringSliceFlag{
+				Name:  "include",
+				Usage: "only remove objects with given pattern, exclude patterns take precedence",
+			},
+			&cli.StringFlag{
+				Name:  "older-than",
+				Usage: "only remove objects modified more than given time ago, e.g. 30d, 2w or 12h",
+			},
+			&cli.StringFlag{
+				Name:  "newer-than",
+				Usage: "only remove objects modified less than given time ago, e.g. 30d, 2w or 12h",
+			},
+			&cli.StringFlag{
+				Name:  "min-size",
+				Usage: "only remove objects whose sizes are greater than or equal to given size, e.g. 1GB or 512KiB",
+			},
+			&cli.StringFlag{
+				Name:  "max-size",
+				Usage: "only remove objects whose sizes are less than or equal to given size, e.g. 10MB or 512KiB",
+			},
+			&cli.BoolFlag{
+				Name:  "bypass-governance-retention",
+				Usage: "remove objects under governance-mode object lock retention, requires s3:BypassGovernanceRetention permission",
+			},
+			&cli.BoolFlag{
+				// used by sync command to report locked objects instead of
+				// failing the whole operation.
+				Name:   "skip-locked",
+				Usage:  "skip objects that are protected by object lock",
+				Hidden: true,
+			},
+			&cli.Float64Flag{
+				Name:    "rate",
+				Aliases: []string{"delete-rate"},
+				Usage:   "limit the number of deleted objects per second",
+			},
+			&cli.IntFlag{
+				Name:    "batch-size",
+				Aliases: []string{"delete-batch-size"},
+				Value:   storage.MaxDeleteBatchSize,
+				Usage:   "number of objects deleted with a single DeleteObjects request",
+			},
+			&cli.StringFlag{
+				Name:  "log-proof",
+				Usage: "append an entry with timestamp and version id of each deleted object to given file, signed with the key in S5CMD_LOG_PROOF_KEY environment variable",
+			},
+			&cli.BoolFlag{
+				Name:    "interactive",
+				Aliases: []string{"i"},
+				Usage:   "prompt before removing each object, answering 'all' removes the rest without prompting",
+			},
+			&cli.StringFlag{
+				Name:  "files-from",
+				Usage: "remove the keys listed in given file, one per line, relative to the bucket or prefix argument; '-' reads them from standard input",
+			},
+			&cli.BoolFlag{
+				Name:  "null",
+				Usage: "keys of --files-from are separated by NUL characters instead of newlines",
+			},
+			&cli.BoolFlag{
+				Name:  "delete-markers-only",
+				Usage: "only remove the delete markers which are the latest versions of the objects, restoring their newest versions on versioned buckets",
+			},
 		},
 		CustomHelpTemplate: deleteHelpTemplate,
 		Before: func(c *cli.Context) error {
@@ -65,16 +169,37 @@ func NewDeleteCommand() *cli.Command {
 		},
 		Action: func(c *cli.Context) (err error) {
 			defer stat.Collect(c.Command.FullName(), &err)()
+
+			// the ages and the sizes are validated before the command runs.
+			age, _ := parseAgeFilter(c)
+			sizeRange, _ := parseSizeFilter(c)
+
+			storageOpts := NewStorageOpts(c)
+			storageOpts.BypassGovernanceRetention = c.Bool("bypass-governance-retention")
+			storageOpts.DeleteRate = c.Float64("rate")
+			storageOpts.DeleteBatchSize = c.Int("batch-size")
+
 			return Delete{
 				src:         c.Args().Slice(),
 				op:          c.Command.Name,
 				fullCommand: commandFromContext(c),
 
 				// flags
-				raw:     c.Bool("raw"),
-				exclude: c.StringSlice("exclude"),
-
-				storageOpts: NewStorageOpts(c),
+				raw:         c.Bool("raw"),
+				exclude:     c.StringSlice("exclude"),
+				include:     c.StringSlice("include"),
+				age:         age,
+				sizeRange:   sizeRange,
+				skipLocked:  c.Bool("skip-locked"),
+				logProof:    c.String("log-proof"),
+				logProofKey: os.Getenv(logProofKeyEnv),
+
+				deleteMarkersOnly: c.Bool("delete-markers-only"),
+				confirmer:         newConfirmer(c),
+				filesFrom:         c.String("files-from"),
+				nullSeparated:     c.Bool("null"),
+
+				storageOpts: storageOpts,
 			}.Run(c.Context)
 		},
 	}
@@ -87,8 +212,19 @@ type Delete struct {
 	fullCommand string
 
 	// flag options
-	exclude []string
-	raw     bool
+	exclude     []string
+	include     []string
+	age         ageFilter
+	sizeRange   sizeFilter
+	raw         bool
+	skipLocked  bool
+	logProof    string
+	logProofKey string
+
+	deleteMarkersOnly bool
+	confirmer         *confirmer
+	filesFrom         string
+	nullSeparated     bool
 
 	// storage options
 	storageOpts storage.Options
@@ -115,7 +251,49 @@ func (d Delete) Run(ctx context.Context) error {
 		return err
 	}
 
-	objch := expandSources(ctx, client, false, srcurls...)
+	includePatterns, err := createIncludesFromWildcard(d.include)
+	if err != nil {
+		printError(d.fullCommand, d.op, err)
+		return err
+	}
+
+	// there is nothing to prove on dry-run.
+	var proof *deletionProofLog
+	if d.logProof != "" && !d.storageOpts.DryRun {
+		proof, err = newDeletionProofLog(d.logProof, d.logProofKey)
+		if err != nil {
+			printError(d.fullCommand, d.op, err)
+			return err
+		}
+	}
+
+	var objch <-chan *storage.Object
+	if d.filesFrom != "" {
+		input := os.Stdin
+		if d.filesFrom != "-" {
+			input, err = os.Open(d.filesFrom)
+			if err != nil {
+				printError(d.fullCommand, d.op, err)
+				return err
+			}
+			defer input.Close()
+		}
+
+		delim := byte('\n')
+		if d.nullSeparated {
+			delim = 0
+		}
+		objch = readKeys(input, delim, srcurl)
+	} else if d.deleteMarkersOnly {
+		remoteClient, err := storage.NewRemoteClient(ctx, srcurl, d.storageOpts)
+		if err != nil {
+			printError(d.fullCommand, d.op, err)
+			return err
+		}
+		objch = listDeleteMarkers(ctx, remoteClient, srcurls...)
+	} else {
+		objch = expandSources(ctx, client, false, srcurls...)
+	}
 
 	var (
 		merrorObjects error
@@ -142,6 +320,32 @@ func (d Delete) Run(ctx context.Context) error {
 				continue
 			}
 
+			if !isURLIncluded(includePatterns, object.URL.Path, srcurl.Prefix) {
+				continue
+			}
+
+			if ok, err := d.age.match(ctx, client, object); err != nil {
+				merrorObjects = multierror.Append(merrorObjects, err)
+				printError(d.fullCommand, d.op, err)
+				continue
+			} else if !ok {
+				printDebug(d.op, errObjectAgeMismatch, object.URL)
+				continue
+			}
+
+			if ok, err := d.sizeRange.match(ctx, client, object); err != nil {
+				merrorObjects = multierror.Append(merrorObjects, err)
+				printError(d.fullCommand, d.op, err)
+				continue
+			} else if !ok {
+				printDebug(d.op, errObjectSizeMismatch, object.URL)
+				continue
+			}
+
+			if !d.confirmer.confirm(fmt.Sprintf("%v %v", d.op, object.URL)) {
+				continue
+			}
+
 			urlch <- object.URL
 		}
 	}()
@@ -154,11 +358,29 @@ func (d Delete) Run(ctx context.Context) error {
 				continue
 			}
 
+			if d.skipLocked && errors.Is(err, storage.ErrObjectLocked) {
+				printWarning(d.op, "skipped: locked", obj.URL)
+				continue
+			}
+
 			merrorResult = multierror.Append(merrorResult, obj.Err)
 			printError(d.fullCommand, d.op, obj.Err)
 			continue
 		}
 
+		if proof != nil {
+			if err := proof.Record(d.op, obj); err != nil {
+				merrorResult = multierror.Append(merrorResult, err)
+				printError(d.fullCommand, d.op, err)
+			}
+		}
+
+		// the objects are not known to be under retention, so every removed
+		// object is reported to make the bypass explicit.
+		if d.storageOpts.BypassGovernanceRetention {
+			printWarning(d.op, "governance retention bypassed", obj.URL)
+		}
+
 		msg := log.InfoMessage{
 			Operation: d.op,
 			Source:    obj.URL,
@@ -166,9 +388,85 @@ func (d Delete) Run(ctx context.Context) error {
 		log.Info(msg)
 	}
 
+	if proof != nil {
+		if err := proof.Close(); err != nil {
+			merrorResult = multierror.Append(merrorResult, err)
+			printError(d.fullCommand, d.op, err)
+		}
+	}
+
 	return multierror.Append(merrorResult, merrorObjects).ErrorOrNil()
 }
 
+// listDeleteMarkers lists the delete markers hiding the objects matching
+// the given sources.
+func listDeleteMarkers(ctx context.Context, client *storage.S3, srcurls ...*url.URL) <-chan *storage.Object {
+	ch := make(chan *storage.Object)
+
+	go func() {
+		defer close(ch)
+
+		found := false
+		for _, srcurl := range srcurls {
+			for object := range client.ListDeleteMarkers(ctx, srcurl) {
+				if object.Err == storage.ErrNoObjectFound {
+					continue
+				}
+				found = true
+				ch <- object
+			}
+		}
+
+		if !found {
+			ch <- &storage.Object{Err: storage.ErrNoObjectFound}
+		}
+	}()
+
+	return ch
+}
+
+// readKeys reads the keys separated by the given delimiter from the reader
+// and sends them as the objects under the given prefix. Empty keys are
+// skipped.
+func readKeys(r io.Reader, delim byte, prefix *url.URL) <-chan *storage.Object {
+	ch := make(chan *storage.Object)
+
+	go func() {
+		defer close(ch)
+
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadString(delim)
+			if err != nil && err != io.EOF {
+				ch <- &storage.Object{Err: err}
+				return
+			}
+
+			key := strings.TrimSuffix(line, string(delim))
+			if delim == '\n' {
+				key = strings.TrimSuffix(key, "\r")
+			}
+			if key != "" {
+				keyurl, uerr := url.New(
+					fmt.Sprintf("s3://%v/%v%v", prefix.Bucket, prefix.Path, key),
+					url.WithRaw(true),
+				)
+				if uerr != nil {
+					ch <- &storage.Object{Err: uerr}
+				} else {
+					ch <- &storage.Object{URL: keyurl}
+				}
+			}
+
+			if err == io.EOF {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
 // newSources creates object URL list from given sources.
 func newURLs(urlMode bool, sources ...string) ([]*url.URL, error) {
 	var urls []*url.URL
@@ -187,11 +485,47 @@ func validateRMCommand(c *cli.Context) error {
 		return fmt.Errorf("expected at least 1 object to remove")
 	}
 
+	if c.Float64("rate") < 0 {
+		return fmt.Errorf("rate must be a positive number")
+	}
+
+	if n := c.Int("batch-size"); c.IsSet("batch-size") && (n < 1 || n > storage.MaxDeleteBatchSize) {
+		return fmt.Errorf("batch-size must be between 1 and %d", storage.MaxDeleteBatchSize)
+	}
+
+	if _, err := parseAgeFilter(c); err != nil {
+		return err
+	}
+
+	if _, err := parseSizeFilter(c); err != nil {
+		return err
+	}
+
+	if c.String("log-proof") != "" && os.Getenv(logProofKeyEnv) == "" {
+		return fmt.Errorf("log-proof requires a signing key in %v environment variable", logProofKeyEnv)
+	}
+
 	srcurls, err := newURLs(c.Bool("raw"), c.Args().Slice()...)
 	if err != nil {
 		return err
 	}
 
+	if c.String("files-from") != "" {
+		return validateFilesFrom(c, srcurls)
+	}
+
+	if c.Bool("null") {
+		return fmt.Errorf("null can only be used with files-from")
+	}
+
+	if c.Bool("delete-markers-only") && !srcurls[0].IsRemote() {
+		return fmt.Errorf("delete-markers-only can only be used with remote sources")
+	}
+
+	if c.Bool("bypass-governance-retention") && !srcurls[0].IsRemote() {
+		return fmt.Errorf("bypass-governance-retention can only be used with remote sources")
+	}
+
 	var (
 		firstBucket         string
 		hasRemote, hasLocal bool
@@ -222,3 +556,26 @@ func validateRMCommand(c *cli.Context) error {
 
 	return nil
 }
+
+// validateFilesFrom validates the sources of rm with --files-from flag, which
+// must be a single bucket or prefix the keys are relative to.
+func validateFilesFrom(c *cli.Context, srcurls []*url.URL) error {
+	if len(srcurls) != 1 {
+		return fmt.Errorf("files-from expects a single bucket or prefix argument")
+	}
+
+	srcurl := srcurls[0]
+	if !srcurl.IsRemote() || srcurl.IsWildcard() || !(srcurl.IsBucket() || srcurl.IsPrefix()) {
+		return fmt.Errorf("files-from expects a bucket or prefix argument without wildcards, e.g. s3://bucket/prefix/")
+	}
+
+	if c.Bool("delete-markers-only") {
+		return fmt.Errorf("files-from can not be used with delete-markers-only")
+	}
+
+	// the answers of the prompts are read from the standard input as well.
+	if c.String("files-from") == "-" && c.Bool("interactive") && !c.Bool("force") {
+		return fmt.Errorf("interactive can not be used while reading files-from standard input")
+	}
+	return nil
+}
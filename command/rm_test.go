@@ -2,9 +2,13 @@ package command
 
 import (
 	"flag"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/storage/url"
 )
 
 func TestValidateRMCommand(t *testing.T) {
@@ -71,3 +75,64 @@ func TestValidateRMCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestReadKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		delim    byte
+		prefix   string
+		expected []string
+	}{
+		{
+			name:     "newline separated",
+			input:    "a.txt\nb/c.txt\r\n\n*.txt\nd.txt",
+			delim:    '\n',
+			prefix:   "s3://bucket/",
+			expected: []string{"s3://bucket/a.txt", "s3://bucket/b/c.txt", "s3://bucket/*.txt", "s3://bucket/d.txt"},
+		},
+		{
+			name:     "nul separated",
+			input:    "a.txt\x00name\nwith newline\x00",
+			delim:    0,
+			prefix:   "s3://bucket/prefix/",
+			expected: []string{"s3://bucket/prefix/a.txt", "s3://bucket/prefix/name\nwith newline"},
+		},
+		{
+			name:   "empty",
+			input:  "",
+			delim:  '\n',
+			prefix: "s3://bucket",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			prefix, err := url.New(tc.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for object := range readKeys(strings.NewReader(tc.input), tc.delim, prefix) {
+				if object.Err != nil {
+					t.Fatal(object.Err)
+				}
+				// keys are read in raw mode, they are not expanded.
+				if object.URL.IsWildcard() {
+					t.Errorf("expected %v to be raw", object.URL)
+				}
+				got = append(got, object.URL.String())
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
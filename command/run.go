@@ -159,6 +159,31 @@ func (r Run) Run(ctx context.Context) error {
 	return multierror.Append(merrorWaiter, reader.Err()).ErrorOrNil()
 }
 
+// runCommandLine runs the command of the given fields, the first field is the
+// name of the command. The command prints its own errors. Only the global
+// flags of the given context apply to the command, the flags of the command
+// running it are not inherited.
+func runCommandLine(c *cli.Context, fields []string) error {
+	cmd := AppCommand(fields[0])
+	if cmd == nil {
+		return fmt.Errorf("%q command not found", fields[0])
+	}
+
+	flagset := flag.NewFlagSet(fields[0], flag.ContinueOnError)
+	if err := flagset.Parse(fields); err != nil {
+		return err
+	}
+
+	// the context of the app holds the global flags.
+	root := c
+	for _, ctx := range c.Lineage() {
+		if ctx.App != nil {
+			root = ctx
+		}
+	}
+	return cmd.Run(cli.NewContext(app, flagset, root))
+}
+
 // Reader is a cancelable reader.
 type Reader struct {
 	*bufio.Reader
@@ -0,0 +1,493 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var serveHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Serve the objects of a bucket over HTTP to the local clients on port 8080
+		 > s5cmd {{.HelpName}} s3://bucket/
+
+	2. Serve the objects under a prefix to the clients on the network on port 9000
+		 > s5cmd {{.HelpName}} --listen :9000 s3://bucket/prefix/
+`
+
+// defaultServeAddress is the default address the HTTP server listens on. The
+// requests are not authenticated, so only the local clients can connect by
+// default.
+const defaultServeAddress = "127.0.0.1:8080"
+
+// serveShutdownTimeout is the duration the requests in progress are waited
+// for once the command is interrupted.
+const serveShutdownTimeout = 5 * time.Second
+
+func NewServeCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "serve",
+		HelpName:           "serve",
+		Usage:              "serve objects under a prefix over HTTP, read-only",
+		CustomHelpTemplate: serveHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Value: defaultServeAddress,
+				Usage: "address the HTTP server listens on",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateServeCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return Serve{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				listen:      c.String("listen"),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Serve holds serve operation flags and states.
+type Serve struct {
+	src         string
+	op          string
+	fullCommand string
+
+	// flags
+	listen string
+
+	storageOpts storage.Options
+}
+
+// Run serves the objects under the source prefix until the context is done.
+func (s Serve) Run(ctx context.Context) error {
+	srcurl, err := url.New(s.src)
+	if err != nil {
+		printError(s.fullCommand, s.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, s.storageOpts)
+	if err != nil {
+		printError(s.fullCommand, s.op, err)
+		return err
+	}
+
+	listener, err := net.Listen("tcp", s.listen)
+	if err != nil {
+		printError(s.fullCommand, s.op, err)
+		return err
+	}
+
+	prefix := srcurl.Path
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	server := &http.Server{
+		Handler: &objectHandler{
+			client: client,
+			bucket: srcurl.Bucket,
+			prefix: prefix,
+			onError: func(err error) {
+				printError(s.fullCommand, s.op, err)
+			},
+		},
+	}
+
+	log.Info(ServeMessage{Source: srcurl, Address: listener.Addr().String()})
+
+	errch := make(chan error, 1)
+	go func() {
+		errch <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errch:
+		printError(s.fullCommand, s.op, err)
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		printError(s.fullCommand, s.op, err)
+		return err
+	}
+	return nil
+}
+
+// ServeMessage is a structure for logging the address the objects are served
+// at.
+type ServeMessage struct {
+	Source  *url.URL `json:"source"`
+	Address string   `json:"address"`
+}
+
+// String returns the string representation of ServeMessage.
+func (m ServeMessage) String() string {
+	return fmt.Sprintf("serving %v at %s", m.Source, m.Address)
+}
+
+// JSON returns the JSON representation of ServeMessage.
+func (m ServeMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+// objectHandler serves the objects under the prefix of the bucket. The paths
+// of the requests are the keys relative to the prefix, the paths ending with
+// a "/" are served as directory indexes.
+type objectHandler struct {
+	client  *storage.S3
+	bucket  string
+	prefix  string
+	onError func(error)
+}
+
+func (h *objectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	rel := strings.TrimPrefix(r.URL.Path, "/")
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == ".." {
+			http.Error(w, "invalid path", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		h.serveIndex(w, r, rel)
+		return
+	}
+	h.serveObject(w, r, rel)
+}
+
+// serveObject serves the object of the given key relative to the prefix,
+// with support for single byte ranges.
+func (h *objectHandler) serveObject(w http.ResponseWriter, r *http.Request, rel string) {
+	ctx := r.Context()
+
+	u, err := url.New(fmt.Sprintf("s3://%s/%s%s", h.bucket, h.prefix, rel), url.WithRaw(true))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	object, err := h.client.Head(ctx, u)
+	if errors.Is(err, storage.ErrGivenObjectNotFound) {
+		// directories are redirected to their indexes.
+		if h.hasObjects(ctx, rel+"/") {
+			http.Redirect(w, r, relativeHref(path.Base(rel)+"/"), http.StatusMovedPermanently)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+
+	etag := strconv.Quote(object.Etag)
+	header := w.Header()
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("ETag", etag)
+	header.Set("Content-Type", objectContentType(object))
+	if object.ModTime != nil {
+		header.Set("Last-Modified", object.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" && (match == etag || match == "*") {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	offset, length := int64(0), object.Size
+	status := http.StatusOK
+	if byteRange := r.Header.Get("Range"); byteRange != "" {
+		start, n, ok, err := parseHTTPRange(byteRange, object.Size)
+		if err != nil {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", object.Size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		// the unsupported ranges are ignored, and the whole object is
+		// served.
+		if ok {
+			offset, length = start, n
+			status = http.StatusPartialContent
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+n-1, object.Size))
+		}
+	}
+
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+
+	body, err := h.client.ReadRange(ctx, u, offset, length)
+	if err != nil {
+		header.Del("Content-Length")
+		header.Del("Content-Range")
+		h.fail(w, err)
+		return
+	}
+	defer body.Close()
+
+	w.WriteHeader(status)
+	// the response can't be changed once it is started, the clients see the
+	// truncated bodies of the failed reads.
+	if _, err := io.Copy(w, body); err != nil && ctx.Err() == nil {
+		h.onError(err)
+	}
+}
+
+// serveIndex serves the objects and the directories under the given
+// directory relative to the prefix.
+func (h *objectHandler) serveIndex(w http.ResponseWriter, r *http.Request, dir string) {
+	ctx := r.Context()
+
+	// the keys are literal, and only the objects and the common prefixes
+	// right under the directory are listed.
+	u, err := url.New(fmt.Sprintf("s3://%s/%s%s", h.bucket, h.prefix, dir), url.WithRaw(true))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var entries []indexEntry
+	for object := range h.client.List(ctx, u, false) {
+		if errors.Is(object.Err, storage.ErrNoObjectFound) {
+			continue
+		}
+		if err := object.Err; err != nil {
+			h.fail(w, err)
+			return
+		}
+
+		name := object.URL.Relative()
+		entry := indexEntry{Name: name, Href: relativeHref(name), Dir: object.Type.IsDir()}
+		if !entry.Dir {
+			entry.Size = object.Size
+			if object.ModTime != nil {
+				entry.ModTime = object.ModTime.UTC().Format(dateFormat)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	// the root is served even if it is empty.
+	if len(entries) == 0 && dir != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	title := "/" + dir
+	if err := indexTemplate.Execute(w, struct {
+		Title   string
+		Entries []indexEntry
+	}{title, entries}); err != nil && ctx.Err() == nil {
+		h.onError(err)
+	}
+}
+
+// hasObjects reports whether there is any object under the given directory
+// relative to the prefix.
+func (h *objectHandler) hasObjects(ctx context.Context, dir string) bool {
+	u, err := url.New(fmt.Sprintf("s3://%s/%s%s", h.bucket, h.prefix, dir), url.WithRaw(true))
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := false
+	for object := range h.client.List(ctx, u, false) {
+		if object.Err == nil {
+			found = true
+			cancel()
+		}
+	}
+	return found
+}
+
+// fail responds with a bad gateway error, and prints the error.
+func (h *objectHandler) fail(w http.ResponseWriter, err error) {
+	h.onError(err)
+	http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+}
+
+// indexEntry is an object or a directory listed in a directory index.
+type indexEntry struct {
+	Name    string
+	Href    string
+	Dir     bool
+	Size    int64
+	ModTime string
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Title}}</title></head>
+<body>
+<h1>Index of {{.Title}}</h1>
+<pre>
+{{- range .Entries}}
+<a href="{{.Href}}">{{.Name}}</a>{{if not .Dir}}  {{.ModTime}}  {{.Size}}{{end}}
+{{- end}}
+</pre>
+</body>
+</html>
+`))
+
+// relativeHref returns the link of the given name relative to the directory,
+// with the characters which have special meanings in URLs escaped.
+func relativeHref(name string) string {
+	href := (&neturl.URL{Path: name}).String()
+	// the names looking like schemes are prefixed by "./", which is not
+	// needed for the names without colons.
+	if !strings.Contains(name, ":") {
+		href = strings.TrimPrefix(href, "./")
+	}
+	return href
+}
+
+// objectContentType returns the content type of the object, or guesses it
+// from the extension of the key if the object has none.
+func objectContentType(object *storage.Object) string {
+	if object.Metadata != nil && object.Metadata.ContentType != "" {
+		return object.Metadata.ContentType
+	}
+	if contentType := mime.TypeByExtension(path.Ext(object.URL.Path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// parseHTTPRange parses the Range header of a request for an object of the
+// given size, and returns the offset and the length of the range. Only single
+// byte ranges are supported, ok is false for the other ranges, which are
+// ignored. An error is returned if the range can't be satisfied.
+func parseHTTPRange(value string, size int64) (offset, length int64, ok bool, err error) {
+	if !strings.HasPrefix(value, byteRangePrefix) {
+		return 0, 0, false, nil
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(value, byteRangePrefix))
+	if strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false, nil
+	}
+	first, last := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+	// the last bytes of the object, e.g. bytes=-500.
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil {
+			return 0, 0, false, nil
+		}
+		if n <= 0 || size == 0 {
+			return 0, 0, false, fmt.Errorf("range %q is not satisfiable", value)
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, true, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, nil
+	}
+	if start >= size {
+		return 0, 0, false, fmt.Errorf("range %q is not satisfiable", value)
+	}
+
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end - start + 1, true, nil
+}
+
+func validateServeCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be a bucket or a prefix")
+	}
+	if srcurl.IsWildcard() {
+		return fmt.Errorf("source can not contain glob characters")
+	}
+
+	if c.String("listen") == "" {
+		return fmt.Errorf("expected an address with --listen")
+	}
+	return nil
+}
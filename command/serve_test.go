@@ -0,0 +1,70 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestParseHTTPRange(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		value          string
+		size           int64
+		expectedOffset int64
+		expectedLength int64
+		expectedOK     bool
+		wantErr        bool
+	}{
+		{value: "bytes=0-99", size: 1000, expectedOffset: 0, expectedLength: 100, expectedOK: true},
+		{value: "bytes=900-", size: 1000, expectedOffset: 900, expectedLength: 100, expectedOK: true},
+		{value: "bytes=-100", size: 1000, expectedOffset: 900, expectedLength: 100, expectedOK: true},
+		{value: "bytes=-2000", size: 1000, expectedOffset: 0, expectedLength: 1000, expectedOK: true},
+		{value: "bytes=900-2000", size: 1000, expectedOffset: 900, expectedLength: 100, expectedOK: true},
+		{value: "bytes=1000-", size: 1000, wantErr: true},
+		{value: "bytes=-0", size: 1000, wantErr: true},
+		{value: "bytes=0-99,200-299", size: 1000},
+		{value: "bytes=99-0", size: 1000},
+		{value: "items=0-99", size: 1000},
+	}
+	for _, tc := range testcases {
+		offset, length, ok, err := parseHTTPRange(tc.value, tc.size)
+		if tc.wantErr {
+			assert.Error(t, err, tc.value)
+			continue
+		}
+		assert.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expectedOK, ok, tc.value)
+		assert.Equal(t, tc.expectedOffset, offset, tc.value)
+		assert.Equal(t, tc.expectedLength, length, tc.value)
+	}
+}
+
+func TestRelativeHref(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "file.txt", relativeHref("file.txt"))
+	assert.Equal(t, "dir/", relativeHref("dir/"))
+	assert.Equal(t, "a%20b%3F%23.txt", relativeHref("a b?#.txt"))
+	assert.Equal(t, "./a:b.txt", relativeHref("a:b.txt"))
+}
+
+func TestObjectContentType(t *testing.T) {
+	t.Parallel()
+
+	object := func(key string, metadata *storage.ObjectMetadata) *storage.Object {
+		u, err := url.New("s3://bucket/" + key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &storage.Object{URL: u, Metadata: metadata}
+	}
+
+	assert.Equal(t, "application/x-custom", objectContentType(object("a.json", &storage.ObjectMetadata{ContentType: "application/x-custom"})))
+	assert.Equal(t, "application/json", objectContentType(object("a.json", &storage.ObjectMetadata{})))
+	assert.Equal(t, "application/octet-stream", objectContentType(object("a.unknownext", nil)))
+}
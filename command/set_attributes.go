@@ -0,0 +1,410 @@
+package command
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	storageurl "github.com/peak/s5cmd/storage/url"
+)
+
+var setAttributesHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] manifest mapping
+
+	The manifest is an S3 Inventory or an S3 Batch Operations CSV manifest,
+	optionally gzip compressed, whose first two columns are the bucket and
+	the URL encoded key of the objects. The mapping is a CSV file whose first
+	column is "key" and whose other columns are either "tag:<name>" or one of
+	the headers "content-type", "cache-control", "content-disposition",
+	"content-language" and "expires". The attributes of the mapping are
+	applied to the objects of the manifest with the same key, empty cells
+	are left as is.
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Tag the objects of an inventory report with the tags of a mapping file
+		 > s5cmd {{.HelpName}} inventory.csv.gz tags.csv
+
+	2. Replace the tags of the objects instead of merging them with the existing tags
+		 > s5cmd {{.HelpName}} --replace-tags inventory.csv.gz tags.csv
+
+	3. Print the objects to be updated without updating them
+		 > s5cmd --dry-run {{.HelpName}} manifest.csv mapping.csv
+`
+
+func NewSetAttributesCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "set-attributes",
+		HelpName:           "set-attributes",
+		Usage:              "set tags and headers of the objects of a manifest from a mapping file",
+		CustomHelpTemplate: setAttributesHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "replace-tags",
+				Usage: "replace the tags of the objects instead of merging them with the existing tags",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateSetAttributesCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return SetAttributes{
+				manifest:    c.Args().Get(0),
+				mapping:     c.Args().Get(1),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+
+				replaceTags: c.Bool("replace-tags"),
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// SetAttributes holds set-attributes operation flags and states.
+type SetAttributes struct {
+	manifest    string
+	mapping     string
+	op          string
+	fullCommand string
+
+	replaceTags bool
+	storageOpts storage.Options
+}
+
+// attributeUpdate holds the attributes to be set on an object.
+type attributeUpdate struct {
+	tags    map[string]string
+	headers storage.Metadata
+}
+
+// Run joins the objects of the manifest with the attributes of the mapping
+// file on their keys and sets the attributes of the joined objects.
+func (s SetAttributes) Run(ctx context.Context) error {
+	updates, err := s.readMapping()
+	if err != nil {
+		printError(s.fullCommand, s.op, err)
+		return err
+	}
+
+	manifest, err := openCSV(s.manifest)
+	if err != nil {
+		printError(s.fullCommand, s.op, err)
+		return err
+	}
+	defer manifest.Close()
+
+	waiter := parallel.NewWaiter()
+
+	var (
+		merror  error
+		errDone = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			printError(s.fullCommand, s.op, err)
+			merror = multierror.Append(merror, err)
+		}
+	}()
+
+	reader := csv.NewReader(manifest)
+	reader.FieldsPerRecord = -1
+
+	var readError error
+	for row := 1; ; row++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readError = err
+			printError(s.fullCommand, s.op, err)
+			break
+		}
+
+		if row == 1 && isManifestHeader(record) {
+			continue
+		}
+
+		u, err := parseManifestRecord(record)
+		if err != nil {
+			readError = fmt.Errorf("manifest row %d: %w", row, err)
+			printError(s.fullCommand, s.op, readError)
+			break
+		}
+
+		update, ok := updates[u.Path]
+		if !ok {
+			continue
+		}
+
+		task := s.prepareTask(ctx, u, update)
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	if readError != nil {
+		merror = multierror.Append(merror, readError)
+	}
+	return merror
+}
+
+func (s SetAttributes) prepareTask(ctx context.Context, u *storageurl.URL, update attributeUpdate) func() error {
+	return func() error {
+		err := s.setAttributes(ctx, u, update)
+		if err != nil {
+			return &errorpkg.Error{
+				Op:  s.op,
+				Src: u,
+				Err: err,
+			}
+		}
+
+		log.Info(log.InfoMessage{
+			Operation: s.op,
+			Source:    u,
+		})
+		return nil
+	}
+}
+
+func (s SetAttributes) setAttributes(ctx context.Context, u *storageurl.URL, update attributeUpdate) error {
+	client, err := storage.NewRemoteClient(ctx, u, s.storageOpts)
+	if err != nil {
+		return err
+	}
+
+	// headers are set first since copying the object onto itself keeps its
+	// tags, but setting the tags doesn't keep the headers given in a copy.
+	if len(update.headers) > 0 {
+		if err := client.SetHeaders(ctx, u, update.headers); err != nil {
+			return err
+		}
+	}
+
+	if len(update.tags) == 0 {
+		return nil
+	}
+
+	tags := update.tags
+	if !s.replaceTags {
+		existing, err := client.Tags(ctx, u)
+		if err != nil {
+			return err
+		}
+
+		tags = make(map[string]string, len(existing)+len(update.tags))
+		for key, value := range existing {
+			tags[key] = value
+		}
+		for key, value := range update.tags {
+			tags[key] = value
+		}
+	}
+	return client.SetTags(ctx, u, tags)
+}
+
+func (s SetAttributes) readMapping() (map[string]attributeUpdate, error) {
+	f, err := openCSV(s.mapping)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readAttributeMapping(f)
+}
+
+// readAttributeMapping reads the attributes of the objects from a CSV file
+// whose header is "key" followed by "tag:<name>" and header columns.
+func readAttributeMapping(r io.Reader) (map[string]attributeUpdate, error) {
+	reader := csv.NewReader(r)
+
+	columns, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("mapping file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(strings.ToLower(columns[0])) != "key" {
+		return nil, fmt.Errorf(`first column of the mapping file must be "key"`)
+	}
+	for i, column := range columns[1:] {
+		column = strings.TrimSpace(column)
+		if !strings.HasPrefix(column, "tag:") && !isMappingHeader(column) {
+			return nil, fmt.Errorf("unsupported mapping column %q", column)
+		}
+		columns[i+1] = column
+	}
+
+	updates := map[string]attributeUpdate{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key := record[0]
+		if key == "" {
+			return nil, fmt.Errorf("mapping row %d: key is empty", row)
+		}
+		if _, ok := updates[key]; ok {
+			return nil, fmt.Errorf("mapping row %d: duplicate key %q", row, key)
+		}
+
+		update := attributeUpdate{
+			tags:    map[string]string{},
+			headers: storage.NewMetadata(),
+		}
+		for i, value := range record[1:] {
+			if value == "" {
+				continue
+			}
+
+			column := columns[i+1]
+			if name := strings.TrimPrefix(column, "tag:"); name != column {
+				update.tags[name] = value
+				continue
+			}
+
+			if err := setMappingHeader(update.headers, column, value); err != nil {
+				return nil, fmt.Errorf("mapping row %d: %w", row, err)
+			}
+		}
+		updates[key] = update
+	}
+	return updates, nil
+}
+
+func isMappingHeader(column string) bool {
+	switch strings.ToLower(column) {
+	case "content-type", "cache-control", "content-disposition", "content-language", "expires":
+		return true
+	default:
+		return false
+	}
+}
+
+func setMappingHeader(metadata storage.Metadata, column, value string) error {
+	switch strings.ToLower(column) {
+	case "content-type":
+		metadata.SetContentType(value)
+	case "cache-control":
+		metadata.SetCacheControl(value)
+	case "content-disposition":
+		metadata.SetContentDisposition(value)
+	case "content-language":
+		metadata.SetContentLanguage(value)
+	case "expires":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("expires %q must be in RFC3339 format", value)
+		}
+		metadata.SetExpires(value)
+	}
+	return nil
+}
+
+// isManifestHeader checks if the record is the optional header row of a
+// manifest.
+func isManifestHeader(record []string) bool {
+	return len(record) >= 2 &&
+		strings.EqualFold(strings.TrimSpace(record[0]), "bucket") &&
+		strings.EqualFold(strings.TrimSpace(record[1]), "key")
+}
+
+// parseManifestRecord returns the URL of the object of an S3 Inventory or an
+// S3 Batch Operations manifest record. The keys of the records are URL
+// encoded, the columns after the key are ignored.
+func parseManifestRecord(record []string) (*storageurl.URL, error) {
+	if len(record) < 2 || record[0] == "" || record[1] == "" {
+		return nil, fmt.Errorf("expected bucket and key columns")
+	}
+
+	key, err := url.QueryUnescape(record[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return storageurl.New(fmt.Sprintf("s3://%v/%v", record[0], key), storageurl.WithRaw(true))
+}
+
+// openCSV opens the CSV file at the given path, decompressing it if its
+// name has the gzip suffix.
+func openCSV(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, gzipSuffix) {
+		return f, nil
+	}
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return gzipFile{Reader: gr, file: f}, nil
+}
+
+// gzipFile closes both the gzip reader and the underlying file.
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+func validateSetAttributesCommand(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("expected manifest and mapping arguments")
+	}
+
+	for _, path := range c.Args().Slice() {
+		if strings.HasPrefix(path, "s3://") {
+			return fmt.Errorf("manifest and mapping must be local files")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestReadAttributeMapping(t *testing.T) {
+	t.Parallel()
+
+	mapping := strings.Join([]string{
+		"key,tag:team,Content-Type,expires",
+		"data/a.csv,analytics,text/csv,",
+		"data/b.csv,,,2030-01-01T00:00:00Z",
+	}, "\n")
+
+	updates, err := readAttributeMapping(strings.NewReader(mapping))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]attributeUpdate{
+		"data/a.csv": {
+			tags:    map[string]string{"team": "analytics"},
+			headers: storage.NewMetadata().SetContentType("text/csv"),
+		},
+		"data/b.csv": {
+			tags:    map[string]string{},
+			headers: storage.NewMetadata().SetExpires("2030-01-01T00:00:00Z"),
+		},
+	}, updates)
+}
+
+func TestReadAttributeMappingFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name        string
+		mapping     string
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			mapping:     "",
+			expectedErr: "mapping file is empty",
+		},
+		{
+			name:        "no key column",
+			mapping:     "tag:team\nanalytics",
+			expectedErr: `first column of the mapping file must be "key"`,
+		},
+		{
+			name:        "unsupported column",
+			mapping:     "key,acl\na.csv,private",
+			expectedErr: `unsupported mapping column "acl"`,
+		},
+		{
+			name:        "duplicate key",
+			mapping:     "key,tag:team\na.csv,analytics\na.csv,finance",
+			expectedErr: `mapping row 3: duplicate key "a.csv"`,
+		},
+		{
+			name:        "invalid expires",
+			mapping:     "key,expires\na.csv,tomorrow",
+			expectedErr: `mapping row 2: expires "tomorrow" must be in RFC3339 format`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := readAttributeMapping(strings.NewReader(tc.mapping))
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestParseManifestRecord(t *testing.T) {
+	t.Parallel()
+
+	// keys of the inventory reports are URL encoded.
+	u, err := parseManifestRecord([]string{"bucket", "data/report+2024%2A.csv", "version-id"})
+	assert.NoError(t, err)
+	assert.Equal(t, "bucket", u.Bucket)
+	assert.Equal(t, "data/report 2024*.csv", u.Path)
+
+	_, err = parseManifestRecord([]string{"bucket"})
+	assert.EqualError(t, err, "expected bucket and key columns")
+
+	assert.True(t, isManifestHeader([]string{"Bucket", "Key", "VersionId"}))
+	assert.False(t, isManifestHeader([]string{"bucket", "key.csv"}))
+}
@@ -0,0 +1,132 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/strutil"
+)
+
+const (
+	defaultSimulateThroughput = "100MB"
+	defaultSimulateLatency    = 50 * time.Millisecond
+)
+
+// Prices of the requests per 1000 requests in USD, as listed for S3 Standard
+// in us-east-1.
+const (
+	writeRequestPrice = 0.005
+	readRequestPrice  = 0.0004
+)
+
+// requestPrice returns the price of 1000 requests of the given API. PUT,
+// COPY, POST and LIST requests are priced as writes, DELETE and CANCEL
+// requests are free and the others are priced as reads.
+func requestPrice(api string) float64 {
+	for _, prefix := range []string{"Delete", "Abort"} {
+		if strings.HasPrefix(api, prefix) {
+			return 0
+		}
+	}
+	for _, prefix := range []string{"Put", "Copy", "Create", "Complete", "Upload", "List", "Restore"} {
+		if strings.HasPrefix(api, prefix) {
+			return writeRequestPrice
+		}
+	}
+	return readRequestPrice
+}
+
+// SimulationMessage is the estimate of the requests, the duration and the
+// cost of the commands run with --simulate.
+type SimulationMessage struct {
+	Requests      map[string]int64 `json:"requests"`
+	TotalRequests int64            `json:"total_requests"`
+	Bytes         int64            `json:"bytes"`
+	Workers       int              `json:"workers"`
+	Duration      time.Duration    `json:"-"`
+	Cost          float64          `json:"estimated_cost"`
+
+	// DurationSeconds is the estimated duration in JSON output.
+	DurationSeconds float64 `json:"estimated_duration_seconds"`
+}
+
+// newSimulationMessage estimates the duration and the cost of the simulated
+// requests. Requests are sent by the given number of workers and each of
+// them takes the given latency, while the bytes are transferred at the given
+// throughput per second. The duration is bounded by whichever is slower.
+func newSimulationMessage(
+	totals storage.SimulationTotals,
+	workers int,
+	latency time.Duration,
+	throughput int64,
+) SimulationMessage {
+	msg := SimulationMessage{
+		Requests: totals.Requests,
+		Bytes:    totals.Bytes,
+		Workers:  workers,
+	}
+
+	for api, n := range totals.Requests {
+		msg.TotalRequests += n
+		msg.Cost += float64(n) * requestPrice(api) / 1000
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	rounds := (msg.TotalRequests + int64(workers) - 1) / int64(workers)
+	requestTime := time.Duration(rounds) * latency
+
+	var transferTime time.Duration
+	if throughput > 0 {
+		transferTime = time.Duration(float64(totals.Bytes) / float64(throughput) * float64(time.Second))
+	}
+
+	msg.Duration = requestTime
+	if transferTime > msg.Duration {
+		msg.Duration = transferTime
+	}
+	msg.Duration = msg.Duration.Round(time.Second)
+	msg.DurationSeconds = msg.Duration.Seconds()
+	msg.Cost = math.Round(msg.Cost*1e6) / 1e6
+
+	return msg
+}
+
+// String returns the string representation of SimulationMessage.
+func (s SimulationMessage) String() string {
+	apis := make([]string, 0, len(s.Requests))
+	for api := range s.Requests {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 8, 1, '\t', tabwriter.AlignRight)
+
+	fmt.Fprintf(w, "\n%s\t%s\t%s\t\n", "API", "Requests", "Cost")
+	for _, api := range apis {
+		n := s.Requests[api]
+		fmt.Fprintf(w, "%s\t%d\t$%.6f\t\n", api, n, float64(n)*requestPrice(api)/1000)
+	}
+	fmt.Fprintf(w, "%s\t%d\t$%.6f\t\n", "Total", s.TotalRequests, s.Cost)
+	w.Flush()
+
+	fmt.Fprintf(
+		&buf,
+		"estimated duration %v with %d workers, transferring %s bytes\n",
+		s.Duration, s.Workers, strutil.HumanizeBytes(s.Bytes),
+	)
+	return buf.String()
+}
+
+// JSON returns the JSON representation of SimulationMessage.
+func (s SimulationMessage) JSON() string {
+	return strutil.JSON(s)
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestNewSimulationMessage(t *testing.T) {
+	t.Parallel()
+
+	totals := storage.SimulationTotals{
+		Requests: map[string]int64{
+			"ListObjectsV2": 1000,
+			"GetObject":     10000,
+			"DeleteObjects": 10,
+		},
+		Bytes: 100 * 1024 * 1024,
+	}
+
+	tests := []struct {
+		name       string
+		workers    int
+		throughput int64
+		want       time.Duration
+	}{
+		{
+			name:       "bounded by requests",
+			workers:    10,
+			throughput: 100 * 1024 * 1024,
+			want:       55 * time.Second,
+		},
+		{
+			name:       "bounded by throughput",
+			workers:    1000,
+			throughput: 1024 * 1024,
+			want:       100 * time.Second,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg := newSimulationMessage(totals, tc.workers, 50*time.Millisecond, tc.throughput)
+			assert.Equal(t, int64(11010), msg.TotalRequests)
+			assert.Equal(t, 0.009, msg.Cost)
+			assert.Equal(t, tc.want, msg.Duration)
+		})
+	}
+}
+
+func TestRequestPrice(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, writeRequestPrice, requestPrice("UploadPart"))
+	assert.Equal(t, writeRequestPrice, requestPrice("ListObjectVersions"))
+	assert.Equal(t, readRequestPrice, requestPrice("HeadObject"))
+	assert.Equal(t, 0.0, requestPrice("DeleteObjects"))
+	assert.Equal(t, 0.0, requestPrice("AbortMultipartUpload"))
+}
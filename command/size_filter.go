@@ -0,0 +1,87 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+// errObjectSizeMismatch indicates the size of an object is out of the range
+// given by --min-size and --max-size flags.
+var errObjectSizeMismatch = errors.New("object size does not match")
+
+// sizeFilter filters objects by their sizes. Negative sizes are unset.
+type sizeFilter struct {
+	// min is the minimum size of the objects, inclusive.
+	min int64
+	// max is the maximum size of the objects, inclusive.
+	max int64
+}
+
+// parseSizeFilter parses --min-size and --max-size flags of the command.
+func parseSizeFilter(c *cli.Context) (sizeFilter, error) {
+	filter := sizeFilter{min: -1, max: -1}
+
+	if value := c.String("min-size"); value != "" {
+		size, err := parseByteSize(value)
+		if err != nil {
+			return sizeFilter{}, fmt.Errorf("min-size: %v", err)
+		}
+		filter.min = size
+	}
+
+	if value := c.String("max-size"); value != "" {
+		size, err := parseByteSize(value)
+		if err != nil {
+			return sizeFilter{}, fmt.Errorf("max-size: %v", err)
+		}
+		filter.max = size
+	}
+
+	if filter.min >= 0 && filter.max >= 0 && filter.min > filter.max {
+		return sizeFilter{}, fmt.Errorf("max-size must be greater than or equal to min-size")
+	}
+	return filter, nil
+}
+
+// isSet reports whether any size is given.
+func (f sizeFilter) isSet() bool {
+	return f.min >= 0 || f.max >= 0
+}
+
+// match reports whether the size of the object is in the range of the
+// filter. The size of the object is fetched if it is not listed, e.g. if the
+// object is given without wildcards.
+func (f sizeFilter) match(ctx context.Context, client storage.Storage, object *storage.Object) (bool, error) {
+	if !f.isSet() {
+		return true, nil
+	}
+
+	// the listed objects have their modification times along with their
+	// sizes.
+	size := object.Size
+	if object.ModTime == nil {
+		obj, err := client.Stat(ctx, object.URL)
+		if err != nil {
+			return false, err
+		}
+		size = obj.Size
+	}
+
+	return f.matchSize(size), nil
+}
+
+// matchSize reports whether the given size is in the range of the filter.
+func (f sizeFilter) matchSize(size int64) bool {
+	if f.min >= 0 && size < f.min {
+		return false
+	}
+	if f.max >= 0 && size > f.max {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeFilterMatchSize(t *testing.T) {
+	t.Parallel()
+
+	filter := sizeFilter{min: 1024, max: 10 * 1024}
+
+	assert.True(t, filter.matchSize(1024))
+	assert.True(t, filter.matchSize(4096))
+	assert.True(t, filter.matchSize(10*1024))
+	assert.False(t, filter.matchSize(1023))
+	assert.False(t, filter.matchSize(10*1024+1))
+
+	// only larger objects are matched.
+	filter = sizeFilter{min: 1024, max: -1}
+	assert.True(t, filter.matchSize(1<<40))
+	assert.False(t, filter.matchSize(0))
+
+	// only empty objects are matched.
+	filter = sizeFilter{min: -1, max: 0}
+	assert.True(t, filter.matchSize(0))
+	assert.False(t, filter.matchSize(1))
+
+	assert.False(t, sizeFilter{min: -1, max: -1}.isSet())
+	assert.True(t, filter.isSet())
+}
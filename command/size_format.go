@@ -0,0 +1,109 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/peak/s5cmd/strutil"
+)
+
+// Units of the sizes of the objects in the outputs of ls and du.
+const (
+	sizeUnitRaw = "raw"
+	sizeUnitIEC = "iec"
+	sizeUnitSI  = "si"
+)
+
+const defaultSizePrecision = 1
+
+// humanizeValue is the value of the humanize flag. It is a boolean flag for
+// the flag parser, so that --humanize and -H without a value still select
+// the IEC units while --humanize=si selects the others.
+type humanizeValue struct {
+	unit string
+}
+
+// Set sets the unit of the sizes. The boolean values are accepted for the
+// flags given without a value. The units are validated by the commands.
+func (h *humanizeValue) Set(value string) error {
+	switch value {
+	case "true":
+		h.unit = sizeUnitIEC
+	case "false":
+		h.unit = sizeUnitRaw
+	default:
+		h.unit = value
+	}
+	return nil
+}
+
+// String returns the unit of the sizes.
+func (h *humanizeValue) String() string {
+	if h == nil || h.unit == "" {
+		return sizeUnitRaw
+	}
+	return h.unit
+}
+
+// Get returns the unit of the sizes.
+func (h *humanizeValue) Get() interface{} {
+	return h.String()
+}
+
+// IsBoolFlag lets the flag be given without a value.
+func (h *humanizeValue) IsBoolFlag() bool {
+	return true
+}
+
+// sizeFormat is the format of the sizes of the objects in the outputs of ls
+// and du.
+type sizeFormat struct {
+	// unit is one of raw, iec and si. Raw sizes are the exact number of
+	// bytes.
+	unit string
+
+	// precision is the number of the decimal places of human-readable sizes.
+	precision int
+}
+
+// newSizeFormat returns the size format of the given humanize flag value and
+// precision. The si flag selects the SI units for compatibility.
+func newSizeFormat(unit string, si bool, precision int) sizeFormat {
+	if si {
+		unit = sizeUnitSI
+	}
+	if unit == "" {
+		unit = sizeUnitRaw
+	}
+	return sizeFormat{unit: unit, precision: precision}
+}
+
+// validateSizeFormat validates the humanize flag value and the precision of
+// the sizes.
+func validateSizeFormat(unit string, precision int) error {
+	switch unit {
+	case sizeUnitRaw, sizeUnitIEC, sizeUnitSI:
+	default:
+		return fmt.Errorf("humanize %q must be one of %v, %v or %v", unit, sizeUnitIEC, sizeUnitSI, sizeUnitRaw)
+	}
+	if precision < 0 {
+		return fmt.Errorf("precision must not be negative")
+	}
+	return nil
+}
+
+// humanized reports whether the sizes are human-readable.
+func (f sizeFormat) humanized() bool {
+	return f.unit == sizeUnitIEC || f.unit == sizeUnitSI
+}
+
+// format returns the given size in the units of the format.
+func (f sizeFormat) format(size int64) string {
+	switch f.unit {
+	case sizeUnitIEC:
+		return strutil.HumanizeBytesPrecision(size, f.precision)
+	case sizeUnitSI:
+		return strutil.HumanizeBytesSIPrecision(size, f.precision)
+	}
+	return strconv.FormatInt(size, 10)
+}
@@ -0,0 +1,121 @@
+package command
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/strutil"
+)
+
+// defaultSizeProgressInterval is the default duration between the progress
+// reports of du.
+const defaultSizeProgressInterval = 10 * time.Second
+
+// sizeProgress counts the objects and the listed prefixes of du, to report
+// its progress and the partial totals if it is interrupted.
+type sizeProgress struct {
+	// the counters are first to be 64-bit aligned for atomic operations.
+	size         int64
+	count        int64
+	prefixes     int64
+	prefixesDone int64
+
+	source string
+	sizes  sizeFormat
+}
+
+func (p *sizeProgress) addObject(obj *storage.Object) {
+	atomic.AddInt64(&p.size, obj.Size)
+	atomic.AddInt64(&p.count, 1)
+}
+
+func (p *sizeProgress) addPrefix() {
+	atomic.AddInt64(&p.prefixes, 1)
+}
+
+func (p *sizeProgress) prefixDone() {
+	atomic.AddInt64(&p.prefixesDone, 1)
+}
+
+// message returns the progress so far.
+func (p *sizeProgress) message() SizeProgressMessage {
+	return SizeProgressMessage{
+		Source:       p.source,
+		Count:        atomic.LoadInt64(&p.count),
+		Size:         atomic.LoadInt64(&p.size),
+		Prefixes:     atomic.LoadInt64(&p.prefixes),
+		PrefixesDone: atomic.LoadInt64(&p.prefixesDone),
+		sizes:        p.sizes,
+	}
+}
+
+// report prints the progress to the diagnostics output at the given interval
+// until the returned function is called, which prints the final progress.
+func (p *sizeProgress) report(interval time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				log.Stat(p.message())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		log.Stat(p.message())
+	}
+}
+
+// interrupted returns the warning of an interrupted du.
+func (p *sizeProgress) interrupted() string {
+	return fmt.Sprintf(
+		"interrupted: the totals are partial, %d of %d prefixes are listed",
+		atomic.LoadInt64(&p.prefixesDone),
+		atomic.LoadInt64(&p.prefixes),
+	)
+}
+
+// SizeProgressMessage is the structure for logging the progress of du.
+type SizeProgressMessage struct {
+	Source       string `json:"source"`
+	Count        int64  `json:"count"`
+	Size         int64  `json:"size"`
+	Prefixes     int64  `json:"prefixes"`
+	PrefixesDone int64  `json:"prefixes_done"`
+
+	sizes sizeFormat
+}
+
+// String returns the string representation of SizeProgressMessage.
+func (m SizeProgressMessage) String() string {
+	count := fmt.Sprintf("%d", m.Count)
+	if m.sizes.humanized() {
+		count = strutil.HumanizeCount(m.Count)
+	}
+	return fmt.Sprintf(
+		"progress: %s bytes in %s objects so far, %d of %d prefixes listed: %s",
+		m.sizes.format(m.Size),
+		count,
+		m.PrefixesDone,
+		m.Prefixes,
+		m.Source,
+	)
+}
+
+// JSON returns the JSON representation of SizeProgressMessage.
+func (m SizeProgressMessage) JSON() string {
+	return strutil.JSON(m)
+}
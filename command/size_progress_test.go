@@ -0,0 +1,90 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestSizeTotalsMerge(t *testing.T) {
+	t.Parallel()
+
+	first, second := newSizeTotals(), newSizeTotals()
+	first.addObject(&storage.Object{Size: 10, StorageClass: "STANDARD"})
+	second.addObject(&storage.Object{Size: 20, StorageClass: "STANDARD"})
+	second.addObject(&storage.Object{Size: 30, StorageClass: "GLACIER"})
+
+	totals := newSizeTotals()
+	totals.merge(first)
+	totals.merge(second)
+
+	assert.Equal(t, sizeAndCount{size: 60, count: 3}, totals.total)
+	assert.Equal(t, map[string]sizeAndCount{
+		"STANDARD": {size: 30, count: 2},
+		"GLACIER":  {size: 30, count: 1},
+	}, totals.byClass)
+}
+
+func TestSizeTotalsMessagesByPrefix(t *testing.T) {
+	t.Parallel()
+
+	srcurl, err := url.New("s3://bucket/logs/*")
+	assert.NoError(t, err)
+
+	totals := newSizeTotals()
+	for key, size := range map[string]int64{
+		"logs/a.txt":         5,
+		"logs/2020/01/b.txt": 10,
+		"logs/2020/02/c.txt": 20,
+		"logs/2021/d.txt":    30,
+		"logs/2021/01/e.txt": 10,
+	} {
+		object := &storage.Object{URL: &url.URL{Scheme: "s3", Bucket: "bucket", Path: key}, Size: size}
+		totals.addObject(object)
+		totals.addPrefix(objectPrefix(object.URL, srcurl.Prefix, 1), object)
+	}
+
+	var lines []string
+	for _, msg := range totals.messagesByPrefix(0, sizeFormat{}) {
+		lines = append(lines, msg.String())
+	}
+	assert.Equal(t, []string{
+		"40 bytes in 2 objects: s3://bucket/logs/2021/",
+		"30 bytes in 2 objects: s3://bucket/logs/2020/",
+		"5 bytes in 1 objects: s3://bucket/logs/",
+	}, lines)
+
+	msgs := totals.messagesByPrefix(1, sizeFormat{})
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, "s3://bucket/logs/2021/", msgs[0].Source)
+}
+
+func TestObjectPrefix(t *testing.T) {
+	t.Parallel()
+
+	u := &url.URL{Scheme: "s3", Bucket: "bucket", Path: "logs/2020/01/b.txt"}
+	assert.Equal(t, "s3://bucket/logs/2020/01/", objectPrefix(u, "logs/2020/01/", 2))
+	assert.Equal(t, "s3://bucket/logs/2020/", objectPrefix(u, "logs/", 1))
+	assert.Equal(t, "s3://bucket/logs/2020/01/", objectPrefix(u, "logs/", 2))
+	assert.Equal(t, "s3://bucket/logs/2020/01/", objectPrefix(u, "logs/", 3))
+	assert.Equal(t, "s3://bucket/logs/", objectPrefix(u, "", 1))
+}
+
+func TestSizeProgress(t *testing.T) {
+	t.Parallel()
+
+	progress := &sizeProgress{
+		source: "s3://bucket/*",
+		sizes:  newSizeFormat(sizeUnitIEC, false, defaultSizePrecision),
+	}
+	progress.addPrefix()
+	progress.addPrefix()
+	progress.addObject(&storage.Object{Size: 2048})
+	progress.prefixDone()
+
+	assert.Equal(t, "progress: 2.0K bytes in 1 objects so far, 1 of 2 prefixes listed: s3://bucket/*", progress.message().String())
+	assert.Equal(t, "interrupted: the totals are partial, 1 of 2 prefixes are listed", progress.interrupted())
+}
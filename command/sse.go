@@ -0,0 +1,65 @@
+package command
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// sseCustomerKeyEnv is the environment variable to read the customer
+// provided key of SSE-C from, unless --sse-c-key-file is given.
+const sseCustomerKeyEnv = "S5CMD_SSE_C_KEY"
+
+// sseCustomerKeySize is the size of the AES-256 keys in bytes.
+const sseCustomerKeySize = 32
+
+// readSSECustomerKey returns the customer provided key of SSE-C if --sse-c
+// flag is given.
+func readSSECustomerKey(c *cli.Context) (string, error) {
+	if !c.Bool("sse-c") {
+		if c.String("sse-c-key-file") != "" {
+			return "", fmt.Errorf("sse-c-key-file requires sse-c flag")
+		}
+		return "", nil
+	}
+
+	if c.String("sse") != "" {
+		return "", fmt.Errorf("sse-c can not be used with sse")
+	}
+
+	if path := c.String("sse-c-key-file"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return decodeSSECustomerKey(data)
+	}
+
+	if key, ok := os.LookupEnv(sseCustomerKeyEnv); ok {
+		return decodeSSECustomerKey([]byte(key))
+	}
+
+	return "", fmt.Errorf("sse-c requires a key from sse-c-key-file flag or %v environment variable", sseCustomerKeyEnv)
+}
+
+// decodeSSECustomerKey decodes the given raw or base64 encoded AES-256 key.
+func decodeSSECustomerKey(data []byte) (string, error) {
+	if len(data) == sseCustomerKeySize {
+		return string(data), nil
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == sseCustomerKeySize {
+		return string(data), nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil || len(key) != sseCustomerKeySize {
+		return "", fmt.Errorf("sse-c key must be %d bytes, either raw or base64 encoded", sseCustomerKeySize)
+	}
+	return string(key), nil
+}
@@ -0,0 +1,28 @@
+package command
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeSSECustomerKey(t *testing.T) {
+	t.Parallel()
+
+	raw := strings.Repeat("k", sseCustomerKeySize)
+	encoded := base64.StdEncoding.EncodeToString([]byte(raw))
+
+	for _, data := range []string{raw, raw + "\n", encoded, encoded + "\n"} {
+		key, err := decodeSSECustomerKey([]byte(data))
+		assert.NoError(t, err, data)
+		assert.Equal(t, raw, key, data)
+	}
+
+	short := base64.StdEncoding.EncodeToString([]byte("short"))
+	for _, data := range []string{"", "short", short, raw + raw} {
+		_, err := decodeSSECustomerKey([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
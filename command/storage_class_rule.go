@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+// storageClassRule selects the storage class of an object by its size.
+type storageClassRule struct {
+	op           string
+	size         int64
+	storageClass storage.StorageClass
+}
+
+// match reports whether an object of the given size matches the rule.
+func (r storageClassRule) match(size int64) bool {
+	switch r.op {
+	case "<":
+		return size < r.size
+	case "<=":
+		return size <= r.size
+	case ">":
+		return size > r.size
+	default:
+		return size >= r.size
+	}
+}
+
+// parseStorageClassRules parses the comma separated storage class rules, e.g.
+// "<1MB=STANDARD,>=1MB=STANDARD_IA".
+func parseStorageClassRules(value string) ([]storageClassRule, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var rules []storageClassRule
+	for _, rule := range strings.Split(value, ",") {
+		// the operator may contain '=' as well, e.g. ">=1MB=STANDARD_IA".
+		rule = strings.TrimSpace(rule)
+		i := strings.LastIndex(rule, "=")
+		if i == -1 || i == len(rule)-1 {
+			return nil, fmt.Errorf("storage class rule %q must be in <op><size>=<class> format", rule)
+		}
+		condition, class := rule[:i], rule[i+1:]
+
+		var op string
+		for _, candidate := range []string{"<=", ">=", "<", ">"} {
+			if strings.HasPrefix(condition, candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("storage class rule %q must start with one of <, <=, >, >=", rule)
+		}
+
+		size, err := parseByteSize(strings.TrimPrefix(condition, op))
+		if err != nil {
+			return nil, fmt.Errorf("storage class rule %q: %v", rule, err)
+		}
+
+		rules = append(rules, storageClassRule{
+			op:           op,
+			size:         size,
+			storageClass: storage.StorageClass(strings.ToUpper(class)),
+		})
+	}
+	return rules, nil
+}
+
+// byteSizeUnits are the multipliers of the size units, in powers of 1024.
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
+}
+
+// parseByteSize parses sizes like 128KB or 1.5GB, in powers of 1024.
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(s)
+	}
+
+	number, unit := s[:i], strings.TrimSpace(s[i:])
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", unit)
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
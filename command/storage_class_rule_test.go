@@ -0,0 +1,66 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+)
+
+func TestParseStorageClassRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := parseStorageClassRules("<1MB=STANDARD, >=1MB=standard_ia")
+	assert.NoError(t, err)
+
+	c := Copy{
+		storageClass:      "GLACIER",
+		storageClassRules: rules,
+	}
+	assert.Equal(t, storage.StorageClass("STANDARD"), c.objectStorageClass(0))
+	assert.Equal(t, storage.StorageClass("STANDARD"), c.objectStorageClass(1<<20-1))
+	assert.Equal(t, storage.StorageClass("STANDARD_IA"), c.objectStorageClass(1<<20))
+
+	// --storage-class is used if no rule matches.
+	rules, err = parseStorageClassRules(">10GB=DEEP_ARCHIVE")
+	assert.NoError(t, err)
+
+	c.storageClassRules = rules
+	assert.Equal(t, storage.StorageClass("GLACIER"), c.objectStorageClass(10<<30))
+	assert.Equal(t, storage.StorageClass("DEEP_ARCHIVE"), c.objectStorageClass(10<<30+1))
+
+	rules, err = parseStorageClassRules("")
+	assert.NoError(t, err)
+	assert.Nil(t, rules)
+
+	for _, value := range []string{"1MB=STANDARD", "<1MB", "<1MB=", "<1XB=STANDARD", "<=abc=STANDARD"} {
+		_, err := parseStorageClassRules(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]int64{
+		"0":      0,
+		"100":    100,
+		"100B":   100,
+		"128KB":  128 << 10,
+		"128kib": 128 << 10,
+		"1.5M":   3 << 19,
+		"2 GB":   2 << 30,
+		"1TiB":   1 << 40,
+	}
+	for s, expected := range testcases {
+		size, err := parseByteSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, size, s)
+	}
+
+	for _, s := range []string{"", "MB", "-1MB", "1PB", "1.2.3MB"} {
+		_, err := parseByteSize(s)
+		assert.Error(t, err, s)
+	}
+}
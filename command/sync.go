@@ -57,8 +57,23 @@ Examples:
 	09. Perform KMS-SSE of the object(s) at the destination using customer managed Customer Master Key (CMK) key id
 		 > s5cmd {{.HelpName}} --sse aws:kms --sse-kms-key-id <your-kms-key-id> s3://bucket/object s3://target-bucket/prefix/object
 
-	10. Sync all files to S3 bucket but exclude the ones with txt and gz extension
+	10. Sync local folder to S3 and delete the extra objects, bypassing governance-mode object lock retention
+		 > s5cmd {{.HelpName}} --delete --bypass-governance-retention folder/ s3://bucket/
+
+	11. Sync all files to S3 bucket but exclude the ones with txt and gz extension
 		 > s5cmd {{.HelpName}} --exclude "*.txt" --exclude "*.gz" dir/ s3://bucket
+
+	12. Sync a source tree to S3 bucket but skip the files ignored by git
+		 > s5cmd {{.HelpName}} --respect-gitignore dir/ s3://bucket/
+
+	13. Sync only the files modified since the last successful run, recording the watermark to a file
+		 > s5cmd {{.HelpName}} --source-newer-than /var/lib/s5cmd/watermark dir/ s3://bucket/
+
+	14. Sync a folder to S3 bucket, rewriting the extensions of the files in the destination
+		 > s5cmd {{.HelpName}} --rename 's/\.markdown$/.md/' dir/ s3://bucket/
+
+	15. Sync local folder to S3 and delete the extra objects, 100 objects per request and at most 500 objects per second
+		 > s5cmd {{.HelpName}} --delete --delete-batch-size 100 --delete-rate 500 folder/ s3://bucket/
 `
 
 func NewSyncCommandFlags() []cli.Flag {
@@ -67,10 +82,32 @@ func NewSyncCommandFlags() []cli.Flag {
 			Name:  "delete",
 			Usage: "delete objects in destination but not in source",
 		},
+		&cli.BoolFlag{
+			Name:    "interactive",
+			Aliases: []string{"i"},
+			Usage:   "prompt before deleting each object with --delete, answering 'all' deletes the rest without prompting",
+		},
 		&cli.BoolFlag{
 			Name:  "size-only",
 			Usage: "make size of object only criteria to decide whether an object should be synced",
 		},
+		&cli.BoolFlag{
+			Name:  "bypass-governance-retention",
+			Usage: "try to delete objects under governance-mode object lock retention with --delete, requires s3:BypassGovernanceRetention permission",
+		},
+		&cli.Float64Flag{
+			Name:  "delete-rate",
+			Usage: "limit the number of objects deleted per second with --delete",
+		},
+		&cli.IntFlag{
+			Name:  "delete-batch-size",
+			Value: storage.MaxDeleteBatchSize,
+			Usage: "number of objects deleted with a single DeleteObjects request with --delete",
+		},
+		&cli.StringFlag{
+			Name:  "source-newer-than",
+			Usage: "only sync source objects which are new or modified since the run recorded in given file, and record the synced objects and their newest modification time to the file on success",
+		},
 	}
 	sharedFlags := NewSharedFlags()
 	return append(syncFlags, sharedFlags...)
@@ -84,8 +121,11 @@ func NewSyncCommand() *cli.Command {
 		Flags:              NewSyncCommandFlags(),
 		CustomHelpTemplate: syncHelpTemplate,
 		Before: func(c *cli.Context) error {
-			// sync command share same validation method as copy command
-			err := validateCopyCommand(c)
+			err := validateSyncDeleteFlags(c)
+			if err == nil {
+				// sync command share same validation method as copy command
+				err = validateCopyCommand(c)
+			}
 			if err != nil {
 				printError(commandFromContext(c), c.Command.Name, err)
 			}
@@ -99,6 +139,20 @@ func NewSyncCommand() *cli.Command {
 	}
 }
 
+// validateSyncDeleteFlags validates the flags of the rm commands generated
+// for --delete flag.
+func validateSyncDeleteFlags(c *cli.Context) error {
+	if c.Float64("delete-rate") < 0 {
+		return fmt.Errorf("delete-rate must be a positive number")
+	}
+
+	if n := c.Int("delete-batch-size"); c.IsSet("delete-batch-size") && (n < 1 || n > storage.MaxDeleteBatchSize) {
+		return fmt.Errorf("delete-batch-size must be between 1 and %d", storage.MaxDeleteBatchSize)
+	}
+
+	return nil
+}
+
 type ObjectPair struct {
 	src, dst *storage.Object
 }
@@ -111,22 +165,60 @@ type Sync struct {
 	fullCommand string
 
 	// flags
-	delete   bool
-	sizeOnly bool
+	delete          bool
+	sizeOnly        bool
+	sourceNewerThan string
 
 	// s3 options
 	storageOpts storage.Options
+	lister      storage.Lister
+
+	followSymlinks   bool
+	storageClass     storage.StorageClass
+	raw              bool
+	respectGitignore bool
+	renamer          *keyRenamer
 
-	followSymlinks bool
-	storageClass   storage.StorageClass
-	raw            bool
+	// quotaPrefix is the prefix the quotas of the copy commands apply to,
+	// if any quota is set.
+	quotaPrefix *url.URL
+
+	// age filters the source objects by their modification times.
+	age ageFilter
+	// sizeRange filters the source objects by their sizes.
+	sizeRange sizeFilter
 
 	srcRegion string
 	dstRegion string
+
+	// state is read from the source-newer-than file. Source objects synced
+	// by the previous run and not modified after it are not synced.
+	state *syncState
+
+	// destinationNames holds the names of the source objects relative to
+	// the destination if they are renamed with --rename flag.
+	destinationNames map[*storage.Object]string
+
+	// planOutput is where the commands are written to, instead of being
+	// executed, if set.
+	planOutput io.Writer
 }
 
 // NewSync creates Sync from cli.Context
 func NewSync(c *cli.Context) Sync {
+	var renamer *keyRenamer
+	if expr := c.String("rename"); expr != "" {
+		renamer, _ = parseRename(expr)
+	}
+
+	var quotaPrefix *url.URL
+	if dstQuota, _ := parseQuota(c); dstQuota != nil {
+		quotaPrefix = dstQuota.prefix
+	}
+
+	age, _ := parseAgeFilter(c)
+	sizeRange, _ := parseSizeFilter(c)
+
 	return Sync{
 		src:         c.Args().Get(0),
 		dst:         c.Args().Get(1),
@@ -134,17 +226,24 @@ func NewSync(c *cli.Context) Sync {
 		fullCommand: commandFromContext(c),
 
 		// flags
-		delete:   c.Bool("delete"),
-		sizeOnly: c.Bool("size-only"),
+		delete:          c.Bool("delete"),
+		sizeOnly:        c.Bool("size-only"),
+		sourceNewerThan: c.String("source-newer-than"),
 
 		// flags
-		followSymlinks: !c.Bool("no-follow-symlinks"),
-		storageClass:   storage.StorageClass(c.String("storage-class")),
-		raw:            c.Bool("raw"),
+		followSymlinks:   !c.Bool("no-follow-symlinks"),
+		storageClass:     storage.StorageClass(c.String("storage-class")),
+		raw:              c.Bool("raw"),
+		respectGitignore: c.Bool("respect-gitignore"),
+		renamer:          renamer,
+		quotaPrefix:      quotaPrefix,
+		age:              age,
+		sizeRange:        sizeRange,
 		// region settings
 		srcRegion:   c.String("source-region"),
 		dstRegion:   c.String("destination-region"),
 		storageOpts: NewStorageOpts(c),
+		lister:      newSourceLister(c),
 	}
 }
 
@@ -161,12 +260,25 @@ func (s Sync) Run(c *cli.Context) error {
 		return err
 	}
 
+	if s.sourceNewerThan != "" {
+		s.state, err = readSyncState(s.sourceNewerThan)
+		if err != nil {
+			printError(s.fullCommand, s.op, err)
+			return err
+		}
+	}
+
 	sourceObjects, destObjects, err := s.getSourceAndDestinationObjects(c.Context, srcurl, dsturl)
 	if err != nil {
 		printError(s.fullCommand, s.op, err)
 		return err
 	}
 
+	var nextState *syncState
+	if s.state != nil {
+		nextState = s.nextSyncState(sourceObjects)
+	}
+
 	isBatch := srcurl.IsWildcard()
 	if !isBatch && !srcurl.IsRemote() {
 		sourceClient, err := storage.NewClient(c.Context, srcurl, s.storageOpts)
@@ -178,11 +290,19 @@ func (s Sync) Run(c *cli.Context) error {
 		isBatch = obj != nil && obj.Type.IsDir()
 	}
 
-	onlySource, onlyDest, commonObjects := compareObjects(sourceObjects, destObjects)
+	if s.renamer != nil {
+		sourceObjects = s.renameSourceObjects(sourceObjects, isBatch)
+	}
+
+	onlySource, onlyDest, commonObjects := compareObjects(sourceObjects, destObjects, s.destinationName)
 
 	sourceObjects = nil
 	destObjects = nil
 
+	if s.planOutput != nil {
+		return s.writePlan(c, onlySource, onlyDest, commonObjects, dsturl, isBatch)
+	}
+
 	waiter := parallel.NewWaiter()
 	var (
 		merrorWaiter error
@@ -193,8 +313,8 @@ func (s Sync) Run(c *cli.Context) error {
 		defer close(errDoneCh)
 		for err := range waiter.Err() {
 			if strings.Contains(err.Error(), "too many open files") {
-				fmt.Println(strings.TrimSpace(fdlimitWarning))
-				fmt.Printf("ERROR %v\n", err)
+				fmt.Fprintln(os.Stderr, strings.TrimSpace(fdlimitWarning))
+				fmt.Fprintf(os.Stderr, "ERROR %v\n", err)
 
 				os.Exit(1)
 			}
@@ -210,25 +330,93 @@ func (s Sync) Run(c *cli.Context) error {
 	go s.planRun(c, onlySource, onlyDest, commonObjects, dsturl, strategy, pipeWriter, isBatch)
 
 	err = NewRun(c, pipeReader).Run(c.Context)
-	return multierror.Append(err, merrorWaiter).ErrorOrNil()
+	err = multierror.Append(err, merrorWaiter).ErrorOrNil()
+	if err != nil {
+		return err
+	}
+
+	// only advance the watermark if every object is synced, otherwise
+	// the failed ones would be skipped on the next run.
+	if s.sourceNewerThan != "" && !s.storageOpts.DryRun {
+		if err := writeSyncState(s.sourceNewerThan, nextState); err != nil {
+			printError(s.fullCommand, s.op, err)
+			return err
+		}
+	}
+	return nil
 }
 
-// compareObjects compares source and destination objects.
-// Returns objects those in only source, only destination
-// and both.
+// writePlan writes the commands to sync source to destination to the plan
+// output instead of executing them. The watermark is not advanced since the
+// commands are executed later on.
+func (s Sync) writePlan(
+	c *cli.Context,
+	onlySource []*storage.Object,
+	onlyDest []*url.URL,
+	common []*ObjectPair,
+	dsturl *url.URL,
+	isBatch bool,
+) error {
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+
+	go s.planRun(c, onlySource, onlyDest, common, dsturl, NewStrategy(s.sizeOnly), pipeWriter, isBatch)
+
+	if _, err := io.Copy(s.planOutput, pipeReader); err != nil {
+		printError(s.fullCommand, s.op, err)
+		return err
+	}
+	return nil
+}
+
+// renameSourceObjects records the names of the source objects renamed with
+// --rename flag. The objects which can't be renamed are reported and left
+// out of the sync.
+func (s *Sync) renameSourceObjects(sourceObjects []*storage.Object, isBatch bool) []*storage.Object {
+	s.destinationNames = make(map[*storage.Object]string, len(sourceObjects))
+
+	renamed := sourceObjects[:0]
+	for _, object := range sourceObjects {
+		name, err := s.renamer.rename(filepath.ToSlash(destinationName(object.URL, false, isBatch)), object)
+		if err != nil {
+			printError(s.fullCommand, s.op, err)
+			continue
+		}
+		s.destinationNames[object] = name
+		renamed = append(renamed, object)
+	}
+	return renamed
+}
+
+// destinationName returns the name of the source object relative to the
+// destination.
+func (s Sync) destinationName(object *storage.Object) string {
+	if name, ok := s.destinationNames[object]; ok {
+		return name
+	}
+	return filepath.ToSlash(object.URL.Relative())
+}
+
+// compareObjects compares source and destination objects. Source objects
+// are matched with the destination objects by the names returned by
+// sourceName. Returns objects those in only source, urls of objects those in
+// only destination and both.
 // The algorithm is taken from;
 // https://github.com/rclone/rclone/blob/HEAD/fs/march/march.go#L304
-func compareObjects(sourceObjects, destObjects []*storage.Object) ([]*url.URL, []*url.URL, []*ObjectPair) {
+func compareObjects(
+	sourceObjects, destObjects []*storage.Object,
+	sourceName func(*storage.Object) string,
+) ([]*storage.Object, []*url.URL, []*ObjectPair) {
 	// sort the source and destination objects.
 	sort.SliceStable(sourceObjects, func(i, j int) bool {
-		return sourceObjects[i].URL.Relative() < sourceObjects[j].URL.Relative()
+		return sourceName(sourceObjects[i]) < sourceName(sourceObjects[j])
 	})
 	sort.SliceStable(destObjects, func(i, j int) bool {
 		return destObjects[i].URL.Relative() < destObjects[j].URL.Relative()
 	})
 
 	var (
-		srcOnly   []*url.URL
+		srcOnly   []*storage.Object
 		dstOnly   []*url.URL
 		commonObj []*ObjectPair
 	)
@@ -239,7 +427,7 @@ func compareObjects(sourceObjects, destObjects []*storage.Object) ([]*url.URL, [
 
 		if iSrc < len(sourceObjects) {
 			srcObject = sourceObjects[iSrc]
-			srcName = filepath.ToSlash(srcObject.URL.Relative())
+			srcName = sourceName(srcObject)
 		}
 
 		if iDst < len(destObjects) {
@@ -269,7 +457,7 @@ func compareObjects(sourceObjects, destObjects []*storage.Object) ([]*url.URL, [
 		case srcObject == nil:
 			dstOnly = append(dstOnly, dstObject.URL)
 		case dstObject == nil:
-			srcOnly = append(srcOnly, srcObject.URL)
+			srcOnly = append(srcOnly, srcObject)
 		}
 	}
 	return srcOnly, dstOnly, commonObj
@@ -278,7 +466,11 @@ func compareObjects(sourceObjects, destObjects []*storage.Object) ([]*url.URL, [
 // getSourceAndDestinationObjects returns source and destination
 // objects from given urls.
 func (s Sync) getSourceAndDestinationObjects(ctx context.Context, srcurl, dsturl *url.URL) ([]*storage.Object, []*storage.Object, error) {
-	sourceClient, err := storage.NewClient(ctx, srcurl, s.storageOpts)
+	// the source objects are listed with the external lister, if it is
+	// given, while the destination objects are always listed from the bucket.
+	srcOpts := s.storageOpts
+	srcOpts.Lister = s.lister
+	sourceClient, err := storage.NewClient(ctx, srcurl, srcOpts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -301,6 +493,14 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, srcurl, dsturl
 		return nil, nil, err
 	}
 
+	var gitignore *gitignoreMatcher
+	if s.respectGitignore && !srcurl.IsRemote() {
+		gitignore, err = newGitignoreMatcher(srcurl)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	var (
 		sourceObjects []*storage.Object
 		destObjects   []*storage.Object
@@ -316,10 +516,21 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, srcurl, dsturl
 			if s.shouldSkipObject(srcObject, true) {
 				continue
 			}
+			if gitignore != nil && gitignore.IsIgnored(srcObject.URL.Absolute()) {
+				continue
+			}
 			sourceObjects = append(sourceObjects, srcObject)
 		}
 	}()
 
+	// the objects synced by the previous run are known, so the destination
+	// doesn't need to be listed unless the objects missing in the source
+	// are deleted.
+	if s.skipsDestinationListing() {
+		wg.Wait()
+		return sourceObjects, nil, nil
+	}
+
 	// get destination objects.
 	wg.Add(1)
 	go func() {
@@ -340,7 +551,8 @@ func (s Sync) getSourceAndDestinationObjects(ctx context.Context, srcurl, dsturl
 // planRun prepares the commands and writes them to writer 'w'.
 func (s Sync) planRun(
 	c *cli.Context,
-	onlySource, onlyDest []*url.URL,
+	onlySource []*storage.Object,
+	onlyDest []*url.URL,
 	common []*ObjectPair,
 	dsturl *url.URL,
 	strategy SyncStrategy,
@@ -356,9 +568,50 @@ func (s Sync) planRun(
 		"raw": true,
 	}
 
+	// the destinations of the commands are already renamed, so the rename
+	// flag is cleared to not be inherited from sync command.
+	if s.renamer != nil {
+		defaultFlags["rename"] = ""
+	}
+
+	// the source objects are already filtered by their ages, and their
+	// modification times are not known by the generated commands.
+	if s.age.isSet() {
+		defaultFlags["older-than"] = ""
+		defaultFlags["newer-than"] = ""
+	}
+
+	// the source objects are already filtered by their sizes.
+	if s.sizeRange.isSet() {
+		defaultFlags["min-size"] = ""
+		defaultFlags["max-size"] = ""
+	}
+
+	// the quotas apply to the destination of sync command rather than the
+	// destinations of the generated commands.
+	if s.quotaPrefix != nil {
+		defaultFlags["quota-prefix"] = s.quotaPrefix
+	}
+
 	// only in source
-	for _, srcurl := range onlySource {
-		curDestURL := generateDestinationURL(srcurl, dsturl, isBatch)
+	for _, srcObject := range onlySource {
+		srcurl := srcObject.URL
+		// the objects missing in the listed destination are synced even if
+		// they are synced by the previous run.
+		if s.skipsDestinationListing() && s.state.isUnchanged(srcObject) {
+			printDebug(s.op, errorpkg.ErrObjectIsNotNewerThanWatermark, srcurl)
+			continue
+		}
+		if !s.matchesAge(srcObject) {
+			printDebug(s.op, errObjectAgeMismatch, srcurl)
+			continue
+		}
+		if !s.sizeRange.matchSize(srcObject.Size) {
+			printDebug(s.op, errObjectSizeMismatch, srcurl)
+			continue
+		}
+
+		curDestURL := generateDestinationURL(srcurl, dsturl, isBatch, s.destinationNames[srcObject])
 		command, err := generateCommand(c, "cp", defaultFlags, srcurl, curDestURL)
 		if err != nil {
 			printDebug(s.op, err, srcurl, curDestURL)
@@ -371,6 +624,19 @@ func (s Sync) planRun(
 	for _, commonObject := range common {
 		sourceObject, destObject := commonObject.src, commonObject.dst
 		curSourceURL, curDestURL := sourceObject.URL, destObject.URL
+		if s.state.isUnchanged(sourceObject) {
+			printDebug(s.op, errorpkg.ErrObjectIsNotNewerThanWatermark, curSourceURL, curDestURL)
+			continue
+		}
+		if !s.matchesAge(sourceObject) {
+			printDebug(s.op, errObjectAgeMismatch, curSourceURL, curDestURL)
+			continue
+		}
+		if !s.sizeRange.matchSize(sourceObject.Size) {
+			printDebug(s.op, errObjectSizeMismatch, curSourceURL, curDestURL)
+			continue
+		}
+
 		err := strategy.ShouldSync(sourceObject, destObject) // check if object should be copied.
 		if err != nil {
 			printDebug(s.op, err, curSourceURL, curDestURL)
@@ -387,7 +653,34 @@ func (s Sync) planRun(
 
 	// only in destination
 	if s.delete && len(onlyDest) > 0 {
-		command, err := generateCommand(c, "rm", defaultFlags, onlyDest...)
+		// objects protected by object lock are reported and skipped
+		// instead of failing the whole sync operation.
+		deleteFlags := map[string]interface{}{
+			"raw":         true,
+			"skip-locked": true,
+		}
+
+		if c.IsSet("delete-rate") {
+			deleteFlags["rate"] = c.Float64("delete-rate")
+		}
+		if c.IsSet("delete-batch-size") {
+			deleteFlags["batch-size"] = c.Int("delete-batch-size")
+		}
+
+		// the ages of the source objects don't apply to the objects
+		// missing in the source.
+		if s.age.isSet() {
+			deleteFlags["older-than"] = ""
+			deleteFlags["newer-than"] = ""
+		}
+
+		// neither do the sizes of the source objects.
+		if s.sizeRange.isSet() {
+			deleteFlags["min-size"] = ""
+			deleteFlags["max-size"] = ""
+		}
+
+		command, err := generateCommand(c, "rm", deleteFlags, onlyDest...)
 		if err != nil {
 			printDebug(s.op, err, onlyDest...)
 			return
@@ -397,11 +690,12 @@ func (s Sync) planRun(
 }
 
 // generateDestinationURL generates destination url for given
-// source url if it would have been in destination.
-func generateDestinationURL(srcurl, dsturl *url.URL, isBatch bool) *url.URL {
-	objname := srcurl.Base()
-	if isBatch {
-		objname = srcurl.Relative()
+// source url if it would have been in destination. The name overrides the
+// name of the object relative to the destination if it is set.
+func generateDestinationURL(srcurl, dsturl *url.URL, isBatch bool, name string) *url.URL {
+	objname := destinationName(srcurl, false, isBatch)
+	if name != "" {
+		objname = name
 	}
 
 	if dsturl.IsRemote() {
@@ -415,6 +709,43 @@ func generateDestinationURL(srcurl, dsturl *url.URL, isBatch bool) *url.URL {
 	return dsturl.Join(objname)
 }
 
+// matchesAge checks if the source object is modified in the range given by
+// --older-than and --newer-than flags. The objects whose modification times
+// are unknown are synced.
+func (s Sync) matchesAge(object *storage.Object) bool {
+	if object.ModTime == nil {
+		return true
+	}
+	return s.age.matchTime(*object.ModTime)
+}
+
+// skipsDestinationListing reports whether the destination is not listed
+// since the objects synced by the previous run are recorded, and the objects
+// missing in the source are not deleted.
+func (s Sync) skipsDestinationListing() bool {
+	return s.state != nil && len(s.state.objects) > 0 && !s.delete
+}
+
+// nextSyncState returns the state to record once the sync succeeds. It
+// records the source objects which match the filters, since they are either
+// synced or already the same in the destination.
+func (s Sync) nextSyncState(sourceObjects []*storage.Object) *syncState {
+	next := &syncState{
+		watermark: s.state.watermark,
+		objects:   make(map[string]int64, len(sourceObjects)),
+	}
+	for _, object := range sourceObjects {
+		if !s.matchesAge(object) || !s.sizeRange.matchSize(object.Size) {
+			continue
+		}
+		if object.ModTime != nil && object.ModTime.After(next.watermark) {
+			next.watermark = *object.ModTime
+		}
+		next.objects[syncStateKey(object.URL)] = object.Size
+	}
+	return next
+}
+
 // shouldSkipObject checks is object should be skipped.
 func (s Sync) shouldSkipObject(object *storage.Object, verbose bool) bool {
 	if object.Type.IsDir() || errorpkg.IsCancelation(object.Err) {
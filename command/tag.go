@@ -0,0 +1,255 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+var tagGetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument [argument]
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Print the tags of an object
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/object
+
+	2. Print the tags of all objects with a prefix as JSON
+		 > s5cmd --json {{.HelpName}} "s3://bucket/prefix/*"
+`
+
+var tagSetHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument [argument]
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Replace the tags of an object
+		 > s5cmd {{.HelpName}} --tags "team=analytics,env=prod" s3://bucket/prefix/object
+
+	2. Replace the tags of all objects with a prefix
+		 > s5cmd {{.HelpName}} --tags "retention=short" "s3://bucket/tmp/*"
+`
+
+var tagRemoveHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] argument [argument]
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Remove all tags of an object
+		 > s5cmd {{.HelpName}} s3://bucket/prefix/object
+
+	2. Remove the "env" tag of all objects with a prefix, keeping their other tags
+		 > s5cmd {{.HelpName}} --key env "s3://bucket/prefix/*"
+`
+
+func NewTagCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "tag",
+		HelpName: "tag",
+		Usage:    "print, replace or remove tags of objects",
+		Subcommands: []*cli.Command{
+			newTagGetCommand(),
+			newTagSetCommand(),
+			newTagRemoveCommand(),
+		},
+	}
+}
+
+func newTagGetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "get",
+		HelpName:           "tag get",
+		Usage:              "print tags of objects",
+		CustomHelpTemplate: tagGetHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateTagCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return newObjectBatch(c).run(c.Context, func(ctx context.Context, client *storage.S3, u *url.URL) error {
+				tags, err := client.Tags(ctx, u)
+				if err != nil {
+					return err
+				}
+				log.Info(TagMessage{Source: u, Tags: tags})
+				return nil
+			})
+		},
+	}
+}
+
+func newTagSetCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "set",
+		HelpName:           "tag set",
+		Usage:              "replace tags of objects",
+		CustomHelpTemplate: tagSetHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "tags",
+				Usage: "tags replacing the tags of the objects, in key1=value1,key2=value2 format",
+			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateTagSetCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			// the tags are validated before the command runs.
+			tags, _ := parseTags(c.String("tags"))
+			op := c.Command.HelpName
+			return newObjectBatch(c).run(c.Context, func(ctx context.Context, client *storage.S3, u *url.URL) error {
+				if err := client.SetTags(ctx, u, tags); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: u})
+				return nil
+			})
+		},
+	}
+}
+
+func newTagRemoveCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "rm",
+		HelpName:           "tag rm",
+		Usage:              "remove tags of objects",
+		CustomHelpTemplate: tagRemoveHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "key",
+				Usage: "remove only the tag with the given key, keeping the other tags of the objects; can be given multiple times",
+			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "disable the wildcard operations, useful with filenames that contains glob characters",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateTagCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			keys := c.StringSlice("key")
+			op := c.Command.HelpName
+			return newObjectBatch(c).run(c.Context, func(ctx context.Context, client *storage.S3, u *url.URL) error {
+				if err := removeTags(ctx, client, u, keys); err != nil {
+					return err
+				}
+				log.Info(log.InfoMessage{Operation: op, Source: u})
+				return nil
+			})
+		},
+	}
+}
+
+// removeTags removes the tags of the object with the given keys, or all of
+// its tags if no key is given.
+func removeTags(ctx context.Context, client *storage.S3, u *url.URL, keys []string) error {
+	if len(keys) == 0 {
+		return client.DeleteTags(ctx, u)
+	}
+
+	tags, err := client.Tags(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	var removed bool
+	for _, key := range keys {
+		if _, ok := tags[key]; ok {
+			delete(tags, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	if len(tags) == 0 {
+		return client.DeleteTags(ctx, u)
+	}
+	return client.SetTags(ctx, u, tags)
+}
+
+// TagMessage is a structure for logging the tags of an object.
+type TagMessage struct {
+	Source *url.URL          `json:"source"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// String returns the string representation of TagMessage, the tags are URL
+// encoded as in the tagging header of S3 requests.
+func (m TagMessage) String() string {
+	return fmt.Sprintf("%v %v", m.Source, storage.EncodeTags(m.Tags))
+}
+
+// JSON returns the JSON representation of TagMessage.
+func (m TagMessage) JSON() string {
+	return strutil.JSON(m)
+}
+
+func validateTagCommand(c *cli.Context) error {
+	return validateObjectBatch(c, "tag")
+}
+
+func validateTagSetCommand(c *cli.Context) error {
+	if err := validateTagCommand(c); err != nil {
+		return err
+	}
+
+	tags, err := parseTags(c.String("tags"))
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("expected at least 1 tag with --tags, use tag rm to remove the tags")
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var tarHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] source destination
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Stream the objects under a prefix to another program as a tar archive
+		 > s5cmd {{.HelpName}} "s3://bucket/prefix/*" - | tape-writer
+
+	2. Archive the objects under a prefix to a gzip compressed local file
+		 > s5cmd {{.HelpName}} "s3://bucket/prefix/*" backup.tar.gz
+
+	3. Archive the log files except the temporary ones, fetching 20 parts of 16MiB concurrently
+		 > s5cmd {{.HelpName}} -c 20 -p 16 --exclude "*.tmp" "s3://bucket/logs/*" logs.tar
+`
+
+func NewTarCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "tar",
+		HelpName:           "tar",
+		Usage:              "write objects to a tar archive in order, without intermediate files",
+		CustomHelpTemplate: tarHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"c"},
+				Value:   defaultCopyConcurrency,
+				Usage:   "number of parts of the objects fetched concurrently, the archive is written in order",
+			},
+			&cli.IntFlag{
+				Name:    "part-size",
+				Aliases: []string{"p"},
+				Value:   defaultPartSize,
+				Usage:   "size of each part fetched concurrently, in MiB",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "exclude objects with given pattern",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateTarCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			partSize := c.Int64("part-size") * megabytes
+			if !c.IsSet("part-size") && transferConfig.MultipartChunkSize > 0 {
+				partSize = transferConfig.MultipartChunkSize
+			}
+
+			return Tar{
+				src:         c.Args().Get(0),
+				dst:         c.Args().Get(1),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				exclude:     c.StringSlice("exclude"),
+				concurrency: c.Int("concurrency"),
+				partSize:    partSize,
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Tar holds tar operation flags and states.
+type Tar struct {
+	src         string
+	dst         string
+	op          string
+	fullCommand string
+
+	// flags
+	exclude []string
+
+	// s3 options
+	concurrency int
+	partSize    int64
+	storageOpts storage.Options
+}
+
+// tarPart is a part of an object to be written to the archive. The first
+// parts of the objects are preceded by the headers of the objects, and the
+// objects are logged once their last parts are written.
+type tarPart struct {
+	object *storage.Object
+	name   string
+	first  bool
+	last   bool
+
+	// result receives the content of the part once it is fetched.
+	result chan tarPartResult
+}
+
+type tarPartResult struct {
+	data []byte
+	err  error
+}
+
+// Run writes the objects at the source to the destination as a tar archive,
+// in the order they are listed. At most concurrency parts are fetched ahead
+// of the writer, which bounds the memory in use.
+func (t Tar) Run(ctx context.Context) error {
+	srcurl, err := url.New(t.src)
+	if err != nil {
+		printError(t.fullCommand, t.op, err)
+		return err
+	}
+
+	excludePatterns, err := createExcludesFromWildcard(t.exclude)
+	if err != nil {
+		printError(t.fullCommand, t.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, t.storageOpts)
+	if err != nil {
+		printError(t.fullCommand, t.op, err)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var objects <-chan *storage.Object
+	if srcurl.IsWildcard() {
+		objects = client.List(ctx, srcurl, false)
+	} else {
+		ch := make(chan *storage.Object, 1)
+		object, err := client.Stat(ctx, srcurl)
+		if err != nil {
+			object = &storage.Object{Err: err}
+		}
+		ch <- object
+		close(ch)
+		objects = ch
+	}
+
+	parts := make(chan tarPart, t.concurrency)
+	go func() {
+		defer close(parts)
+		// the listing is drained, so that it is not blocked once the
+		// archive is stopped.
+		defer func() {
+			for range objects {
+			}
+		}()
+
+		for object := range objects {
+			if object.Err == nil && (object.Type.IsDir() || isURLExcluded(excludePatterns, object.URL.Path, srcurl.Prefix)) {
+				continue
+			}
+			if !t.fetch(ctx, client, srcurl, object, parts) {
+				return
+			}
+		}
+	}()
+
+	err = t.write(ctx, parts)
+	if err != nil {
+		cancel()
+		for range parts {
+		}
+		printError(t.fullCommand, t.op, err)
+		return err
+	}
+	return nil
+}
+
+// fetch sends the parts of the object, and fetches them concurrently. It
+// returns false if the archive is stopped.
+func (t Tar) fetch(ctx context.Context, client *storage.S3, srcurl *url.URL, object *storage.Object, parts chan<- tarPart) bool {
+	var name string
+	if object.Err == nil {
+		name = object.URL.Base()
+		if srcurl.IsWildcard() {
+			name = object.URL.Relative()
+		}
+	}
+
+	partSize := t.partSize
+	if partSize <= 0 {
+		partSize = object.Size
+	}
+
+	// the parts are requested with the ETag of the listed object, so that
+	// the archive fails instead of mixing the parts of different versions,
+	// whose sizes may not match the header.
+	var etag string
+	if object.Etag != "" {
+		etag = `"` + object.Etag + `"`
+	}
+
+	for offset := int64(0); offset == 0 || offset < object.Size; offset += partSize {
+		length := object.Size - offset
+		if length > partSize {
+			length = partSize
+		}
+
+		part := tarPart{
+			object: object,
+			name:   name,
+			first:  offset == 0,
+			last:   offset+length >= object.Size,
+			result: make(chan tarPartResult, 1),
+		}
+		select {
+		case parts <- part:
+		case <-ctx.Done():
+			return false
+		}
+
+		// the errors of the listing are sent to the writer in order.
+		if object.Err != nil {
+			part.result <- tarPartResult{err: object.Err}
+			return false
+		}
+
+		go func(offset, length int64) {
+			// empty objects can't be read with a range.
+			if length == 0 {
+				part.result <- tarPartResult{}
+				return
+			}
+			data, err := client.ReadPart(ctx, object.URL, etag, offset, length)
+			part.result <- tarPartResult{data: data, err: err}
+		}(offset, length)
+	}
+	return true
+}
+
+// write writes the parts to the archive at the destination in order. The
+// archive files are removed if the archive is not written completely,
+// including when the parts stop since the context is canceled.
+func (t Tar) write(ctx context.Context, parts <-chan tarPart) (err error) {
+	var w io.Writer = os.Stdout
+	if t.dst != "-" {
+		f, ferr := os.Create(t.dst)
+		if ferr != nil {
+			return ferr
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(t.dst)
+			}
+		}()
+		w = f
+
+		if isGzipArchive(t.dst) {
+			gw := gzip.NewWriter(f)
+			defer func() {
+				if cerr := gw.Close(); err == nil {
+					err = cerr
+				}
+			}()
+			w = gw
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	archived := false
+	for part := range parts {
+		result := <-part.result
+		if result.err != nil {
+			return result.err
+		}
+
+		object := part.object
+		if part.first {
+			modTime := time.Now()
+			if object.ModTime != nil {
+				modTime = *object.ModTime
+			}
+
+			header := &tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     part.name,
+				Size:     object.Size,
+				Mode:     0644,
+				ModTime:  modTime,
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tw.Write(result.data); err != nil {
+			return err
+		}
+
+		// the standard output is the archive.
+		if part.last && t.dst != "-" {
+			memberurl, err := url.New(t.dst + archiveMemberSeparator + part.name)
+			if err != nil {
+				return err
+			}
+			log.Info(log.InfoMessage{
+				Operation:   t.op,
+				Source:      object.URL,
+				Destination: memberurl,
+				Object: &storage.Object{
+					Size: object.Size,
+				},
+			})
+		}
+		archived = true
+	}
+
+	// the fetching stops without an error once the context is canceled, the
+	// archive is not closed so as not to look complete.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !archived {
+		return storage.ErrNoObjectFound
+	}
+	return tw.Close()
+}
+
+// isGzipArchive reports whether the archive at the given path is compressed
+// with gzip, by its extension.
+func isGzipArchive(path string) bool {
+	return strings.HasSuffix(path, gzipSuffix) || strings.HasSuffix(path, ".tgz")
+}
+
+func validateTarCommand(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("expected source and destination arguments")
+	}
+
+	srcurl, err := url.New(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be a remote object or contain glob characters")
+	}
+	if srcurl.IsBucketWildcard() {
+		return fmt.Errorf("source bucket can not contain glob characters")
+	}
+	if !srcurl.IsWildcard() && (srcurl.IsBucket() || srcurl.IsPrefix()) {
+		return fmt.Errorf("source must be an object or contain glob characters, e.g. %q", strings.TrimSuffix(srcurl.String(), "/")+"/*")
+	}
+
+	if dst := c.Args().Get(1); dst != "-" {
+		dsturl, err := url.New(dst)
+		if err != nil {
+			return err
+		}
+		if dsturl.IsRemote() {
+			return fmt.Errorf("destination must be a local file or -")
+		}
+		if info, err := os.Stat(dst); err == nil && info.IsDir() {
+			return fmt.Errorf("destination must be a local file or -")
+		}
+	}
+
+	if c.Int("concurrency") < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	if c.Int("part-size") < 1 {
+		return fmt.Errorf("part-size must be at least 1")
+	}
+	return nil
+}
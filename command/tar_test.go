@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestIsGzipArchive(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isGzipArchive("backup.tar.gz"))
+	assert.True(t, isGzipArchive("dir/backup.tgz"))
+	assert.False(t, isGzipArchive("backup.tar"))
+	assert.False(t, isGzipArchive("backup.gzip"))
+}
+
+func TestTarWriteInterrupted(t *testing.T) {
+	log.Init("error", false)
+
+	dir, err := ioutil.TempDir("", "s5cmd-tar")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src, err := url.New("s3://bucket/file.txt")
+	assert.NoError(t, err)
+
+	// the fetching stops between the objects once the context is canceled.
+	parts := make(chan tarPart, 1)
+	part := tarPart{
+		object: &storage.Object{URL: src, Size: 4},
+		name:   "file.txt",
+		first:  true,
+		last:   true,
+		result: make(chan tarPartResult, 1),
+	}
+	part.result <- tarPartResult{data: []byte("data")}
+	parts <- part
+	close(parts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dst := filepath.Join(dir, "archive.tar")
+	err = Tar{dst: dst, op: "tar"}.write(ctx, parts)
+	assert.True(t, errorpkg.IsCancelation(err))
+
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+}
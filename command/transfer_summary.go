@@ -0,0 +1,43 @@
+package command
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/parallel"
+)
+
+// transferSummary counts the results of the transfer tasks of a command, to
+// report the partial results if the command is canceled.
+type transferSummary struct {
+	succeeded int64
+	failed    int64
+	canceled  int64
+}
+
+// track returns a task which runs the given task and counts its result.
+func (s *transferSummary) track(task parallel.Task) parallel.Task {
+	return func() error {
+		err := task()
+		switch {
+		case err == nil:
+			atomic.AddInt64(&s.succeeded, 1)
+		case errorpkg.IsCancelation(err):
+			atomic.AddInt64(&s.canceled, 1)
+		default:
+			atomic.AddInt64(&s.failed, 1)
+		}
+		return err
+	}
+}
+
+// String returns the summary of the results.
+func (s *transferSummary) String() string {
+	return fmt.Sprintf(
+		"interrupted: %d succeeded, %d failed, %d canceled",
+		atomic.LoadInt64(&s.succeeded),
+		atomic.LoadInt64(&s.failed),
+		atomic.LoadInt64(&s.canceled),
+	)
+}
@@ -0,0 +1,27 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferSummary(t *testing.T) {
+	t.Parallel()
+
+	var summary transferSummary
+
+	tasks := []func() error{
+		func() error { return nil },
+		func() error { return nil },
+		func() error { return fmt.Errorf("access denied") },
+		func() error { return fmt.Errorf("upload: %w", context.Canceled) },
+	}
+	for _, task := range tasks {
+		_ = summary.track(task)()
+	}
+
+	assert.Equal(t, "interrupted: 2 succeeded, 1 failed, 1 canceled", summary.String())
+}
@@ -0,0 +1,172 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var undeleteHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} argument
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Undelete an S3 object on a versioned bucket
+		 > s5cmd {{.HelpName}} s3://bucketname/prefix/object.gz
+
+	2. Undelete all objects with a prefix on a versioned bucket
+		 > s5cmd {{.HelpName}} "s3://bucketname/prefix/*"
+
+	3. Print the objects to be undeleted without restoring them
+		 > s5cmd --dry-run {{.HelpName}} "s3://bucketname/prefix/*"
+`
+
+func NewUndeleteCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "undelete",
+		HelpName:           "undelete",
+		Usage:              "restore the newest versions of deleted objects on versioned buckets",
+		CustomHelpTemplate: undeleteHelpTemplate,
+		Before: func(c *cli.Context) error {
+			err := validateUndeleteCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return Undelete{
+				src:         c.Args().First(),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Undelete holds undelete operation flags and states.
+type Undelete struct {
+	src         string
+	op          string
+	fullCommand string
+
+	storageOpts storage.Options
+}
+
+// Run restores the newest versions of the deleted objects matching the
+// source by removing the delete markers hiding them.
+func (u Undelete) Run(ctx context.Context) error {
+	srcurl, err := url.New(u.src)
+	if err != nil {
+		printError(u.fullCommand, u.op, err)
+		return err
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, u.storageOpts)
+	if err != nil {
+		printError(u.fullCommand, u.op, err)
+		return err
+	}
+
+	waiter := parallel.NewWaiter()
+
+	var (
+		merror  error
+		errDone = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			printError(u.fullCommand, u.op, err)
+			merror = multierror.Append(merror, err)
+		}
+	}()
+
+	var listError error
+	for object := range client.ListDeletedObjects(ctx, srcurl) {
+		if errorpkg.IsCancelation(object.Err) {
+			continue
+		}
+
+		if err := object.Err; err != nil {
+			listError = err
+			printError(u.fullCommand, u.op, err)
+			continue
+		}
+
+		if !object.HasVersion {
+			printWarning(u.op, "skipped: no version to restore", object.URL)
+			continue
+		}
+
+		object := object
+		task := func() error {
+			// the object stays deleted until all of the markers newer than
+			// its newest version are removed.
+			for _, marker := range object.DeleteMarkers {
+				if err := client.Delete(ctx, marker); err != nil {
+					return &errorpkg.Error{
+						Op:  u.op,
+						Src: object.URL,
+						Err: err,
+					}
+				}
+			}
+
+			log.Info(log.InfoMessage{
+				Operation: u.op,
+				Source:    object.URL,
+			})
+			return nil
+		}
+		parallel.Run(task, waiter)
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	if listError != nil {
+		merror = multierror.Append(merror, listError)
+	}
+	return merror
+}
+
+func validateUndeleteCommand(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("expected only 1 argument")
+	}
+
+	srcurl, err := url.New(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if !srcurl.IsRemote() {
+		return fmt.Errorf("source must be remote")
+	}
+
+	if srcurl.IsBucket() {
+		return fmt.Errorf("source argument must contain wildcard if it is a bucket")
+	}
+
+	return nil
+}
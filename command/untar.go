@@ -0,0 +1,755 @@
+package command
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var untarHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] source destination
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Extract a local gzip compressed tar archive under a prefix
+		 > s5cmd {{.HelpName}} backup.tar.gz s3://bucket/prefix/
+
+	2. Extract a tar archive read from stdin
+		 > tape-reader | s5cmd {{.HelpName}} - s3://bucket/prefix/
+
+	3. Extract a remote zip archive to another bucket, except the temporary files
+		 > s5cmd {{.HelpName}} --exclude "*.tmp" s3://bucket/archive.zip s3://target-bucket/
+
+	4. Extract a tar archive with the STANDARD_IA storage class
+		 > s5cmd {{.HelpName}} --storage-class STANDARD_IA backup.tar s3://bucket/prefix/
+`
+
+func NewUntarCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "untar",
+		HelpName:           "untar",
+		Usage:              "extract a tar or zip archive to a bucket or a prefix, without intermediate files",
+		CustomHelpTemplate: untarHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"c"},
+				Value:   defaultCopyConcurrency,
+				Usage:   "number of parts transferred concurrently for each of the large files and the remote archives",
+			},
+			&cli.IntFlag{
+				Name:    "part-size",
+				Aliases: []string{"p"},
+				Value:   defaultPartSize,
+				Usage:   "size of each part transferred concurrently, in MiB; the files of tar archives up to this size are buffered in memory to be uploaded in parallel",
+			},
+			&cli.StringFlag{
+				Name:  "storage-class",
+				Usage: "set storage class for the extracted objects ('STANDARD','REDUCED_REDUNDANCY','GLACIER','STANDARD_IA','ONEZONE_IA','INTELLIGENT_TIERING','DEEP_ARCHIVE')",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "exclude files in the archive with given pattern",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateUntarCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			partSize := c.Int64("part-size") * megabytes
+			if !c.IsSet("part-size") && transferConfig.MultipartChunkSize > 0 {
+				partSize = transferConfig.MultipartChunkSize
+			}
+
+			return Untar{
+				src:          c.Args().Get(0),
+				dst:          c.Args().Get(1),
+				op:           c.Command.Name,
+				fullCommand:  commandFromContext(c),
+				exclude:      c.StringSlice("exclude"),
+				storageClass: storage.StorageClass(c.String("storage-class")),
+				concurrency:  c.Int("concurrency"),
+				partSize:     partSize,
+
+				storageOpts: NewStorageOpts(c),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Untar holds untar operation flags and states.
+type Untar struct {
+	src         string
+	dst         string
+	op          string
+	fullCommand string
+
+	// flags
+	exclude      []string
+	storageClass storage.StorageClass
+
+	// s3 options
+	concurrency int
+	partSize    int64
+	storageOpts storage.Options
+}
+
+// untarEntry is a regular file in an archive.
+type untarEntry struct {
+	name string
+	size int64
+
+	// open opens the content of the file. The content of the files of tar
+	// archives can only be read until the next file is read, while the files
+	// of zip archives can be opened concurrently.
+	open       func() (io.ReadCloser, error)
+	sequential bool
+}
+
+// untarArchive reads the regular files of an archive in order, until io.EOF
+// is returned.
+type untarArchive interface {
+	next() (untarEntry, error)
+	Close() error
+}
+
+// Run extracts the files of the archive at the source under the destination
+// prefix. The files of tar archives which are not larger than the part size
+// are buffered in memory and uploaded in parallel, up to concurrency parts in
+// total, while the larger ones are streamed to multipart uploads.
+func (u Untar) Run(ctx context.Context) error {
+	dsturl, err := url.New(u.dst)
+	if err != nil {
+		printError(u.fullCommand, u.op, err)
+		return err
+	}
+
+	excludePatterns, err := createExcludesFromWildcard(u.exclude)
+	if err != nil {
+		printError(u.fullCommand, u.op, err)
+		return err
+	}
+
+	dstClient, err := storage.NewRemoteClient(ctx, dsturl, u.storageOpts)
+	if err != nil {
+		printError(u.fullCommand, u.op, err)
+		return err
+	}
+
+	archive, err := u.openArchive(ctx)
+	if err != nil {
+		printError(u.fullCommand, u.op, err)
+		return err
+	}
+	defer archive.Close()
+
+	prefix := dsturl.Path
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	budget := newByteBudget(int64(u.concurrency) * u.partSize)
+	waiter := parallel.NewWaiter()
+
+	var (
+		merror  error
+		errDone = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			if errorpkg.IsCancelation(err) {
+				continue
+			}
+			printError(u.fullCommand, u.op, err)
+			merror = multierror.Append(merror, err)
+		}
+	}()
+
+	// the errors of the files read in order, the uploads in parallel report
+	// theirs to the waiter.
+	var (
+		failed     error
+		archiveErr error
+	)
+	fail := func(err error) {
+		printError(u.fullCommand, u.op, err)
+		failed = multierror.Append(failed, err)
+	}
+
+	// the files with the same name are uploaded in archive order, so that
+	// the later one wins as it does when the archive is extracted.
+	uploads := untarUploads{}
+
+	for ctx.Err() == nil {
+		entry, err := archive.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// the archive can't be read after an error.
+			archiveErr = err
+			break
+		}
+
+		name := untarKey(entry.name)
+		if name == "" || isURLExcluded(excludePatterns, name, "") {
+			continue
+		}
+
+		srcurl, err := url.New(u.src+archiveMemberSeparator+name, url.WithRaw(true))
+		if err != nil {
+			fail(err)
+			continue
+		}
+		entryurl, err := url.New(fmt.Sprintf("s3://%s/%s%s", dsturl.Bucket, prefix, name), url.WithRaw(true))
+		if err != nil {
+			fail(err)
+			continue
+		}
+
+		prev, done := uploads.add(entryurl.Path)
+
+		switch {
+		case !entry.sequential:
+			task := func() error {
+				defer close(done)
+				<-prev
+				rc, err := entry.open()
+				if err != nil {
+					return err
+				}
+				defer rc.Close()
+				return u.upload(ctx, dstClient, rc, srcurl, entryurl, entry.size, "")
+			}
+			parallel.Run(task, waiter)
+		case entry.size > u.partSize:
+			// the large files are streamed before the next ones are read.
+			<-prev
+			rc, err := entry.open()
+			if err == nil {
+				err = u.upload(ctx, dstClient, rc, srcurl, entryurl, entry.size, "")
+				rc.Close()
+			}
+			close(done)
+			if err != nil && !errorpkg.IsCancelation(err) {
+				fail(err)
+			}
+		default:
+			budget.acquire(entry.size)
+			rc, err := entry.open()
+			var data []byte
+			if err == nil {
+				data, err = ioutil.ReadAll(rc)
+				rc.Close()
+			}
+			if err != nil {
+				budget.release(entry.size)
+				close(done)
+				archiveErr = err
+				break
+			}
+
+			contentType := http.DetectContentType(data)
+			task := func() error {
+				defer budget.release(entry.size)
+				defer close(done)
+				<-prev
+				return u.upload(ctx, dstClient, bytes.NewReader(data), srcurl, entryurl, entry.size, contentType)
+			}
+			parallel.Run(task, waiter)
+		}
+
+		if archiveErr != nil {
+			break
+		}
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	if archiveErr != nil && !errorpkg.IsCancelation(archiveErr) {
+		fail(archiveErr)
+	}
+	if failed != nil {
+		merror = multierror.Append(merror, failed)
+	}
+	// the interrupted extraction is not complete.
+	if err := ctx.Err(); err != nil {
+		merror = multierror.Append(merror, err)
+	}
+	return merror
+}
+
+// untarUploads tracks the last upload of each key, so that the uploads of
+// the same key run one after another.
+type untarUploads map[string]chan struct{}
+
+// add registers an upload of the key. The upload waits on prev, which is
+// closed once the previous upload of the key finishes, and closes done once
+// it finishes.
+func (u untarUploads) add(key string) (prev <-chan struct{}, done chan struct{}) {
+	prevDone, ok := u[key]
+	if !ok {
+		prevDone = make(chan struct{})
+		close(prevDone)
+	}
+	done = make(chan struct{})
+	u[key] = done
+	return prevDone, done
+}
+
+// upload uploads the content of the file in the archive to the destination.
+// The content type is guessed from the extension of the file, or the
+// detected one is used if it is given.
+func (u Untar) upload(
+	ctx context.Context,
+	client *storage.S3,
+	r io.Reader,
+	srcurl, dsturl *url.URL,
+	size int64,
+	detectedContentType string,
+) error {
+	if !u.storageOpts.DryRun {
+		contentType := mime.TypeByExtension(path.Ext(dsturl.Path))
+		if contentType == "" {
+			contentType = detectedContentType
+		}
+
+		metadata := storage.NewMetadata().
+			SetContentType(contentType).
+			SetStorageClass(string(u.storageClass))
+
+		if err := client.Put(ctx, r, dsturl, metadata, u.concurrency, u.partSize); err != nil {
+			return err
+		}
+	}
+
+	log.Info(log.InfoMessage{
+		Operation:   u.op,
+		Source:      srcurl,
+		Destination: dsturl,
+		Object: &storage.Object{
+			Size: size,
+		},
+	})
+	return nil
+}
+
+// untarKey returns the key of the file in the archive relative to the
+// destination prefix. The names are cleaned, so that the files are not
+// extracted outside of the prefix. Empty keys are not extracted.
+func untarKey(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// openArchive opens the archive at the source, which is a local file, a
+// remote object or "-" for stdin. The format of the archive is detected from
+// its content.
+func (u Untar) openArchive(ctx context.Context) (untarArchive, error) {
+	if u.src == "-" {
+		r := bufio.NewReader(os.Stdin)
+		if isZipArchive(r) {
+			return nil, fmt.Errorf("zip archives can not be read from stdin")
+		}
+		return newTarArchive(r, ioutil.NopCloser(nil))
+	}
+
+	srcurl, err := url.New(u.src)
+	if err != nil {
+		return nil, err
+	}
+
+	if !srcurl.IsRemote() {
+		f, err := os.Open(srcurl.Absolute())
+		if err != nil {
+			return nil, err
+		}
+
+		if isZipArchive(bufio.NewReader(f)) {
+			f.Close()
+			return newLocalZipArchive(srcurl.Absolute())
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return newTarArchive(bufio.NewReader(f), f)
+	}
+
+	client, err := storage.NewRemoteClient(ctx, srcurl, u.storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := client.Stat(ctx, srcurl)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := client.ReadParallel(ctx, srcurl, u.concurrency, u.partSize)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(rc)
+	if isZipArchive(r) {
+		rc.Close()
+		return newRemoteZipArchive(ctx, client, srcurl, object)
+	}
+	return newTarArchive(r, rc)
+}
+
+// isZipArchive reports whether the archive read from r is a zip archive, by
+// its signature.
+func isZipArchive(r *bufio.Reader) bool {
+	signature, _ := r.Peek(4)
+	return bytes.Equal(signature, []byte("PK\x03\x04")) || bytes.Equal(signature, []byte("PK\x05\x06"))
+}
+
+// tarArchive reads the regular files of a tar archive, which is optionally
+// compressed with gzip.
+type tarArchive struct {
+	tr     *tar.Reader
+	closer io.Closer
+}
+
+func newTarArchive(r *bufio.Reader, closer io.Closer) (*tarArchive, error) {
+	var tr io.Reader = r
+	if signature, _ := r.Peek(2); bytes.Equal(signature, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			closer.Close()
+			return nil, err
+		}
+		tr = gr
+	}
+	return &tarArchive{tr: tar.NewReader(tr), closer: closer}, nil
+}
+
+func (a *tarArchive) next() (untarEntry, error) {
+	for {
+		header, err := a.tr.Next()
+		if err != nil {
+			return untarEntry{}, err
+		}
+
+		// the directories, the links and the special files are skipped.
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		return untarEntry{
+			name: header.Name,
+			size: header.Size,
+			open: func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(a.tr), nil
+			},
+			sequential: true,
+		}, nil
+	}
+}
+
+func (a *tarArchive) Close() error {
+	return a.closer.Close()
+}
+
+// zipArchive reads the regular files of a zip archive.
+type zipArchive struct {
+	files  []*zip.File
+	open   func(*zip.File) (io.ReadCloser, error)
+	closer io.Closer
+}
+
+func newLocalZipArchive(path string) (*zipArchive, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return &zipArchive{
+		files:  zr.File,
+		open:   (*zip.File).Open,
+		closer: zr,
+	}, nil
+}
+
+// newRemoteZipArchive reads the directory of a remote zip archive with
+// ranged reads, and the content of each file with a single ranged read.
+func newRemoteZipArchive(ctx context.Context, client *storage.S3, srcurl *url.URL, object *storage.Object) (*zipArchive, error) {
+	var etag string
+	if object.Etag != "" {
+		etag = `"` + object.Etag + `"`
+	}
+
+	r := &remoteReaderAt{
+		ctx:    ctx,
+		client: client,
+		url:    srcurl,
+		etag:   etag,
+		size:   object.Size,
+	}
+	zr, err := zip.NewReader(r, object.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	open := func(f *zip.File) (io.ReadCloser, error) {
+		offset, err := f.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+
+		rc, err := client.ReadRange(ctx, srcurl, offset, int64(f.CompressedSize64))
+		if err != nil {
+			return nil, err
+		}
+
+		var content io.Reader
+		switch f.Method {
+		case zip.Store:
+			content = rc
+		case zip.Deflate:
+			content = flate.NewReader(rc)
+		default:
+			rc.Close()
+			return nil, zip.ErrAlgorithm
+		}
+
+		return &zipChecksumReader{
+			r:         content,
+			closer:    rc,
+			hash:      crc32.NewIEEE(),
+			checksum:  f.CRC32,
+			remaining: int64(f.UncompressedSize64),
+		}, nil
+	}
+
+	return &zipArchive{
+		files:  zr.File,
+		open:   open,
+		closer: ioutil.NopCloser(nil),
+	}, nil
+}
+
+func (a *zipArchive) next() (untarEntry, error) {
+	for len(a.files) > 0 {
+		f := a.files[0]
+		a.files = a.files[1:]
+
+		// the directories and the links are skipped.
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		return untarEntry{
+			name: f.Name,
+			size: int64(f.UncompressedSize64),
+			open: func() (io.ReadCloser, error) {
+				return a.open(f)
+			},
+		}, nil
+	}
+	return untarEntry{}, io.EOF
+}
+
+func (a *zipArchive) Close() error {
+	return a.closer.Close()
+}
+
+// zipChecksumReader verifies the size and the checksum of the content of a
+// file in a zip archive once it is read.
+type zipChecksumReader struct {
+	r         io.Reader
+	closer    io.Closer
+	hash      hash.Hash32
+	checksum  uint32
+	remaining int64
+}
+
+func (z *zipChecksumReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.hash.Write(p[:n])
+	z.remaining -= int64(n)
+	if z.remaining < 0 {
+		return n, zip.ErrFormat
+	}
+
+	if err == io.EOF {
+		if z.remaining != 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		if z.checksum != 0 && z.hash.Sum32() != z.checksum {
+			return n, zip.ErrChecksum
+		}
+	}
+	return n, err
+}
+
+func (z *zipChecksumReader) Close() error {
+	return z.closer.Close()
+}
+
+// remoteReaderAt reads a remote object with ranged reads. The last block read
+// is cached, since the directories of zip archives are read in small pieces.
+type remoteReaderAt struct {
+	ctx    context.Context
+	client *storage.S3
+	url    *url.URL
+	etag   string
+	size   int64
+
+	mu          sync.Mutex
+	block       []byte
+	blockOffset int64
+}
+
+// remoteReadBlockSize is the minimum size of the ranged reads of
+// remoteReaderAt.
+const remoteReadBlockSize = megabytes
+
+func (r *remoteReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		pos := offset + int64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+
+		if pos < r.blockOffset || pos >= r.blockOffset+int64(len(r.block)) {
+			length := r.size - pos
+			if length > remoteReadBlockSize {
+				length = remoteReadBlockSize
+			}
+			block, err := r.client.ReadPart(r.ctx, r.url, r.etag, pos, length)
+			if err != nil {
+				return n, err
+			}
+			if len(block) == 0 {
+				return n, io.ErrUnexpectedEOF
+			}
+			r.block, r.blockOffset = block, pos
+		}
+
+		n += copy(p[n:], r.block[pos-r.blockOffset:])
+	}
+	return n, nil
+}
+
+// byteBudget limits the total size of the files buffered in memory.
+type byteBudget struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	available int64
+	limit     int64
+}
+
+func newByteBudget(limit int64) *byteBudget {
+	b := &byteBudget{available: limit, limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire waits until n bytes are available. The files larger than the limit
+// wait for all of the others to be released.
+func (b *byteBudget) acquire(n int64) {
+	if n > b.limit {
+		n = b.limit
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.available < n {
+		b.cond.Wait()
+	}
+	b.available -= n
+}
+
+// release releases n bytes acquired before.
+func (b *byteBudget) release(n int64) {
+	if n > b.limit {
+		n = b.limit
+	}
+
+	b.mu.Lock()
+	b.available += n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+func validateUntarCommand(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("expected source and destination arguments")
+	}
+
+	if src := c.Args().Get(0); src != "-" {
+		srcurl, err := url.New(src)
+		if err != nil {
+			return err
+		}
+		if srcurl.IsWildcard() {
+			return fmt.Errorf("source can not contain glob characters")
+		}
+		if srcurl.IsRemote() && (srcurl.IsBucket() || srcurl.IsPrefix()) {
+			return fmt.Errorf("source must be an archive or -")
+		}
+	}
+
+	dsturl, err := url.New(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	if !dsturl.IsRemote() {
+		return fmt.Errorf("destination must be a bucket or a prefix")
+	}
+	if dsturl.IsWildcard() {
+		return fmt.Errorf("destination can not contain glob characters")
+	}
+
+	if c.Int("concurrency") < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	if c.Int("part-size") < 1 {
+		return fmt.Errorf("part-size must be at least 1")
+	}
+	return nil
+}
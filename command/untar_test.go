@@ -0,0 +1,28 @@
+package command
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUntarKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "a.txt", untarKey("a.txt"))
+	assert.Equal(t, "dir/a.txt", untarKey("./dir/a.txt"))
+	assert.Equal(t, "a.txt", untarKey("../../a.txt"))
+	assert.Equal(t, "etc/passwd", untarKey("/etc/passwd"))
+	assert.Equal(t, "dir", untarKey("dir/"))
+}
+
+func TestIsZipArchive(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isZipArchive(bufio.NewReader(strings.NewReader("PK\x03\x04rest"))))
+	assert.True(t, isZipArchive(bufio.NewReader(strings.NewReader("PK\x05\x06"))))
+	assert.False(t, isZipArchive(bufio.NewReader(strings.NewReader("\x1f\x8b\x08"))))
+	assert.False(t, isZipArchive(bufio.NewReader(strings.NewReader("PK"))))
+}
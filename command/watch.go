@@ -0,0 +1,332 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/urfave/cli/v2"
+
+	errorpkg "github.com/peak/s5cmd/error"
+	"github.com/peak/s5cmd/log/stat"
+	"github.com/peak/s5cmd/parallel"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var watchHelpTemplate = `Name:
+	{{.HelpName}} - {{.Usage}}
+
+Usage:
+	{{.HelpName}} [options] source destination
+
+Options:
+	{{range .VisibleFlags}}{{.}}
+	{{end}}
+Examples:
+	1. Upload the files created or modified in a directory to a prefix until interrupted
+		 > s5cmd {{.HelpName}} logs/ s3://bucket/logs/
+
+	2. Upload the files once they are not modified for 10 seconds, excluding the temporary files
+		 > s5cmd {{.HelpName}} --debounce 10s --exclude "*.tmp" logs/ s3://bucket/logs/
+`
+
+// defaultWatchDebounce is the default duration a file must be left
+// unmodified before it is uploaded.
+const defaultWatchDebounce = time.Second
+
+func NewWatchCommand() *cli.Command {
+	return &cli.Command{
+		Name:               "watch",
+		HelpName:           "watch",
+		Usage:              "upload files as they are created or modified in a directory",
+		CustomHelpTemplate: watchHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "debounce",
+				Value: defaultWatchDebounce,
+				Usage: "upload the files once they are not modified for given duration, so that the files being written are not uploaded on each write",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "exclude files with given pattern, relative to the source directory",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			err := validateWatchCommand(c)
+			if err != nil {
+				printError(commandFromContext(c), c.Command.Name, err)
+			}
+			return err
+		},
+		Action: func(c *cli.Context) (err error) {
+			defer stat.Collect(c.Command.FullName(), &err)()
+
+			return Watch{
+				src:         c.Args().Get(0),
+				dst:         c.Args().Get(1),
+				op:          c.Command.Name,
+				fullCommand: commandFromContext(c),
+				c:           c,
+				debounce:    c.Duration("debounce"),
+				exclude:     c.StringSlice("exclude"),
+				numWorkers:  c.Int("numworkers"),
+			}.Run(c.Context)
+		},
+	}
+}
+
+// Watch holds watch operation flags and states.
+type Watch struct {
+	src         string
+	dst         string
+	op          string
+	fullCommand string
+	c           *cli.Context
+
+	// flags
+	debounce   time.Duration
+	exclude    []string
+	numWorkers int
+}
+
+// watchEvent is a file created or modified in a watched directory, or an
+// error of the watcher.
+type watchEvent struct {
+	path string
+	err  error
+}
+
+// Run uploads the files created or modified in the source directory until the
+// context is done. The files which are still being debounced when the context
+// is done are not uploaded.
+func (w Watch) Run(ctx context.Context) error {
+	excludePatterns, err := createExcludesFromWildcard(w.exclude)
+	if err != nil {
+		printError(w.fullCommand, w.op, err)
+		return err
+	}
+
+	events, err := watchDir(ctx, w.src)
+	if err != nil {
+		printError(w.fullCommand, w.op, err)
+		return err
+	}
+
+	dst := w.dst
+	if !strings.HasSuffix(dst, "/") {
+		dst += "/"
+	}
+
+	// the uploads run by a separate pool, since they run their own tasks in
+	// the shared one.
+	pm := parallel.New(w.numWorkers)
+	defer pm.Close()
+
+	waiter := parallel.NewWaiter()
+
+	var (
+		merrorWaiter  error
+		merrorObjects error
+		errDone       = make(chan bool)
+	)
+
+	go func() {
+		defer close(errDone)
+		for err := range waiter.Err() {
+			// the uploads print their own errors.
+			if errorpkg.IsCancelation(err) {
+				continue
+			}
+			merrorWaiter = multierror.Append(merrorWaiter, err)
+		}
+	}()
+
+	pending := newDebouncer(w.debounce)
+
+	// uploading holds the files being uploaded, their later modifications
+	// are uploaded once their uploads are completed.
+	var (
+		mu        sync.Mutex
+		uploading = map[string]bool{}
+	)
+
+	upload := func(path string) {
+		// the files removed before they are uploaded, e.g. the temporary
+		// files renamed after they are written, are skipped.
+		if !fileExists(path) {
+			return
+		}
+
+		rel, err := filepath.Rel(w.src, path)
+		if err != nil {
+			printError(w.fullCommand, w.op, err)
+			return
+		}
+
+		mu.Lock()
+		uploading[path] = true
+		mu.Unlock()
+
+		fields := []string{"cp", "--raw", path, dst + filepath.ToSlash(rel)}
+		task := func() error {
+			defer func() {
+				mu.Lock()
+				delete(uploading, path)
+				mu.Unlock()
+			}()
+			err := runCommandLine(w.c, fields)
+			// the upload fails if the file is removed while it is
+			// uploaded, which doesn't fail the command.
+			if err != nil && !fileExists(path) {
+				return nil
+			}
+			return err
+		}
+		pm.Run(task, waiter)
+	}
+
+	ticker := time.NewTicker(watchTick(w.debounce))
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				break loop
+			}
+
+			if err := event.err; err != nil {
+				if errorpkg.IsCancelation(err) {
+					continue
+				}
+				merrorObjects = multierror.Append(merrorObjects, err)
+				printError(w.fullCommand, w.op, err)
+				continue
+			}
+
+			rel, err := filepath.Rel(w.src, event.path)
+			if err != nil || isURLExcluded(excludePatterns, filepath.ToSlash(rel), "") {
+				continue
+			}
+			pending.add(event.path, time.Now())
+		case now := <-ticker.C:
+			for _, path := range pending.due(now) {
+				mu.Lock()
+				busy := uploading[path]
+				mu.Unlock()
+
+				if busy {
+					pending.add(path, now)
+					continue
+				}
+				upload(path)
+			}
+		}
+	}
+
+	waiter.Wait()
+	<-errDone
+
+	return multierror.Append(merrorWaiter, merrorObjects).ErrorOrNil()
+}
+
+// fileExists reports whether the file at the given path still exists.
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !os.IsNotExist(err)
+}
+
+// watchTick returns the interval the debounced files are checked at.
+func watchTick(debounce time.Duration) time.Duration {
+	tick := debounce / 4
+	if tick < 10*time.Millisecond {
+		tick = 10 * time.Millisecond
+	}
+	if tick > time.Second {
+		tick = time.Second
+	}
+	return tick
+}
+
+// debouncer holds the files until they are left unmodified for the delay.
+type debouncer struct {
+	delay time.Duration
+	// pending are the times of the last modifications of the files.
+	pending map[string]time.Time
+}
+
+func newDebouncer(delay time.Duration) *debouncer {
+	return &debouncer{
+		delay:   delay,
+		pending: map[string]time.Time{},
+	}
+}
+
+// add records the modification of the file at the given time.
+func (d *debouncer) add(path string, now time.Time) {
+	d.pending[path] = now
+}
+
+// due removes and returns the files which are not modified for the delay at
+// the given time, in lexicographical order.
+func (d *debouncer) due(now time.Time) []string {
+	var paths []string
+	for path, modified := range d.pending {
+		if now.Sub(modified) >= d.delay {
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range paths {
+		delete(d.pending, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func validateWatchCommand(c *cli.Context) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("expected source and destination arguments")
+	}
+
+	srcurl, err := url.New(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	dsturl, err := url.New(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	if srcurl.IsRemote() {
+		return fmt.Errorf("source must be a local directory")
+	}
+	if srcurl.IsWildcard() {
+		return fmt.Errorf("source can not contain glob characters")
+	}
+	info, err := os.Stat(srcurl.Absolute())
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("source must be a local directory")
+	}
+
+	if !dsturl.IsRemote() {
+		return fmt.Errorf("destination must be a bucket or a prefix")
+	}
+	if dsturl.IsWildcard() {
+		return fmt.Errorf("destination can not contain glob characters")
+	}
+
+	if c.Duration("debounce") < 0 {
+		return fmt.Errorf("debounce can not be negative")
+	}
+	return nil
+}
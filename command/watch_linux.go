@@ -0,0 +1,170 @@
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask is the mask of the events of the watched directories. Files
+// are reported on each write, as well as when they are closed or moved into
+// the directories, since the files being appended to, e.g. logs, may never be
+// closed.
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_TO | syscall.IN_ONLYDIR | syscall.IN_DONT_FOLLOW
+
+// inotifyWatcher watches the directories of a tree with inotify. The
+// directories created in the tree are watched as they are created.
+type inotifyWatcher struct {
+	ctx  context.Context
+	root string
+	file *os.File
+	fd   int
+	// dirs are the paths of the watched directories by their watch
+	// descriptors.
+	dirs map[int32]string
+	ch   chan watchEvent
+}
+
+// watchDir sends the paths of the files created or modified under the
+// directory to the returned channel, until the context is done.
+func watchDir(ctx context.Context, dir string) (<-chan watchEvent, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	w := &inotifyWatcher{
+		ctx:  ctx,
+		root: dir,
+		// the reads of non-blocking files are interrupted when they are
+		// closed.
+		file: os.NewFile(uintptr(fd), "inotify"),
+		fd:   fd,
+		dirs: map[int32]string{},
+		ch:   make(chan watchEvent),
+	}
+
+	if err := w.addTree(dir, false); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		w.file.Close()
+	}()
+	go w.read()
+
+	return w.ch, nil
+}
+
+// addTree watches the directory and its subdirectories. The files in the
+// tree are sent if emit is set, e.g. the files created in a new directory
+// before it is watched.
+func (w *inotifyWatcher) addTree(root string, emit bool) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// the files removed during the walk are skipped, as well as
+			// the new directories removed before they are watched.
+			if path != w.root && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+			if err == syscall.ENOENT && path != w.root {
+				return nil
+			}
+			if err != nil {
+				return os.NewSyscallError("inotify_add_watch", err)
+			}
+			w.dirs[int32(wd)] = path
+			return nil
+		}
+
+		if emit && info.Mode().IsRegular() {
+			w.send(watchEvent{path: path})
+		}
+		return nil
+	})
+}
+
+func (w *inotifyWatcher) send(event watchEvent) {
+	select {
+	case w.ch <- event:
+	case <-w.ctx.Done():
+	}
+}
+
+// read reads the events until the watcher is closed.
+func (w *inotifyWatcher) read() {
+	defer close(w.ch)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if w.ctx.Err() == nil {
+				w.send(watchEvent{err: err})
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + syscall.SizeofInotifyEvent
+			end := start + int(event.Len)
+			name := strings.TrimRight(string(buf[start:end]), "\x00")
+			offset = end
+
+			w.handle(event.Wd, event.Mask, name)
+		}
+	}
+}
+
+// handle sends the files of the event, and watches the directories created
+// in the tree.
+func (w *inotifyWatcher) handle(wd int32, mask uint32, name string) {
+	// the events are lost if the queue overflows, so all of the files of the
+	// tree are sent.
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		if err := w.addTree(w.root, true); err != nil {
+			w.send(watchEvent{err: err})
+		}
+		return
+	}
+
+	dir, ok := w.dirs[wd]
+	if !ok {
+		return
+	}
+
+	// the watch is removed, e.g. the directory is deleted.
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+		return
+	}
+
+	path := filepath.Join(dir, name)
+	if mask&syscall.IN_ISDIR != 0 {
+		if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+			if err := w.addTree(path, true); err != nil {
+				w.send(watchEvent{err: err})
+			}
+		}
+		return
+	}
+
+	if mask&(syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO) != 0 {
+		w.send(watchEvent{path: path})
+	}
+}
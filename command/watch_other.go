@@ -0,0 +1,96 @@
+//go:build !linux
+// +build !linux
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// watchPollInterval is the interval the watched directories are scanned at.
+const watchPollInterval = time.Second
+
+// fileState is the state of a file compared between the scans.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// watchDir sends the paths of the files created or modified under the
+// directory to the returned channel, until the context is done. The
+// directory is scanned periodically, file change notifications are only used
+// on Linux.
+func watchDir(ctx context.Context, dir string) (<-chan watchEvent, error) {
+	files, err := scanDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan watchEvent)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+
+		send := func(event watchEvent) bool {
+			select {
+			case ch <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, err := scanDir(dir)
+			if err != nil {
+				if !send(watchEvent{err: err}) {
+					return
+				}
+				continue
+			}
+
+			for path, state := range current {
+				if previous, ok := files[path]; ok && previous.size == state.size && previous.modTime.Equal(state.modTime) {
+					continue
+				}
+				if !send(watchEvent{path: path}) {
+					return
+				}
+			}
+			files = current
+		}
+	}()
+
+	return ch, nil
+}
+
+// scanDir returns the states of the regular files in the tree of the
+// directory.
+func scanDir(root string) (map[string]fileState, error) {
+	files := map[string]fileState{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// the files removed during the walk are skipped.
+			if path != root && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		}
+		return nil
+	})
+	return files, err
+}
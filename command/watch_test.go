@@ -0,0 +1,91 @@
+package command
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebouncerDue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	d := newDebouncer(time.Second)
+	d.add("b.log", now)
+	d.add("a.log", now)
+	d.add("c.log", now.Add(500*time.Millisecond))
+
+	assert.Empty(t, d.due(now.Add(999*time.Millisecond)))
+	assert.Equal(t, []string{"a.log", "b.log"}, d.due(now.Add(time.Second)))
+
+	// the modifications postpone the files.
+	d.add("c.log", now.Add(1200*time.Millisecond))
+	assert.Empty(t, d.due(now.Add(1500*time.Millisecond)))
+	assert.Equal(t, []string{"c.log"}, d.due(now.Add(2200*time.Millisecond)))
+	assert.Empty(t, d.due(now.Add(time.Hour)))
+}
+
+func TestWatchTick(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 10*time.Millisecond, watchTick(0))
+	assert.Equal(t, 250*time.Millisecond, watchTick(time.Second))
+	assert.Equal(t, time.Second, watchTick(time.Minute))
+}
+
+func TestWatchDir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := watchDir(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// waits for the event of the given file, the files may be reported more
+	// than once.
+	expect := func(path string) {
+		t.Helper()
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case event := <-events:
+				assert.NoError(t, event.err)
+				if event.path == path {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no event of %v", path)
+			}
+		}
+	}
+
+	file := filepath.Join(dir, "a.log")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("content"), 0644))
+	expect(file)
+
+	// the files of the new directories are reported.
+	nested := filepath.Join(dir, "nested", "deeper")
+	assert.NoError(t, os.MkdirAll(nested, 0755))
+	file = filepath.Join(nested, "b.log")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("content"), 0644))
+	expect(file)
+
+	cancel()
+	for range events {
+	}
+}
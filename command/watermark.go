@@ -0,0 +1,126 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// syncState is the state recorded by a successful run of sync with
+// --source-newer-than flag. It holds the newest modification time of the
+// source objects and the sizes of the objects known to be synced, keyed by
+// their URLs.
+type syncState struct {
+	watermark time.Time
+	objects   map[string]int64
+}
+
+// isUnchanged reports whether the object is synced by a previous run and it
+// is not modified since then. The objects which are not recorded are never
+// unchanged, even if they are older than the watermark, e.g. files copied
+// with their modification times preserved.
+func (st *syncState) isUnchanged(object *storage.Object) bool {
+	if st == nil || st.watermark.IsZero() || object.ModTime == nil {
+		return false
+	}
+	size, ok := st.objects[syncStateKey(object.URL)]
+	return ok && size == object.Size && !object.ModTime.After(st.watermark)
+}
+
+// syncStateKey returns the key of the object in the sync state. Local paths
+// are made absolute, so that the state doesn't depend on the working
+// directory.
+func syncStateKey(u *url.URL) string {
+	if u.IsRemote() {
+		return u.String()
+	}
+	if abs, err := filepath.Abs(u.Path); err == nil {
+		return abs
+	}
+	return u.Path
+}
+
+// readSyncState reads the sync state recorded by a previous run from the
+// given file. The first line of the file is the watermark, and the others
+// are the sizes and the quoted keys of the synced objects. A missing file
+// yields an empty state, so that the first run compares every object with
+// the destination.
+func readSyncState(path string) (*syncState, error) {
+	state := &syncState{objects: map[string]int64{}}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		content := strings.TrimSpace(scanner.Text())
+		if content == "" {
+			continue
+		}
+
+		if line == 1 {
+			state.watermark, err = time.Parse(time.RFC3339Nano, content)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		fields := strings.SplitN(content, "\t", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%v:%d: invalid sync state line", path, line)
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%v:%d: invalid size: %v", path, line, err)
+		}
+		key, err := strconv.Unquote(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%v:%d: invalid key: %v", path, line, err)
+		}
+		state.objects[key] = size
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// writeSyncState atomically records the given sync state to the given file.
+func writeSyncState(path string, state *syncState) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, state.watermark.UTC().Format(time.RFC3339Nano))
+	for key, size := range state.objects {
+		fmt.Fprintf(w, "%d\t%s\n", size, strconv.Quote(key))
+	}
+
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
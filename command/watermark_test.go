@@ -0,0 +1,64 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/peak/s5cmd/storage"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestSyncState(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "s5cmd-sync-state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state")
+
+	// a missing file is an empty state.
+	state, err := readSyncState(path)
+	assert.NoError(t, err)
+	assert.True(t, state.watermark.IsZero())
+	assert.Empty(t, state.objects)
+
+	watermark := time.Date(2022, 1, 2, 3, 4, 5, 6, time.UTC)
+	state = &syncState{
+		watermark: watermark,
+		objects: map[string]int64{
+			"s3://bucket/a.txt":        10,
+			"s3://bucket/tab\tkey.txt": 20,
+		},
+	}
+	assert.NoError(t, writeSyncState(path, state))
+
+	read, err := readSyncState(path)
+	assert.NoError(t, err)
+	assert.True(t, watermark.Equal(read.watermark))
+	assert.Equal(t, state.objects, read.objects)
+
+	newObject := func(key string, size int64, modTime time.Time) *storage.Object {
+		u, err := url.New(key)
+		assert.NoError(t, err)
+		return &storage.Object{URL: u, Size: size, ModTime: &modTime}
+	}
+
+	assert.True(t, read.isUnchanged(newObject("s3://bucket/a.txt", 10, watermark)))
+	assert.False(t, read.isUnchanged(newObject("s3://bucket/a.txt", 10, watermark.Add(time.Second))))
+	assert.False(t, read.isUnchanged(newObject("s3://bucket/a.txt", 11, watermark)))
+	// the objects which are not recorded are synced even if they are older.
+	assert.False(t, read.isUnchanged(newObject("s3://bucket/b.txt", 10, watermark.Add(-time.Hour))))
+
+	// the files with only a watermark don't record any object.
+	assert.NoError(t, ioutil.WriteFile(path, []byte(watermark.Format(time.RFC3339Nano)+"\n"), 0644))
+	read, err = readSyncState(path)
+	assert.NoError(t, err)
+	assert.True(t, watermark.Equal(read.watermark))
+	assert.Empty(t, read.objects)
+}
@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"testing"
+
+	"gotest.tools/v3/icmd"
+)
+
+// --dry-run acl set --canned public-read s3://bucket/*
+func TestACLSetDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+
+	cmd := s5cmd("--dry-run", "acl", "set", "--canned", "public-read", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`acl set s3://%v/testfile1.txt`, bucket),
+		1: equals(`acl set s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+}
+
+func TestACLFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			cmd:      []string{"acl", "get", "file.txt"},
+			expected: `ERROR "acl get file.txt": source must be remote`,
+		},
+		{
+			name:     "bucket source",
+			cmd:      []string{"acl", "get", "s3://bucket"},
+			expected: `ERROR "acl get s3://bucket": s3 bucket/prefix cannot be used for acl operations (forgot wildcard character?)`,
+		},
+		{
+			name:     "set without canned acl",
+			cmd:      []string{"acl", "set", "s3://bucket/*"},
+			expected: `ERROR "acl set s3://bucket/*": expected a canned ACL with --canned`,
+		},
+		{
+			name:     "set invalid canned acl",
+			cmd:      []string{"acl", "set", "--canned", "public", "s3://bucket/*"},
+			expected: `ERROR "acl set --canned=public s3://bucket/*": canned ACL must be one of private, public-read, public-read-write, authenticated-read, aws-exec-read, bucket-owner-read, bucket-owner-full-control`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
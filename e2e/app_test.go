@@ -2,11 +2,16 @@ package e2e
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
 )
 
@@ -69,6 +74,72 @@ func TestAppRetryCount(t *testing.T) {
 	}
 }
 
+func TestAppRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		args             []string
+		expectedError    string
+		expectedExitCode int
+	}{
+		{
+			name:             "valid_policy",
+			args:             []string{"--retry-policy", "throttling=20,server=3", "--retry-max-delay", "30s"},
+			expectedExitCode: 0,
+		},
+		{
+			name:             "unknown_class",
+			args:             []string{"--retry-policy", "network=3"},
+			expectedError:    `ERROR unknown retry class "network", expected one of [throttling kms-throttling server connection timeout]`,
+			expectedExitCode: 1,
+		},
+		{
+			name:             "negative_max_delay",
+			args:             []string{"--retry-max-delay", "-1s"},
+			expectedError:    `ERROR retry max delay cannot be a negative value`,
+			expectedExitCode: 1,
+		},
+		{
+			name:             "kms_rate",
+			args:             []string{"--retry-policy", "kms-throttling=30", "--kms-rate", "50"},
+			expectedExitCode: 0,
+		},
+		{
+			name:             "negative_kms_rate",
+			args:             []string{"--kms-rate", "-1"},
+			expectedError:    `ERROR kms rate cannot be a negative value`,
+			expectedExitCode: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: tc.expectedExitCode})
+
+			if tc.expectedError == "" {
+				if result.Stderr() != "" {
+					t.Fatalf("expected no error, got: %q", result.Stderr())
+				}
+				return
+			}
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals("%v", tc.expectedError),
+			})
+		})
+	}
+}
+
 // Checks if the stats are written at the end of each log level output.
 func TestAppDashStat(t *testing.T) {
 	t.Parallel()
@@ -120,7 +191,7 @@ func TestAppDashStat(t *testing.T) {
 
 			result.Assert(t, icmd.Success)
 
-			out := result.Stdout()
+			out := result.Stderr()
 			tsv := fmt.Sprintf("%s\t%s\t%s\t%s\t", "Operation", "Total", "Error", "Success")
 
 			assert.Assert(t, strings.Contains(out, tsv))
@@ -129,6 +200,71 @@ func TestAppDashStat(t *testing.T) {
 	}
 }
 
+func TestAppDiagnosticsStdout(t *testing.T) {
+	t.Parallel()
+
+	const (
+		bucket      = "bucket"
+		fileContent = "this is a file content"
+		src         = "file1.txt"
+	)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, src, fileContent)
+
+	srcPath := fmt.Sprintf("s3://%v/%v", bucket, src)
+	cmd := s5cmd("--stat", "--diagnostics", "stdout", "cp", srcPath, ".")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	tsv := fmt.Sprintf("%s\t%s\t%s\t%s\t", "Operation", "Total", "Error", "Success")
+	assert.Assert(t, strings.Contains(result.Stdout(), tsv))
+	assert.Assert(t, !strings.Contains(result.Stderr(), tsv))
+}
+
+func TestAppSharedConfigWithInvalidTransferSettings(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("config", "[default]\ns3 =\n  multipart_chunksize = 1MB\n"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("ls")
+	cmd.Env = append(cmd.Env, "AWS_SDK_LOAD_CONFIG=1", "AWS_CONFIG_FILE="+workdir.Join("config"), "AWS_PROFILE=default")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`WARNING ignoring transfer settings: `),
+	})
+	assert.Assert(t, strings.Contains(result.Stderr(), `invalid multipart_chunksize "1MB", must be at least 5MB`))
+}
+
+func TestAppSharedConfigDisabledWithInvalidTransferSettings(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("config", "[default]\ns3 =\n  multipart_chunksize = 1MB\n"))
+	defer workdir.Remove()
+
+	// the config file is not read unless AWS_SDK_LOAD_CONFIG is set.
+	cmd := s5cmd("ls")
+	cmd.Env = append(cmd.Env, "AWS_CONFIG_FILE="+workdir.Join("config"), "AWS_PROFILE=default")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+}
+
 func TestAppUnknownCommand(t *testing.T) {
 	t.Parallel()
 
@@ -179,3 +315,507 @@ func TestInvalidLoglevel(t *testing.T) {
 		1: equals("See 's5cmd --help' for usage"),
 	})
 }
+
+func TestAppStreamingSignatureWithNoSignRequest(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--streaming-signature", "--no-sign-request", "ls")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`streaming-signature can not be used with no-sign-request`),
+	})
+}
+
+func TestAppUseAccelerateEndpointWithEndpointURL(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--use-accelerate-endpoint", "ls")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`use-accelerate-endpoint can not be used with endpoint-url`),
+	})
+}
+
+func TestAppLogSample(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	var files []fs.PathOp
+	for i := 0; i < 10; i++ {
+		files = append(files, fs.WithFile(fmt.Sprintf("file%d.txt", i), "content"))
+	}
+	workdir := fs.NewDir(t, bucket, files...)
+	defer workdir.Remove()
+
+	cmd := s5cmd("--log-sample", "20%", "cp", "*.txt", fmt.Sprintf("s3://%v/", bucket))
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	// only a sample of the successful operations is logged.
+	lines := strings.Split(strings.TrimSpace(result.Stdout()), "\n")
+	assert.Equal(t, len(lines), 2)
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals("logged 2 of 10 successful operations (20%% sample): cp=10"),
+	})
+}
+
+func TestAppLogSampleInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--log-sample", "0%")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR log sample "0%%" must be a percentage or a fraction between 0 and 1`),
+	})
+}
+
+func TestAppConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	var files []fs.PathOp
+	for i := 0; i < 10; i++ {
+		files = append(files, fs.WithFile(fmt.Sprintf("file%d.txt", i), "content"))
+	}
+	workdir := fs.NewDir(t, bucket, files...)
+	defer workdir.Remove()
+
+	cmd := s5cmd("--concurrency-limit", fmt.Sprintf("s3://%v=1", bucket), "cp", "*.txt", fmt.Sprintf("s3://%v/", bucket))
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	lines := strings.Split(strings.TrimSpace(result.Stdout()), "\n")
+	assert.Equal(t, len(lines), 10)
+}
+
+func TestAppConcurrencyLimitInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--concurrency-limit", "s3://bucket=many")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR concurrency limit of "s3://bucket" must be a positive integer`),
+	})
+}
+
+func TestAppListerCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("lister command uses a POSIX shell")
+	}
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "dir/a.txt", "content a")
+	putFile(t, s3client, bucket, "dir/b.txt", "content b")
+	putFile(t, s3client, bucket, "dir/c.csv", "content c")
+
+	// b.txt is not in the catalog, and the catalog has an object of another
+	// prefix.
+	catalog := fmt.Sprintf("s3://%v/dir/a.txt\ndir/c.csv\nother/d.txt\n", bucket)
+	workdir := fs.NewDir(t, bucket, fs.WithFile("catalog", catalog))
+	defer workdir.Remove()
+
+	cmd := s5cmd("--lister-cmd", "cat catalog", "cp", fmt.Sprintf("s3://%v/dir/*", bucket), "out/")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/dir/a.txt out/a.txt`, bucket),
+		1: equals(`cp s3://%v/dir/c.csv out/c.csv`, bucket),
+	}, sortInput(true))
+
+	expected := fs.Expected(t,
+		fs.WithFile("catalog", catalog),
+		fs.WithDir("out",
+			fs.WithFile("a.txt", "content a"),
+			fs.WithFile("c.csv", "content c"),
+		),
+	)
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+func TestAppListerCommandFail(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("lister command uses a POSIX shell")
+	}
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("--lister-cmd", "exit 2", "ls", fmt.Sprintf("s3://%v/*", bucket))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls s3://%v/*": lister command: exit status 2`, bucket),
+	})
+}
+
+// --lister-cmd is not used by the commands which act on the bucket.
+func TestAppListerCommandNotUsedByDelete(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("lister command uses a POSIX shell")
+	}
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	cmd := s5cmd("--lister-cmd", "exit 2", "rm", fmt.Sprintf("s3://%v/*", bucket))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/file.txt`, bucket),
+	})
+
+	err := ensureS3Object(s3client, bucket, "file.txt", "content")
+	assertError(t, err, errS3NoSuchKey)
+}
+
+func TestAppPreconnect(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", "content"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("--preconnect", "8", "cp", "file.txt", fmt.Sprintf("s3://%v/", bucket))
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp file.txt s3://%v/file.txt`, bucket),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file.txt", "content"))
+}
+
+func TestAppIOUring(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	// the file is uploaded and downloaded in multiple parts.
+	content := strings.Repeat("0123456789abcdef", 7*1024*1024/16)
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", content))
+	defer workdir.Remove()
+
+	cmd := s5cmd("--io-uring", "cp", "-p", "5", "file.txt", fmt.Sprintf("s3://%v/", bucket))
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file.txt", content))
+
+	cmd = s5cmd("--io-uring", "cp", "-p", "5", fmt.Sprintf("s3://%v/file.txt", bucket), "copy.txt")
+	result = icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	expected := fs.Expected(t, fs.WithFile("file.txt", content), fs.WithFile("copy.txt", content))
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+func TestAppBandwidthSchedule(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	content := strings.Repeat("0123456789abcdef", 64*1024/16)
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", content))
+	defer workdir.Remove()
+
+	// the cap applies to the whole day, the upload takes at least half a
+	// second.
+	start := time.Now()
+	cmd := s5cmd("--bandwidth-schedule", "00:00-24:00=128KB", "cp", "file.txt", fmt.Sprintf("s3://%v/", bucket))
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+	assert.Assert(t, time.Since(start) >= 400*time.Millisecond)
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file.txt", content))
+}
+
+func TestAppBandwidthScheduleInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--bandwidth-schedule", "09:00-18:00=fast")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR bandwidth of "09:00-18:00=fast": unknown size unit "FAST"`),
+	})
+}
+
+func TestAppPreconnectInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--preconnect", "-1")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR preconnect cannot be a negative value`),
+	})
+}
+
+func TestAppFailoverInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--failover", "s3://replica")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR invalid failover "s3://replica", expected [s3://<bucket>=]s3://<replica> or [s3://<bucket>=]<endpoint>[/<replica>]`),
+	})
+}
+
+// --failover s3://bucket=s3://replica ls s3://bucket/*
+func TestAppFailoverHealthyBucket(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	cmd := s5cmd("--failover", fmt.Sprintf("s3://%v=s3://replica", bucket), "cat", fmt.Sprintf("s3://%v/file.txt", bucket))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("content"),
+	})
+}
+
+// --simulate cp s3://bucket/* dir/
+func TestAppSimulate(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file1.txt", "content")
+	putFile(t, s3client, bucket, "file2.txt", "content")
+
+	cmd := s5cmd("--json", "--simulate", "cp", "s3://"+bucket+"/*", "dir/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the region of the bucket is detected with HeadBucket.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: contains(`"source":"s3://%v/file1.txt"`, bucket),
+		1: contains(`"source":"s3://%v/file2.txt"`, bucket),
+		2: json(`
+			{
+				"requests": {"GetObject":2,"HeadBucket":1,"ListObjectsV2":1},
+				"total_requests": 4,
+				"bytes": 14,
+				"workers": 256,
+				"estimated_cost": 0.000006,
+				"estimated_duration_seconds": 0
+			}
+		`),
+	}, sortInput(true))
+
+	// nothing is downloaded.
+	_, err := os.Stat(filepath.Join(cmd.Dir, "dir"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+// --simulate cp --range bytes=-4 s3://bucket/file.txt .
+func TestAppSimulateSingleObject(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "content")
+
+	// the size of the source is fetched with HeadObject.
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "download",
+			args:     []string{"s3://" + bucket + "/file.txt", "."},
+			expected: `"requests":{"GetObject":1,"HeadBucket":1,"HeadObject":1},"total_requests":3,"bytes":7,`,
+		},
+		{
+			name:     "download byte range",
+			args:     []string{"--range", "bytes=-4", "s3://" + bucket + "/file.txt", "."},
+			expected: `"requests":{"GetObject":1,"HeadBucket":1,"HeadObject":1},"total_requests":3,"bytes":4,`,
+		},
+		{
+			name:     "copy",
+			args:     []string{"s3://" + bucket + "/file.txt", "s3://" + bucket + "/copy.txt"},
+			expected: `"requests":{"CopyObject":1,"HeadBucket":1,"HeadObject":1},"total_requests":3,"bytes":0,`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"--json", "--simulate", "cp"}, tc.args...)
+			result := icmd.RunCmd(s5cmd(args...))
+
+			result.Assert(t, icmd.Success)
+
+			assertLines(t, result.Stdout(), map[int]compareFunc{
+				0: contains(`"source":"s3://%v/file.txt"`, bucket),
+				1: contains(tc.expected),
+			})
+		})
+	}
+}
+
+// --simulate --simulate-throughput 1KB cp dir/ s3://bucket/
+func TestAppSimulateUpload(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("file.txt", strings.Repeat("a", 4096)))
+	defer workdir.Remove()
+
+	cmd := s5cmd("--simulate", "--simulate-throughput", "1KB", "cp", workdir.Path()+"/", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`s3://%v/file.txt`, bucket),
+		1: equals(""),
+		2: match(`^API\s+Requests\s+Cost\s*$`),
+		3: match(`^HeadBucket\s+1\s+\$0\.000000\s*$`),
+		4: match(`^PutObject\s+1\s+\$0\.000005\s*$`),
+		5: match(`^Total\s+2\s+\$0\.000005\s*$`),
+		6: equals(`estimated duration 4s with 256 workers, transferring 4.0K bytes`),
+	})
+
+	// nothing is uploaded.
+	err := ensureS3Object(s3client, bucket, "file.txt", strings.Repeat("a", 4096))
+	assertError(t, err, errS3NoSuchKey)
+}
+
+func TestAppSimulateInvalidThroughput(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("--simulate", "--simulate-throughput", "0")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR simulate-throughput must be positive`),
+	})
+}
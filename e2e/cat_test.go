@@ -68,6 +68,62 @@ func TestCatS3Object(t *testing.T) {
 
 }
 
+func TestCatS3ObjectWithRange(t *testing.T) {
+	t.Parallel()
+
+	const (
+		bucket   = "bucket"
+		filename = "file.txt"
+		content  = "abcdefghij"
+	)
+
+	src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+
+	testcases := []struct {
+		name      string
+		byteRange string
+		expected  string
+	}{
+		{
+			name:      "start and end",
+			byteRange: "bytes=2-4",
+			expected:  "cde",
+		},
+		{
+			name:      "from offset",
+			byteRange: "7-",
+			expected:  "hij",
+		},
+		{
+			name:      "suffix",
+			byteRange: "bytes=-2",
+			expected:  "ij",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s3client, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			createBucket(t, s3client, bucket)
+			putFile(t, s3client, bucket, filename, content)
+
+			cmd := s5cmd("cat", "--range", tc.byteRange, src)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Success)
+
+			assertLines(t, result.Stdout(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
 func TestCatS3ObjectFail(t *testing.T) {
 	t.Parallel()
 
@@ -134,6 +190,41 @@ func TestCatS3ObjectFail(t *testing.T) {
 				0: contains(`ERROR "cat s3://bucket": remote source must be an object`),
 			},
 		},
+		{
+			name: "cat remote object with sse-c without key",
+			cmd: []string{
+				"cat",
+				"--sse-c",
+				src,
+			},
+			expected: map[int]compareFunc{
+				0: contains(`sse-c requires a key from sse-c-key-file flag or S5CMD_SSE_C_KEY environment variable`),
+			},
+		},
+		{
+			name: "cat remote object with sse-c-key-file without sse-c",
+			cmd: []string{
+				"cat",
+				"--sse-c-key-file",
+				"key.bin",
+				src,
+			},
+			expected: map[int]compareFunc{
+				0: contains(`sse-c-key-file requires sse-c flag`),
+			},
+		},
+		{
+			name: "cat remote object with invalid range",
+			cmd: []string{
+				"cat",
+				"--range",
+				"bytes=10-5",
+				src,
+			},
+			expected: map[int]compareFunc{
+				0: contains(`end of range "bytes=10-5" is before its start`),
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -221,3 +312,34 @@ func getSequentialFileContent() (string, map[int]compareFunc) {
 
 	return sb.String(), expectedLines
 }
+
+func TestCatS3ObjectInParallelParts(t *testing.T) {
+	t.Parallel()
+
+	const (
+		bucket   = "bucket"
+		filename = "file.bin"
+	)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	// 3.5 MiB of distinct lines, so that misordered parts are detected.
+	var sb strings.Builder
+	for i := 0; sb.Len() < 7*1024*1024/2; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	content := sb.String()
+	putFile(t, s3client, bucket, filename, content)
+
+	cmd := s5cmd("cat", "--concurrency", "2", "--part-size", "1", fmt.Sprintf("s3://%v/%v", bucket, filename))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	if result.Stdout() != content {
+		t.Fatalf("expected %d bytes of content, got %d different bytes", len(content), len(result.Stdout()))
+	}
+}
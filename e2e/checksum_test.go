@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"testing"
+
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// checksum s3://bucket/*
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "hello")
+	putFile(t, s3client, bucket, "testfile2.txt", "world")
+
+	cmd := s5cmd("checksum", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`5d41402abc4b2a76b9719d911017c592 - s3://%v/testfile1.txt`, bucket),
+		1: equals(`7d793037a0760186574b0282f2f435e7 - s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+}
+
+// --json checksum s3://bucket/object
+func TestChecksumJSON(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "hello")
+
+	cmd := s5cmd("--json", "checksum", "s3://"+bucket+"/testfile1.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	jsonText := `
+		{
+			"source": "s3://%v/testfile1.txt",
+			"etag": "5d41402abc4b2a76b9719d911017c592",
+			"size": 5
+		}
+	`
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: json(jsonText, bucket),
+	}, jsonCheck(true))
+}
+
+// checksum --compare file.txt s3://bucket/object
+func TestChecksumCompare(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "hello")
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("same.txt", "hello"), fs.WithFile("other.txt", "hallo"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("checksum", "--compare", "same.txt", "s3://"+bucket+"/testfile1.txt")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`s3://%v/testfile1.txt matches same.txt`, bucket),
+	})
+
+	cmd = s5cmd("checksum", "--compare", "other.txt", "s3://"+bucket+"/testfile1.txt")
+	result = icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "checksum --compare=other.txt s3://%v/testfile1.txt": copy mismatch: ETag of the object is "5d41402abc4b2a76b9719d911017c592", expected "598d4c200461b81522a3328565c25f7c"`, bucket),
+	})
+}
+
+func TestChecksumFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			cmd:      []string{"checksum", "file.txt"},
+			expected: `ERROR "checksum file.txt": source must be remote`,
+		},
+		{
+			name:     "bucket source",
+			cmd:      []string{"checksum", "s3://bucket"},
+			expected: `ERROR "checksum s3://bucket": s3 bucket/prefix cannot be used for checksum operations (forgot wildcard character?)`,
+		},
+		{
+			name:     "compare with wildcard",
+			cmd:      []string{"checksum", "--compare", "file.txt", "s3://bucket/*"},
+			expected: `ERROR "checksum --compare=file.txt s3://bucket/*": compare does not support wildcards`,
+		},
+		{
+			name:     "compare with multiple objects",
+			cmd:      []string{"checksum", "--compare", "file.txt", "s3://bucket/a", "s3://bucket/b"},
+			expected: `ERROR "checksum --compare=file.txt s3://bucket/a s3://bucket/b": compare expects only 1 object`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
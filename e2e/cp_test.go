@@ -23,13 +23,21 @@
 package e2e
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
@@ -370,6 +378,94 @@ func TestCopyMultipleFlatNestedS3ObjectsToLocalWithPartialMatching(t *testing.T)
 	}
 }
 
+// cp --flatten --flatten-collision <policy> s3://bucket/*/*.csv .
+func TestCopyMultipleFlatS3ObjectsToLocalWithCollision(t *testing.T) {
+	t.Parallel()
+
+	// expected lines are formatted with the bucket name as %[1]v.
+	testcases := []struct {
+		policy         string
+		expectedFiles  []fs.PathOp
+		expectedStdout []string
+		expectedStderr []string
+		expectedExit   int
+	}{
+		{
+			policy: "rename",
+			expectedFiles: []fs.PathOp{
+				fs.WithFile("report.csv", "report a", fs.WithMode(0644)),
+				fs.WithFile("report-1.csv", "report b", fs.WithMode(0644)),
+			},
+			expectedStdout: []string{
+				`cp s3://%[1]v/a/report.csv report.csv`,
+				`cp s3://%[1]v/b/report.csv report-1.csv`,
+			},
+		},
+		{
+			policy: "skip",
+			expectedFiles: []fs.PathOp{
+				fs.WithFile("report.csv", "report a", fs.WithMode(0644)),
+			},
+			expectedStdout: []string{
+				`cp s3://%[1]v/a/report.csv report.csv`,
+			},
+			expectedStderr: []string{
+				`WARNING "cp s3://%[1]v/b/report.csv .": skipped: "report.csv" is copied from s3://%[1]v/a/report.csv`,
+			},
+		},
+		{
+			policy: "fail",
+			expectedFiles: []fs.PathOp{
+				fs.WithFile("report.csv", "report a", fs.WithMode(0644)),
+			},
+			expectedStdout: []string{
+				`cp s3://%[1]v/a/report.csv report.csv`,
+			},
+			expectedStderr: []string{
+				`ERROR "cp --flatten=true --flatten-collision=fail s3://%[1]v/*/*.csv .": "report.csv" is copied from both s3://%[1]v/a/report.csv and s3://%[1]v/b/report.csv`,
+			},
+			expectedExit: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.policy, func(t *testing.T) {
+			t.Parallel()
+
+			bucket := s3BucketFromTestName(t)
+
+			s3client, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			createBucket(t, s3client, bucket)
+
+			putFile(t, s3client, bucket, "a/report.csv", "report a")
+			putFile(t, s3client, bucket, "b/report.csv", "report b")
+
+			cmd := s5cmd("cp", "--flatten", "--flatten-collision", tc.policy, "s3://"+bucket+"/*/*.csv", ".")
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: tc.expectedExit})
+
+			stdout := map[int]compareFunc{}
+			for i, line := range tc.expectedStdout {
+				stdout[i] = equals(line, bucket)
+			}
+			assertLines(t, result.Stdout(), stdout, sortInput(true))
+
+			stderr := map[int]compareFunc{}
+			for i, line := range tc.expectedStderr {
+				stderr[i] = equals(line, bucket)
+			}
+			assertLines(t, result.Stderr(), stderr)
+
+			expected := fs.Expected(t, tc.expectedFiles...)
+			assert.Assert(t, fs.Equal(cmd.Dir, expected))
+		})
+	}
+}
+
 // --json cp --flatten s3://bucket/* .
 func TestCopyMultipleFlatS3ObjectsToLocalJSON(t *testing.T) {
 	t.Parallel()
@@ -1495,6 +1591,68 @@ func TestCopySingleS3ObjectToS3(t *testing.T) {
 	assert.Assert(t, ensureS3Object(s3client, bucket, dstfilename, content))
 }
 
+// cp --verify-copy s3://bucket/object s3://bucket/object2
+func TestCopySingleS3ObjectToS3WithVerifyCopy(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename    = "testfile1.txt"
+		dstfilename = "copy_" + filename
+		content     = "this is a file content"
+	)
+
+	putFile(t, s3client, bucket, filename, content)
+
+	src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+	dst := fmt.Sprintf("s3://%v/%v", bucket, dstfilename)
+
+	cmd := s5cmd("cp", "--verify-copy", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v %v`, src, dst),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, dstfilename, content))
+}
+
+// cp --verify-copy dir/file s3://bucket/
+func TestCopySingleFileToS3WithVerifyCopy(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("report.csv", "content"))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join("report.csv")
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--verify-copy", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --verify-copy=true %v %v": verify-copy is only supported for server-side copies`, srcpath, dstpath),
+	})
+}
+
 // --json cp s3://bucket/object s3://bucket2/object
 func TestCopySingleS3ObjectToS3JSON(t *testing.T) {
 	t.Parallel()
@@ -2057,12 +2215,12 @@ func TestCopyS3ToLocalWithSameFilenameWithNoClobber(t *testing.T) {
 
 	result.Assert(t, icmd.Success)
 
-	assertLines(t, result.Stdout(), map[int]compareFunc{
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "cp s3://%v/%v %v": object already exists`, bucket, filename, filename),
 	})
 
-	assertLines(t, result.Stderr(), map[int]compareFunc{})
-
 	expected := fs.Expected(t, fs.WithFile(filename, content))
 	assert.Assert(t, fs.Equal(workdir.Path(), expected))
 }
@@ -2182,12 +2340,12 @@ func TestCopyS3ToLocalWithSameFilenameDontOverrideIfS3ObjectIsOlder(t *testing.T
 	// size differs.
 	result.Assert(t, icmd.Success)
 
-	assertLines(t, result.Stdout(), map[int]compareFunc{
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "cp s3://%v/%v %v": object is newer or same age`, bucket, filename, filename),
 	})
 
-	assertLines(t, result.Stderr(), map[int]compareFunc{})
-
 	expected := fs.Expected(t, fs.WithFile(filename, content))
 	assert.Assert(t, fs.Equal(workdir.Path(), expected))
 }
@@ -2316,12 +2474,12 @@ func TestCopyLocalFileToS3WithSameFilenameWithNoClobber(t *testing.T) {
 
 	result.Assert(t, icmd.Success)
 
-	assertLines(t, result.Stdout(), map[int]compareFunc{
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "cp %v s3://%v/%v": object already exists`, filename, bucket, filename),
 	})
 
-	assertLines(t, result.Stderr(), map[int]compareFunc{})
-
 	// assert local filesystem
 	expected := fs.Expected(t, fs.WithFile(filename, newContent))
 	assert.Assert(t, fs.Equal(workdir.Path(), expected))
@@ -2490,12 +2648,12 @@ func TestCopyLocalFileToS3WithSameFilenameDontOverrideIfS3ObjectIsOlder(t *testi
 	// modtime differs.
 	result.Assert(t, icmd.Success)
 
-	assertLines(t, result.Stdout(), map[int]compareFunc{
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "cp %v s3://%v/%v": object is newer or same age`, filename, bucket, filename),
 	})
 
-	assertLines(t, result.Stderr(), map[int]compareFunc{})
-
 	assert.NilError(t, ensureS3Object(s3client, bucket, filename, content))
 }
 
@@ -3857,3 +4015,1563 @@ func TestCopyExpectExitCode1OnUnreachableHost(t *testing.T) {
 
 	result.Assert(t, icmd.Expected{ExitCode: 1})
 }
+
+// cp --respect-gitignore dir/ s3://bucket/prefix/
+func TestCopyLocalDirectoryToS3WithRespectGitignore(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	folderLayout := []fs.PathOp{
+		fs.WithFile(".gitignore", "*.o\nbuild/\n"),
+		fs.WithFile("main.c", "int main() {}"),
+		fs.WithFile("main.o", "object file"),
+		fs.WithDir("build", fs.WithFile("app", "binary")),
+		fs.WithDir(
+			"lib",
+			fs.WithFile(".gitignore", "!lib.o\n"),
+			fs.WithFile("lib.c", "int lib() {}"),
+			fs.WithFile("lib.o", "library object file"),
+		),
+	}
+
+	workdir := fs.NewDir(t, "somedir", folderLayout...)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("%v/", workdir.Path())
+	src = filepath.ToSlash(src)
+	dst := fmt.Sprintf("s3://%v/prefix/", bucket)
+
+	cmd := s5cmd("cp", "--respect-gitignore", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// assert local filesystem
+	expected := fs.Expected(t, folderLayout...)
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+
+	expectedS3Content := map[string]string{
+		"prefix/.gitignore":     "*.o\nbuild/\n",
+		"prefix/main.c":         "int main() {}",
+		"prefix/lib/.gitignore": "!lib.o\n",
+		"prefix/lib/lib.c":      "int lib() {}",
+		"prefix/lib/lib.o":      "library object file",
+	}
+
+	nonExpectedS3Content := map[string]string{
+		"prefix/main.o":    "object file",
+		"prefix/build/app": "binary",
+	}
+
+	// assert objects should be in S3
+	for key, content := range expectedS3Content {
+		assert.Assert(t, ensureS3Object(s3client, bucket, key, content))
+	}
+
+	// assert objects should not be in S3.
+	for key, content := range nonExpectedS3Content {
+		err := ensureS3Object(s3client, bucket, key, content)
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+// cp --checksum-algorithm SHA256 file s3://bucket/
+func TestCopySingleFileToS3WithChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile.txt"
+		content  = "this is a file content"
+	)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join(filename)
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--checksum-algorithm", "sha256", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v %v%v`, srcpath, dstpath, filename),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
+}
+
+// cp --checksum-algorithm MD5 file s3://bucket/
+func TestCopySingleFileToS3WithInvalidChecksumAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile.txt"
+		content  = "this is a file content"
+	)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join(filename)
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--checksum-algorithm", "MD5", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --checksum-algorithm=MD5 %v %v": unsupported checksum algorithm "MD5"`, srcpath, dstpath),
+	})
+
+	err := ensureS3Object(s3client, bucket, filename, content)
+	assertError(t, err, errS3NoSuchKey)
+}
+
+// cp --checksum-algorithm SHA256 --resume file s3://bucket/
+func TestCopyWithChecksumAlgorithmAndResume(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile.txt"
+		content  = "this is a file content"
+	)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join(filename)
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--checksum-algorithm", "SHA256", "--resume", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --resume=true --checksum-algorithm=SHA256 %v %v": checksum-algorithm can not be used with resume flag`, srcpath, dstpath),
+	})
+}
+
+// cp --metadata-directive MERGE s3://bucket/object s3://bucket/object2
+func TestCopyS3ObjectToS3WithInvalidMetadataDirective(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile.txt"
+		content  = "this is a file content"
+	)
+
+	putFile(t, s3client, bucket, filename, content)
+
+	src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+	dst := fmt.Sprintf("s3://%v/copy_%v", bucket, filename)
+
+	cmd := s5cmd("cp", "--metadata-directive", "MERGE", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --metadata-directive=MERGE %v %v": unsupported metadata directive "MERGE", expected COPY or REPLACE`, src, dst),
+	})
+
+	err := ensureS3Object(s3client, bucket, "copy_"+filename, content)
+	assertError(t, err, errS3NoSuchKey)
+}
+
+// cp --content-type-map invalid file s3://bucket/
+func TestCopySingleFileToS3WithInvalidContentTypeMap(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const filename = "readme.md"
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile(filename, "# readme"))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join(filename)
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--content-type-map", "text/markdown", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --content-type-map=text/markdown %v %v": invalid content type mapping "text/markdown", expected ext=type`, srcpath, dstpath),
+	})
+}
+
+// cp --compress gzip --compress-suffix .gz file s3://bucket/
+func TestCopySingleFileToS3WithCompression(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "access.log"
+		content  = "this is a log line\nthis is another log line\n"
+	)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join(filename)
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--compress", "gzip", "--compress-suffix", ".gz", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v %v%v.gz`, srcpath, dstpath, filename),
+	})
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err := gw.Write([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, filename+".gz", compressed.String()))
+	assert.Assert(t, ensureS3Object(s3client, bucket, filename, content) != nil)
+}
+
+// cp --compress gzip s3://bucket/object dir/
+func TestCopyS3ObjectToLocalWithCompression(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "access.log", "this is a log line")
+
+	cmd := s5cmd("cp", "--compress", "gzip", "s3://"+bucket+"/access.log", ".")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --compress=gzip s3://%v/access.log .": compress is only supported for uploads`, bucket),
+	})
+}
+
+func TestCopyGzipS3ObjectToLocalWithDecompress(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	const content = "this is a log line"
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err := gw.Write([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "access.log.gz", compressed.String())
+
+	cmd := s5cmd("cp", "--decompress", "s3://"+bucket+"/access.log.gz", ".")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/access.log.gz access.log`, bucket),
+	})
+
+	expected := fs.Expected(t, fs.WithFile("access.log", content, fs.WithMode(0644)))
+	assert.Assert(t, fs.Equal(cmd.Dir, expected))
+}
+
+func TestCopyGzipEncodedS3ObjectToLocalWithDecompress(t *testing.T) {
+	t.Parallel()
+
+	const content = "this is a log line"
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err := gw.Write([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+
+	// gofakes3 doesn't keep the content encoding of the objects, serve the
+	// object as S3 does. The HTTP transport must not decompress the object
+	// before it is decompressed by s5cmd.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bucket":
+			return
+		case "/bucket/access.log":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", fmt.Sprint(compressed.Len()))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(compressed.Bytes())
+		}
+	}))
+	defer srv.Close()
+
+	workdir := fs.NewDir(t, t.Name())
+	defer workdir.Remove()
+
+	cmd := s5cmd(workdir.Path(), srv.URL)("cp", "--decompress", "s3://bucket/access.log", ".")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://bucket/access.log access.log`),
+	})
+
+	expected := fs.Expected(t, fs.WithFile("access.log", content, fs.WithMode(0644)))
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+func TestCopyLocalFileToS3WithDecompress(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("access.log.gz", "content"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("cp", "--decompress", workdir.Join("access.log.gz"), "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`decompress is only supported for downloads`),
+	})
+}
+
+func TestCopySingleFileToS3WithInvalidTags(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("report.csv", "content"))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join("report.csv")
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--tags", "team", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --tags=team %v %v": invalid tag "team", expected key=value`, srcpath, dstpath),
+	})
+}
+
+func TestCopySingleFileToS3WithCopyTags(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("report.csv", "content"))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join("report.csv")
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--copy-tags", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --copy-tags=true %v %v": copy-tags is only supported for server-side copies`, srcpath, dstpath),
+	})
+}
+
+func TestCopySingleFileToS3WithTags(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("report.csv", "content"))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join("report.csv")
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--tags", "team=data,env=prod", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`cp %v %vreport.csv`, srcpath, dstpath),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "report.csv", "content"))
+}
+
+func TestCopyChangingFileToS3WithSkip(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "app.log", "existing content")
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("app.log", "first line\n"))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join("app.log")
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	// keep appending to the file until the upload finishes.
+	done := make(chan bool)
+	appended := make(chan bool)
+	go func() {
+		defer close(appended)
+
+		f, err := os.OpenFile(srcpath, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				f.WriteString("log line\n")
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+
+	cmd := s5cmd("cp", "--changing-files", "skip", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+	close(done)
+	<-appended
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`WARNING "cp %v %vapp.log": skipped: file changed during upload`, srcpath, dstpath),
+	})
+
+	// the upload is aborted without touching the existing object.
+	assert.Assert(t, ensureS3Object(s3client, bucket, "app.log", "existing content"))
+}
+
+func TestCopyS3ObjectToLocalWithFsync(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "prefix/file.txt", "content")
+
+	cmd := s5cmd("cp", "--fsync", "s3://"+bucket+"/prefix/*", "dir/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/prefix/file.txt dir/file.txt`, bucket),
+	})
+
+	expected := fs.Expected(t, fs.WithDir("dir", fs.WithFile("file.txt", "content", fs.WithMode(0644))))
+	assert.Assert(t, fs.Equal(cmd.Dir, expected))
+}
+
+func TestCopyLocalFileToS3WithFsync(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", "content"))
+	defer workdir.Remove()
+
+	srcpath := workdir.Join("file.txt")
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--fsync", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --fsync=true %v %v": fsync is only supported for downloads`, srcpath, dstpath),
+	})
+}
+
+// cp --bucket-key-enabled file s3://bucket/
+func TestCopyLocalFileToS3WithBucketKeyWithoutKMS(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", "content"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("cp", "--bucket-key-enabled", workdir.Join("file.txt"), "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`bucket-key-enabled requires sse aws:kms`),
+	})
+}
+
+// cp --storage-class-rule "<1KB=STANDARD,>=1KB=STANDARD_IA" dir/* s3://bucket/
+func TestCopyDirToS3WithStorageClassRule(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	small := "content"
+	big := strings.Repeat("x", 2048)
+
+	workdir := fs.NewDir(t, bucket,
+		fs.WithFile("small.txt", small),
+		fs.WithFile("big.txt", big),
+	)
+	defer workdir.Remove()
+
+	srcpath := filepath.ToSlash(workdir.Path())
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--storage-class-rule", "<1KB=STANDARD,>=1KB=STANDARD_IA", srcpath+"/*", dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/big.txt %vbig.txt`, srcpath, dstpath),
+		1: equals(`cp %v/small.txt %vsmall.txt`, srcpath, dstpath),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "small.txt", small, ensureStorageClass("STANDARD")))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "big.txt", big, ensureStorageClass("STANDARD_IA")))
+}
+
+// cp --storage-class-rule "<1KB=STANDARD,>=1KB=STANDARD_IA" s3://bucket/object s3://bucket/copy
+func TestCopySingleS3ObjectToS3WithStorageClassRule(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	// the size of the object is not known without listing it.
+	big := strings.Repeat("x", 2048)
+	putFile(t, s3client, bucket, "big.txt", big)
+
+	srcpath := fmt.Sprintf("s3://%v/big.txt", bucket)
+	dstpath := fmt.Sprintf("s3://%v/copy.txt", bucket)
+
+	cmd := s5cmd("cp", "--storage-class-rule", "<1KB=STANDARD,>=1KB=STANDARD_IA", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v %v`, srcpath, dstpath),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "copy.txt", big, ensureStorageClass("STANDARD_IA")))
+}
+
+// cp --storage-class-rule "1KB=STANDARD" file s3://bucket/
+func TestCopySingleFileToS3WithInvalidStorageClassRule(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", "content"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("cp", "--storage-class-rule", "1KB=STANDARD", workdir.Join("file.txt"), "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`storage class rule "1KB=STANDARD" must start with one of <, <=, >, >=`),
+	})
+}
+
+// cp --group-by-directory dir/ s3://bucket/
+func TestCopyDirToS3GroupedByDirectory(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket,
+		fs.WithFile("a.txt", "a"),
+		fs.WithDir("b",
+			fs.WithFile("x.txt", "x"),
+			fs.WithDir("c", fs.WithFile("y.txt", "y")),
+		),
+	)
+	defer workdir.Remove()
+
+	srcpath := filepath.ToSlash(workdir.Path())
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--group-by-directory", srcpath+"/", dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/a.txt %va.txt`, srcpath, dstpath),
+		1: equals(`cp %v/b/c/y.txt %vb/c/y.txt`, srcpath, dstpath),
+		2: equals(`cp %v/b/x.txt %vb/x.txt`, srcpath, dstpath),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "a.txt", "a"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "b/x.txt", "x"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "b/c/y.txt", "y"))
+}
+
+// cp --archive tar dir/ s3://bucket/archive.tar
+// cp s3://bucket/archive.tar::member dir/
+func TestCopyDirToS3AsArchiveAndExtractMember(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket,
+		fs.WithFile("a.txt", "content of a"),
+		fs.WithDir("b", fs.WithFile("inside.txt", "content inside b")),
+	)
+	defer workdir.Remove()
+
+	srcpath := filepath.ToSlash(workdir.Path())
+	archive := fmt.Sprintf("s3://%v/archive.tar", bucket)
+
+	cmd := s5cmd("cp", "--archive", "tar", srcpath+"/", archive)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/a.txt %v::a.txt`, srcpath, archive),
+		1: equals(`cp %v/b/inside.txt %v::b/inside.txt`, srcpath, archive),
+	}, sortInput(true))
+
+	_, err := s3client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String("archive.tar.index"),
+	})
+	assert.NilError(t, err)
+
+	cmd = s5cmd("cp", "--archive-member", archive+"::b/inside.txt", ".")
+	result = icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v::b/inside.txt inside.txt`, archive),
+	})
+
+	assert.Assert(t, fs.Equal(workdir.Path(), fs.Expected(t,
+		fs.WithFile("a.txt", "content of a"),
+		fs.WithFile("inside.txt", "content inside b"),
+		fs.WithDir("b", fs.WithFile("inside.txt", "content inside b")),
+	)))
+
+	cmd = s5cmd("cp", "--archive-member", archive+"::missing.txt", ".")
+	result = icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`"missing.txt" is not found in archive %v`, archive),
+	})
+}
+
+// cp s3://bucket/key::with::colons .
+func TestCopySingleS3ObjectWithArchiveSeparatorInKey(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "key::with::colons", "content")
+
+	workdir := fs.NewDir(t, bucket)
+	defer workdir.Remove()
+
+	cmd := s5cmd("cp", "s3://"+bucket+"/key::with::colons", ".")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/key::with::colons key::with::colons`, bucket),
+	})
+
+	assert.Assert(t, fs.Equal(workdir.Path(), fs.Expected(t,
+		fs.WithFile("key::with::colons", "content"),
+	)))
+}
+
+// cp --archive-member s3://bucket/archive.tar::a.txt s3://bucket/a.txt
+func TestCopyArchiveMemberToS3(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("cp", "--archive-member", "s3://bucket/archive.tar::a.txt", "s3://bucket/a.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --archive-member=true s3://bucket/archive.tar::a.txt s3://bucket/a.txt": archive-member is only supported for downloads`),
+	})
+}
+
+// cp --archive tar dir/ s3://bucket/prefix/
+func TestCopyDirToS3AsArchiveWithPrefixTarget(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("cp", "--archive", "tar", "dir/", "s3://bucket/prefix/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --archive=tar dir/ s3://bucket/prefix/": target "s3://bucket/prefix/" must be an object for archive`),
+	})
+}
+
+// cp --archive tar missing-dir/ s3://bucket/archive.tar
+func TestCopyMissingDirToS3AsArchive(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("cp", "--archive", "tar", "missing-dir/", "s3://bucket/archive.tar")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --archive=tar missing-dir/ s3://bucket/archive.tar": given object not found`),
+	})
+}
+
+// cp --archive-member s3://bucket/archive.tar::a.txt dir/*
+func TestCopyArchiveMemberToWildcardTarget(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("cp", "--archive-member", "s3://bucket/archive.tar::a.txt", "dir/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --archive-member=true s3://bucket/archive.tar::a.txt dir/*": target "dir/*" can not contain glob characters`),
+	})
+}
+
+// cp --website-redirect /new.html s3://bucket/object dir/
+func TestCopyS3ObjectToLocalWithWebsiteRedirect(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("cp", "--website-redirect", "/new.html", "s3://bucket/object", "dir/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --website-redirect=/new.html s3://bucket/object dir/": website-redirect is only supported for remote destinations`),
+	})
+}
+
+// --key-shard-length 1 cp file.txt s3://bucket/dir/file.txt
+// --key-shard-length 1 ls s3://bucket/dir/
+// --key-shard-length 1 cp s3://bucket/dir/file.txt copy.txt
+func TestCopySingleFileToS3WithKeyShardLength(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "file.txt"
+		content  = "this is a file content"
+	)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile(filename, content))
+	defer workdir.Remove()
+
+	dst := fmt.Sprintf("s3://%v/dir/%v", bucket, filename)
+
+	cmd := s5cmd("--key-shard-length", "1", "cp", filename, dst)
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v %v`, filename, dst),
+	})
+
+	// md5("dir/file.txt") = "f212ada1..."
+	assert.Assert(t, ensureS3Object(s3client, bucket, "f/dir/file.txt", content))
+
+	cmd = s5cmd("--key-shard-length", "1", "ls", fmt.Sprintf("s3://%v/dir/", bucket))
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(" %v", filename),
+	})
+
+	cmd = s5cmd("--key-shard-length", "1", "cp", dst, "copy.txt")
+	result = icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	expected := fs.Expected(t,
+		fs.WithFile(filename, content, fs.WithMode(0644)),
+		fs.WithFile("copy.txt", content, fs.WithMode(0644)),
+	)
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+// cp --range bytes=-4 s3://bucket/object file
+func TestCopyS3ObjectToLocalWithRange(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "data.parquet"
+		content  = "header-body-footer"
+	)
+
+	putFile(t, s3client, bucket, filename, content)
+
+	workdir := fs.NewDir(t, bucket)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+
+	cmd := s5cmd("cp", "--range", "bytes=-6", src, "footer.bin")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v footer.bin`, src),
+	})
+
+	expected := fs.Expected(t, fs.WithFile("footer.bin", "footer", fs.WithMode(0644)))
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+func TestCopyWithRangeFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "upload",
+			args:     []string{"cp", "--range", "bytes=0-9", "file.txt", "s3://bucket/file.txt"},
+			expected: `ERROR "cp --range=bytes=0-9 file.txt s3://bucket/file.txt": range is only supported for downloads`,
+		},
+		{
+			name:     "wildcard",
+			args:     []string{"cp", "--range", "bytes=0-9", "s3://bucket/*", "dir/"},
+			expected: `ERROR "cp --range=bytes=0-9 s3://bucket/* dir/": range is only supported for single object downloads`,
+		},
+		{
+			name:     "mv",
+			args:     []string{"mv", "--range", "bytes=0-9", "s3://bucket/file.txt", "file.txt"},
+			expected: `ERROR "mv --range=bytes=0-9 s3://bucket/file.txt file.txt": range is only supported by cp command`,
+		},
+		{
+			name:     "invalid",
+			args:     []string{"cp", "--range", "bytes=a-b", "s3://bucket/file.txt", "file.txt"},
+			expected: `ERROR "cp --range=bytes=a-b s3://bucket/file.txt file.txt": range "bytes=a-b" must consist of non-negative integers`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+// cp --sparse file s3://bucket/ && cp --sparse s3://bucket/file dir/
+func TestCopySparseFileToS3AndBack(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	// a file of 1MiB with a single block of data in the middle of holes.
+	content := make([]byte, 1024*1024)
+	copy(content[512*1024:], "data")
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("disk.img", string(content)))
+	defer workdir.Remove()
+
+	dst := fmt.Sprintf("s3://%v/disk.img", bucket)
+
+	cmd := s5cmd("cp", "--sparse", workdir.Join("disk.img"), dst)
+	result := icmd.RunCmd(cmd)
+	result.Assert(t, icmd.Success)
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "disk.img", string(content)))
+
+	cmd = s5cmd("cp", "--sparse", dst, "copy.img")
+	result = icmd.RunCmd(cmd, withWorkingDir(workdir))
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v copy.img`, dst),
+	})
+
+	got, err := ioutil.ReadFile(workdir.Join("copy.img"))
+	assert.NilError(t, err)
+	assert.Assert(t, bytes.Equal(content, got))
+}
+
+func TestCopyWithSparseFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "server-side copy",
+			args:     []string{"cp", "--sparse", "s3://bucket/disk.img", "s3://bucket/copy.img"},
+			expected: `ERROR "cp --sparse=true s3://bucket/disk.img s3://bucket/copy.img": sparse is only supported for uploads and downloads`,
+		},
+		{
+			name:     "compress",
+			args:     []string{"cp", "--sparse", "--compress", "gzip", "disk.img", "s3://bucket/"},
+			expected: `ERROR "cp --compress=gzip --sparse=true disk.img s3://bucket/": sparse can not be used with compress, decompress, resume or range flags`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+// cp --rename 's/\.log$/.log.gz/' dir/* s3://bucket/
+func TestCopyMultipleFilesToS3WithRename(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithFile("readme.md", "readme"),
+		fs.WithDir("app", fs.WithFile("server.log", "log")),
+	)
+	defer workdir.Remove()
+
+	src := filepath.ToSlash(workdir.Path())
+
+	cmd := s5cmd("cp", "--rename", `s/\.log$/.log.gz/`, src+"/*", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/app/server.log s3://%v/app/server.log.gz`, src, bucket),
+		1: equals(`cp %v/readme.md s3://%v/readme.md`, src, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "app/server.log.gz", "log"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "readme.md", "readme"))
+}
+
+// cp --flatten --rename '{{.Name | upper}}' s3://bucket/* dir/
+func TestCopyMultipleS3ObjectsToLocalWithRenameTemplate(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "a/report.csv", "report")
+	putFile(t, s3client, bucket, "b/summary.csv", "summary")
+
+	workdir := fs.NewDir(t, t.Name())
+	defer workdir.Remove()
+
+	dst := filepath.ToSlash(workdir.Path())
+
+	// renamed objects are placed under the directories of their new names.
+	cmd := s5cmd("cp", "--flatten", "--rename", `out/{{.Name | upper}}`, "s3://"+bucket+"/*", dst+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/a/report.csv %v/out/REPORT.CSV`, bucket, dst),
+		1: equals(`cp s3://%v/b/summary.csv %v/out/SUMMARY.CSV`, bucket, dst),
+	}, sortInput(true))
+
+	expected := fs.Expected(t, fs.WithDir("out",
+		fs.WithFile("REPORT.CSV", "report", fs.WithMode(0644)),
+		fs.WithFile("SUMMARY.CSV", "summary", fs.WithMode(0644)),
+	))
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+func TestCopyS3ObjectsOfBucketWildcardToLocal(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	for _, suffix := range []string{"a", "b"} {
+		createBucket(t, s3client, bucket+"-"+suffix)
+		putFile(t, s3client, bucket+"-"+suffix, "logs/report.csv", "report "+suffix)
+	}
+
+	workdir := fs.NewDir(t, t.Name())
+	defer workdir.Remove()
+
+	dst := filepath.ToSlash(workdir.Path())
+
+	// the objects are placed under the directories of their buckets.
+	cmd := s5cmd("cp", "s3://"+bucket+"-{a,b}/logs/*.csv", dst+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v-a/logs/report.csv %v/%v-a/report.csv`, bucket, dst, bucket),
+		1: equals(`cp s3://%v-b/logs/report.csv %v/%v-b/report.csv`, bucket, dst, bucket),
+	}, sortInput(true))
+
+	expected := fs.Expected(t,
+		fs.WithDir(bucket+"-a", fs.WithFile("report.csv", "report a", fs.WithMode(0644))),
+		fs.WithDir(bucket+"-b", fs.WithFile("report.csv", "report b", fs.WithMode(0644))),
+	)
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+}
+
+func TestCopyLocalFilesWithBraceWildcardToS3(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithFile("a1.txt", "a1"),
+		fs.WithFile("b2.txt", "b2"),
+		fs.WithFile("c3.txt", "c3"),
+	)
+	defer workdir.Remove()
+
+	src := filepath.ToSlash(workdir.Path())
+
+	cmd := s5cmd("cp", src+"/{a,b}[0-9].txt", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/a1.txt s3://%v/a1.txt`, src, bucket),
+		1: equals(`cp %v/b2.txt s3://%v/b2.txt`, src, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "a1.txt", "a1"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "b2.txt", "b2"))
+}
+
+func TestCopyWithInvalidRename(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("cp", "--rename", "s/a/b", "dir/", "s3://bucket/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "cp --rename=s/a/b dir/ s3://bucket/": invalid rename expression "s/a/b", expected s/<regexp>/<replacement>/[gi] or a Go template`),
+	})
+}
+
+func TestCopySingleFileToS3WithQuota(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "tenant/existing.txt", "0123456789")
+	putFile(t, s3client, bucket, "other/object.txt", "content of another tenant")
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("a.txt", "0123456789"), fs.WithFile("b.txt", "0123456789"))
+	defer workdir.Remove()
+
+	dstpath := fmt.Sprintf("s3://%v/tenant/", bucket)
+
+	// the prefix has 2 objects of 20 bytes after the upload.
+	srcpath := filepath.ToSlash(workdir.Join("a.txt"))
+	cmd := s5cmd("cp", "--quota-count", "2", "--quota-size", "20", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v %va.txt`, srcpath, dstpath),
+	})
+
+	srcpath = filepath.ToSlash(workdir.Join("b.txt"))
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "count",
+			args:     []string{"--quota-count", "2"},
+			expected: fmt.Sprintf("quota exceeded: s3://%v/tenant/ would have more than 2 objects", bucket),
+		},
+		{
+			name:     "size",
+			args:     []string{"--quota-size", "25B"},
+			expected: fmt.Sprintf("quota exceeded: s3://%v/tenant/ would have more than 25, it has 20", bucket),
+		},
+	}
+
+	for _, tc := range testcases {
+		args := append(append([]string{"cp"}, tc.args...), srcpath, dstpath)
+		result := icmd.RunCmd(s5cmd(args...))
+
+		result.Assert(t, icmd.Expected{ExitCode: 1})
+
+		assertLines(t, result.Stderr(), map[int]compareFunc{
+			0: contains(tc.expected),
+		})
+
+		err := ensureS3Object(s3client, bucket, "tenant/b.txt", "0123456789")
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+// cp --quota-size 25B s3://bucket/other/object.txt s3://bucket/tenant/
+func TestCopySingleS3ObjectToS3WithQuota(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "tenant/existing.txt", "0123456789")
+	putFile(t, s3client, bucket, "other/object.txt", "content of another tenant")
+
+	// the size of the source is not listed, it has to be fetched.
+	srcpath := fmt.Sprintf("s3://%v/other/object.txt", bucket)
+	dstpath := fmt.Sprintf("s3://%v/tenant/", bucket)
+	cmd := s5cmd("cp", "--quota-size", "25B", srcpath, dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains("quota exceeded: s3://%v/tenant/ would have more than 25, it has 10", bucket),
+	})
+
+	err := ensureS3Object(s3client, bucket, "tenant/object.txt", "content of another tenant")
+	assertError(t, err, errS3NoSuchKey)
+}
+
+func TestCopyWithQuotaFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "local destination",
+			args:     []string{"cp", "--quota-count", "10", "s3://bucket/*", "dir/"},
+			expected: `ERROR "cp --quota-count=10 s3://bucket/* dir/": quotas can only be used with remote destinations`,
+		},
+		{
+			name:     "invalid size",
+			args:     []string{"cp", "--quota-size", "10XB", "dir/*", "s3://bucket/"},
+			expected: `ERROR "cp --quota-size=10XB dir/* s3://bucket/": quota-size: unknown size unit "XB"`,
+		},
+		{
+			name:     "prefix without quota",
+			args:     []string{"cp", "--quota-prefix", "s3://bucket/tenant/", "dir/*", "s3://bucket/tenant/"},
+			expected: `ERROR "cp --quota-prefix=s3://bucket/tenant/ dir/* s3://bucket/tenant/": quota-prefix requires quota-size or quota-count`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+func TestCopyMultipleFilesToS3OlderThan(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	old := time.Now().Add(-48 * time.Hour)
+	workdir := fs.NewDir(t, bucket,
+		fs.WithFile("old.txt", "old content", fs.WithTimestamps(old, old)),
+		fs.WithFile("new.txt", "new content"),
+	)
+	defer workdir.Remove()
+
+	srcpath := filepath.ToSlash(workdir.Path())
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--older-than", "1d", srcpath+"/*", dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/old.txt %vold.txt`, srcpath, dstpath),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "old.txt", "old content"))
+	err := ensureS3Object(s3client, bucket, "new.txt", "new content")
+	assertError(t, err, errS3NoSuchKey)
+}
+
+func TestCopyMultipleFilesToS3MaxSize(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket,
+		fs.WithFile("small.txt", "small content"),
+		fs.WithFile("large.txt", strings.Repeat("x", 2048)),
+	)
+	defer workdir.Remove()
+
+	srcpath := filepath.ToSlash(workdir.Path())
+	dstpath := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("cp", "--max-size", "1KB", srcpath+"/*", dstpath)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/small.txt %vsmall.txt`, srcpath, dstpath),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "small.txt", "small content"))
+	err := ensureS3Object(s3client, bucket, "large.txt", strings.Repeat("x", 2048))
+	assertError(t, err, errS3NoSuchKey)
+}
+
+func TestCopyWithSizeFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "invalid size",
+			args:     []string{"cp", "--max-size", "10XB", "dir/*", "s3://bucket/"},
+			expected: `ERROR "cp --max-size=10XB dir/* s3://bucket/": max-size: unknown size unit "XB"`,
+		},
+		{
+			name:     "empty range",
+			args:     []string{"cp", "--min-size", "1GB", "--max-size", "10MB", "dir/*", "s3://bucket/"},
+			expected: `ERROR "cp --min-size=1GB --max-size=10MB dir/* s3://bucket/": max-size must be greater than or equal to min-size`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+func TestCopyWithAgeFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "invalid age",
+			args:     []string{"cp", "--older-than", "30y", "dir/*", "s3://bucket/"},
+			expected: `ERROR "cp --older-than=30y dir/* s3://bucket/": older-than: invalid age "30y", e.g. 30d, 2w or 12h`,
+		},
+		{
+			name:     "empty range",
+			args:     []string{"cp", "--older-than", "30d", "--newer-than", "1w", "dir/*", "s3://bucket/"},
+			expected: `ERROR "cp --older-than=30d --newer-than=1w dir/* s3://bucket/": newer-than must be greater than older-than`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// diff old.json new.json
+func TestDiffListings(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "a.txt", "content")
+	putFile(t, s3client, bucket, "b.txt", "content")
+	putFile(t, s3client, bucket, "c.txt", "content")
+
+	cmd := s5cmd("--json", "ls", "s3://"+bucket+"/*")
+	before := icmd.RunCmd(cmd)
+	before.Assert(t, icmd.Success)
+
+	putFile(t, s3client, bucket, "b.txt", "new content")
+	putFile(t, s3client, bucket, "d.txt", "content")
+	cmd = s5cmd("rm", "s3://"+bucket+"/c.txt")
+	icmd.RunCmd(cmd).Assert(t, icmd.Success)
+
+	cmd = s5cmd("--json", "ls", "s3://"+bucket+"/*")
+	after := icmd.RunCmd(cmd)
+	after.Assert(t, icmd.Success)
+
+	workdir := fs.NewDir(t, t.Name(),
+		fs.WithFile("old.json", before.Stdout()),
+		fs.WithFile("new.json", after.Stdout()),
+	)
+	defer workdir.Remove()
+
+	cmd = s5cmd("diff", "old.json", "new.json")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`changed s3://%v/b.txt`, bucket),
+		1: equals(`deleted s3://%v/c.txt`, bucket),
+		2: equals(`added s3://%v/d.txt`, bucket),
+	})
+}
+
+// --json diff --from 2021-06-01T00:00:00Z s3://bucket/*
+func TestDiffVersions(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioning is only supported by the in-memory backend.
+	s3client, s5cmd, cleanup := setup(t, withS3Backend("mem"))
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	_, err := s3client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	assert.NilError(t, err)
+
+	putFile(t, s3client, bucket, "a.txt", "content")
+	putFile(t, s3client, bucket, "b.txt", "content")
+	putFile(t, s3client, bucket, "c.txt", "content")
+
+	// the modification times of the objects are in seconds.
+	time.Sleep(time.Second)
+	from := time.Now().UTC().Format(time.RFC3339)
+	time.Sleep(time.Second)
+
+	putFile(t, s3client, bucket, "b.txt", "content")
+	putFile(t, s3client, bucket, "d.txt", "content")
+
+	cmd := s5cmd("rm", "s3://"+bucket+"/c.txt")
+	icmd.RunCmd(cmd).Assert(t, icmd.Success)
+
+	cmd = s5cmd("--json", "diff", "--from", from, "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// b.txt is overwritten with the same content.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^{"operation":"diff","change":"changed","object":{"key":"s3://` + bucket + `/b.txt",.*"version_id":"\S+"}}$`),
+		1: match(`^{"operation":"diff","change":"deleted","object":{"key":"s3://` + bucket + `/c.txt",.*}}$`),
+		2: match(`^{"operation":"diff","change":"added","object":{"key":"s3://` + bucket + `/d.txt",.*}}$`),
+	})
+}
+
+func TestDiffFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "single listing",
+			args:     []string{"diff", "old.json"},
+			expected: `ERROR "diff old.json": expected old and new listing files`,
+		},
+		{
+			name:     "remote listing",
+			args:     []string{"diff", "old.json", "s3://bucket/*"},
+			expected: `ERROR "diff old.json s3://bucket/*": listings must be local files, use --from to compare the versions of remote objects`,
+		},
+		{
+			name:     "invalid time",
+			args:     []string{"diff", "--from", "yesterday", "s3://bucket/*"},
+			expected: `ERROR "diff --from=yesterday s3://bucket/*": from "yesterday" must be in RFC3339 format, e.g. 2021-06-01T00:00:00Z`,
+		},
+		{
+			name:     "to before from",
+			args:     []string{"diff", "--from", "2021-06-02T00:00:00Z", "--to", "2021-06-01T00:00:00Z", "s3://bucket/*"},
+			expected: `ERROR "diff --from=2021-06-02T00:00:00Z --to=2021-06-01T00:00:00Z s3://bucket/*": to must be after from`,
+		},
+		{
+			name:     "missing listing",
+			args:     []string{"diff", "old.json", "new.json"},
+			expected: `ERROR "diff old.json new.json": open old.json: no such file or directory`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -163,6 +163,73 @@ func TestDiskUsageWildcardS3ObjectsWithDashH(t *testing.T) {
 	})
 }
 
+// du --si s3://bucket
+func TestDiskUsageWildcardS3ObjectsWithSI(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", strings.Repeat("this is a file content", 10000))
+	putFile(t, s3client, bucket, "testfile2.txt", strings.Repeat("this is also a file content", 1000))
+
+	cmd := s5cmd("du", "--si", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`247.0k bytes in 2 objects: s3://%v`, bucket),
+	})
+}
+
+// du --humanize=si --precision 3 s3://bucket
+func TestDiskUsageWildcardS3ObjectsWithHumanizeUnitAndPrecision(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", strings.Repeat("this is a file content", 10000))
+	putFile(t, s3client, bucket, "testfile2.txt", strings.Repeat("this is also a file content", 1000))
+
+	cmd := s5cmd("du", "--humanize=si", "--precision", "3", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`247.000k bytes in 2 objects: s3://%v`, bucket),
+	})
+}
+
+// du --precision -1 s3://bucket
+func TestDiskUsageNegativePrecision(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("du", "--precision", "-1", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "du --precision=-1 s3://%v": precision must not be negative`, bucket),
+	})
+}
+
 func TestDiskUsageMissingObject(t *testing.T) {
 	t.Parallel()
 
@@ -246,3 +313,158 @@ func TestDiskUsageWildcardWithExcludeFilters(t *testing.T) {
 		0: suffix(`84 bytes in 3 objects: s3://%v/*`, bucket),
 	})
 }
+
+// du --concurrency 2 --progress s3://bucket/logs/*.txt
+func TestDiskUsageWildcardOfCommonPrefixesWithProgress(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "logs/testfile1.txt", "content")
+	putFile(t, s3client, bucket, "logs/testfile2.gz", "content")
+	putFile(t, s3client, bucket, "logs/a/testfile3.txt", "content")
+	putFile(t, s3client, bucket, "logs/a/b/testfile4.txt", "content")
+	putFile(t, s3client, bucket, "logs/b/testfile5.txt", "content")
+	putFile(t, s3client, bucket, "logs/c/testfile6.gz", "content")
+	putFile(t, s3client, bucket, "other/testfile7.txt", "content")
+
+	cmd := s5cmd("du", "--concurrency", "2", "--progress", "s3://"+bucket+"/logs/*.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`28 bytes in 4 objects: s3://%v/logs/*.txt`, bucket),
+	})
+
+	// the final progress is printed when the listing completes.
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`progress: 28 bytes in 4 objects so far, 3 of 3 prefixes listed: s3://%v/logs/*.txt`, bucket),
+	})
+}
+
+// du s3://bucket/*.csv
+func TestDiskUsageWildcardOfCommonPrefixesWithoutMatches(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "a/testfile2.txt", "content")
+	putFile(t, s3client, bucket, "b/testfile3.txt", "content")
+
+	cmd := s5cmd("du", "s3://"+bucket+"/*.csv")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`0 bytes in 0 objects: s3://%v/*.csv`, bucket),
+	})
+
+	// the prefixes without matches are reported once.
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "du s3://%v/*.csv": no object found`, bucket),
+	})
+}
+
+// du --concurrency 0 s3://bucket/*
+func TestDiskUsageInvalidConcurrency(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("du", "--concurrency", "0", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "du --concurrency=0 s3://%v/*": concurrency must be a positive integer`, bucket),
+	})
+}
+
+// du --depth 2 --top 2 s3://bucket/logs/*
+func TestDiskUsageTopPrefixes(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "logs/testfile1.txt", "content")
+	putFile(t, s3client, bucket, "logs/a/testfile2.txt", "content")
+	putFile(t, s3client, bucket, "logs/a/b/testfile3.txt", "content")
+	putFile(t, s3client, bucket, "logs/a/b/c/testfile4.txt", "content")
+	putFile(t, s3client, bucket, "logs/b/testfile5.txt", "content")
+	putFile(t, s3client, bucket, "other/testfile6.txt", "content")
+
+	cmd := s5cmd("du", "--depth", "2", "--top", "2", "s3://"+bucket+"/logs/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`14 bytes in 2 objects: s3://%v/logs/a/b/`, bucket),
+		1: equals(`7 bytes in 1 objects: s3://%v/logs/`, bucket),
+		2: equals(`35 bytes in 5 objects: s3://%v/logs/*`, bucket),
+	})
+}
+
+// du --group --top 2 s3://bucket/*
+func TestDiskUsageTopPrefixesWithGroup(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("du", "--group", "--top", "2", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "du --group=true --top=2 s3://%v/*": depth and top can not be used with --group`, bucket),
+	})
+}
+
+// du --exclude "_tmp/*" --exclude "*.partial" s3://bucket/*
+func TestDiskUsageWildcardWithExcludedPrefix(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "a/testfile2.txt", "content")
+	putFile(t, s3client, bucket, "a/testfile3.partial", "content")
+	putFile(t, s3client, bucket, "_tmp/testfile4.txt", "content")
+	putFile(t, s3client, bucket, "_tmp/b/testfile5.txt", "content")
+
+	cmd := s5cmd("du", "--exclude", "_tmp/*", "--exclude", "*.partial", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`14 bytes in 2 objects: s3://%v/*`, bucket),
+	})
+}
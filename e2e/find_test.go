@@ -0,0 +1,176 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// find --name "*.parquet" s3://bucket/prefix/
+func TestFindName(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "prefix/a.parquet", "content")
+	putFile(t, s3client, bucket, "prefix/nested/b.parquet", "content")
+	putFile(t, s3client, bucket, "prefix/c.csv", "content")
+	putFile(t, s3client, bucket, "other/d.parquet", "content")
+
+	cmd := s5cmd("find", "--name", "*.parquet", "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`s3://%v/prefix/a.parquet`, bucket),
+		1: equals(`s3://%v/prefix/nested/b.parquet`, bucket),
+	}, sortInput(true))
+}
+
+// find --size +1K --mtime -1d s3://bucket
+func TestFindSizeAndMtime(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "small.txt", "content")
+	putFile(t, s3client, bucket, "large.txt", strings.Repeat("x", 2048))
+
+	cmd := s5cmd("find", "--size", "+1K", "--mtime", "-1d", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`s3://%v/large.txt`, bucket),
+	})
+
+	// none of the objects is modified more than a day ago.
+	cmd = s5cmd("find", "--mtime", "+1d", "s3://"+bucket)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+}
+
+// find --name "*.parquet" --exec "cp {} ./out/" s3://bucket/
+func TestFindExec(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "a.parquet", "content a")
+	putFile(t, s3client, bucket, "b.csv", "content b")
+
+	cmd := s5cmd("find", "--name", "*.parquet", "--exec", "cp {} ./out/", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/a.parquet out/a.parquet`, bucket),
+	})
+
+	expected := fs.Expected(t, fs.WithDir("out", fs.WithFile("a.parquet", "content a", fs.WithMode(0644))))
+	assert.Assert(t, fs.Equal(cmd.Dir, expected))
+}
+
+// find --exec "cp {} ./out/" s3://bucket/
+func TestFindExecKeyWithGlobCharacters(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "*.txt", "content star")
+	putFile(t, s3client, bucket, "a.txt", "content a")
+
+	// each command copies only its own object, the key is not expanded.
+	cmd := s5cmd("find", "--exec", "cp {} ./out/", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp s3://%v/*.txt out/*.txt`, bucket),
+		1: equals(`cp s3://%v/a.txt out/a.txt`, bucket),
+	}, sortInput(true))
+}
+
+func TestFindFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "no source",
+			cmd:      []string{"find", "--name", "*.gz"},
+			expected: `ERROR "find --name=*.gz": expected only 1 argument`,
+		},
+		{
+			name:     "mtime without sign",
+			cmd:      []string{"find", "--mtime", "7d", "s3://bucket"},
+			expected: `ERROR "find --mtime=7d s3://bucket": mtime: invalid age "7d", must start with + or -, e.g. +30d or -12h`,
+		},
+		{
+			name:     "invalid size",
+			cmd:      []string{"find", "--size", "+1X", "s3://bucket"},
+			expected: `ERROR "find --size=+1X s3://bucket": size: unknown size unit "X"`,
+		},
+		{
+			name:     "storage class of local source",
+			cmd:      []string{"find", "--storage-class", "GLACIER", "dir/"},
+			expected: `ERROR "find --storage-class=GLACIER dir/": storage-class can only be used with remote sources`,
+		},
+		{
+			name:     "unknown exec command",
+			cmd:      []string{"find", "--exec", "copy {} out/", "s3://bucket"},
+			expected: `ERROR "find --exec=copy {} out/ s3://bucket": exec: "copy" command not found`,
+		},
+		{
+			name:     "exec without placeholder",
+			cmd:      []string{"find", "--exec", "ls", "s3://bucket"},
+			expected: `ERROR "find --exec=ls s3://bucket": exec: command must contain {} to be replaced by the objects`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
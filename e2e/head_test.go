@@ -0,0 +1,117 @@
+package e2e
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/icmd"
+)
+
+// head s3://bucket/object
+func TestHeadObject(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+
+	cmd := s5cmd("head", "s3://"+bucket+"/testfile.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`key: s3://%v/testfile.txt`, bucket),
+		1: equals(`size: 7`),
+		2: equals(`etag: 9a0364b9e99bb480dd25e1f0284c8555`),
+		3: contains(`last_modified:`),
+		4: equals(`storage_class: STANDARD`),
+	})
+}
+
+// --json head s3://bucket/object
+func TestHeadObjectJSON(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+
+	cmd := s5cmd("--json", "head", "s3://"+bucket+"/testfile.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(fmt.Sprintf(`^\{"key":"s3://%v/testfile.txt","etag":"9a0364b9e99bb480dd25e1f0284c8555","last_modified":".+","type":"file","size":7,"storage_class":"STANDARD","metadata":\{\}\}$`, bucket)),
+	}, jsonCheck(true))
+}
+
+// head s3://bucket
+func TestHeadBucket(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("head", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`s3://%v us-east-1`, bucket),
+	})
+}
+
+// head s3://nonexistent-bucket
+func TestHeadBucketNotFound(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("head", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "head s3://%v": given bucket not found`, bucket),
+	})
+}
+
+// head s3://bucket/nonexistent
+func TestHeadObjectNotFound(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("head", "s3://"+bucket+"/nonexistent")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "head s3://%v/nonexistent": given object not found`, bucket),
+	})
+}
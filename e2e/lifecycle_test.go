@@ -0,0 +1,128 @@
+package e2e
+
+import (
+	"testing"
+
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// --dry-run lifecycle set --rule "prefix=logs/,expire=30" s3://bucket
+func TestLifecycleSetDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("--dry-run", "lifecycle", "set", "--rule", "prefix=logs/,transition=10:GLACIER,expire=30", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`lifecycle set s3://%v`, bucket),
+	})
+}
+
+// --dry-run lifecycle set --file rules.json s3://bucket
+func TestLifecycleSetFromFileDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	rules := `{"source":"s3://other","rules":[{"id":"logs","status":"Enabled","prefix":"logs/","expiration_days":30}]}`
+	workdir := fs.NewDir(t, bucket, fs.WithFile("rules.json", rules))
+	defer workdir.Remove()
+
+	cmd := s5cmd("--dry-run", "lifecycle", "set", "--file", "rules.json", "s3://"+bucket)
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`lifecycle set s3://%v`, bucket),
+	})
+}
+
+// --dry-run lifecycle rm s3://bucket
+func TestLifecycleRemoveDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("--dry-run", "lifecycle", "rm", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`lifecycle rm s3://%v`, bucket),
+	})
+}
+
+func TestLifecycleFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "object source",
+			cmd:      []string{"lifecycle", "get", "s3://bucket/object"},
+			expected: `ERROR "lifecycle get s3://bucket/object": invalid s3 bucket`,
+		},
+		{
+			name:     "set without rules",
+			cmd:      []string{"lifecycle", "set", "s3://bucket"},
+			expected: `ERROR "lifecycle set s3://bucket": expected at least 1 rule with --rule or --file, use lifecycle rm to remove the rules`,
+		},
+		{
+			name:     "set with rule and file",
+			cmd:      []string{"lifecycle", "set", "--rule", "expire=1", "--file", "rules.json", "s3://bucket"},
+			expected: `ERROR "lifecycle set --rule=expire=1 --file=rules.json s3://bucket": --rule and --file can not be used together`,
+		},
+		{
+			name:     "set rule without action",
+			cmd:      []string{"lifecycle", "set", "--rule", "prefix=logs/", "s3://bucket"},
+			expected: `ERROR "lifecycle set --rule=prefix=logs/ s3://bucket": lifecycle rule must have at least one of expire, expire-delete-markers, transition, noncurrent-expire, noncurrent-transition or abort-upload`,
+		},
+		{
+			name:     "set rule with unknown key",
+			cmd:      []string{"lifecycle", "set", "--rule", "delete=1", "s3://bucket"},
+			expected: `ERROR "lifecycle set --rule=delete=1 s3://bucket": unknown lifecycle rule key "delete"`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -2,10 +2,16 @@ package e2e
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
 )
@@ -395,6 +401,264 @@ func TestListS3ObjectsWithDashH(t *testing.T) {
 	}, trimMatch(dateRe), alignment(true))
 }
 
+// ls -H --si bucket
+func TestListS3ObjectsWithSI(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", strings.Repeat("this is a file content", 10000))
+	putFile(t, s3client, bucket, "testfile2.txt", strings.Repeat("this is also a file content", 10000))
+
+	cmd := s5cmd("ls", "-H", "--si", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^ 220.0k testfile1.txt$`),
+		1: match(`^ 270.0k testfile2.txt$`),
+	}, trimMatch(dateRe), alignment(true))
+}
+
+// ls --humanize=si --precision 3 bucket
+func TestListS3ObjectsWithHumanizeUnitAndPrecision(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "testfile1.txt", strings.Repeat("this is a file content", 10000))
+	putFile(t, s3client, bucket, "testfile2.txt", strings.Repeat("this is also a file content", 10000))
+
+	cmd := s5cmd("ls", "--humanize=iec", "--precision", "3", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^ 214.844K testfile1.txt$`),
+		1: match(`^ 263.672K testfile2.txt$`),
+	}, trimMatch(dateRe), alignment(true))
+
+	cmd = s5cmd("ls", "--humanize=raw", "s3://"+bucket)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^ 220000 testfile1.txt$`),
+		1: match(`^ 270000 testfile2.txt$`),
+	}, trimMatch(dateRe), alignment(true))
+}
+
+// ls --humanize=gb bucket
+func TestListS3ObjectsWithInvalidHumanizeUnit(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("ls", "--humanize=gb", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls --humanize=gb s3://%v": humanize "gb" must be one of iec, si or raw`, bucket),
+	})
+}
+
+// ls --delimiter "" s3://bucket/prefix/
+func TestListS3ObjectsWithEmptyDelimiter(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "prefix/a.txt", "content")
+	putFile(t, s3client, bucket, "prefix/b/c.txt", "content")
+	putFile(t, s3client, bucket, "prefix/b/d/e.txt", "content")
+	putFile(t, s3client, bucket, "other/f.txt", "content")
+
+	cmd := s5cmd("ls", "--delimiter", "", "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 a.txt"),
+		1: suffix("7 b/c.txt"),
+		2: suffix("7 b/d/e.txt"),
+	}, sortInput(true))
+}
+
+// ls --delimiter "|" "s3://bucket/logs|"
+func TestListS3ObjectsWithCustomDelimiter(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	putFile(t, s3client, bucket, "logs|2023|a.txt", "content")
+	putFile(t, s3client, bucket, "logs|2024|b/c.txt", "content")
+	putFile(t, s3client, bucket, "logs|d/e.txt", "content")
+
+	cmd := s5cmd("ls", "--delimiter", "|", "s3://"+bucket+"/logs|")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("DIR 2023|"),
+		1: suffix("DIR 2024|"),
+		2: suffix("7 d/e.txt"),
+	})
+}
+
+// ls --delimiter "" --all-versions s3://bucket/
+func TestListS3ObjectsWithDelimiterAndAllVersions(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("ls", "--delimiter", "", "--all-versions", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls --all-versions=true --delimiter= s3://%v/": delimiter can not be used with at-time, all-versions or show-version-id`, bucket),
+	})
+}
+
+// ls --limit 2 s3://bucket/*
+// ls --limit 2 --start-after <last key> s3://bucket/*
+func TestListS3ObjectsWithLimitAndStartAfter(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// the bolt backend doesn't support listing after a key.
+	s3client, s5cmd, cleanup := setup(t, withS3Backend("mem"))
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	for _, key := range []string{"a.txt", "b/c.txt", "b/d.txt", "e.txt", "f.txt"} {
+		putFile(t, s3client, bucket, key, "content")
+	}
+
+	cmd := s5cmd("ls", "--limit", "2", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 a.txt"),
+		1: suffix("7 b/c.txt"),
+	})
+
+	// the next page starts after the last key of the previous one.
+	cmd = s5cmd("ls", "--limit", "2", "--start-after", "b/c.txt", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 b/d.txt"),
+		1: suffix("7 e.txt"),
+	})
+
+	cmd = s5cmd("ls", "--start-after", "e.txt", "s3://"+bucket+"/")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("7 f.txt"),
+	})
+}
+
+// ls --limit 0 s3://bucket/
+func TestListS3ObjectsWithInvalidLimit(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("ls", "--limit", "0", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls --limit=0 s3://%v/": limit must be a positive integer`, bucket),
+	})
+}
+
+// ls "s3://bucket-{a,b}/app-*/[0-9]?/data.json.gz"
+func TestListBraceAndClassWildcards(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	for _, suffix := range []string{"a", "b", "c"} {
+		createBucket(t, s3client, bucket+"-"+suffix)
+		putFile(t, s3client, bucket+"-"+suffix, "app-1/01/data.json.gz", "content")
+		putFile(t, s3client, bucket+"-"+suffix, "app-1/x1/data.json.gz", "content")
+		putFile(t, s3client, bucket+"-"+suffix, "web-1/01/data.json.gz", "content")
+	}
+
+	cmd := s5cmd("ls", "s3://"+bucket+"-{a,b}/app-*/[0-9]?/data.json.gz")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("%v-a/app-1/01/data.json.gz", bucket),
+		1: suffix("%v-b/app-1/01/data.json.gz", bucket),
+	})
+
+	// the buckets which aren't given with brace groups are listed.
+	cmd = s5cmd("ls", "s3://"+bucket+"-[!a]/{app,web}-1/x*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("%v-b/app-1/x1/data.json.gz", bucket),
+		1: suffix("%v-c/app-1/x1/data.json.gz", bucket),
+	})
+}
+
 // ls --exclude "*.txt" s3://bucket/*
 func TestListS3ObjectsWithExcludeFilter(t *testing.T) {
 	t.Parallel()
@@ -742,3 +1006,652 @@ func TestListNestedLocalFolders(t *testing.T) {
 		2: match(filepath.ToSlash("file.txt")),
 	}, trimMatch(dateRe), alignment(true))
 }
+
+// ls --resume-token-file state s3://bucket/*
+func TestListS3ObjectsWithResumeTokenFile(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+
+	statedir := fs.NewDir(t, "state")
+	defer statedir.Remove()
+
+	state := statedir.Join("ls.state")
+	src := fmt.Sprintf("s3://%v/*", bucket)
+
+	// a listing interrupted before its first checkpoint is resumed from the
+	// beginning.
+	err := ioutil.WriteFile(state, []byte(fmt.Sprintf(`{"source":%q,"token":""}`, src)), 0644)
+	assert.NilError(t, err)
+
+	cmd := s5cmd("ls", "--resume-token-file", state, src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("testfile1.txt"),
+		1: suffix("testfile2.txt"),
+	}, trimMatch(dateRe), alignment(true))
+
+	// the resume token file is removed once the listing is completed.
+	_, err = os.Stat(state)
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+// ls --resume-token-file state s3://bucket/*
+func TestListS3ObjectsWithResumeTokenFileOfAnotherSource(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+
+	statedir := fs.NewDir(t, "state", fs.WithFile("ls.state", `{"source":"s3://another/*","token":"token"}`))
+	defer statedir.Remove()
+
+	state := statedir.Join("ls.state")
+	src := fmt.Sprintf("s3://%v/*", bucket)
+
+	cmd := s5cmd("ls", "--resume-token-file", state, src)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`resume token file %q belongs to listing of "s3://another/*"`, state),
+	})
+
+	// the resume token file of the other listing is kept as is.
+	_, err := os.Stat(state)
+	assert.NilError(t, err)
+}
+
+// ls --resume-token-file state dir/
+func TestListLocalFilesWithResumeTokenFile(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("ls", "--resume-token-file", "ls.state", ".")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls --resume-token-file=ls.state .": resume-token-file can only be used with remote sources`),
+	})
+}
+
+// ls --at-time 2021-06-01T12:00:00Z s3://bucket/*
+func TestListS3ObjectsAtTime(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioning is only supported by the in-memory backend.
+	s3client, s5cmd, cleanup := setup(t, withS3Backend("mem"))
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	_, err := s3client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	assert.NilError(t, err)
+
+	putFile(t, s3client, bucket, "a.txt", "v1")
+	putFile(t, s3client, bucket, "b.txt", "content")
+
+	// the modification times of the objects are in seconds.
+	time.Sleep(time.Second)
+	at := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(time.Second)
+
+	putFile(t, s3client, bucket, "a.txt", "version2")
+	putFile(t, s3client, bucket, "c.txt", "content")
+
+	cmd := s5cmd("rm", "s3://"+bucket+"/b.txt")
+	result := icmd.RunCmd(cmd)
+	result.Assert(t, icmd.Success)
+
+	cmd = s5cmd("ls", "--at-time", at, "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^\S+ \S+\s+2 \S+\s+a.txt$`),
+		1: match(`^\S+ \S+\s+7 \S+\s+b.txt$`),
+	})
+}
+
+// ls --show-etag --show-version-id s3://bucket/*
+func TestListS3ObjectsWithVersionID(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioning is only supported by the in-memory backend.
+	s3client, s5cmd, cleanup := setup(t, withS3Backend("mem"))
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	_, err := s3client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	assert.NilError(t, err)
+
+	putFile(t, s3client, bucket, "a.txt", "v1")
+	putFile(t, s3client, bucket, "b.txt", "content")
+
+	// the modification times of the objects are in seconds.
+	time.Sleep(time.Second)
+
+	putFile(t, s3client, bucket, "a.txt", "version2")
+
+	cmd := s5cmd("ls", "--show-etag", "--show-version-id", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^\S+ \S+\s+[0-9a-f]{32}\s+8 \S+\s+a.txt$`),
+		1: match(`^\S+ \S+\s+[0-9a-f]{32}\s+7 \S+\s+b.txt$`),
+	})
+
+	cmd = s5cmd("--json", "ls", "--show-version-id", "s3://"+bucket+"/a.txt")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`"etag":"[0-9a-f]{32}".*"size":8.*"version_id":"\S+"`),
+	})
+}
+
+func TestListShowVersionIDFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			args:     []string{"ls", "--show-version-id", "dir/*"},
+			expected: `ERROR "ls --show-version-id=true dir/*": show-version-id can only be used with remote sources`,
+		},
+		{
+			name:     "prefix without wildcard",
+			args:     []string{"ls", "--show-version-id", "s3://bucket/prefix/"},
+			expected: `ERROR "ls --show-version-id=true s3://bucket/prefix/": show-version-id requires a wildcard or an object key, e.g. s3://bucket/prefix/*`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+// ls --all-versions s3://bucket/*
+func TestListS3ObjectsAllVersions(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioning is only supported by the in-memory backend.
+	s3client, s5cmd, cleanup := setup(t, withS3Backend("mem"))
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	_, err := s3client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	assert.NilError(t, err)
+
+	putFile(t, s3client, bucket, "a.txt", "v1")
+	putFile(t, s3client, bucket, "b.txt", "content")
+
+	// the modification times of the objects are in seconds.
+	time.Sleep(time.Second)
+
+	putFile(t, s3client, bucket, "a.txt", "version2")
+
+	cmd := s5cmd("rm", "s3://"+bucket+"/b.txt")
+	result := icmd.RunCmd(cmd)
+	result.Assert(t, icmd.Success)
+
+	cmd = s5cmd("ls", "--all-versions", "--summarize", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`^\S+ \S+\s+8 \S+\s+LATEST a.txt$`),
+		1: match(`^\S+ \S+\s+2 \S+\s+a.txt$`),
+		2: match(`^\S+ \S+\s+DEL \S+\s+LATEST b.txt$`),
+		3: match(`^\S+ \S+\s+7 \S+\s+b.txt$`),
+		4: equals(`17 bytes in 3 objects: s3://%v/*`, bucket),
+	})
+
+	cmd = s5cmd("--json", "ls", "--all-versions", "s3://"+bucket+"/b.txt")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: match(`"version_id":"\S+","delete_marker":true,"is_latest":true`),
+		1: match(`"size":7,.*"version_id":"\S+"}$`),
+	})
+}
+
+func TestListAllVersionsFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "at time",
+			args:     []string{"ls", "--all-versions", "--at-time", "2021-06-01T12:00:00Z", "s3://bucket/*"},
+			expected: `ERROR "ls --at-time=2021-06-01T12:00:00Z --all-versions=true s3://bucket/*": all-versions can not be used with at-time`,
+		},
+		{
+			name:     "prefix without wildcard",
+			args:     []string{"ls", "--all-versions", "s3://bucket/prefix/"},
+			expected: `ERROR "ls --all-versions=true s3://bucket/prefix/": all-versions requires a wildcard or an object key, e.g. s3://bucket/prefix/*`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+func TestListAtTimeFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "invalid timestamp",
+			args:     []string{"ls", "--at-time", "yesterday", "s3://bucket/*"},
+			expected: `ERROR "ls --at-time=yesterday s3://bucket/*": at-time "yesterday" must be in RFC3339 format, e.g. 2021-06-01T12:00:00Z`,
+		},
+		{
+			name:     "prefix without wildcard",
+			args:     []string{"ls", "--at-time", "2021-06-01T12:00:00Z", "s3://bucket/prefix/"},
+			expected: `ERROR "ls --at-time=2021-06-01T12:00:00Z s3://bucket/prefix/": at-time requires a wildcard or an object key, e.g. s3://bucket/prefix/*`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+func TestListFetchMetadataFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "without json",
+			args:     []string{"ls", "--fetch-metadata", "s3://bucket/*"},
+			expected: `ERROR "ls --fetch-metadata=true s3://bucket/*": fetch-metadata requires --json`,
+		},
+		{
+			name:     "list buckets",
+			args:     []string{"--json", "ls", "--fetch-metadata"},
+			expected: `{"operation":"ls","command":"ls --fetch-metadata=true","error":"fetch-metadata can not be used while listing buckets"}`,
+		},
+		{
+			name:     "local source",
+			args:     []string{"--json", "ls", "--fetch-metadata", "dir/*"},
+			expected: `{"operation":"ls","command":"ls --fetch-metadata=true dir/*","error":"fetch-metadata can only be used with remote sources"}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+func TestListShowTagsFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "list buckets",
+			args:     []string{"ls", "--show-tags"},
+			expected: `ERROR "ls --show-tags=true": show-tags can not be used while listing buckets`,
+		},
+		{
+			name:     "local source",
+			args:     []string{"ls", "--show-tags", "dir/*"},
+			expected: `ERROR "ls --show-tags=true dir/*": show-tags can only be used with remote sources`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+func TestListS3ObjectsByAge(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "a.txt", "content")
+	putFile(t, s3client, bucket, "b.txt", "content")
+
+	cmd := s5cmd("ls", "--newer-than", "1h", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(" 7 a.txt"),
+		1: suffix(" 7 b.txt"),
+	})
+
+	cmd = s5cmd("ls", "--older-than", "1h", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+}
+
+// ls --min-size 1KB s3://bucket/*
+func TestListS3ObjectsBySize(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "tiny.txt", "abc")
+	putFile(t, s3client, bucket, "small.txt", "content")
+	putFile(t, s3client, bucket, "large.txt", strings.Repeat("x", 2048))
+	putFile(t, s3client, bucket, "dir/file.txt", "content")
+
+	cmd := s5cmd("ls", "--min-size", "1KB", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(" 2048 large.txt"),
+	})
+
+	// the prefixes are not filtered by their sizes.
+	cmd = s5cmd("ls", "--min-size", "4", "--max-size", "1KB", "s3://"+bucket)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("DIR dir/"),
+		1: suffix(" 7 small.txt"),
+	}, trimMatch(dateRe), alignment(true))
+}
+
+// ls --summarize s3://bucket/*
+func TestListS3ObjectsWithSummarize(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+	putFile(t, s3client, bucket, "a/readme.md", "readme file")
+	putFile(t, s3client, bucket, "b/main.py", "python file")
+
+	cmd := s5cmd("ls", "--summarize", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`11 a/readme.md`),
+		1: suffix(`11 b/main.py`),
+		2: suffix(`7 testfile.txt`),
+		3: equals(`29 bytes in 3 objects: s3://%v/*`, bucket),
+	})
+}
+
+// ls --summarize s3://bucket/
+func TestListS3PrefixesWithSummarize(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+	putFile(t, s3client, bucket, "a/readme.md", "readme file")
+
+	cmd := s5cmd("--json", "ls", "--summarize", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// prefixes are not counted.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: contains(`"key":"s3://%v/a/"`, bucket),
+		1: contains(`"key":"s3://%v/testfile.txt"`, bucket),
+		2: json(`
+			{
+				"source": "s3://%v",
+				"count":1,
+				"size":7
+			}
+		`, bucket),
+	})
+}
+
+func TestListBucketsWithSummarizeFail(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("ls", "--summarize")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "ls --summarize=true": summarize can not be used while listing buckets`),
+	})
+}
+
+// ls --format "{key},{size}" s3://bucket/*
+func TestListS3ObjectsWithFormat(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+	putFile(t, s3client, bucket, "a/readme.md", "readme file")
+
+	cmd := s5cmd("ls", "--format", "{key},{size}", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals("a/readme.md,11"),
+		1: equals("testfile.txt,7"),
+	})
+}
+
+func TestListWithFormatFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "unknown field",
+			args:     []string{"ls", "--format", "{name}", "s3://bucket/*"},
+			expected: `ERROR "ls --format={name} s3://bucket/*": unknown field "name" in format, expected one of etag, key, last_modified, size, storage_class, tags, type, url, version_id`,
+		},
+		{
+			name:     "json",
+			args:     []string{"--json", "ls", "--format", "{key}", "s3://bucket/*"},
+			expected: `{"operation":"ls","command":"ls --format={key} s3://bucket/*","error":"format can not be used with json"}`,
+		},
+		{
+			name:     "buckets",
+			args:     []string{"ls", "--format", "{key}"},
+			expected: `ERROR "ls --format={key}": format can not be used while listing buckets`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			cmd := s5cmd(tc.args...)
+			result := icmd.RunCmd(cmd)
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package e2e
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// mount s3://bucket/prefix/ mnt/
+func TestMount(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Skip("mounting filesystems requires root on linux")
+	}
+	if _, err := os.Stat("/dev/fuse"); err != nil {
+		t.Skip("fuse device is not available")
+	}
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "prefix/file.txt", "0123456789")
+	putFile(t, s3client, bucket, "prefix/dir/nested.txt", "nested")
+	putFile(t, s3client, bucket, "other.txt", "other")
+
+	// the large objects are read with multiple ranged reads.
+	large := strings.Repeat("0123456789abcdef", 256*1024)
+	putFile(t, s3client, bucket, "prefix/large.bin", large)
+
+	workdir := fs.NewDir(t, bucket, fs.WithDir("mnt"))
+	defer workdir.Remove()
+
+	mountpoint := workdir.Join("mnt")
+
+	cmd := s5cmd("mount", "--cache-ttl", "1s", "s3://"+bucket+"/prefix/", mountpoint)
+	result := icmd.StartCmd(cmd)
+	assert.NilError(t, result.Error)
+
+	// wait for the filesystem to be mounted.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		entries, _ := ioutil.ReadDir(mountpoint)
+		if len(entries) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("filesystem is not mounted")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	entries, err := ioutil.ReadDir(mountpoint)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 3)
+	assert.Equal(t, entries[0].Name(), "dir")
+	assert.Assert(t, entries[0].IsDir())
+	assert.Equal(t, entries[1].Name(), "file.txt")
+	assert.Equal(t, entries[1].Size(), int64(10))
+	assert.Equal(t, entries[2].Name(), "large.bin")
+	assert.Equal(t, entries[2].Size(), int64(len(large)))
+
+	content, err := ioutil.ReadFile(filepath.Join(mountpoint, "file.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "0123456789")
+
+	content, err = ioutil.ReadFile(filepath.Join(mountpoint, "dir", "nested.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "nested")
+
+	content, err = ioutil.ReadFile(filepath.Join(mountpoint, "large.bin"))
+	assert.NilError(t, err)
+	assert.Assert(t, string(content) == large)
+
+	f, err := os.Open(filepath.Join(mountpoint, "file.txt"))
+	assert.NilError(t, err)
+	buf := make([]byte, 4)
+	_, err = f.ReadAt(buf, 2)
+	f.Close()
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "2345")
+
+	_, err = os.Stat(filepath.Join(mountpoint, "other.txt"))
+	assert.Assert(t, os.IsNotExist(err))
+
+	err = ioutil.WriteFile(filepath.Join(mountpoint, "new.txt"), []byte("content"), 0644)
+	assert.ErrorContains(t, err, "read-only file system")
+
+	assert.NilError(t, result.Cmd.Process.Signal(os.Interrupt))
+	result = icmd.WaitOnCmd(10*time.Second, result)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mounted s3://%v/prefix/ at %v`, bucket, mountpoint),
+	})
+
+	// the filesystem is unmounted.
+	entries, err = ioutil.ReadDir(mountpoint)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
+
+func TestMountFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			cmd:      []string{"mount", "dir/", "."},
+			expected: `ERROR "mount dir/ .": source must be a bucket or a prefix`,
+		},
+		{
+			name:     "wildcard source",
+			cmd:      []string{"mount", "s3://bucket/*.txt", "."},
+			expected: `ERROR "mount s3://bucket/*.txt .": source can not contain glob characters`,
+		},
+		{
+			name:     "missing mountpoint",
+			cmd:      []string{"mount", "s3://bucket/prefix/"},
+			expected: `ERROR "mount s3://bucket/prefix/": expected source and mountpoint arguments`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/icmd"
+)
+
+func createMultipartUpload(t *testing.T, client *s3.S3, bucket, key string) string {
+	t.Helper()
+
+	output, err := client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aws.StringValue(output.UploadId)
+}
+
+// mpu list s3://bucket
+func TestMultipartUploadList(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	id1 := createMultipartUpload(t, s3client, bucket, "a/file1.tar")
+	id2 := createMultipartUpload(t, s3client, bucket, "b/file2.tar")
+
+	cmd := s5cmd("mpu", "list", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`%v s3://%v/a/file1.tar`, id1, bucket),
+		1: suffix(`%v s3://%v/b/file2.tar`, id2, bucket),
+	}, sortInput(true))
+}
+
+// mpu list s3://bucket/prefix/
+func TestMultipartUploadListPrefix(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	id1 := createMultipartUpload(t, s3client, bucket, "a/file1.tar")
+	createMultipartUpload(t, s3client, bucket, "b/file2.tar")
+
+	cmd := s5cmd("mpu", "list", "s3://"+bucket+"/a/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`%v s3://%v/a/file1.tar`, id1, bucket),
+	})
+}
+
+// mpu list --older-than 7d s3://bucket
+func TestMultipartUploadListOlderThan(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	createMultipartUpload(t, s3client, bucket, "file.tar")
+
+	cmd := s5cmd("mpu", "list", "--older-than", "7d", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+}
+
+// --dry-run mpu abort s3://bucket
+func TestMultipartUploadAbortDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	id := createMultipartUpload(t, s3client, bucket, "file.tar")
+
+	cmd := s5cmd("--dry-run", "mpu", "abort", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mpu abort s3://%v/file.tar`, bucket),
+	})
+
+	// the upload is still in progress
+	cmd = s5cmd("mpu", "list", "s3://"+bucket)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`%v s3://%v/file.tar`, id, bucket),
+	})
+}
+
+// mpu abort s3://bucket
+func TestMultipartUploadAbort(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	createMultipartUpload(t, s3client, bucket, "file.tar")
+
+	// all uploads of a bucket are not aborted without a confirmation.
+	cmd := s5cmd("mpu", "abort", "s3://"+bucket)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("n\n")))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`ERROR "mpu abort s3://%v": aborting all multipart uploads of the bucket is not confirmed`, bucket),
+	})
+
+	cmd = s5cmd("mpu", "list", "s3://"+bucket)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix(`s3://%v/file.tar`, bucket),
+	})
+
+	cmd = s5cmd("mpu", "abort", "s3://"+bucket)
+	result = icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("y\n")))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mpu abort s3://%v/file.tar`, bucket),
+	})
+
+	cmd = s5cmd("mpu", "list", "s3://"+bucket)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+}
+
+// --force mpu abort s3://bucket
+func TestMultipartUploadAbortForce(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	createMultipartUpload(t, s3client, bucket, "file.tar")
+
+	cmd := s5cmd("--force", "mpu", "abort", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mpu abort s3://%v/file.tar`, bucket),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+}
+
+func TestMultipartUploadFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			cmd:      []string{"mpu", "list", "dir/"},
+			expected: `ERROR "mpu list dir/": source must be remote`,
+		},
+		{
+			name:     "no source",
+			cmd:      []string{"mpu", "abort"},
+			expected: `ERROR "mpu abort": expected only 1 argument`,
+		},
+		{
+			name:     "invalid age",
+			cmd:      []string{"mpu", "abort", "--older-than", "7x", "s3://bucket"},
+			expected: `ERROR "mpu abort --older-than=7x s3://bucket": `,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: contains(tc.expected),
+			})
+		})
+	}
+}
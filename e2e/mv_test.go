@@ -2,8 +2,10 @@ package e2e
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -425,3 +427,195 @@ func TestMoveLocalObjectToS3WithRawFlag(t *testing.T) {
 	expected := fs.Expected(t, otherObjects...)
 	assert.Assert(t, fs.Equal(workdir.Path(), expected))
 }
+
+// mv --verify file s3://bucket/
+func TestMoveSingleFileToS3WithVerify(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const content = "this is a test file"
+
+	file := fs.NewFile(t, "", fs.WithContent(content))
+	defer file.Remove()
+
+	fpath := filepath.ToSlash(file.Path())
+	filename := filepath.Base(file.Path())
+
+	dst := fmt.Sprintf("s3://%v/", bucket)
+	cmd := s5cmd("mv", "--verify", fpath, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mv %v %v%v`, fpath, dst, filename),
+	})
+
+	// expect the source file to be deleted
+	_, err := os.Stat(file.Path())
+	assert.Assert(t, os.IsNotExist(err))
+
+	// assert s3 object
+	assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
+}
+
+// mv --verify s3://bucket/object .
+func TestMoveSingleS3ObjectToLocalWithVerify(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile1.txt"
+		content  = "this is a file content"
+	)
+
+	putFile(t, s3client, bucket, filename, content)
+
+	cmd := s5cmd("mv", "--verify", "s3://"+bucket+"/"+filename, ".")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mv s3://%v/%v %v`, bucket, filename, filename),
+	})
+
+	// assert local filesystem
+	expected := fs.Expected(t, fs.WithFile(filename, content, fs.WithMode(0644)))
+	assert.Assert(t, fs.Equal(cmd.Dir, expected))
+
+	// assert s3 object
+	err := ensureS3Object(s3client, bucket, filename, content)
+	assertError(t, err, errS3NoSuchKey)
+}
+
+// mv --verify s3://bucket/object s3://bucket/dst/object
+func TestMoveSingleS3ObjectToS3WithVerify(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	const (
+		filename = "testfile1.txt"
+		content  = "this is a file content"
+	)
+
+	src := fmt.Sprintf("s3://%v/%v", bucket, filename)
+	dst := fmt.Sprintf("s3://%v/dst/%v", bucket, filename)
+
+	putFile(t, s3client, bucket, filename, content)
+
+	cmd := s5cmd("mv", "--verify", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mv %v %v`, src, dst),
+	})
+
+	// expect no s3 source object
+	err := ensureS3Object(s3client, bucket, filename, content)
+	assertError(t, err, errS3NoSuchKey)
+
+	// assert s3 destination object
+	assert.Assert(t, ensureS3Object(s3client, bucket, "dst/"+filename, content))
+}
+
+func TestMoveWithVerifyAndCompressFail(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("mv", "--verify", "--compress", "gzip", "file.txt", "s3://"+bucket+"/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "mv --compress=gzip --verify=true file.txt s3://%v/": verify can not be used with compress or decompress flags`, bucket),
+	})
+}
+
+func TestMoveSingleFileToS3InteractivelyDeclined(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "file.txt", "existing content")
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", "new content"))
+	defer workdir.Remove()
+
+	fpath := filepath.ToSlash(workdir.Join("file.txt"))
+	dst := fmt.Sprintf("s3://%v/file.txt", bucket)
+
+	cmd := s5cmd("mv", "-i", fpath, dst)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("n\n")))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`overwrite %v? [y/N/all]`, dst),
+	})
+
+	// the source is kept and the destination is not overwritten.
+	expected := fs.Expected(t, fs.WithFile("file.txt", "new content"))
+	assert.Assert(t, fs.Equal(workdir.Path(), expected))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file.txt", "existing content"))
+}
+
+func TestMoveSingleFileToS3InteractivelyWithoutOverwrite(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("file.txt", "new content"))
+	defer workdir.Remove()
+
+	fpath := filepath.ToSlash(workdir.Join("file.txt"))
+	dst := fmt.Sprintf("s3://%v/file.txt", bucket)
+
+	// nothing is asked if the destination doesn't exist.
+	cmd := s5cmd("mv", "-i", fpath, dst)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("")))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`mv %v %v`, fpath, dst),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "file.txt", "new content"))
+}
@@ -0,0 +1,103 @@
+package e2e
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+func TestPlanSyncLocalFolderToS3(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "extra.txt", "D: this is an extra file")
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("testfile.txt", "S: this is a test file"),
+		fs.WithDir("a",
+			fs.WithFile("readme.md", "S: this is a readme file"),
+		),
+	)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("%v/", workdir.Path())
+	src = filepath.ToSlash(src)
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	plandir := fs.NewDir(t, "plan")
+	defer plandir.Remove()
+
+	plan := plandir.Join("plan.txt")
+
+	cmd := s5cmd("plan", "sync", "--delete", "--output", plan, src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	// nothing is synced until the plan is executed.
+	assert.Assert(t, ensureS3Object(s3client, bucket, "testfile.txt", "S: this is a test file") != nil)
+
+	result = icmd.RunCmd(icmd.Command("cat", plan))
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp --raw=true "%va/readme.md" "%va/readme.md"`, src, dst),
+		1: equals(`cp --raw=true "%vtestfile.txt" "%vtestfile.txt"`, src, dst),
+		2: equals(`rm --raw=true --skip-locked=true "%vextra.txt"`, dst),
+	})
+
+	cmd = s5cmd("run", plan)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %va/readme.md %va/readme.md`, src, dst),
+		1: equals(`cp %vtestfile.txt %vtestfile.txt`, src, dst),
+		2: equals(`rm %vextra.txt`, dst),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "testfile.txt", "S: this is a test file"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "a/readme.md", "S: this is a readme file"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "extra.txt", "D: this is an extra file") != nil)
+}
+
+func TestPlanSyncToStdout(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "S: this is a test file")
+
+	workdir := fs.NewDir(t, "somedir")
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("s3://%v/*", bucket)
+	dst := fmt.Sprintf("%v/", filepath.ToSlash(workdir.Path()))
+
+	cmd := s5cmd("plan", "sync", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp --raw=true "s3://%v/testfile.txt" "%vtestfile.txt"`, bucket, dst),
+	})
+
+	// nothing is synced until the plan is executed.
+	assert.Assert(t, fs.Equal(workdir.Path(), fs.Expected(t)))
+}
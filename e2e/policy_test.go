@@ -0,0 +1,141 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+const testBucketPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`
+
+// --dry-run policy set --file policy.json s3://bucket
+func TestPolicySetDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("policy.json", testBucketPolicy))
+	defer workdir.Remove()
+
+	cmd := s5cmd("--dry-run", "policy", "set", "--file", "policy.json", "s3://"+bucket)
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`policy set s3://%v`, bucket),
+	})
+}
+
+// --dry-run policy set --file - s3://bucket
+func TestPolicySetFromStdinDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("--dry-run", "policy", "set", "--file", "-", "s3://"+bucket)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader(testBucketPolicy)))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`policy set s3://%v`, bucket),
+	})
+}
+
+// policy set --file - s3://bucket
+func TestPolicySetInvalidDocument(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("policy", "set", "--file", "-", "s3://"+bucket)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader(`{"Version":`)))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "policy set --file=- s3://%v": policy must be a valid JSON document`, bucket),
+	})
+}
+
+// --dry-run policy rm s3://bucket
+func TestPolicyRemoveDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("--dry-run", "policy", "rm", "s3://"+bucket)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`policy rm s3://%v`, bucket),
+	})
+}
+
+func TestPolicyFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "object source",
+			cmd:      []string{"policy", "get", "s3://bucket/object"},
+			expected: `ERROR "policy get s3://bucket/object": invalid s3 bucket`,
+		},
+		{
+			name:     "set without file",
+			cmd:      []string{"policy", "set", "s3://bucket"},
+			expected: `ERROR "policy set s3://bucket": expected a policy document with --file, use - to read it from stdin`,
+		},
+		{
+			name:     "set with missing file",
+			cmd:      []string{"policy", "set", "--file", "missing.json", "s3://bucket"},
+			expected: `ERROR "policy set --file=missing.json s3://bucket": stat missing.json: no such file or directory`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package e2e
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/icmd"
+)
+
+// presign s3://bucket/object
+func TestPresignGet(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile.txt", "content")
+
+	cmd := s5cmd("presign", "--expire", "24h", "s3://"+bucket+"/testfile.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	presigned := strings.TrimSpace(result.Stdout())
+	assert.Assert(t, strings.Contains(presigned, "X-Amz-Expires=86400"), presigned)
+
+	resp, err := http.Get(presigned)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, string(body), "content")
+}
+
+// presign --method PUT s3://bucket/object
+func TestPresignPut(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	cmd := s5cmd("presign", "--method", "put", "s3://"+bucket+"/testfile.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	req, err := http.NewRequest(http.MethodPut, strings.TrimSpace(result.Stdout()), strings.NewReader("content"))
+	assert.NilError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "testfile.txt", "content"))
+}
+
+func TestPresignFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			cmd:      []string{"presign", "file.txt"},
+			expected: `ERROR "presign file.txt": source must be a remote object`,
+		},
+		{
+			name:     "wildcard source",
+			cmd:      []string{"presign", "s3://bucket/*.txt"},
+			expected: `ERROR "presign s3://bucket/*.txt": remote source "s3://bucket/*.txt" can not contain glob characters`,
+		},
+		{
+			name:     "unsupported method",
+			cmd:      []string{"presign", "--method", "DELETE", "s3://bucket/file.txt"},
+			expected: `ERROR "presign --method=DELETE s3://bucket/file.txt": method must be one of GET or PUT`,
+		},
+		{
+			name:     "too long expiry",
+			cmd:      []string{"presign", "--expire", "200h", "s3://bucket/file.txt"},
+			expected: `ERROR "presign --expire=200h0m0s s3://bucket/file.txt": expire can not be longer than 168h0m0s`,
+		},
+		{
+			name:     "unsigned requests",
+			cmd:      []string{"--no-sign-request", "presign", "s3://bucket/file.txt"},
+			expected: `ERROR "presign s3://bucket/file.txt": presign can not be used with --no-sign-request`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package e2e
+
+import (
+	"testing"
+
+	"gotest.tools/v3/icmd"
+)
+
+func TestRestoreStatusNotArchivedObjects(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "prefix/a.txt", "content")
+	putFile(t, s3client, bucket, "prefix/b.txt", "content")
+
+	cmd := s5cmd("restore-status", "--wait", "s3://"+bucket+"/prefix/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`not-archived s3://%v/prefix/a.txt`, bucket),
+		1: equals(`not-archived s3://%v/prefix/b.txt`, bucket),
+	}, sortInput(true))
+}
+
+func TestRestoreStatusTimeoutWithoutWait(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("restore-status", "--timeout", "1h", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "restore-status --timeout=1h0m0s s3://%v/*": timeout requires wait flag`, bucket),
+	})
+}
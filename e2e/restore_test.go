@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"testing"
+
+	"gotest.tools/v3/icmd"
+)
+
+// --dry-run restore --days 7 --tier bulk s3://bucket/*
+func TestRestoreDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+
+	cmd := s5cmd("--dry-run", "restore", "--days", "7", "--tier", "bulk", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`accepted s3://%v/testfile1.txt`, bucket),
+		1: equals(`accepted s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+}
+
+func TestRestoreFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			cmd:      []string{"restore", "file.txt"},
+			expected: `ERROR "restore file.txt": source must be remote`,
+		},
+		{
+			name:     "bucket source",
+			cmd:      []string{"restore", "s3://bucket"},
+			expected: `ERROR "restore s3://bucket": source argument must contain wildcard if it is a bucket`,
+		},
+		{
+			name:     "invalid days",
+			cmd:      []string{"restore", "--days", "0", "s3://bucket/*"},
+			expected: `ERROR "restore --days=0 s3://bucket/*": days must be a positive integer`,
+		},
+		{
+			name:     "invalid tier",
+			cmd:      []string{"restore", "--tier", "Fast", "s3://bucket/*"},
+			expected: `ERROR "restore --tier=Fast s3://bucket/*": tier must be one of Standard, Bulk, Expedited`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -2,10 +2,15 @@ package e2e
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/icmd"
@@ -932,6 +937,109 @@ func TestRemoveMultipleS3ObjectsWithExcludeFilters(t *testing.T) {
 	}
 }
 
+// rm --include "*.tmp" s3://bucket/*
+func TestRemoveMultipleS3ObjectsWithIncludeFilter(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	const includePattern = "*.tmp"
+
+	expectedFiles := map[string]string{
+		"readme.md":  "this is a readme file",
+		"a/file.txt": "this is a txt file",
+	}
+
+	nonExpectedFiles := map[string]string{
+		"upload.tmp":   "this is a temporary file",
+		"a/upload.tmp": "this is a temporary file with prefix a",
+	}
+
+	for filename, content := range expectedFiles {
+		putFile(t, s3client, bucket, filename, content)
+	}
+	for filename, content := range nonExpectedFiles {
+		putFile(t, s3client, bucket, filename, content)
+	}
+
+	cmd := s5cmd("rm", "--include", includePattern, "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/a/upload.tmp`, bucket),
+		1: equals(`rm s3://%v/upload.tmp`, bucket),
+	}, sortInput(true))
+
+	// assert s3 objects were not removed
+	for filename, content := range expectedFiles {
+		assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
+	}
+
+	// assert s3 objects should be removed
+	for filename, content := range nonExpectedFiles {
+		err := ensureS3Object(s3client, bucket, filename, content)
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+// rm --include "*.tmp" --exclude "keep*" s3://bucket/*
+func TestRemoveMultipleS3ObjectsWithIncludeAndExcludeFilters(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	expectedFiles := map[string]string{
+		"readme.md":     "this is a readme file",
+		"keep-this.tmp": "this is a temporary file to keep",
+	}
+
+	nonExpectedFiles := map[string]string{
+		"upload.tmp": "this is a temporary file",
+	}
+
+	for filename, content := range expectedFiles {
+		putFile(t, s3client, bucket, filename, content)
+	}
+	for filename, content := range nonExpectedFiles {
+		putFile(t, s3client, bucket, filename, content)
+	}
+
+	cmd := s5cmd("rm", "--include", "*.tmp", "--exclude", "keep*", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/upload.tmp`, bucket),
+	})
+
+	// assert s3 objects were not removed
+	for filename, content := range expectedFiles {
+		assert.Assert(t, ensureS3Object(s3client, bucket, filename, content))
+	}
+
+	// assert s3 objects should be removed
+	for filename, content := range nonExpectedFiles {
+		err := ensureS3Object(s3client, bucket, filename, content)
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
 // rm --exclude "" s3://bucket/*
 func TestRemoveS3ObjectsWithEmptyExcludeFilter(t *testing.T) {
 	t.Parallel()
@@ -1189,3 +1297,458 @@ func TestRemovetNonexistingLocalFile(t *testing.T) {
 		0: equals(`ERROR "rm nonexistentfile": no object found`),
 	}, strictLineCheck(true))
 }
+
+// rm --rate 2 --log-proof proof.log s3://bucket/*
+func TestRemoveMultipleS3ObjectsWithRateAndLogProof(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	filesToContent := map[string]string{
+		"testfile1.txt": "this is a test file 1",
+		"readme.md":     "this is a readme file",
+		"main.py":       "python file",
+	}
+
+	for filename, content := range filesToContent {
+		putFile(t, s3client, bucket, filename, content)
+	}
+
+	cmd := s5cmd("rm", "--rate", "2", "--log-proof", "proof.log", "s3://"+bucket+"/*")
+	cmd.Env = append(cmd.Env, "S5CMD_LOG_PROOF_KEY=secret")
+
+	start := time.Now()
+	result := icmd.RunCmd(cmd)
+	elapsed := time.Since(start)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/main.py`, bucket),
+		1: equals(`rm s3://%v/readme.md`, bucket),
+		2: equals(`rm s3://%v/testfile1.txt`, bucket),
+	}, sortInput(true))
+
+	// 3 objects are deleted in 2 batches, 1 second apart.
+	assert.Assert(t, elapsed >= time.Second, "elapsed %v", elapsed)
+
+	data, err := ioutil.ReadFile(filepath.Join(cmd.Dir, "proof.log"))
+	assert.NilError(t, err)
+
+	assertLines(t, string(data), map[int]compareFunc{
+		0: contains(`"operation":"rm","key":"s3://%v/main.py"`, bucket),
+		1: contains(`"operation":"rm","key":"s3://%v/readme.md"`, bucket),
+		2: contains(`"operation":"rm","key":"s3://%v/testfile1.txt"`, bucket),
+	}, sortInput(true))
+
+	// assert s3 objects
+	for filename, content := range filesToContent {
+		err := ensureS3Object(s3client, bucket, filename, content)
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+// rm --log-proof proof.log s3://bucket/* (without a signing key)
+func TestRemoveS3ObjectsWithLogProofWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "this is a test file 1")
+
+	cmd := s5cmd("rm", "--log-proof", "proof.log", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "rm --log-proof=proof.log s3://%v/*": log-proof requires a signing key in S5CMD_LOG_PROOF_KEY environment variable`, bucket),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "testfile1.txt", "this is a test file 1"))
+}
+
+// rm --delete-markers-only s3://bucket/*
+func TestRemoveDeleteMarkersOnly(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioning is only supported by the in-memory backend.
+	s3client, s5cmd, cleanup := setup(t, withS3Backend("mem"))
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	_, err := s3client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	assert.NilError(t, err)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+
+	// an accidental recursive delete creates delete markers for the objects.
+	cmd := s5cmd("rm", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	err = ensureS3Object(s3client, bucket, "testfile1.txt", "content")
+	assertError(t, err, errS3NoSuchKey)
+
+	cmd = s5cmd("rm", "--delete-markers-only", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/testfile1.txt`, bucket),
+		1: equals(`rm s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+
+	// gofakes3 ignores version ids of DeleteObjects requests, so the
+	// restoration of the objects can't be asserted here.
+}
+
+// rm --delete-markers-only dir/*
+func TestRemoveDeleteMarkersOnlyOfLocalFiles(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("rm", "--delete-markers-only", "dir/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`delete-markers-only can only be used with remote sources`),
+	})
+}
+
+func TestRemoveMultipleS3ObjectsBypassingGovernanceRetention(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	filesToContent := map[string]string{
+		"testfile1.txt": "this is a test file 1",
+		"testfile2.txt": "this is a test file 2",
+	}
+	for filename, content := range filesToContent {
+		putFile(t, s3client, bucket, filename, content)
+	}
+
+	cmd := s5cmd("rm", "--bypass-governance-retention", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/testfile1.txt`, bucket),
+		1: equals(`rm s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`WARNING "rm s3://%v/testfile1.txt": governance retention bypassed`, bucket),
+		1: equals(`WARNING "rm s3://%v/testfile2.txt": governance retention bypassed`, bucket),
+	}, sortInput(true))
+
+	for filename, content := range filesToContent {
+		err := ensureS3Object(s3client, bucket, filename, content)
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+func TestRemoveLocalFilesBypassingGovernanceRetentionFail(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("rm", "--bypass-governance-retention", "dir/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "rm --bypass-governance-retention=true dir/*": bypass-governance-retention can only be used with remote sources`),
+	})
+}
+
+func TestRemoveMultipleS3ObjectsInteractively(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	filenames := []string{"a.txt", "b.txt", "c.txt", "d.txt"}
+	for _, filename := range filenames {
+		putFile(t, s3client, bucket, filename, "content")
+	}
+
+	// the objects are confirmed in the order they are listed.
+	cmd := s5cmd("rm", "-i", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("y\nn\nall\n")))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/a.txt`, bucket),
+		1: equals(`rm s3://%v/c.txt`, bucket),
+		2: equals(`rm s3://%v/d.txt`, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, strings.HasPrefix(result.Stderr(), fmt.Sprintf("rm s3://%v/a.txt? [y/N/all] ", bucket)))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "b.txt", "content"))
+	for _, filename := range []string{"a.txt", "c.txt", "d.txt"} {
+		err := ensureS3Object(s3client, bucket, filename, "content")
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+func TestRemoveMultipleS3ObjectsInteractivelyWithForce(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "a.txt", "content")
+	putFile(t, s3client, bucket, "b.txt", "content")
+
+	// nothing is asked, so the objects are removed even if the input is empty.
+	cmd := s5cmd("--force", "rm", "-i", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("")))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/a.txt`, bucket),
+		1: equals(`rm s3://%v/b.txt`, bucket),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+}
+
+func TestRemoveMultipleS3ObjectsByAge(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "a.txt", "content")
+	putFile(t, s3client, bucket, "tmp/b.txt", "content")
+
+	// the objects are just created, so none of them is older than a day.
+	cmd := s5cmd("rm", "--older-than", "1d", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "a.txt", "content"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "tmp/b.txt", "content"))
+
+	// a single object is checked as well.
+	cmd = s5cmd("rm", "--newer-than", "1d", "s3://"+bucket+"/tmp/b.txt")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/tmp/b.txt`, bucket),
+	})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "a.txt", "content"))
+	err := ensureS3Object(s3client, bucket, "tmp/b.txt", "content")
+	assertError(t, err, errS3NoSuchKey)
+}
+
+func TestRemoveS3ObjectsFromFile(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	filenames := []string{"prefix/a.txt", "prefix/b/c.txt", "prefix/*.txt", "prefix/d.txt", "e.txt"}
+	for _, filename := range filenames {
+		putFile(t, s3client, bucket, filename, "content")
+	}
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("keys.txt", "a.txt\nb/c.txt\n\n*.txt\n"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("rm", "--files-from", workdir.Join("keys.txt"), "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	// the keys are not expanded.
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/prefix/*.txt`, bucket),
+		1: equals(`rm s3://%v/prefix/a.txt`, bucket),
+		2: equals(`rm s3://%v/prefix/b/c.txt`, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "prefix/d.txt", "content"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "e.txt", "content"))
+}
+
+func TestRemoveS3ObjectsFromStdinNullSeparated(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "a.txt", "content")
+	putFile(t, s3client, bucket, "b c.txt", "content")
+	putFile(t, s3client, bucket, "d.txt", "content")
+
+	cmd := s5cmd("rm", "--files-from", "-", "--null", "s3://"+bucket)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("a.txt\x00b c.txt\x00")))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/a.txt`, bucket),
+		1: equals(`rm s3://%v/b c.txt`, bucket),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "d.txt", "content"))
+}
+
+func TestRemoveFromFileFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "object argument",
+			args:     []string{"rm", "--files-from", "keys.txt", "s3://bucket/object"},
+			expected: `ERROR "rm --files-from=keys.txt s3://bucket/object": files-from expects a bucket or prefix argument without wildcards, e.g. s3://bucket/prefix/`,
+		},
+		{
+			name:     "interactive with stdin",
+			args:     []string{"rm", "--files-from", "-", "-i", "s3://bucket/"},
+			expected: `ERROR "rm --interactive=true --files-from=- s3://bucket/": interactive can not be used while reading files-from standard input`,
+		},
+		{
+			name:     "null without files-from",
+			args:     []string{"rm", "--null", "s3://bucket/*"},
+			expected: `ERROR "rm --null=true s3://bucket/*": null can only be used with files-from`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.args...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
+
+// rm --batch-size 2 s3://bucket/*
+func TestRemoveMultipleS3ObjectsWithBatchSize(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	filesToContent := map[string]string{
+		"testfile1.txt": "this is a test file 1",
+		"readme.md":     "this is a readme file",
+		"main.py":       "python file",
+	}
+
+	for filename, content := range filesToContent {
+		putFile(t, s3client, bucket, filename, content)
+	}
+
+	cmd := s5cmd("rm", "--batch-size", "2", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`rm s3://%v/main.py`, bucket),
+		1: equals(`rm s3://%v/readme.md`, bucket),
+		2: equals(`rm s3://%v/testfile1.txt`, bucket),
+	}, sortInput(true))
+
+	for filename, content := range filesToContent {
+		err := ensureS3Object(s3client, bucket, filename, content)
+		assertError(t, err, errS3NoSuchKey)
+	}
+}
+
+func TestRemoveInvalidBatchSize(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("rm", "--batch-size", "0", "s3://bucket/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "rm --batch-size=0 s3://bucket/*": batch-size must be between 1 and 1000`),
+	})
+}
@@ -166,6 +166,64 @@ func TestRunFromFile(t *testing.T) {
 	assertLines(t, result.Stderr(), map[int]compareFunc{})
 }
 
+func TestRunFromFileWithURLRules(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "dataset/file1.txt", "content")
+	putFile(t, s3client, bucket, "dataset/file2.txt", "content")
+
+	rules := fs.NewFile(t, "rules", fs.WithContent(fmt.Sprintf("store://dataset/ s3://%v/dataset/\n", bucket)))
+	defer rules.Remove()
+
+	filecontent := strings.Join([]string{
+		"cp store://dataset/file1.txt store://dataset/copy.txt",
+		"ls store://dataset/file2.txt",
+	}, "\n")
+
+	file := fs.NewFile(t, "prefix", fs.WithContent(filecontent))
+	defer file.Remove()
+
+	cmd := s5cmd("--url-rules", rules.Path(), "run", file.Path())
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: suffix("file2.txt"),
+		1: equals("cp s3://%v/dataset/file1.txt s3://%v/dataset/copy.txt", bucket, bucket),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "dataset/copy.txt", "content"))
+}
+
+// --url-rules with an invalid rule
+func TestRunWithInvalidURLRules(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	rules := fs.NewFile(t, "rules", fs.WithContent("dataset/ s3://bucket/\n"))
+	defer rules.Remove()
+
+	cmd := s5cmd("--url-rules", rules.Path(), "ls", "store://dataset/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`invalid rewrite rule (line: 1): logical prefix "dataset/" must have a scheme`),
+	})
+}
+
 func TestRunFromFileJSON(t *testing.T) {
 	t.Parallel()
 
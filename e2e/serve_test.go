@@ -0,0 +1,171 @@
+package e2e
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/icmd"
+)
+
+// serve --listen 127.0.0.1:<port> s3://bucket/prefix/
+func TestServe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupting the command is not supported on windows")
+	}
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "prefix/file.txt", "0123456789")
+	putFile(t, s3client, bucket, "prefix/dir/nested.txt", "nested")
+	putFile(t, s3client, bucket, "other.txt", "other")
+	putFile(t, s3client, bucket, "prefix/[draft]/notes.txt", "notes")
+	putFile(t, s3client, bucket, "prefix/d/decoy.txt", "decoy")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	cmd := s5cmd("serve", "--listen", address, "s3://"+bucket+"/prefix/")
+	result := icmd.StartCmd(cmd)
+	assert.NilError(t, result.Error)
+
+	endpoint := fmt.Sprintf("http://%v/", address)
+
+	get := func(path string, header http.Header) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, endpoint+path, nil)
+		assert.NilError(t, err)
+		if header != nil {
+			req.Header = header
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NilError(t, err)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NilError(t, err)
+		return resp, string(body)
+	}
+
+	// wait for the server to start.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, err := http.Get(endpoint)
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server is not started")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	resp, body := get("file.txt", nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, body, "0123456789")
+	assert.Equal(t, resp.Header.Get("Accept-Ranges"), "bytes")
+
+	resp, body = get("file.txt", http.Header{"Range": {"bytes=2-5"}})
+	assert.Equal(t, resp.StatusCode, http.StatusPartialContent)
+	assert.Equal(t, body, "2345")
+	assert.Equal(t, resp.Header.Get("Content-Range"), "bytes 2-5/10")
+
+	resp, _ = get("file.txt", http.Header{"Range": {"bytes=20-"}})
+	assert.Equal(t, resp.StatusCode, http.StatusRequestedRangeNotSatisfiable)
+
+	resp, body = get("", nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Assert(t, strings.Contains(body, `<a href="file.txt">file.txt</a>`), body)
+	assert.Assert(t, strings.Contains(body, `<a href="dir/">dir/</a>`), body)
+	assert.Assert(t, !strings.Contains(body, "other.txt"), body)
+
+	// directories are redirected to their indexes.
+	resp, body = get("dir", nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, resp.Request.URL.Path, "/dir/")
+	assert.Assert(t, strings.Contains(body, `<a href="nested.txt">nested.txt</a>`), body)
+
+	// the keys with glob characters are listed literally.
+	resp, body = get("%5Bdraft%5D", nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, resp.Request.URL.Path, "/[draft]/")
+	assert.Assert(t, strings.Contains(body, `<a href="notes.txt">notes.txt</a>`), body)
+	assert.Assert(t, !strings.Contains(body, "decoy.txt"), body)
+
+	resp, _ = get("missing.txt", nil)
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+
+	// the objects outside of the prefix are not served.
+	resp, _ = get("../other.txt", nil)
+	assert.Assert(t, resp.StatusCode != http.StatusOK)
+
+	req, err := http.NewRequest(http.MethodPut, endpoint+"file.txt", strings.NewReader("content"))
+	assert.NilError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusMethodNotAllowed)
+
+	assert.NilError(t, result.Cmd.Process.Signal(os.Interrupt))
+	result = icmd.WaitOnCmd(10*time.Second, result)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`serving s3://%v/prefix/ at %v`, bucket, address),
+	})
+}
+
+func TestServeFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local source",
+			cmd:      []string{"serve", "dir/"},
+			expected: `ERROR "serve dir/": source must be a bucket or a prefix`,
+		},
+		{
+			name:     "wildcard source",
+			cmd:      []string{"serve", "s3://bucket/*.txt"},
+			expected: `ERROR "serve s3://bucket/*.txt": source can not contain glob characters`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
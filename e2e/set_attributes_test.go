@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// set-attributes manifest.csv mapping.csv
+func TestSetAttributesFromMapping(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "data/report 1.csv", "content")
+	putFile(t, s3client, bucket, "data/report 2.csv", "content")
+	putFile(t, s3client, bucket, "data/unmapped.csv", "content")
+
+	manifest := fmt.Sprintf("%[1]v,data/report+1.csv\n%[1]v,data/report+2.csv\n%[1]v,data/unmapped.csv\n", bucket)
+	mapping := "key,content-type\ndata/report 1.csv,text/csv\ndata/report 2.csv,application/json\n"
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("manifest.csv", manifest), fs.WithFile("mapping.csv", mapping))
+	defer workdir.Remove()
+
+	cmd := s5cmd("set-attributes", workdir.Join("manifest.csv"), workdir.Join("mapping.csv"))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`set-attributes s3://%v/data/report 1.csv`, bucket),
+		1: equals(`set-attributes s3://%v/data/report 2.csv`, bucket),
+	}, sortInput(true))
+
+	// gofakes3 drops the standard headers of copies, so only the contents
+	// of the objects can be asserted here.
+	assert.Assert(t, ensureS3Object(s3client, bucket, "data/report 1.csv", "content"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "data/report 2.csv", "content"))
+}
+
+// set-attributes manifest.csv mapping.csv
+func TestSetAttributesInvalidMapping(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	workdir := fs.NewDir(t, t.Name(), fs.WithFile("manifest.csv", "bucket,key\n"), fs.WithFile("mapping.csv", "key,acl\nkey,private\n"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("set-attributes", workdir.Join("manifest.csv"), workdir.Join("mapping.csv"))
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`unsupported mapping column "acl"`),
+	})
+}
+
+// set-attributes s3://bucket/manifest.csv mapping.csv
+func TestSetAttributesRemoteManifest(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("set-attributes", "s3://bucket/manifest.csv", "mapping.csv")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "set-attributes s3://bucket/manifest.csv mapping.csv": manifest and mapping must be local files`),
+	})
+}
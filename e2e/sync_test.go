@@ -2,8 +2,10 @@ package e2e
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -414,7 +416,7 @@ func TestSyncLocalFolderToS3BucketSameObjectsSourceOlder(t *testing.T) {
 
 	result.Assert(t, icmd.Success)
 
-	assertLines(t, result.Stdout(), map[int]compareFunc{
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "sync %va/another_test_file.txt %va/another_test_file.txt": object is newer or same age and object size matches`, src, dst),
 		1: equals(`DEBUG "sync %vmain.py %vmain.py": object is newer or same age and object size matches`, src, dst),
 		2: equals(`DEBUG "sync %vreadme.md %vreadme.md": object is newer or same age and object size matches`, src, dst),
@@ -574,7 +576,7 @@ func TestSyncS3BucketToLocalFolderSameObjectsSourceOlder(t *testing.T) {
 
 	result.Assert(t, icmd.Success)
 
-	assertLines(t, result.Stdout(), map[int]compareFunc{
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "sync %v/a/another_test_file.txt %va/another_test_file.txt": object is newer or same age and object size matches`, bucketPath, dst),
 		1: equals(`DEBUG "sync %v/main.py %vmain.py": object is newer or same age and object size matches`, bucketPath, dst),
 		2: equals(`DEBUG "sync %v/readme.md %vreadme.md": object is newer or same age and object size matches`, bucketPath, dst),
@@ -797,7 +799,7 @@ func TestSyncS3BucketToS3BucketSameSizesSourceOlder(t *testing.T) {
 
 	result.Assert(t, icmd.Success)
 
-	assertLines(t, result.Stdout(), map[int]compareFunc{
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "sync %v/a/another_test_file.txt %va/another_test_file.txt": object is newer or same age and object size matches`, bucketPath, dst),
 		1: equals(`DEBUG "sync %v/main.py %vmain.py": object is newer or same age and object size matches`, bucketPath, dst),
 		2: equals(`DEBUG "sync %v/readme.md %vreadme.md": object is newer or same age and object size matches`, bucketPath, dst),
@@ -860,11 +862,14 @@ func TestSyncS3BucketToLocalFolderSameObjectsSizeOnly(t *testing.T) {
 	result.Assert(t, icmd.Success)
 
 	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/abc/def/main.py %vabc/def/main.py`, bucketPath, dst),
+		1: equals(`cp %v/test.py %vtest.py`, bucketPath, dst),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "sync %v/a/another_test_file.txt %va/another_test_file.txt": object size matches`, bucketPath, dst),
 		1: equals(`DEBUG "sync %v/readme.md %vreadme.md": object size matches`, bucketPath, dst),
 		2: equals(`DEBUG "sync %v/testfile.txt %vtestfile.txt": object size matches`, bucketPath, dst),
-		3: equals(`cp %v/abc/def/main.py %vabc/def/main.py`, bucketPath, dst),
-		4: equals(`cp %v/test.py %vtest.py`, bucketPath, dst),
 	}, sortInput(true))
 
 	expectedFolderLayout := []fs.PathOp{
@@ -940,11 +945,14 @@ func TestSyncLocalFolderToS3BucketSameObjectsSizeOnly(t *testing.T) {
 	result.Assert(t, icmd.Success)
 
 	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vabc/def/main.py %vabc/def/main.py`, src, dst),
+		1: equals(`cp %vtestfile.txt %vtestfile.txt`, src, dst),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "sync %va/another_test_file.txt %va/another_test_file.txt": object size matches`, src, dst),
 		1: equals(`DEBUG "sync %vreadme.md %vreadme.md": object size matches`, src, dst),
 		2: equals(`DEBUG "sync %vtest.py %vtest.py": object size matches`, src, dst),
-		3: equals(`cp %vabc/def/main.py %vabc/def/main.py`, src, dst),
-		4: equals(`cp %vtestfile.txt %vtestfile.txt`, src, dst),
 	}, sortInput(true))
 
 	// expected folder structure without the timestamp.
@@ -1016,10 +1024,13 @@ func TestSyncS3BucketToS3BucketSizeOnly(t *testing.T) {
 	result.Assert(t, icmd.Success)
 
 	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %v/main.py %vmain.py`, bucketPath, dst),
+	}, sortInput(true))
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
 		0: equals(`DEBUG "sync %v/a/another_test_file.txt %va/another_test_file.txt": object size matches`, bucketPath, dst),
 		1: equals(`DEBUG "sync %v/readme.md %vreadme.md": object size matches`, bucketPath, dst),
 		2: equals(`DEBUG "sync %v/testfile.txt %vtestfile.txt": object size matches`, bucketPath, dst),
-		3: equals(`cp %v/main.py %vmain.py`, bucketPath, dst),
 	}, sortInput(true))
 
 	// assert s3 objects in source
@@ -1677,3 +1688,317 @@ func TestIssue435(t *testing.T) {
 		assertError(t, err, errS3NoSuchKey)
 	}
 }
+
+// sync --source-newer-than watermark folder/ s3://bucket
+func TestSyncLocalFolderToS3WithSourceNewerThan(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	now := time.Now()
+	timestamp := fs.WithTimestamps(
+		now.Add(-time.Hour), // access time
+		now.Add(-time.Hour), // mod time
+	)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("testfile.txt", "S: this is a test file", timestamp),
+		fs.WithFile("readme.md", "S: this is a readme file", timestamp),
+	)
+	defer workdir.Remove()
+
+	statedir := fs.NewDir(t, "state")
+	defer statedir.Remove()
+
+	watermark := statedir.Join("watermark")
+
+	src := fmt.Sprintf("%v/", workdir.Path())
+	src = filepath.ToSlash(src)
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	// there is no watermark on the first run, every file is synced.
+	cmd := s5cmd("sync", "--source-newer-than", watermark, src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vreadme.md %vreadme.md`, src, dst),
+		1: equals(`cp %vtestfile.txt %vtestfile.txt`, src, dst),
+	}, sortInput(true))
+
+	data, err := ioutil.ReadFile(watermark)
+	assert.NilError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, len(lines), 3)
+	recorded, err := time.Parse(time.RFC3339Nano, lines[0])
+	assert.NilError(t, err)
+	assert.Assert(t, recorded.Equal(now.Add(-time.Hour)))
+
+	// only the file modified after the watermark and the new file are
+	// synced on the next run, even if the new file is older than the
+	// watermark.
+	updated := fs.WithTimestamps(now, now)
+	older := fs.WithTimestamps(now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+	fs.Apply(t, workdir,
+		fs.WithFile("testfile.txt", "S: this is an updated test file", updated),
+		fs.WithFile("extracted.txt", "S: this is an extracted file", older),
+	)
+
+	cmd = s5cmd("--log", "debug", "sync", "--source-newer-than", watermark, src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vextracted.txt %vextracted.txt`, src, dst),
+		1: equals(`cp %vtestfile.txt %vtestfile.txt`, src, dst),
+	}, sortInput(true))
+
+	// the destination is not listed, the objects are compared with the
+	// recorded state.
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`DEBUG "sync %vreadme.md": object is not newer than watermark`, src),
+	}, sortInput(true))
+
+	expectedS3Content := map[string]string{
+		"testfile.txt":  "S: this is an updated test file",
+		"readme.md":     "S: this is a readme file",
+		"extracted.txt": "S: this is an extracted file",
+	}
+
+	for key, content := range expectedS3Content {
+		assert.Assert(t, ensureS3Object(s3client, bucket, key, content))
+	}
+
+	data, err = ioutil.ReadFile(watermark)
+	assert.NilError(t, err)
+
+	lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, len(lines), 4)
+	recorded, err = time.Parse(time.RFC3339Nano, lines[0])
+	assert.NilError(t, err)
+	assert.Assert(t, recorded.Equal(now))
+}
+
+// sync --rename 's/\.txt$/.md/' folder/ s3://bucket/
+func TestSyncLocalFolderToS3WithRename(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir",
+		fs.WithFile("readme.txt", "S: this is a readme file"),
+		fs.WithDir("a", fs.WithFile("notes.txt", "S: these are notes")),
+	)
+	defer workdir.Remove()
+
+	src := filepath.ToSlash(workdir.Path()) + "/"
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--rename", `s/\.txt$/.md/`, src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %va/notes.txt %va/notes.md`, src, dst),
+		1: equals(`cp %vreadme.txt %vreadme.md`, src, dst),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "a/notes.md", "S: these are notes"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "readme.md", "S: this is a readme file"))
+
+	// the source files are matched with the renamed objects, so nothing is
+	// synced again.
+	cmd = s5cmd("sync", "--rename", `s/\.txt$/.md/`, src, dst)
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+	assertLines(t, result.Stdout(), map[int]compareFunc{})
+}
+
+func TestSyncLocalFolderToS3WithQuota(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "tenant/x.txt", "existing object")
+	putFile(t, s3client, bucket, "tenant/y.txt", "existing object")
+
+	// the files are uploaded into different directories, the quota still
+	// applies to the destination of the sync command.
+	workdir := fs.NewDir(t, bucket,
+		fs.WithDir("a", fs.WithFile("1.txt", "content")),
+		fs.WithDir("b", fs.WithFile("2.txt", "content")),
+	)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("%v/", filepath.ToSlash(workdir.Path()))
+	dst := fmt.Sprintf("s3://%v/tenant/", bucket)
+
+	cmd := s5cmd("sync", "--quota-count", "3", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: contains(`cp %v`, src),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: contains(`quota exceeded: s3://%v/tenant/ would have more than 3 objects`, bucket),
+	})
+}
+
+func TestSyncS3BucketToEmptyFolderWithDeleteInteractively(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "main.py", "S3: this is a python file")
+
+	workdir := fs.NewDir(t, "somedir", fs.WithFile("readme.md", "this is a readme file"))
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("%v/", filepath.ToSlash(workdir.Path()))
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--delete", "-i", src, dst)
+	result := icmd.RunCmd(cmd, icmd.WithStdin(strings.NewReader("n\n")))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vreadme.md %vreadme.md`, src, dst),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`rm %vmain.py? [y/N/all]`, dst),
+	})
+
+	// the extra object is not deleted.
+	assert.Assert(t, ensureS3Object(s3client, bucket, "main.py", "S3: this is a python file"))
+	assert.Assert(t, ensureS3Object(s3client, bucket, "readme.md", "this is a readme file"))
+}
+
+func TestSyncLocalFolderToS3NewerThan(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "extra.txt", "extra content")
+
+	old := time.Now().Add(-48 * time.Hour)
+	workdir := fs.NewDir(t, bucket,
+		fs.WithFile("old.txt", "old content", fs.WithTimestamps(old, old)),
+		fs.WithFile("new.txt", "new content"),
+	)
+	defer workdir.Remove()
+
+	src := fmt.Sprintf("%v/", filepath.ToSlash(workdir.Path()))
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	// the objects missing in the source are deleted regardless of the age.
+	cmd := s5cmd("sync", "--newer-than", "1d", "--delete", src, dst)
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vnew.txt %vnew.txt`, src, dst),
+		1: equals(`rm %vextra.txt`, dst),
+	}, sortInput(true))
+
+	assert.Assert(t, ensureS3Object(s3client, bucket, "new.txt", "new content"))
+	err := ensureS3Object(s3client, bucket, "old.txt", "old content")
+	assertError(t, err, errS3NoSuchKey)
+}
+
+// sync --delete --delete-rate 2 --delete-batch-size 1 folder/ s3://bucket/
+func TestSyncLocalFolderToS3BucketWithDeleteRateAndBatchSize(t *testing.T) {
+	t.Parallel()
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	bucket := s3BucketFromTestName(t)
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, "somedir", fs.WithFile("contributing.md", "S: this is a readme file"))
+	defer workdir.Remove()
+
+	S3Content := map[string]string{
+		"readme.md":    "D: this is a readme file",
+		"dir/main.py":  "D: this is a python file",
+		"testfile.txt": "D: this is a test file",
+	}
+
+	for filename, content := range S3Content {
+		putFile(t, s3client, bucket, filename, content)
+	}
+
+	src := fmt.Sprintf("%v/", workdir.Path())
+	src = filepath.ToSlash(src)
+	dst := fmt.Sprintf("s3://%v/", bucket)
+
+	cmd := s5cmd("sync", "--delete", "--delete-rate", "2", "--delete-batch-size", "1", src, dst)
+
+	start := time.Now()
+	result := icmd.RunCmd(cmd)
+	elapsed := time.Since(start)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp %vcontributing.md %vcontributing.md`, src, dst),
+		1: equals(`rm %vdir/main.py`, dst),
+		2: equals(`rm %vreadme.md`, dst),
+		3: equals(`rm %vtestfile.txt`, dst),
+	}, sortInput(true))
+
+	// 3 objects are deleted in 3 batches, half a second apart.
+	assert.Assert(t, elapsed >= time.Second, "elapsed %v", elapsed)
+
+	for key, content := range S3Content {
+		err := ensureS3Object(s3client, bucket, key, content)
+		if err == nil {
+			t.Errorf("File %v is not deleted from remote : %v\n", key, err)
+		}
+	}
+}
+
+func TestSyncInvalidDeleteBatchSize(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("sync", "--delete", "--delete-batch-size", "2000", "dir/", "s3://bucket/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "sync --delete=true --delete-batch-size=2000 dir/ s3://bucket/": delete-batch-size must be between 1 and 1000`),
+	})
+}
@@ -0,0 +1,111 @@
+package e2e
+
+import (
+	"testing"
+
+	"gotest.tools/v3/icmd"
+)
+
+// --dry-run tag set --tags "env=prod,team=data" s3://bucket/*
+func TestTagSetDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+
+	cmd := s5cmd("--dry-run", "tag", "set", "--tags", "env=prod,team=data", "s3://"+bucket+"/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`tag set s3://%v/testfile1.txt`, bucket),
+		1: equals(`tag set s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+}
+
+// --dry-run tag rm s3://bucket/object
+func TestTagRemoveDryRun(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+
+	cmd := s5cmd("--dry-run", "tag", "rm", "s3://"+bucket+"/testfile1.txt")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`tag rm s3://%v/testfile1.txt`, bucket),
+	})
+}
+
+func TestTagFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "no source",
+			cmd:      []string{"tag", "get"},
+			expected: `ERROR "tag get": expected at least 1 object`,
+		},
+		{
+			name:     "local source",
+			cmd:      []string{"tag", "get", "file.txt"},
+			expected: `ERROR "tag get file.txt": source must be remote`,
+		},
+		{
+			name:     "prefix source",
+			cmd:      []string{"tag", "rm", "s3://bucket/prefix/"},
+			expected: `ERROR "tag rm s3://bucket/prefix/": s3 bucket/prefix cannot be used for tag operations (forgot wildcard character?)`,
+		},
+		{
+			name:     "different buckets",
+			cmd:      []string{"tag", "get", "s3://bucket/a.txt", "s3://other/b.txt"},
+			expected: `ERROR "tag get s3://bucket/a.txt s3://other/b.txt": tag operations on objects of different buckets in a single command are not allowed`,
+		},
+		{
+			name:     "set without tags",
+			cmd:      []string{"tag", "set", "s3://bucket/a.txt"},
+			expected: `ERROR "tag set s3://bucket/a.txt": expected at least 1 tag with --tags, use tag rm to remove the tags`,
+		},
+		{
+			name:     "set invalid tags",
+			cmd:      []string{"tag", "set", "--tags", "env", "s3://bucket/a.txt"},
+			expected: `ERROR "tag set --tags=env s3://bucket/a.txt": invalid tag "env", expected key=value`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
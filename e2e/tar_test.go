@@ -0,0 +1,209 @@
+package e2e
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// readTar returns the names and the contents of the members of the archive
+// in order.
+func readTar(t *testing.T, r io.Reader) ([]string, map[string]string) {
+	t.Helper()
+
+	var names []string
+	contents := map[string]string{}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+
+		content, err := ioutil.ReadAll(tr)
+		assert.NilError(t, err)
+
+		names = append(names, header.Name)
+		contents[header.Name] = string(content)
+	}
+	return names, contents
+}
+
+// tar "s3://bucket/prefix/*" -
+func TestTarToStdout(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	// the large object is fetched in multiple parts.
+	large := strings.Repeat("0123456789abcdef", 3*64*1024+1)
+	putFile(t, s3client, bucket, "prefix/b/large.bin", large)
+	putFile(t, s3client, bucket, "prefix/a.txt", "content of a")
+	putFile(t, s3client, bucket, "prefix/c/small.txt", "s")
+	putFile(t, s3client, bucket, "prefix/c/skip.tmp", "excluded")
+	putFile(t, s3client, bucket, "other.txt", "other")
+
+	cmd := s5cmd("tar", "-c", "2", "-p", "1", "--exclude", "*.tmp", "s3://"+bucket+"/prefix/*", "-")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	names, contents := readTar(t, strings.NewReader(result.Stdout()))
+	assert.DeepEqual(t, names, []string{"a.txt", "b/large.bin", "c/small.txt"})
+	assert.Equal(t, contents["a.txt"], "content of a")
+	assert.Assert(t, contents["b/large.bin"] == large)
+	assert.Equal(t, contents["c/small.txt"], "s")
+}
+
+// tar "s3://bucket/prefix/*" archive.tar.gz
+func TestTarToGzipFile(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "prefix/a.txt", "content of a")
+	putFile(t, s3client, bucket, "prefix/dir/b.txt", "content of b")
+
+	workdir := fs.NewDir(t, bucket)
+	defer workdir.Remove()
+
+	cmd := s5cmd("tar", "s3://"+bucket+"/prefix/*", "archive.tar.gz")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`tar s3://%v/prefix/a.txt archive.tar.gz::a.txt`, bucket),
+		1: equals(`tar s3://%v/prefix/dir/b.txt archive.tar.gz::dir/b.txt`, bucket),
+	})
+
+	f, err := os.Open(workdir.Join("archive.tar.gz"))
+	assert.NilError(t, err)
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	assert.NilError(t, err)
+
+	names, contents := readTar(t, gr)
+	assert.DeepEqual(t, names, []string{"a.txt", "dir/b.txt"})
+	assert.Equal(t, contents["dir/b.txt"], "content of b")
+}
+
+// tar s3://bucket/object archive.tar
+func TestTarSingleObject(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+	putFile(t, s3client, bucket, "prefix/a.txt", "content of a")
+
+	workdir := fs.NewDir(t, bucket)
+	defer workdir.Remove()
+
+	cmd := s5cmd("tar", "s3://"+bucket+"/prefix/a.txt", "archive.tar")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	f, err := os.Open(workdir.Join("archive.tar"))
+	assert.NilError(t, err)
+	defer f.Close()
+
+	names, contents := readTar(t, f)
+	assert.DeepEqual(t, names, []string{"a.txt"})
+	assert.Equal(t, contents["a.txt"], "content of a")
+}
+
+// tar "s3://bucket/prefix/*" archive.tar
+func TestTarNoObjectFound(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket)
+	defer workdir.Remove()
+
+	cmd := s5cmd("tar", "s3://"+bucket+"/prefix/*", "archive.tar")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "tar s3://%v/prefix/* archive.tar": no object found`, bucket),
+	})
+
+	// the incomplete archive is removed.
+	_, err := os.Stat(workdir.Join("archive.tar"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestTarFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "prefix without wildcard",
+			cmd:      []string{"tar", "s3://bucket/prefix/", "-"},
+			expected: `ERROR "tar s3://bucket/prefix/ -": source must be an object or contain glob characters, e.g. "s3://bucket/prefix/*"`,
+		},
+		{
+			name:     "local source",
+			cmd:      []string{"tar", "dir/*", "-"},
+			expected: `ERROR "tar dir/* -": source must be a remote object or contain glob characters`,
+		},
+		{
+			name:     "remote destination",
+			cmd:      []string{"tar", "s3://bucket/prefix/*", "s3://bucket/archive.tar"},
+			expected: `ERROR "tar s3://bucket/prefix/* s3://bucket/archive.tar": destination must be a local file or -`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/icmd"
+)
+
+// undelete s3://bucket/*
+func TestUndeleteObjects(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	// versioning is only supported by the in-memory backend.
+	s3client, s5cmd, cleanup := setup(t, withS3Backend("mem"))
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	_, err := s3client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	assert.NilError(t, err)
+
+	putFile(t, s3client, bucket, "testfile1.txt", "content")
+	putFile(t, s3client, bucket, "testfile2.txt", "content")
+	putFile(t, s3client, bucket, "keep.txt", "content")
+
+	// an accidental delete creates delete markers for the objects.
+	cmd := s5cmd("rm", "s3://"+bucket+"/testfile*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	cmd = s5cmd("undelete", "s3://"+bucket+"/*")
+	result = icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`undelete s3://%v/testfile1.txt`, bucket),
+		1: equals(`undelete s3://%v/testfile2.txt`, bucket),
+	}, sortInput(true))
+
+	// gofakes3 ignores version ids of DeleteObjects requests, so the
+	// restoration of the objects can't be asserted here.
+}
+
+// undelete dir/*
+func TestUndeleteLocalFiles(t *testing.T) {
+	t.Parallel()
+
+	_, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	cmd := s5cmd("undelete", "dir/*")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "undelete dir/*": source must be remote`),
+	})
+}
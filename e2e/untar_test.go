@@ -0,0 +1,348 @@
+package e2e
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// tarEntry is a file or a directory of a test archive.
+type tarEntry struct {
+	name    string
+	content string
+	dir     bool
+}
+
+func createTarArchive(t *testing.T, entries []tarEntry, compress bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gw *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gw)
+	}
+
+	for _, entry := range entries {
+		header := &tar.Header{Typeflag: tar.TypeReg, Name: entry.name, Size: int64(len(entry.content)), Mode: 0644}
+		if entry.dir {
+			header = &tar.Header{Typeflag: tar.TypeDir, Name: entry.name, Mode: 0755}
+		}
+		assert.NilError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(entry.content))
+		assert.NilError(t, err)
+	}
+
+	assert.NilError(t, tw.Close())
+	if gw != nil {
+		assert.NilError(t, gw.Close())
+	}
+	return buf.Bytes()
+}
+
+func createZipArchive(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, entry := range entries {
+		method := zip.Deflate
+		// the files are stored without compression too.
+		if i%2 == 1 {
+			method = zip.Store
+		}
+
+		name := entry.name
+		if entry.dir {
+			_, err := zw.Create(name)
+			assert.NilError(t, err)
+			continue
+		}
+
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		assert.NilError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// untar backup.tar.gz s3://bucket/prefix/
+func TestUntarLocalTarGzip(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	// the large file is streamed to a multipart upload.
+	large := strings.Repeat("0123456789abcdef", 6*64*1024+1)
+	archive := createTarArchive(t, []tarEntry{
+		{name: "dir/", dir: true},
+		{name: "dir/a.txt", content: "content of a"},
+		{name: "./b.txt", content: "content of b"},
+		{name: "../escape.txt", content: "not outside"},
+		{name: "large.bin", content: large},
+		{name: "skip.tmp", content: "excluded"},
+	}, true)
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("backup.tar.gz", string(archive)))
+	defer workdir.Remove()
+
+	cmd := s5cmd("untar", "-p", "5", "--exclude", "*.tmp", "backup.tar.gz", "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`untar backup.tar.gz::b.txt s3://%v/prefix/b.txt`, bucket),
+		1: equals(`untar backup.tar.gz::dir/a.txt s3://%v/prefix/dir/a.txt`, bucket),
+		2: equals(`untar backup.tar.gz::escape.txt s3://%v/prefix/escape.txt`, bucket),
+		3: equals(`untar backup.tar.gz::large.bin s3://%v/prefix/large.bin`, bucket),
+	}, sortInput(true))
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/dir/a.txt", "content of a"))
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/b.txt", "content of b"))
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/escape.txt", "not outside"))
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/large.bin", large))
+
+	err := ensureS3Object(s3client, bucket, "prefix/skip.tmp", "excluded")
+	assert.ErrorContains(t, err, "")
+}
+
+// untar archive.tar s3://bucket/prefix/
+func TestUntarDuplicateNames(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	// the earlier file takes longer to upload, the later one still wins.
+	earlier := strings.Repeat("earlier content ", 256*1024)
+	archive := createTarArchive(t, []tarEntry{
+		{name: "a.txt", content: earlier},
+		{name: "b.txt", content: "content of b"},
+		{name: "./a.txt", content: "later content"},
+	}, false)
+
+	cmd := s5cmd("untar", "-p", "5", "-", "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(bytes.NewReader(archive)))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`untar -::a.txt s3://%v/prefix/a.txt`, bucket),
+		1: equals(`untar -::a.txt s3://%v/prefix/a.txt`, bucket),
+		2: equals(`untar -::b.txt s3://%v/prefix/b.txt`, bucket),
+	}, sortInput(true))
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/a.txt", "later content"))
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/b.txt", "content of b"))
+}
+
+// untar - s3://bucket/prefix/
+func TestUntarFromStdin(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	archive := createTarArchive(t, []tarEntry{
+		{name: "a.txt", content: "content of a"},
+	}, false)
+
+	cmd := s5cmd("untar", "-", "s3://"+bucket+"/prefix")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(bytes.NewReader(archive)))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`untar -::a.txt s3://%v/prefix/a.txt`, bucket),
+	})
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/a.txt", "content of a"))
+}
+
+// untar s3://bucket/archive.tar s3://bucket/prefix/
+func TestUntarRemoteTar(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	archive := createTarArchive(t, []tarEntry{
+		{name: "a.txt", content: "content of a"},
+		{name: "dir/b.txt", content: "content of b"},
+	}, false)
+	putFile(t, s3client, bucket, "archive.tar", string(archive))
+
+	cmd := s5cmd("untar", "s3://"+bucket+"/archive.tar", "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`untar s3://%v/archive.tar::a.txt s3://%v/prefix/a.txt`, bucket, bucket),
+		1: equals(`untar s3://%v/archive.tar::dir/b.txt s3://%v/prefix/dir/b.txt`, bucket, bucket),
+	}, sortInput(true))
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/a.txt", "content of a"))
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/dir/b.txt", "content of b"))
+}
+
+// untar s3://bucket/archive.zip s3://bucket/prefix/
+func TestUntarRemoteZip(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	archive := createZipArchive(t, []tarEntry{
+		{name: "dir/", dir: true},
+		{name: "dir/a.txt", content: strings.Repeat("compressed ", 100)},
+		{name: "b.txt", content: "stored"},
+	})
+	putFile(t, s3client, bucket, "archive.zip", string(archive))
+
+	cmd := s5cmd("untar", "s3://"+bucket+"/archive.zip", "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`untar s3://%v/archive.zip::b.txt s3://%v/prefix/b.txt`, bucket, bucket),
+		1: equals(`untar s3://%v/archive.zip::dir/a.txt s3://%v/prefix/dir/a.txt`, bucket, bucket),
+	}, sortInput(true))
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/dir/a.txt", strings.Repeat("compressed ", 100)))
+	assert.NilError(t, ensureS3Object(s3client, bucket, "prefix/b.txt", "stored"))
+}
+
+// untar archive.zip s3://bucket/
+func TestUntarLocalZip(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	archive := createZipArchive(t, []tarEntry{
+		{name: "a.txt", content: "content of a"},
+		{name: "dir/b.txt", content: "content of b"},
+	})
+
+	workdir := fs.NewDir(t, bucket, fs.WithFile("archive.zip", string(archive)))
+	defer workdir.Remove()
+
+	cmd := s5cmd("untar", "archive.zip", "s3://"+bucket)
+	result := icmd.RunCmd(cmd, withWorkingDir(workdir))
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`untar archive.zip::a.txt s3://%v/a.txt`, bucket),
+		1: equals(`untar archive.zip::dir/b.txt s3://%v/dir/b.txt`, bucket),
+	}, sortInput(true))
+
+	assert.NilError(t, ensureS3Object(s3client, bucket, "a.txt", "content of a"))
+	assert.NilError(t, ensureS3Object(s3client, bucket, "dir/b.txt", "content of b"))
+}
+
+// untar - s3://bucket/prefix/
+func TestUntarZipFromStdin(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	archive := createZipArchive(t, []tarEntry{
+		{name: "a.txt", content: "content of a"},
+	})
+
+	cmd := s5cmd("untar", "-", "s3://"+bucket+"/prefix/")
+	result := icmd.RunCmd(cmd, icmd.WithStdin(bytes.NewReader(archive)))
+
+	result.Assert(t, icmd.Expected{ExitCode: 1})
+
+	assertLines(t, result.Stderr(), map[int]compareFunc{
+		0: equals(`ERROR "untar - s3://%v/prefix/": zip archives can not be read from stdin`, bucket),
+	})
+}
+
+func TestUntarFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "local destination",
+			cmd:      []string{"untar", "backup.tar", "dir/"},
+			expected: `ERROR "untar backup.tar dir/": destination must be a bucket or a prefix`,
+		},
+		{
+			name:     "prefix source",
+			cmd:      []string{"untar", "s3://bucket/prefix/", "s3://bucket/"},
+			expected: `ERROR "untar s3://bucket/prefix/ s3://bucket/": source must be an archive or -`,
+		},
+		{
+			name:     "wildcard source",
+			cmd:      []string{"untar", "s3://bucket/*.tar", "s3://bucket/"},
+			expected: `ERROR "untar s3://bucket/*.tar s3://bucket/": source can not contain glob characters`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
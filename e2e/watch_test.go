@@ -0,0 +1,160 @@
+package e2e
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/icmd"
+)
+
+// watch --exclude "*.tmp" dir/ s3://bucket/prefix/
+func TestWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupting the command is not supported on windows")
+	}
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithDir("logs"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("watch", "--debounce", "100ms", "--exclude", "*.tmp", "logs/", "s3://"+bucket+"/prefix/")
+	cmd.Dir = workdir.Path()
+	result := icmd.StartCmd(cmd)
+	assert.NilError(t, result.Error)
+
+	// wait for the command to start watching the directory.
+	time.Sleep(time.Second)
+
+	logs := workdir.Join("logs")
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(logs, "app.tmp"), []byte("partial"), 0644))
+	assert.NilError(t, os.MkdirAll(filepath.Join(logs, "nested"), 0755))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(logs, "nested", "app.log"), []byte("content"), 0644))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for ensureS3Object(s3client, bucket, "prefix/nested/app.log", "content") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("file is not uploaded")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	assert.NilError(t, result.Cmd.Process.Signal(os.Interrupt))
+	result = icmd.WaitOnCmd(10*time.Second, result)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp logs/nested/app.log s3://%v/prefix/nested/app.log`, bucket),
+	})
+
+	err := ensureS3Object(s3client, bucket, "prefix/app.tmp", "partial")
+	assert.ErrorContains(t, err, "")
+}
+
+// watch dir/ s3://bucket/prefix/
+func TestWatchRemovedFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupting the command is not supported on windows")
+	}
+	t.Parallel()
+
+	bucket := s3BucketFromTestName(t)
+
+	s3client, s5cmd, cleanup := setup(t)
+	defer cleanup()
+
+	createBucket(t, s3client, bucket)
+
+	workdir := fs.NewDir(t, bucket, fs.WithDir("logs"))
+	defer workdir.Remove()
+
+	cmd := s5cmd("watch", "--debounce", "500ms", "logs/", "s3://"+bucket+"/prefix/")
+	cmd.Dir = workdir.Path()
+	result := icmd.StartCmd(cmd)
+	assert.NilError(t, result.Error)
+
+	// wait for the command to start watching the directory.
+	time.Sleep(time.Second)
+
+	// the files and the directories removed before they are uploaded are
+	// skipped.
+	logs := workdir.Join("logs")
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(logs, "app.log.partial"), []byte("partial"), 0644))
+	assert.NilError(t, os.Rename(filepath.Join(logs, "app.log.partial"), filepath.Join(logs, "app.log")))
+	assert.NilError(t, os.MkdirAll(filepath.Join(logs, "removed", "nested"), 0755))
+	assert.NilError(t, os.RemoveAll(filepath.Join(logs, "removed")))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for ensureS3Object(s3client, bucket, "prefix/app.log", "partial") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("file is not uploaded")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	assert.NilError(t, result.Cmd.Process.Signal(os.Interrupt))
+	result = icmd.WaitOnCmd(10*time.Second, result)
+
+	result.Assert(t, icmd.Success)
+
+	assertLines(t, result.Stdout(), map[int]compareFunc{
+		0: equals(`cp logs/app.log s3://%v/prefix/app.log`, bucket),
+	})
+	assertLines(t, result.Stderr(), map[int]compareFunc{})
+}
+
+func TestWatchFail(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		cmd      []string
+		expected string
+	}{
+		{
+			name:     "remote source",
+			cmd:      []string{"watch", "s3://bucket/logs/", "s3://bucket/prefix/"},
+			expected: `ERROR "watch s3://bucket/logs/ s3://bucket/prefix/": source must be a local directory`,
+		},
+		{
+			name:     "local destination",
+			cmd:      []string{"watch", ".", "dir/"},
+			expected: `ERROR "watch . dir/": destination must be a bucket or a prefix`,
+		},
+		{
+			name:     "missing destination",
+			cmd:      []string{"watch", "."},
+			expected: `ERROR "watch .": expected source and destination arguments`,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, s5cmd, cleanup := setup(t)
+			defer cleanup()
+
+			result := icmd.RunCmd(s5cmd(tc.cmd...))
+
+			result.Assert(t, icmd.Expected{ExitCode: 1})
+
+			assertLines(t, result.Stderr(), map[int]compareFunc{
+				0: equals(tc.expected),
+			})
+		})
+	}
+}
@@ -69,7 +69,7 @@ func IsCancelation(err error) bool {
 
 var (
 	// ErrObjectExists indicates a specified object already exists.
-	ErrObjectExists = fmt.Errorf("object already exists")
+	ErrObjectExists = storage.ErrObjectExists
 
 	// ErrObjectIsNewer indicates a specified object is newer or same age.
 	ErrObjectIsNewer = fmt.Errorf("object is newer or same age")
@@ -79,13 +79,20 @@ var (
 
 	// ErrObjectIsNewerAndSizesMatch indicates the specified object is newer or same age and sizes of objects match.
 	ErrObjectIsNewerAndSizesMatch = fmt.Errorf("%v and %v", ErrObjectIsNewer, ErrObjectSizesMatch)
+
+	// ErrObjectIsNotNewerThanWatermark indicates a specified object is not
+	// modified after the watermark recorded by the previous run.
+	ErrObjectIsNotNewerThanWatermark = fmt.Errorf("object is not newer than watermark")
+
+	// ErrNotConfirmed indicates an overwrite of an object is declined.
+	ErrNotConfirmed = fmt.Errorf("overwrite is not confirmed")
 )
 
 // IsWarning checks if given error is either ErrObjectExists,
-// ErrObjectIsNewer or ErrObjectSizesMatch.
+// ErrObjectIsNewer, ErrObjectSizesMatch or ErrNotConfirmed.
 func IsWarning(err error) bool {
 	switch err {
-	case ErrObjectExists, ErrObjectIsNewer, ErrObjectSizesMatch, ErrObjectIsNewerAndSizesMatch:
+	case ErrObjectExists, ErrObjectIsNewer, ErrObjectSizesMatch, ErrObjectIsNewerAndSizesMatch, ErrNotConfirmed:
 		return true
 	}
 
@@ -17,30 +17,51 @@ var outputCh = make(chan output, 10000)
 
 var global *Logger
 
+// diagnostics is the file that debug logs and statistics are printed to.
+// Results are always printed to standard output and warnings and errors are
+// always printed to standard error, so the results can be piped into other
+// tools without mixing them with diagnostics.
+var diagnostics = os.Stderr
+
 // Init inits global logger.
 func Init(level string, json bool) {
 	global = New(level, json)
 }
 
+// SetDiagnosticsOutput sets the file that debug logs and statistics are
+// printed to. It must be called before any message is logged.
+func SetDiagnosticsOutput(std *os.File) {
+	diagnostics = std
+}
+
 // Trace prints message in trace mode.
 func Trace(msg Message) {
-	global.printf(levelTrace, msg, os.Stdout)
+	global.printf(levelTrace, msg, diagnostics)
 }
 
 // Debug prints message in debug mode.
 func Debug(msg Message) {
-	global.printf(levelDebug, msg, os.Stdout)
+	global.printf(levelDebug, msg, diagnostics)
 }
 
-// Info prints message in info mode.
+// Info prints message in info mode. If sampling is enabled, only a sample of
+// the results of the operations are printed.
 func Info(msg Message) {
+	if info, ok := msg.(InfoMessage); ok && sampling != nil && !sampling.sample(info) {
+		return
+	}
 	global.printf(levelInfo, msg, os.Stdout)
 }
 
 // Stat prints stat message regardless of the log level with info print formatting.
 // It uses printfHelper instead of printf to ignore the log level condition.
 func Stat(msg Message) {
-	global.printfHelper(levelInfo, msg, os.Stdout)
+	global.printfHelper(levelInfo, msg, diagnostics)
+}
+
+// Warning prints message in warning mode.
+func Warning(msg Message) {
+	global.printf(levelWarning, msg, os.Stderr)
 }
 
 // Error prints message in error mode.
@@ -111,6 +132,7 @@ const (
 	levelTrace logLevel = iota
 	levelDebug
 	levelInfo
+	levelWarning
 	levelError
 )
 
@@ -119,6 +141,8 @@ func (l logLevel) String() string {
 	switch l {
 	case levelInfo:
 		return ""
+	case levelWarning:
+		return "WARNING "
 	case levelError:
 		return "ERROR "
 	case levelDebug:
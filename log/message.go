@@ -56,6 +56,27 @@ func (e ErrorMessage) JSON() string {
 	return strutil.JSON(e)
 }
 
+// WarningMessage is a generic message structure for operations that are
+// skipped or partially succeeded but do not fail the command.
+type WarningMessage struct {
+	Operation string `json:"operation,omitempty"`
+	Command   string `json:"command,omitempty"`
+	Warning   string `json:"warning"`
+}
+
+// String is the string representation of WarningMessage.
+func (w WarningMessage) String() string {
+	if w.Command == "" {
+		return w.Warning
+	}
+	return fmt.Sprintf("%q: %v", w.Command, w.Warning)
+}
+
+// JSON is the JSON representation of WarningMessage.
+func (w WarningMessage) JSON() string {
+	return strutil.JSON(w)
+}
+
 // DebugMessage is a generic message structure for unsuccessful operations.
 type DebugMessage struct {
 	Operation string `json:"operation,omitempty"`
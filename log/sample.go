@@ -0,0 +1,111 @@
+package log
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/peak/s5cmd/strutil"
+)
+
+// sampler logs only a sample of the results of the operations, while
+// counting all of them. Warnings and errors are not sampled.
+type sampler struct {
+	rate float64
+
+	mu     sync.Mutex
+	seen   int64
+	logged int64
+	counts map[string]int64
+}
+
+// sampling is the sampler of the results, nil if all of them are logged.
+var sampling *sampler
+
+// SetSampleRate sets the fraction of the results of the operations to be
+// logged, e.g. 0.01 to log one of each 100 results. It must be called before
+// any message is logged.
+func SetSampleRate(rate float64) {
+	if rate <= 0 || rate >= 1 {
+		sampling = nil
+		return
+	}
+	sampling = &sampler{
+		rate:   rate,
+		counts: map[string]int64{},
+	}
+}
+
+// sample counts the result and reports whether it is in the sample. The
+// results are spread evenly, starting from the first one.
+func (s *sampler) sample(msg InfoMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[msg.Operation]++
+	n := float64(s.seen)
+	s.seen++
+
+	if math.Ceil((n+1)*s.rate) > math.Ceil(n*s.rate) {
+		s.logged++
+		return true
+	}
+	return false
+}
+
+// SampleSummary returns the summary of the results logged with sampling, or
+// nil if sampling is disabled.
+func SampleSummary() Message {
+	if sampling == nil {
+		return nil
+	}
+
+	sampling.mu.Lock()
+	defer sampling.mu.Unlock()
+
+	counts := make(map[string]int64, len(sampling.counts))
+	for op, count := range sampling.counts {
+		counts[op] = count
+	}
+
+	return SampleMessage{
+		Rate:    sampling.rate,
+		Total:   sampling.seen,
+		Logged:  sampling.logged,
+		Results: counts,
+	}
+}
+
+// SampleMessage is the summary of the results logged with sampling.
+type SampleMessage struct {
+	Rate    float64          `json:"sample_rate"`
+	Total   int64            `json:"total"`
+	Logged  int64            `json:"logged"`
+	Results map[string]int64 `json:"results"`
+}
+
+// String is the string representation of SampleMessage.
+func (s SampleMessage) String() string {
+	ops := make([]string, 0, len(s.Results))
+	for op := range s.Results {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	results := make([]string, 0, len(ops))
+	for _, op := range ops {
+		results = append(results, fmt.Sprintf("%v=%d", op, s.Results[op]))
+	}
+
+	return fmt.Sprintf(
+		"logged %d of %d successful operations (%v%% sample): %v",
+		s.Logged, s.Total, s.Rate*100, strings.Join(results, " "),
+	)
+}
+
+// JSON is the JSON representation of SampleMessage.
+func (s SampleMessage) JSON() string {
+	return strutil.JSON(s)
+}
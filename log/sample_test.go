@@ -0,0 +1,38 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestSamplerSample(t *testing.T) {
+	s := &sampler{
+		rate:   0.3,
+		counts: map[string]int64{},
+	}
+
+	var sampled []int
+	for i := 0; i < 10; i++ {
+		op := "cp"
+		if i%2 == 1 {
+			op = "rm"
+		}
+		if s.sample(InfoMessage{Operation: op}) {
+			sampled = append(sampled, i)
+		}
+	}
+
+	// the first result is always logged and the rest are spread evenly.
+	expected := []int{0, 3, 6}
+	if len(sampled) != len(expected) {
+		t.Fatalf("expected results %v to be sampled, got %v", expected, sampled)
+	}
+	for i := range expected {
+		if sampled[i] != expected[i] {
+			t.Fatalf("expected results %v to be sampled, got %v", expected, sampled)
+		}
+	}
+
+	if s.counts["cp"] != 5 || s.counts["rm"] != 5 || s.logged != 3 {
+		t.Errorf("unexpected counts: %v, logged %v", s.counts, s.logged)
+	}
+}
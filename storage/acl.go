@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// ACL is the access control list of an object.
+type ACL struct {
+	Owner  string  `json:"owner"`
+	Grants []Grant `json:"grants"`
+}
+
+// Grant is a permission granted to a grantee.
+type Grant struct {
+	// Grantee is the canonical user id, the email address or the group URI
+	// of the grantee, depending on its type.
+	Grantee    string `json:"grantee"`
+	Type       string `json:"type"`
+	Permission string `json:"permission"`
+}
+
+// ACL returns the access control list of the given object.
+func (s *S3) ACL(ctx context.Context, u *url.URL) (*ACL, error) {
+	output, err := s.api.GetObjectAclWithContext(ctx, &s3.GetObjectAclInput{
+		Bucket:       aws.String(u.Bucket),
+		Key:          aws.String(s.objectKey(u.Path)),
+		RequestPayer: s.RequestPayer(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	acl := &ACL{Grants: make([]Grant, 0, len(output.Grants))}
+	if output.Owner != nil {
+		acl.Owner = aws.StringValue(output.Owner.ID)
+	}
+	for _, grant := range output.Grants {
+		if grant.Grantee == nil {
+			continue
+		}
+
+		grantee := aws.StringValue(grant.Grantee.ID)
+		switch aws.StringValue(grant.Grantee.Type) {
+		case s3.TypeGroup:
+			grantee = aws.StringValue(grant.Grantee.URI)
+		case s3.TypeAmazonCustomerByEmail:
+			grantee = aws.StringValue(grant.Grantee.EmailAddress)
+		}
+
+		acl.Grants = append(acl.Grants, Grant{
+			Grantee:    grantee,
+			Type:       aws.StringValue(grant.Grantee.Type),
+			Permission: aws.StringValue(grant.Permission),
+		})
+	}
+	return acl, nil
+}
+
+// SetACL replaces the access control list of the given object with the given
+// canned ACL, e.g. public-read.
+func (s *S3) SetACL(ctx context.Context, u *url.URL, cannedACL string) error {
+	if s.dryRun {
+		simulation.add("PutObjectAcl", 1, 0)
+		return nil
+	}
+
+	_, err := s.api.PutObjectAclWithContext(ctx, &s3.PutObjectAclInput{
+		Bucket:       aws.String(u.Bucket),
+		Key:          aws.String(s.objectKey(u.Path)),
+		ACL:          aws.String(cannedACL),
+		RequestPayer: s.RequestPayer(),
+	})
+	return err
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3ACL(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		output := r.Data.(*s3.GetObjectAclOutput)
+		output.Owner = &s3.Owner{ID: aws.String("owner-id")}
+		output.Grants = []*s3.Grant{
+			{
+				Grantee:    &s3.Grantee{ID: aws.String("owner-id"), Type: aws.String(s3.TypeCanonicalUser)},
+				Permission: aws.String(s3.PermissionFullControl),
+			},
+			{
+				Grantee:    &s3.Grantee{URI: aws.String("http://acs.amazonaws.com/groups/global/AllUsers"), Type: aws.String(s3.TypeGroup)},
+				Permission: aws.String(s3.PermissionRead),
+			},
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	acl, err := mockS3.ACL(context.Background(), u)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, acl, &ACL{
+		Owner: "owner-id",
+		Grants: []Grant{
+			{Grantee: "owner-id", Type: s3.TypeCanonicalUser, Permission: s3.PermissionFullControl},
+			{Grantee: "http://acs.amazonaws.com/groups/global/AllUsers", Type: s3.TypeGroup, Permission: s3.PermissionRead},
+		},
+	})
+}
+
+func TestS3SetACL(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var input *s3.PutObjectAclInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input = r.Params.(*s3.PutObjectAclInput)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.SetACL(context.Background(), u, s3.ObjectCannedACLPublicRead)
+	assert.NilError(t, err)
+	assert.Equal(t, aws.StringValue(input.ACL), s3.ObjectCannedACLPublicRead)
+	assert.Equal(t, aws.StringValue(input.Key), "key")
+}
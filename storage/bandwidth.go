@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/strutil"
+)
+
+// bandwidthOperations are the operations whose request or response bodies
+// carry the contents of the objects.
+var bandwidthOperations = map[string]struct{}{
+	"GetObject":  {},
+	"PutObject":  {},
+	"UploadPart": {},
+}
+
+// maxBandwidthChunk is the maximum number of the bytes read at once by a
+// limited body, so that the transfers are paced smoothly.
+const maxBandwidthChunk = 64 * 1024
+
+// BandwidthWindow is the bandwidth cap of the transfers during a time of the
+// day.
+type BandwidthWindow struct {
+	// Start and End are the durations since the midnight of the local time.
+	// The window wraps around the midnight if End is before Start. The
+	// window applies to the whole day if both of them are zero.
+	Start time.Duration
+	End   time.Duration
+
+	// Rate is the maximum number of the bytes transferred per second, zero
+	// if it is unlimited.
+	Rate int64
+}
+
+// contains reports whether the given time of the day is in the window.
+func (w BandwidthWindow) contains(clock time.Duration) bool {
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return clock >= w.Start && clock < w.End
+	default:
+		return clock >= w.Start || clock < w.End
+	}
+}
+
+// BandwidthSchedule is the list of the bandwidth caps of the times of the
+// day. The first window containing the time applies, the transfers are
+// unlimited if none of them does.
+type BandwidthSchedule []BandwidthWindow
+
+// rateAt returns the bandwidth cap at the given time, zero if it is
+// unlimited.
+func (s BandwidthSchedule) rateAt(t time.Time) int64 {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	clock := t.Sub(midnight)
+	for _, window := range s {
+		if window.contains(clock) {
+			return window.Rate
+		}
+	}
+	return 0
+}
+
+// bandwidthLimiter paces the bytes of the transfers of all of the sessions to
+// the cap of the current time of the day, so that the cap changes as the time
+// passes through the windows of a long-running command.
+type bandwidthLimiter struct {
+	mu       sync.Mutex
+	schedule BandwidthSchedule
+	// next is the earliest time the next bytes can be transferred.
+	next time.Time
+	// rate is the last cap the transfers are paced to.
+	rate int64
+}
+
+var transferLimiter = &bandwidthLimiter{}
+
+// SetBandwidthSchedule sets the bandwidth caps of the transfers. It must be
+// called before any request is sent.
+func SetBandwidthSchedule(schedule BandwidthSchedule) {
+	transferLimiter.mu.Lock()
+	defer transferLimiter.mu.Unlock()
+	transferLimiter.schedule = schedule
+}
+
+// reserve returns the time the given number of bytes can be transferred at,
+// and reserves their share of the bandwidth.
+func (l *bandwidthLimiter) reserve(now time.Time, n int) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := l.schedule.rateAt(now)
+	if rate != l.rate {
+		l.rate = rate
+		l.logRate()
+	}
+	if rate <= 0 {
+		return now
+	}
+
+	at := now
+	if l.next.After(at) {
+		at = l.next
+	}
+	l.next = at.Add(time.Duration(float64(n) / float64(rate) * float64(time.Second)))
+	return at
+}
+
+func (l *bandwidthLimiter) logRate() {
+	limit := "unlimited"
+	if l.rate > 0 {
+		limit = fmt.Sprintf("%s/s", strutil.HumanizeBytes(l.rate))
+	}
+	msg := log.DebugMessage{Err: fmt.Sprintf("bandwidth of the transfers is %s", limit)}
+	log.Debug(msg)
+}
+
+// wait blocks until the given number of bytes can be transferred or the
+// context is done.
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	delay := time.Until(l.reserve(time.Now(), n))
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limitedBody is a request or response body whose reads are paced by the
+// bandwidth limiter.
+type limitedBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *bandwidthLimiter
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if len(p) > maxBandwidthChunk {
+		p = p[:maxBandwidthChunk]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if werr := b.limiter.wait(b.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (l *bandwidthLimiter) isSet() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.schedule) > 0
+}
+
+// limitRequest paces the request body of the uploads before each attempt to
+// send it.
+func (l *bandwidthLimiter) limitRequest(r *request.Request) {
+	if _, ok := bandwidthOperations[r.Operation.Name]; !ok || !l.isSet() {
+		return
+	}
+	if body := r.HTTPRequest.Body; body != nil && body != http.NoBody {
+		r.HTTPRequest.Body = &limitedBody{ReadCloser: body, ctx: r.Context(), limiter: l}
+	}
+}
+
+// limitResponse paces the response body of the downloads.
+func (l *bandwidthLimiter) limitResponse(r *request.Request) {
+	if _, ok := bandwidthOperations[r.Operation.Name]; !ok || !l.isSet() {
+		return
+	}
+	if r.HTTPResponse != nil && r.HTTPResponse.Body != nil {
+		r.HTTPResponse.Body = &limitedBody{ReadCloser: r.HTTPResponse.Body, ctx: r.Context(), limiter: l}
+	}
+}
+
+// install adds the handlers pacing the bodies of the transfers to the given
+// handlers.
+func (l *bandwidthLimiter) install(handlers *request.Handlers) {
+	handlers.Send.PushFront(l.limitRequest)
+	handlers.UnmarshalMeta.PushFront(l.limitResponse)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+)
+
+func TestBandwidthScheduleRateAt(t *testing.T) {
+	t.Parallel()
+
+	schedule := BandwidthSchedule{
+		{Start: 9 * time.Hour, End: 18 * time.Hour, Rate: 50},
+		{Start: 22 * time.Hour, End: 6 * time.Hour, Rate: 0},
+		{Rate: 10},
+	}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 6, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	assert.Equal(t, schedule.rateAt(at(9, 0)), int64(50))
+	assert.Equal(t, schedule.rateAt(at(17, 59)), int64(50))
+	assert.Equal(t, schedule.rateAt(at(18, 0)), int64(10))
+	assert.Equal(t, schedule.rateAt(at(23, 0)), int64(0))
+	assert.Equal(t, schedule.rateAt(at(3, 0)), int64(0))
+	assert.Equal(t, schedule.rateAt(at(6, 0)), int64(10))
+
+	// the transfers are unlimited outside of the windows.
+	assert.Equal(t, schedule[:1].rateAt(at(20, 0)), int64(0))
+}
+
+func TestBandwidthLimiterReserve(t *testing.T) {
+	log.Init("error", false)
+
+	limiter := &bandwidthLimiter{schedule: BandwidthSchedule{{Rate: 1000}}}
+
+	now := time.Now()
+	assert.Equal(t, limiter.reserve(now, 500), now)
+	assert.Equal(t, limiter.reserve(now, 500), now.Add(500*time.Millisecond))
+	assert.Equal(t, limiter.reserve(now, 100), now.Add(time.Second))
+
+	// the bytes are not delayed once the cap is lifted.
+	limiter.schedule = nil
+	assert.Equal(t, limiter.reserve(now, 500), now)
+}
+
+func TestLimitedBody(t *testing.T) {
+	log.Init("error", false)
+
+	limiter := &bandwidthLimiter{schedule: BandwidthSchedule{{Rate: 100 * 1024}}}
+	body := &limitedBody{
+		ReadCloser: ioutil.NopCloser(strings.NewReader(strings.Repeat("x", 30*1024))),
+		ctx:        context.Background(),
+		limiter:    limiter,
+	}
+
+	start := time.Now()
+	content, err := ioutil.ReadAll(body)
+	assert.NilError(t, err)
+	assert.Equal(t, len(content), 30*1024)
+	assert.Assert(t, time.Since(start) >= 100*time.Millisecond)
+
+	// the reads waiting for the bandwidth are canceled with their context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	body = &limitedBody{
+		ReadCloser: ioutil.NopCloser(strings.NewReader(strings.Repeat("x", 30*1024))),
+		ctx:        ctx,
+		limiter:    limiter,
+	}
+	_, err = ioutil.ReadAll(body)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/strutil"
+)
+
+// BucketInfo is the summary of the configuration of a bucket.
+type BucketInfo struct {
+	Name              string             `json:"name"`
+	Region            string             `json:"region"`
+	Versioning        string             `json:"versioning"`
+	Encryption        string             `json:"encryption"`
+	LifecycleRules    int                `json:"lifecycle_rules"`
+	PublicAccessBlock *PublicAccessBlock `json:"public_access_block"`
+	ObjectLock        bool               `json:"object_lock"`
+	Accelerate        string             `json:"accelerate"`
+}
+
+// PublicAccessBlock is the public access block configuration of a bucket.
+type PublicAccessBlock struct {
+	BlockPublicAcls       bool `json:"block_public_acls"`
+	IgnorePublicAcls      bool `json:"ignore_public_acls"`
+	BlockPublicPolicy     bool `json:"block_public_policy"`
+	RestrictPublicBuckets bool `json:"restrict_public_buckets"`
+}
+
+// String returns the string representation of BucketInfo. It is the same as
+// its JSON representation since the info is meant to be processed by tools.
+func (b BucketInfo) String() string {
+	return b.JSON()
+}
+
+// JSON returns the JSON representation of BucketInfo.
+func (b BucketInfo) JSON() string {
+	return strutil.JSON(b)
+}
+
+// BucketInfo returns the region, versioning, encryption, lifecycle, public
+// access block, object lock and transfer acceleration configuration of the
+// bucket with the given name. Configurations which are not set on the bucket
+// are reported with their zero values.
+func (s *S3) BucketInfo(ctx context.Context, name string) (*BucketInfo, error) {
+	bucket := aws.String(name)
+	info := &BucketInfo{Name: name}
+
+	location, err := s.api.GetBucketLocationWithContext(ctx, &s3.GetBucketLocationInput{
+		Bucket: bucket,
+	})
+	if err != nil {
+		return nil, err
+	}
+	info.Region = s3.NormalizeBucketLocation(aws.StringValue(location.LocationConstraint))
+
+	versioning, err := s.api.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{
+		Bucket: bucket,
+	})
+	if err != nil {
+		return nil, err
+	}
+	info.Versioning = aws.StringValue(versioning.Status)
+	if info.Versioning == "" {
+		info.Versioning = "Disabled"
+	}
+
+	encryption, err := s.api.GetBucketEncryptionWithContext(ctx, &s3.GetBucketEncryptionInput{
+		Bucket: bucket,
+	})
+	switch {
+	case errHasCode(err, "ServerSideEncryptionConfigurationNotFoundError"):
+	case err != nil:
+		return nil, err
+	case encryption.ServerSideEncryptionConfiguration != nil:
+		for _, rule := range encryption.ServerSideEncryptionConfiguration.Rules {
+			if def := rule.ApplyServerSideEncryptionByDefault; def != nil {
+				info.Encryption = aws.StringValue(def.SSEAlgorithm)
+				break
+			}
+		}
+	}
+
+	lifecycle, err := s.api.GetBucketLifecycleConfigurationWithContext(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: bucket,
+	})
+	switch {
+	case errHasCode(err, "NoSuchLifecycleConfiguration"):
+	case err != nil:
+		return nil, err
+	default:
+		info.LifecycleRules = len(lifecycle.Rules)
+	}
+
+	pab, err := s.api.GetPublicAccessBlockWithContext(ctx, &s3.GetPublicAccessBlockInput{
+		Bucket: bucket,
+	})
+	switch {
+	case errHasCode(err, "NoSuchPublicAccessBlockConfiguration"):
+	case err != nil:
+		return nil, err
+	case pab.PublicAccessBlockConfiguration != nil:
+		conf := pab.PublicAccessBlockConfiguration
+		info.PublicAccessBlock = &PublicAccessBlock{
+			BlockPublicAcls:       aws.BoolValue(conf.BlockPublicAcls),
+			IgnorePublicAcls:      aws.BoolValue(conf.IgnorePublicAcls),
+			BlockPublicPolicy:     aws.BoolValue(conf.BlockPublicPolicy),
+			RestrictPublicBuckets: aws.BoolValue(conf.RestrictPublicBuckets),
+		}
+	}
+
+	objectLock, err := s.api.GetObjectLockConfigurationWithContext(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: bucket,
+	})
+	switch {
+	case errHasCode(err, "ObjectLockConfigurationNotFoundError"):
+	case err != nil:
+		return nil, err
+	case objectLock.ObjectLockConfiguration != nil:
+		info.ObjectLock = aws.StringValue(objectLock.ObjectLockConfiguration.ObjectLockEnabled) == s3.ObjectLockEnabledEnabled
+	}
+
+	accelerate, err := s.api.GetBucketAccelerateConfigurationWithContext(ctx, &s3.GetBucketAccelerateConfigurationInput{
+		Bucket: bucket,
+	})
+	if err != nil {
+		return nil, err
+	}
+	info.Accelerate = aws.StringValue(accelerate.Status)
+	if info.Accelerate == "" {
+		info.Accelerate = "Disabled"
+	}
+
+	return info, nil
+}
+
+// BucketRegion reports the region of the bucket with the given name, or
+// ErrGivenBucketNotFound if the bucket doesn't exist.
+func (s *S3) BucketRegion(ctx context.Context, name string) (string, error) {
+	req, _ := s.api.HeadBucketRequest(&s3.HeadBucketInput{
+		Bucket: aws.String(name),
+	})
+	req.SetContext(ctx)
+	if err := req.Send(); err != nil {
+		if errHasCode(err, "NotFound") || errHasCode(err, "NoSuchBucket") {
+			return "", ErrGivenBucketNotFound
+		}
+		return "", err
+	}
+
+	// the region is returned along with the response, and asked separately
+	// from the services which don't return it.
+	if req.HTTPResponse != nil {
+		if region := req.HTTPResponse.Header.Get("X-Amz-Bucket-Region"); region != "" {
+			return region, nil
+		}
+	}
+
+	location, err := s.api.GetBucketLocationWithContext(ctx, &s3.GetBucketLocationInput{
+		Bucket: aws.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	return s3.NormalizeBucketLocation(aws.StringValue(location.LocationConstraint)), nil
+}
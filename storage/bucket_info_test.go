@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+)
+
+func TestS3BucketInfo(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.UnmarshalError.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}
+
+		switch r.Params.(type) {
+		case *s3.GetBucketEncryptionInput:
+			r.Error = awserr.New("ServerSideEncryptionConfigurationNotFoundError", "not found", nil)
+		case *s3.GetPublicAccessBlockInput:
+			r.Error = awserr.New("NoSuchPublicAccessBlockConfiguration", "not found", nil)
+		}
+	})
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		switch output := r.Data.(type) {
+		case *s3.GetBucketLocationOutput:
+			output.LocationConstraint = aws.String("EU")
+		case *s3.GetBucketVersioningOutput:
+			output.Status = aws.String(s3.BucketVersioningStatusEnabled)
+		case *s3.GetBucketLifecycleConfigurationOutput:
+			output.Rules = []*s3.LifecycleRule{{}, {}}
+		case *s3.GetObjectLockConfigurationOutput:
+			output.ObjectLockConfiguration = &s3.ObjectLockConfiguration{
+				ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled),
+			}
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	info, err := mockS3.BucketInfo(context.Background(), "bucket")
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, info, &BucketInfo{
+		Name:           "bucket",
+		Region:         "eu-west-1",
+		Versioning:     "Enabled",
+		LifecycleRules: 2,
+		ObjectLock:     true,
+		Accelerate:     "Disabled",
+	})
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// listWildcardBuckets lists the objects of each of the buckets matching the
+// bucket of the given URL with the given function, one bucket after another.
+// Each of the buckets is listed with a client of its own region. URLs without
+// bucket wildcards are listed as is.
+func (s *S3) listWildcardBuckets(
+	ctx context.Context,
+	srcurl *url.URL,
+	list func(client *S3, u *url.URL) <-chan *Object,
+) <-chan *Object {
+	if !srcurl.IsBucketWildcard() {
+		return list(s, srcurl)
+	}
+
+	objCh := make(chan *Object)
+	go func() {
+		defer close(objCh)
+
+		buckets, err := s.matchBuckets(ctx, srcurl)
+		if err != nil {
+			objCh <- &Object{Err: err}
+			return
+		}
+
+		objectFound := false
+		for _, bucket := range buckets {
+			bucketurl := srcurl.Clone()
+			bucketurl.Bucket = bucket
+
+			client, err := s.bucketClient(ctx, bucket)
+			if err != nil {
+				objCh <- &Object{Err: err}
+				objectFound = true
+				continue
+			}
+
+			for object := range list(client, bucketurl) {
+				if object.Err == ErrNoObjectFound {
+					continue
+				}
+				objCh <- object
+				objectFound = true
+			}
+		}
+
+		if !objectFound {
+			objCh <- &Object{Err: ErrNoObjectFound}
+		}
+	}()
+	return objCh
+}
+
+// matchBuckets returns the names of the buckets matching the bucket of the
+// given URL. The buckets given with brace groups only are not listed, e.g.
+// logs-2023 and logs-2024 for s3://logs-{2023,2024}/.
+func (s *S3) matchBuckets(ctx context.Context, srcurl *url.URL) ([]string, error) {
+	if names, ok := srcurl.Buckets(); ok {
+		return names, nil
+	}
+
+	buckets, err := s.ListBuckets(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, bucket := range buckets {
+		if srcurl.MatchBucket(bucket.Name) {
+			names = append(names, bucket.Name)
+		}
+	}
+	return names, nil
+}
+
+// bucketClient returns the client of the region of the given bucket, which is
+// matched by a bucket wildcard. The client itself is returned if its region
+// is given explicitly, since the region of its first bucket is detected
+// otherwise.
+func (s *S3) bucketClient(ctx context.Context, bucket string) (*S3, error) {
+	if s.opts == nil || s.opts.region != "" || s.opts.bucket == bucket {
+		return s, nil
+	}
+
+	opts := *s.opts
+	opts.bucket = bucket
+	return newS3Storage(ctx, opts)
+}
+
+// listSegmentPrefixes returns the common prefixes matching the segment globs
+// of the given wildcard URL. Each of the levels of the segments is listed
+// with a delimiter, and only the common prefixes which the keys matching the
+// URL can be under are listed further.
+func (s *S3) listSegmentPrefixes(
+	ctx context.Context,
+	u *url.URL,
+	globs []*url.SegmentGlob,
+) ([]string, error) {
+	var prefixes []string
+
+	var walk func(glob *url.SegmentGlob, prefix string) error
+	walk = func(glob *url.SegmentGlob, prefix string) error {
+		ok, complete := glob.Match(prefix)
+		if !ok {
+			return nil
+		}
+		if complete {
+			prefixes = append(prefixes, prefix)
+			return nil
+		}
+
+		// the keys right under the prefix can't match, since the segments
+		// end with a delimiter.
+		var commonPrefixes []string
+		err := s.api.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+			Bucket:       aws.String(u.Bucket),
+			Prefix:       aws.String(prefix),
+			Delimiter:    aws.String("/"),
+			RequestPayer: s.RequestPayer(),
+		}, func(p *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, c := range p.CommonPrefixes {
+				commonPrefixes = append(commonPrefixes, aws.StringValue(c.Prefix))
+			}
+			return !lastPage
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, commonPrefix := range commonPrefixes {
+			if err := walk(glob, commonPrefix); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, glob := range globs {
+		if err := walk(glob, glob.Prefix); err != nil {
+			return nil, err
+		}
+	}
+	return prefixes, nil
+}
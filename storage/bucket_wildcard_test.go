@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3ListWildcardBuckets(t *testing.T) {
+	keys := map[string][]string{
+		"logs-2023":    {"app-1/01/data.json.gz", "app-1/01/other.json.gz", "app-a/01/data.json.gz", "web-1/01/data.json.gz"},
+		"logs-2024":    {"app-2/02/data.json.gz", "app-2/2/data.json.gz"},
+		"metrics-2024": {"app-3/03/data.json.gz"},
+	}
+
+	testcases := []struct {
+		name             string
+		src              string
+		expected         []string
+		expectedRequests []string
+	}{
+		{
+			name: "brace groups",
+			src:  "s3://logs-{2023,2024}/app-*/??/data.json.gz",
+			expected: []string{
+				"s3://logs-2023/app-1/01/data.json.gz",
+				"s3://logs-2023/app-a/01/data.json.gz",
+				"s3://logs-2024/app-2/02/data.json.gz",
+			},
+			expectedRequests: []string{"logs-2023/app-", "logs-2024/app-"},
+		},
+		{
+			name: "segment wildcards",
+			src:  "s3://logs-{2023,2024}/app-[0-9]/??/data.json.gz",
+			expected: []string{
+				"s3://logs-2023/app-1/01/data.json.gz",
+				"s3://logs-2024/app-2/02/data.json.gz",
+			},
+			expectedRequests: []string{
+				"logs-2023/app- (delimited)",
+				"logs-2023/app-1/ (delimited)",
+				"logs-2023/app-1/01/",
+				"logs-2024/app- (delimited)",
+				"logs-2024/app-2/ (delimited)",
+				"logs-2024/app-2/2/ (delimited)",
+				"logs-2024/app-2/02/",
+			},
+		},
+		{
+			name: "listed buckets",
+			src:  "s3://*-2024/{app,web}-*/*",
+			expected: []string{
+				"s3://logs-2024/app-2/02/data.json.gz",
+				"s3://logs-2024/app-2/2/data.json.gz",
+				"s3://metrics-2024/app-3/03/data.json.gz",
+			},
+			expectedRequests: []string{
+				"ListBuckets",
+				"logs-2024/app-", "logs-2024/web-",
+				"metrics-2024/app-", "metrics-2024/web-",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch out := r.Data.(type) {
+				case *s3.ListBucketsOutput:
+					requests = append(requests, "ListBuckets")
+					for name := range keys {
+						out.Buckets = append(out.Buckets, &s3.Bucket{Name: aws.String(name)})
+					}
+					sort.Slice(out.Buckets, func(i, j int) bool {
+						return *out.Buckets[i].Name < *out.Buckets[j].Name
+					})
+				case *s3.ListObjectsV2Output:
+					input := r.Params.(*s3.ListObjectsV2Input)
+					if input.Delimiter == nil {
+						requests = append(requests, *input.Bucket+"/"+*input.Prefix)
+					} else {
+						requests = append(requests, *input.Bucket+"/"+*input.Prefix+" (delimited)")
+					}
+
+					seen := map[string]bool{}
+					for _, key := range keys[*input.Bucket] {
+						if !strings.HasPrefix(key, *input.Prefix) {
+							continue
+						}
+						rest := key[len(*input.Prefix):]
+						if i := strings.Index(rest, "/"); input.Delimiter != nil && i >= 0 {
+							prefix := *input.Prefix + rest[:i+1]
+							if !seen[prefix] {
+								seen[prefix] = true
+								out.CommonPrefixes = append(out.CommonPrefixes, &s3.CommonPrefix{Prefix: aws.String(prefix)})
+							}
+							continue
+						}
+						out.Contents = append(out.Contents, &s3.Object{Key: aws.String(key)})
+					}
+				}
+			})
+
+			mockS3 := &S3{api: mockApi}
+
+			srcurl, err := url.New(tc.src)
+			assert.NilError(t, err)
+
+			var got []string
+			for object := range mockS3.List(context.Background(), srcurl, false) {
+				assert.NilError(t, object.Err)
+				got = append(got, object.URL.String())
+			}
+
+			assert.DeepEqual(t, got, tc.expected)
+			assert.DeepEqual(t, requests, tc.expectedRequests)
+		})
+	}
+}
+
+func TestS3BucketClient(t *testing.T) {
+	// clients without options, e.g. the mocks, list every bucket.
+	client := &S3{}
+	got, err := client.bucketClient(context.Background(), "logs-2024")
+	assert.NilError(t, err)
+	assert.Equal(t, got, client)
+
+	// the given region is used for every bucket.
+	opts := Options{bucket: "logs-2023"}
+	opts.SetRegion("eu-west-1")
+	client = &S3{opts: &opts}
+	got, err = client.bucketClient(context.Background(), "logs-2024")
+	assert.NilError(t, err)
+	assert.Equal(t, got, client)
+}
@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// ChecksumAlgorithm represents the algorithm of the additional checksums
+// S3 uses to verify the integrity of objects.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumAlgorithmCRC32  = ChecksumAlgorithm("CRC32")
+	ChecksumAlgorithmCRC32C = ChecksumAlgorithm("CRC32C")
+	ChecksumAlgorithmSHA1   = ChecksumAlgorithm("SHA1")
+	ChecksumAlgorithmSHA256 = ChecksumAlgorithm("SHA256")
+)
+
+// ChecksumAlgorithms is the list of supported checksum algorithms.
+var ChecksumAlgorithms = []ChecksumAlgorithm{
+	ChecksumAlgorithmCRC32,
+	ChecksumAlgorithmCRC32C,
+	ChecksumAlgorithmSHA1,
+	ChecksumAlgorithmSHA256,
+}
+
+// IsValid checks if the checksum algorithm is supported.
+func (c ChecksumAlgorithm) IsValid() bool {
+	for _, algorithm := range ChecksumAlgorithms {
+		if c == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// header returns the HTTP header S3 uses to transfer the checksum.
+func (c ChecksumAlgorithm) header() string {
+	return "x-amz-checksum-" + strings.ToLower(string(c))
+}
+
+func (c ChecksumAlgorithm) newHash() hash.Hash {
+	switch c {
+	case ChecksumAlgorithmCRC32:
+		return crc32.NewIEEE()
+	case ChecksumAlgorithmCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case ChecksumAlgorithmSHA1:
+		return sha1.New()
+	default:
+		return sha256.New()
+	}
+}
+
+// sum returns the raw checksum of the given reader.
+func (c ChecksumAlgorithm) sum(r io.Reader) ([]byte, error) {
+	h := c.newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// maxSourceChanges is the number of attempts an uploaded part is allowed to
+// have a different checksum than its previous attempt. Parts are re-read on
+// each attempt, so the source is considered volatile if it keeps changing.
+const maxSourceChanges = 3
+
+// errCodeVolatileSource is the error code of the requests aborted since
+// their source keeps changing.
+const errCodeVolatileSource = "VolatileSource"
+
+// checksumRequestOption returns a request option which adds the checksums
+// of the request bodies to upload requests, and asks S3 to calculate the
+// checksums of the server-side copied objects.
+//
+// Checksums of the uploaded parts are kept to be sent with the
+// CompleteMultipartUpload request, so a new option must be used for each
+// upload. The checksums are calculated on each attempt, so the parts
+// rejected by S3 because of a checksum mismatch are re-read when they are
+// retried.
+func checksumRequestOption(algorithm ChecksumAlgorithm) request.Option {
+	var (
+		mu      sync.Mutex
+		parts   = map[int64]string{}
+		changes = map[int64]int{}
+	)
+
+	build := func(r *request.Request) {
+		switch r.Operation.Name {
+		case "CreateMultipartUpload", "CopyObject":
+			r.HTTPRequest.Header.Set("x-amz-checksum-algorithm", string(algorithm))
+		case "CompleteMultipartUpload":
+			input, ok := r.Params.(*s3.CompleteMultipartUploadInput)
+			if !ok {
+				return
+			}
+
+			mu.Lock()
+			body, err := completeMultipartUploadBody(input, algorithm, parts)
+			mu.Unlock()
+			if err != nil {
+				r.Error = err
+				return
+			}
+			r.SetBufferBody(body)
+		}
+	}
+
+	sign := func(r *request.Request) {
+		if r.Operation.Name != "PutObject" && r.Operation.Name != "UploadPart" {
+			return
+		}
+
+		checksum, err := bodyChecksum(r, algorithm)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		r.HTTPRequest.Header.Set("x-amz-sdk-checksum-algorithm", string(algorithm))
+		r.HTTPRequest.Header.Set(algorithm.header(), checksum)
+
+		// single part uploads are kept as part 0.
+		var partNumber int64
+		if input, ok := r.Params.(*s3.UploadPartInput); ok {
+			partNumber = aws.Int64Value(input.PartNumber)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if previous, ok := parts[partNumber]; ok && previous != checksum && r.RetryCount > 0 {
+			changes[partNumber]++
+			if changes[partNumber] >= maxSourceChanges {
+				r.Error = awserr.New(errCodeVolatileSource, ErrVolatileSource.Error(), nil)
+				return
+			}
+		}
+		parts[partNumber] = checksum
+	}
+
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBack(build)
+		// checksum headers must be set before the request is signed.
+		r.Handlers.Sign.PushFront(sign)
+	}
+}
+
+// bodyChecksum returns the base64 encoded checksum of the request body. The
+// body is rewound to its original position afterwards.
+func bodyChecksum(r *request.Request, algorithm ChecksumAlgorithm) (string, error) {
+	if r.Body == nil {
+		return base64.StdEncoding.EncodeToString(algorithm.newHash().Sum(nil)), nil
+	}
+
+	start, err := r.Body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+
+	sum, err := algorithm.sum(r.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := r.Body.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sum), nil
+}
+
+type completedPartXML struct {
+	ChecksumCRC32  string `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+	ETag           string `xml:"ETag"`
+	PartNumber     int64  `xml:"PartNumber"`
+}
+
+type completeMultipartUploadXML struct {
+	XMLName xml.Name           `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUpload"`
+	Parts   []completedPartXML `xml:"Part"`
+}
+
+// completeMultipartUploadBody builds the CompleteMultipartUpload request body
+// including the checksums of the parts, which the SDK doesn't support.
+func completeMultipartUploadBody(
+	input *s3.CompleteMultipartUploadInput,
+	algorithm ChecksumAlgorithm,
+	checksums map[int64]string,
+) ([]byte, error) {
+	var body completeMultipartUploadXML
+	if input.MultipartUpload != nil {
+		for _, part := range input.MultipartUpload.Parts {
+			partNumber := aws.Int64Value(part.PartNumber)
+			checksum, ok := checksums[partNumber]
+			if !ok {
+				return nil, fmt.Errorf("missing %v checksum of part %d", algorithm, partNumber)
+			}
+
+			p := completedPartXML{
+				ETag:       aws.StringValue(part.ETag),
+				PartNumber: partNumber,
+			}
+			switch algorithm {
+			case ChecksumAlgorithmCRC32:
+				p.ChecksumCRC32 = checksum
+			case ChecksumAlgorithmCRC32C:
+				p.ChecksumCRC32C = checksum
+			case ChecksumAlgorithmSHA1:
+				p.ChecksumSHA1 = checksum
+			default:
+				p.ChecksumSHA256 = checksum
+			}
+			body.Parts = append(body.Parts, p)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := xml.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// verifyChecksum compares the checksum S3 stores for the object with the
+// checksum of the downloaded content. Objects without a checksum of the
+// given algorithm are not verified. Checksums of multipart objects are
+// calculated over the checksums of their parts, so the part boundaries are
+// fetched from S3 to calculate the checksum of the local content.
+func (s *S3) verifyChecksum(ctx context.Context, from *url.URL, content io.ReaderAt) error {
+	algorithm := s.checksumAlgorithm
+
+	expected, err := s.objectChecksum(ctx, from, 0)
+	if err != nil {
+		return err
+	}
+	if expected.value == "" {
+		return nil
+	}
+
+	var actual string
+	if expected.parts == 0 {
+		sum, err := algorithm.sum(io.NewSectionReader(content, 0, expected.size))
+		if err != nil {
+			return err
+		}
+		actual = base64.StdEncoding.EncodeToString(sum)
+	} else {
+		composite := algorithm.newHash()
+		var offset int64
+		for partNumber := int64(1); partNumber <= expected.parts; partNumber++ {
+			part, err := s.objectChecksum(ctx, from, partNumber)
+			if err != nil {
+				return err
+			}
+
+			sum, err := algorithm.sum(io.NewSectionReader(content, offset, part.size))
+			if err != nil {
+				return err
+			}
+			composite.Write(sum)
+			offset += part.size
+		}
+		actual = fmt.Sprintf(
+			"%v-%d",
+			base64.StdEncoding.EncodeToString(composite.Sum(nil)),
+			expected.parts,
+		)
+	}
+
+	if actual != expected.value {
+		return fmt.Errorf("%w: expected %v %q, got %q", ErrChecksumMismatch, algorithm, expected.value, actual)
+	}
+	return nil
+}
+
+type objectChecksum struct {
+	value string
+	size  int64
+	parts int64
+}
+
+// objectChecksum fetches the checksum of the object, or of the given part of
+// the object if partNumber is not zero.
+func (s *S3) objectChecksum(ctx context.Context, from *url.URL, partNumber int64) (objectChecksum, error) {
+	input := &s3.HeadObjectInput{
+		Bucket:       aws.String(from.Bucket),
+		Key:          aws.String(s.objectKey(from.Path)),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	}
+	if partNumber > 0 {
+		input.PartNumber = aws.Int64(partNumber)
+	}
+
+	req, output := s.api.HeadObjectRequest(input)
+	req.SetContext(ctx)
+	req.HTTPRequest.Header.Set("x-amz-checksum-mode", "ENABLED")
+	if err := req.Send(); err != nil {
+		return objectChecksum{}, err
+	}
+
+	checksum := objectChecksum{
+		value: req.HTTPResponse.Header.Get(s.checksumAlgorithm.header()),
+		size:  aws.Int64Value(output.ContentLength),
+	}
+
+	// checksums of multipart objects are suffixed with the number of parts.
+	if i := strings.LastIndex(checksum.value, "-"); i > 0 && partNumber == 0 {
+		parts, err := strconv.ParseInt(checksum.value[i+1:], 10, 64)
+		if err != nil {
+			return objectChecksum{}, fmt.Errorf("invalid %v checksum %q", s.checksumAlgorithm, checksum.value)
+		}
+		checksum.parts = parts
+	}
+	return checksum, nil
+}
@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3PutWithChecksumAlgorithm(t *testing.T) {
+	testcases := []struct {
+		algorithm        ChecksumAlgorithm
+		expectedHeader   string
+		expectedChecksum string
+	}{
+		{
+			algorithm:        ChecksumAlgorithmSHA256,
+			expectedHeader:   "x-amz-checksum-sha256",
+			expectedChecksum: "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=",
+		},
+		{
+			algorithm:        ChecksumAlgorithmCRC32C,
+			expectedHeader:   "x-amz-checksum-crc32c",
+			expectedChecksum: "mnG7TA==",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(string(tc.algorithm), func(t *testing.T) {
+			u, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.UnmarshalError.Clear()
+			mockApi.Handlers.Send.Clear()
+
+			var sent bool
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+
+				sent = true
+				assert.Equal(t, r.HTTPRequest.Header.Get(tc.expectedHeader), tc.expectedChecksum)
+				assert.Equal(t, r.HTTPRequest.Header.Get("x-amz-sdk-checksum-algorithm"), string(tc.algorithm))
+
+				// body must be rewound after calculating the checksum.
+				body, err := ioutil.ReadAll(r.HTTPRequest.Body)
+				assert.NilError(t, err)
+				assert.Equal(t, string(body), "hello")
+			})
+
+			mockS3 := &S3{
+				uploader:          s3manager.NewUploaderWithClient(mockApi),
+				checksumAlgorithm: tc.algorithm,
+			}
+
+			err = mockS3.Put(context.Background(), bytes.NewReader([]byte("hello")), u, NewMetadata(), 1, 5242880)
+			assert.NilError(t, err)
+			assert.Assert(t, sent)
+		})
+	}
+}
+
+func TestS3VerifyChecksum(t *testing.T) {
+	testcases := []struct {
+		name        string
+		checksums   map[int64]string
+		sizes       map[int64]int64
+		content     string
+		expectedErr error
+	}{
+		{
+			name:      "matching checksum",
+			checksums: map[int64]string{0: "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="},
+			sizes:     map[int64]int64{0: 5},
+			content:   "hello",
+		},
+		{
+			name:        "mismatching checksum",
+			checksums:   map[int64]string{0: "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="},
+			sizes:       map[int64]int64{0: 5},
+			content:     "hellO",
+			expectedErr: ErrChecksumMismatch,
+		},
+		{
+			name:    "object without checksum",
+			sizes:   map[int64]int64{0: 5},
+			content: "anything",
+		},
+		{
+			name: "multipart object",
+			checksums: map[int64]string{
+				0: "70XoEVT1AHwargvQwWnwBIbK/cux5uVcrDIIQ2cwUJk=-2",
+			},
+			sizes:   map[int64]int64{0: 5, 1: 3, 2: 2},
+			content: "hello",
+		},
+		{
+			name: "multipart object with mismatching content",
+			checksums: map[int64]string{
+				0: "70XoEVT1AHwargvQwWnwBIbK/cux5uVcrDIIQ2cwUJk=-2",
+			},
+			sizes:       map[int64]int64{0: 5, 1: 3, 2: 2},
+			content:     "help!",
+			expectedErr: ErrChecksumMismatch,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.UnmarshalError.Clear()
+			mockApi.Handlers.Send.Clear()
+
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				assert.Equal(t, r.HTTPRequest.Header.Get("x-amz-checksum-mode"), "ENABLED")
+
+				partNumber := aws.Int64Value(r.Params.(*s3.HeadObjectInput).PartNumber)
+				header := http.Header{}
+				if checksum, ok := tc.checksums[partNumber]; ok {
+					header.Set("x-amz-checksum-sha256", checksum)
+				}
+
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+			})
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				partNumber := aws.Int64Value(r.Params.(*s3.HeadObjectInput).PartNumber)
+				r.Data.(*s3.HeadObjectOutput).ContentLength = aws.Int64(tc.sizes[partNumber])
+			})
+
+			mockS3 := &S3{
+				api:               mockApi,
+				checksumAlgorithm: ChecksumAlgorithmSHA256,
+			}
+
+			err = mockS3.verifyChecksum(context.Background(), u, strings.NewReader(tc.content))
+			if tc.expectedErr == nil {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Assert(t, errors.Is(err, tc.expectedErr), "got %v", err)
+		})
+	}
+}
+
+func TestCompleteMultipartUploadBody(t *testing.T) {
+	input := &s3.CompleteMultipartUploadInput{
+		MultipartUpload: &s3.CompletedMultipartUpload{
+			Parts: []*s3.CompletedPart{
+				{ETag: aws.String(`"etag-1"`), PartNumber: aws.Int64(1)},
+				{ETag: aws.String(`"etag-2"`), PartNumber: aws.Int64(2)},
+			},
+		},
+	}
+
+	body, err := completeMultipartUploadBody(input, ChecksumAlgorithmCRC32C, map[int64]string{
+		1: "checksum-1",
+		2: "checksum-2",
+	})
+	assert.NilError(t, err)
+
+	expected := `<CompleteMultipartUpload xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+		`<Part><ChecksumCRC32C>checksum-1</ChecksumCRC32C><ETag>&#34;etag-1&#34;</ETag><PartNumber>1</PartNumber></Part>` +
+		`<Part><ChecksumCRC32C>checksum-2</ChecksumCRC32C><ETag>&#34;etag-2&#34;</ETag><PartNumber>2</PartNumber></Part>` +
+		`</CompleteMultipartUpload>`
+	assert.Equal(t, string(body), expected)
+
+	_, err = completeMultipartUploadBody(input, ChecksumAlgorithmCRC32C, map[int64]string{1: "checksum-1"})
+	assert.ErrorContains(t, err, "missing CRC32C checksum of part 2")
+}
+
+// changingFile is a file whose content changes each time it is read from
+// the beginning.
+type changingFile struct {
+	reads int
+}
+
+func (f *changingFile) ReadAt(p []byte, off int64) (int, error) {
+	if off == 0 {
+		f.reads++
+	}
+	return bytes.NewReader([]byte(fmt.Sprintf("content %04d", f.reads))).ReadAt(p, off)
+}
+
+func (f *changingFile) Read(p []byte) (int, error) { panic("unexpected read") }
+
+// Seek is only used to get the size of the file.
+func (f *changingFile) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekEnd {
+		return int64(len("content 0000")), nil
+	}
+	return 0, nil
+}
+
+func TestS3PutRetriesChecksumMismatch(t *testing.T) {
+	log.Init("error", false)
+
+	testcases := []struct {
+		name        string
+		body        io.ReadSeeker
+		failures    int
+		expectedErr error
+	}{
+		{
+			name:     "retried part is uploaded",
+			body:     bytes.NewReader([]byte("hello")),
+			failures: 2,
+		},
+		{
+			name:        "source keeps changing",
+			body:        &changingFile{},
+			failures:    10,
+			expectedErr: ErrVolatileSource,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			mockApi := s3.New(unit.Session.Copy(&aws.Config{Retryer: newCustomRetryer(5)}))
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.UnmarshalError.Clear()
+			mockApi.Handlers.Send.Clear()
+
+			var attempts int
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+
+				attempts++
+				if attempts <= tc.failures {
+					r.HTTPResponse.StatusCode = http.StatusBadRequest
+					r.Error = awserr.New("BadDigest", "The checksum you specified did not match", nil)
+				}
+			})
+
+			mockS3 := &S3{
+				uploader:          s3manager.NewUploaderWithClient(mockApi),
+				checksumAlgorithm: ChecksumAlgorithmCRC32C,
+			}
+
+			err = mockS3.Put(context.Background(), tc.body, u, NewMetadata(), 1, 5242880)
+			if tc.expectedErr != nil {
+				assert.Equal(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, attempts, tc.failures+1)
+		})
+	}
+}
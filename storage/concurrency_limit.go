@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// ConcurrencyLimit caps the number of concurrent requests sent to an
+// endpoint or to a bucket.
+type ConcurrencyLimit struct {
+	// Endpoint is the host of the endpoint, optionally with its port, e.g.
+	// "ceph.local:7480". It is empty for the limits of buckets.
+	Endpoint string
+	Bucket   string
+	Limit    int
+}
+
+// ParseConcurrencyLimit parses a concurrency limit given as
+// "<endpoint>=<limit>" or "s3://<bucket>=<limit>".
+func ParseConcurrencyLimit(value string) (ConcurrencyLimit, error) {
+	invalid := fmt.Errorf("invalid concurrency limit %q, expected <endpoint>=<limit> or s3://<bucket>=<limit>", value)
+
+	i := strings.LastIndex(value, "=")
+	if i <= 0 {
+		return ConcurrencyLimit{}, invalid
+	}
+	target, number := value[:i], value[i+1:]
+
+	limit, err := strconv.Atoi(number)
+	if err != nil || limit <= 0 {
+		return ConcurrencyLimit{}, fmt.Errorf("concurrency limit of %q must be a positive integer", target)
+	}
+
+	if !strings.HasPrefix(target, "s3://") {
+		if strings.Contains(target, "/") {
+			return ConcurrencyLimit{}, invalid
+		}
+		return ConcurrencyLimit{Endpoint: target, Limit: limit}, nil
+	}
+
+	u, err := url.New(target)
+	if err != nil || !u.IsBucket() {
+		return ConcurrencyLimit{}, invalid
+	}
+	return ConcurrencyLimit{Bucket: u.Bucket, Limit: limit}, nil
+}
+
+// requestLimiter holds the semaphores of the concurrency limits. It is shared
+// by all of the sessions, so that the limits are enforced across the commands
+// running together in the same process.
+var requestLimiter = &concurrencyLimiter{}
+
+// SetConcurrencyLimits sets the limits of the requests sent to the endpoints
+// and the buckets. It must be called before any request is sent.
+func SetConcurrencyLimits(limits []ConcurrencyLimit) {
+	requestLimiter.set(limits)
+}
+
+type concurrencyLimiter struct {
+	mu        sync.RWMutex
+	endpoints map[string]chan struct{}
+	buckets   map[string]chan struct{}
+
+	// acquired holds the semaphores acquired by the requests in flight.
+	acquired map[*request.Request][]chan struct{}
+}
+
+func (l *concurrencyLimiter) set(limits []ConcurrencyLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.endpoints = map[string]chan struct{}{}
+	l.buckets = map[string]chan struct{}{}
+	l.acquired = map[*request.Request][]chan struct{}{}
+	for _, limit := range limits {
+		if limit.Bucket != "" {
+			l.buckets[limit.Bucket] = make(chan struct{}, limit.Limit)
+		} else {
+			l.endpoints[limit.Endpoint] = make(chan struct{}, limit.Limit)
+		}
+	}
+}
+
+// semaphores returns the semaphores of the limits which apply to the request.
+func (l *concurrencyLimiter) semaphores(r *request.Request) []chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(l.endpoints) == 0 && len(l.buckets) == 0 {
+		return nil
+	}
+
+	var semaphores []chan struct{}
+	if sem, ok := l.endpoints[r.HTTPRequest.URL.Host]; ok {
+		semaphores = append(semaphores, sem)
+	}
+	if values, err := awsutil.ValuesAtPath(r.Params, "Bucket"); err == nil && len(values) > 0 {
+		if bucket, ok := values[0].(*string); ok {
+			if sem, ok := l.buckets[aws.StringValue(bucket)]; ok {
+				semaphores = append(semaphores, sem)
+			}
+		}
+	}
+	return semaphores
+}
+
+// acquire waits for the limits of the request before each attempt to send
+// it. If the wait is canceled, the request fails since its context is done.
+func (l *concurrencyLimiter) acquire(r *request.Request) {
+	semaphores := l.semaphores(r)
+	if len(semaphores) == 0 {
+		return
+	}
+
+	for i, sem := range semaphores {
+		select {
+		case sem <- struct{}{}:
+		case <-r.Context().Done():
+			for _, acquired := range semaphores[:i] {
+				<-acquired
+			}
+			r.Error = r.Context().Err()
+			return
+		}
+	}
+
+	l.mu.Lock()
+	l.acquired[r] = semaphores
+	l.mu.Unlock()
+}
+
+// release frees the limits of the request after each attempt, so that the
+// delays between the retries don't hold them.
+func (l *concurrencyLimiter) release(r *request.Request) {
+	l.mu.Lock()
+	semaphores := l.acquired[r]
+	delete(l.acquired, r)
+	l.mu.Unlock()
+
+	for _, sem := range semaphores {
+		<-sem
+	}
+}
+
+// install adds the handlers enforcing the limits to the given handlers.
+func (l *concurrencyLimiter) install(handlers *request.Handlers) {
+	handlers.Send.PushFront(l.acquire)
+	handlers.CompleteAttempt.PushBack(l.release)
+}
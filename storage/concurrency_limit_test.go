@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value       string
+		want        ConcurrencyLimit
+		expectedErr string
+	}{
+		{
+			value: "ceph.local:7480=16",
+			want:  ConcurrencyLimit{Endpoint: "ceph.local:7480", Limit: 16},
+		},
+		{
+			value: "s3://bucket=64",
+			want:  ConcurrencyLimit{Bucket: "bucket", Limit: 64},
+		},
+		{
+			value:       "ceph.local",
+			expectedErr: `invalid concurrency limit "ceph.local", expected <endpoint>=<limit> or s3://<bucket>=<limit>`,
+		},
+		{
+			value:       "s3://bucket/prefix/=8",
+			expectedErr: `invalid concurrency limit "s3://bucket/prefix/=8", expected <endpoint>=<limit> or s3://<bucket>=<limit>`,
+		},
+		{
+			value:       "ceph.local=0",
+			expectedErr: `concurrency limit of "ceph.local" must be a positive integer`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseConcurrencyLimit(tc.value)
+			if tc.expectedErr != "" {
+				assert.Error(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.want, got)
+		})
+	}
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Parallel()
+
+	const limit = 2
+
+	limiter := &concurrencyLimiter{}
+	limiter.set([]ConcurrencyLimit{
+		{Bucket: "limited", Limit: limit},
+	})
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var inflight, maxInflight int64
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		n := atomic.AddInt64(&inflight, 1)
+		defer atomic.AddInt64(&inflight, -1)
+
+		if aws.StringValue(r.Params.(*s3.HeadObjectInput).Bucket) != "limited" {
+			return
+		}
+		for {
+			max := atomic.LoadInt64(&maxInflight)
+			if n <= max || atomic.CompareAndSwapInt64(&maxInflight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	})
+	limiter.install(&mockApi.Handlers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mockApi.HeadObjectWithContext(context.Background(), &s3.HeadObjectInput{
+				Bucket: aws.String("limited"),
+				Key:    aws.String("key"),
+			})
+			assert.NilError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(limit), atomic.LoadInt64(&maxInflight))
+	assert.Equal(t, 0, len(limiter.acquired))
+
+	// the requests waiting for the limit are canceled with their context.
+	for i := 0; i < limit; i++ {
+		limiter.buckets["limited"] <- struct{}{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := mockApi.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String("limited"),
+		Key:    aws.String("key"),
+	})
+	assert.ErrorContains(t, err, "deadline exceeded")
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// ListDeleteMarkers lists the delete markers of the objects matching the
+// given URL whose latest versions are delete markers. Only the markers newer
+// than the newest version of an object are listed, removing them restores
+// the newest version. The URLs of the returned objects refer to the versions
+// of the delete markers.
+func (s *S3) ListDeleteMarkers(ctx context.Context, srcurl *url.URL) <-chan *Object {
+	return s.listWildcardBuckets(ctx, srcurl, func(client *S3, url *url.URL) <-chan *Object {
+		return client.listDeleteMarkers(ctx, url)
+	})
+}
+
+func (s *S3) listDeleteMarkers(ctx context.Context, url *url.URL) <-chan *Object {
+	objCh := make(chan *Object)
+
+	go func() {
+		defer close(objCh)
+
+		for deleted := range s.ListDeletedObjects(ctx, url) {
+			if deleted.Err != nil {
+				objCh <- &Object{Err: deleted.Err}
+				continue
+			}
+
+			// an object with stacked delete markers stays deleted unless
+			// all of them are removed.
+			for _, markerurl := range deleted.DeleteMarkers {
+				objCh <- &Object{
+					URL:          markerurl,
+					VersionID:    markerurl.VersionID,
+					DeleteMarker: true,
+				}
+			}
+		}
+	}()
+
+	return objCh
+}
+
+// DeletedObject is an object whose latest version is a delete marker.
+type DeletedObject struct {
+	URL *url.URL
+
+	// DeleteMarkers are the URLs of the delete markers newer than the newest
+	// version of the object, newest first. Removing them restores the object.
+	DeleteMarkers []*url.URL
+
+	// HasVersion reports whether the object has a version to restore.
+	HasVersion bool
+
+	Err error
+}
+
+// ListDeletedObjects lists the objects matching the given URL whose latest
+// versions are delete markers. The objects are sent after the whole listing
+// is done, since the versions of a key may span multiple pages.
+func (s *S3) ListDeletedObjects(ctx context.Context, url *url.URL) <-chan *DeletedObject {
+	type deletedKey struct {
+		markers       []*s3.DeleteMarkerEntry
+		hasVersion    bool
+		newestVersion time.Time
+	}
+
+	objCh := make(chan *DeletedObject)
+
+	go func() {
+		defer close(objCh)
+
+		matches := func(key string) bool {
+			if !url.IsWildcard() && key != url.Path {
+				return false
+			}
+			return url.Match(key)
+		}
+
+		deleted := map[string]*deletedKey{}
+
+		var err error
+		for _, prefix := range s.listPrefixes(url) {
+			input := &s3.ListObjectVersionsInput{
+				Bucket: aws.String(url.Bucket),
+				Prefix: aws.String(prefix),
+			}
+
+			err = s.api.ListObjectVersionsPagesWithContext(ctx, input, func(p *s3.ListObjectVersionsOutput, lastPage bool) bool {
+				// the latest version of a key is listed before its older
+				// versions, so the keys are known to be deleted before their
+				// versions are seen.
+				for _, marker := range p.DeleteMarkers {
+					key := s.objectPath(aws.StringValue(marker.Key))
+					if aws.BoolValue(marker.IsLatest) && matches(key) {
+						deleted[key] = &deletedKey{}
+					}
+					if d, ok := deleted[key]; ok {
+						d.markers = append(d.markers, marker)
+					}
+				}
+
+				for _, version := range p.Versions {
+					d, ok := deleted[s.objectPath(aws.StringValue(version.Key))]
+					if !ok {
+						continue
+					}
+
+					mod := aws.TimeValue(version.LastModified)
+					if !d.hasVersion || mod.After(d.newestVersion) {
+						d.newestVersion = mod
+					}
+					d.hasVersion = true
+				}
+
+				return !lastPage
+			})
+			if err != nil {
+				break
+			}
+		}
+
+		if err != nil {
+			objCh <- &DeletedObject{Err: err}
+			return
+		}
+
+		if len(deleted) == 0 {
+			objCh <- &DeletedObject{Err: ErrNoObjectFound}
+			return
+		}
+
+		keys := make([]string, 0, len(deleted))
+		for key := range deleted {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			d := deleted[key]
+
+			newurl := url.Clone()
+			newurl.Path = key
+
+			obj := &DeletedObject{
+				URL:        newurl,
+				HasVersion: d.hasVersion,
+			}
+			for _, marker := range d.markers {
+				// markers older than the newest version don't hide it.
+				if d.hasVersion && aws.TimeValue(marker.LastModified).Before(d.newestVersion) {
+					continue
+				}
+
+				markerurl := newurl.Clone()
+				markerurl.VersionID = aws.StringValue(marker.VersionId)
+				obj.DeleteMarkers = append(obj.DeleteMarkers, markerurl)
+			}
+
+			select {
+			case objCh <- obj:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return objCh
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/go-cmp/cmp"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3ListDeleteMarkers(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		r.Data = &s3.ListObjectVersionsOutput{
+			DeleteMarkers: []*s3.DeleteMarkerEntry{
+				{Key: aws.String("prefix/a.txt"), VersionId: aws.String("v1"), IsLatest: aws.Bool(true)},
+				{Key: aws.String("prefix/b.txt"), VersionId: aws.String("v2"), IsLatest: aws.Bool(false)},
+				{Key: aws.String("prefix/c.log"), VersionId: aws.String("v3"), IsLatest: aws.Bool(true)},
+				{Key: aws.String("prefix/d.txt"), VersionId: aws.String("v4"), IsLatest: aws.Bool(true)},
+			},
+		}
+	})
+
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	testcases := []struct {
+		name     string
+		url      string
+		expected map[string]string
+		wantErr  error
+	}{
+		{
+			name: "wildcard",
+			url:  "s3://bucket/prefix/*.txt",
+			expected: map[string]string{
+				"s3://bucket/prefix/a.txt": "v1",
+				"s3://bucket/prefix/d.txt": "v4",
+			},
+		},
+		{
+			name: "single object",
+			url:  "s3://bucket/prefix/c.log",
+			expected: map[string]string{
+				"s3://bucket/prefix/c.log": "v3",
+			},
+		},
+		{
+			name:     "no delete marker is the latest version",
+			url:      "s3://bucket/prefix/b.txt",
+			expected: map[string]string{},
+			wantErr:  ErrNoObjectFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New(tc.url)
+			assert.NilError(t, err)
+
+			got := map[string]string{}
+			var gotErr error
+			for object := range mockS3.ListDeleteMarkers(context.Background(), u) {
+				if object.Err != nil {
+					gotErr = object.Err
+					continue
+				}
+				assert.Assert(t, object.DeleteMarker)
+				assert.Equal(t, object.VersionID, object.URL.VersionID)
+				got[object.URL.String()] = object.VersionID
+			}
+
+			assert.Equal(t, gotErr, tc.wantErr)
+			assert.DeepEqual(t, got, tc.expected)
+		})
+	}
+}
+
+func TestS3ListDeleteMarkersStacked(t *testing.T) {
+	at := func(minute int) *time.Time {
+		return aws.Time(time.Date(2021, 1, 1, 0, minute, 0, 0, time.UTC))
+	}
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		r.Data = &s3.ListObjectVersionsOutput{
+			DeleteMarkers: []*s3.DeleteMarkerEntry{
+				{Key: aws.String("a.txt"), VersionId: aws.String("m3"), IsLatest: aws.Bool(true), LastModified: at(30)},
+				{Key: aws.String("a.txt"), VersionId: aws.String("m2"), IsLatest: aws.Bool(false), LastModified: at(20)},
+				{Key: aws.String("a.txt"), VersionId: aws.String("m1"), IsLatest: aws.Bool(false), LastModified: at(5)},
+			},
+			Versions: []*s3.ObjectVersion{
+				{Key: aws.String("a.txt"), VersionId: aws.String("v1"), IsLatest: aws.Bool(false), LastModified: at(10)},
+			},
+		}
+	})
+
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	u, err := url.New("s3://bucket/a.txt")
+	assert.NilError(t, err)
+
+	// all of the markers newer than the newest version must be removed to
+	// restore it.
+	var got []string
+	for object := range mockS3.ListDeleteMarkers(context.Background(), u) {
+		assert.NilError(t, object.Err)
+		got = append(got, object.VersionID)
+	}
+	assert.DeepEqual(t, got, []string{"m3", "m2"})
+}
+
+func TestS3MultiDeleteVersions(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var identifiers []*s3.ObjectIdentifier
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		identifiers = r.Params.(*s3.DeleteObjectsInput).Delete.Objects
+		r.Data = &s3.DeleteObjectsOutput{}
+	})
+
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+	u.VersionID = "v1"
+
+	urlch := make(chan *url.URL, 1)
+	urlch <- u
+	close(urlch)
+
+	for range mockS3.MultiDelete(context.Background(), urlch) {
+	}
+
+	assert.Equal(t, len(identifiers), 1)
+	assert.Equal(t, aws.StringValue(identifiers[0].Key), "key")
+	assert.Equal(t, aws.StringValue(identifiers[0].VersionId), "v1")
+}
+
+func TestS3ListDeletedObjects(t *testing.T) {
+	at := func(minute int) *time.Time {
+		return aws.Time(time.Date(2021, 1, 1, 0, minute, 0, 0, time.UTC))
+	}
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		r.Data = &s3.ListObjectVersionsOutput{
+			DeleteMarkers: []*s3.DeleteMarkerEntry{
+				// stacked delete markers of a.txt, and an older marker
+				// which doesn't hide its newest version.
+				{Key: aws.String("a.txt"), VersionId: aws.String("m3"), IsLatest: aws.Bool(true), LastModified: at(30)},
+				{Key: aws.String("a.txt"), VersionId: aws.String("m2"), IsLatest: aws.Bool(false), LastModified: at(20)},
+				{Key: aws.String("a.txt"), VersionId: aws.String("m1"), IsLatest: aws.Bool(false), LastModified: at(5)},
+				// b.txt only has a delete marker.
+				{Key: aws.String("b.txt"), VersionId: aws.String("m4"), IsLatest: aws.Bool(true), LastModified: at(10)},
+				// c.txt is not deleted.
+				{Key: aws.String("c.txt"), VersionId: aws.String("m5"), IsLatest: aws.Bool(false), LastModified: at(1)},
+			},
+			Versions: []*s3.ObjectVersion{
+				{Key: aws.String("a.txt"), VersionId: aws.String("v2"), IsLatest: aws.Bool(false), LastModified: at(10)},
+				{Key: aws.String("a.txt"), VersionId: aws.String("v1"), IsLatest: aws.Bool(false), LastModified: at(0)},
+				{Key: aws.String("c.txt"), VersionId: aws.String("v3"), IsLatest: aws.Bool(true), LastModified: at(2)},
+			},
+		}
+	})
+
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	u, err := url.New("s3://bucket/*")
+	assert.NilError(t, err)
+
+	type deleted struct {
+		url        string
+		markers    []string
+		hasVersion bool
+	}
+
+	var got []deleted
+	for object := range mockS3.ListDeletedObjects(context.Background(), u) {
+		assert.NilError(t, object.Err)
+
+		var markers []string
+		for _, marker := range object.DeleteMarkers {
+			assert.Equal(t, marker.Path, object.URL.Path)
+			markers = append(markers, marker.VersionID)
+		}
+		got = append(got, deleted{
+			url:        object.URL.String(),
+			markers:    markers,
+			hasVersion: object.HasVersion,
+		})
+	}
+
+	expected := []deleted{
+		{url: "s3://bucket/a.txt", markers: []string{"m3", "m2"}, hasVersion: true},
+		{url: "s3://bucket/b.txt", markers: []string{"m4"}, hasVersion: false},
+	}
+	assert.DeepEqual(t, got, expected, cmp.AllowUnexported(deleted{}))
+}
@@ -0,0 +1,357 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// failoverThreshold is the number of consecutive requests of a bucket that
+// fail with server or connection errors, after their retries, before the
+// reads of the bucket fail over to the next replica.
+const failoverThreshold = 3
+
+// Failover is a replica of the buckets, which the reads fail over to if the
+// endpoint of a bucket fails persistently.
+type Failover struct {
+	// Bucket is the bucket the replica is of. It is empty for the replicas
+	// of all of the buckets.
+	Bucket string
+
+	// Endpoint is the endpoint of the replica. It is empty for the endpoint
+	// of the bucket.
+	Endpoint string
+
+	// Replica is the name of the replica bucket. It is empty if the replica
+	// has the same name as the bucket.
+	Replica string
+}
+
+// ParseFailover parses a replica given as "[s3://<bucket>=]<target>", where
+// the target is either "s3://<replica>" for a bucket on the same endpoint,
+// or "<endpoint>[/<replica>]".
+func ParseFailover(value string) (Failover, error) {
+	invalid := fmt.Errorf("invalid failover %q, expected [s3://<bucket>=]s3://<replica> or [s3://<bucket>=]<endpoint>[/<replica>]", value)
+
+	var failover Failover
+
+	target := value
+	if strings.HasPrefix(value, "s3://") {
+		i := strings.Index(value, "=")
+		if i < 0 {
+			return Failover{}, invalid
+		}
+		u, err := url.New(value[:i])
+		if err != nil || !u.IsBucket() {
+			return Failover{}, invalid
+		}
+		failover.Bucket = u.Bucket
+		target = value[i+1:]
+	}
+
+	if strings.HasPrefix(target, "s3://") {
+		u, err := url.New(target)
+		if err != nil || !u.IsBucket() || failover.Bucket == "" {
+			return Failover{}, invalid
+		}
+		failover.Replica = u.Bucket
+		return failover, nil
+	}
+
+	endpoint, err := parseEndpoint(target)
+	if err != nil || endpoint.Host == "" {
+		return Failover{}, invalid
+	}
+	replica := strings.Trim(endpoint.Path, "/")
+	if strings.Contains(replica, "/") || (replica != "" && failover.Bucket == "") {
+		return Failover{}, invalid
+	}
+	endpoint.Path = ""
+
+	failover.Endpoint = endpoint.String()
+	failover.Replica = replica
+	return failover, nil
+}
+
+// String returns the target of the replica.
+func (f Failover) String() string {
+	if f.Endpoint == "" {
+		return "s3://" + f.Replica
+	}
+	if f.Replica == "" {
+		return f.Endpoint
+	}
+	return f.Endpoint + "/" + f.Replica
+}
+
+// endpointFailover holds the replicas and the failover states of the
+// buckets. It is shared by all of the clients, so that the failures of the
+// commands running together in the same process add up.
+var endpointFailover = &failoverRegistry{states: map[string]*failoverState{}}
+
+// SetFailovers sets the replicas the reads fail over to, in the order they
+// are tried. It must be called before any request is sent.
+func SetFailovers(failovers []Failover) {
+	endpointFailover.mu.Lock()
+	defer endpointFailover.mu.Unlock()
+
+	endpointFailover.failovers = failovers
+	endpointFailover.states = map[string]*failoverState{}
+}
+
+type failoverRegistry struct {
+	mu        sync.Mutex
+	failovers []Failover
+	states    map[string]*failoverState
+}
+
+// enabled reports whether any replica is set.
+func (r *failoverRegistry) enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.failovers) > 0
+}
+
+// stateOf returns the failover state of the bucket on the given endpoint.
+func (r *failoverRegistry) stateOf(endpoint, bucket string) *failoverState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := endpoint + "|" + bucket
+	state, ok := r.states[key]
+	if !ok {
+		state = &failoverState{bucket: bucket, clients: map[int]s3iface.S3API{}}
+		for _, failover := range r.failovers {
+			if failover.Bucket == "" || failover.Bucket == bucket {
+				state.failovers = append(state.failovers, failover)
+			}
+		}
+		r.states[key] = state
+	}
+	return state
+}
+
+// failoverState is the failover state of a bucket. The bucket itself is
+// the target 0, and its replicas follow it.
+type failoverState struct {
+	mu        sync.Mutex
+	bucket    string
+	failovers []Failover
+	active    int
+	failures  int
+	clients   map[int]s3iface.S3API
+}
+
+func (s *failoverState) current() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// record records the result of a request sent to the given target. It
+// reports whether the request should be sent again, since the bucket is
+// failed over to another target.
+func (s *failoverState) record(target int, err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !isFailoverError(err) {
+		if target == s.active {
+			s.failures = 0
+		}
+		return false
+	}
+
+	// another request has already failed over the bucket.
+	if target != s.active {
+		return s.active > target
+	}
+
+	s.failures++
+	if s.failures < failoverThreshold || s.active >= len(s.failovers) {
+		return false
+	}
+
+	s.active++
+	s.failures = 0
+
+	msg := log.WarningMessage{
+		Warning: fmt.Sprintf(
+			"failing over reads of bucket %q to %v after %d consecutive errors: %v",
+			s.bucket, s.failovers[s.active-1], failoverThreshold, err,
+		),
+	}
+	log.Warning(msg)
+	return true
+}
+
+// isFailoverError reports whether the error is a server or connection error,
+// which may be caused by an outage of the endpoint.
+func isFailoverError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() >= 500 {
+		return true
+	}
+	return errHasCode(err, request.ErrCodeRequestError) || errHasCode(err, request.ErrCodeResponseTimeout)
+}
+
+// failoverAPI sends the read requests of the objects to the replicas of
+// their buckets if the buckets are failed over. The other requests are sent
+// to the buckets.
+type failoverAPI struct {
+	s3iface.S3API
+
+	// opts are the options of the client of the buckets, which the clients
+	// of the replicas are created with.
+	opts Options
+}
+
+func newFailoverAPI(api s3iface.S3API, opts Options) *failoverAPI {
+	return &failoverAPI{S3API: api, opts: opts}
+}
+
+// do calls fn with the client and the name of the active target of the
+// bucket, until the bucket is not failed over anymore.
+func (f *failoverAPI) do(ctx context.Context, bucket *string, fn func(api s3iface.S3API, bucket *string) error) error {
+	state := endpointFailover.stateOf(f.opts.Endpoint, aws.StringValue(bucket))
+	for {
+		target := state.current()
+		api, name, err := f.client(ctx, state, target)
+		if err != nil {
+			return err
+		}
+
+		err = fn(api, aws.String(name))
+		if !state.record(target, err) {
+			return err
+		}
+	}
+}
+
+// client returns the client and the bucket name of the given target.
+func (f *failoverAPI) client(ctx context.Context, state *failoverState, target int) (s3iface.S3API, string, error) {
+	if target == 0 {
+		return f.S3API, state.bucket, nil
+	}
+
+	failover := state.failovers[target-1]
+	name := failover.Replica
+	if name == "" {
+		name = state.bucket
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if api, ok := state.clients[target]; ok {
+		return api, name, nil
+	}
+
+	opts := f.opts
+	if failover.Endpoint != "" {
+		opts.Endpoint = failover.Endpoint
+	}
+	// the replica may be in another region.
+	opts.bucket, opts.region = name, ""
+
+	sess, err := globalSessionCache.newSession(ctx, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failover to %v: %w", failover, err)
+	}
+
+	api := s3.New(sess)
+	state.clients[target] = api
+	return api, name, nil
+}
+
+func (f *failoverAPI) HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	var output *s3.HeadObjectOutput
+	err := f.do(ctx, input.Bucket, func(api s3iface.S3API, bucket *string) error {
+		in := *input
+		in.Bucket = bucket
+
+		var err error
+		output, err = api.HeadObjectWithContext(ctx, &in, opts...)
+		return err
+	})
+	return output, err
+}
+
+func (f *failoverAPI) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	var output *s3.GetObjectOutput
+	err := f.do(ctx, input.Bucket, func(api s3iface.S3API, bucket *string) error {
+		in := *input
+		in.Bucket = bucket
+
+		var err error
+		output, err = api.GetObjectWithContext(ctx, &in, opts...)
+		return err
+	})
+	return output, err
+}
+
+// ListObjectsV2PagesWithContext lists the objects on the active target of
+// the bucket. A listing fails over only if none of its pages is received,
+// otherwise the received objects would be listed again.
+func (f *failoverAPI) ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	var partialErr error
+	err := f.do(ctx, input.Bucket, func(api s3iface.S3API, bucket *string) error {
+		in := *input
+		in.Bucket = bucket
+
+		received := false
+		err := api.ListObjectsV2PagesWithContext(ctx, &in, func(p *s3.ListObjectsV2Output, lastPage bool) bool {
+			received = true
+			return fn(p, lastPage)
+		}, opts...)
+		if received {
+			partialErr = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return partialErr
+}
+
+// ListObjectsPagesWithContext is the ListObjectsV1 equivalent of
+// ListObjectsV2PagesWithContext.
+func (f *failoverAPI) ListObjectsPagesWithContext(ctx aws.Context, input *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool, opts ...request.Option) error {
+	var partialErr error
+	err := f.do(ctx, input.Bucket, func(api s3iface.S3API, bucket *string) error {
+		in := *input
+		in.Bucket = bucket
+
+		received := false
+		err := api.ListObjectsPagesWithContext(ctx, &in, func(p *s3.ListObjectsOutput, lastPage bool) bool {
+			received = true
+			return fn(p, lastPage)
+		}, opts...)
+		if received {
+			partialErr = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return partialErr
+}
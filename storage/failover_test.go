@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestParseFailover(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    Failover
+		wantErr bool
+	}{
+		{
+			value: "https://dr.example.com",
+			want:  Failover{Endpoint: "https://dr.example.com"},
+		},
+		{
+			value: "dr.example.com:9000",
+			want:  Failover{Endpoint: "http://dr.example.com:9000"},
+		},
+		{
+			value: "s3://bucket=https://dr.example.com",
+			want:  Failover{Bucket: "bucket", Endpoint: "https://dr.example.com"},
+		},
+		{
+			value: "s3://bucket=https://dr.example.com/replica",
+			want:  Failover{Bucket: "bucket", Endpoint: "https://dr.example.com", Replica: "replica"},
+		},
+		{
+			value: "s3://bucket=s3://replica",
+			want:  Failover{Bucket: "bucket", Replica: "replica"},
+		},
+		{value: "https://dr.example.com/replica", wantErr: true},
+		{value: "s3://replica", wantErr: true},
+		{value: "s3://bucket/prefix=s3://replica", wantErr: true},
+		{value: "s3://bucket=s3://replica/prefix", wantErr: true},
+		{value: "s3://bucket=https://dr.example.com/replica/prefix", wantErr: true},
+		{value: "s3://bucket=", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseFailover(tc.value)
+			if tc.wantErr {
+				assert.ErrorContains(t, err, "invalid failover")
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFailoverReads(t *testing.T) {
+	log.Init("error", false)
+
+	var primaryRequests int64
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&primaryRequests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/replica":
+			w.Header().Set("X-Amz-Bucket-Region", "eu-west-1")
+		case "/replica/key":
+			w.Header().Set("Content-Length", "7")
+			w.Header().Set("ETag", `"etag"`)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte("content"))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer replica.Close()
+
+	SetFailovers([]Failover{{Bucket: "bucket", Endpoint: replica.URL, Replica: "replica"}})
+	defer SetFailovers(nil)
+
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	opts := Options{Endpoint: primary.URL, NoSignRequest: true}
+	opts.SetRegion("us-east-1")
+
+	client, err := NewRemoteClient(context.Background(), u, opts)
+	assert.NilError(t, err)
+
+	// the reads fail until the threshold is reached.
+	for i := 0; i < failoverThreshold-1; i++ {
+		_, err := client.Stat(context.Background(), u)
+		assert.Assert(t, err != nil)
+	}
+
+	// the request reaching the threshold is sent to the replica.
+	object, err := client.Stat(context.Background(), u)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(7), object.Size)
+	assert.Equal(t, "s3://bucket/key", object.URL.String())
+
+	rc, err := client.Read(context.Background(), u)
+	assert.NilError(t, err)
+	defer rc.Close()
+
+	content, err := ioutil.ReadAll(rc)
+	assert.NilError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	assert.Equal(t, int64(failoverThreshold), atomic.LoadInt64(&primaryRequests))
+}
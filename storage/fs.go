@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 
 	"github.com/karrick/godirwalk"
 	"github.com/termie/go-shutil"
@@ -14,7 +16,8 @@ import (
 
 // Filesystem is the Storage implementation of a local filesystem.
 type Filesystem struct {
-	dryRun bool
+	dryRun           bool
+	groupByDirectory bool
 }
 
 // Stat returns the Object structure describing object.
@@ -71,11 +74,31 @@ func (f *Filesystem) expandGlob(ctx context.Context, src *url.URL, followSymlink
 	go func() {
 		defer close(ch)
 
-		matchedFiles, err := filepath.Glob(src.Absolute())
+		patterns, err := src.GlobPatterns()
 		if err != nil {
 			sendError(ctx, err, ch)
 			return
 		}
+
+		// the files matching more than one of the alternatives of the brace
+		// groups are sent once.
+		var (
+			matchedFiles []string
+			seen         = map[string]bool{}
+		)
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				sendError(ctx, err, ch)
+				return
+			}
+			for _, match := range matches {
+				if !seen[match] {
+					seen[match] = true
+					matchedFiles = append(matchedFiles, match)
+				}
+			}
+		}
 		if len(matchedFiles) == 0 {
 			err := fmt.Errorf("no match found for %q", src)
 			sendError(ctx, err, ch)
@@ -108,42 +131,90 @@ func walkDir(ctx context.Context, fs *Filesystem, src *url.URL, followSymlinks b
 	if !ShouldProcessUrl(src, followSymlinks) {
 		return
 	}
-	err := godirwalk.Walk(src.Absolute(), &godirwalk.Options{
-		Callback: func(pathname string, dirent *godirwalk.Dirent) error {
-			// we're interested in files
-			if dirent.IsDir() {
-				return nil
-			}
+	visit := func(pathname string) error {
+		fileurl, err := url.New(pathname)
+		if err != nil {
+			return err
+		}
 
-			fileurl, err := url.New(pathname)
-			if err != nil {
-				return err
-			}
+		fileurl.SetRelative(src.Absolute())
 
-			fileurl.SetRelative(src.Absolute())
+		//skip if symlink is pointing to a file and --no-follow-symlink
+		if !ShouldProcessUrl(fileurl, followSymlinks) {
+			return nil
+		}
 
-			//skip if symlink is pointing to a file and --no-follow-symlink
-			if !ShouldProcessUrl(fileurl, followSymlinks) {
-				return nil
-			}
+		obj, err := fs.Stat(ctx, fileurl)
 
-			obj, err := fs.Stat(ctx, fileurl)
+		if err != nil {
+			return err
+		}
+		fn(obj)
+		return nil
+	}
 
-			if err != nil {
-				return err
-			}
-			fn(obj)
-			return nil
-		},
-		// flags
-		FollowSymbolicLinks: followSymlinks,
-	})
+	var err error
+	if fs.groupByDirectory {
+		err = walkGroupedByDirectory(src.Absolute(), followSymlinks, visit)
+	} else {
+		err = godirwalk.Walk(src.Absolute(), &godirwalk.Options{
+			Callback: func(pathname string, dirent *godirwalk.Dirent) error {
+				// we're interested in files
+				if dirent.IsDir() {
+					return nil
+				}
+				return visit(pathname)
+			},
+			// flags
+			FollowSymbolicLinks: followSymlinks,
+		})
+	}
 	if err != nil {
 		obj := &Object{Err: err}
 		fn(obj)
 	}
 }
 
+// walkGroupedByDirectory visits all files of a directory in lexical order
+// before descending into its subdirectories, so that the files of a directory
+// are read together.
+func walkGroupedByDirectory(dirname string, followSymlinks bool, visit func(pathname string) error) error {
+	dirents, err := godirwalk.ReadDirents(dirname, nil)
+	if err != nil {
+		return err
+	}
+	sort.Sort(dirents)
+
+	var subdirs []string
+	for _, dirent := range dirents {
+		pathname := filepath.Join(dirname, dirent.Name())
+
+		isDir := dirent.IsDir()
+		if !isDir && followSymlinks && dirent.IsSymlink() {
+			isDir, err = dirent.IsDirOrSymlinkToDir()
+			if err != nil {
+				return err
+			}
+		}
+
+		if isDir {
+			subdirs = append(subdirs, pathname)
+			continue
+		}
+
+		if err := visit(pathname); err != nil {
+			return err
+		}
+	}
+
+	for _, subdir := range subdirs {
+		if err := walkGroupedByDirectory(subdir, followSymlinks, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *Filesystem) walkDir(ctx context.Context, src *url.URL, followSymlinks bool) <-chan *Object {
 	ch := make(chan *Object)
 	go func() {
@@ -213,6 +284,30 @@ func (f *Filesystem) Create(path string) (*os.File, error) {
 	return os.Create(path)
 }
 
+// Sync commits the content of the given file and its directory entry to the
+// disk. Directories can't be synced on Windows, only the file is synced there.
+func (f *Filesystem) Sync(file *os.File) error {
+	if f.dryRun {
+		return nil
+	}
+
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	dir, err := os.Open(filepath.Dir(file.Name()))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
 // Open opens the given source.
 func (f *Filesystem) Open(path string) (*os.File, error) {
 	file, err := os.OpenFile(path, os.O_RDONLY, 0644)
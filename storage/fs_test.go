@@ -1,6 +1,15 @@
 package storage
 
-import "testing"
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/peak/s5cmd/storage/url"
+)
 
 func TestFilesystemImplementsStorageInterface(t *testing.T) {
 	var i interface{} = new(Filesystem)
@@ -8,3 +17,51 @@ func TestFilesystemImplementsStorageInterface(t *testing.T) {
 		t.Errorf("expected %t to implement Storage interface", i)
 	}
 }
+
+func TestFilesystemListGroupedByDirectory(t *testing.T) {
+	dir := fs.NewDir(t, "s5cmd-walk",
+		fs.WithFile("a.txt", ""),
+		fs.WithDir("b",
+			fs.WithFile("x.txt", ""),
+			fs.WithDir("c", fs.WithFile("y.txt", "")),
+			fs.WithFile("z.txt", ""),
+		),
+		fs.WithFile("d.txt", ""),
+	)
+	defer dir.Remove()
+
+	testcases := []struct {
+		name             string
+		groupByDirectory bool
+		expected         []string
+	}{
+		{
+			name:     "lexical order",
+			expected: []string{"a.txt", "b/c/y.txt", "b/x.txt", "b/z.txt", "d.txt"},
+		},
+		{
+			name:             "grouped by directory",
+			groupByDirectory: true,
+			expected:         []string{"a.txt", "d.txt", "b/x.txt", "b/z.txt", "b/c/y.txt"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := url.New(dir.Path())
+			assert.NilError(t, err)
+
+			client := NewLocalClient(Options{GroupByDirectory: tc.groupByDirectory})
+
+			var got []string
+			for obj := range client.List(context.Background(), src, true) {
+				assert.NilError(t, obj.Err)
+				rel, err := filepath.Rel(dir.Path(), obj.URL.Absolute())
+				assert.NilError(t, err)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			assert.DeepEqual(t, got, tc.expected)
+		})
+	}
+}
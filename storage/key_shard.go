@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// MaxKeyShardLength is the maximum number of hex digits of the shard
+// prefixes. Listings send a request for each of the 16^n shards, so longer
+// prefixes make listings too slow to be useful.
+const MaxKeyShardLength = 3
+
+// shardKey inserts the shard of the key before it, e.g. "ab/dir/file.txt".
+// The shard is the first hex digits of the MD5 hash of the key, so that the
+// keys are spread evenly over the shards while the shard of a key can be
+// computed without a lookup.
+func shardKey(key string, length int) string {
+	if length <= 0 {
+		return key
+	}
+	sum := md5.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])[:length] + "/" + key
+}
+
+// unshardKey removes the shard prefix added by shardKey from the key. Keys
+// without a shard prefix are returned unchanged.
+func unshardKey(key string, length int) string {
+	if length <= 0 || len(key) <= length || key[length] != '/' {
+		return key
+	}
+	return key[length+1:]
+}
+
+// shardPrefixes returns the given prefix under each of the shards.
+func shardPrefixes(prefix string, length int) []string {
+	if length <= 0 {
+		return []string{prefix}
+	}
+
+	count := 1 << (4 * uint(length))
+	prefixes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		prefixes = append(prefixes, fmt.Sprintf("%0*x/%v", length, i, prefix))
+	}
+	return prefixes
+}
+
+// objectKey returns the key of the object with the given path in the bucket.
+func (s *S3) objectKey(path string) string {
+	return shardKey(path, s.keyShardLength)
+}
+
+// objectPath returns the path of the object with the given key in the
+// bucket. It is the reverse of objectKey.
+func (s *S3) objectPath(key string) string {
+	return unshardKey(key, s.keyShardLength)
+}
+
+// listPrefixes returns the prefixes to be listed to find the objects of the
+// given URL.
+func (s *S3) listPrefixes(url *url.URL) []string {
+	var prefixes []string
+	for _, prefix := range url.ListPrefixes() {
+		prefixes = append(prefixes, shardPrefixes(prefix, s.keyShardLength)...)
+	}
+	return prefixes
+}
+
+// shardStartAfter returns the key which the listing of the given shard prefix
+// starts after. The keys are ordered within each of the shards, so each of
+// them is listed after the key in the shard.
+func (s *S3) shardStartAfter(listPrefix, key string) string {
+	if s.keyShardLength <= 0 || len(listPrefix) <= s.keyShardLength {
+		return key
+	}
+	return listPrefix[:s.keyShardLength+1] + key
+}
+
+// commonPrefixFilter returns a function which reports whether the given
+// common prefix is seen before. The same directory is listed under each of
+// the shards, so it is reported only once.
+func (s *S3) commonPrefixFilter() func(prefix string) bool {
+	if s.keyShardLength <= 0 {
+		return func(string) bool { return false }
+	}
+
+	seen := map[string]bool{}
+	return func(prefix string) bool {
+		if seen[prefix] {
+			return true
+		}
+		seen[prefix] = true
+		return false
+	}
+}
+
+// copySource returns the escaped bucket and key of the given object to be
+// used as the source of copy requests.
+func (s *S3) copySource(from *url.URL) string {
+	source := from.Clone()
+	source.Path = s.objectKey(from.Path)
+	return source.EscapedPath()
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestShardKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		key    string
+		length int
+		want   string
+	}{
+		{
+			name:   "disabled",
+			key:    "dir/file.txt",
+			length: 0,
+			want:   "dir/file.txt",
+		},
+		{
+			// md5("dir/file.txt") = "f212ada1..."
+			name:   "single digit",
+			key:    "dir/file.txt",
+			length: 1,
+			want:   "f/dir/file.txt",
+		},
+		{
+			name:   "two digits",
+			key:    "dir/file.txt",
+			length: 2,
+			want:   "f2/dir/file.txt",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := shardKey(tc.key, tc.length)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.key, unshardKey(got, tc.length))
+		})
+	}
+}
+
+func TestShardPrefixes(t *testing.T) {
+	t.Parallel()
+
+	assert.DeepEqual(t, []string{"dir/"}, shardPrefixes("dir/", 0))
+
+	prefixes := shardPrefixes("dir/", 2)
+	assert.Equal(t, 256, len(prefixes))
+	assert.Equal(t, "00/dir/", prefixes[0])
+	assert.Equal(t, "ff/dir/", prefixes[255])
+
+	// the shard of every key must be one of the listed prefixes.
+	key := shardKey("dir/file.txt", 2)
+	var found bool
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix[:3]) {
+			found = true
+		}
+	}
+	assert.Assert(t, found)
+}
+
+func TestS3ListShardedKeys(t *testing.T) {
+	u, err := url.New("s3://bucket/dir/")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var listed []string
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		prefix := aws.StringValue(r.Params.(*s3.ListObjectsV2Input).Prefix)
+		listed = append(listed, prefix)
+
+		shard := prefix[:2]
+		r.Data = &s3.ListObjectsV2Output{
+			// the same directory is found under each of the shards.
+			CommonPrefixes: []*s3.CommonPrefix{
+				{Prefix: aws.String(shard + "dir/sub/")},
+			},
+		}
+		switch shard {
+		case "0/":
+			r.Data.(*s3.ListObjectsV2Output).Contents = []*s3.Object{
+				{Key: aws.String("0/dir/a.txt")},
+			}
+		case "f/":
+			r.Data.(*s3.ListObjectsV2Output).Contents = []*s3.Object{
+				{Key: aws.String("f/dir/b.txt")},
+			}
+		}
+	})
+
+	mockS3 := &S3{
+		api:            mockApi,
+		keyShardLength: 1,
+	}
+
+	var got []string
+	for object := range mockS3.List(context.Background(), u, true) {
+		assert.NilError(t, object.Err)
+		got = append(got, object.URL.String())
+	}
+	sort.Strings(got)
+
+	assert.Equal(t, 16, len(listed))
+	assert.DeepEqual(t, []string{
+		"s3://bucket/dir/a.txt",
+		"s3://bucket/dir/b.txt",
+		"s3://bucket/dir/sub/",
+	}, got)
+}
+
+func TestS3StatShardedKey(t *testing.T) {
+	u, err := url.New("s3://bucket/dir/file.txt")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var key string
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		key = aws.StringValue(r.Params.(*s3.HeadObjectInput).Key)
+	})
+
+	mockS3 := &S3{
+		api:            mockApi,
+		keyShardLength: 2,
+	}
+
+	_, err = mockS3.Stat(context.Background(), u)
+	assert.NilError(t, err)
+	assert.Equal(t, "f2/dir/file.txt", key)
+}
+
+func TestS3ListShardedKeysStartAfter(t *testing.T) {
+	u, err := url.New("s3://bucket/dir/*")
+	assert.NilError(t, err)
+	u.StartAfter = "dir/b.txt"
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var startAfter []string
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		input := r.Params.(*s3.ListObjectsV2Input)
+		startAfter = append(startAfter, aws.StringValue(input.StartAfter))
+		r.Data = &s3.ListObjectsV2Output{}
+	})
+
+	mockS3 := &S3{
+		api:            mockApi,
+		keyShardLength: 1,
+	}
+
+	for range mockS3.List(context.Background(), u, true) {
+	}
+
+	// each of the shards is listed after the key in the shard.
+	assert.Equal(t, 16, len(startAfter))
+	assert.Equal(t, "0/dir/b.txt", startAfter[0])
+	assert.Equal(t, "f/dir/b.txt", startAfter[15])
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// kmsBoundOperations are the operations which may call KMS on the server side
+// for the objects encrypted with SSE-KMS, e.g. to generate or decrypt the data
+// keys of the objects.
+var kmsBoundOperations = map[string]struct{}{
+	"PutObject":               {},
+	"CreateMultipartUpload":   {},
+	"UploadPart":              {},
+	"UploadPartCopy":          {},
+	"CompleteMultipartUpload": {},
+	"CopyObject":              {},
+	"GetObject":               {},
+}
+
+const (
+	// minKMSBackoff and maxKMSBackoff are the bounds of the pause of the
+	// KMS-bound requests after KMS throttles them.
+	minKMSBackoff = 500 * time.Millisecond
+	maxKMSBackoff = 20 * time.Second
+)
+
+// isKMSThrottlingError reports whether the error is returned because KMS
+// throttled the request made by S3 on behalf of the client, e.g.
+// "KMS.ThrottlingException: You have exceeded the rate at which you may call
+// KMS".
+func isKMSThrottlingError(err error) bool {
+	if errHasCode(err, "KMS.ThrottlingException") {
+		return true
+	}
+
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	switch awsErr.Code() {
+	case "ThrottlingException", "SlowDown":
+		return strings.Contains(awsErr.Message(), "KMS")
+	}
+	return false
+}
+
+// usesKMS reports whether the value of the x-amz-server-side-encryption
+// header is of SSE-KMS, e.g. aws:kms or aws:kms:dsse.
+func usesKMS(header http.Header) bool {
+	return strings.HasPrefix(header.Get("X-Amz-Server-Side-Encryption"), s3.ServerSideEncryptionAwsKms)
+}
+
+// requestBucket returns the bucket of the request, if its input has one.
+func requestBucket(r *request.Request) string {
+	v := reflect.Indirect(reflect.ValueOf(r.Params))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.FieldByName("Bucket")
+	if !field.IsValid() {
+		return ""
+	}
+	bucket, _ := field.Interface().(*string)
+	return aws.StringValue(bucket)
+}
+
+// kmsLimiter paces the KMS-bound requests separately from the others, since
+// the request quotas of KMS are much lower than the ones of S3 and are shared
+// by all of the buckets using the same key. The requests wait for the
+// configured rate, if any, and all of them pause for a while once KMS
+// throttles one of them, instead of retrying at once.
+//
+// The requests are KMS-bound if they are of the operations which may call
+// KMS and they encrypt the objects with SSE-KMS, or their buckets are seen to
+// have SSE-KMS encrypted objects, e.g. the downloads and the parts of the
+// uploads of the buckets with SSE-KMS default encryption.
+type kmsLimiter struct {
+	mu sync.Mutex
+	// kmsBuckets are the buckets whose responses show SSE-KMS encryption.
+	kmsBuckets map[string]bool
+	// rate is the maximum number of the KMS-bound requests per second, zero
+	// if it is unlimited.
+	rate float64
+	// next is the earliest time the next KMS-bound request can be sent.
+	next time.Time
+	// backoff is the pause after the last throttled request, it grows as the
+	// requests keep being throttled.
+	backoff time.Duration
+
+	throttled int64
+}
+
+// kmsRequestLimiter is shared by all of the sessions, so that the rate applies
+// across the commands running together in the same process.
+var kmsRequestLimiter = &kmsLimiter{}
+
+// SetKMSRate sets the maximum number of the requests per second which may call
+// KMS, e.g. uploads, copies and downloads of SSE-KMS encrypted objects. Zero
+// rate disables the limit. It must be called before any request is sent.
+func SetKMSRate(rate float64) {
+	kmsRequestLimiter.mu.Lock()
+	defer kmsRequestLimiter.mu.Unlock()
+	kmsRequestLimiter.rate = rate
+}
+
+// KMSThrottles returns the number of the requests throttled by KMS.
+func KMSThrottles() int64 {
+	return atomic.LoadInt64(&kmsRequestLimiter.throttled)
+}
+
+// reserve returns the time the KMS-bound request can be sent at, and
+// reserves the slot of the request.
+func (l *kmsLimiter) reserve(now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	at := now
+	if l.next.After(at) {
+		at = l.next
+	}
+	if l.rate > 0 {
+		l.next = at.Add(time.Duration(float64(time.Second) / l.rate))
+	}
+	return at
+}
+
+// isKMSBound reports whether the request may call KMS, before it is sent.
+func (l *kmsLimiter) isKMSBound(r *request.Request) bool {
+	if _, ok := kmsBoundOperations[r.Operation.Name]; !ok {
+		return false
+	}
+	if usesKMS(r.HTTPRequest.Header) {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.kmsBuckets[requestBucket(r)]
+}
+
+// wait delays the KMS-bound request before each attempt to send it. If the
+// wait is canceled, the request fails since its context is done.
+func (l *kmsLimiter) wait(r *request.Request) {
+	if !l.isKMSBound(r) {
+		return
+	}
+
+	delay := time.Until(l.reserve(time.Now()))
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+		r.Error = r.Context().Err()
+	}
+}
+
+// observe pauses the KMS-bound requests after each attempt throttled by KMS,
+// and resets the pause once the requests succeed again. The buckets of the
+// responses showing SSE-KMS encryption are recorded, so that their requests
+// are KMS-bound from then on.
+func (l *kmsLimiter) observe(r *request.Request) {
+	if _, ok := kmsBoundOperations[r.Operation.Name]; !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket := requestBucket(r)
+	if r.HTTPResponse != nil && usesKMS(r.HTTPResponse.Header) {
+		if l.kmsBuckets == nil {
+			l.kmsBuckets = map[string]bool{}
+		}
+		l.kmsBuckets[bucket] = true
+	}
+
+	// the responses of the other requests don't tell if KMS is called.
+	throttled := isKMSThrottlingError(r.Error)
+	if !throttled && !usesKMS(r.HTTPRequest.Header) && !l.kmsBuckets[bucket] {
+		return
+	}
+
+	if !throttled {
+		if r.Error == nil {
+			l.backoff = 0
+		}
+		return
+	}
+
+	atomic.AddInt64(&l.throttled, 1)
+
+	l.backoff *= 2
+	if l.backoff < minKMSBackoff {
+		l.backoff = minKMSBackoff
+	}
+	if l.backoff > maxKMSBackoff {
+		l.backoff = maxKMSBackoff
+	}
+	if next := time.Now().Add(l.backoff); next.After(l.next) {
+		l.next = next
+	}
+}
+
+// install adds the handlers pacing the KMS-bound requests to the given
+// handlers. It must be installed after the concurrency limits, so that the
+// requests don't hold the concurrency limits while they wait.
+func (l *kmsLimiter) install(handlers *request.Handlers) {
+	handlers.Send.PushFront(l.wait)
+	handlers.CompleteAttempt.PushBack(l.observe)
+}
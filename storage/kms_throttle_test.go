@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+)
+
+func TestIsKMSThrottlingError(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "kms throttling",
+			err:      awserr.New("KMS.ThrottlingException", "You have exceeded the rate at which you may call KMS.", nil),
+			expected: true,
+		},
+		{
+			name:     "throttling of kms calls",
+			err:      awserr.New("ThrottlingException", "Rate exceeded for KMS", nil),
+			expected: true,
+		},
+		{
+			name:     "s3 throttling",
+			err:      awserr.New("SlowDown", "Please reduce your request rate.", nil),
+			expected: false,
+		},
+		{
+			name:     "kms access denied",
+			err:      awserr.New("KMS.AccessDeniedException", "access denied", nil),
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("KMS.ThrottlingException"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, isKMSThrottlingError(tc.err), tc.expected)
+		})
+	}
+}
+
+func TestKMSLimiterRate(t *testing.T) {
+	t.Parallel()
+
+	limiter := &kmsLimiter{rate: 10}
+
+	now := time.Now()
+	assert.Equal(t, limiter.reserve(now), now)
+	assert.Equal(t, limiter.reserve(now), now.Add(100*time.Millisecond))
+	assert.Equal(t, limiter.reserve(now), now.Add(200*time.Millisecond))
+
+	// the requests are not delayed without a rate.
+	limiter = &kmsLimiter{}
+	assert.Equal(t, limiter.reserve(now), now)
+	assert.Equal(t, limiter.reserve(now), now)
+}
+
+func TestKMSLimiterBackoff(t *testing.T) {
+	t.Parallel()
+
+	limiter := &kmsLimiter{}
+
+	mockApi := s3.New(unit.Session.Copy(&aws.Config{
+		Retryer:    newCustomRetryer(0),
+		SleepDelay: func(time.Duration) {},
+	}))
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var kmsErr error
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		r.Error = kmsErr
+		r.HTTPResponse = &http.Response{StatusCode: http.StatusBadRequest}
+	})
+	limiter.install(&mockApi.Handlers)
+
+	putObject := func(ctx context.Context) error {
+		_, err := mockApi.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket:               aws.String("bucket"),
+			Key:                  aws.String("key"),
+			ServerSideEncryption: aws.String(s3.ServerSideEncryptionAwsKms),
+		})
+		return err
+	}
+
+	kmsErr = awserr.New("KMS.ThrottlingException", "You have exceeded the rate at which you may call KMS.", nil)
+	assert.ErrorContains(t, putObject(context.Background()), "KMS.ThrottlingException")
+	assert.Equal(t, limiter.throttled, int64(1))
+	assert.Equal(t, limiter.backoff, minKMSBackoff)
+
+	// the KMS-bound requests pause after the throttled one.
+	kmsErr = nil
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorContains(t, putObject(ctx), "deadline exceeded")
+
+	// the other requests are not paused.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := mockApi.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+	})
+	assert.NilError(t, err)
+
+	// neither are the uploads without SSE-KMS.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = mockApi.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+	})
+	assert.NilError(t, err)
+}
+
+func TestKMSLimiterBuckets(t *testing.T) {
+	t.Parallel()
+
+	limiter := &kmsLimiter{}
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		header := http.Header{}
+		if requestBucket(r) == "kms-bucket" {
+			header.Set("X-Amz-Server-Side-Encryption", s3.ServerSideEncryptionAwsKms)
+		}
+		r.HTTPResponse = &http.Response{StatusCode: http.StatusOK, Header: header}
+	})
+	limiter.install(&mockApi.Handlers)
+
+	getObject := func(bucket string) *request.Request {
+		req, _ := mockApi.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String("key"),
+		})
+		return req
+	}
+
+	assert.Assert(t, !limiter.isKMSBound(getObject("kms-bucket")))
+	assert.NilError(t, getObject("kms-bucket").Send())
+	assert.NilError(t, getObject("bucket").Send())
+
+	// the downloads of the buckets with SSE-KMS encrypted objects are
+	// KMS-bound once they are seen.
+	assert.Assert(t, limiter.isKMSBound(getObject("kms-bucket")))
+	assert.Assert(t, !limiter.isKMSBound(getObject("bucket")))
+
+	req, _ := mockApi.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:               aws.String("bucket"),
+		Key:                  aws.String("key"),
+		ServerSideEncryption: aws.String("aws:kms:dsse"),
+	})
+	assert.NilError(t, req.Build())
+	assert.Assert(t, limiter.isKMSBound(req))
+}
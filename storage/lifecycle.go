@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// LifecycleRule is a lifecycle rule of a bucket, expiring or transitioning
+// the objects matching its prefix and tags.
+type LifecycleRule struct {
+	ID     string            `json:"id,omitempty"`
+	Status string            `json:"status"`
+	Prefix string            `json:"prefix"`
+	Tags   map[string]string `json:"tags,omitempty"`
+
+	ExpirationDays            int64                           `json:"expiration_days,omitempty"`
+	ExpirationDate            *time.Time                      `json:"expiration_date,omitempty"`
+	ExpiredObjectDeleteMarker bool                            `json:"expired_object_delete_marker,omitempty"`
+	Transitions               []LifecycleTransition           `json:"transitions,omitempty"`
+	NoncurrentExpirationDays  int64                           `json:"noncurrent_expiration_days,omitempty"`
+	NoncurrentTransitions     []LifecycleNoncurrentTransition `json:"noncurrent_transitions,omitempty"`
+	AbortIncompleteUploadDays int64                           `json:"abort_incomplete_upload_days,omitempty"`
+}
+
+// LifecycleTransition moves the objects to the given storage class once they
+// are older than the given number of days, or on the given date.
+type LifecycleTransition struct {
+	Days         int64      `json:"days,omitempty"`
+	Date         *time.Time `json:"date,omitempty"`
+	StorageClass string     `json:"storage_class"`
+}
+
+// LifecycleNoncurrentTransition moves the noncurrent versions of the objects
+// to the given storage class once they are noncurrent for the given number
+// of days.
+type LifecycleNoncurrentTransition struct {
+	Days         int64  `json:"days"`
+	StorageClass string `json:"storage_class"`
+}
+
+// unsupportedLifecycleElements are the elements of the lifecycle rules which
+// are not known by the SDK. The SDK drops them while parsing the response,
+// so the rules having them are refused instead of being printed without
+// them, which would be lost once the rules are set again.
+var unsupportedLifecycleElements = []string{
+	"ObjectSizeGreaterThan",
+	"ObjectSizeLessThan",
+	"NewerNoncurrentVersions",
+}
+
+// Lifecycle returns the lifecycle rules of the bucket with the given name,
+// or ErrNoLifecycleConfiguration if the bucket has no lifecycle
+// configuration.
+func (s *S3) Lifecycle(ctx context.Context, name string) ([]LifecycleRule, error) {
+	var unsupported []string
+	output, err := s.api.GetBucketLifecycleConfigurationWithContext(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(name),
+	}, unsupportedLifecycleOption(&unsupported))
+	if errHasCode(err, "NoSuchLifecycleConfiguration") {
+		return nil, ErrNoLifecycleConfiguration
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("lifecycle rules with %s are not supported", strings.Join(unsupported, ", "))
+	}
+
+	rules := make([]LifecycleRule, 0, len(output.Rules))
+	for _, rule := range output.Rules {
+		rules = append(rules, fromS3LifecycleRule(rule))
+	}
+	return rules, nil
+}
+
+// SetLifecycle replaces the lifecycle configuration of the bucket with the
+// given name with the given rules.
+func (s *S3) SetLifecycle(ctx context.Context, name string, rules []LifecycleRule) error {
+	if s.dryRun {
+		simulation.add("PutBucketLifecycleConfiguration", 1, 0)
+		return nil
+	}
+
+	s3rules := make([]*s3.LifecycleRule, 0, len(rules))
+	for _, rule := range rules {
+		s3rules = append(s3rules, toS3LifecycleRule(rule))
+	}
+
+	_, err := s.api.PutBucketLifecycleConfigurationWithContext(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(name),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: s3rules},
+	})
+	return err
+}
+
+// DeleteLifecycle removes the lifecycle configuration of the bucket with the
+// given name.
+func (s *S3) DeleteLifecycle(ctx context.Context, name string) error {
+	if s.dryRun {
+		simulation.add("DeleteBucketLifecycle", 1, 0)
+		return nil
+	}
+
+	_, err := s.api.DeleteBucketLifecycleWithContext(ctx, &s3.DeleteBucketLifecycleInput{
+		Bucket: aws.String(name),
+	})
+	return err
+}
+
+// unsupportedLifecycleOption returns the request option which looks for the
+// unsupported elements in the response body before it is parsed.
+func unsupportedLifecycleOption(found *[]string) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Unmarshal.PushFront(func(r *request.Request) {
+			if r.Error != nil || r.HTTPResponse == nil || r.HTTPResponse.Body == nil {
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.HTTPResponse.Body)
+			r.HTTPResponse.Body.Close()
+			r.HTTPResponse.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				r.Error = err
+				return
+			}
+
+			for _, element := range unsupportedLifecycleElements {
+				if bytes.Contains(body, []byte("<"+element+">")) {
+					*found = append(*found, element)
+				}
+			}
+		})
+	}
+}
+
+func fromS3LifecycleRule(rule *s3.LifecycleRule) LifecycleRule {
+	r := LifecycleRule{
+		ID:     aws.StringValue(rule.ID),
+		Status: aws.StringValue(rule.Status),
+		// the prefix of the rule is deprecated in favor of its filter, but
+		// the rules created with it are still returned.
+		Prefix: aws.StringValue(rule.Prefix),
+	}
+
+	if filter := rule.Filter; filter != nil {
+		if filter.Prefix != nil {
+			r.Prefix = aws.StringValue(filter.Prefix)
+		}
+		var tags []*s3.Tag
+		if filter.Tag != nil {
+			tags = append(tags, filter.Tag)
+		}
+		if and := filter.And; and != nil {
+			r.Prefix = aws.StringValue(and.Prefix)
+			tags = append(tags, and.Tags...)
+		}
+		for _, tag := range tags {
+			if r.Tags == nil {
+				r.Tags = map[string]string{}
+			}
+			r.Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+	}
+
+	if expiration := rule.Expiration; expiration != nil {
+		r.ExpirationDays = aws.Int64Value(expiration.Days)
+		r.ExpirationDate = expiration.Date
+		r.ExpiredObjectDeleteMarker = aws.BoolValue(expiration.ExpiredObjectDeleteMarker)
+	}
+	for _, transition := range rule.Transitions {
+		r.Transitions = append(r.Transitions, LifecycleTransition{
+			Days:         aws.Int64Value(transition.Days),
+			Date:         transition.Date,
+			StorageClass: aws.StringValue(transition.StorageClass),
+		})
+	}
+	if rule.NoncurrentVersionExpiration != nil {
+		r.NoncurrentExpirationDays = aws.Int64Value(rule.NoncurrentVersionExpiration.NoncurrentDays)
+	}
+	for _, transition := range rule.NoncurrentVersionTransitions {
+		r.NoncurrentTransitions = append(r.NoncurrentTransitions, LifecycleNoncurrentTransition{
+			Days:         aws.Int64Value(transition.NoncurrentDays),
+			StorageClass: aws.StringValue(transition.StorageClass),
+		})
+	}
+	if rule.AbortIncompleteMultipartUpload != nil {
+		r.AbortIncompleteUploadDays = aws.Int64Value(rule.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+	}
+	return r
+}
+
+func toS3LifecycleRule(rule LifecycleRule) *s3.LifecycleRule {
+	status := rule.Status
+	if status == "" {
+		status = s3.ExpirationStatusEnabled
+	}
+
+	r := &s3.LifecycleRule{
+		Status: aws.String(status),
+		Filter: &s3.LifecycleRuleFilter{Prefix: aws.String(rule.Prefix)},
+	}
+	if rule.ID != "" {
+		r.ID = aws.String(rule.ID)
+	}
+
+	// a filter can have a single condition, multiple conditions are combined
+	// with an and operator. The empty prefix is not a condition. The tags are
+	// sorted to send the same filter for the same rule.
+	if len(rule.Tags) > 0 {
+		keys := make([]string, 0, len(rule.Tags))
+		for key := range rule.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var tags []*s3.Tag
+		for _, key := range keys {
+			tags = append(tags, &s3.Tag{
+				Key:   aws.String(key),
+				Value: aws.String(rule.Tags[key]),
+			})
+		}
+
+		switch {
+		case len(tags) == 1 && rule.Prefix == "":
+			r.Filter = &s3.LifecycleRuleFilter{Tag: tags[0]}
+		case rule.Prefix == "":
+			r.Filter = &s3.LifecycleRuleFilter{And: &s3.LifecycleRuleAndOperator{Tags: tags}}
+		default:
+			r.Filter = &s3.LifecycleRuleFilter{And: &s3.LifecycleRuleAndOperator{
+				Prefix: aws.String(rule.Prefix),
+				Tags:   tags,
+			}}
+		}
+	}
+
+	// an expiration has a single condition.
+	switch {
+	case rule.ExpirationDays > 0:
+		r.Expiration = &s3.LifecycleExpiration{Days: aws.Int64(rule.ExpirationDays)}
+	case rule.ExpirationDate != nil:
+		r.Expiration = &s3.LifecycleExpiration{Date: rule.ExpirationDate}
+	case rule.ExpiredObjectDeleteMarker:
+		r.Expiration = &s3.LifecycleExpiration{ExpiredObjectDeleteMarker: aws.Bool(true)}
+	}
+	for _, transition := range rule.Transitions {
+		t := &s3.Transition{StorageClass: aws.String(transition.StorageClass)}
+		if transition.Date != nil {
+			t.Date = transition.Date
+		} else {
+			t.Days = aws.Int64(transition.Days)
+		}
+		r.Transitions = append(r.Transitions, t)
+	}
+	if rule.NoncurrentExpirationDays > 0 {
+		r.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{
+			NoncurrentDays: aws.Int64(rule.NoncurrentExpirationDays),
+		}
+	}
+	for _, transition := range rule.NoncurrentTransitions {
+		r.NoncurrentVersionTransitions = append(r.NoncurrentVersionTransitions, &s3.NoncurrentVersionTransition{
+			NoncurrentDays: aws.Int64(transition.Days),
+			StorageClass:   aws.String(transition.StorageClass),
+		})
+	}
+	if rule.AbortIncompleteUploadDays > 0 {
+		r.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{
+			DaysAfterInitiation: aws.Int64(rule.AbortIncompleteUploadDays),
+		}
+	}
+	return r
+}
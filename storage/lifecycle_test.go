@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+)
+
+func TestS3Lifecycle(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		output := r.Data.(*s3.GetBucketLifecycleConfigurationOutput)
+		output.Rules = []*s3.LifecycleRule{
+			{
+				ID:         aws.String("logs"),
+				Status:     aws.String(s3.ExpirationStatusEnabled),
+				Filter:     &s3.LifecycleRuleFilter{Prefix: aws.String("logs/")},
+				Expiration: &s3.LifecycleExpiration{Days: aws.Int64(90)},
+				Transitions: []*s3.Transition{
+					{Days: aws.Int64(30), StorageClass: aws.String(s3.TransitionStorageClassGlacier)},
+				},
+			},
+			{
+				Status: aws.String(s3.ExpirationStatusDisabled),
+				Filter: &s3.LifecycleRuleFilter{
+					And: &s3.LifecycleRuleAndOperator{
+						Prefix: aws.String("tmp/"),
+						Tags:   []*s3.Tag{{Key: aws.String("retention"), Value: aws.String("short")}},
+					},
+				},
+				NoncurrentVersionExpiration:    &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(7)},
+				AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(1)},
+			},
+			{
+				// the deprecated prefix of the rule.
+				Status:     aws.String(s3.ExpirationStatusEnabled),
+				Prefix:     aws.String("old/"),
+				Expiration: &s3.LifecycleExpiration{Days: aws.Int64(1)},
+			},
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	rules, err := mockS3.Lifecycle(context.Background(), "bucket")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, rules, []LifecycleRule{
+		{
+			ID:             "logs",
+			Status:         "Enabled",
+			Prefix:         "logs/",
+			ExpirationDays: 90,
+			Transitions:    []LifecycleTransition{{Days: 30, StorageClass: "GLACIER"}},
+		},
+		{
+			Status:                    "Disabled",
+			Prefix:                    "tmp/",
+			Tags:                      map[string]string{"retention": "short"},
+			NoncurrentExpirationDays:  7,
+			AbortIncompleteUploadDays: 1,
+		},
+		{
+			Status:         "Enabled",
+			Prefix:         "old/",
+			ExpirationDays: 1,
+		},
+	})
+}
+
+// newLifecycleResponseMock returns the client which parses the given body as
+// the response of GetBucketLifecycleConfiguration.
+func newLifecycleResponseMock(body string) *s3.S3 {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+	})
+	return mockApi
+}
+
+func TestS3LifecycleResponse(t *testing.T) {
+	mockApi := newLifecycleResponseMock(`<?xml version="1.0" encoding="UTF-8"?>
+<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<Rule>
+		<ID>archive</ID>
+		<Filter><Prefix>archive/</Prefix></Filter>
+		<Status>Enabled</Status>
+		<Transition><Date>2030-01-01T00:00:00.000Z</Date><StorageClass>GLACIER</StorageClass></Transition>
+		<Expiration><Date>2031-01-01T00:00:00.000Z</Date></Expiration>
+	</Rule>
+	<Rule>
+		<ID>versions</ID>
+		<Filter><Prefix></Prefix></Filter>
+		<Status>Enabled</Status>
+		<Expiration><ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker></Expiration>
+		<NoncurrentVersionTransition><NoncurrentDays>30</NoncurrentDays><StorageClass>STANDARD_IA</StorageClass></NoncurrentVersionTransition>
+		<NoncurrentVersionExpiration><NoncurrentDays>90</NoncurrentDays></NoncurrentVersionExpiration>
+	</Rule>
+</LifecycleConfiguration>`)
+
+	mockS3 := &S3{api: mockApi}
+
+	rules, err := mockS3.Lifecycle(context.Background(), "bucket")
+	assert.NilError(t, err)
+
+	transitionDate := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	expirationDate := time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.DeepEqual(t, rules, []LifecycleRule{
+		{
+			ID:             "archive",
+			Status:         "Enabled",
+			Prefix:         "archive/",
+			ExpirationDate: &expirationDate,
+			Transitions:    []LifecycleTransition{{Date: &transitionDate, StorageClass: "GLACIER"}},
+		},
+		{
+			ID:                        "versions",
+			Status:                    "Enabled",
+			ExpiredObjectDeleteMarker: true,
+			NoncurrentExpirationDays:  90,
+			NoncurrentTransitions:     []LifecycleNoncurrentTransition{{Days: 30, StorageClass: "STANDARD_IA"}},
+		},
+	})
+
+	// the rules are set as they are got.
+	output, err := mockApi.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String("bucket"),
+	})
+	assert.NilError(t, err)
+	for i, rule := range rules {
+		assert.DeepEqual(t, toS3LifecycleRule(rule), output.Rules[i])
+	}
+}
+
+func TestS3LifecycleUnsupported(t *testing.T) {
+	mockApi := newLifecycleResponseMock(`<?xml version="1.0" encoding="UTF-8"?>
+<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<Rule>
+		<Filter><And><Prefix>logs/</Prefix><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan></And></Filter>
+		<Status>Enabled</Status>
+		<NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays><NewerNoncurrentVersions>3</NewerNoncurrentVersions></NoncurrentVersionExpiration>
+	</Rule>
+</LifecycleConfiguration>`)
+
+	mockS3 := &S3{api: mockApi}
+
+	_, err := mockS3.Lifecycle(context.Background(), "bucket")
+	assert.Error(t, err, "lifecycle rules with ObjectSizeGreaterThan, NewerNoncurrentVersions are not supported")
+}
+
+func TestS3LifecycleNotFound(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.Error = awserr.New("NoSuchLifecycleConfiguration", "not found", nil)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	_, err := mockS3.Lifecycle(context.Background(), "bucket")
+	assert.Equal(t, err, ErrNoLifecycleConfiguration)
+}
+
+func TestS3SetLifecycle(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var input *s3.PutBucketLifecycleConfigurationInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input = r.Params.(*s3.PutBucketLifecycleConfigurationInput)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err := mockS3.SetLifecycle(context.Background(), "bucket", []LifecycleRule{
+		{
+			ID:             "logs",
+			Prefix:         "logs/",
+			ExpirationDays: 90,
+			Transitions:    []LifecycleTransition{{Days: 30, StorageClass: "GLACIER"}},
+		},
+		{
+			Status:                    "Disabled",
+			Tags:                      map[string]string{"b": "2", "a": "1"},
+			AbortIncompleteUploadDays: 1,
+		},
+		{
+			Tags:           map[string]string{"a": "1"},
+			ExpirationDays: 7,
+		},
+		{
+			Prefix:         "tmp/",
+			Tags:           map[string]string{"a": "1"},
+			ExpirationDays: 1,
+		},
+	})
+	assert.NilError(t, err)
+
+	assert.Equal(t, aws.StringValue(input.Bucket), "bucket")
+	assert.DeepEqual(t, input.LifecycleConfiguration.Rules, []*s3.LifecycleRule{
+		{
+			ID:         aws.String("logs"),
+			Status:     aws.String("Enabled"),
+			Filter:     &s3.LifecycleRuleFilter{Prefix: aws.String("logs/")},
+			Expiration: &s3.LifecycleExpiration{Days: aws.Int64(90)},
+			Transitions: []*s3.Transition{
+				{Days: aws.Int64(30), StorageClass: aws.String("GLACIER")},
+			},
+		},
+		{
+			Status: aws.String("Disabled"),
+			Filter: &s3.LifecycleRuleFilter{
+				And: &s3.LifecycleRuleAndOperator{
+					Tags: []*s3.Tag{
+						{Key: aws.String("a"), Value: aws.String("1")},
+						{Key: aws.String("b"), Value: aws.String("2")},
+					},
+				},
+			},
+			AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(1)},
+		},
+		{
+			// a single tag is not combined with the empty prefix.
+			Status:     aws.String("Enabled"),
+			Filter:     &s3.LifecycleRuleFilter{Tag: &s3.Tag{Key: aws.String("a"), Value: aws.String("1")}},
+			Expiration: &s3.LifecycleExpiration{Days: aws.Int64(7)},
+		},
+		{
+			Status: aws.String("Enabled"),
+			Filter: &s3.LifecycleRuleFilter{
+				And: &s3.LifecycleRuleAndOperator{
+					Prefix: aws.String("tmp/"),
+					Tags:   []*s3.Tag{{Key: aws.String("a"), Value: aws.String("1")}},
+				},
+			},
+			Expiration: &s3.LifecycleExpiration{Days: aws.Int64(1)},
+		},
+	})
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// Lister lists remote objects from a source other than the ListObjects API,
+// e.g. a database, a Hive metastore or an inventory service. It sends the
+// objects of the bucket of the given URL, which are filtered by the URL
+// afterwards, so a lister may send the objects of a wider prefix. Errors
+// are sent in the Err field of the objects.
+type Lister interface {
+	List(ctx context.Context, url *url.URL) <-chan *Object
+}
+
+// listWithLister lists the objects of the URL using the external lister of
+// the storage, in the same way as the ListObjects API lists them: keys are
+// matched by the URL and the keys under the sub-prefixes are grouped as
+// directories if the URL has a delimiter.
+func (s *S3) listWithLister(ctx context.Context, url *url.URL) <-chan *Object {
+	objCh := make(chan *Object)
+
+	go func() {
+		defer close(objCh)
+
+		objectFound := false
+		seenDirs := map[string]struct{}{}
+
+		for object := range s.lister.List(ctx, url) {
+			if object.Err != nil {
+				objCh <- object
+				objectFound = true
+				continue
+			}
+			if object.URL == nil || object.URL.Bucket != url.Bucket {
+				continue
+			}
+
+			key := object.URL.Path
+			if !strings.HasPrefix(key, url.Prefix) {
+				continue
+			}
+
+			// the keys are not sent in order, so each of them is compared
+			// with the key the listing starts after.
+			if url.StartAfter != "" && key <= url.StartAfter {
+				continue
+			}
+
+			if url.Delimiter != "" {
+				rest := key[len(url.Prefix):]
+				if i := strings.Index(rest, url.Delimiter); i >= 0 {
+					dir := url.Prefix + rest[:i+len(url.Delimiter)]
+					if _, ok := seenDirs[dir]; ok || !url.Match(dir) {
+						continue
+					}
+					seenDirs[dir] = struct{}{}
+
+					newurl := url.Clone()
+					newurl.Path = dir
+					objCh <- &Object{
+						URL:  newurl,
+						Type: ObjectType{os.ModeDir},
+					}
+					objectFound = true
+					continue
+				}
+			}
+
+			if !url.Match(key) {
+				continue
+			}
+
+			newurl := url.Clone()
+			newurl.Path = key
+			object.URL = newurl
+			if strings.HasSuffix(key, "/") {
+				object.Type = ObjectType{os.ModeDir}
+			}
+
+			objCh <- object
+			objectFound = true
+		}
+
+		if !objectFound {
+			objCh <- &Object{Err: ErrNoObjectFound}
+		}
+	}()
+
+	return objCh
+}
+
+// commandLister lists the objects by running a command, which prints the
+// objects to its standard output.
+type commandLister struct {
+	command string
+}
+
+// NewCommandLister returns a lister which runs the given shell command for
+// each listing. The URL to be listed is passed to the command in the
+// S5CMD_LIST_URL environment variable. The command prints an object per
+// line, either as a key, e.g. "s3://bucket/key" or "key", or as a JSON
+// object in the format of ls --json output, e.g.
+//
+//	{"key":"s3://bucket/key","size":42,"last_modified":"2021-01-02T15:04:05Z"}
+func NewCommandLister(command string) Lister {
+	return &commandLister{command: command}
+}
+
+func (l *commandLister) List(ctx context.Context, u *url.URL) <-chan *Object {
+	objCh := make(chan *Object)
+
+	go func() {
+		defer close(objCh)
+
+		cmd := shellCommand(ctx, l.command)
+		cmd.Env = append(os.Environ(), "S5CMD_LIST_URL="+u.String())
+		cmd.Stderr = os.Stderr
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			objCh <- &Object{Err: fmt.Errorf("lister command: %v", err)}
+			return
+		}
+		if err := cmd.Start(); err != nil {
+			objCh <- &Object{Err: fmt.Errorf("lister command: %v", err)}
+			return
+		}
+
+		parseErr := parseListerOutput(stdout, u.Bucket, func(object *Object) {
+			objCh <- object
+		})
+		if parseErr != nil {
+			// drain the output, so that the command doesn't block on writing.
+			_, _ = io.Copy(ioutil.Discard, stdout)
+		}
+
+		if err := cmd.Wait(); err != nil {
+			objCh <- &Object{Err: fmt.Errorf("lister command: %v", err)}
+			return
+		}
+		if parseErr != nil {
+			objCh <- &Object{Err: fmt.Errorf("lister command: %v", parseErr)}
+		}
+	}()
+
+	return objCh
+}
+
+// listerObject is an object printed by a lister command.
+type listerObject struct {
+	Key          string     `json:"key"`
+	Etag         string     `json:"etag"`
+	ModTime      *time.Time `json:"last_modified"`
+	Size         int64      `json:"size"`
+	StorageClass string     `json:"storage_class"`
+	VersionID    string     `json:"version_id"`
+}
+
+// parseListerOutput parses the objects printed by a lister command and
+// calls fn for each of them. Keys without a scheme are the keys of the
+// given bucket. Empty lines are skipped.
+func parseListerOutput(r io.Reader, bucket string, fn func(*Object)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var lo listerObject
+		if strings.HasPrefix(line, "{") {
+			if err := json.Unmarshal([]byte(line), &lo); err != nil {
+				return fmt.Errorf("line %d: %v", lineno, err)
+			}
+		} else {
+			lo.Key = line
+		}
+
+		key := lo.Key
+		if !strings.Contains(key, "://") {
+			key = fmt.Sprintf("s3://%v/%v", bucket, key)
+		}
+		objurl, err := url.New(key, url.WithRaw(true))
+		if err != nil {
+			return fmt.Errorf("line %d: %v", lineno, err)
+		}
+		if objurl.IsBucket() {
+			return fmt.Errorf("line %d: %q is not an object", lineno, lo.Key)
+		}
+
+		fn(&Object{
+			URL:          objurl,
+			Etag:         strings.Trim(lo.Etag, `"`),
+			ModTime:      lo.ModTime,
+			Size:         lo.Size,
+			StorageClass: StorageClass(lo.StorageClass),
+			VersionID:    lo.VersionID,
+		})
+	}
+	return scanner.Err()
+}
+
+// shellCommand returns a command which runs the given command line in the
+// shell of the platform.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+type staticLister []string
+
+func (l staticLister) List(ctx context.Context, u *url.URL) <-chan *Object {
+	objCh := make(chan *Object, len(l))
+	for _, key := range l {
+		objurl, _ := url.New(key, url.WithRaw(true))
+		objCh <- &Object{URL: objurl, Size: 1}
+	}
+	close(objCh)
+	return objCh
+}
+
+func TestS3ListWithLister(t *testing.T) {
+	t.Parallel()
+
+	lister := staticLister{
+		"s3://bucket/a/file.txt",
+		"s3://bucket/a/b/file.txt",
+		"s3://bucket/a/b/other.txt",
+		"s3://bucket/a/file.csv",
+		"s3://bucket/c/file.txt",
+		"s3://other/a/file.txt",
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		startAfter string
+		want       []string
+	}{
+		{
+			name: "prefix",
+			url:  "s3://bucket/a/",
+			want: []string{"a/file.txt", "a/b/", "a/file.csv"},
+		},
+		{
+			name: "wildcard",
+			url:  "s3://bucket/a/*.txt",
+			want: []string{"a/file.txt", "a/b/file.txt", "a/b/other.txt"},
+		},
+		{
+			name:       "start after",
+			url:        "s3://bucket/a/*.txt",
+			startAfter: "a/b/file.txt",
+			want:       []string{"a/file.txt", "a/b/other.txt"},
+		},
+		{
+			name: "no match",
+			url:  "s3://bucket/d/*",
+			want: []string{"no object found"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.New(tc.url)
+			assert.NilError(t, err)
+			u.StartAfter = tc.startAfter
+
+			s := &S3{lister: lister}
+
+			var got []string
+			for object := range s.List(context.Background(), u, false) {
+				if object.Err != nil {
+					got = append(got, object.Err.Error())
+					continue
+				}
+				assert.Equal(t, "bucket", object.URL.Bucket)
+				got = append(got, object.URL.Path)
+			}
+			assert.DeepEqual(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseListerOutput(t *testing.T) {
+	t.Parallel()
+
+	input := strings.Join([]string{
+		"s3://bucket/dir/file.txt",
+		"",
+		"dir/key with spaces",
+		`{"key":"s3://bucket/dir/obj","size":42,"etag":"\"abc\"","last_modified":"2021-01-02T15:04:05Z","storage_class":"GLACIER"}`,
+	}, "\n")
+
+	var objects []*Object
+	err := parseListerOutput(strings.NewReader(input), "bucket", func(o *Object) {
+		objects = append(objects, o)
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, 3, len(objects))
+
+	assert.Equal(t, "s3://bucket/dir/file.txt", objects[0].URL.String())
+	assert.Equal(t, "s3://bucket/dir/key with spaces", objects[1].URL.String())
+
+	assert.Equal(t, "s3://bucket/dir/obj", objects[2].URL.String())
+	assert.Equal(t, int64(42), objects[2].Size)
+	assert.Equal(t, "abc", objects[2].Etag)
+	assert.Equal(t, StorageClass("GLACIER"), objects[2].StorageClass)
+	assert.Equal(t, "2021-01-02T15:04:05Z", objects[2].ModTime.Format("2006-01-02T15:04:05Z07:00"))
+}
+
+func TestParseListerOutputInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "invalid json",
+			input: "s3://bucket/key\n{\"key\":",
+			want:  "line 2: unexpected end of JSON input",
+		},
+		{
+			name:  "bucket",
+			input: "s3://bucket",
+			want:  `line 1: "s3://bucket" is not an object`,
+		},
+		{
+			name:  "unsupported scheme",
+			input: "gs://bucket/key",
+			want:  `line 1: s3 url should start with "s3://"`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := parseListerOutput(strings.NewReader(tc.input), "bucket", func(*Object) {})
+			assert.Error(t, err, tc.want)
+		})
+	}
+}
+
+func TestCommandLister(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("lister command uses a POSIX shell")
+	}
+	t.Parallel()
+
+	u, err := url.New("s3://bucket/dir/*")
+	assert.NilError(t, err)
+
+	lister := NewCommandLister(`echo "$S5CMD_LIST_URL"; echo dir/file.txt; exit 3`)
+
+	var got []string
+	for object := range lister.List(context.Background(), u) {
+		if object.Err != nil {
+			got = append(got, object.Err.Error())
+			continue
+		}
+		got = append(got, object.URL.String())
+	}
+
+	want := []string{
+		"s3://bucket/dir/*",
+		"s3://bucket/dir/file.txt",
+		"lister command: exit status 3",
+	}
+	assert.DeepEqual(t, want, got)
+}
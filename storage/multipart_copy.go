@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+const (
+	// multipartCopyPartSize is the minimum size of the parts of a multipart
+	// copy. It is increased for large objects to stay in the part count limit.
+	multipartCopyPartSize = 512 * 1024 * 1024
+
+	// multipartCopyConcurrency is the number of parts copied in parallel.
+	multipartCopyConcurrency = 10
+
+	// maxMultipartParts is the max number of parts of a multipart upload.
+	maxMultipartParts = 10000
+)
+
+// isCopySourceTooLarge checks if the error is returned because the source
+// object exceeds the 5 GB size limit of CopyObject.
+func isCopySourceTooLarge(err error) bool {
+	return errHasCode(err, "InvalidRequest") &&
+		strings.Contains(err.Error(), "copy source is larger than the maximum allowable size")
+}
+
+// copyPartSize returns the part size to copy an object of the given size.
+func copyPartSize(size int64) int64 {
+	partSize := int64(multipartCopyPartSize)
+	if min := (size + maxMultipartParts - 1) / maxMultipartParts; min > partSize {
+		partSize = min
+	}
+	return partSize
+}
+
+// multipartCopy copies objects larger than the CopyObject size limit by
+// copying the ranges of the source object as the parts of a multipart upload
+// in parallel. The destination object is created with the headers of the
+// given copy input. Unlike CopyObject, object tags are not copied.
+func (s *S3) multipartCopy(ctx context.Context, from, to *url.URL, input *s3.CopyObjectInput) error {
+	head, err := s.api.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(from.Bucket),
+		Key:          aws.String(s.objectKey(from.Path)),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: input.CopySourceSSECustomerAlgorithm,
+		SSECustomerKey:       input.CopySourceSSECustomerKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	// multipart uploads don't copy the metadata of the source object.
+	if aws.StringValue(input.MetadataDirective) == string(MetadataDirectiveCopy) {
+		setSourceMetadata(input, head)
+	}
+
+	created, err := s.api.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:                  input.Bucket,
+		Key:                     input.Key,
+		ACL:                     input.ACL,
+		CacheControl:            input.CacheControl,
+		ContentDisposition:      input.ContentDisposition,
+		ContentEncoding:         input.ContentEncoding,
+		ContentLanguage:         input.ContentLanguage,
+		ContentType:             input.ContentType,
+		Expires:                 input.Expires,
+		Metadata:                input.Metadata,
+		RequestPayer:            input.RequestPayer,
+		SSECustomerAlgorithm:    input.SSECustomerAlgorithm,
+		SSECustomerKey:          input.SSECustomerKey,
+		SSEKMSKeyId:             input.SSEKMSKeyId,
+		BucketKeyEnabled:        input.BucketKeyEnabled,
+		ServerSideEncryption:    input.ServerSideEncryption,
+		StorageClass:            input.StorageClass,
+		Tagging:                 input.Tagging,
+		WebsiteRedirectLocation: input.WebsiteRedirectLocation,
+	})
+	if err != nil {
+		return err
+	}
+	uploadID := created.UploadId
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan bool, multipartCopyConcurrency)
+		parts    []*s3.CompletedPart
+		firstErr error
+	)
+
+	size := aws.Int64Value(head.ContentLength)
+	partSize := copyPartSize(size)
+	numParts := (size + partSize - 1) / partSize
+	for partNumber := int64(1); partNumber <= numParts; partNumber++ {
+		mu.Lock()
+		err := firstErr
+		mu.Unlock()
+		if err != nil || ctx.Err() != nil {
+			break
+		}
+
+		start := (partNumber - 1) * partSize
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+
+		wg.Add(1)
+		sem <- true
+		go func(partNumber, start, end int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			output, err := s.api.UploadPartCopyWithContext(ctx, &s3.UploadPartCopyInput{
+				Bucket:          input.Bucket,
+				Key:             input.Key,
+				CopySource:      input.CopySource,
+				CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+				UploadId:        uploadID,
+				PartNumber:      aws.Int64(partNumber),
+				RequestPayer:    input.RequestPayer,
+
+				SSECustomerAlgorithm:           input.SSECustomerAlgorithm,
+				SSECustomerKey:                 input.SSECustomerKey,
+				CopySourceSSECustomerAlgorithm: input.CopySourceSSECustomerAlgorithm,
+				CopySourceSSECustomerKey:       input.CopySourceSSECustomerKey,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("copy part %d: %w", partNumber, err)
+				}
+				return
+			}
+			parts = append(parts, &s3.CompletedPart{
+				ETag:       output.CopyPartResult.ETag,
+				PartNumber: aws.Int64(partNumber),
+			})
+		}(partNumber, start, end)
+	}
+
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		// don't leave orphaned parts behind.
+		_, _ = s.api.AbortMultipartUploadWithContext(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:       input.Bucket,
+			Key:          input.Key,
+			UploadId:     uploadID,
+			RequestPayer: input.RequestPayer,
+		})
+		return firstErr
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return *parts[i].PartNumber < *parts[j].PartNumber
+	})
+
+	_, err = s.api.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          input.Bucket,
+		Key:             input.Key,
+		UploadId:        uploadID,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		RequestPayer:    input.RequestPayer,
+	})
+	return err
+}
+
+// setSourceMetadata sets the headers and the user-defined metadata of the
+// source object to the copy input.
+func setSourceMetadata(input *s3.CopyObjectInput, head *s3.HeadObjectOutput) {
+	input.Metadata = head.Metadata
+	input.ContentType = head.ContentType
+	input.CacheControl = head.CacheControl
+	input.ContentDisposition = head.ContentDisposition
+	input.ContentEncoding = head.ContentEncoding
+	input.ContentLanguage = head.ContentLanguage
+	input.WebsiteRedirectLocation = head.WebsiteRedirectLocation
+
+	if expires := aws.StringValue(head.Expires); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			input.Expires = aws.Time(t)
+		}
+	}
+}
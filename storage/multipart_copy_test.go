@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3CopyLargeObject(t *testing.T) {
+	from, err := url.New("s3://bucket/source")
+	assert.NilError(t, err)
+	to, err := url.New("s3://bucket/destination")
+	assert.NilError(t, err)
+
+	const size = 2*multipartCopyPartSize + 100
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.UnmarshalError.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	var (
+		mu             sync.Mutex
+		ranges         []string
+		completedParts []*s3.CompletedPart
+		created        *s3.CreateMultipartUploadInput
+	)
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		// responses of the copy operations are checked for errors even if
+		// the status code is 200.
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("<Result/>")),
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch input := r.Params.(type) {
+		case *s3.CopyObjectInput:
+			r.Error = awserr.New(
+				"InvalidRequest",
+				"The specified copy source is larger than the maximum allowable size for a copy source: 5368709120",
+				nil,
+			)
+		case *s3.CreateMultipartUploadInput:
+			created = input
+		case *s3.UploadPartCopyInput:
+			assert.Equal(t, aws.StringValue(input.UploadId), "upload-id")
+			assert.Equal(t, aws.StringValue(input.CopySource), "bucket/source")
+			ranges = append(ranges, aws.StringValue(input.CopySourceRange))
+		case *s3.CompleteMultipartUploadInput:
+			completedParts = input.MultipartUpload.Parts
+		}
+	})
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		switch output := r.Data.(type) {
+		case *s3.HeadObjectOutput:
+			output.ContentLength = aws.Int64(size)
+			output.ContentType = aws.String("video/mp4")
+			output.Metadata = map[string]*string{"Owner": aws.String("s5cmd")}
+		case *s3.CreateMultipartUploadOutput:
+			output.UploadId = aws.String("upload-id")
+		case *s3.UploadPartCopyOutput:
+			partNumber := aws.Int64Value(r.Params.(*s3.UploadPartCopyInput).PartNumber)
+			output.CopyPartResult = &s3.CopyPartResult{ETag: aws.String(strings.Repeat("e", int(partNumber)))}
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.Copy(context.Background(), from, to, NewMetadata().SetStorageClass("STANDARD_IA"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, aws.StringValue(created.ContentType), "video/mp4")
+	assert.Equal(t, aws.StringValue(created.StorageClass), "STANDARD_IA")
+	assert.DeepEqual(t, created.Metadata, map[string]*string{"Owner": aws.String("s5cmd")})
+
+	assert.Equal(t, len(ranges), 3)
+	for _, r := range []string{
+		"bytes=0-536870911",
+		"bytes=536870912-1073741823",
+		"bytes=1073741824-1073741923",
+	} {
+		assert.Assert(t, strings.Contains(strings.Join(ranges, ","), r), "missing range %v", r)
+	}
+
+	assert.Equal(t, len(completedParts), 3)
+	for i, part := range completedParts {
+		assert.Equal(t, aws.Int64Value(part.PartNumber), int64(i+1))
+		assert.Equal(t, aws.StringValue(part.ETag), strings.Repeat("e", i+1))
+	}
+}
+
+func TestCopyPartSize(t *testing.T) {
+	assert.Equal(t, copyPartSize(1), int64(multipartCopyPartSize))
+	assert.Equal(t, copyPartSize(10*1024*1024*1024), int64(multipartCopyPartSize))
+
+	// 5 TB objects don't fit into 10000 parts of 512 MB.
+	const size = 5 * 1024 * 1024 * 1024 * 1024
+	partSize := copyPartSize(size)
+	assert.Assert(t, partSize > multipartCopyPartSize)
+	assert.Assert(t, (size+partSize-1)/partSize <= maxMultipartParts)
+}
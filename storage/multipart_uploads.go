@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+// MultipartUpload is a multipart upload which is neither completed nor
+// aborted yet. The parts of such uploads are stored and charged until the
+// upload is aborted.
+type MultipartUpload struct {
+	URL          *url.URL     `json:"key,omitempty"`
+	UploadID     string       `json:"upload_id,omitempty"`
+	Initiated    *time.Time   `json:"initiated,omitempty"`
+	StorageClass StorageClass `json:"storage_class,omitempty"`
+	Err          error        `json:"error,omitempty"`
+}
+
+// String returns the string representation of MultipartUpload.
+func (m *MultipartUpload) String() string {
+	return m.URL.String()
+}
+
+// JSON returns the JSON representation of MultipartUpload.
+func (m *MultipartUpload) JSON() string {
+	return strutil.JSON(m)
+}
+
+// ListMultipartUploads lists the multipart uploads in progress of the
+// objects with the prefix of the given URL, or of the objects matching it if
+// it has wildcards. The errors are sent to the channel.
+func (s *S3) ListMultipartUploads(ctx context.Context, u *url.URL) <-chan *MultipartUpload {
+	ch := make(chan *MultipartUpload)
+
+	go func() {
+		defer close(ch)
+
+		for _, prefix := range s.listPrefixes(u) {
+			input := &s3.ListMultipartUploadsInput{
+				Bucket: aws.String(u.Bucket),
+				Prefix: aws.String(prefix),
+			}
+
+			err := s.api.ListMultipartUploadsPagesWithContext(ctx, input, func(page *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+				for _, upload := range page.Uploads {
+					path := s.objectPath(aws.StringValue(upload.Key))
+					if u.IsWildcard() && !u.Match(path) {
+						continue
+					}
+					if !u.IsWildcard() && !strings.HasPrefix(path, u.Path) {
+						continue
+					}
+
+					uploadURL := u.Clone()
+					uploadURL.Path = path
+					ch <- &MultipartUpload{
+						URL:          uploadURL,
+						UploadID:     aws.StringValue(upload.UploadId),
+						Initiated:    upload.Initiated,
+						StorageClass: StorageClass(aws.StringValue(upload.StorageClass)),
+					}
+				}
+				return !lastPage
+			})
+			if err != nil {
+				ch <- &MultipartUpload{Err: err}
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// AbortMultipartUpload aborts the given multipart upload, and deletes its
+// parts.
+func (s *S3) AbortMultipartUpload(ctx context.Context, upload *MultipartUpload) error {
+	if s.dryRun {
+		simulation.add("AbortMultipartUpload", 1, 0)
+		return nil
+	}
+
+	_, err := s.api.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:       aws.String(upload.URL.Bucket),
+		Key:          aws.String(s.objectKey(upload.URL.Path)),
+		UploadId:     aws.String(upload.UploadID),
+		RequestPayer: s.RequestPayer(),
+	})
+	return err
+}
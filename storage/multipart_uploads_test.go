@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3ListMultipartUploads(t *testing.T) {
+	initiated := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		name           string
+		src            string
+		keyShardLength int
+		expected       []string
+	}{
+		{
+			name:     "bucket",
+			src:      "s3://bucket",
+			expected: []string{"s3://bucket/a/file.txt", "s3://bucket/a/file.tar", "s3://bucket/b/file.tar"},
+		},
+		{
+			name:     "prefix",
+			src:      "s3://bucket/a/",
+			expected: []string{"s3://bucket/a/file.txt", "s3://bucket/a/file.tar"},
+		},
+		{
+			name:     "wildcard",
+			src:      "s3://bucket/a/*.tar",
+			expected: []string{"s3://bucket/a/file.tar"},
+		},
+		{
+			name:           "sharded prefix",
+			src:            "s3://bucket/a/",
+			keyShardLength: 1,
+			// the uploads are listed in the order of their shards.
+			expected: []string{"s3://bucket/a/file.tar", "s3://bucket/a/file.txt"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New(tc.src)
+			assert.NilError(t, err)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				prefix := aws.StringValue(r.Params.(*s3.ListMultipartUploadsInput).Prefix)
+
+				output := r.Data.(*s3.ListMultipartUploadsOutput)
+				for _, key := range []string{"a/file.txt", "a/file.tar", "b/file.tar"} {
+					key = shardKey(key, tc.keyShardLength)
+					if len(key) < len(prefix) || key[:len(prefix)] != prefix {
+						continue
+					}
+					output.Uploads = append(output.Uploads, &s3.MultipartUpload{
+						Key:       aws.String(key),
+						UploadId:  aws.String("id-" + key),
+						Initiated: aws.Time(initiated),
+					})
+				}
+			})
+
+			mockS3 := &S3{api: mockApi, keyShardLength: tc.keyShardLength}
+
+			var got []string
+			for upload := range mockS3.ListMultipartUploads(context.Background(), u) {
+				assert.NilError(t, upload.Err)
+				assert.Equal(t, upload.UploadID, "id-"+shardKey(upload.URL.Path, tc.keyShardLength))
+				assert.Equal(t, *upload.Initiated, initiated)
+				got = append(got, upload.URL.String())
+			}
+			assert.DeepEqual(t, got, tc.expected)
+		})
+	}
+}
+
+func TestS3AbortMultipartUpload(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var input *s3.AbortMultipartUploadInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input = r.Params.(*s3.AbortMultipartUploadInput)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.AbortMultipartUpload(context.Background(), &MultipartUpload{URL: u, UploadID: "upload-id"})
+	assert.NilError(t, err)
+	assert.Equal(t, aws.StringValue(input.Bucket), "bucket")
+	assert.Equal(t, aws.StringValue(input.Key), "key")
+	assert.Equal(t, aws.StringValue(input.UploadId), "upload-id")
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// SetHeaders replaces the given headers of the object by copying it onto
+// itself. The other headers, the user-defined metadata, the tags, the storage
+// class and the SSE-KMS encryption of the object are kept. ACLs are not kept
+// by S3 on copies, objects get the default ACL of the bucket. Objects larger
+// than the CopyObject size limit are copied with multipart copy.
+func (s *S3) SetHeaders(ctx context.Context, u *url.URL, metadata Metadata) error {
+	if s.dryRun {
+		simulation.add("HeadObject", 1, 0)
+		simulation.add("CopyObject", 1, 0)
+		return nil
+	}
+
+	head, err := s.api.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(u.Bucket),
+		Key:          aws.String(s.objectKey(u.Path)),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	})
+	if err != nil {
+		return err
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(u.Bucket),
+		Key:               aws.String(s.objectKey(u.Path)),
+		CopySource:        aws.String(s.copySource(u)),
+		MetadataDirective: aws.String(string(MetadataDirectiveReplace)),
+		StorageClass:      head.StorageClass,
+		RequestPayer:      s.RequestPayer(),
+
+		SSECustomerAlgorithm:           s.sseCustomerAlgorithm(),
+		SSECustomerKey:                 s.sseCustomerKey(),
+		CopySourceSSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		CopySourceSSECustomerKey:       s.sseCustomerKey(),
+	}
+	setSourceMetadata(input, head)
+
+	if aws.StringValue(head.ServerSideEncryption) == s3.ServerSideEncryptionAwsKms {
+		input.ServerSideEncryption = head.ServerSideEncryption
+		input.SSEKMSKeyId = head.SSEKMSKeyId
+		input.BucketKeyEnabled = head.BucketKeyEnabled
+	}
+
+	if contentType := metadata.ContentType(); contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if cacheControl := metadata.CacheControl(); cacheControl != "" {
+		input.CacheControl = aws.String(cacheControl)
+	}
+	if contentDisposition := metadata.ContentDisposition(); contentDisposition != "" {
+		input.ContentDisposition = aws.String(contentDisposition)
+	}
+	if contentLanguage := metadata.ContentLanguage(); contentLanguage != "" {
+		input.ContentLanguage = aws.String(contentLanguage)
+	}
+	if expires := metadata.Expires(); expires != "" {
+		t, err := time.Parse(time.RFC3339, expires)
+		if err != nil {
+			return err
+		}
+		input.Expires = aws.Time(t)
+	}
+
+	if aws.Int64Value(head.ContentLength) <= maxCopyObjectSize {
+		_, err = s.api.CopyObjectWithContext(ctx, input)
+		return err
+	}
+
+	// multipart copies don't carry the tags of the object.
+	tags, err := s.Tags(ctx, u)
+	if err != nil {
+		return err
+	}
+	if len(tags) > 0 {
+		input.Tagging = aws.String(EncodeTags(tags))
+	}
+	return s.multipartCopy(ctx, u, u, input)
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3SetHeaders(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var copyInput *s3.CopyObjectInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		switch input := r.Params.(type) {
+		case *s3.HeadObjectInput:
+			output := r.Data.(*s3.HeadObjectOutput)
+			output.ContentType = aws.String("text/html")
+			output.CacheControl = aws.String("max-age=60")
+			output.Expires = aws.String(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+			output.Metadata = map[string]*string{"Owner": aws.String("s5cmd")}
+			output.StorageClass = aws.String(s3.StorageClassStandardIa)
+			output.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+			output.SSEKMSKeyId = aws.String("key-id")
+		case *s3.CopyObjectInput:
+			copyInput = input
+			r.HTTPResponse = &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("<CopyObjectResult></CopyObjectResult>")),
+			}
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.SetHeaders(context.Background(), u, NewMetadata().SetCacheControl("no-cache"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, aws.StringValue(copyInput.CopySource), "bucket/key")
+	assert.Equal(t, aws.StringValue(copyInput.MetadataDirective), "REPLACE")
+
+	// the given header is replaced, the others are kept.
+	assert.Equal(t, aws.StringValue(copyInput.CacheControl), "no-cache")
+	assert.Equal(t, aws.StringValue(copyInput.ContentType), "text/html")
+	assert.Equal(t, aws.TimeValue(copyInput.Expires), time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.DeepEqual(t, copyInput.Metadata, map[string]*string{"Owner": aws.String("s5cmd")})
+
+	assert.Equal(t, aws.StringValue(copyInput.StorageClass), s3.StorageClassStandardIa)
+	assert.Equal(t, aws.StringValue(copyInput.ServerSideEncryption), s3.ServerSideEncryptionAwsKms)
+	assert.Equal(t, aws.StringValue(copyInput.SSEKMSKeyId), "key-id")
+}
+
+func TestS3SetHeadersLargeObject(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.UnmarshalError.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	var (
+		mu        sync.Mutex
+		created   *s3.CreateMultipartUploadInput
+		partCount int
+		completed bool
+	)
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("<Result/>")),
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch input := r.Params.(type) {
+		case *s3.CopyObjectInput:
+			t.Errorf("unexpected CopyObject request of an object larger than 5GB")
+		case *s3.CreateMultipartUploadInput:
+			created = input
+		case *s3.UploadPartCopyInput:
+			assert.Equal(t, aws.StringValue(input.CopySource), "bucket/key")
+			partCount++
+		case *s3.CompleteMultipartUploadInput:
+			completed = true
+		}
+	})
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		switch output := r.Data.(type) {
+		case *s3.HeadObjectOutput:
+			output.ContentLength = aws.Int64(maxCopyObjectSize + 1)
+			output.ContentType = aws.String("video/mp4")
+			output.StorageClass = aws.String(s3.StorageClassStandardIa)
+		case *s3.GetObjectTaggingOutput:
+			output.TagSet = []*s3.Tag{{Key: aws.String("owner"), Value: aws.String("s5cmd")}}
+		case *s3.CreateMultipartUploadOutput:
+			output.UploadId = aws.String("upload-id")
+		case *s3.UploadPartCopyOutput:
+			output.CopyPartResult = &s3.CopyPartResult{ETag: aws.String("etag")}
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.SetHeaders(context.Background(), u, NewMetadata().SetCacheControl("no-cache"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, aws.StringValue(created.Key), "key")
+	assert.Equal(t, aws.StringValue(created.CacheControl), "no-cache")
+	assert.Equal(t, aws.StringValue(created.ContentType), "video/mp4")
+	assert.Equal(t, aws.StringValue(created.StorageClass), s3.StorageClassStandardIa)
+	assert.Equal(t, aws.StringValue(created.Tagging), "owner=s5cmd")
+	assert.Equal(t, partCount, 11)
+	assert.Assert(t, completed)
+}
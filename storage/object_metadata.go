@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// ObjectMetadata is the metadata of an object which is not returned by the
+// listings, fetched with a HEAD request.
+type ObjectMetadata struct {
+	ContentType        string `json:"content_type,omitempty"`
+	ContentEncoding    string `json:"content_encoding,omitempty"`
+	ContentDisposition string `json:"content_disposition,omitempty"`
+	ContentLanguage    string `json:"content_language,omitempty"`
+	CacheControl       string `json:"cache_control,omitempty"`
+
+	// UserMetadata is the user-defined metadata of the object, with the keys
+	// in lowercase without the x-amz-meta- prefix.
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+
+	// ServerSideEncryption is the server side encryption of the object, e.g.
+	// AES256 or aws:kms. It is empty for the objects encrypted with customer
+	// provided keys.
+	ServerSideEncryption string `json:"sse,omitempty"`
+	SSEKMSKeyID          string `json:"sse_kms_key_id,omitempty"`
+	BucketKeyEnabled     bool   `json:"bucket_key_enabled,omitempty"`
+	SSECustomerAlgorithm string `json:"sse_customer_algorithm,omitempty"`
+}
+
+// ObjectMetadata fetches the metadata of the given object, or of the given
+// version of it.
+func (s *S3) ObjectMetadata(ctx context.Context, u *url.URL) (*ObjectMetadata, error) {
+	output, err := s.headObject(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return newObjectMetadata(output), nil
+}
+
+// Head fetches the object with its storage class, restore status and
+// metadata, or the given version of it.
+func (s *S3) Head(ctx context.Context, u *url.URL) (*Object, error) {
+	output, err := s.headObject(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// the storage class is not returned for the objects of the standard
+	// storage class.
+	storageClass := StorageClass(aws.StringValue(output.StorageClass))
+	if storageClass == "" {
+		storageClass = StorageClass(s3.StorageClassStandard)
+	}
+
+	mod := aws.TimeValue(output.LastModified)
+	return &Object{
+		URL:          u,
+		Etag:         strings.Trim(aws.StringValue(output.ETag), `"`),
+		ModTime:      &mod,
+		Size:         aws.Int64Value(output.ContentLength),
+		StorageClass: storageClass,
+		VersionID:    aws.StringValue(output.VersionId),
+		Restore:      aws.StringValue(output.Restore),
+		Metadata:     newObjectMetadata(output),
+	}, nil
+}
+
+func (s *S3) headObject(ctx context.Context, u *url.URL) (*s3.HeadObjectOutput, error) {
+	input := &s3.HeadObjectInput{
+		Bucket:       aws.String(u.Bucket),
+		Key:          aws.String(s.objectKey(u.Path)),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	}
+	if u.VersionID != "" {
+		input.VersionId = aws.String(u.VersionID)
+	}
+
+	output, err := s.api.HeadObjectWithContext(ctx, input)
+	if err != nil {
+		if errHasCode(err, "NotFound") {
+			return nil, ErrGivenObjectNotFound
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+func newObjectMetadata(output *s3.HeadObjectOutput) *ObjectMetadata {
+	var userMetadata map[string]string
+	if len(output.Metadata) > 0 {
+		userMetadata = make(map[string]string, len(output.Metadata))
+		for key, value := range output.Metadata {
+			userMetadata[strings.ToLower(key)] = aws.StringValue(value)
+		}
+	}
+
+	return &ObjectMetadata{
+		ContentType:          aws.StringValue(output.ContentType),
+		ContentEncoding:      aws.StringValue(output.ContentEncoding),
+		ContentDisposition:   aws.StringValue(output.ContentDisposition),
+		ContentLanguage:      aws.StringValue(output.ContentLanguage),
+		CacheControl:         aws.StringValue(output.CacheControl),
+		UserMetadata:         userMetadata,
+		ServerSideEncryption: aws.StringValue(output.ServerSideEncryption),
+		SSEKMSKeyID:          aws.StringValue(output.SSEKMSKeyId),
+		BucketKeyEnabled:     aws.BoolValue(output.BucketKeyEnabled),
+		SSECustomerAlgorithm: aws.StringValue(output.SSECustomerAlgorithm),
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3ObjectMetadata(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+	u.VersionID = "version"
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var input *s3.HeadObjectInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input = r.Params.(*s3.HeadObjectInput)
+
+		output := r.Data.(*s3.HeadObjectOutput)
+		output.ContentType = aws.String("text/html")
+		output.CacheControl = aws.String("max-age=60")
+		output.Metadata = map[string]*string{"Owner": aws.String("s5cmd")}
+		output.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		output.SSEKMSKeyId = aws.String("key-id")
+		output.BucketKeyEnabled = aws.Bool(true)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	metadata, err := mockS3.ObjectMetadata(context.Background(), u)
+	assert.NilError(t, err)
+
+	assert.Equal(t, aws.StringValue(input.VersionId), "version")
+	assert.DeepEqual(t, metadata, &ObjectMetadata{
+		ContentType:          "text/html",
+		CacheControl:         "max-age=60",
+		UserMetadata:         map[string]string{"owner": "s5cmd"},
+		ServerSideEncryption: s3.ServerSideEncryptionAwsKms,
+		SSEKMSKeyID:          "key-id",
+		BucketKeyEnabled:     true,
+	})
+}
+
+func TestS3Head(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	storageClass := ""
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		output := r.Data.(*s3.HeadObjectOutput)
+		output.ContentLength = aws.Int64(7)
+		output.ETag = aws.String(`"etag"`)
+		output.ContentType = aws.String("text/plain")
+		if storageClass != "" {
+			output.StorageClass = aws.String(storageClass)
+			output.Restore = aws.String(`ongoing-request="true"`)
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	// the storage class is not returned for the standard storage class.
+	object, err := mockS3.Head(context.Background(), u)
+	assert.NilError(t, err)
+	assert.Equal(t, object.Size, int64(7))
+	assert.Equal(t, object.Etag, "etag")
+	assert.Equal(t, object.StorageClass, StorageClass(s3.StorageClassStandard))
+	assert.Equal(t, object.Metadata.ContentType, "text/plain")
+	assert.Equal(t, object.Restore, "")
+
+	storageClass = s3.StorageClassGlacier
+	object, err = mockS3.Head(context.Background(), u)
+	assert.NilError(t, err)
+	assert.Equal(t, object.StorageClass, StorageClass(s3.StorageClassGlacier))
+	assert.Equal(t, object.Restore, `ongoing-request="true"`)
+}
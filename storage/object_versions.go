@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// ListAtTime lists the versions of the objects matching the given URL which
+// were current at the given time. Objects which didn't exist or were deleted
+// at that time are not listed. A zero time lists the current versions. The
+// objects are sent after the whole listing is done, since the versions of a
+// key may span multiple pages.
+func (s *S3) ListAtTime(ctx context.Context, srcurl *url.URL, at time.Time) <-chan *Object {
+	return s.listWildcardBuckets(ctx, srcurl, func(client *S3, url *url.URL) <-chan *Object {
+		return client.listAtTime(ctx, url, at)
+	})
+}
+
+func (s *S3) listAtTime(ctx context.Context, url *url.URL, at time.Time) <-chan *Object {
+	// current is the newest version or delete marker of a key which is not
+	// newer than the given time.
+	type current struct {
+		object  *Object
+		modTime time.Time
+	}
+
+	objCh := make(chan *Object)
+
+	go func() {
+		defer close(objCh)
+
+		versions := map[string]*current{}
+
+		// consider records the version if it is the newest version of the
+		// key up to the given time. A nil object is a delete marker.
+		consider := func(key string, modTime time.Time, newObject func() *Object) {
+			if !at.IsZero() && modTime.After(at) {
+				return
+			}
+			if c, ok := versions[key]; ok && !modTime.After(c.modTime) {
+				return
+			}
+			if !url.IsWildcard() && key != url.Path {
+				return
+			}
+			if !url.Match(key) {
+				return
+			}
+			versions[key] = &current{object: newObject(), modTime: modTime}
+		}
+
+		var err error
+		for _, prefix := range s.listPrefixes(url) {
+			input := &s3.ListObjectVersionsInput{
+				Bucket: aws.String(url.Bucket),
+				Prefix: aws.String(prefix),
+			}
+
+			err = s.api.ListObjectVersionsPagesWithContext(ctx, input, func(p *s3.ListObjectVersionsOutput, lastPage bool) bool {
+				for _, marker := range p.DeleteMarkers {
+					key := s.objectPath(aws.StringValue(marker.Key))
+					consider(key, aws.TimeValue(marker.LastModified), func() *Object {
+						return nil
+					})
+				}
+
+				for _, version := range p.Versions {
+					key := s.objectPath(aws.StringValue(version.Key))
+					version := version
+					consider(key, aws.TimeValue(version.LastModified), func() *Object {
+						newurl := url.Clone()
+						newurl.Path = key
+						newurl.VersionID = aws.StringValue(version.VersionId)
+
+						mod := aws.TimeValue(version.LastModified).UTC()
+						return &Object{
+							URL:          newurl,
+							Etag:         strings.Trim(aws.StringValue(version.ETag), `"`),
+							ModTime:      &mod,
+							Size:         aws.Int64Value(version.Size),
+							StorageClass: StorageClass(aws.StringValue(version.StorageClass)),
+							VersionID:    newurl.VersionID,
+						}
+					})
+				}
+
+				return !lastPage
+			})
+			if err != nil {
+				break
+			}
+		}
+
+		if err != nil {
+			objCh <- &Object{Err: err}
+			return
+		}
+
+		keys := make([]string, 0, len(versions))
+		for key, c := range versions {
+			if c.object != nil {
+				keys = append(keys, key)
+			}
+		}
+
+		if len(keys) == 0 {
+			objCh <- &Object{Err: ErrNoObjectFound}
+			return
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			select {
+			case objCh <- versions[key].object:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return objCh
+}
+
+// ListVersions lists all of the versions and the delete markers of the
+// objects matching the given URL. The versions of a key are listed newest
+// first, and the latest one is marked with IsLatest.
+func (s *S3) ListVersions(ctx context.Context, srcurl *url.URL) <-chan *Object {
+	return s.listWildcardBuckets(ctx, srcurl, func(client *S3, url *url.URL) <-chan *Object {
+		return client.listVersions(ctx, url)
+	})
+}
+
+func (s *S3) listVersions(ctx context.Context, url *url.URL) <-chan *Object {
+	objCh := make(chan *Object)
+
+	go func() {
+		defer close(objCh)
+		objectFound := false
+
+		matches := func(key string) bool {
+			if !url.IsWildcard() && key != url.Path {
+				return false
+			}
+			return url.Match(key)
+		}
+
+		newObject := func(key, versionID string, modTime *time.Time, isLatest bool) *Object {
+			newurl := url.Clone()
+			newurl.Path = key
+			newurl.VersionID = versionID
+
+			mod := aws.TimeValue(modTime).UTC()
+			return &Object{
+				URL:       newurl,
+				ModTime:   &mod,
+				VersionID: versionID,
+				IsLatest:  isLatest,
+			}
+		}
+
+		var err error
+		for _, prefix := range s.listPrefixes(url) {
+			input := &s3.ListObjectVersionsInput{
+				Bucket: aws.String(url.Bucket),
+				Prefix: aws.String(prefix),
+			}
+
+			err = s.api.ListObjectVersionsPagesWithContext(ctx, input, func(p *s3.ListObjectVersionsOutput, lastPage bool) bool {
+				// the versions and the delete markers of a page are listed
+				// separately, they are merged in the order of the keys.
+				var objects []*Object
+				for _, marker := range p.DeleteMarkers {
+					key := s.objectPath(aws.StringValue(marker.Key))
+					if !matches(key) {
+						continue
+					}
+					object := newObject(key, aws.StringValue(marker.VersionId), marker.LastModified, aws.BoolValue(marker.IsLatest))
+					object.DeleteMarker = true
+					objects = append(objects, object)
+				}
+
+				for _, version := range p.Versions {
+					key := s.objectPath(aws.StringValue(version.Key))
+					if !matches(key) {
+						continue
+					}
+					object := newObject(key, aws.StringValue(version.VersionId), version.LastModified, aws.BoolValue(version.IsLatest))
+					object.Etag = strings.Trim(aws.StringValue(version.ETag), `"`)
+					object.Size = aws.Int64Value(version.Size)
+					object.StorageClass = StorageClass(aws.StringValue(version.StorageClass))
+					objects = append(objects, object)
+				}
+
+				sort.SliceStable(objects, func(i, j int) bool {
+					if objects[i].URL.Path != objects[j].URL.Path {
+						return objects[i].URL.Path < objects[j].URL.Path
+					}
+					if objects[i].IsLatest != objects[j].IsLatest {
+						return objects[i].IsLatest
+					}
+					return objects[i].ModTime.After(*objects[j].ModTime)
+				})
+
+				for _, object := range objects {
+					select {
+					case objCh <- object:
+					case <-ctx.Done():
+						return false
+					}
+					objectFound = true
+				}
+
+				return !lastPage
+			})
+			if err != nil {
+				break
+			}
+		}
+
+		if err != nil {
+			objCh <- &Object{Err: err}
+			return
+		}
+
+		if !objectFound {
+			objCh <- &Object{Err: ErrNoObjectFound}
+		}
+	}()
+
+	return objCh
+}
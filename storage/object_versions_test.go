@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/go-cmp/cmp"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3ListAtTime(t *testing.T) {
+	at := func(minute int) *time.Time {
+		return aws.Time(time.Date(2021, 1, 1, 0, minute, 0, 0, time.UTC))
+	}
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		r.Data = &s3.ListObjectVersionsOutput{
+			Versions: []*s3.ObjectVersion{
+				// overwritten after the given time.
+				{Key: aws.String("prefix/a.txt"), VersionId: aws.String("a2"), LastModified: at(30), Size: aws.Int64(2)},
+				{Key: aws.String("prefix/a.txt"), VersionId: aws.String("a1"), LastModified: at(5), Size: aws.Int64(1)},
+				// deleted before the given time.
+				{Key: aws.String("prefix/b.txt"), VersionId: aws.String("b1"), LastModified: at(1)},
+				// created after the given time.
+				{Key: aws.String("prefix/c.txt"), VersionId: aws.String("c1"), LastModified: at(20)},
+				// deleted after the given time.
+				{Key: aws.String("prefix/d.txt"), VersionId: aws.String("d1"), LastModified: at(2), ETag: aws.String(`"etag"`)},
+				{Key: aws.String("prefix/e.log"), VersionId: aws.String("e1"), LastModified: at(3)},
+			},
+			DeleteMarkers: []*s3.DeleteMarkerEntry{
+				{Key: aws.String("prefix/b.txt"), VersionId: aws.String("b2"), LastModified: at(4)},
+				{Key: aws.String("prefix/d.txt"), VersionId: aws.String("d2"), LastModified: at(15)},
+			},
+		}
+	})
+
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	u, err := url.New("s3://bucket/prefix/*.txt")
+	assert.NilError(t, err)
+
+	var got []*Object
+	for object := range mockS3.ListAtTime(context.Background(), u, *at(10)) {
+		assert.NilError(t, object.Err)
+		got = append(got, object)
+	}
+
+	assert.Equal(t, len(got), 2)
+
+	assert.Equal(t, got[0].URL.String(), "s3://bucket/prefix/a.txt")
+	assert.Equal(t, got[0].URL.Relative(), "a.txt")
+	assert.Equal(t, got[0].VersionID, "a1")
+	assert.Equal(t, got[0].Size, int64(1))
+
+	assert.Equal(t, got[1].URL.String(), "s3://bucket/prefix/d.txt")
+	assert.Equal(t, got[1].URL.Relative(), "d.txt")
+	assert.Equal(t, got[1].VersionID, "d1")
+	assert.Equal(t, got[1].Etag, "etag")
+
+	// the current versions are listed with a zero time.
+	got = nil
+	for object := range mockS3.ListAtTime(context.Background(), u, time.Time{}) {
+		assert.NilError(t, object.Err)
+		got = append(got, object)
+	}
+
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0].VersionID, "a2")
+	assert.Equal(t, got[1].VersionID, "c1")
+
+	// nothing matches before the objects are created.
+	for object := range mockS3.ListAtTime(context.Background(), u, *at(0)) {
+		assert.Equal(t, object.Err, ErrNoObjectFound)
+	}
+}
+
+func TestS3ListVersions(t *testing.T) {
+	at := func(minute int) *time.Time {
+		return aws.Time(time.Date(2021, 1, 1, 0, minute, 0, 0, time.UTC))
+	}
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		r.Data = &s3.ListObjectVersionsOutput{
+			Versions: []*s3.ObjectVersion{
+				{Key: aws.String("prefix/a.txt"), VersionId: aws.String("a2"), LastModified: at(30), Size: aws.Int64(2), IsLatest: aws.Bool(true)},
+				{Key: aws.String("prefix/a.txt"), VersionId: aws.String("a1"), LastModified: at(5), Size: aws.Int64(1)},
+				{Key: aws.String("prefix/b.txt"), VersionId: aws.String("b1"), LastModified: at(1), ETag: aws.String(`"etag"`)},
+				{Key: aws.String("prefix/c.log"), VersionId: aws.String("c1"), LastModified: at(3), IsLatest: aws.Bool(true)},
+			},
+			DeleteMarkers: []*s3.DeleteMarkerEntry{
+				{Key: aws.String("prefix/b.txt"), VersionId: aws.String("b2"), LastModified: at(4), IsLatest: aws.Bool(true)},
+			},
+		}
+	})
+
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	u, err := url.New("s3://bucket/prefix/*.txt")
+	assert.NilError(t, err)
+
+	type version struct {
+		key          string
+		versionID    string
+		isLatest     bool
+		deleteMarker bool
+	}
+
+	var got []version
+	for object := range mockS3.ListVersions(context.Background(), u) {
+		assert.NilError(t, object.Err)
+		got = append(got, version{
+			key:          object.URL.Relative(),
+			versionID:    object.VersionID,
+			isLatest:     object.IsLatest,
+			deleteMarker: object.DeleteMarker,
+		})
+	}
+
+	assert.DeepEqual(t, got, []version{
+		{key: "a.txt", versionID: "a2", isLatest: true},
+		{key: "a.txt", versionID: "a1"},
+		{key: "b.txt", versionID: "b2", isLatest: true, deleteMarker: true},
+		{key: "b.txt", versionID: "b1"},
+	}, cmp.AllowUnexported(version{}))
+}
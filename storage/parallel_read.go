@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// ReadParallel fetches the remote object with concurrent ranged GET requests
+// of partSize bytes and returns its contents in order as an io.ReadCloser.
+// At most concurrency parts are fetched ahead of the reader, which bounds
+// the memory in use. The parts after the first one are requested with the
+// ETag of the first one, so the read fails instead of mixing the parts of
+// different versions if the object is overwritten meanwhile.
+func (s *S3) ReadParallel(ctx context.Context, src *url.URL, concurrency int, partSize int64) (io.ReadCloser, error) {
+	if concurrency <= 1 || partSize <= 0 {
+		return s.Read(ctx, src)
+	}
+
+	// the size and the ETag of the object are taken from the response of
+	// the first part.
+	resp, err := s.getPart(ctx, src, "", 0, partSize)
+	// empty objects can't be read with a range.
+	if errHasCode(err, "InvalidRange") {
+		return s.Read(ctx, src)
+	}
+	if err != nil {
+		return nil, err
+	}
+	first, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := objectSizeOf(aws.StringValue(resp.ContentRange))
+	if err != nil {
+		return nil, err
+	}
+	etag := aws.StringValue(resp.ETag)
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &parallelReader{
+		ctx:     ctx,
+		cancel:  cancel,
+		parts:   make(chan chan readPart, concurrency-1),
+		current: bytes.NewReader(first),
+	}
+
+	go func() {
+		defer close(r.parts)
+
+		for offset := partSize; offset < size; offset += partSize {
+			length := partSize
+			if offset+length > size {
+				length = size - offset
+			}
+
+			partch := make(chan readPart, 1)
+			select {
+			case r.parts <- partch:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(offset, length int64) {
+				data, err := s.ReadPart(ctx, src, etag, offset, length)
+				partch <- readPart{data: data, err: err}
+			}(offset, length)
+		}
+	}()
+
+	return r, nil
+}
+
+// getPart requests length bytes of the object starting from the given
+// offset. The request is conditional on the ETag if it is given.
+func (s *S3) getPart(ctx context.Context, src *url.URL, etag string, offset, length int64) (*s3.GetObjectOutput, error) {
+	input := &s3.GetObjectInput{
+		Bucket:       aws.String(src.Bucket),
+		Key:          aws.String(s.objectKey(src.Path)),
+		Range:        aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	}
+	if etag != "" {
+		input.IfMatch = aws.String(etag)
+	}
+	return s.api.GetObjectWithContext(ctx, input, s.recoverStreamOption())
+}
+
+// ReadPart fetches length bytes of the object starting from the given offset.
+// If the ETag is given, the read fails if the object is overwritten and its
+// ETag doesn't match anymore.
+func (s *S3) ReadPart(ctx context.Context, src *url.URL, etag string, offset, length int64) ([]byte, error) {
+	resp, err := s.getPart(ctx, src, etag, offset, length)
+	if err != nil {
+		if errHasCode(err, "PreconditionFailed") {
+			return nil, fmt.Errorf("object %v is changed while being read", src)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// objectSizeOf returns the size of the object from the Content-Range header
+// of a ranged GET response, e.g. "bytes 0-1023/4096".
+func objectSizeOf(contentRange string) (int64, error) {
+	i := strings.LastIndex(contentRange, "/")
+	if i == -1 {
+		return 0, fmt.Errorf("invalid content range %q", contentRange)
+	}
+
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid content range %q", contentRange)
+	}
+	return size, nil
+}
+
+// readPart is a fetched part of an object.
+type readPart struct {
+	data []byte
+	err  error
+}
+
+// parallelReader returns the parts fetched by ReadParallel in order.
+type parallelReader struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// parts receives the results of the parts in order, each of which is
+	// sent once the part is fetched.
+	parts chan chan readPart
+
+	current *bytes.Reader
+	err     error
+}
+
+func (r *parallelReader) Read(p []byte) (int, error) {
+	for {
+		if r.current != nil && r.current.Len() > 0 {
+			return r.current.Read(p)
+		}
+
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		partch, ok := <-r.parts
+		if !ok {
+			// the parts are not sent after the context is canceled.
+			r.err = r.ctx.Err()
+			if r.err == nil {
+				r.err = io.EOF
+			}
+			continue
+		}
+
+		part := <-partch
+		if part.err != nil {
+			r.err = part.err
+			r.cancel()
+			continue
+		}
+		r.current = bytes.NewReader(part.data)
+	}
+}
+
+// Close stops fetching the remaining parts.
+func (r *parallelReader) Close() error {
+	r.cancel()
+	return nil
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3ReadParallel(t *testing.T) {
+	newMockS3 := func(content, failedRange string) (*S3, *[]string) {
+		mockApi := s3.New(unit.Session)
+		mockApi.Handlers.Send.Clear()
+		mockApi.Handlers.Unmarshal.Clear()
+		mockApi.Handlers.UnmarshalMeta.Clear()
+		mockApi.Handlers.ValidateResponse.Clear()
+
+		var (
+			mu     sync.Mutex
+			ranges []string
+		)
+		mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+			input := r.Params.(*s3.GetObjectInput)
+
+			mu.Lock()
+			ranges = append(ranges, aws.StringValue(input.Range))
+			mu.Unlock()
+
+			// the first part is requested without an ETag.
+			if input.Range != nil && !strings.HasPrefix(*input.Range, "bytes=0-") {
+				assert.Equal(t, aws.StringValue(input.IfMatch), `"etag"`)
+			}
+			switch {
+			case content == "" && input.Range != nil:
+				r.Error = awserr.New("InvalidRange", "The requested range is not satisfiable", nil)
+			case failedRange != "" && aws.StringValue(input.Range) == failedRange:
+				r.Error = awserr.New("PreconditionFailed", "At least one of the pre-conditions you specified did not hold", nil)
+			}
+		})
+		mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+			output := r.Data.(*s3.GetObjectOutput)
+			if content == "" {
+				output.Body = ioutil.NopCloser(strings.NewReader(""))
+				return
+			}
+
+			var start, end int
+			fmt.Sscanf(aws.StringValue(r.Params.(*s3.GetObjectInput).Range), "bytes=%d-%d", &start, &end)
+			if end >= len(content) {
+				end = len(content) - 1
+			}
+
+			output.Body = ioutil.NopCloser(strings.NewReader(content[start : end+1]))
+			output.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+			output.ETag = aws.String(`"etag"`)
+		})
+
+		return &S3{api: mockApi}, &ranges
+	}
+
+	u, err := url.New("s3://bucket/object")
+	assert.NilError(t, err)
+
+	const content = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	t.Run("parts are read in order", func(t *testing.T) {
+		mockS3, ranges := newMockS3(content, "")
+
+		rc, err := mockS3.ReadParallel(context.Background(), u, 3, 8)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		got, err := ioutil.ReadAll(rc)
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), content)
+		assert.Equal(t, len(*ranges), 5)
+	})
+
+	t.Run("single part", func(t *testing.T) {
+		mockS3, ranges := newMockS3(content, "")
+
+		rc, err := mockS3.ReadParallel(context.Background(), u, 3, 64)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		got, err := ioutil.ReadAll(rc)
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), content)
+		assert.DeepEqual(t, *ranges, []string{"bytes=0-63"})
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		mockS3, ranges := newMockS3("", "")
+
+		rc, err := mockS3.ReadParallel(context.Background(), u, 3, 8)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		got, err := ioutil.ReadAll(rc)
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), "")
+		// the object is read without a range after the ranged request fails.
+		assert.DeepEqual(t, *ranges, []string{"bytes=0-7", ""})
+	})
+
+	t.Run("changed object", func(t *testing.T) {
+		mockS3, _ := newMockS3(content, "bytes=16-23")
+
+		rc, err := mockS3.ReadParallel(context.Background(), u, 3, 8)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		got, err := ioutil.ReadAll(rc)
+		assert.Error(t, err, "object s3://bucket/object is changed while being read")
+		assert.Equal(t, string(got), content[:16])
+	})
+}
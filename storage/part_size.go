@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage/url"
+	"github.com/peak/s5cmd/strutil"
+)
+
+// minDowngradedPartSize is the floor which the part sizes of the failing
+// transfers are halved down to. It is the minimum part size of S3.
+const minDowngradedPartSize = 5 * 1024 * 1024
+
+// partSizeDowngrades is the number of times the part sizes of the transfers
+// are halved.
+var partSizeDowngrades int64
+
+// PartSizeDowngrades returns the number of times the part sizes of the
+// transfers are halved after server or connection errors.
+func PartSizeDowngrades() int64 {
+	return atomic.LoadInt64(&partSizeDowngrades)
+}
+
+// downgradePartSize returns the half of the given part size if the transfer
+// failed with a server or connection error, which may be caused by a lossy
+// link dropping large parts. It reports false if the error is not such an
+// error or if the half is below the floor.
+func downgradePartSize(partSize int64, err error) (int64, bool) {
+	// failed multipart uploads wrap the errors of their parts.
+	var uploadErr s3manager.MultiUploadFailure
+	if errors.As(err, &uploadErr) && uploadErr.OrigErr() != nil {
+		err = uploadErr.OrigErr()
+	}
+
+	if !isFailoverError(err) {
+		return 0, false
+	}
+
+	half := partSize / 2
+	if half < minDowngradedPartSize {
+		return 0, false
+	}
+	return half, true
+}
+
+// warnPartSizeDowngrade records that the part size of the transfer of the
+// given object is halved after the given error.
+func warnPartSizeDowngrade(op string, u *url.URL, partSize int64, err error) {
+	atomic.AddInt64(&partSizeDowngrades, 1)
+
+	msg := log.WarningMessage{
+		Warning: fmt.Sprintf(
+			"retrying %v of %v with part size %v after error: %v",
+			op, u, strutil.HumanizeBytes(partSize), err,
+		),
+	}
+	log.Warning(msg)
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestDowngradePartSize(t *testing.T) {
+	const mb = 1024 * 1024
+
+	serverErr := awserr.NewRequestFailure(awserr.New("InternalError", "internal error", nil), 500, "")
+	clientErr := awserr.NewRequestFailure(awserr.New("AccessDenied", "access denied", nil), 403, "")
+
+	testcases := []struct {
+		name     string
+		partSize int64
+		err      error
+		expected int64
+		ok       bool
+	}{
+		{name: "server error", partSize: 50 * mb, err: serverErr, expected: 25 * mb, ok: true},
+		{name: "connection error", partSize: 16 * mb, err: awserr.New(request.ErrCodeRequestError, "send request failed", nil), expected: 8 * mb, ok: true},
+		{name: "down to the floor", partSize: 10 * mb, err: serverErr, expected: 5 * mb, ok: true},
+		{name: "below the floor", partSize: 8 * mb, err: serverErr},
+		{name: "client error", partSize: 50 * mb, err: clientErr},
+		{name: "no error", partSize: 50 * mb},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			partSize, ok := downgradePartSize(tc.partSize, tc.err)
+			assert.Equal(t, ok, tc.ok)
+			assert.Equal(t, partSize, tc.expected)
+		})
+	}
+}
+
+func TestS3PutDowngradesPartSize(t *testing.T) {
+	log.Init("error", false)
+
+	const (
+		mb       = 1024 * 1024
+		size     = 12 * mb
+		partSize = 10 * mb
+	)
+
+	var (
+		mu        sync.Mutex
+		partSizes []int64
+	)
+
+	mockApi := s3.New(unit.Session, aws.NewConfig().WithMaxRetries(0))
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("<CompleteMultipartUploadResult/>")),
+		}
+
+		input, ok := r.Params.(*s3.UploadPartInput)
+		if !ok {
+			return
+		}
+		n, _ := input.Body.Seek(0, io.SeekEnd)
+
+		mu.Lock()
+		partSizes = append(partSizes, n)
+		mu.Unlock()
+
+		// the link drops the parts larger than 6 MiB.
+		if n > 6*mb {
+			r.HTTPResponse.StatusCode = http.StatusInternalServerError
+			r.Error = awserr.NewRequestFailure(awserr.New("InternalError", "internal error", nil), 500, "")
+		}
+	})
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		switch out := r.Data.(type) {
+		case *s3.CreateMultipartUploadOutput:
+			out.UploadId = aws.String("upload-1")
+		case *s3.UploadPartOutput:
+			number := r.Params.(*s3.UploadPartInput).PartNumber
+			out.ETag = aws.String(fmt.Sprintf(`"etag-%d"`, *number))
+		}
+	})
+
+	mockS3 := &S3{api: mockApi, uploader: s3manager.NewUploaderWithClient(mockApi)}
+
+	dst, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	before := PartSizeDowngrades()
+
+	reader := bytes.NewReader(make([]byte, size))
+	err = mockS3.Put(context.Background(), reader, dst, NewMetadata(), 1, partSize)
+	assert.NilError(t, err)
+
+	assert.Equal(t, PartSizeDowngrades()-before, int64(1))
+
+	// the last attempt uploads the object in 5 MiB parts.
+	mu.Lock()
+	defer mu.Unlock()
+	last := partSizes[len(partSizes)-3:]
+	assert.DeepEqual(t, last, []int64{5 * mb, 5 * mb, 2 * mb})
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Policy returns the policy document of the bucket with the given name, or
+// ErrNoBucketPolicy if the bucket has no policy.
+func (s *S3) Policy(ctx context.Context, name string) (string, error) {
+	output, err := s.api.GetBucketPolicyWithContext(ctx, &s3.GetBucketPolicyInput{
+		Bucket: aws.String(name),
+	})
+	if errHasCode(err, "NoSuchBucketPolicy") {
+		return "", ErrNoBucketPolicy
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.Policy), nil
+}
+
+// SetPolicy replaces the policy of the bucket with the given name with the
+// given policy document.
+func (s *S3) SetPolicy(ctx context.Context, name, policy string) error {
+	if s.dryRun {
+		simulation.add("PutBucketPolicy", 1, 0)
+		return nil
+	}
+
+	_, err := s.api.PutBucketPolicyWithContext(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(name),
+		Policy: aws.String(policy),
+	})
+	return err
+}
+
+// DeletePolicy removes the policy of the bucket with the given name.
+func (s *S3) DeletePolicy(ctx context.Context, name string) error {
+	if s.dryRun {
+		simulation.add("DeleteBucketPolicy", 1, 0)
+		return nil
+	}
+
+	_, err := s.api.DeleteBucketPolicyWithContext(ctx, &s3.DeleteBucketPolicyInput{
+		Bucket: aws.String(name),
+	})
+	return err
+}
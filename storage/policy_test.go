@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+)
+
+func TestS3Policy(t *testing.T) {
+	const policy = `{"Version":"2012-10-17","Statement":[]}`
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		output := r.Data.(*s3.GetBucketPolicyOutput)
+		output.Policy = aws.String(policy)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	got, err := mockS3.Policy(context.Background(), "bucket")
+	assert.NilError(t, err)
+	assert.Equal(t, got, policy)
+}
+
+func TestS3PolicyNotFound(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.Error = awserr.New("NoSuchBucketPolicy", "not found", nil)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	_, err := mockS3.Policy(context.Background(), "bucket")
+	assert.Equal(t, err, ErrNoBucketPolicy)
+}
+
+func TestS3SetPolicy(t *testing.T) {
+	const policy = `{"Version":"2012-10-17","Statement":[]}`
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var input *s3.PutBucketPolicyInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input = r.Params.(*s3.PutBucketPolicyInput)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err := mockS3.SetPolicy(context.Background(), "bucket", policy)
+	assert.NilError(t, err)
+	assert.Equal(t, aws.StringValue(input.Bucket), "bucket")
+	assert.Equal(t, aws.StringValue(input.Policy), policy)
+}
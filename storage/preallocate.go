@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// preallocatedFile is a file whose space is reserved for the whole object
+// before the parts are written to it. The space is allocated at once instead
+// of part by part, which avoids the fragmentation of the file by the parts
+// written concurrently, and a download fails at the start instead of halfway
+// through if there is not enough space on the disk.
+type preallocatedFile struct {
+	*os.File
+
+	// writer writes the parts to the file if it is set, e.g. with io_uring.
+	writer io.WriterAt
+
+	once sync.Once
+	err  error
+}
+
+// preallocate reserves the space of the object once its size is received
+// with the response of a ranged GET request. Errors are returned by the
+// writes, since the downloader would retry the request otherwise.
+func (f *preallocatedFile) preallocate(r *request.Request) {
+	if r.Error != nil || r.HTTPResponse == nil {
+		return
+	}
+
+	f.once.Do(func() {
+		size, err := objectSizeOf(r.HTTPResponse.Header.Get("Content-Range"))
+		if err != nil {
+			return
+		}
+		if err := preallocate(f.File, size); err != nil {
+			f.err = fmt.Errorf("unable to allocate %d bytes: %w", size, err)
+		}
+	})
+}
+
+func (f *preallocatedFile) WriteAt(p []byte, off int64) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if f.writer != nil {
+		return f.writer.WriteAt(p, off)
+	}
+	return f.File.WriteAt(p, off)
+}
+
+// preallocateOption returns the request option which reserves the space of
+// the object in the given file.
+func preallocateOption(f *preallocatedFile) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Send.PushBack(f.preallocate)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// preallocate allocates the space of the given size for the file with
+// fallocate. Filesystems which don't support it are not preallocated.
+func preallocate(file *os.File, size int64) error {
+	err := syscall.Fallocate(int(file.Fd()), 0, 0, size)
+	if errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOSYS) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package storage
+
+import "os"
+
+// preallocate is a no-op, files are only preallocated on Linux and Windows.
+func preallocate(_ *os.File, _ int64) error {
+	return nil
+}
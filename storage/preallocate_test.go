@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3GetPreallocatesFile(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		t.Skip("files are only preallocated on Linux and Windows")
+	}
+
+	const content = "0123456789"
+
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	dir := fs.NewDir(t, "preallocate")
+	defer dir.Remove()
+
+	file, err := os.Create(dir.Join("file"))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	// sizes of the file when each of the parts is requested.
+	var sizes []int64
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		st, err := file.Stat()
+		assert.NilError(t, err)
+		sizes = append(sizes, st.Size())
+
+		var start, end int
+		_, err = fmt.Sscanf(aws.StringValue(r.Params.(*s3.GetObjectInput).Range), "bytes=%d-%d", &start, &end)
+		assert.NilError(t, err)
+		if end >= len(content) {
+			end = len(content) - 1
+		}
+		contentRange := fmt.Sprintf("bytes %d-%d/%d", start, end, len(content))
+
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusPartialContent,
+			Header:     http.Header{"Content-Range": []string{contentRange}},
+			Body:       ioutil.NopCloser(strings.NewReader(content[start : end+1])),
+		}
+		output := r.Data.(*s3.GetObjectOutput)
+		output.Body = r.HTTPResponse.Body
+		output.ContentRange = aws.String(contentRange)
+		output.ContentLength = aws.Int64(int64(end - start + 1))
+	})
+
+	mockS3 := &S3{
+		downloader: s3manager.NewDownloaderWithClient(mockApi),
+	}
+
+	size, err := mockS3.Get(context.Background(), u, file, 1, 4)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+
+	// the file is allocated for the whole object after the first part.
+	assert.DeepEqual(t, []int64{0, 10, 10}, sizes)
+
+	got, err := ioutil.ReadFile(file.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, content, string(got))
+}
@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package storage
+
+import "os"
+
+// preallocate allocates the space of the given size for the file by setting
+// its end, which is what Truncate does with SetEndOfFile on Windows.
+func preallocate(file *os.File, size int64) error {
+	return file.Truncate(size)
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/log"
+)
+
+// preconnectHTTPClient returns a copy of the given HTTP client, or of the
+// default client if it is nil, which keeps at least n idle connections per
+// host. Otherwise the pre-established connections would be closed as soon
+// as they are returned to the pool.
+func preconnectHTTPClient(client *http.Client, n int) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	base, ok := client.Transport.(*http.Transport)
+	if client.Transport == nil {
+		base, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if !ok {
+		return client
+	}
+
+	transport := base.Clone()
+	if transport.MaxIdleConnsPerHost < n {
+		transport.MaxIdleConnsPerHost = n
+	}
+	if transport.MaxIdleConns != 0 && transport.MaxIdleConns < n {
+		transport.MaxIdleConns = n
+	}
+
+	newClient := *client
+	newClient.Transport = transport
+	return &newClient
+}
+
+// preconnect opens and handshakes n connections to the endpoint of the
+// bucket by sending concurrent HeadBucket requests, so that the transfers
+// don't wait for the connections to be established. The responses are
+// ignored, since the connections are kept even if the requests fail.
+func preconnect(ctx context.Context, sess *session.Session, bucket string, n int) {
+	if n <= 0 || bucket == "" {
+		return
+	}
+
+	client := s3.New(sess, aws.NewConfig().WithMaxRetries(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+				Bucket: aws.String(bucket),
+			})
+			if err != nil {
+				msg := log.DebugMessage{Err: fmt.Sprintf("preconnect to bucket %q: %v", bucket, err)}
+				log.Debug(msg)
+			}
+		}()
+	}
+	wg.Wait()
+}
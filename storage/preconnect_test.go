@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+)
+
+func TestPreconnectHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	client := preconnectHTTPClient(nil, 64)
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, 64, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Assert(t, transport != http.DefaultTransport)
+
+	client = preconnectHTTPClient(insecureHTTPClient, 8)
+	transport = client.Transport.(*http.Transport)
+	assert.Equal(t, 8, transport.MaxIdleConnsPerHost)
+	assert.Assert(t, transport.TLSClientConfig.InsecureSkipVerify)
+	assert.Equal(t, 0, insecureHTTPClient.Transport.(*http.Transport).MaxIdleConnsPerHost)
+}
+
+func TestSessionPreconnect(t *testing.T) {
+	log.Init("error", false)
+
+	const n = 4
+
+	var (
+		conns    int64
+		mu       sync.Mutex
+		inflight int
+		arrived  = make(chan struct{})
+	)
+
+	// hold the requests until all of them arrive, so that each of them uses
+	// a connection of its own.
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		wait := arrived
+		inflight++
+		if inflight == n {
+			inflight = 0
+			close(arrived)
+			arrived = make(chan struct{})
+		}
+		mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cache := &SessionCache{sessions: map[Options]*session.Session{}}
+
+	opts := Options{
+		Endpoint:      server.URL,
+		NoSignRequest: true,
+		Preconnect:    n,
+		bucket:        "bucket",
+	}
+	opts.SetRegion("us-east-1")
+
+	sess, err := cache.newSession(context.Background(), opts)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(n), atomic.LoadInt64(&conns))
+
+	// the transfers use the pre-established connections.
+	client := s3.New(sess)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String("bucket")})
+			assert.NilError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(n), atomic.LoadInt64(&conns))
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// MaxPresignExpiry is the longest duration a presigned URL can be valid for
+// with signature version 4.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// Presign returns a URL of the object signed with the credentials of the
+// client, which can be used to download the object with a GET request or to
+// upload it with a PUT request until it expires.
+func (s *S3) Presign(ctx context.Context, u *url.URL, method string, expire time.Duration) (string, error) {
+	var req *request.Request
+	switch method {
+	case http.MethodGet:
+		input := &s3.GetObjectInput{
+			Bucket:       aws.String(u.Bucket),
+			Key:          aws.String(s.objectKey(u.Path)),
+			RequestPayer: s.RequestPayer(),
+		}
+		if u.VersionID != "" {
+			input.VersionId = aws.String(u.VersionID)
+		}
+		req, _ = s.api.GetObjectRequest(input)
+	case http.MethodPut:
+		req, _ = s.api.PutObjectRequest(&s3.PutObjectInput{
+			Bucket:       aws.String(u.Bucket),
+			Key:          aws.String(s.objectKey(u.Path)),
+			RequestPayer: s.RequestPayer(),
+		})
+	default:
+		return "", fmt.Errorf("unsupported presign method %q", method)
+	}
+
+	req.SetContext(ctx)
+	return req.Presign(expire)
+}
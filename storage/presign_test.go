@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	neturl "net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3Presign(t *testing.T) {
+	u, err := url.New("s3://bucket/prefix/key")
+	assert.NilError(t, err)
+
+	mockS3 := &S3{api: s3.New(unit.Session)}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		presigned, err := mockS3.Presign(context.Background(), u, method, time.Hour)
+		assert.NilError(t, err)
+
+		parsed, err := neturl.Parse(presigned)
+		assert.NilError(t, err)
+		assert.Equal(t, parsed.Host, "bucket.s3.mock-region.amazonaws.com")
+		assert.Equal(t, parsed.Path, "/prefix/key")
+		assert.Equal(t, parsed.Query().Get("X-Amz-Expires"), "3600")
+		assert.Assert(t, parsed.Query().Get("X-Amz-Signature") != "")
+	}
+
+	_, err = mockS3.Presign(context.Background(), u, http.MethodDelete, time.Hour)
+	assert.ErrorContains(t, err, "unsupported presign method")
+}
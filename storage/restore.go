@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// RestoreStatus is the status of the restoration of an archived object.
+type RestoreStatus struct {
+	Ongoing bool       `json:"ongoing"`
+	Expiry  *time.Time `json:"expiry,omitempty"`
+}
+
+// ParseRestoreStatus parses the value of x-amz-restore header, e.g.
+// `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`.
+func ParseRestoreStatus(header string) (*RestoreStatus, error) {
+	var status RestoreStatus
+	for _, field := range strings.Split(header, `",`) {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid restore status %q", header)
+		}
+		value := strings.Trim(parts[1], `"`)
+
+		switch parts[0] {
+		case "ongoing-request":
+			status.Ongoing = value == "true"
+		case "expiry-date":
+			expiry, err := http.ParseTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid restore status %q: %v", header, err)
+			}
+			status.Expiry = &expiry
+		}
+	}
+	return &status, nil
+}
+
+// Restore requests a temporary copy of the archived object to be restored
+// for the given number of days with the given retrieval tier, e.g. Bulk. It
+// reports whether the object is restored already, in which case only the
+// expiry of the copy is updated.
+func (s *S3) Restore(ctx context.Context, u *url.URL, days int64, tier string) (bool, error) {
+	if s.dryRun {
+		simulation.add("RestoreObject", 1, 0)
+		return false, nil
+	}
+
+	input := &s3.RestoreObjectInput{
+		Bucket:       aws.String(u.Bucket),
+		Key:          aws.String(s.objectKey(u.Path)),
+		RequestPayer: s.RequestPayer(),
+		RestoreRequest: &s3.RestoreRequest{
+			Days: aws.Int64(days),
+			GlacierJobParameters: &s3.GlacierJobParameters{
+				Tier: aws.String(tier),
+			},
+		},
+	}
+	if u.VersionID != "" {
+		input.VersionId = aws.String(u.VersionID)
+	}
+
+	req, _ := s.api.RestoreObjectRequest(input)
+	req.SetContext(ctx)
+	if err := req.Send(); err != nil {
+		switch {
+		case errHasCode(err, "RestoreAlreadyInProgress"):
+			return false, ErrRestoreInProgress
+		case errHasCode(err, "InvalidObjectState"):
+			return false, ErrObjectNotArchived
+		case errHasCode(err, "NoSuchKey"):
+			return false, ErrGivenObjectNotFound
+		}
+		return false, err
+	}
+
+	// S3 responds with 202 Accepted for the new restorations, and 200 OK if
+	// the object is restored already.
+	return req.HTTPResponse.StatusCode == http.StatusOK, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestParseRestoreStatus(t *testing.T) {
+	expiry := time.Date(2012, 12, 21, 0, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		name     string
+		header   string
+		expected *RestoreStatus
+		wantErr  bool
+	}{
+		{
+			name:     "ongoing",
+			header:   `ongoing-request="true"`,
+			expected: &RestoreStatus{Ongoing: true},
+		},
+		{
+			name:     "completed",
+			header:   `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`,
+			expected: &RestoreStatus{Expiry: &expiry},
+		},
+		{
+			name:    "invalid expiry",
+			header:  `ongoing-request="false", expiry-date="tomorrow"`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid field",
+			header:  `ongoing-request`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := ParseRestoreStatus(tc.header)
+			if tc.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, status, tc.expected)
+		})
+	}
+}
+
+func TestS3Restore(t *testing.T) {
+	testcases := []struct {
+		name         string
+		statusCode   int
+		errCode      string
+		wantRestored bool
+		wantErr      error
+	}{
+		{
+			name:       "accepted",
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:         "restored already",
+			statusCode:   http.StatusOK,
+			wantRestored: true,
+		},
+		{
+			name:       "in progress",
+			statusCode: http.StatusConflict,
+			errCode:    "RestoreAlreadyInProgress",
+			wantErr:    ErrRestoreInProgress,
+		},
+		{
+			name:       "not archived",
+			statusCode: http.StatusForbidden,
+			errCode:    "InvalidObjectState",
+			wantErr:    ErrObjectNotArchived,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.UnmarshalError.Clear()
+			mockApi.Handlers.Send.Clear()
+
+			var input *s3.RestoreObjectInput
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				input = r.Params.(*s3.RestoreObjectInput)
+				r.HTTPResponse = &http.Response{
+					StatusCode: tc.statusCode,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+				if tc.errCode != "" {
+					r.Error = awserr.New(tc.errCode, "", nil)
+				}
+			})
+
+			mockS3 := &S3{api: mockApi}
+
+			restored, err := mockS3.Restore(context.Background(), u, 7, s3.TierBulk)
+			assert.Equal(t, err, tc.wantErr)
+			assert.Equal(t, restored, tc.wantRestored)
+
+			assert.Equal(t, aws.Int64Value(input.RestoreRequest.Days), int64(7))
+			assert.Equal(t, aws.StringValue(input.RestoreRequest.GlacierJobParameters.Tier), s3.TierBulk)
+		})
+	}
+}
@@ -0,0 +1,447 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// UploadJournal persists the state of multipart uploads on the local
+// filesystem, so that an interrupted upload can be resumed from the last
+// completed part on the next run.
+type UploadJournal struct {
+	dir string
+}
+
+// NewUploadJournal creates an UploadJournal which keeps its records in the
+// given directory. If dir is empty, a directory in the user cache directory
+// is used.
+func NewUploadJournal(dir string) (*UploadJournal, error) {
+	if dir == "" {
+		cachedir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(cachedir, "s5cmd", "uploads")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &UploadJournal{dir: dir}, nil
+}
+
+// uploadRecord is the journal entry of a single multipart upload.
+type uploadRecord struct {
+	Source   string                 `json:"source"`
+	Bucket   string                 `json:"bucket"`
+	Key      string                 `json:"key"`
+	UploadID string                 `json:"upload_id"`
+	Size     int64                  `json:"size"`
+	PartSize int64                  `json:"part_size"`
+	Parts    map[int64]uploadedPart `json:"parts"`
+}
+
+// uploadedPart holds the ETag returned by S3 and the MD5 checksum of a
+// completed part.
+type uploadedPart struct {
+	ETag     string `json:"etag"`
+	Checksum string `json:"checksum"`
+}
+
+// path returns the journal file path of the upload of src to dst.
+func (j *UploadJournal) path(src string, dst *url.URL) string {
+	sum := sha256.Sum256([]byte(src + "\x00" + dst.Absolute()))
+	return filepath.Join(j.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads the journal record of the upload of src to dst. It returns nil
+// if there is no record.
+func (j *UploadJournal) load(src string, dst *url.URL) *uploadRecord {
+	data, err := ioutil.ReadFile(j.path(src, dst))
+	if err != nil {
+		return nil
+	}
+
+	var record uploadRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil
+	}
+	if record.Parts == nil {
+		record.Parts = map[int64]uploadedPart{}
+	}
+	return &record
+}
+
+// save atomically writes the journal record of the upload of src to dst.
+func (j *UploadJournal) save(src string, dst *url.URL, record *uploadRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(j.dir, "journal")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), j.path(src, dst))
+}
+
+// remove deletes the journal record of the upload of src to dst.
+func (j *UploadJournal) remove(src string, dst *url.URL) {
+	os.Remove(j.path(src, dst))
+}
+
+// PutResumable is a multipart upload operation which records the state of the
+// upload to the given journal. If a previous upload of the same file to the
+// same destination was interrupted, the upload is resumed and only the parts
+// that are not completed, or whose content has changed since, are uploaded.
+// If the verifier is not nil, the file is verified before the upload is
+// completed, see SourceVerifier.
+func (s *S3) PutResumable(
+	ctx context.Context,
+	file *os.File,
+	to *url.URL,
+	metadata Metadata,
+	concurrency int,
+	partSize int64,
+	journal *UploadJournal,
+	verifier SourceVerifier,
+) error {
+	if s.dryRun {
+		return nil
+	}
+
+	st, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// there is nothing to resume for single part uploads. The checksums of
+	// the parts are not recorded in the journal, so the uploads with
+	// checksums are not resumed.
+	size := st.Size()
+	if size <= partSize || s.checksumAlgorithm != "" {
+		if verifier != nil {
+			return s.Put(ctx, struct {
+				io.ReadSeeker
+				SourceVerifier
+			}{file, verifier}, to, metadata, concurrency, partSize)
+		}
+		return s.Put(ctx, file, to, metadata, concurrency, partSize)
+	}
+
+	src, err := filepath.Abs(file.Name())
+	if err != nil {
+		return err
+	}
+
+	record := s.resumableRecord(ctx, journal.load(src, to), to, size, partSize)
+	if record == nil {
+		uploadID, err := s.createMultipartUpload(ctx, to, metadata)
+		if err != nil {
+			return err
+		}
+
+		record = &uploadRecord{
+			Source:   src,
+			Bucket:   to.Bucket,
+			Key:      to.Path,
+			UploadID: uploadID,
+			Size:     size,
+			PartSize: partSize,
+			Parts:    map[int64]uploadedPart{},
+		}
+		if err := journal.save(src, to, record); err != nil {
+			return err
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan bool, concurrency)
+		firstErr error
+	)
+
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	numParts := (size + partSize - 1) / partSize
+	for partNumber := int64(1); partNumber <= numParts; partNumber++ {
+		offset := (partNumber - 1) * partSize
+		length := partSize
+		if offset+length > size {
+			length = size - offset
+		}
+
+		mu.Lock()
+		part, completed := record.Parts[partNumber]
+		mu.Unlock()
+
+		if completed {
+			// the local file may have been modified since the part was
+			// uploaded. verify the checksum before skipping the part.
+			checksum, err := md5Checksum(io.NewSectionReader(file, offset, length))
+			if err != nil {
+				return err
+			}
+			if checksum == part.Checksum {
+				continue
+			}
+		}
+
+		wg.Add(1)
+		sem <- true
+		go func(partNumber, offset, length int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			part, err := s.uploadPart(ctx, file, to, record.UploadID, partNumber, offset, length)
+			if err != nil {
+				setErr(err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			record.Parts[partNumber] = part
+			if err := journal.save(src, to, record); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(partNumber, offset, length)
+
+		mu.Lock()
+		err := firstErr
+		mu.Unlock()
+		if err != nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	wg.Wait()
+
+	// keep the journal to resume the upload later on.
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// keep the journal, the parts whose content has changed are uploaded
+	// again when the upload is resumed.
+	if verifier != nil {
+		if err := verifier.VerifySource(); err != nil {
+			return err
+		}
+	}
+
+	completedParts := make([]*s3.CompletedPart, 0, len(record.Parts))
+	for partNumber, part := range record.Parts {
+		completedParts = append(completedParts, &s3.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int64(partNumber),
+		})
+	}
+	sort.Slice(completedParts, func(i, j int) bool {
+		return *completedParts[i].PartNumber < *completedParts[j].PartNumber
+	})
+
+	input := &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(to.Bucket),
+		Key:             aws.String(s.objectKey(to.Path)),
+		UploadId:        aws.String(record.UploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completedParts},
+		RequestPayer:    s.RequestPayer(),
+	}
+
+	ifNoneMatch := metadata.IfNoneMatch()
+	if ifNoneMatch == "" {
+		_, err = s.api.CompleteMultipartUploadWithContext(ctx, input)
+	} else {
+		_, err = s.api.CompleteMultipartUploadWithContext(ctx, input, ifNoneMatchRequestOption(ifNoneMatch))
+		if isConditionalWriteUnsupported(err) {
+			_, err = s.api.CompleteMultipartUploadWithContext(ctx, input)
+		}
+	}
+
+	// the upload can't be completed anymore, don't leave its parts behind.
+	if ifNoneMatch != "" && errHasCode(err, "PreconditionFailed") {
+		_, _ = s.api.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:       input.Bucket,
+			Key:          input.Key,
+			UploadId:     input.UploadId,
+			RequestPayer: s.RequestPayer(),
+		})
+		journal.remove(src, to)
+		return ErrObjectExists
+	}
+	if err != nil {
+		return err
+	}
+
+	journal.remove(src, to)
+	return nil
+}
+
+// resumableRecord validates the given journal record against the in-progress
+// multipart uploads on S3. It returns nil if the upload can not be resumed.
+// Parts that are not found on S3 are dropped from the record.
+func (s *S3) resumableRecord(
+	ctx context.Context,
+	record *uploadRecord,
+	to *url.URL,
+	size int64,
+	partSize int64,
+) *uploadRecord {
+	if record == nil {
+		return nil
+	}
+
+	// the file or the part size has changed, part boundaries don't match.
+	// abort the stale upload to not leave orphaned parts behind.
+	if record.Size != size || record.PartSize != partSize {
+		_, _ = s.api.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:       aws.String(record.Bucket),
+			Key:          aws.String(s.objectKey(record.Key)),
+			UploadId:     aws.String(record.UploadID),
+			RequestPayer: s.RequestPayer(),
+		})
+		return nil
+	}
+
+	remoteParts := map[int64]string{}
+	err := s.api.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:       aws.String(to.Bucket),
+		Key:          aws.String(s.objectKey(to.Path)),
+		UploadId:     aws.String(record.UploadID),
+		RequestPayer: s.RequestPayer(),
+	}, func(p *s3.ListPartsOutput, lastPage bool) bool {
+		for _, part := range p.Parts {
+			remoteParts[aws.Int64Value(part.PartNumber)] = aws.StringValue(part.ETag)
+		}
+		return !lastPage
+	})
+	if err != nil {
+		// the upload is either completed or aborted.
+		return nil
+	}
+
+	for partNumber, part := range record.Parts {
+		etag, ok := remoteParts[partNumber]
+		if !ok || strings.Trim(etag, `"`) != strings.Trim(part.ETag, `"`) {
+			delete(record.Parts, partNumber)
+		}
+	}
+	return record
+}
+
+// createMultipartUpload initiates a multipart upload with the given metadata
+// and returns its upload id. The input is copied from the one of Put, as the
+// uploader does, so that the resumed uploads have the same metadata.
+func (s *S3) createMultipartUpload(ctx context.Context, to *url.URL, metadata Metadata) (string, error) {
+	uploadInput, err := s.uploadInput(to, metadata)
+	if err != nil {
+		return "", err
+	}
+
+	input := &s3.CreateMultipartUploadInput{}
+	awsutil.Copy(input, uploadInput)
+
+	output, err := s.api.CreateMultipartUploadWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.UploadId), nil
+}
+
+// uploadPart uploads the given section of the file as a part of the
+// multipart upload.
+func (s *S3) uploadPart(
+	ctx context.Context,
+	file *os.File,
+	to *url.URL,
+	uploadID string,
+	partNumber int64,
+	offset int64,
+	length int64,
+) (uploadedPart, error) {
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(io.NewSectionReader(file, offset, length), buf); err != nil {
+		return uploadedPart{}, err
+	}
+
+	var opts []request.Option
+	if s.streamingSignature {
+		opts = append(opts, streamingSignatureOption())
+	}
+
+	sum := md5.Sum(buf)
+	output, err := s.api.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:       aws.String(to.Bucket),
+		Key:          aws.String(s.objectKey(to.Path)),
+		UploadId:     aws.String(uploadID),
+		PartNumber:   aws.Int64(partNumber),
+		Body:         bytes.NewReader(buf),
+		ContentMD5:   aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	}, opts...)
+	if err != nil {
+		return uploadedPart{}, fmt.Errorf("upload part %d: %w", partNumber, err)
+	}
+
+	return uploadedPart{
+		ETag:     aws.StringValue(output.ETag),
+		Checksum: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// md5Checksum returns the hex encoded MD5 checksum of the given reader.
+func md5Checksum(r io.Reader) (string, error) {
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
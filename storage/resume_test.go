@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestS3PutResumable(t *testing.T) {
+	const content = "0123456789"
+
+	testcases := []struct {
+		name          string
+		journaled     map[int64]uploadedPart
+		remoteParts   map[int64]string
+		expectedParts []int64
+		expectCreate  bool
+	}{
+		{
+			name:          "new upload",
+			expectedParts: []int64{1, 2, 3},
+			expectCreate:  true,
+		},
+		{
+			name: "resume from the last completed part",
+			journaled: map[int64]uploadedPart{
+				1: {ETag: `"etag-1"`, Checksum: "eb62f6b9306db575c2d596b1279627a4"},
+			},
+			remoteParts: map[int64]string{
+				1: `"etag-1"`,
+			},
+			expectedParts: []int64{2, 3},
+		},
+		{
+			name: "reupload parts whose content changed",
+			journaled: map[int64]uploadedPart{
+				1: {ETag: `"etag-1"`, Checksum: "stale-checksum"},
+				2: {ETag: `"etag-2"`, Checksum: "stale-checksum"},
+			},
+			remoteParts: map[int64]string{
+				1: `"etag-1"`,
+			},
+			expectedParts: []int64{1, 2, 3},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "resume", fs.WithFile("file", content))
+			defer dir.Remove()
+
+			file, err := os.Open(dir.Join("file"))
+			assert.NilError(t, err)
+			defer file.Close()
+
+			journal, err := NewUploadJournal(dir.Join("journal"))
+			assert.NilError(t, err)
+
+			dst, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			if tc.journaled != nil {
+				err := journal.save(file.Name(), dst, &uploadRecord{
+					UploadID: "upload-1",
+					Bucket:   "bucket",
+					Key:      "key",
+					Size:     int64(len(content)),
+					PartSize: 4,
+					Parts:    tc.journaled,
+				})
+				assert.NilError(t, err)
+			}
+
+			var (
+				mu             sync.Mutex
+				created        bool
+				uploadedParts  []int64
+				completedParts int
+			)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("<CompleteMultipartUploadResult/>")),
+				}
+			})
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch out := r.Data.(type) {
+				case *s3.CreateMultipartUploadOutput:
+					created = true
+					out.UploadId = aws.String("upload-2")
+				case *s3.ListPartsOutput:
+					for number, etag := range tc.remoteParts {
+						out.Parts = append(out.Parts, &s3.Part{
+							PartNumber: aws.Int64(number),
+							ETag:       aws.String(etag),
+						})
+					}
+				case *s3.UploadPartOutput:
+					number := r.Params.(*s3.UploadPartInput).PartNumber
+					uploadedParts = append(uploadedParts, *number)
+					out.ETag = aws.String(fmt.Sprintf(`"etag-%d"`, *number))
+				case *s3.CompleteMultipartUploadOutput:
+					completedParts = len(r.Params.(*s3.CompleteMultipartUploadInput).MultipartUpload.Parts)
+				}
+			})
+
+			mockS3 := &S3{api: mockApi}
+
+			err = mockS3.PutResumable(context.Background(), file, dst, NewMetadata(), 2, 4, journal, nil)
+			assert.NilError(t, err)
+
+			sort.Slice(uploadedParts, func(i, j int) bool { return uploadedParts[i] < uploadedParts[j] })
+
+			assert.Equal(t, created, tc.expectCreate)
+			assert.DeepEqual(t, uploadedParts, tc.expectedParts)
+			assert.Equal(t, completedParts, 3)
+
+			// journal must be cleaned up after a successful upload
+			entries, err := ioutil.ReadDir(dir.Join("journal"))
+			assert.NilError(t, err)
+			for _, e := range entries {
+				if strings.HasSuffix(e.Name(), ".json") {
+					t.Errorf("expected journal to be removed, found %q", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestS3PutResumableKeepsJournalOnError(t *testing.T) {
+	dir := fs.NewDir(t, "resume", fs.WithFile("file", "0123456789"))
+	defer dir.Remove()
+
+	file, err := os.Open(dir.Join("file"))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	journal, err := NewUploadJournal(dir.Join("journal"))
+	assert.NilError(t, err)
+
+	dst, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		if input, ok := r.Params.(*s3.UploadPartInput); ok && *input.PartNumber == 3 {
+			r.Error = fmt.Errorf("connection reset")
+			r.Retryable = aws.Bool(false)
+		}
+	})
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		switch out := r.Data.(type) {
+		case *s3.CreateMultipartUploadOutput:
+			out.UploadId = aws.String("upload-1")
+		case *s3.UploadPartOutput:
+			out.ETag = aws.String(`"etag"`)
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.PutResumable(context.Background(), file, dst, NewMetadata(), 1, 4, journal, nil)
+	assert.ErrorContains(t, err, "connection reset")
+
+	record := journal.load(file.Name(), dst)
+	assert.Assert(t, record != nil)
+	assert.Equal(t, record.UploadID, "upload-1")
+	assert.Equal(t, len(record.Parts), 2)
+}
+
+func TestS3PutResumableIfNoneMatch(t *testing.T) {
+	testcases := []struct {
+		name               string
+		completeErrs       []string
+		expectedConditions []string
+		expectedErr        error
+		expectedAborted    bool
+	}{
+		{
+			name:               "object doesn't exist",
+			expectedConditions: []string{"*"},
+		},
+		{
+			name:               "object exists",
+			completeErrs:       []string{"PreconditionFailed"},
+			expectedConditions: []string{"*"},
+			expectedErr:        ErrObjectExists,
+			expectedAborted:    true,
+		},
+		{
+			name:               "condition is not supported",
+			completeErrs:       []string{"NotImplemented"},
+			expectedConditions: []string{"*", ""},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "resume", fs.WithFile("file", "0123456789"))
+			defer dir.Remove()
+
+			file, err := os.Open(dir.Join("file"))
+			assert.NilError(t, err)
+			defer file.Close()
+
+			journal, err := NewUploadJournal(dir.Join("journal"))
+			assert.NilError(t, err)
+
+			dst, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			var (
+				mu         sync.Mutex
+				conditions []string
+				aborted    bool
+			)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("<CompleteMultipartUploadResult/>")),
+				}
+
+				switch r.Operation.Name {
+				case "CompleteMultipartUpload":
+					conditions = append(conditions, r.HTTPRequest.Header.Get("If-None-Match"))
+					if len(conditions) <= len(tc.completeErrs) {
+						r.Error = awserr.New(tc.completeErrs[len(conditions)-1], "", nil)
+						r.Retryable = aws.Bool(false)
+					}
+				case "AbortMultipartUpload":
+					aborted = true
+				}
+			})
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				switch out := r.Data.(type) {
+				case *s3.CreateMultipartUploadOutput:
+					out.UploadId = aws.String("upload-1")
+				case *s3.UploadPartOutput:
+					out.ETag = aws.String(`"etag"`)
+				}
+			})
+
+			mockS3 := &S3{api: mockApi}
+
+			metadata := NewMetadata().SetIfNoneMatch("*")
+			err = mockS3.PutResumable(context.Background(), file, dst, metadata, 1, 4, journal, nil)
+			assert.Equal(t, err, tc.expectedErr)
+			assert.Equal(t, aborted, tc.expectedAborted)
+			assert.DeepEqual(t, conditions, tc.expectedConditions)
+			assert.Assert(t, journal.load(file.Name(), dst) == nil)
+		})
+	}
+}
+
+func TestS3CreateMultipartUploadMetadata(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var input *s3.CreateMultipartUploadInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input = r.Params.(*s3.CreateMultipartUploadInput)
+		r.Data.(*s3.CreateMultipartUploadOutput).UploadId = aws.String("upload-1")
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	dst, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	metadata := NewMetadata().
+		SetContentType("text/plain").
+		SetContentEncoding("gzip").
+		SetCacheControl("no-cache").
+		SetStorageClass("STANDARD_IA").
+		SetSSE("aws:kms").
+		SetSSEKeyID("key-1")
+
+	uploadID, err := mockS3.createMultipartUpload(context.Background(), dst, metadata)
+	assert.NilError(t, err)
+	assert.Equal(t, uploadID, "upload-1")
+
+	// the resumed uploads have the same metadata as the uploads of Put.
+	expected, err := mockS3.uploadInput(dst, metadata)
+	assert.NilError(t, err)
+	assert.Equal(t, aws.StringValue(input.ContentEncoding), "gzip")
+	assert.DeepEqual(t, input, &s3.CreateMultipartUploadInput{
+		Bucket:               expected.Bucket,
+		Key:                  expected.Key,
+		ContentType:          aws.String("text/plain"),
+		ContentEncoding:      aws.String("gzip"),
+		CacheControl:         aws.String("no-cache"),
+		StorageClass:         aws.String("STANDARD_IA"),
+		ServerSideEncryption: aws.String("aws:kms"),
+		SSEKMSKeyId:          aws.String("key-1"),
+	})
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// RetryClass is a class of errors whose number of retries can be set
+// independently of the retry count.
+type RetryClass string
+
+const (
+	// RetryClassThrottling is the class of the errors of throttled requests,
+	// e.g. SlowDown or 503 Service Unavailable.
+	RetryClassThrottling RetryClass = "throttling"
+
+	// RetryClassKMSThrottling is the class of the errors of the requests
+	// whose calls to KMS are throttled, e.g. KMS.ThrottlingException of
+	// uploads of SSE-KMS encrypted objects.
+	RetryClassKMSThrottling RetryClass = "kms-throttling"
+
+	// RetryClassServer is the class of the other 5xx server errors.
+	RetryClassServer RetryClass = "server"
+
+	// RetryClassConnection is the class of the network errors, e.g.
+	// connection reset.
+	RetryClassConnection RetryClass = "connection"
+
+	// RetryClassTimeout is the class of RequestTimeout errors, returned when
+	// the request body is not sent in time. Parts of uploads are read again
+	// from the source on retries.
+	RetryClassTimeout RetryClass = "timeout"
+)
+
+var retryClasses = []RetryClass{
+	RetryClassThrottling,
+	RetryClassKMSThrottling,
+	RetryClassServer,
+	RetryClassConnection,
+	RetryClassTimeout,
+}
+
+// RetryPolicy is the maximum number of retries of the classes of errors. The
+// retry count is used for the classes not in the policy.
+type RetryPolicy map[RetryClass]int
+
+// ParseRetryPolicy parses the comma separated retry counts of the error
+// classes, e.g. "throttling=20,server=3". A zero count disables the retries
+// of the class.
+func ParseRetryPolicy(value string) (RetryPolicy, error) {
+	policy := RetryPolicy{}
+	if value == "" {
+		return policy, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(rule), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("retry policy %q must be in <class>=<count> format", rule)
+		}
+
+		class := RetryClass(strings.ToLower(parts[0]))
+		if !class.isValid() {
+			return nil, fmt.Errorf("unknown retry class %q, expected one of %v", parts[0], retryClasses)
+		}
+
+		count, err := strconv.Atoi(parts[1])
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("retry count of %q must be a non-negative integer", class)
+		}
+		policy[class] = count
+	}
+	return policy, nil
+}
+
+func (c RetryClass) isValid() bool {
+	for _, class := range retryClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// maxRetries returns the highest retry count in the policy.
+func (p RetryPolicy) maxRetries() int {
+	var max int
+	for _, count := range p {
+		if count > max {
+			max = count
+		}
+	}
+	return max
+}
+
+// retryClassOf returns the class of the error of the given request. An empty
+// class is returned for the errors in none of the classes.
+func retryClassOf(req *request.Request) RetryClass {
+	if req.Error == nil {
+		return ""
+	}
+
+	switch {
+	case errHasCode(req.Error, "RequestTimeout"):
+		return RetryClassTimeout
+	case isKMSThrottlingError(req.Error):
+		return RetryClassKMSThrottling
+	case errHasCode(req.Error, "SlowDown") || req.IsErrorThrottle():
+		return RetryClassThrottling
+	case errHasCode(req.Error, request.ErrCodeRequestError),
+		errHasCode(req.Error, request.ErrCodeResponseTimeout),
+		strings.Contains(req.Error.Error(), "connection reset"),
+		strings.Contains(req.Error.Error(), "connection timed out"):
+		return RetryClassConnection
+	case req.HTTPResponse != nil && req.HTTPResponse.StatusCode >= 500:
+		return RetryClassServer
+	default:
+		return ""
+	}
+}
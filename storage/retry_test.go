@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestParseRetryPolicy(t *testing.T) {
+	testcases := []struct {
+		value    string
+		expected RetryPolicy
+		wantErr  bool
+	}{
+		{
+			value:    "",
+			expected: RetryPolicy{},
+		},
+		{
+			value: "throttling=20, Server=0,connection=3,timeout=5",
+			expected: RetryPolicy{
+				RetryClassThrottling: 20,
+				RetryClassServer:     0,
+				RetryClassConnection: 3,
+				RetryClassTimeout:    5,
+			},
+		},
+		{value: "throttling", wantErr: true},
+		{value: "unknown=3", wantErr: true},
+		{value: "server=-1", wantErr: true},
+		{value: "server=many", wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseRetryPolicy(tc.value)
+			if tc.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tc.expected)
+		})
+	}
+}
+
+func TestCustomRetryerWithRetryPolicy(t *testing.T) {
+	log.Init("error", false)
+
+	policy := RetryPolicy{
+		RetryClassThrottling:    8,
+		RetryClassKMSThrottling: 5,
+		RetryClassServer:        0,
+		RetryClassTimeout:       2,
+	}
+
+	testcases := []struct {
+		name          string
+		err           error
+		statusCode    int
+		expectedRetry int
+	}{
+		{
+			name:          "throttling",
+			err:           awserr.New("SlowDown", "reduce your request rate", nil),
+			statusCode:    http.StatusServiceUnavailable,
+			expectedRetry: 8,
+		},
+		{
+			name:          "kms throttling",
+			err:           awserr.New("KMS.ThrottlingException", "You have exceeded the rate at which you may call KMS. Reduce the frequency of your calls.", nil),
+			statusCode:    http.StatusBadRequest,
+			expectedRetry: 5,
+		},
+		{
+			name:          "server",
+			err:           awserr.New("InternalError", "internal error", nil),
+			statusCode:    http.StatusInternalServerError,
+			expectedRetry: 0,
+		},
+		{
+			name:          "timeout",
+			err:           awserr.New("RequestTimeout", "request timeout", nil),
+			statusCode:    http.StatusBadRequest,
+			expectedRetry: 2,
+		},
+		{
+			name:          "connection uses retry count",
+			err:           awserr.New(request.ErrCodeRequestError, "send request failed", errors.New("connection reset by peer")),
+			expectedRetry: 3,
+		},
+	}
+
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			retryer := newCustomRetryer(3)
+			retryer.policy = policy
+
+			mockApi := s3.New(unit.Session.Copy(&aws.Config{
+				Retryer:    retryer,
+				SleepDelay: func(time.Duration) {},
+			}))
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				r.Error = tc.err
+				r.HTTPResponse = &http.Response{StatusCode: tc.statusCode}
+			})
+
+			retried := -1
+			mockApi.Handlers.AfterRetry.PushBack(func(_ *request.Request) {
+				retried++
+			})
+
+			mockS3 := &S3{api: mockApi}
+			_, _ = mockS3.Stat(context.Background(), u)
+
+			assert.Equal(t, retried, tc.expectedRetry)
+		})
+	}
+}
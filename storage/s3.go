@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"net/http"
 	urlpkg "net/url"
 	"os"
@@ -34,9 +36,9 @@ import (
 var sentinelURL = urlpkg.URL{}
 
 const (
-	// deleteObjectsMax is the max allowed objects to be deleted on single HTTP
-	// request.
-	deleteObjectsMax = 1000
+	// MaxDeleteBatchSize is the max allowed objects to be deleted on single
+	// HTTP request.
+	MaxDeleteBatchSize = 1000
 
 	// Amazon Accelerated Transfer endpoint
 	transferAccelEndpoint = "s3-accelerate.amazonaws.com"
@@ -60,6 +62,20 @@ type S3 struct {
 	dryRun           bool
 	useListObjectsV1 bool
 	requestPayer     string
+
+	bypassGovernanceRetention bool
+	checksumAlgorithm         ChecksumAlgorithm
+	deleteRate                float64
+	deleteBatchSize           int
+	customerKey               string
+	keyShardLength            int
+	streamingSignature        bool
+	ioUring                   bool
+	lister                    Lister
+
+	// opts are the options the client is created with. The clients of the
+	// other buckets matched by bucket wildcards are created with them.
+	opts *Options
 }
 
 func (s *S3) RequestPayer() *string {
@@ -69,6 +85,24 @@ func (s *S3) RequestPayer() *string {
 	return &s.requestPayer
 }
 
+// sseCustomerAlgorithm returns the algorithm of the server side encryption
+// with customer provided key, if the key is set.
+func (s *S3) sseCustomerAlgorithm() *string {
+	if s.customerKey == "" {
+		return nil
+	}
+	return aws.String(s3.ServerSideEncryptionAes256)
+}
+
+// sseCustomerKey returns the customer provided key of the server side
+// encryption, if set.
+func (s *S3) sseCustomerKey() *string {
+	if s.customerKey == "" {
+		return nil
+	}
+	return &s.customerKey
+}
+
 func parseEndpoint(endpoint string) (urlpkg.URL, error) {
 	if endpoint == "" {
 		return sentinelURL, nil
@@ -98,14 +132,32 @@ func newS3Storage(ctx context.Context, opts Options) (*S3, error) {
 		return nil, err
 	}
 
+	// the reads of the objects fail over to the replicas of the buckets on
+	// persistent errors, if any replica is set.
+	var api s3iface.S3API = s3.New(awsSession)
+	if endpointFailover.enabled() {
+		api = newFailoverAPI(api, opts)
+	}
+
 	return &S3{
-		api:              s3.New(awsSession),
-		downloader:       s3manager.NewDownloader(awsSession),
+		api:              api,
+		downloader:       s3manager.NewDownloaderWithClient(api),
 		uploader:         s3manager.NewUploader(awsSession),
 		endpointURL:      endpointURL,
 		dryRun:           opts.DryRun,
 		useListObjectsV1: opts.UseListObjectsV1,
 		requestPayer:     opts.RequestPayer,
+
+		bypassGovernanceRetention: opts.BypassGovernanceRetention,
+		checksumAlgorithm:         opts.ChecksumAlgorithm,
+		deleteRate:                opts.DeleteRate,
+		deleteBatchSize:           opts.DeleteBatchSize,
+		customerKey:               opts.SSECustomerKey,
+		keyShardLength:            opts.KeyShardLength,
+		streamingSignature:        opts.StreamingSignature,
+		ioUring:                   opts.IOUring,
+		lister:                    opts.Lister,
+		opts:                      &opts,
 	}, nil
 }
 
@@ -113,8 +165,11 @@ func newS3Storage(ctx context.Context, opts Options) (*S3, error) {
 func (s *S3) Stat(ctx context.Context, url *url.URL) (*Object, error) {
 	output, err := s.api.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
 		Bucket:       aws.String(url.Bucket),
-		Key:          aws.String(url.Path),
+		Key:          aws.String(s.objectKey(url.Path)),
 		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
 	})
 	if err != nil {
 		if errHasCode(err, "NotFound") {
@@ -126,100 +181,196 @@ func (s *S3) Stat(ctx context.Context, url *url.URL) (*Object, error) {
 	etag := aws.StringValue(output.ETag)
 	mod := aws.TimeValue(output.LastModified)
 	return &Object{
-		URL:     url,
-		Etag:    strings.Trim(etag, `"`),
-		ModTime: &mod,
-		Size:    aws.Int64Value(output.ContentLength),
+		URL:             url,
+		Etag:            strings.Trim(etag, `"`),
+		ModTime:         &mod,
+		Size:            aws.Int64Value(output.ContentLength),
+		ContentEncoding: aws.StringValue(output.ContentEncoding),
+		Restore:         aws.StringValue(output.Restore),
 	}, nil
 }
 
 // List is a non-blocking S3 list operation which paginates and filters S3
 // keys. If no object found or an error is encountered during this period,
-// it sends these errors to object channel.
-func (s *S3) List(ctx context.Context, url *url.URL, _ bool) <-chan *Object {
+// it sends these errors to object channel. The objects are listed by the
+// external lister instead, if the storage has one.
+func (s *S3) List(ctx context.Context, srcurl *url.URL, _ bool) <-chan *Object {
+	return s.listWildcardBuckets(ctx, srcurl, func(client *S3, url *url.URL) <-chan *Object {
+		return client.listBucket(ctx, url)
+	})
+}
+
+// listBucket lists the objects of the URL of a single bucket. The segments
+// of the wildcard URLs with wildcards before their first '*' are listed level
+// by level, and only the keys under the matching common prefixes are listed.
+func (s *S3) listBucket(ctx context.Context, url *url.URL) <-chan *Object {
+	if s.lister != nil {
+		return s.listWithLister(ctx, url)
+	}
+
 	if isGoogleEndpoint(s.endpointURL) || s.useListObjectsV1 {
 		return s.listObjects(ctx, url)
 	}
 
-	return s.listObjectsV2(ctx, url)
+	if globs := url.SegmentGlobs(); len(globs) > 0 && s.keyShardLength <= 0 {
+		prefixes, err := s.listSegmentPrefixes(ctx, url, globs)
+		if err == nil && len(prefixes) == 0 {
+			err = ErrNoObjectFound
+		}
+		if err != nil {
+			objCh := make(chan *Object, 1)
+			objCh <- &Object{Err: err}
+			close(objCh)
+			return objCh
+		}
+		url = url.WithListPrefixes(prefixes)
+	}
+
+	return s.listObjectsV2(ctx, url, "", nil)
 }
 
-func (s *S3) listObjectsV2(ctx context.Context, url *url.URL) <-chan *Object {
-	listInput := s3.ListObjectsV2Input{
-		Bucket:       aws.String(url.Bucket),
-		Prefix:       aws.String(url.Prefix),
-		RequestPayer: s.RequestPayer(),
+// ListResumable lists the objects like List, starting from the page of the
+// given continuation token if it is not empty. Before each page, checkpoint
+// is called with the token of the previous page, so that the listing can be
+// resumed without missing the objects which are received but not processed
+// yet. Listing is stopped if checkpoint returns an error.
+func (s *S3) ListResumable(
+	ctx context.Context,
+	url *url.URL,
+	token string,
+	checkpoint func(token string) error,
+) <-chan *Object {
+	// the continuation tokens are of the listing of a single prefix, while
+	// the objects of a sharded bucket are listed under each of the shards.
+	// External listers have no continuation tokens. The brace groups and the
+	// bucket wildcards are listed under multiple prefixes or buckets too.
+	if isGoogleEndpoint(s.endpointURL) || s.useListObjectsV1 || s.keyShardLength > 0 || s.lister != nil ||
+		len(url.ListPrefixes()) > 1 || url.IsBucketWildcard() {
+		objCh := make(chan *Object, 1)
+		objCh <- &Object{Err: ErrResumableListNotSupported}
+		close(objCh)
+		return objCh
 	}
 
-	if url.Delimiter != "" {
-		listInput.SetDelimiter(url.Delimiter)
-	}
+	return s.listObjectsV2(ctx, url, token, checkpoint)
+}
 
+func (s *S3) listObjectsV2(
+	ctx context.Context,
+	url *url.URL,
+	token string,
+	checkpoint func(token string) error,
+) <-chan *Object {
 	objCh := make(chan *Object)
 
 	go func() {
 		defer close(objCh)
 		objectFound := false
+		seenPrefix := s.commonPrefixFilter()
+
+		var (
+			now time.Time
+
+			// continuation tokens of the previous and the current pages.
+			previousToken string
+			currentToken  = token
+			firstPage     = true
+			checkpointErr error
+			err           error
+		)
+
+		for _, listPrefix := range s.listPrefixes(url) {
+			listInput := s3.ListObjectsV2Input{
+				Bucket:       aws.String(url.Bucket),
+				Prefix:       aws.String(listPrefix),
+				RequestPayer: s.RequestPayer(),
+			}
 
-		var now time.Time
-
-		err := s.api.ListObjectsV2PagesWithContext(ctx, &listInput, func(p *s3.ListObjectsV2Output, lastPage bool) bool {
-			for _, c := range p.CommonPrefixes {
-				prefix := aws.StringValue(c.Prefix)
-				if !url.Match(prefix) {
-					continue
-				}
-
-				newurl := url.Clone()
-				newurl.Path = prefix
-				objCh <- &Object{
-					URL:  newurl,
-					Type: ObjectType{os.ModeDir},
-				}
+			if url.Delimiter != "" {
+				listInput.SetDelimiter(url.Delimiter)
+			}
 
-				objectFound = true
+			if url.StartAfter != "" {
+				listInput.SetStartAfter(s.shardStartAfter(listPrefix, url.StartAfter))
 			}
-			// track the instant object iteration began,
-			// so it can be used to bypass objects created after this instant
-			if now.IsZero() {
-				now = time.Now().UTC()
+
+			if token != "" {
+				listInput.SetContinuationToken(token)
 			}
 
-			for _, c := range p.Contents {
-				key := aws.StringValue(c.Key)
-				if !url.Match(key) {
-					continue
+			err = s.api.ListObjectsV2PagesWithContext(ctx, &listInput, func(p *s3.ListObjectsV2Output, lastPage bool) bool {
+				if checkpoint != nil && !firstPage {
+					if checkpointErr = checkpoint(previousToken); checkpointErr != nil {
+						return false
+					}
 				}
+				firstPage = false
+				previousToken, currentToken = currentToken, aws.StringValue(p.NextContinuationToken)
+
+				for _, c := range p.CommonPrefixes {
+					prefix := s.objectPath(aws.StringValue(c.Prefix))
+					if !url.Match(prefix) || seenPrefix(prefix) {
+						continue
+					}
+
+					newurl := url.Clone()
+					newurl.Path = prefix
+					objCh <- &Object{
+						URL:  newurl,
+						Type: ObjectType{os.ModeDir},
+					}
 
-				mod := aws.TimeValue(c.LastModified).UTC()
-				if mod.After(now) {
 					objectFound = true
-					continue
 				}
-
-				var objtype os.FileMode
-				if strings.HasSuffix(key, "/") {
-					objtype = os.ModeDir
+				// track the instant object iteration began,
+				// so it can be used to bypass objects created after this instant
+				if now.IsZero() {
+					now = time.Now().UTC()
 				}
 
-				newurl := url.Clone()
-				newurl.Path = aws.StringValue(c.Key)
-				etag := aws.StringValue(c.ETag)
-
-				objCh <- &Object{
-					URL:          newurl,
-					Etag:         strings.Trim(etag, `"`),
-					ModTime:      &mod,
-					Type:         ObjectType{objtype},
-					Size:         aws.Int64Value(c.Size),
-					StorageClass: StorageClass(aws.StringValue(c.StorageClass)),
+				for _, c := range p.Contents {
+					key := s.objectPath(aws.StringValue(c.Key))
+					if !url.Match(key) {
+						continue
+					}
+
+					mod := aws.TimeValue(c.LastModified).UTC()
+					if mod.After(now) {
+						objectFound = true
+						continue
+					}
+
+					var objtype os.FileMode
+					if strings.HasSuffix(key, "/") {
+						objtype = os.ModeDir
+					}
+
+					newurl := url.Clone()
+					newurl.Path = key
+					etag := aws.StringValue(c.ETag)
+
+					objCh <- &Object{
+						URL:          newurl,
+						Etag:         strings.Trim(etag, `"`),
+						ModTime:      &mod,
+						Type:         ObjectType{objtype},
+						Size:         aws.Int64Value(c.Size),
+						StorageClass: StorageClass(aws.StringValue(c.StorageClass)),
+					}
+
+					objectFound = true
 				}
 
-				objectFound = true
+				return !lastPage
+			})
+			if err != nil || checkpointErr != nil {
+				break
 			}
+		}
 
-			return !lastPage
-		})
+		if err == nil {
+			err = checkpointErr
+		}
 
 		if err != nil {
 			objCh <- &Object{Err: err}
@@ -237,81 +388,94 @@ func (s *S3) listObjectsV2(ctx context.Context, url *url.URL) <-chan *Object {
 // listObjects is used for cloud services that does not support S3
 // ListObjectsV2 API. I'm looking at you GCS.
 func (s *S3) listObjects(ctx context.Context, url *url.URL) <-chan *Object {
-	listInput := s3.ListObjectsInput{
-		Bucket:       aws.String(url.Bucket),
-		Prefix:       aws.String(url.Prefix),
-		RequestPayer: s.RequestPayer(),
-	}
-
-	if url.Delimiter != "" {
-		listInput.SetDelimiter(url.Delimiter)
-	}
-
 	objCh := make(chan *Object)
 
 	go func() {
 		defer close(objCh)
 		objectFound := false
+		seenPrefix := s.commonPrefixFilter()
+
+		var (
+			now time.Time
+			err error
+		)
+
+		for _, listPrefix := range s.listPrefixes(url) {
+			listInput := s3.ListObjectsInput{
+				Bucket:       aws.String(url.Bucket),
+				Prefix:       aws.String(listPrefix),
+				RequestPayer: s.RequestPayer(),
+			}
 
-		var now time.Time
-
-		err := s.api.ListObjectsPagesWithContext(ctx, &listInput, func(p *s3.ListObjectsOutput, lastPage bool) bool {
-			for _, c := range p.CommonPrefixes {
-				prefix := aws.StringValue(c.Prefix)
-				if !url.Match(prefix) {
-					continue
-				}
-
-				newurl := url.Clone()
-				newurl.Path = prefix
-				objCh <- &Object{
-					URL:  newurl,
-					Type: ObjectType{os.ModeDir},
-				}
-
-				objectFound = true
+			if url.Delimiter != "" {
+				listInput.SetDelimiter(url.Delimiter)
 			}
-			// track the instant object iteration began,
-			// so it can be used to bypass objects created after this instant
-			if now.IsZero() {
-				now = time.Now().UTC()
+
+			if url.StartAfter != "" {
+				listInput.SetMarker(s.shardStartAfter(listPrefix, url.StartAfter))
 			}
 
-			for _, c := range p.Contents {
-				key := aws.StringValue(c.Key)
-				if !url.Match(key) {
-					continue
-				}
+			err = s.api.ListObjectsPagesWithContext(ctx, &listInput, func(p *s3.ListObjectsOutput, lastPage bool) bool {
+				for _, c := range p.CommonPrefixes {
+					prefix := s.objectPath(aws.StringValue(c.Prefix))
+					if !url.Match(prefix) || seenPrefix(prefix) {
+						continue
+					}
+
+					newurl := url.Clone()
+					newurl.Path = prefix
+					objCh <- &Object{
+						URL:  newurl,
+						Type: ObjectType{os.ModeDir},
+					}
 
-				mod := aws.TimeValue(c.LastModified).UTC()
-				if mod.After(now) {
 					objectFound = true
-					continue
 				}
-
-				var objtype os.FileMode
-				if strings.HasSuffix(key, "/") {
-					objtype = os.ModeDir
+				// track the instant object iteration began,
+				// so it can be used to bypass objects created after this instant
+				if now.IsZero() {
+					now = time.Now().UTC()
 				}
 
-				newurl := url.Clone()
-				newurl.Path = aws.StringValue(c.Key)
-				etag := aws.StringValue(c.ETag)
-
-				objCh <- &Object{
-					URL:          newurl,
-					Etag:         strings.Trim(etag, `"`),
-					ModTime:      &mod,
-					Type:         ObjectType{objtype},
-					Size:         aws.Int64Value(c.Size),
-					StorageClass: StorageClass(aws.StringValue(c.StorageClass)),
+				for _, c := range p.Contents {
+					key := s.objectPath(aws.StringValue(c.Key))
+					if !url.Match(key) {
+						continue
+					}
+
+					mod := aws.TimeValue(c.LastModified).UTC()
+					if mod.After(now) {
+						objectFound = true
+						continue
+					}
+
+					var objtype os.FileMode
+					if strings.HasSuffix(key, "/") {
+						objtype = os.ModeDir
+					}
+
+					newurl := url.Clone()
+					newurl.Path = key
+					etag := aws.StringValue(c.ETag)
+
+					objCh <- &Object{
+						URL:          newurl,
+						Etag:         strings.Trim(etag, `"`),
+						ModTime:      &mod,
+						Type:         ObjectType{objtype},
+						Size:         aws.Int64Value(c.Size),
+						StorageClass: StorageClass(aws.StringValue(c.StorageClass)),
+					}
+
+					objectFound = true
 				}
 
-				objectFound = true
+				return !lastPage
+			})
+			if err != nil {
+				break
 			}
-
-			return !lastPage
-		})
+		}
 
 		if err != nil {
 			objCh <- &Object{Err: err}
@@ -328,19 +492,47 @@ func (s *S3) listObjects(ctx context.Context, url *url.URL) <-chan *Object {
 
 // Copy is a single-object copy operation which copies objects to S3
 // destination from another S3 source.
+//
+// The metadata of the source object is copied unless the REPLACE metadata
+// directive is given. S3 ignores the headers given in the request while
+// copying the metadata, so if any header is overridden, the metadata of the
+// source object is fetched and replaced with the overridden headers.
 func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) error {
 	if s.dryRun {
 		return nil
 	}
 
 	// SDK expects CopySource like "bucket[/key]"
-	copySource := from.EscapedPath()
+	copySource := s.copySource(from)
 
 	input := &s3.CopyObjectInput{
 		Bucket:       aws.String(to.Bucket),
-		Key:          aws.String(to.Path),
+		Key:          aws.String(s.objectKey(to.Path)),
 		CopySource:   aws.String(copySource),
 		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm:           s.sseCustomerAlgorithm(),
+		SSECustomerKey:                 s.sseCustomerKey(),
+		CopySourceSSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		CopySourceSSECustomerKey:       s.sseCustomerKey(),
+	}
+
+	directive := metadata.Directive()
+	if directive == "" {
+		directive = MetadataDirectiveCopy
+	}
+
+	if directive == MetadataDirectiveCopy && hasHeaderOverrides(metadata) {
+		if err := s.copySourceMetadata(ctx, from, input); err != nil {
+			return err
+		}
+		directive = MetadataDirectiveReplace
+	}
+	input.MetadataDirective = aws.String(string(directive))
+
+	contentType := metadata.ContentType()
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
 	}
 
 	storageClass := metadata.StorageClass()
@@ -355,6 +547,9 @@ func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) err
 		if sseKmsKeyID != "" {
 			input.SSEKMSKeyId = aws.String(sseKmsKeyID)
 		}
+		if metadata.BucketKeyEnabled() {
+			input.BucketKeyEnabled = aws.Bool(true)
+		}
 	}
 
 	acl := metadata.ACL()
@@ -367,6 +562,21 @@ func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) err
 		input.CacheControl = aws.String(cacheControl)
 	}
 
+	contentDisposition := metadata.ContentDisposition()
+	if contentDisposition != "" {
+		input.ContentDisposition = aws.String(contentDisposition)
+	}
+
+	contentLanguage := metadata.ContentLanguage()
+	if contentLanguage != "" {
+		input.ContentLanguage = aws.String(contentLanguage)
+	}
+
+	websiteRedirect := metadata.WebsiteRedirectLocation()
+	if websiteRedirect != "" {
+		input.WebsiteRedirectLocation = aws.String(websiteRedirect)
+	}
+
 	expires := metadata.Expires()
 	if expires != "" {
 		t, err := time.Parse(time.RFC3339, expires)
@@ -376,17 +586,96 @@ func (s *S3) Copy(ctx context.Context, from, to *url.URL, metadata Metadata) err
 		input.Expires = aws.Time(t)
 	}
 
-	_, err := s.api.CopyObject(input)
+	tagging := metadata.Tagging()
+	if tagging != "" {
+		input.Tagging = aws.String(tagging)
+		input.TaggingDirective = aws.String(s3.TaggingDirectiveReplace)
+	}
+
+	var opts []request.Option
+	if s.checksumAlgorithm != "" {
+		opts = append(opts, checksumRequestOption(s.checksumAlgorithm))
+	}
+
+	_, err := s.api.CopyObjectWithContext(ctx, input, opts...)
+	if isCopySourceTooLarge(err) {
+		return s.multipartCopy(ctx, from, to, input)
+	}
 	return err
 }
 
+// hasHeaderOverrides checks if any of the object headers, which are replaced
+// with the REPLACE metadata directive, is given in the metadata.
+func hasHeaderOverrides(metadata Metadata) bool {
+	return metadata.ContentType() != "" ||
+		metadata.CacheControl() != "" ||
+		metadata.ContentDisposition() != "" ||
+		metadata.ContentLanguage() != "" ||
+		metadata.WebsiteRedirectLocation() != "" ||
+		metadata.Expires() != ""
+}
+
+// copySourceMetadata sets the headers and the user-defined metadata of the
+// source object to the copy input.
+func (s *S3) copySourceMetadata(ctx context.Context, from *url.URL, input *s3.CopyObjectInput) error {
+	head, err := s.api.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(from.Bucket),
+		Key:          aws.String(s.objectKey(from.Path)),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	})
+	if err != nil {
+		return err
+	}
+
+	setSourceMetadata(input, head)
+	return nil
+}
+
 // Read fetches the remote object and returns its contents as an io.ReadCloser.
+// The contents are returned as stored, even if the object has a gzip content
+// encoding.
 func (s *S3) Read(ctx context.Context, src *url.URL) (io.ReadCloser, error) {
 	resp, err := s.api.GetObjectWithContext(ctx, &s3.GetObjectInput{
 		Bucket:       aws.String(src.Bucket),
-		Key:          aws.String(src.Path),
+		Key:          aws.String(s.objectKey(src.Path)),
 		RequestPayer: s.RequestPayer(),
-	})
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	}, s.recoverStreamOption(), identityEncodingRequestOption)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ReadRange fetches length bytes of the remote object starting from the given
+// offset and returns them as an io.ReadCloser.
+func (s *S3) ReadRange(ctx context.Context, src *url.URL, offset, length int64) (io.ReadCloser, error) {
+	// an empty range can't be expressed with a Range header.
+	if length == 0 {
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	}
+
+	return s.ReadByteRange(ctx, src, fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+}
+
+// ReadByteRange fetches the given byte range of the remote object and returns
+// it as an io.ReadCloser. The range is in HTTP Range header format, e.g.
+// "bytes=0-1023", "bytes=1024-" or "bytes=-1024" for the last 1024 bytes.
+func (s *S3) ReadByteRange(ctx context.Context, src *url.URL, byteRange string) (io.ReadCloser, error) {
+	resp, err := s.api.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(src.Bucket),
+		Key:          aws.String(s.objectKey(src.Path)),
+		Range:        aws.String(byteRange),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	}, s.recoverStreamOption())
 	if err != nil {
 		return nil, err
 	}
@@ -396,6 +685,10 @@ func (s *S3) Read(ctx context.Context, src *url.URL) (io.ReadCloser, error) {
 // Get is a multipart download operation which downloads S3 objects into any
 // destination that implements io.WriterAt interface.
 // Makes a single 'GetObject' call if 'concurrency' is 1 and ignores 'partSize'.
+// If a checksum algorithm is set and the destination implements io.ReaderAt,
+// the downloaded content is verified against the checksum of the object.
+// Files are preallocated to the size of the object before the parts are
+// written.
 func (s *S3) Get(
 	ctx context.Context,
 	from *url.URL,
@@ -407,14 +700,58 @@ func (s *S3) Get(
 		return 0, nil
 	}
 
-	return s.downloader.DownloadWithContext(ctx, to, &s3.GetObjectInput{
+	requestOptions := []request.Option{s.recoverStreamOption()}
+	if file, ok := to.(*os.File); ok {
+		preallocated := &preallocatedFile{File: file}
+		if ring := s.ringFileOf(file); ring != nil {
+			preallocated.writer = ring
+		}
+		requestOptions = append(requestOptions, preallocateOption(preallocated))
+		to = preallocated
+	}
+
+	input := &s3.GetObjectInput{
 		Bucket:       aws.String(from.Bucket),
-		Key:          aws.String(from.Path),
+		Key:          aws.String(s.objectKey(from.Path)),
 		RequestPayer: s.RequestPayer(),
-	}, func(u *s3manager.Downloader) {
-		u.PartSize = partSize
-		u.Concurrency = concurrency
-	})
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	}
+
+	// the download is retried with halved part sizes if large parts fail,
+	// the parts are written to the same offsets again.
+	var (
+		size int64
+		err  error
+	)
+	for {
+		size, err = s.downloader.DownloadWithContext(ctx, to, input, func(u *s3manager.Downloader) {
+			u.PartSize = partSize
+			u.Concurrency = concurrency
+			u.RequestOptions = append(u.RequestOptions, requestOptions...)
+		})
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+
+		next, ok := downgradePartSize(partSize, err)
+		if !ok {
+			break
+		}
+		warnPartSizeDowngrade("download", from, next, err)
+		partSize = next
+	}
+	if err != nil {
+		return size, err
+	}
+
+	if content, ok := to.(io.ReaderAt); ok && s.checksumAlgorithm != "" {
+		if err := s.verifyChecksum(ctx, from, content); err != nil {
+			return size, err
+		}
+	}
+	return size, nil
 }
 
 type SelectQuery struct {
@@ -425,12 +762,13 @@ type SelectQuery struct {
 
 func (s *S3) Select(ctx context.Context, url *url.URL, query *SelectQuery, resultCh chan<- json.RawMessage) error {
 	if s.dryRun {
+		simulation.add("SelectObjectContent", 1, 0)
 		return nil
 	}
 
 	input := &s3.SelectObjectContentInput{
 		Bucket:         aws.String(url.Bucket),
-		Key:            aws.String(url.Path),
+		Key:            aws.String(s.objectKey(url.Path)),
 		ExpressionType: aws.String(query.ExpressionType),
 		Expression:     aws.String(query.Expression),
 		InputSerialization: &s3.InputSerialization{
@@ -442,6 +780,8 @@ func (s *S3) Select(ctx context.Context, url *url.URL, query *SelectQuery, resul
 		OutputSerialization: &s3.OutputSerialization{
 			JSON: &s3.JSONOutput{},
 		},
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
 	}
 
 	resp, err := s.api.SelectObjectContentWithContext(ctx, input)
@@ -490,6 +830,14 @@ func (s *S3) Select(ctx context.Context, url *url.URL, query *SelectQuery, resul
 	return resp.EventStream.Reader.Err()
 }
 
+// SourceVerifier is implemented by the upload sources which may change while
+// they are read. The source is verified before the upload is completed, and
+// the upload is aborted with the returned error, so that a partially changed
+// content never replaces the destination object.
+type SourceVerifier interface {
+	VerifySource() error
+}
+
 // Put is a multipart upload operation to upload resources, which implements
 // io.Reader interface, into S3 destination.
 func (s *S3) Put(
@@ -504,6 +852,100 @@ func (s *S3) Put(
 		return nil
 	}
 
+	// the parts of the files are read with io_uring if it is enabled.
+	if file, ok := reader.(*os.File); ok {
+		if ring := s.ringFileOf(file); ring != nil {
+			reader = ring
+		}
+	}
+
+	input, err := s.uploadInput(to, metadata)
+	if err != nil {
+		return err
+	}
+	input.Body = reader
+
+	ifNoneMatch := metadata.IfNoneMatch()
+	verifier, _ := reader.(SourceVerifier)
+	var verifyErr error
+
+	// the upload is retried from the start with halved part sizes if large
+	// parts fail, as long as the content can be read again.
+	seeker, _ := reader.(io.Seeker)
+	var start int64
+	if seeker != nil {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seeker = nil
+		}
+	}
+
+	for {
+		_, err = s.uploader.UploadWithContext(ctx, input, func(u *s3manager.Uploader) {
+			u.PartSize = partSize
+			u.Concurrency = concurrency
+			if s.checksumAlgorithm != "" {
+				u.RequestOptions = append(u.RequestOptions, checksumRequestOption(s.checksumAlgorithm))
+			}
+			if ifNoneMatch != "" {
+				u.RequestOptions = append(u.RequestOptions, ifNoneMatchRequestOption(ifNoneMatch))
+			}
+			if s.streamingSignature {
+				u.RequestOptions = append(u.RequestOptions, streamingSignatureOption())
+			}
+			if verifier != nil {
+				u.RequestOptions = append(u.RequestOptions, verifySourceRequestOption(verifier, &verifyErr))
+			}
+		})
+		if verifyErr != nil {
+			return verifyErr
+		}
+		if seeker == nil || ctx.Err() != nil {
+			break
+		}
+
+		if ifNoneMatch != "" && isConditionalWriteUnsupported(err) {
+			if _, serr := seeker.Seek(start, io.SeekStart); serr != nil {
+				break
+			}
+			ifNoneMatch = ""
+			continue
+		}
+
+		next, ok := downgradePartSize(partSize, err)
+		if !ok {
+			break
+		}
+		if _, serr := seeker.Seek(start, io.SeekStart); serr != nil {
+			break
+		}
+		warnPartSizeDowngrade("upload", to, next, err)
+		partSize = next
+	}
+
+	// the uploader aborts the failed multipart uploads with the given
+	// context, which fails once the context is canceled.
+	if ctx.Err() != nil {
+		s.abortCanceledUpload(input.Bucket, input.Key, err)
+	}
+
+	if errHasCode(err, errCodeVolatileSource) {
+		return ErrVolatileSource
+	}
+	// S3 rejects the content if it doesn't match the given checksum.
+	if s.checksumAlgorithm != "" && errHasCode(err, "BadDigest") {
+		return fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+	}
+	if ifNoneMatch != "" && errHasCode(err, "PreconditionFailed") {
+		return ErrObjectExists
+	}
+	return err
+}
+
+// uploadInput returns the input of the uploads to the given destination with
+// the given metadata. The multipart uploads created without the uploader
+// copy their input from it, so that they have the same metadata.
+func (s *S3) uploadInput(to *url.URL, metadata Metadata) (*s3manager.UploadInput, error) {
 	contentType := metadata.ContentType()
 	if contentType == "" {
 		contentType = "application/octet-stream"
@@ -511,10 +953,12 @@ func (s *S3) Put(
 
 	input := &s3manager.UploadInput{
 		Bucket:       aws.String(to.Bucket),
-		Key:          aws.String(to.Path),
-		Body:         reader,
+		Key:          aws.String(s.objectKey(to.Path)),
 		ContentType:  aws.String(contentType),
 		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
 	}
 
 	storageClass := metadata.StorageClass()
@@ -531,11 +975,36 @@ func (s *S3) Put(
 		input.CacheControl = aws.String(cacheControl)
 	}
 
+	contentDisposition := metadata.ContentDisposition()
+	if contentDisposition != "" {
+		input.ContentDisposition = aws.String(contentDisposition)
+	}
+
+	contentLanguage := metadata.ContentLanguage()
+	if contentLanguage != "" {
+		input.ContentLanguage = aws.String(contentLanguage)
+	}
+
+	websiteRedirect := metadata.WebsiteRedirectLocation()
+	if websiteRedirect != "" {
+		input.WebsiteRedirectLocation = aws.String(websiteRedirect)
+	}
+
+	contentEncoding := metadata.ContentEncoding()
+	if contentEncoding != "" {
+		input.ContentEncoding = aws.String(contentEncoding)
+	}
+
+	tagging := metadata.Tagging()
+	if tagging != "" {
+		input.Tagging = aws.String(tagging)
+	}
+
 	expires := metadata.Expires()
 	if expires != "" {
 		t, err := time.Parse(time.RFC3339, expires)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		input.Expires = aws.Time(t)
 	}
@@ -547,14 +1016,86 @@ func (s *S3) Put(
 		if sseKmsKeyID != "" {
 			input.SSEKMSKeyId = aws.String(sseKmsKeyID)
 		}
+		if metadata.BucketKeyEnabled() {
+			input.BucketKeyEnabled = aws.Bool(true)
+		}
+	}
+	return input, nil
+}
+
+// abortUploadTimeout is the time limit of aborting a canceled multipart
+// upload, so that an unresponsive endpoint doesn't block the exit after an
+// interrupt.
+const abortUploadTimeout = 10 * time.Second
+
+// abortCanceledUpload aborts the multipart upload of the given failed upload,
+// if any, so that the uploaded parts of the canceled uploads are not left
+// behind.
+func (s *S3) abortCanceledUpload(bucket, key *string, err error) {
+	var failure s3manager.MultiUploadFailure
+	if !errors.As(err, &failure) || failure.UploadID() == "" {
+		return
 	}
 
-	_, err := s.uploader.UploadWithContext(ctx, input, func(u *s3manager.Uploader) {
-		u.PartSize = partSize
-		u.Concurrency = concurrency
+	// the context of the upload is canceled already.
+	ctx, cancel := context.WithTimeout(context.Background(), abortUploadTimeout)
+	defer cancel()
+
+	_, _ = s.api.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:       bucket,
+		Key:          key,
+		UploadId:     aws.String(failure.UploadID()),
+		RequestPayer: s.RequestPayer(),
 	})
+}
 
-	return err
+// ifNoneMatchRequestOption returns a request option which sets If-None-Match
+// header of the requests that create the object. The SDK version in use
+// doesn't have a field for the header in the input types.
+func ifNoneMatchRequestOption(ifNoneMatch string) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBack(func(r *request.Request) {
+			switch r.Operation.Name {
+			case "PutObject", "CompleteMultipartUpload":
+				r.HTTPRequest.Header.Set("If-None-Match", ifNoneMatch)
+			}
+		})
+	}
+}
+
+// identityEncodingRequestOption asks for the object as stored. Otherwise the
+// HTTP transport asks for a gzip response and transparently decompresses the
+// objects stored with gzip content encoding.
+func identityEncodingRequestOption(r *request.Request) {
+	r.Handlers.Build.PushBack(func(r *request.Request) {
+		r.HTTPRequest.Header.Set("Accept-Encoding", "identity")
+	})
+}
+
+// verifySourceRequestOption returns a request option which verifies the
+// source of the upload before the request that creates the object. The
+// request fails if the source can't be verified, and the error is stored in
+// the given pointer.
+func verifySourceRequestOption(verifier SourceVerifier, errp *error) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBack(func(r *request.Request) {
+			switch r.Operation.Name {
+			case "PutObject", "CompleteMultipartUpload":
+				if err := verifier.VerifySource(); err != nil {
+					*errp = err
+					r.Error = err
+				}
+			}
+		})
+	}
+}
+
+// isConditionalWriteUnsupported reports whether the request is rejected since
+// the storage doesn't support conditional writes. The callers check the
+// destination before the upload, so the upload is retried without the
+// condition.
+func isConditionalWriteUnsupported(err error) bool {
+	return errHasCode(err, "NotImplemented")
 }
 
 // chunk is an object identifier container which is used on MultiDelete
@@ -570,6 +1111,17 @@ type chunk struct {
 func (s *S3) calculateChunks(ch <-chan *url.URL) <-chan chunk {
 	chunkch := make(chan chunk)
 
+	chunkSize := MaxDeleteBatchSize
+	if s.deleteBatchSize > 0 && s.deleteBatchSize < chunkSize {
+		chunkSize = s.deleteBatchSize
+	}
+
+	// keep the chunks small enough to not exceed the delete rate with a
+	// single request.
+	if s.deleteRate > 0 && s.deleteRate < float64(chunkSize) {
+		chunkSize = int(math.Ceil(s.deleteRate))
+	}
+
 	go func() {
 		defer close(chunkch)
 
@@ -580,11 +1132,22 @@ func (s *S3) calculateChunks(ch <-chan *url.URL) <-chan chunk {
 
 		var bucket string
 		for url := range ch {
+			// a request deletes the objects of a single bucket.
+			if len(keys) > 0 && url.Bucket != bucket {
+				chunkch <- chunk{
+					Bucket: bucket,
+					Keys:   keys,
+				}
+				initKeys()
+			}
 			bucket = url.Bucket
 
-			objid := &s3.ObjectIdentifier{Key: aws.String(url.Path)}
+			objid := &s3.ObjectIdentifier{Key: aws.String(s.objectKey(url.Path))}
+			if url.VersionID != "" {
+				objid.VersionId = aws.String(url.VersionID)
+			}
 			keys = append(keys, objid)
-			if len(keys) == deleteObjectsMax {
+			if len(keys) == chunkSize {
 				chunkch <- chunk{
 					Bucket: bucket,
 					Keys:   keys,
@@ -609,9 +1172,12 @@ func (s *S3) Delete(ctx context.Context, url *url.URL) error {
 	chunk := chunk{
 		Bucket: url.Bucket,
 		Keys: []*s3.ObjectIdentifier{
-			{Key: aws.String(url.Path)},
+			{Key: aws.String(s.objectKey(url.Path))},
 		},
 	}
+	if url.VersionID != "" {
+		chunk.Keys[0].VersionId = aws.String(url.VersionID)
+	}
 
 	resultch := make(chan *Object, 1)
 	defer close(resultch)
@@ -625,8 +1191,9 @@ func (s *S3) Delete(ctx context.Context, url *url.URL) error {
 // the Object container.
 func (s *S3) doDelete(ctx context.Context, chunk chunk, resultch chan *Object) {
 	if s.dryRun {
+		simulation.add("DeleteObjects", 1, 0)
 		for _, k := range chunk.Keys {
-			key := fmt.Sprintf("s3://%v/%v", chunk.Bucket, aws.StringValue(k.Key))
+			key := fmt.Sprintf("s3://%v/%v", chunk.Bucket, s.objectPath(aws.StringValue(k.Key)))
 			url, _ := url.New(key)
 			resultch <- &Object{URL: url}
 		}
@@ -634,37 +1201,70 @@ func (s *S3) doDelete(ctx context.Context, chunk chunk, resultch chan *Object) {
 	}
 
 	bucket := chunk.Bucket
-	o, err := s.api.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+	input := &s3.DeleteObjectsInput{
 		Bucket:       aws.String(bucket),
 		Delete:       &s3.Delete{Objects: chunk.Keys},
 		RequestPayer: s.RequestPayer(),
-	})
+	}
+	if s.bypassGovernanceRetention {
+		input.BypassGovernanceRetention = aws.Bool(true)
+	}
+
+	o, err := s.api.DeleteObjectsWithContext(ctx, input)
 	if err != nil {
 		resultch <- &Object{Err: err}
 		return
 	}
 
 	for _, d := range o.Deleted {
-		key := fmt.Sprintf("s3://%v/%v", bucket, aws.StringValue(d.Key))
+		key := fmt.Sprintf("s3://%v/%v", bucket, s.objectPath(aws.StringValue(d.Key)))
 		url, _ := url.New(key)
-		resultch <- &Object{URL: url}
+
+		// deleting an object without a version id doesn't erase any version
+		// on versioned buckets, it creates a delete marker instead.
+		resultch <- &Object{
+			URL:                   url,
+			VersionID:             aws.StringValue(d.VersionId),
+			DeleteMarker:          aws.BoolValue(d.DeleteMarker),
+			DeleteMarkerVersionID: aws.StringValue(d.DeleteMarkerVersionId),
+		}
 	}
 
 	for _, e := range o.Errors {
-		key := fmt.Sprintf("s3://%v/%v", bucket, aws.StringValue(e.Key))
+		key := fmt.Sprintf("s3://%v/%v", bucket, s.objectPath(aws.StringValue(e.Key)))
 		url, _ := url.New(key)
+
+		err := fmt.Errorf(aws.StringValue(e.Message))
+		if isObjectLockError(aws.StringValue(e.Code), aws.StringValue(e.Message)) {
+			err = ErrObjectLocked
+		}
+
 		resultch <- &Object{
 			URL: url,
-			Err: fmt.Errorf(aws.StringValue(e.Message)),
+			Err: err,
 		}
 	}
 }
 
+// isObjectLockError reports whether the given DeleteObjects error is caused by
+// an object lock retention period or legal hold. S3 reports such errors as
+// access denied errors, so the message is inspected as well.
+func isObjectLockError(code, message string) bool {
+	if code == "ObjectLocked" {
+		return true
+	}
+
+	message = strings.ToLower(message)
+	return code == "AccessDenied" && (strings.Contains(message, "object lock") || strings.Contains(message, "worm protected"))
+}
+
 // MultiDelete is a asynchronous removal operation for multiple objects.
 // It reads given url channel, creates multiple chunks and run these
 // chunks in parallel. Each chunk may have at most 1000 objects since DeleteObjects
 // API has a limitation.
 // See: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObjects.html.
+// If a delete rate is set, chunks are paced to not delete more objects per
+// second than the rate.
 func (s *S3) MultiDelete(ctx context.Context, urlch <-chan *url.URL) <-chan *Object {
 	resultch := make(chan *Object)
 
@@ -675,10 +1275,24 @@ func (s *S3) MultiDelete(ctx context.Context, urlch <-chan *url.URL) <-chan *Obj
 
 		chunks := s.calculateChunks(urlch)
 
-		var wg sync.WaitGroup
+		var (
+			wg   sync.WaitGroup
+			next time.Time
+		)
 		for chunk := range chunks {
 			chunk := chunk
 
+			if s.deleteRate > 0 {
+				if wait := time.Until(next); wait > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(wait):
+					}
+				}
+				interval := float64(len(chunk.Keys)) / s.deleteRate * float64(time.Second)
+				next = time.Now().Add(time.Duration(interval))
+			}
+
 			wg.Add(1)
 			sem <- true
 
@@ -719,6 +1333,7 @@ func (s *S3) ListBuckets(ctx context.Context, prefix string) ([]Bucket, error) {
 // MakeBucket creates an S3 bucket with the given name.
 func (s *S3) MakeBucket(ctx context.Context, name string) error {
 	if s.dryRun {
+		simulation.add("CreateBucket", 1, 0)
 		return nil
 	}
 
@@ -731,6 +1346,7 @@ func (s *S3) MakeBucket(ctx context.Context, name string) error {
 // RemoveBucket removes an S3 bucket with the given name.
 func (s *S3) RemoveBucket(ctx context.Context, name string) error {
 	if s.dryRun {
+		simulation.add("DeleteBucket", 1, 0)
 		return nil
 	}
 
@@ -759,11 +1375,33 @@ type SessionCache struct {
 // newSession initializes a new AWS session with region fallback and custom
 // options.
 func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.Session, error) {
+	sess, created, err := sc.session(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// connections are opened after the lock is released, so that the
+	// sessions of the other options don't wait for them.
+	if created {
+		preconnect(ctx, sess, opts.bucket, opts.Preconnect)
+	}
+	return sess, nil
+}
+
+// session returns the cached session of the given options, or creates it.
+// It reports whether the session is created.
+func (sc *SessionCache) session(ctx context.Context, opts Options) (*session.Session, bool, error) {
 	sc.Lock()
 	defer sc.Unlock()
 
+	// the lister and the delete options don't affect the session, and the
+	// lister may not be comparable.
+	opts.Lister = nil
+	opts.DeleteRate = 0
+	opts.DeleteBatchSize = 0
+
 	if sess, ok := sc.sessions[opts]; ok {
-		return sess, nil
+		return sess, false, nil
 	}
 
 	awsCfg := aws.NewConfig()
@@ -775,7 +1413,7 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 
 	endpointURL, err := parseEndpoint(opts.Endpoint)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// use virtual-host-style if the endpoint is known to support it,
@@ -789,11 +1427,15 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 	if useAccelerate {
 		endpointURL = sentinelURL
 	}
+	useAccelerate = useAccelerate || opts.UseAccelerate
 
 	var httpClient *http.Client
 	if opts.NoVerifySSL {
 		httpClient = insecureHTTPClient
 	}
+	if opts.Preconnect > 0 {
+		httpClient = preconnectHTTPClient(httpClient, opts.Preconnect)
+	}
 
 	awsCfg = awsCfg.
 		WithEndpoint(endpointURL.String()).
@@ -803,7 +1445,16 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 		WithLogLevel(aws.LogDebug).
 		WithLogger(sdkLogger{})
 
-	awsCfg.Retryer = newCustomRetryer(opts.MaxRetries)
+	retryPolicy, err := ParseRetryPolicy(opts.RetryPolicy)
+	if err != nil {
+		return nil, false, err
+	}
+
+	retryer := newCustomRetryer(opts.MaxRetries)
+	retryer.MaxRetryDelay = opts.RetryMaxDelay
+	retryer.MaxThrottleDelay = opts.RetryMaxDelay
+	retryer.policy = retryPolicy
+	awsCfg.Retryer = retryer
 
 	useSharedConfig := session.SharedConfigEnable
 	{
@@ -824,8 +1475,12 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 		},
 	)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	requestLimiter.install(&sess.Handlers)
+	kmsRequestLimiter.install(&sess.Handlers)
+	transferLimiter.install(&sess.Handlers)
+	simulation.install(&sess.Handlers)
 
 	// get region of the bucket and create session accordingly. if the region
 	// is not provided, it means we want region-independent session
@@ -835,13 +1490,52 @@ func (sc *SessionCache) newSession(ctx context.Context, opts Options) (*session.
 		sess.Config.Region = aws.String(opts.region)
 	} else {
 		if err := setSessionRegion(ctx, sess, opts.bucket); err != nil {
-			return nil, err
+			return nil, false, err
+		}
+	}
+
+	if useAccelerate && opts.bucket != "" {
+		if err := checkBucketAccelerate(ctx, sess, opts.bucket); err != nil {
+			msg := log.WarningMessage{
+				Warning: fmt.Sprintf("not using transfer acceleration for bucket %q: %v", opts.bucket, err),
+			}
+			log.Warning(msg)
+			sess.Config.S3UseAccelerate = aws.Bool(false)
 		}
 	}
 
 	sc.sessions[opts] = sess
 
-	return sess, nil
+	return sess, true, nil
+}
+
+// checkBucketAccelerate returns an error if the transfer acceleration
+// endpoint of the bucket can not be used.
+func checkBucketAccelerate(ctx context.Context, sess *session.Session, bucket string) error {
+	// accelerate endpoints are virtual-host-style endpoints, which don't
+	// support bucket names with dots.
+	if strings.Contains(bucket, ".") {
+		return fmt.Errorf("bucket name is not compatible with transfer acceleration")
+	}
+
+	// the configuration can't be fetched from the accelerate endpoint if the
+	// acceleration is not enabled.
+	client := s3.New(sess, aws.NewConfig().WithS3UseAccelerate(false))
+	output, err := client.GetBucketAccelerateConfigurationWithContext(ctx, &s3.GetBucketAccelerateConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		// assume the acceleration is enabled if the configuration is not
+		// accessible, requests would fail if it is not.
+		msg := log.DebugMessage{Err: fmt.Sprintf("unable to check transfer acceleration of bucket %q: %v", bucket, err)}
+		log.Debug(msg)
+		return nil
+	}
+
+	if aws.StringValue(output.Status) != s3.BucketAccelerateStatusEnabled {
+		return fmt.Errorf("transfer acceleration is not enabled")
+	}
+	return nil
 }
 
 func (sc *SessionCache) clear() {
@@ -892,6 +1586,9 @@ func setSessionRegion(ctx context.Context, sess *session.Session, bucket string)
 // error codes. Such as, retry for S3 InternalError code.
 type customRetryer struct {
 	client.DefaultRetryer
+
+	// policy overrides the number of retries of the classes of errors.
+	policy RetryPolicy
 }
 
 func newCustomRetryer(maxRetries int) *customRetryer {
@@ -902,10 +1599,31 @@ func newCustomRetryer(maxRetries int) *customRetryer {
 	}
 }
 
+// MaxRetries returns the number of retries of the error class with the most
+// retries, the retry count of each request is checked in ShouldRetry.
+func (c *customRetryer) MaxRetries() int {
+	if max := c.policy.maxRetries(); max > c.NumMaxRetries {
+		return max
+	}
+	return c.NumMaxRetries
+}
+
 // ShouldRetry overrides SDK's built in DefaultRetryer, adding custom retry
 // logics that are not included in the SDK.
 func (c *customRetryer) ShouldRetry(req *request.Request) bool {
 	shouldRetry := errHasCode(req.Error, "InternalError") || errHasCode(req.Error, "RequestTimeTooSkewed") || strings.Contains(req.Error.Error(), "connection reset") || strings.Contains(req.Error.Error(), "connection timed out")
+
+	// the requests throttled by KMS are paced by the KMS limiter before they
+	// are retried.
+	if !shouldRetry {
+		shouldRetry = isKMSThrottlingError(req.Error)
+	}
+
+	// the checksum of the re-read part is calculated again on retries.
+	if !shouldRetry && errHasCode(req.Error, "BadDigest") {
+		shouldRetry = req.Operation.Name == "PutObject" || req.Operation.Name == "UploadPart"
+	}
+
 	if !shouldRetry {
 		shouldRetry = c.DefaultRetryer.ShouldRetry(req)
 	}
@@ -915,6 +1633,12 @@ func (c *customRetryer) ShouldRetry(req *request.Request) bool {
 		return false
 	}
 
+	if count, ok := c.policy[retryClassOf(req)]; ok {
+		shouldRetry = req.RetryCount < count
+	} else if req.RetryCount >= c.NumMaxRetries {
+		shouldRetry = false
+	}
+
 	if shouldRetry && req.Error != nil {
 		err := fmt.Errorf("retryable error: %v", req.Error)
 		msg := log.DebugMessage{Err: err.Error()}
@@ -971,3 +1695,10 @@ func errHasCode(err error, code string) bool {
 func IsCancelationError(err error) bool {
 	return errHasCode(err, request.CanceledErrorCode)
 }
+
+// IsNotFoundError reports whether the error is returned by a HEAD request of
+// a bucket or an object which doesn't exist, e.g. while detecting the region
+// of the bucket.
+func IsNotFoundError(err error) bool {
+	return errHasCode(err, "NotFound")
+}
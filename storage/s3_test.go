@@ -3,6 +3,8 @@ package storage
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -128,6 +130,48 @@ func TestNewSessionWithNoSignRequest(t *testing.T) {
 	}
 }
 
+func TestNewRemoteClientWithSSECustomerKey(t *testing.T) {
+	globalSessionCache.clear()
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{SSECustomerKey: "0123456789abcdef0123456789abcdef"}
+	opts.SetRegion("us-east-1")
+
+	client, err := NewRemoteClient(context.Background(), u, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := aws.StringValue(client.sseCustomerKey()); got != opts.SSECustomerKey {
+		t.Fatalf("expected %q, got %q", opts.SSECustomerKey, got)
+	}
+}
+
+func TestNewRemoteClientWithStreamingSignature(t *testing.T) {
+	globalSessionCache.clear()
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{StreamingSignature: true}
+	opts.SetRegion("us-east-1")
+
+	client, err := NewRemoteClient(context.Background(), u, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !client.streamingSignature {
+		t.Fatalf("expected streaming signature to be enabled")
+	}
+}
+
 func TestS3ListURL(t *testing.T) {
 	url, err := url.New("s3://bucket/key")
 	if err != nil {
@@ -266,6 +310,139 @@ func TestS3ListNoItemFound(t *testing.T) {
 	}
 }
 
+func TestS3ListResumable(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// pages of the listing keyed by their continuation tokens.
+	pages := map[string]*s3.ListObjectsV2Output{
+		"": {
+			Contents:              []*s3.Object{{Key: aws.String("key/a")}},
+			NextContinuationToken: aws.String("t1"),
+			IsTruncated:           aws.Bool(true),
+		},
+		"t1": {
+			Contents:              []*s3.Object{{Key: aws.String("key/b")}},
+			NextContinuationToken: aws.String("t2"),
+			IsTruncated:           aws.Bool(true),
+		},
+		"t2": {
+			Contents: []*s3.Object{{Key: aws.String("key/c")}},
+		},
+	}
+
+	testcases := []struct {
+		name  string
+		token string
+
+		expectedKeys        []string
+		expectedCheckpoints []string
+	}{
+		{
+			name:                "from the beginning",
+			expectedKeys:        []string{"key/a", "key/b", "key/c"},
+			expectedCheckpoints: []string{"", "t1"},
+		},
+		{
+			name:                "from a continuation token",
+			token:               "t1",
+			expectedKeys:        []string{"key/b", "key/c"},
+			expectedCheckpoints: []string{"t1"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := s3.New(unit.Session)
+			mockS3 := &S3{
+				api: mockApi,
+			}
+
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				token := aws.StringValue(r.Params.(*s3.ListObjectsV2Input).ContinuationToken)
+				r.Data = pages[token]
+			})
+
+			var checkpoints []string
+			checkpoint := func(token string) error {
+				checkpoints = append(checkpoints, token)
+				return nil
+			}
+
+			var keys []string
+			for got := range mockS3.ListResumable(context.Background(), u, tc.token, checkpoint) {
+				if got.Err != nil {
+					t.Fatalf("unexpected error: %v", got.Err)
+				}
+				keys = append(keys, got.URL.Path)
+			}
+
+			assert.DeepEqual(t, tc.expectedKeys, keys)
+			assert.DeepEqual(t, tc.expectedCheckpoints, checkpoints)
+		})
+	}
+}
+
+func TestS3ListResumableCheckpointError(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	mockApi := s3.New(unit.Session)
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		r.Data = &s3.ListObjectsV2Output{
+			Contents:              []*s3.Object{{Key: aws.String("key/a")}},
+			NextContinuationToken: aws.String("next"),
+			IsTruncated:           aws.Bool(true),
+		}
+	})
+
+	mockErr := fmt.Errorf("mock error")
+	checkpoint := func(string) error {
+		return mockErr
+	}
+
+	var gotErr error
+	for got := range mockS3.ListResumable(context.Background(), u, "", checkpoint) {
+		if got.Err != nil {
+			gotErr = got.Err
+		}
+	}
+	assert.Equal(t, mockErr, gotErr)
+}
+
+func TestS3ListResumableNotSupported(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	mockS3 := &S3{
+		api:              s3.New(unit.Session),
+		useListObjectsV1: true,
+	}
+
+	for got := range mockS3.ListResumable(context.Background(), u, "", nil) {
+		assert.Equal(t, ErrResumableListNotSupported, got.Err)
+	}
+}
+
 func TestS3ListContextCancelled(t *testing.T) {
 	url, err := url.New("s3://bucket/key")
 	if err != nil {
@@ -485,14 +662,16 @@ func TestS3Retry(t *testing.T) {
 
 func TestS3CopyEncryptionRequest(t *testing.T) {
 	testcases := []struct {
-		name     string
-		sse      string
-		sseKeyID string
-		acl      string
-
-		expectedSSE      string
-		expectedSSEKeyID string
-		expectedAcl      string
+		name      string
+		sse       string
+		sseKeyID  string
+		bucketKey bool
+		acl       string
+
+		expectedSSE       string
+		expectedSSEKeyID  string
+		expectedBucketKey bool
+		expectedAcl       string
 	}{
 		{
 			name: "no encryption/no acl, by default",
@@ -511,10 +690,24 @@ func TestS3CopyEncryptionRequest(t *testing.T) {
 			expectedSSE:      "aws:kms",
 			expectedSSEKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
 		},
+		{
+			name:      "aws:kms encryption with bucket key",
+			sse:       "aws:kms",
+			sseKeyID:  "sdkjn12SDdci#@#EFRFERTqW/ke",
+			bucketKey: true,
+
+			expectedSSE:       "aws:kms",
+			expectedSSEKeyID:  "sdkjn12SDdci#@#EFRFERTqW/ke",
+			expectedBucketKey: true,
+		},
 		{
 			name:     "provide key without encryption flag, shall be ignored",
 			sseKeyID: "1234567890",
 		},
+		{
+			name:      "bucket key without encryption flag, shall be ignored",
+			bucketKey: true,
+		},
 		{
 			name:        "acl flag with a value",
 			acl:         "bucket-owner-full-control",
@@ -547,6 +740,7 @@ func TestS3CopyEncryptionRequest(t *testing.T) {
 				params := r.Params
 				sse := valueAtPath(params, "ServerSideEncryption")
 				key := valueAtPath(params, "SSEKMSKeyId")
+				bucketKey := valueAtPath(params, "BucketKeyEnabled")
 
 				if !(sse == nil && tc.expectedSSE == "") {
 					assert.Equal(t, sse, tc.expectedSSE)
@@ -554,6 +748,9 @@ func TestS3CopyEncryptionRequest(t *testing.T) {
 				if !(key == nil && tc.expectedSSEKeyID == "") {
 					assert.Equal(t, key, tc.expectedSSEKeyID)
 				}
+				if !(bucketKey == nil && !tc.expectedBucketKey) {
+					assert.Equal(t, bucketKey, tc.expectedBucketKey)
+				}
 
 				aclVal := valueAtPath(r.Params, "ACL")
 
@@ -576,7 +773,11 @@ func TestS3CopyEncryptionRequest(t *testing.T) {
 				api: mockApi,
 			}
 
-			metadata := NewMetadata().SetSSE(tc.sse).SetSSEKeyID(tc.sseKeyID).SetACL(tc.acl)
+			metadata := NewMetadata().
+				SetSSE(tc.sse).
+				SetSSEKeyID(tc.sseKeyID).
+				SetBucketKeyEnabled(tc.bucketKey).
+				SetACL(tc.acl)
 
 			err = mockS3.Copy(context.Background(), u, u, metadata)
 
@@ -587,16 +788,146 @@ func TestS3CopyEncryptionRequest(t *testing.T) {
 	}
 }
 
-func TestS3PutEncryptionRequest(t *testing.T) {
+func TestS3CopyMetadataDirective(t *testing.T) {
 	testcases := []struct {
 		name     string
-		sse      string
-		sseKeyID string
-		acl      string
+		metadata Metadata
+
+		expectHead           bool
+		expectedDirective    string
+		expectedContentType  string
+		expectedCacheControl string
+		expectedDisposition  string
+		expectedLanguage     string
+		expectedRedirect     string
+		expectedUserMetadata map[string]*string
+	}{
+		{
+			name:              "copy metadata by default",
+			metadata:          NewMetadata(),
+			expectedDirective: "COPY",
+		},
+		{
+			name:                 "override a header while copying metadata",
+			metadata:             NewMetadata().SetCacheControl("no-cache"),
+			expectHead:           true,
+			expectedDirective:    "REPLACE",
+			expectedContentType:  "text/html",
+			expectedCacheControl: "no-cache",
+			expectedLanguage:     "en",
+			expectedUserMetadata: map[string]*string{"Owner": aws.String("s5cmd")},
+		},
+		{
+			name:                 "override content disposition while copying metadata",
+			metadata:             NewMetadata().SetContentDisposition("attachment"),
+			expectHead:           true,
+			expectedDirective:    "REPLACE",
+			expectedContentType:  "text/html",
+			expectedCacheControl: "max-age=60",
+			expectedDisposition:  "attachment",
+			expectedLanguage:     "en",
+			expectedUserMetadata: map[string]*string{"Owner": aws.String("s5cmd")},
+		},
+		{
+			name:                 "override website redirect location while copying metadata",
+			metadata:             NewMetadata().SetWebsiteRedirectLocation("/new.html"),
+			expectHead:           true,
+			expectedDirective:    "REPLACE",
+			expectedContentType:  "text/html",
+			expectedCacheControl: "max-age=60",
+			expectedLanguage:     "en",
+			expectedRedirect:     "/new.html",
+			expectedUserMetadata: map[string]*string{"Owner": aws.String("s5cmd")},
+		},
+		{
+			name:              "replace metadata with content language",
+			metadata:          NewMetadata().SetContentLanguage("tr").SetDirective(MetadataDirectiveReplace),
+			expectedDirective: "REPLACE",
+			expectedLanguage:  "tr",
+		},
+		{
+			name:                 "replace metadata",
+			metadata:             NewMetadata().SetCacheControl("no-cache").SetDirective(MetadataDirectiveReplace),
+			expectedDirective:    "REPLACE",
+			expectedCacheControl: "no-cache",
+		},
+	}
+
+	u, err := url.New("s3://bucket/key")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := s3.New(unit.Session)
+
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.UnmarshalError.Clear()
+			mockApi.Handlers.Send.Clear()
+
+			var headCalled bool
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+
+				switch input := r.Params.(type) {
+				case *s3.HeadObjectInput:
+					headCalled = true
+				case *s3.CopyObjectInput:
+					assert.Equal(t, aws.StringValue(input.MetadataDirective), tc.expectedDirective)
+					assert.Equal(t, aws.StringValue(input.ContentType), tc.expectedContentType)
+					assert.Equal(t, aws.StringValue(input.CacheControl), tc.expectedCacheControl)
+					assert.Equal(t, aws.StringValue(input.ContentDisposition), tc.expectedDisposition)
+					assert.Equal(t, aws.StringValue(input.ContentLanguage), tc.expectedLanguage)
+					assert.Equal(t, aws.StringValue(input.WebsiteRedirectLocation), tc.expectedRedirect)
+					assert.DeepEqual(t, input.Metadata, tc.expectedUserMetadata)
+				}
+			})
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				if output, ok := r.Data.(*s3.HeadObjectOutput); ok {
+					output.ContentType = aws.String("text/html")
+					output.CacheControl = aws.String("max-age=60")
+					output.ContentLanguage = aws.String("en")
+					output.Metadata = map[string]*string{"Owner": aws.String("s5cmd")}
+				}
+				if r.Error != nil {
+					if awsErr, ok := r.Error.(awserr.Error); ok {
+						if awsErr.Code() == request.ErrCodeSerialization {
+							r.Error = nil
+						}
+					}
+				}
+			})
+
+			mockS3 := &S3{
+				api: mockApi,
+			}
 
-		expectedSSE      string
-		expectedSSEKeyID string
-		expectedAcl      string
+			err = mockS3.Copy(context.Background(), u, u, tc.metadata)
+			if err != nil {
+				t.Errorf("Expected %v, but received %q", nil, err)
+			}
+			assert.Equal(t, headCalled, tc.expectHead)
+		})
+	}
+}
+
+func TestS3PutEncryptionRequest(t *testing.T) {
+	testcases := []struct {
+		name      string
+		sse       string
+		sseKeyID  string
+		bucketKey bool
+		acl       string
+
+		expectedSSE       string
+		expectedSSEKeyID  string
+		expectedBucketKey bool
+		expectedAcl       string
 	}{
 		{
 			name: "no encryption, no acl flag",
@@ -614,10 +945,24 @@ func TestS3PutEncryptionRequest(t *testing.T) {
 			expectedSSE:      "aws:kms",
 			expectedSSEKeyID: "sdkjn12SDdci#@#EFRFERTqW/ke",
 		},
+		{
+			name:      "aws:kms encryption with bucket key",
+			sse:       "aws:kms",
+			sseKeyID:  "sdkjn12SDdci#@#EFRFERTqW/ke",
+			bucketKey: true,
+
+			expectedSSE:       "aws:kms",
+			expectedSSEKeyID:  "sdkjn12SDdci#@#EFRFERTqW/ke",
+			expectedBucketKey: true,
+		},
 		{
 			name:     "provide key without encryption flag, shall be ignored",
 			sseKeyID: "1234567890",
 		},
+		{
+			name:      "bucket key without encryption flag, shall be ignored",
+			bucketKey: true,
+		},
 		{
 			name:        "acl flag with a value",
 			acl:         "bucket-owner-full-control",
@@ -649,6 +994,7 @@ func TestS3PutEncryptionRequest(t *testing.T) {
 				params := r.Params
 				sse := valueAtPath(params, "ServerSideEncryption")
 				key := valueAtPath(params, "SSEKMSKeyId")
+				bucketKey := valueAtPath(params, "BucketKeyEnabled")
 
 				if !(sse == nil && tc.expectedSSE == "") {
 					assert.Equal(t, sse, tc.expectedSSE)
@@ -656,6 +1002,9 @@ func TestS3PutEncryptionRequest(t *testing.T) {
 				if !(key == nil && tc.expectedSSEKeyID == "") {
 					assert.Equal(t, key, tc.expectedSSEKeyID)
 				}
+				if !(bucketKey == nil && !tc.expectedBucketKey) {
+					assert.Equal(t, bucketKey, tc.expectedBucketKey)
+				}
 
 				aclVal := valueAtPath(r.Params, "ACL")
 
@@ -669,7 +1018,11 @@ func TestS3PutEncryptionRequest(t *testing.T) {
 				uploader: s3manager.NewUploaderWithClient(mockApi),
 			}
 
-			metadata := NewMetadata().SetSSE(tc.sse).SetSSEKeyID(tc.sseKeyID).SetACL(tc.acl)
+			metadata := NewMetadata().
+				SetSSE(tc.sse).
+				SetSSEKeyID(tc.sseKeyID).
+				SetBucketKeyEnabled(tc.bucketKey).
+				SetACL(tc.acl)
 
 			err = mockS3.Put(context.Background(), bytes.NewReader([]byte("")), u, metadata, 1, 5242880)
 
@@ -680,6 +1033,205 @@ func TestS3PutEncryptionRequest(t *testing.T) {
 	}
 }
 
+func TestS3SSECustomerKey(t *testing.T) {
+	key := strings.Repeat("k", 32)
+	sum := md5.Sum([]byte(key))
+
+	encodedKey := base64.StdEncoding.EncodeToString([]byte(key))
+	encodedKeyMD5 := base64.StdEncoding.EncodeToString(sum[:])
+
+	from, err := url.New("s3://bucket/source")
+	assert.NilError(t, err)
+	to, err := url.New("s3://bucket/destination")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.UnmarshalError.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	var headers []http.Header
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		headers = append(headers, r.HTTPRequest.Header)
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("<Result/>")),
+		}
+	})
+
+	mockS3 := &S3{
+		api:         mockApi,
+		customerKey: key,
+	}
+
+	_, err = mockS3.Stat(context.Background(), from)
+	assert.NilError(t, err)
+
+	err = mockS3.Copy(context.Background(), from, to, NewMetadata())
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(headers), 2)
+	for _, header := range headers {
+		assert.Equal(t, header.Get("x-amz-server-side-encryption-customer-algorithm"), "AES256")
+		assert.Equal(t, header.Get("x-amz-server-side-encryption-customer-key"), encodedKey)
+		assert.Equal(t, header.Get("x-amz-server-side-encryption-customer-key-md5"), encodedKeyMD5)
+	}
+
+	// the key is sent for both the source and the destination of copies.
+	copyHeader := headers[1]
+	assert.Equal(t, copyHeader.Get("x-amz-copy-source-server-side-encryption-customer-algorithm"), "AES256")
+	assert.Equal(t, copyHeader.Get("x-amz-copy-source-server-side-encryption-customer-key"), encodedKey)
+	assert.Equal(t, copyHeader.Get("x-amz-copy-source-server-side-encryption-customer-key-md5"), encodedKeyMD5)
+}
+
+func TestS3PutIfNoneMatch(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.UnmarshalError.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusPreconditionFailed,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}
+
+		assert.Equal(t, r.HTTPRequest.Header.Get("If-None-Match"), "*")
+		r.Error = awserr.New("PreconditionFailed", "At least one of the pre-conditions you specified did not hold", nil)
+	})
+
+	mockS3 := &S3{
+		uploader: s3manager.NewUploaderWithClient(mockApi),
+	}
+
+	metadata := NewMetadata().SetIfNoneMatch("*")
+	err = mockS3.Put(context.Background(), strings.NewReader("content"), u, metadata, 1, 5242880)
+	assert.Equal(t, err, ErrObjectExists)
+}
+
+func TestS3PutIfNoneMatchUnsupported(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.UnmarshalError.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	var conditions []string
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}
+
+		conditions = append(conditions, r.HTTPRequest.Header.Get("If-None-Match"))
+		if len(conditions) == 1 {
+			r.HTTPResponse.StatusCode = http.StatusNotImplemented
+			r.Error = awserr.New("NotImplemented", "A header you provided implies functionality that is not implemented", nil)
+		}
+	})
+
+	mockS3 := &S3{
+		uploader: s3manager.NewUploaderWithClient(mockApi),
+	}
+
+	// the upload is retried without the condition.
+	metadata := NewMetadata().SetIfNoneMatch("*")
+	err = mockS3.Put(context.Background(), strings.NewReader("content"), u, metadata, 1, 5242880)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, conditions, []string{"*", ""})
+}
+
+type sourceVerifierFunc func() error
+
+func (f sourceVerifierFunc) VerifySource() error { return f() }
+
+func TestS3PutVerifiesSource(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.UnmarshalError.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	var sent int
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		sent++
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}
+	})
+
+	mockS3 := &S3{
+		uploader: s3manager.NewUploaderWithClient(mockApi),
+	}
+
+	errChanged := fmt.Errorf("changed")
+	reader := struct {
+		io.Reader
+		SourceVerifier
+	}{strings.NewReader("content"), sourceVerifierFunc(func() error { return errChanged })}
+
+	// the object is not created if the source can't be verified.
+	err = mockS3.Put(context.Background(), reader, u, NewMetadata(), 1, 5242880)
+	assert.Equal(t, err, errChanged)
+	assert.Equal(t, sent, 0)
+}
+
+func TestS3PutAbortsCanceledMultipartUpload(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Send.Clear()
+
+	var aborts []error
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		switch r.Operation.Name {
+		case "UploadPart":
+			// the upload is canceled while the parts are being uploaded.
+			cancel()
+			r.Error = awserr.New(request.CanceledErrorCode, "request context canceled", context.Canceled)
+		case "AbortMultipartUpload":
+			aborts = append(aborts, r.Context().Err())
+		}
+	})
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		if output, ok := r.Data.(*s3.CreateMultipartUploadOutput); ok {
+			output.UploadId = aws.String("upload-id")
+		}
+	})
+
+	mockS3 := &S3{
+		api:      mockApi,
+		uploader: s3manager.NewUploaderWithClient(mockApi),
+	}
+
+	content := bytes.NewReader(make([]byte, 2*s3manager.MinUploadPartSize))
+	err = mockS3.Put(ctx, content, u, NewMetadata(), 1, s3manager.MinUploadPartSize)
+	assert.Assert(t, IsCancelationError(err))
+
+	// the abort request of the uploader is not sent with the canceled
+	// context.
+	assert.DeepEqual(t, aborts, []error{nil})
+}
+
 func TestS3listObjectsV2(t *testing.T) {
 	const (
 		numObjectsToReturn = 10100
@@ -748,7 +1300,7 @@ func TestS3listObjectsV2(t *testing.T) {
 		api: mockApi,
 	}
 
-	ouputCh := mockS3.listObjectsV2(context.Background(), u)
+	ouputCh := mockS3.listObjectsV2(context.Background(), u, "", nil)
 
 	for obj := range ouputCh {
 		if _, ok := mapReturnObjNameToModtime[obj.String()]; ok {
@@ -881,6 +1433,74 @@ func TestSessionRegionDetection(t *testing.T) {
 	}
 }
 
+func TestCheckBucketAccelerate(t *testing.T) {
+	log.Init("error", false)
+
+	testcases := []struct {
+		name    string
+		bucket  string
+		status  string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:   "enabled",
+			bucket: "bucket",
+			status: s3.BucketAccelerateStatusEnabled,
+		},
+		{
+			name:    "suspended",
+			bucket:  "bucket",
+			status:  s3.BucketAccelerateStatusSuspended,
+			wantErr: true,
+		},
+		{
+			name:    "never enabled",
+			bucket:  "bucket",
+			wantErr: true,
+		},
+		{
+			name:    "bucket name with dots",
+			bucket:  "bucket.example.com",
+			status:  s3.BucketAccelerateStatusEnabled,
+			wantErr: true,
+		},
+		{
+			name:   "configuration is not accessible",
+			bucket: "bucket",
+			err:    awserr.New("AccessDenied", "Access Denied", nil),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			sess := unit.Session.Copy()
+			sess.Handlers.Unmarshal.Clear()
+			sess.Handlers.UnmarshalMeta.Clear()
+			sess.Handlers.UnmarshalError.Clear()
+			sess.Handlers.Send.Clear()
+			sess.Handlers.Send.PushBack(func(r *request.Request) {
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+				r.Error = tc.err
+			})
+			sess.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				if output, ok := r.Data.(*s3.GetBucketAccelerateConfigurationOutput); ok && tc.status != "" {
+					output.Status = aws.String(tc.status)
+				}
+			})
+
+			err := checkBucketAccelerate(context.Background(), sess, tc.bucket)
+			if tc.wantErr != (err != nil) {
+				t.Errorf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestSessionAutoRegionValidateCredentials(t *testing.T) {
 	awsSess := unit.Session
 	awsSess.Handlers.Unmarshal.Clear()
@@ -1072,3 +1692,204 @@ func (e tempError) Error() string { return e.err.Error() }
 func (e tempError) Temporary() bool { return e.temp }
 
 func (e *tempError) Unwrap() error { return e.err }
+
+func TestS3MultiDeleteObjectLock(t *testing.T) {
+	testcases := []struct {
+		name                      string
+		bypassGovernanceRetention bool
+	}{
+		{
+			name: "without governance bypass",
+		},
+		{
+			name:                      "with governance bypass",
+			bypassGovernanceRetention: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := s3.New(unit.Session)
+			mockS3 := &S3{
+				api:                       mockApi,
+				bypassGovernanceRetention: tc.bypassGovernanceRetention,
+			}
+
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				bypass := valueAtPath(r.Params, "BypassGovernanceRetention")
+				if tc.bypassGovernanceRetention {
+					assert.Equal(t, bypass, true)
+				} else {
+					assert.Equal(t, bypass, nil)
+				}
+			})
+			mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+				*r.Data.(*s3.DeleteObjectsOutput) = s3.DeleteObjectsOutput{
+					Deleted: []*s3.DeletedObject{
+						{Key: aws.String("unlocked")},
+					},
+					Errors: []*s3.Error{
+						{
+							Key:     aws.String("locked"),
+							Code:    aws.String("AccessDenied"),
+							Message: aws.String("Access Denied because object protected by object lock."),
+						},
+						{
+							Key:     aws.String("forbidden"),
+							Code:    aws.String("AccessDenied"),
+							Message: aws.String("Access Denied"),
+						},
+					},
+				}
+			})
+
+			urlch := make(chan *url.URL, 3)
+			for _, key := range []string{"unlocked", "locked", "forbidden"} {
+				u, err := url.New("s3://bucket/" + key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				urlch <- u
+			}
+			close(urlch)
+
+			got := map[string]error{}
+			for obj := range mockS3.MultiDelete(context.Background(), urlch) {
+				got[obj.URL.Path] = obj.Err
+			}
+
+			assert.Equal(t, len(got), 3)
+			assert.Equal(t, got["unlocked"], nil)
+			assert.Equal(t, got["locked"], ErrObjectLocked)
+			assert.Error(t, got["forbidden"], "Access Denied")
+		})
+	}
+}
+
+func TestS3MultiDeleteDeletedVersions(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockS3 := &S3{api: mockApi}
+
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		*r.Data.(*s3.DeleteObjectsOutput) = s3.DeleteObjectsOutput{
+			Deleted: []*s3.DeletedObject{
+				// deleted without a version id, a delete marker is created.
+				{
+					Key:                   aws.String("hidden"),
+					DeleteMarker:          aws.Bool(true),
+					DeleteMarkerVersionId: aws.String("marker"),
+				},
+				// a specific version of the object is erased.
+				{
+					Key:       aws.String("erased"),
+					VersionId: aws.String("v1"),
+				},
+			},
+		}
+	})
+
+	urlch := make(chan *url.URL, 2)
+	for _, key := range []string{"hidden", "erased"} {
+		u, err := url.New("s3://bucket/" + key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urlch <- u
+	}
+	close(urlch)
+
+	got := map[string]*Object{}
+	for obj := range mockS3.MultiDelete(context.Background(), urlch) {
+		assert.NilError(t, obj.Err)
+		got[obj.URL.Path] = obj
+	}
+
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got["hidden"].VersionID, "")
+	assert.Equal(t, got["hidden"].DeleteMarker, true)
+	assert.Equal(t, got["hidden"].DeleteMarkerVersionID, "marker")
+	assert.Equal(t, got["erased"].VersionID, "v1")
+	assert.Equal(t, got["erased"].DeleteMarker, false)
+}
+
+func TestS3CalculateChunksWithDeleteRate(t *testing.T) {
+	testcases := []struct {
+		name           string
+		rate           float64
+		batchSize      int
+		numKeys        int
+		expectedChunks []int
+	}{
+		{name: "no rate", rate: 0, numKeys: 1500, expectedChunks: []int{1000, 500}},
+		{name: "rate lower than max chunk size", rate: 2.5, numKeys: 7, expectedChunks: []int{3, 3, 1}},
+		{name: "rate higher than max chunk size", rate: 5000, numKeys: 1200, expectedChunks: []int{1000, 200}},
+		{name: "batch size", batchSize: 100, numKeys: 250, expectedChunks: []int{100, 100, 50}},
+		{name: "batch size higher than max chunk size", batchSize: 5000, numKeys: 1200, expectedChunks: []int{1000, 200}},
+		{name: "rate lower than batch size", rate: 20, batchSize: 100, numKeys: 50, expectedChunks: []int{20, 20, 10}},
+		{name: "rate higher than batch size", rate: 500, batchSize: 100, numKeys: 150, expectedChunks: []int{100, 50}},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mockS3 := &S3{deleteRate: tc.rate, deleteBatchSize: tc.batchSize}
+
+			urlch := make(chan *url.URL)
+			go func() {
+				defer close(urlch)
+				for i := 0; i < tc.numKeys; i++ {
+					u, _ := url.New(fmt.Sprintf("s3://bucket/key%d", i))
+					urlch <- u
+				}
+			}()
+
+			var got []int
+			for chunk := range mockS3.calculateChunks(urlch) {
+				got = append(got, len(chunk.Keys))
+			}
+
+			assert.DeepEqual(t, got, tc.expectedChunks)
+		})
+	}
+}
+
+func TestS3ReadRange(t *testing.T) {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var ranges []string
+	mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+		ranges = append(ranges, aws.StringValue(r.Params.(*s3.GetObjectInput).Range))
+		r.Data.(*s3.GetObjectOutput).Body = ioutil.NopCloser(strings.NewReader("content"))
+	})
+
+	mockS3 := &S3{
+		api: mockApi,
+	}
+
+	u, err := url.New("s3://bucket/archive.tar")
+	assert.NilError(t, err)
+
+	rc, err := mockS3.ReadRange(context.Background(), u, 512, 7)
+	assert.NilError(t, err)
+	rc.Close()
+
+	// empty ranges are not requested.
+	rc, err = mockS3.ReadRange(context.Background(), u, 1024, 0)
+	assert.NilError(t, err)
+	rc.Close()
+
+	assert.DeepEqual(t, ranges, []string{"bytes=512-518"})
+}
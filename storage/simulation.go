@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// simulation counts the requests of the clients on simulations. It is nil
+// unless a simulation is enabled.
+var simulation *Simulation
+
+// Simulation counts the requests a command sends and plans to send to
+// estimate its duration and cost. The requests which are sent on dry-runs,
+// e.g. listings, are counted as they complete, and the requests which are
+// skipped on dry-runs, e.g. uploads, are counted as planned.
+type Simulation struct {
+	mu       sync.Mutex
+	requests map[string]int64
+	bytes    int64
+}
+
+// SimulationTotals are the requests and the bytes counted by a simulation.
+type SimulationTotals struct {
+	// Requests are the number of the requests of each API, e.g. PutObject.
+	Requests map[string]int64
+
+	// Bytes is the number of the bytes transferred between the client and
+	// S3. Server-side copies are not included.
+	Bytes int64
+}
+
+// EnableSimulation starts counting the requests of the clients. It must be
+// called before any client is created.
+func EnableSimulation() {
+	simulation = &Simulation{requests: map[string]int64{}}
+}
+
+// SimulationResult returns the totals of the simulation, and reports whether
+// a simulation is enabled.
+func SimulationResult() (SimulationTotals, bool) {
+	if simulation == nil {
+		return SimulationTotals{}, false
+	}
+
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+
+	requests := make(map[string]int64, len(simulation.requests))
+	for api, n := range simulation.requests {
+		requests[api] = n
+	}
+	return SimulationTotals{Requests: requests, Bytes: simulation.bytes}, true
+}
+
+// add counts n requests of the given API which transfer the given bytes.
+func (s *Simulation) add(api string, n, bytes int64) {
+	if s == nil || n == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[api] += n
+	s.bytes += bytes
+}
+
+// install counts the requests sent with the given handlers.
+func (s *Simulation) install(handlers *request.Handlers) {
+	if s == nil {
+		return
+	}
+	handlers.Complete.PushBack(func(r *request.Request) {
+		s.add(r.Operation.Name, 1, 0)
+	})
+}
+
+// PlanDownload counts the requests of downloading an object of the given
+// size in parts of the given size on simulations.
+func PlanDownload(size, partSize int64) {
+	simulation.add("GetObject", partCount(size, partSize), size)
+}
+
+// PlanUpload counts the requests of uploading an object of the given size in
+// parts of the given size on simulations. Objects which fit in a single part
+// are uploaded with PutObject.
+func PlanUpload(size, partSize int64) {
+	if size <= partSize {
+		simulation.add("PutObject", 1, size)
+		return
+	}
+
+	// the part size is increased to stay in the part count limit.
+	if size/partSize >= s3manager.MaxUploadParts {
+		partSize = size/s3manager.MaxUploadParts + 1
+	}
+
+	simulation.add("CreateMultipartUpload", 1, 0)
+	simulation.add("UploadPart", partCount(size, partSize), size)
+	simulation.add("CompleteMultipartUpload", 1, 0)
+}
+
+// PlanCopy counts the requests of copying an object of the given size on
+// simulations. Objects larger than the CopyObject size limit are copied in
+// parts.
+func PlanCopy(size int64) {
+	if size <= maxCopyObjectSize {
+		simulation.add("CopyObject", 1, 0)
+		return
+	}
+
+	simulation.add("CreateMultipartUpload", 1, 0)
+	simulation.add("UploadPartCopy", partCount(size, copyPartSize(size)), 0)
+	simulation.add("CompleteMultipartUpload", 1, 0)
+}
+
+// maxCopyObjectSize is the size limit of CopyObject.
+const maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+
+// partCount returns the number of the parts of the given size of an object.
+// Empty objects are transferred in a single request.
+func partCount(size, partSize int64) int64 {
+	if size <= 0 || partSize <= 0 {
+		return 1
+	}
+	return (size + partSize - 1) / partSize
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSimulationPlans(t *testing.T) {
+	EnableSimulation()
+	defer func() { simulation = nil }()
+
+	const mb = 1024 * 1024
+
+	PlanDownload(0, 5*mb)
+	PlanDownload(12*mb, 5*mb)
+	PlanUpload(5*mb, 5*mb)
+	PlanUpload(12*mb, 5*mb)
+	PlanCopy(mb)
+	PlanCopy(6 * 1024 * mb)
+
+	totals, ok := SimulationResult()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, totals.Requests, map[string]int64{
+		"GetObject":               4,
+		"PutObject":               1,
+		"CreateMultipartUpload":   2,
+		"UploadPart":              3,
+		"UploadPartCopy":          12,
+		"CompleteMultipartUpload": 2,
+		"CopyObject":              1,
+	})
+	assert.Equal(t, totals.Bytes, int64(29*mb))
+}
+
+func TestSimulationDisabled(t *testing.T) {
+	PlanUpload(1, 1)
+
+	_, ok := SimulationResult()
+	assert.Assert(t, !ok)
+}
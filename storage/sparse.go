@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// sparseBlockSize is the size of the blocks which are checked for zeros
+// while writing sparse files. It matches the block size of the common
+// filesystems, smaller holes wouldn't save any space.
+const sparseBlockSize = 4096
+
+// SparseWriter writes to a newly created file skipping the blocks which
+// consist of zeros, so that they are left as holes in the file and don't
+// take any space on the disk. Holes are read as zeros, the content of the
+// file is not changed.
+type SparseWriter struct {
+	file *os.File
+
+	mu   sync.Mutex
+	size int64
+}
+
+// NewSparseWriter creates a SparseWriter for the given empty file.
+func NewSparseWriter(file *os.File) *SparseWriter {
+	return &SparseWriter{file: file}
+}
+
+// WriteAt writes the non-zero blocks of p to the file at the given offset.
+// Blocks are aligned to the offsets in the file, so the holes of partial
+// blocks at the ends of p are written as well.
+func (w *SparseWriter) WriteAt(p []byte, off int64) (int, error) {
+	end := off + int64(len(p))
+
+	// trailingHole reports whether the last block of p is skipped.
+	start, trailingHole := 0, false
+	for start < len(p) {
+		blockEnd := start + sparseBlockSize - int((off+int64(start))%sparseBlockSize)
+		if blockEnd > len(p) {
+			blockEnd = len(p)
+		}
+		if isZero(p[start:blockEnd]) {
+			start, trailingHole = blockEnd, true
+			continue
+		}
+
+		// write the consecutive non-zero blocks at once.
+		dataEnd := blockEnd
+		for dataEnd < len(p) {
+			next := dataEnd + sparseBlockSize
+			if next > len(p) {
+				next = len(p)
+			}
+			if isZero(p[dataEnd:next]) {
+				break
+			}
+			dataEnd = next
+		}
+
+		if _, err := w.file.WriteAt(p[start:dataEnd], off+int64(start)); err != nil {
+			return start, err
+		}
+		start, trailingHole = dataEnd, false
+	}
+
+	if err := w.extend(end, trailingHole); err != nil {
+		return len(p), err
+	}
+	return len(p), nil
+}
+
+// extend makes sure the file is at least of the given size when the zeros at
+// the end of a write are skipped. The last byte of the skipped zeros is
+// written instead of truncating the file, since writes never shrink a file
+// which is extended by the concurrent writes meanwhile. The file is already
+// of the given size if the write ends with data.
+func (w *SparseWriter) extend(size int64, trailingHole bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if size <= w.size {
+		return nil
+	}
+	if trailingHole {
+		if _, err := w.file.WriteAt([]byte{0}, size-1); err != nil {
+			return err
+		}
+	}
+	w.size = size
+	return nil
+}
+
+// ReadAt reads the content of the file, so that the written content can be
+// verified.
+func (w *SparseWriter) ReadAt(p []byte, off int64) (int, error) {
+	return w.file.ReadAt(p, off)
+}
+
+func isZero(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// fileSegment is a region of a file which contains data.
+type fileSegment struct {
+	offset int64
+	length int64
+}
+
+// SparseReader reads a file skipping the holes in it. Holes are returned as
+// zeros without reading them from the file, which saves the reads of the
+// unallocated regions of large sparse files such as VM images and database
+// files.
+type SparseReader struct {
+	file     *os.File
+	size     int64
+	segments []fileSegment
+	offset   int64
+}
+
+// NewSparseReader creates a SparseReader for the given file. The data
+// regions of the file are found with SEEK_DATA and SEEK_HOLE where they are
+// supported, the whole file is read otherwise.
+func NewSparseReader(file *os.File) (*SparseReader, error) {
+	st, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := dataSegments(file, st.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SparseReader{
+		file:     file,
+		size:     st.Size(),
+		segments: segments,
+	}, nil
+}
+
+// ReadAt reads the data regions of the file and fills the holes with zeros.
+func (r *SparseReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+
+		// the region is read up to the end of the data segment it is in, or
+		// filled with zeros up to the start of the next data segment.
+		end, data := r.size, false
+		for _, segment := range r.segments {
+			if pos < segment.offset {
+				end = segment.offset
+				break
+			}
+			if pos < segment.offset+segment.length {
+				end, data = segment.offset+segment.length, true
+				break
+			}
+		}
+
+		chunk := p[n:]
+		if int64(len(chunk)) > end-pos {
+			chunk = chunk[:end-pos]
+		}
+
+		if !data {
+			for i := range chunk {
+				chunk[i] = 0
+			}
+			n += len(chunk)
+			continue
+		}
+
+		read, err := r.file.ReadAt(chunk, pos)
+		n += read
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Read reads the file from the current offset.
+func (r *SparseReader) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the offset of the next Read.
+func (r *SparseReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	r.offset = offset
+	return offset, nil
+}
@@ -0,0 +1,51 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// whence values of lseek to find the data regions and the holes of a file.
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+// dataSegments returns the data regions of the file of the given size.
+// Filesystems which don't support SEEK_DATA and SEEK_HOLE report the whole
+// file as data.
+func dataSegments(file *os.File, size int64) ([]fileSegment, error) {
+	defer file.Seek(0, io.SeekStart)
+
+	var segments []fileSegment
+	for offset := int64(0); offset < size; {
+		start, err := file.Seek(offset, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			// there is no data after the offset.
+			break
+		}
+		if errors.Is(err, syscall.EINVAL) {
+			return []fileSegment{{offset: 0, length: size}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := file.Seek(start, seekHole)
+		if err != nil {
+			return nil, err
+		}
+		if end > size {
+			end = size
+		}
+
+		segments = append(segments, fileSegment{offset: start, length: end - start})
+		offset = end
+	}
+	return segments, nil
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+// sparseContent returns content with a block of data between two holes and
+// zeros at the end.
+func sparseContent() []byte {
+	content := make([]byte, 5*sparseBlockSize+100)
+	copy(content[2*sparseBlockSize+10:], bytes.Repeat([]byte("data"), sparseBlockSize/4))
+	return content
+}
+
+func TestSparseWriter(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "sparse")
+	defer dir.Remove()
+
+	file, err := os.Create(dir.Join("file"))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	content := sparseContent()
+	w := NewSparseWriter(file)
+
+	// parts are written concurrently and out of order like the downloader.
+	const partSize = 3000
+	var wg sync.WaitGroup
+	for offset := 0; offset < len(content); offset += partSize {
+		end := offset + partSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		wg.Add(1)
+		go func(offset, end int) {
+			defer wg.Done()
+			n, err := w.WriteAt(content[offset:end], int64(offset))
+			assert.NilError(t, err)
+			assert.Equal(t, end-offset, n)
+		}(offset, end)
+	}
+	wg.Wait()
+
+	got, err := ioutil.ReadFile(file.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, len(content), len(got))
+	assert.Assert(t, bytes.Equal(content, got))
+}
+
+func TestSparseWriterTrailingData(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "sparse")
+	defer dir.Remove()
+
+	file, err := os.Create(dir.Join("file"))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	// every write grows the file and ends with data.
+	content := make([]byte, 3*sparseBlockSize)
+	copy(content[sparseBlockSize-4:], "data")
+	copy(content[3*sparseBlockSize-4:], "tail")
+
+	w := NewSparseWriter(file)
+	for offset := 0; offset < len(content); offset += sparseBlockSize {
+		n, err := w.WriteAt(content[offset:offset+sparseBlockSize], int64(offset))
+		assert.NilError(t, err)
+		assert.Equal(t, sparseBlockSize, n)
+	}
+
+	got, err := ioutil.ReadFile(file.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, len(content), len(got))
+	assert.Assert(t, bytes.Equal(content, got))
+}
+
+func TestSparseReader(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "sparse")
+	defer dir.Remove()
+
+	content := sparseContent()
+
+	file, err := os.Create(dir.Join("file"))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	// leave the zeros as holes.
+	assert.NilError(t, file.Truncate(int64(len(content))))
+	_, err = file.WriteAt(content[2*sparseBlockSize:3*sparseBlockSize+10], 2*sparseBlockSize)
+	assert.NilError(t, err)
+
+	r, err := NewSparseReader(file)
+	assert.NilError(t, err)
+
+	got, err := ioutil.ReadAll(r)
+	assert.NilError(t, err)
+	assert.Assert(t, bytes.Equal(content, got))
+
+	// parts are read at their offsets like the uploader.
+	part := make([]byte, 100)
+	n, err := r.ReadAt(part, 2*sparseBlockSize-50)
+	assert.NilError(t, err)
+	assert.Equal(t, 100, n)
+	assert.Assert(t, bytes.Equal(content[2*sparseBlockSize-50:2*sparseBlockSize+50], part))
+}
@@ -16,8 +16,54 @@ var (
 	// ErrGivenObjectNotFound indicates a specified object is not found.
 	ErrGivenObjectNotFound = fmt.Errorf("given object not found")
 
+	// ErrGivenBucketNotFound indicates a specified bucket is not found.
+	ErrGivenBucketNotFound = fmt.Errorf("given bucket not found")
+
 	// ErrNoObjectFound indicates there are no objects found from a given directory.
 	ErrNoObjectFound = fmt.Errorf("no object found")
+
+	// ErrObjectLocked indicates a specified object is protected by an object
+	// lock retention period or legal hold.
+	ErrObjectLocked = fmt.Errorf("object is locked")
+
+	// ErrRestoreInProgress indicates the restoration of an archived object
+	// is requested already and it is not completed yet.
+	ErrRestoreInProgress = fmt.Errorf("restore is already in progress")
+
+	// ErrObjectNotArchived indicates a restoration is requested for an object
+	// which is not in an archive storage class.
+	ErrObjectNotArchived = fmt.Errorf("object is not archived")
+
+	// ErrNoLifecycleConfiguration indicates the bucket has no lifecycle
+	// configuration.
+	ErrNoLifecycleConfiguration = fmt.Errorf("no lifecycle configuration")
+
+	// ErrNoBucketPolicy indicates the bucket has no policy.
+	ErrNoBucketPolicy = fmt.Errorf("no bucket policy")
+
+	// ErrObjectExists indicates a conditional write is rejected since the
+	// object already exists.
+	ErrObjectExists = fmt.Errorf("object already exists")
+
+	// ErrChecksumMismatch indicates the checksum of the transferred content
+	// doesn't match the checksum of the object.
+	ErrChecksumMismatch = fmt.Errorf("checksum mismatch")
+
+	// ErrCopyMismatch indicates the server-side copy of an object doesn't
+	// match the source object.
+	ErrCopyMismatch = fmt.Errorf("copy mismatch")
+
+	// ErrCopyNotVerified indicates the server-side copy of an object can't
+	// be compared with the source object.
+	ErrCopyNotVerified = fmt.Errorf("copy can not be verified")
+
+	// ErrResumableListNotSupported indicates the listing can't be resumed
+	// since the storage doesn't support ListObjectsV2 API.
+	ErrResumableListNotSupported = fmt.Errorf("resumable listing requires ListObjectsV2 API")
+
+	// ErrVolatileSource indicates the content of the uploaded file keeps
+	// changing while it is read.
+	ErrVolatileSource = fmt.Errorf("source changed while being read")
 )
 
 // Storage is an interface for storage operations that is common
@@ -43,24 +89,53 @@ type Storage interface {
 }
 
 func NewLocalClient(opts Options) *Filesystem {
-	return &Filesystem{dryRun: opts.DryRun}
+	return &Filesystem{
+		dryRun:           opts.DryRun,
+		groupByDirectory: opts.GroupByDirectory,
+	}
 }
 
 func NewRemoteClient(ctx context.Context, url *url.URL, opts Options) (*S3, error) {
 	newOpts := Options{
-		MaxRetries:       opts.MaxRetries,
-		Endpoint:         opts.Endpoint,
-		NoVerifySSL:      opts.NoVerifySSL,
-		DryRun:           opts.DryRun,
-		NoSignRequest:    opts.NoSignRequest,
-		UseListObjectsV1: opts.UseListObjectsV1,
-		RequestPayer:     opts.RequestPayer,
-		bucket:           url.Bucket,
-		region:           opts.region,
+		MaxRetries:                opts.MaxRetries,
+		Endpoint:                  opts.Endpoint,
+		NoVerifySSL:               opts.NoVerifySSL,
+		DryRun:                    opts.DryRun,
+		NoSignRequest:             opts.NoSignRequest,
+		UseListObjectsV1:          opts.UseListObjectsV1,
+		UseAccelerate:             opts.UseAccelerate,
+		RequestPayer:              opts.RequestPayer,
+		BypassGovernanceRetention: opts.BypassGovernanceRetention,
+		ChecksumAlgorithm:         opts.ChecksumAlgorithm,
+		DeleteRate:                opts.DeleteRate,
+		DeleteBatchSize:           opts.DeleteBatchSize,
+		SSECustomerKey:            opts.SSECustomerKey,
+		RetryMaxDelay:             opts.RetryMaxDelay,
+		RetryPolicy:               opts.RetryPolicy,
+		KeyShardLength:            opts.KeyShardLength,
+		StreamingSignature:        opts.StreamingSignature,
+		Preconnect:                opts.Preconnect,
+		Lister:                    opts.Lister,
+		bucket:                    bucketOf(url),
+		region:                    opts.region,
 	}
 	return newS3Storage(ctx, newOpts)
 }
 
+// bucketOf returns the bucket the region of the client is detected with. The
+// first of the buckets given with brace groups is used, and no bucket if the
+// buckets have to be listed to be matched. The other buckets matched by the
+// wildcard are listed with the clients of their own regions.
+func bucketOf(u *url.URL) string {
+	if !u.IsBucketWildcard() {
+		return u.Bucket
+	}
+	if names, ok := u.Buckets(); ok {
+		return names[0]
+	}
+	return ""
+}
+
 func NewClient(ctx context.Context, url *url.URL, opts Options) (Storage, error) {
 	if url.IsRemote() {
 		return NewRemoteClient(ctx, url, opts)
@@ -70,15 +145,29 @@ func NewClient(ctx context.Context, url *url.URL, opts Options) (Storage, error)
 
 // Options stores configuration for storage.
 type Options struct {
-	MaxRetries       int
-	Endpoint         string
-	NoVerifySSL      bool
-	DryRun           bool
-	NoSignRequest    bool
-	UseListObjectsV1 bool
-	RequestPayer     string
-	bucket           string
-	region           string
+	MaxRetries                int
+	Endpoint                  string
+	NoVerifySSL               bool
+	DryRun                    bool
+	NoSignRequest             bool
+	UseListObjectsV1          bool
+	UseAccelerate             bool
+	RequestPayer              string
+	BypassGovernanceRetention bool
+	ChecksumAlgorithm         ChecksumAlgorithm
+	DeleteRate                float64
+	DeleteBatchSize           int
+	GroupByDirectory          bool
+	RetryMaxDelay             time.Duration
+	RetryPolicy               string
+	KeyShardLength            int
+	SSECustomerKey            string
+	StreamingSignature        bool
+	IOUring                   bool
+	Preconnect                int
+	Lister                    Lister
+	bucket                    string
+	region                    string
 }
 
 func (o *Options) SetRegion(region string) {
@@ -93,7 +182,27 @@ type Object struct {
 	Type         ObjectType   `json:"type,omitempty"`
 	Size         int64        `json:"size,omitempty"`
 	StorageClass StorageClass `json:"storage_class,omitempty"`
+	VersionID    string       `json:"version_id,omitempty"`
+	DeleteMarker bool         `json:"delete_marker,omitempty"`
 	Err          error        `json:"error,omitempty"`
+
+	// IsLatest is only set by the listings of all of the versions.
+	IsLatest bool `json:"is_latest,omitempty"`
+
+	// DeleteMarkerVersionID is only set by deletions. It is the version id
+	// of the delete marker which is removed or created by the deletion.
+	DeleteMarkerVersionID string `json:"delete_marker_version_id,omitempty"`
+
+	// ContentEncoding and Restore are only set by Stat of remote objects.
+	// Restore is the value of x-amz-restore header, see ParseRestoreStatus.
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	Restore         string `json:"restore,omitempty"`
+
+	// Tags are only set if they are asked while listing.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Metadata is only set if it is asked while listing.
+	Metadata *ObjectMetadata `json:"metadata,omitempty"`
 }
 
 // String returns the string representation of Object.
@@ -185,6 +294,12 @@ func (s StorageClass) IsGlacier() bool {
 	return s == "GLACIER"
 }
 
+// IsArchived checks if the objects of the storage class must be restored
+// before they are accessed.
+func (s StorageClass) IsArchived() bool {
+	return s == "GLACIER" || s == "DEEP_ARCHIVE"
+}
+
 // notImplemented is a structure which is used on the unsupported operations.
 type notImplemented struct {
 	apiType string
@@ -221,6 +336,66 @@ func (m Metadata) SetCacheControl(cacheControl string) Metadata {
 	return m
 }
 
+func (m Metadata) ContentDisposition() string {
+	return m["ContentDisposition"]
+}
+
+func (m Metadata) SetContentDisposition(contentDisposition string) Metadata {
+	m["ContentDisposition"] = contentDisposition
+	return m
+}
+
+func (m Metadata) ContentLanguage() string {
+	return m["ContentLanguage"]
+}
+
+func (m Metadata) SetContentLanguage(contentLanguage string) Metadata {
+	m["ContentLanguage"] = contentLanguage
+	return m
+}
+
+// WebsiteRedirectLocation returns the URL which the requests to the object
+// are redirected to by S3 static website hosting.
+func (m Metadata) WebsiteRedirectLocation() string {
+	return m["WebsiteRedirectLocation"]
+}
+
+func (m Metadata) SetWebsiteRedirectLocation(location string) Metadata {
+	m["WebsiteRedirectLocation"] = location
+	return m
+}
+
+func (m Metadata) ContentEncoding() string {
+	return m["ContentEncoding"]
+}
+
+func (m Metadata) SetContentEncoding(contentEncoding string) Metadata {
+	m["ContentEncoding"] = contentEncoding
+	return m
+}
+
+func (m Metadata) IfNoneMatch() string {
+	return m["IfNoneMatch"]
+}
+
+// SetIfNoneMatch makes the upload conditional. "*" rejects the upload with
+// ErrObjectExists if the object already exists.
+func (m Metadata) SetIfNoneMatch(ifNoneMatch string) Metadata {
+	m["IfNoneMatch"] = ifNoneMatch
+	return m
+}
+
+// Tagging returns the URL encoded tags of the object.
+func (m Metadata) Tagging() string {
+	return m["Tagging"]
+}
+
+// SetTagging sets the URL encoded tags of the object, see EncodeTags.
+func (m Metadata) SetTagging(tagging string) Metadata {
+	m["Tagging"] = tagging
+	return m
+}
+
 func (m Metadata) Expires() string {
 	return m["Expires"]
 }
@@ -248,6 +423,30 @@ func (m Metadata) SetContentType(contentType string) Metadata {
 	return m
 }
 
+// MetadataDirective specifies whether the metadata of the source object is
+// copied or replaced with the metadata given in the request on server-side
+// copies.
+type MetadataDirective string
+
+const (
+	MetadataDirectiveCopy    = MetadataDirective("COPY")
+	MetadataDirectiveReplace = MetadataDirective("REPLACE")
+)
+
+// IsValid checks if the metadata directive is supported.
+func (d MetadataDirective) IsValid() bool {
+	return d == MetadataDirectiveCopy || d == MetadataDirectiveReplace
+}
+
+func (m Metadata) Directive() MetadataDirective {
+	return MetadataDirective(m["Directive"])
+}
+
+func (m Metadata) SetDirective(directive MetadataDirective) Metadata {
+	m["Directive"] = string(directive)
+	return m
+}
+
 func (m Metadata) SSE() string {
 	return m["EncryptionMethod"]
 }
@@ -265,3 +464,17 @@ func (m Metadata) SetSSEKeyID(kid string) Metadata {
 	m["EncryptionKeyID"] = kid
 	return m
 }
+
+// BucketKeyEnabled reports whether S3 Bucket Key is used for SSE-KMS.
+func (m Metadata) BucketKeyEnabled() bool {
+	return m["BucketKeyEnabled"] == "true"
+}
+
+func (m Metadata) SetBucketKeyEnabled(enabled bool) Metadata {
+	if enabled {
+		m["BucketKeyEnabled"] = "true"
+	} else {
+		delete(m, "BucketKeyEnabled")
+	}
+	return m
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/log/stat"
+)
+
+// maxStreamRecoveries is the number of times an interrupted response body
+// of a GET request is resumed before failing.
+const maxStreamRecoveries = 5
+
+// streamRecoveryOp is the operation the recoveries are counted under in the
+// statistics.
+const streamRecoveryOp = "download-recovery"
+
+// recoverStreamOption returns the request option which resumes the response
+// body of a GET request from the last received offset with a ranged GET if
+// the connection breaks, instead of restarting the object from zero.
+func (s *S3) recoverStreamOption() request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Unmarshal.PushBack(s.recoverStream)
+	}
+}
+
+func (s *S3) recoverStream(r *request.Request) {
+	if r.Error != nil {
+		return
+	}
+
+	input, ok := r.Params.(*s3.GetObjectInput)
+	if !ok {
+		return
+	}
+	output, ok := r.Data.(*s3.GetObjectOutput)
+	if !ok || output.Body == nil {
+		return
+	}
+
+	start, end, ok := responseRange(output)
+	if !ok {
+		return
+	}
+
+	output.Body = &recoveringBody{
+		ctx:    r.Context(),
+		api:    s.api,
+		input:  input,
+		etag:   aws.StringValue(output.ETag),
+		body:   output.Body,
+		offset: start,
+		end:    end,
+	}
+}
+
+// responseRange returns the offsets of the first and the last bytes of the
+// object in the response.
+func responseRange(output *s3.GetObjectOutput) (int64, int64, bool) {
+	if contentRange := aws.StringValue(output.ContentRange); contentRange != "" {
+		// e.g. "bytes 0-1023/4096"
+		fields := strings.FieldsFunc(contentRange, func(r rune) bool {
+			return r == ' ' || r == '-' || r == '/'
+		})
+		if len(fields) != 4 {
+			return 0, 0, false
+		}
+		start, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		end, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		return start, end, true
+	}
+
+	length := aws.Int64Value(output.ContentLength)
+	if length <= 0 {
+		return 0, 0, false
+	}
+	return 0, length - 1, true
+}
+
+// recoveringBody is a response body which resumes reading from the last
+// received offset if it is interrupted. The remaining bytes are requested
+// with the ETag of the object, so that the parts of different versions are
+// not mixed if the object is overwritten meanwhile.
+type recoveringBody struct {
+	ctx   context.Context
+	api   s3iface.S3API
+	input *s3.GetObjectInput
+	etag  string
+	body  io.ReadCloser
+
+	// offset is the offset of the next byte to read, end is the offset of
+	// the last byte of the response.
+	offset int64
+	end    int64
+
+	recoveries int
+}
+
+func (b *recoveringBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.offset += int64(n)
+	if err == nil || err == io.EOF || !b.recoverable(err) {
+		return n, err
+	}
+
+	if rerr := b.recover(err); rerr != nil {
+		return n, rerr
+	}
+
+	// the bytes read before the error are returned, the rest is read from
+	// the resumed body on the next read.
+	if n > 0 {
+		return n, nil
+	}
+	return b.Read(p)
+}
+
+func (b *recoveringBody) Close() error {
+	return b.body.Close()
+}
+
+func (b *recoveringBody) recoverable(err error) bool {
+	if b.ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return b.offset <= b.end && b.recoveries < maxStreamRecoveries
+}
+
+// recover requests the remaining bytes of the response. The reason of the
+// interruption is returned if they can't be requested.
+func (b *recoveringBody) recover(reason error) (err error) {
+	defer stat.Collect(streamRecoveryOp, &err)()
+
+	b.recoveries++
+	b.body.Close()
+
+	in := &s3.GetObjectInput{}
+	awsutil.Copy(in, b.input)
+	in.Range = aws.String(fmt.Sprintf("bytes=%d-%d", b.offset, b.end))
+	if b.etag != "" {
+		in.IfMatch = aws.String(b.etag)
+	}
+
+	log.Debug(log.DebugMessage{
+		Err: fmt.Sprintf(
+			"resuming download of s3://%v/%v from offset %d: %v",
+			aws.StringValue(in.Bucket), aws.StringValue(in.Key), b.offset, reason,
+		),
+	})
+
+	resp, err := b.api.GetObjectWithContext(b.ctx, in)
+	if err != nil {
+		if errHasCode(err, "PreconditionFailed") {
+			return fmt.Errorf("object is changed while being read: %w", reason)
+		}
+		return reason
+	}
+
+	b.body = resp.Body
+	return nil
+}
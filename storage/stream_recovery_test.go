@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+	"github.com/peak/s5cmd/storage/url"
+)
+
+var errConnectionReset = errors.New("read: connection reset by peer")
+
+// brokenReader returns the error after reading n bytes of r.
+type brokenReader struct {
+	r io.Reader
+	n int
+}
+
+func (b *brokenReader) Read(p []byte) (int, error) {
+	if b.n == 0 {
+		return 0, errConnectionReset
+	}
+	if len(p) > b.n {
+		p = p[:b.n]
+	}
+	n, err := b.r.Read(p)
+	b.n -= n
+	return n, err
+}
+
+func TestS3ReadRecoversInterruptedStream(t *testing.T) {
+	log.Init("error", false)
+
+	const content = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	// newMockS3 returns a client whose responses break after the given number
+	// of bytes, one entry per request. Negative values don't break.
+	newMockS3 := func(breakAfter []int, retryErr error) (*S3, *[]*s3.GetObjectInput) {
+		mockApi := s3.New(unit.Session)
+		mockApi.Handlers.Send.Clear()
+		mockApi.Handlers.Unmarshal.Clear()
+		mockApi.Handlers.UnmarshalMeta.Clear()
+		mockApi.Handlers.ValidateResponse.Clear()
+
+		var inputs []*s3.GetObjectInput
+		mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+			input := r.Params.(*s3.GetObjectInput)
+			inputs = append(inputs, input)
+			if len(inputs) > 1 && retryErr != nil {
+				r.Error = retryErr
+			}
+		})
+		mockApi.Handlers.Unmarshal.PushBack(func(r *request.Request) {
+			output := r.Data.(*s3.GetObjectOutput)
+
+			start, end := 0, len(content)-1
+			if rng := r.Params.(*s3.GetObjectInput).Range; rng != nil {
+				fmt.Sscanf(*rng, "bytes=%d-%d", &start, &end)
+				output.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+			}
+
+			var body io.Reader = strings.NewReader(content[start : end+1])
+			if i := len(inputs) - 1; i < len(breakAfter) && breakAfter[i] >= 0 {
+				body = &brokenReader{r: body, n: breakAfter[i]}
+			}
+
+			output.Body = ioutil.NopCloser(body)
+			output.ContentLength = aws.Int64(int64(end - start + 1))
+			output.ETag = aws.String(`"etag"`)
+		})
+
+		return &S3{api: mockApi}, &inputs
+	}
+
+	u, err := url.New("s3://bucket/object")
+	assert.NilError(t, err)
+
+	t.Run("resumes from the last received offset", func(t *testing.T) {
+		mockS3, inputs := newMockS3([]int{10, 5}, nil)
+
+		rc, err := mockS3.Read(context.Background(), u)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		got, err := ioutil.ReadAll(rc)
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), content)
+
+		assert.Equal(t, len(*inputs), 3)
+		assert.Equal(t, aws.StringValue((*inputs)[1].Range), "bytes=10-35")
+		assert.Equal(t, aws.StringValue((*inputs)[2].Range), "bytes=15-35")
+		for _, input := range (*inputs)[1:] {
+			assert.Equal(t, aws.StringValue(input.IfMatch), `"etag"`)
+			assert.Equal(t, aws.StringValue(input.Key), "object")
+		}
+	})
+
+	t.Run("resumes a ranged read within its range", func(t *testing.T) {
+		mockS3, inputs := newMockS3([]int{4}, nil)
+
+		rc, err := mockS3.ReadRange(context.Background(), u, 10, 10)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		got, err := ioutil.ReadAll(rc)
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), content[10:20])
+
+		assert.Equal(t, len(*inputs), 2)
+		assert.Equal(t, aws.StringValue((*inputs)[1].Range), "bytes=14-19")
+	})
+
+	t.Run("fails if the object is changed", func(t *testing.T) {
+		preconditionFailed := awserr.New("PreconditionFailed", "At least one of the pre-conditions you specified did not hold", nil)
+		mockS3, _ := newMockS3([]int{10}, preconditionFailed)
+
+		rc, err := mockS3.Read(context.Background(), u)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		_, err = ioutil.ReadAll(rc)
+		assert.ErrorContains(t, err, "object is changed while being read")
+		assert.Assert(t, errors.Is(err, errConnectionReset))
+	})
+
+	t.Run("fails after too many recoveries", func(t *testing.T) {
+		breakAfter := make([]int, maxStreamRecoveries+1)
+		mockS3, inputs := newMockS3(breakAfter, nil)
+
+		rc, err := mockS3.Read(context.Background(), u)
+		assert.NilError(t, err)
+		defer rc.Close()
+
+		_, err = ioutil.ReadAll(rc)
+		assert.Assert(t, errors.Is(err, errConnectionReset))
+		assert.Equal(t, len(*inputs), maxStreamRecoveries+1)
+	})
+}
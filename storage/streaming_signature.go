@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// streamingPayload is the payload hash of the requests whose bodies are
+	// signed chunk by chunk.
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+	// streamingChunkSize is the size of the chunks of the streaming signed
+	// request bodies.
+	streamingChunkSize = 64 * 1024
+
+	// emptySHA256 is the hex encoded SHA256 hash of an empty string.
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// streamingSignatureOption returns a request option which signs the bodies
+// of upload requests with SigV4 streaming signatures. The bodies are sent
+// with aws-chunked encoding and each chunk is signed as it is sent, instead
+// of hashing the whole body before the request is signed.
+func streamingSignatureOption() request.Option {
+	return func(r *request.Request) {
+		if r.Operation.Name != "PutObject" && r.Operation.Name != "UploadPart" {
+			return
+		}
+		// anonymous requests are not signed.
+		if r.Config.Credentials == credentials.AnonymousCredentials {
+			return
+		}
+
+		// the SDK hashes the bodies of uploads unless their hashes are
+		// given. The chunk signatures protect the integrity of the body, so
+		// its MD5 digest isn't calculated either.
+		r.Config.S3DisableContentMD5Validation = aws.Bool(true)
+		r.Handlers.Build.PushFront(func(r *request.Request) {
+			r.HTTPRequest.Header.Set("X-Amz-Content-Sha256", streamingPayload)
+		})
+
+		// the headers must be set before the request is signed, and the
+		// body is encoded with the signature of the request.
+		r.Handlers.Sign.PushFront(prepareStreamingSignature)
+		r.Handlers.Sign.PushBack(encodeStreamingBody)
+	}
+}
+
+func prepareStreamingSignature(r *request.Request) {
+	header := r.HTTPRequest.Header
+
+	// the decoded length is kept in the headers on retries, while the
+	// content length is the length of the encoded body.
+	var length int64
+	if decoded := header.Get("X-Amz-Decoded-Content-Length"); decoded != "" {
+		length, _ = strconv.ParseInt(decoded, 10, 64)
+	} else if r.Body != nil {
+		var err error
+		length, err = aws.SeekerLen(r.Body)
+		if err != nil {
+			r.Error = err
+			return
+		}
+	}
+	// empty bodies are signed as usual.
+	if length <= 0 {
+		header.Del("X-Amz-Content-Sha256")
+		return
+	}
+
+	header.Set("X-Amz-Content-Sha256", streamingPayload)
+	header.Set("X-Amz-Decoded-Content-Length", strconv.FormatInt(length, 10))
+	header.Set("Content-Length", strconv.FormatInt(streamingContentLength(length, streamingChunkSize), 10))
+
+	switch encoding := header.Get("Content-Encoding"); {
+	case encoding == "":
+		header.Set("Content-Encoding", "aws-chunked")
+	case !strings.HasPrefix(encoding, "aws-chunked"):
+		header.Set("Content-Encoding", "aws-chunked,"+encoding)
+	}
+}
+
+func encodeStreamingBody(r *request.Request) {
+	if r.Error != nil || r.HTTPRequest.Header.Get("X-Amz-Content-Sha256") != streamingPayload {
+		return
+	}
+
+	seed, scope, err := parseAuthorization(r.HTTPRequest.Header.Get("Authorization"))
+	if err != nil {
+		r.Error = err
+		return
+	}
+
+	creds, err := r.Config.Credentials.Get()
+	if err != nil {
+		r.Error = err
+		return
+	}
+
+	// the request is signed again if its signature expires before it is
+	// sent, the body is encoded with the new signature then.
+	body := r.HTTPRequest.Body
+	if encoded, ok := body.(*chunkedBody); ok {
+		body = encoded.body
+	}
+
+	r.HTTPRequest.Body = newChunkedBody(
+		body,
+		streamingSigningKey(creds.SecretAccessKey, scope),
+		r.HTTPRequest.Header.Get("X-Amz-Date"),
+		scope,
+		seed,
+		streamingChunkSize,
+	)
+	r.HTTPRequest.GetBody = nil
+}
+
+// parseAuthorization returns the signature and the credential scope of the
+// SigV4 Authorization header, e.g. "20130524/us-east-1/s3/aws4_request".
+func parseAuthorization(authorization string) (string, string, error) {
+	var signature, scope string
+	for _, field := range strings.Split(authorization, ",") {
+		field = strings.TrimSpace(field)
+		field = strings.TrimPrefix(field, "AWS4-HMAC-SHA256 ")
+		switch {
+		case strings.HasPrefix(field, "Credential="):
+			credential := strings.TrimPrefix(field, "Credential=")
+			if i := strings.Index(credential, "/"); i >= 0 {
+				scope = credential[i+1:]
+			}
+		case strings.HasPrefix(field, "Signature="):
+			signature = strings.TrimPrefix(field, "Signature=")
+		}
+	}
+
+	if signature == "" || strings.Count(scope, "/") != 3 {
+		return "", "", fmt.Errorf("request is not signed with SigV4")
+	}
+	return signature, scope, nil
+}
+
+// streamingSigningKey derives the SigV4 signing key of the credential scope.
+func streamingSigningKey(secret, scope string) []byte {
+	key := []byte("AWS4" + secret)
+	for _, part := range strings.Split(scope, "/") {
+		key = hmacSHA256(key, part)
+	}
+	return key
+}
+
+// streamingContentLength returns the length of the aws-chunked encoding of
+// a body of the given length.
+func streamingContentLength(length, chunkSize int64) int64 {
+	chunkLength := func(size int64) int64 {
+		// <hex size>;chunk-signature=<64 hex digits>\r\n<data>\r\n
+		return int64(len(strconv.FormatInt(size, 16))) + int64(len(";chunk-signature=")) + 64 + 2 + size + 2
+	}
+
+	total := (length / chunkSize) * chunkLength(chunkSize)
+	if rem := length % chunkSize; rem > 0 {
+		total += chunkLength(rem)
+	}
+	// the body ends with an empty chunk.
+	return total + chunkLength(0)
+}
+
+// chunkedBody encodes the body with aws-chunked encoding, signing each chunk
+// with the signature of the previous chunk, starting from the signature of
+// the request.
+type chunkedBody struct {
+	body       io.ReadCloser
+	signingKey []byte
+	timestamp  string
+	scope      string
+	signature  string
+
+	chunk   []byte
+	encoded bytes.Buffer
+	done    bool
+}
+
+func newChunkedBody(body io.ReadCloser, signingKey []byte, timestamp, scope, seed string, chunkSize int) *chunkedBody {
+	return &chunkedBody{
+		body:       body,
+		signingKey: signingKey,
+		timestamp:  timestamp,
+		scope:      scope,
+		signature:  seed,
+		chunk:      make([]byte, chunkSize),
+	}
+}
+
+func (b *chunkedBody) Read(p []byte) (int, error) {
+	for b.encoded.Len() == 0 {
+		if b.done {
+			return 0, io.EOF
+		}
+
+		n, err := io.ReadFull(b.body, b.chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		if n > 0 {
+			b.writeChunk(b.chunk[:n])
+		}
+		if err != nil {
+			b.writeChunk(nil)
+			b.done = true
+		}
+	}
+	return b.encoded.Read(p)
+}
+
+func (b *chunkedBody) Close() error {
+	return b.body.Close()
+}
+
+func (b *chunkedBody) writeChunk(data []byte) {
+	hash := sha256.Sum256(data)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-PAYLOAD",
+		b.timestamp,
+		b.scope,
+		b.signature,
+		emptySHA256,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+	b.signature = hex.EncodeToString(hmacSHA256(b.signingKey, stringToSign))
+
+	fmt.Fprintf(&b.encoded, "%x;chunk-signature=%s\r\n", len(data), b.signature)
+	b.encoded.Write(data)
+	b.encoded.WriteString("\r\n")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+)
+
+// the example of "Signature Calculations for the Authorization Header:
+// Transferring Payload in Multiple Chunks" of the S3 documentation.
+func TestChunkedBodySignatures(t *testing.T) {
+	t.Parallel()
+
+	const (
+		secret    = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+		timestamp = "20130524T000000Z"
+		scope     = "20130524/us-east-1/s3/aws4_request"
+		seed      = "4f232c4386841ef735655705268965c44a0e4690baa4adea153f7db9fa80a0a9"
+	)
+
+	content := bytes.Repeat([]byte("a"), 66560)
+	body := newChunkedBody(
+		ioutil.NopCloser(bytes.NewReader(content)),
+		streamingSigningKey(secret, scope),
+		timestamp,
+		scope,
+		seed,
+		64*1024,
+	)
+
+	encoded, err := ioutil.ReadAll(body)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(len(encoded)), streamingContentLength(int64(len(content)), 64*1024))
+	assert.Equal(t, len(encoded), 66824)
+
+	chunks := decodeChunks(t, encoded)
+	assert.Equal(t, len(chunks), 3)
+
+	expected := []struct {
+		size      int
+		signature string
+	}{
+		{size: 65536, signature: "ad80c730a21e5b8d04586a2213dd63b9a0e99e0e2307b0ade35a65485a288648"},
+		{size: 1024, signature: "0055627c9e194cb4542bae2aa5492e3c1575bbb81b612b7d234b86a503ef5497"},
+		{size: 0, signature: "b6c6ea8a5354eaf15b3cb7646744f4275b71ea724fed81ceb9323e279d449df9"},
+	}
+	for i, chunk := range chunks {
+		assert.Equal(t, len(chunk.data), expected[i].size)
+		assert.Equal(t, chunk.signature, expected[i].signature)
+	}
+}
+
+func TestS3PutObjectStreamingSignature(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var attempts int
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		attempts++
+
+		header := r.HTTPRequest.Header
+		assert.Equal(t, header.Get("X-Amz-Content-Sha256"), streamingPayload)
+		assert.Equal(t, header.Get("X-Amz-Decoded-Content-Length"), strconv.Itoa(len(content)))
+		assert.Equal(t, header.Get("Content-Encoding"), "aws-chunked,gzip")
+		assert.Equal(t, header.Get("Content-MD5"), "")
+		assert.Assert(t, strings.Contains(header.Get("Authorization"), "x-amz-decoded-content-length"))
+
+		encoded, err := ioutil.ReadAll(r.HTTPRequest.Body)
+		assert.NilError(t, err)
+		assert.Equal(t, int64(len(encoded)), r.HTTPRequest.ContentLength)
+
+		var decoded []byte
+		for _, chunk := range decodeChunks(t, encoded) {
+			decoded = append(decoded, chunk.data...)
+		}
+		assert.DeepEqual(t, decoded, content)
+
+		// the body is encoded again on retries.
+		if attempts == 1 {
+			r.Error = awserr.New("RequestTimeout", "request timeout", nil)
+			r.Retryable = aws.Bool(true)
+		}
+	})
+
+	_, err := mockApi.PutObjectWithContext(context.Background(), &s3.PutObjectInput{
+		Bucket:          aws.String("bucket"),
+		Key:             aws.String("key"),
+		Body:            bytes.NewReader(content),
+		ContentEncoding: aws.String("gzip"),
+	}, streamingSignatureOption())
+	assert.NilError(t, err)
+	assert.Equal(t, attempts, 2)
+}
+
+func TestS3PutEmptyObjectStreamingSignature(t *testing.T) {
+	t.Parallel()
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		header := r.HTTPRequest.Header
+		assert.Equal(t, header.Get("X-Amz-Content-Sha256"), emptySHA256)
+		assert.Equal(t, header.Get("Content-Encoding"), "")
+		assert.Equal(t, header.Get("X-Amz-Decoded-Content-Length"), "")
+	})
+
+	_, err := mockApi.PutObjectWithContext(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+		Body:   bytes.NewReader(nil),
+	}, streamingSignatureOption())
+	assert.NilError(t, err)
+}
+
+type testChunk struct {
+	signature string
+	data      []byte
+}
+
+// decodeChunks decodes the aws-chunked encoded body.
+func decodeChunks(t *testing.T, encoded []byte) []testChunk {
+	t.Helper()
+
+	var chunks []testChunk
+	reader := bufio.NewReader(bytes.NewReader(encoded))
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return chunks
+		}
+		assert.NilError(t, err)
+
+		fields := strings.SplitN(strings.TrimSuffix(line, "\r\n"), ";chunk-signature=", 2)
+		assert.Equal(t, len(fields), 2, "invalid chunk header %q", line)
+
+		size, err := strconv.ParseInt(fields[0], 16, 64)
+		assert.NilError(t, err)
+
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(reader, data)
+		assert.NilError(t, err)
+		assert.Equal(t, string(data[size:]), "\r\n")
+
+		chunks = append(chunks, testChunk{signature: fields[1], data: data[:size]})
+	}
+}
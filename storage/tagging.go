@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	urlpkg "net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// EncodeTags returns the URL encoded form of the tags which is expected by
+// the tagging header of S3 requests. Tags are sorted by their keys.
+func EncodeTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, escapeTag(key)+"="+escapeTag(tags[key]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapeTag escapes the tag key or value, encoding spaces as %20 rather
+// than +.
+func escapeTag(s string) string {
+	return strings.Replace(urlpkg.QueryEscape(s), "+", "%20", -1)
+}
+
+// Tags returns the tags of the given object.
+func (s *S3) Tags(ctx context.Context, src *url.URL) (map[string]string, error) {
+	output, err := s.api.GetObjectTaggingWithContext(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(src.Bucket),
+		Key:    aws.String(s.objectKey(src.Path)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags, nil
+}
+
+// SetTags replaces the tags of the given object with the given tags.
+func (s *S3) SetTags(ctx context.Context, dst *url.URL, tags map[string]string) error {
+	if s.dryRun {
+		simulation.add("PutObjectTagging", 1, 0)
+		return nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tagSet := make([]*s3.Tag, 0, len(keys))
+	for _, key := range keys {
+		tagSet = append(tagSet, &s3.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(tags[key]),
+		})
+	}
+
+	_, err := s.api.PutObjectTaggingWithContext(ctx, &s3.PutObjectTaggingInput{
+		Bucket:       aws.String(dst.Bucket),
+		Key:          aws.String(s.objectKey(dst.Path)),
+		Tagging:      &s3.Tagging{TagSet: tagSet},
+		RequestPayer: s.RequestPayer(),
+	})
+	return err
+}
+
+// DeleteTags removes all of the tags of the given object.
+func (s *S3) DeleteTags(ctx context.Context, dst *url.URL) error {
+	if s.dryRun {
+		simulation.add("DeleteObjectTagging", 1, 0)
+		return nil
+	}
+
+	_, err := s.api.DeleteObjectTaggingWithContext(ctx, &s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(dst.Bucket),
+		Key:    aws.String(s.objectKey(dst.Path)),
+	})
+	return err
+}
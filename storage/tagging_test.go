@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+func TestEncodeTags(t *testing.T) {
+	tags := map[string]string{
+		"team":        "data platform",
+		"cost-center": "42",
+		"path":        "a/b&c=d",
+	}
+
+	assert.Equal(t, EncodeTags(tags), "cost-center=42&path=a%2Fb%26c%3Dd&team=data%20platform")
+	assert.Equal(t, EncodeTags(nil), "")
+}
+
+func TestS3SetTags(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var tagSet []*s3.Tag
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input := r.Params.(*s3.PutObjectTaggingInput)
+		assert.Equal(t, aws.StringValue(input.Bucket), "bucket")
+		assert.Equal(t, aws.StringValue(input.Key), "key")
+		tagSet = input.Tagging.TagSet
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.SetTags(context.Background(), u, map[string]string{"team": "data", "env": "prod"})
+	assert.NilError(t, err)
+
+	// tags are sent in the order of their keys.
+	assert.DeepEqual(t, []*s3.Tag{
+		{Key: aws.String("env"), Value: aws.String("prod")},
+		{Key: aws.String("team"), Value: aws.String("data")},
+	}, tagSet)
+}
+
+func TestS3DeleteTags(t *testing.T) {
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+
+	var input *s3.DeleteObjectTaggingInput
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		input = r.Params.(*s3.DeleteObjectTaggingInput)
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	err = mockS3.DeleteTags(context.Background(), u)
+	assert.NilError(t, err)
+	assert.Equal(t, aws.StringValue(input.Bucket), "bucket")
+	assert.Equal(t, aws.StringValue(input.Key), "key")
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// minPartSize is the minimum size of the parts of a multipart upload.
+const minPartSize = 5 * 1024 * 1024
+
+// TransferConfig holds the S3 transfer settings of the AWS shared config
+// file, which are used by awscli to tune the transfers.
+//
+//	[default]
+//	s3 =
+//	  max_concurrent_requests = 20
+//	  multipart_threshold = 64MB
+//	  multipart_chunksize = 16MB
+type TransferConfig struct {
+	MaxConcurrentRequests int
+	MultipartThreshold    int64
+	MultipartChunkSize    int64
+}
+
+// LoadTransferConfig reads the S3 transfer settings of the current profile
+// from the AWS shared config file, if it is enabled with AWS_SDK_LOAD_CONFIG
+// environment variable. Zero values are returned for the settings that are
+// not set, or if the shared config is not enabled.
+func LoadTransferConfig() (TransferConfig, error) {
+	if enable, _ := strconv.ParseBool(os.Getenv("AWS_SDK_LOAD_CONFIG")); !enable {
+		return TransferConfig{}, nil
+	}
+
+	path := os.Getenv("AWS_CONFIG_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return TransferConfig{}, nil
+		}
+		path = filepath.Join(home, ".aws", "config")
+	}
+
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = os.Getenv("AWS_DEFAULT_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return TransferConfig{}, nil
+	}
+	if err != nil {
+		return TransferConfig{}, err
+	}
+	defer f.Close()
+
+	settings, err := readS3Settings(f, profile)
+	if err != nil {
+		return TransferConfig{}, fmt.Errorf("%v: %w", path, err)
+	}
+
+	cfg, err := newTransferConfig(settings)
+	if err != nil {
+		return TransferConfig{}, fmt.Errorf("%v: profile %q: %w", path, profile, err)
+	}
+	return cfg, nil
+}
+
+// readS3Settings returns the nested "s3" settings of the given profile in
+// the shared config file.
+func readS3Settings(r io.Reader, profile string) (map[string]string, error) {
+	var (
+		settings  = map[string]string{}
+		inProfile bool
+		inS3      bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			section = strings.TrimSpace(strings.TrimPrefix(section, "profile "))
+			inProfile = section == profile
+			inS3 = false
+			continue
+		}
+
+		if !inProfile {
+			continue
+		}
+
+		key, value := splitSetting(trimmed)
+		// nested settings are indented under their parent setting.
+		isNested := line[0] == ' ' || line[0] == '\t'
+		if !isNested {
+			inS3 = key == "s3" && value == ""
+			continue
+		}
+
+		if inS3 {
+			settings[key] = value
+		}
+	}
+	return settings, scanner.Err()
+}
+
+func splitSetting(line string) (string, string) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return strings.TrimSpace(line), ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
+func newTransferConfig(settings map[string]string) (TransferConfig, error) {
+	var cfg TransferConfig
+
+	if value, ok := settings["max_concurrent_requests"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return TransferConfig{}, fmt.Errorf("invalid max_concurrent_requests %q", value)
+		}
+		cfg.MaxConcurrentRequests = n
+	}
+
+	if value, ok := settings["multipart_threshold"]; ok {
+		size, err := parseTransferSize(value)
+		if err != nil {
+			return TransferConfig{}, fmt.Errorf("invalid multipart_threshold %q", value)
+		}
+		cfg.MultipartThreshold = size
+	}
+
+	if value, ok := settings["multipart_chunksize"]; ok {
+		size, err := parseTransferSize(value)
+		if err != nil || size < minPartSize {
+			return TransferConfig{}, fmt.Errorf("invalid multipart_chunksize %q, must be at least 5MB", value)
+		}
+		cfg.MultipartChunkSize = size
+	}
+	return cfg, nil
+}
+
+// parseTransferSize parses sizes in the format awscli accepts, such as
+// "8388608", "8MB" or "8MiB". Units are powers of 1024.
+func parseTransferSize(s string) (int64, error) {
+	suffixes := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+		{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40},
+	}
+
+	multiplier := int64(1)
+	for _, unit := range suffixes {
+		if strings.HasSuffix(strings.ToUpper(s), strings.ToUpper(unit.suffix)) {
+			s = s[:len(s)-len(unit.suffix)]
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("size must be positive")
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const sharedConfig = `
+[default]
+region = us-east-1
+s3 =
+  max_concurrent_requests = 20
+  multipart_threshold = 64MB
+  multipart_chunksize = 16MiB
+
+# other profiles are not read
+[profile tuned]
+s3 =
+	multipart_chunksize = 8388608
+s3api =
+  max_concurrent_requests = 5
+output = json
+`
+
+func TestReadS3Settings(t *testing.T) {
+	testcases := []struct {
+		profile  string
+		expected map[string]string
+	}{
+		{
+			profile: "default",
+			expected: map[string]string{
+				"max_concurrent_requests": "20",
+				"multipart_threshold":     "64MB",
+				"multipart_chunksize":     "16MiB",
+			},
+		},
+		{
+			profile: "tuned",
+			expected: map[string]string{
+				"multipart_chunksize": "8388608",
+			},
+		},
+		{
+			profile:  "missing",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.profile, func(t *testing.T) {
+			settings, err := readS3Settings(strings.NewReader(sharedConfig), tc.profile)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, settings, tc.expected)
+		})
+	}
+}
+
+func TestNewTransferConfig(t *testing.T) {
+	cfg, err := newTransferConfig(map[string]string{
+		"max_concurrent_requests": "20",
+		"multipart_threshold":     "64MB",
+		"multipart_chunksize":     "16MiB",
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cfg, TransferConfig{
+		MaxConcurrentRequests: 20,
+		MultipartThreshold:    64 * 1024 * 1024,
+		MultipartChunkSize:    16 * 1024 * 1024,
+	})
+
+	for _, settings := range []map[string]string{
+		{"max_concurrent_requests": "0"},
+		{"multipart_threshold": "a lot"},
+		{"multipart_chunksize": "1MB"},
+	} {
+		_, err := newTransferConfig(settings)
+		assert.Assert(t, err != nil, "%v", settings)
+	}
+}
+
+func TestParseTransferSize(t *testing.T) {
+	testcases := map[string]int64{
+		"8388608": 8388608,
+		"10KB":    10 * 1024,
+		"8MB":     8 * 1024 * 1024,
+		"8mb":     8 * 1024 * 1024,
+		"8MiB":    8 * 1024 * 1024,
+		"1GB":     1024 * 1024 * 1024,
+		"1TiB":    1024 * 1024 * 1024 * 1024,
+	}
+
+	for input, expected := range testcases {
+		size, err := parseTransferSize(input)
+		assert.NilError(t, err, input)
+		assert.Equal(t, size, expected, input)
+	}
+
+	for _, input := range []string{"", "MB", "-1MB", "1.5MB"} {
+		_, err := parseTransferSize(input)
+		assert.Assert(t, err != nil, input)
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/peak/s5cmd/log"
+)
+
+// errIOUringUnsupported indicates that io_uring or its operations are not
+// available, e.g. on the kernels older than 5.6 or in the containers which
+// don't allow it.
+var errIOUringUnsupported = errors.New("io_uring is not supported")
+
+var (
+	sharedRingOnce sync.Once
+	sharedRing     *ioRing
+
+	// ringDisabled is set if the operations of the ring fail as
+	// unsupported, the files are read and written with the regular system
+	// calls afterwards.
+	ringDisabled int32
+)
+
+// ringOf returns the io_uring instance shared by the transfers of the
+// process. It is set up on the first call, and a warning is printed once if
+// it is not available.
+func ringOf() *ioRing {
+	sharedRingOnce.Do(func() {
+		ring, err := newIORing()
+		if err != nil {
+			warnIOUringFallback(err)
+			return
+		}
+		sharedRing = ring
+	})
+	if sharedRing == nil || atomic.LoadInt32(&ringDisabled) == 1 {
+		return nil
+	}
+	return sharedRing
+}
+
+// disableRing stops using the ring after its operations fail as unsupported.
+func disableRing(err error) {
+	if atomic.CompareAndSwapInt32(&ringDisabled, 0, 1) {
+		warnIOUringFallback(err)
+	}
+}
+
+func warnIOUringFallback(err error) {
+	msg := log.WarningMessage{
+		Warning: fmt.Sprintf("io_uring is not available, local files are read and written with the regular system calls: %v", err),
+	}
+	log.Warning(msg)
+}
+
+// ringFile reads and writes a local file with the shared io_uring instance,
+// which reduces the system call overhead of the parts read and written
+// concurrently. The other operations are made by the file itself.
+type ringFile struct {
+	*os.File
+	ring *ioRing
+}
+
+// ringFileOf returns the ring file of the given file, or nil if io_uring is
+// not enabled or not available.
+func (s *S3) ringFileOf(file *os.File) *ringFile {
+	if !s.ioUring {
+		return nil
+	}
+	ring := ringOf()
+	if ring == nil {
+		return nil
+	}
+	return &ringFile{File: file, ring: ring}
+}
+
+// ReadAt implements io.ReaderAt. It reads until the buffer is full, since
+// the reads of the ring may be short.
+func (f *ringFile) ReadAt(p []byte, off int64) (int, error) {
+	if atomic.LoadInt32(&ringDisabled) == 1 {
+		return f.File.ReadAt(p, off)
+	}
+
+	var n int
+	for n < len(p) {
+		m, err := f.ring.readAt(f.Fd(), p[n:], off+int64(n))
+		runtime.KeepAlive(f.File)
+		if errors.Is(err, errIOUringUnsupported) {
+			disableRing(err)
+			m, err = f.File.ReadAt(p[n:], off+int64(n))
+			return n + m, err
+		}
+		if err != nil {
+			return n, &os.PathError{Op: "read", Path: f.Name(), Err: err}
+		}
+		if m == 0 {
+			return n, io.EOF
+		}
+		n += m
+	}
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt.
+func (f *ringFile) WriteAt(p []byte, off int64) (int, error) {
+	if atomic.LoadInt32(&ringDisabled) == 1 {
+		return f.File.WriteAt(p, off)
+	}
+
+	var n int
+	for n < len(p) {
+		m, err := f.ring.writeAt(f.Fd(), p[n:], off+int64(n))
+		runtime.KeepAlive(f.File)
+		if errors.Is(err, errIOUringUnsupported) {
+			disableRing(err)
+			m, err = f.File.WriteAt(p[n:], off+int64(n))
+			return n + m, err
+		}
+		if err != nil {
+			return n, &os.PathError{Op: "write", Path: f.Name(), Err: err}
+		}
+		if m == 0 {
+			return n, io.ErrShortWrite
+		}
+		n += m
+	}
+	return n, nil
+}
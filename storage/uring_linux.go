@@ -0,0 +1,375 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package storage
+
+import (
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// The system calls and the structures of io_uring, see
+// include/uapi/linux/io_uring.h. The system call numbers are the same on
+// amd64 and arm64.
+const (
+	sysIOUringSetup = 425
+	sysIOUringEnter = 426
+
+	ioringOpRead  = 22
+	ioringOpWrite = 23
+
+	ioringEnterGetEvents = 1 << 0
+	ioringFeatSingleMmap = 1 << 0
+
+	ioringOffSQRing = 0
+	ioringOffCQRing = 0x8000000
+	ioringOffSQEs   = 0x10000000
+)
+
+type ioSQRingOffsets struct {
+	head        uint32
+	tail        uint32
+	ringMask    uint32
+	ringEntries uint32
+	flags       uint32
+	dropped     uint32
+	array       uint32
+	resv1       uint32
+	userAddr    uint64
+}
+
+type ioCQRingOffsets struct {
+	head        uint32
+	tail        uint32
+	ringMask    uint32
+	ringEntries uint32
+	overflow    uint32
+	cqes        uint32
+	flags       uint32
+	resv1       uint32
+	userAddr    uint64
+}
+
+type ioUringParams struct {
+	sqEntries    uint32
+	cqEntries    uint32
+	flags        uint32
+	sqThreadCPU  uint32
+	sqThreadIdle uint32
+	features     uint32
+	wqFd         uint32
+	resv         [3]uint32
+	sqOff        ioSQRingOffsets
+	cqOff        ioCQRingOffsets
+}
+
+type ioUringSQE struct {
+	opcode   uint8
+	flags    uint8
+	ioprio   uint16
+	fd       int32
+	off      uint64
+	addr     uint64
+	len      uint32
+	rwFlags  uint32
+	userData uint64
+	pad      [3]uint64
+}
+
+type ioUringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// ringEntries is the number of the submission queue entries of the ring,
+// which is the maximum number of the reads and writes in flight.
+const ringEntries = 256
+
+// maxRingRequestSize is the maximum number of the bytes read or written by a
+// single request.
+const maxRingRequestSize = 1 << 30
+
+// brokenRingPollInterval is the interval the completions of the requests
+// submitted before the ring broke are polled at.
+const brokenRingPollInterval = time.Millisecond
+
+// ioRing is an io_uring instance. The reads and writes of the goroutines are
+// submitted by a single goroutine, so the requests made at the same time are
+// submitted and reaped with a single system call.
+type ioRing struct {
+	fd int
+
+	sqRing     []byte
+	cqRing     []byte
+	sqeMem     []byte
+	singleMmap bool
+	sqHead     *uint32
+	sqTail     *uint32
+	sqMask     uint32
+	sqArray    unsafe.Pointer
+	cqHead     *uint32
+	cqTail     *uint32
+	cqMask     uint32
+	cqes       unsafe.Pointer
+
+	entries  uint32
+	requests chan *ringRequest
+}
+
+// ringRequest is a read or a write waiting for its completion.
+type ringRequest struct {
+	opcode uint8
+	fd     int32
+	buf    []byte
+	off    int64
+	// sqTail is the tail of the submission queue when the request is
+	// queued, the request is submitted once the head passes it.
+	sqTail uint32
+
+	res  int32
+	err  error
+	done chan struct{}
+}
+
+// newIORing sets up an io_uring instance.
+func newIORing() (*ioRing, error) {
+	var params ioUringParams
+	fd, _, errno := syscall.Syscall(sysIOUringSetup, ringEntries, uintptr(unsafe.Pointer(&params)), 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("io_uring_setup: %w", errno)
+	}
+
+	r := &ioRing{
+		fd:       int(fd),
+		entries:  params.sqEntries,
+		requests: make(chan *ringRequest, params.sqEntries),
+	}
+	if err := r.mmap(&params); err != nil {
+		r.close()
+		return nil, err
+	}
+
+	go r.run()
+	return r, nil
+}
+
+// mmap maps the submission and the completion queues of the ring.
+func (r *ioRing) mmap(params *ioUringParams) error {
+	const (
+		prot  = syscall.PROT_READ | syscall.PROT_WRITE
+		flags = syscall.MAP_SHARED | syscall.MAP_POPULATE
+	)
+
+	sqSize := int(params.sqOff.array + params.sqEntries*4)
+	cqSize := int(params.cqOff.cqes + params.cqEntries*uint32(unsafe.Sizeof(ioUringCQE{})))
+	r.singleMmap = params.features&ioringFeatSingleMmap != 0
+	if r.singleMmap && cqSize > sqSize {
+		sqSize = cqSize
+	}
+
+	var err error
+	r.sqRing, err = syscall.Mmap(r.fd, ioringOffSQRing, sqSize, prot, flags)
+	if err != nil {
+		return fmt.Errorf("io_uring mmap: %w", err)
+	}
+	if r.singleMmap {
+		r.cqRing = r.sqRing
+	} else {
+		r.cqRing, err = syscall.Mmap(r.fd, ioringOffCQRing, cqSize, prot, flags)
+		if err != nil {
+			return fmt.Errorf("io_uring mmap: %w", err)
+		}
+	}
+	r.sqeMem, err = syscall.Mmap(r.fd, ioringOffSQEs, int(params.sqEntries)*int(unsafe.Sizeof(ioUringSQE{})), prot, flags)
+	if err != nil {
+		return fmt.Errorf("io_uring mmap: %w", err)
+	}
+
+	r.sqHead = (*uint32)(unsafe.Pointer(&r.sqRing[params.sqOff.head]))
+	r.sqTail = (*uint32)(unsafe.Pointer(&r.sqRing[params.sqOff.tail]))
+	r.sqMask = *(*uint32)(unsafe.Pointer(&r.sqRing[params.sqOff.ringMask]))
+	r.sqArray = unsafe.Pointer(&r.sqRing[params.sqOff.array])
+	r.cqHead = (*uint32)(unsafe.Pointer(&r.cqRing[params.cqOff.head]))
+	r.cqTail = (*uint32)(unsafe.Pointer(&r.cqRing[params.cqOff.tail]))
+	r.cqMask = *(*uint32)(unsafe.Pointer(&r.cqRing[params.cqOff.ringMask]))
+	r.cqes = unsafe.Pointer(&r.cqRing[params.cqOff.cqes])
+	return nil
+}
+
+func (r *ioRing) close() {
+	if r.sqeMem != nil {
+		_ = syscall.Munmap(r.sqeMem)
+	}
+	if r.cqRing != nil && !r.singleMmap {
+		_ = syscall.Munmap(r.cqRing)
+	}
+	if r.sqRing != nil {
+		_ = syscall.Munmap(r.sqRing)
+	}
+	_ = syscall.Close(r.fd)
+}
+
+// readAt reads from the file with the given descriptor at the given offset.
+// It returns the result of the read system call.
+func (r *ioRing) readAt(fd uintptr, p []byte, off int64) (int, error) {
+	return r.do(ioringOpRead, fd, p, off)
+}
+
+// writeAt writes to the file with the given descriptor at the given offset.
+// It returns the result of the write system call.
+func (r *ioRing) writeAt(fd uintptr, p []byte, off int64) (int, error) {
+	return r.do(ioringOpWrite, fd, p, off)
+}
+
+func (r *ioRing) do(opcode uint8, fd uintptr, p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// the length of a request is 32-bit, the rest is read or written by
+	// the following requests.
+	if len(p) > maxRingRequestSize {
+		p = p[:maxRingRequestSize]
+	}
+
+	req := &ringRequest{
+		opcode: opcode,
+		fd:     int32(fd),
+		buf:    p,
+		off:    off,
+		done:   make(chan struct{}),
+	}
+	r.requests <- req
+	<-req.done
+
+	if req.err != nil {
+		return 0, req.err
+	}
+	if req.res < 0 {
+		errno := syscall.Errno(-req.res)
+		// the kernels older than 5.6 have io_uring without these operations.
+		if errno == syscall.EINVAL || errno == syscall.EOPNOTSUPP {
+			return 0, errIOUringUnsupported
+		}
+		return 0, errno
+	}
+	return int(req.res), nil
+}
+
+// run submits the requests and completes them as their results are reaped.
+func (r *ioRing) run() {
+	inflight := map[uint64]*ringRequest{}
+	var nextID uint64
+
+	// the ring is not used anymore after an unexpected error, since the
+	// queued requests may be submitted after they are given up.
+	var broken error
+
+	fail := func(req *ringRequest) {
+		req.err = broken
+		close(req.done)
+	}
+
+	push := func(req *ringRequest) {
+		nextID++
+		inflight[nextID] = req
+
+		tail := *r.sqTail
+		req.sqTail = tail
+		index := tail & r.sqMask
+		sqe := (*ioUringSQE)(unsafe.Pointer(uintptr(unsafe.Pointer(&r.sqeMem[0])) + uintptr(index)*unsafe.Sizeof(ioUringSQE{})))
+		*sqe = ioUringSQE{
+			opcode:   req.opcode,
+			fd:       req.fd,
+			off:      uint64(req.off),
+			addr:     uint64(uintptr(unsafe.Pointer(&req.buf[0]))),
+			len:      uint32(len(req.buf)),
+			userData: nextID,
+		}
+		*(*uint32)(unsafe.Pointer(uintptr(r.sqArray) + uintptr(index)*4)) = index
+		atomic.StoreUint32(r.sqTail, tail+1)
+	}
+
+	// reap completes the requests whose completions are posted, and reports
+	// whether there are any.
+	reap := func() bool {
+		head := atomic.LoadUint32(r.cqHead)
+		tail := atomic.LoadUint32(r.cqTail)
+		reaped := head != tail
+		for ; head != tail; head++ {
+			cqe := (*ioUringCQE)(unsafe.Pointer(uintptr(r.cqes) + uintptr(head&r.cqMask)*unsafe.Sizeof(ioUringCQE{})))
+			if req, ok := inflight[cqe.userData]; ok {
+				delete(inflight, cqe.userData)
+				req.res = cqe.res
+				close(req.done)
+			}
+		}
+		atomic.StoreUint32(r.cqHead, head)
+		return reaped
+	}
+
+	for {
+		if broken != nil {
+			if len(inflight) == 0 {
+				fail(<-r.requests)
+				continue
+			}
+
+			select {
+			case req := <-r.requests:
+				fail(req)
+				continue
+			default:
+			}
+
+			// the kernel may still read or write the buffers of the
+			// submitted requests, so they are not handed back to the
+			// callers until their completions are reaped.
+			if !reap() {
+				_, _, errno := syscall.Syscall6(sysIOUringEnter, uintptr(r.fd), 0, 1, ioringEnterGetEvents, 0, 0)
+				if errno != 0 {
+					time.Sleep(brokenRingPollInterval)
+				}
+			}
+			continue
+		}
+
+		// wait for a request if there is nothing to complete.
+		if len(inflight) == 0 {
+			push(<-r.requests)
+		}
+
+		// the requests made in the meantime are submitted together.
+	gather:
+		for uint32(len(inflight)) < r.entries {
+			select {
+			case req := <-r.requests:
+				push(req)
+			default:
+				break gather
+			}
+		}
+
+		toSubmit := atomic.LoadUint32(r.sqTail) - atomic.LoadUint32(r.sqHead)
+		_, _, errno := syscall.Syscall6(sysIOUringEnter, uintptr(r.fd), uintptr(toSubmit), 1, ioringEnterGetEvents, 0, 0)
+		if errno != 0 && errno != syscall.EINTR && errno != syscall.EAGAIN && errno != syscall.EBUSY {
+			broken = fmt.Errorf("%w: io_uring_enter: %v", errIOUringUnsupported, errno)
+
+			// the requests which are not consumed by the kernel are never
+			// submitted, the others are completed once they are reaped.
+			head := atomic.LoadUint32(r.sqHead)
+			for id, req := range inflight {
+				if int32(req.sqTail-head) >= 0 {
+					delete(inflight, id)
+					fail(req)
+				}
+			}
+		}
+
+		reap()
+	}
+}
@@ -0,0 +1,43 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package storage
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestIORingBroken(t *testing.T) {
+	r, err := newIORing()
+	if err != nil {
+		t.Skip("io_uring is not available")
+	}
+	ringFd := r.fd
+	defer func() {
+		r.fd = ringFd
+		r.close()
+	}()
+
+	file, err := ioutil.TempFile("", "s5cmd-uring")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// io_uring_enter fails on a descriptor which is not a ring, the request
+	// is not submitted and fails.
+	r.fd = int(file.Fd())
+
+	buf := make([]byte, 16)
+	_, err = r.readAt(file.Fd(), buf, 0)
+	assert.Assert(t, errors.Is(err, errIOUringUnsupported), err)
+
+	// the ring is not used anymore.
+	_, err = r.writeAt(file.Fd(), buf, 0)
+	assert.Assert(t, errors.Is(err, errIOUringUnsupported), err)
+}
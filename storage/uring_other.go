@@ -0,0 +1,20 @@
+//go:build !linux || (!amd64 && !arm64)
+// +build !linux !amd64,!arm64
+
+package storage
+
+// ioRing is not available, local files are read and written with the
+// regular system calls on the other platforms.
+type ioRing struct{}
+
+func newIORing() (*ioRing, error) {
+	return nil, errIOUringUnsupported
+}
+
+func (r *ioRing) readAt(_ uintptr, _ []byte, _ int64) (int, error) {
+	return 0, errIOUringUnsupported
+}
+
+func (r *ioRing) writeAt(_ uintptr, _ []byte, _ int64) (int, error) {
+	return 0, errIOUringUnsupported
+}
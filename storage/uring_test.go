@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/log"
+)
+
+func TestRingFileReadWriteAt(t *testing.T) {
+	log.Init("error", false)
+
+	dir, err := ioutil.TempDir("", "s5cmd-uring")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	file, err := os.Create(filepath.Join(dir, "file"))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	s := &S3{ioUring: true}
+	ring := s.ringFileOf(file)
+	if ring == nil {
+		t.Skip("io_uring is not available")
+	}
+
+	const (
+		partSize = 64 * 1024
+		parts    = 32
+	)
+	content := make([]byte, partSize*parts)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	// the parts are written and read concurrently, as the transfers do.
+	var wg sync.WaitGroup
+	for i := 0; i < parts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			off := int64(i * partSize)
+			n, err := ring.WriteAt(content[off:off+partSize], off)
+			assert.NilError(t, err)
+			assert.Equal(t, n, partSize)
+		}(i)
+	}
+	wg.Wait()
+
+	got := make([]byte, len(content))
+	for i := 0; i < parts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			off := int64(i * partSize)
+			n, err := ring.ReadAt(got[off:off+partSize], off)
+			assert.NilError(t, err)
+			assert.Equal(t, n, partSize)
+		}(i)
+	}
+	wg.Wait()
+	assert.Assert(t, bytes.Equal(got, content))
+
+	// the reads past the end of the file are short.
+	buf := make([]byte, partSize)
+	n, err := ring.ReadAt(buf, int64(len(content)-10))
+	assert.Equal(t, err, io.EOF)
+	assert.Equal(t, n, 10)
+}
+
+func TestRingFileDisabled(t *testing.T) {
+	file, err := ioutil.TempFile("", "s5cmd-uring")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	s := &S3{}
+	assert.Assert(t, s.ringFileOf(file) == nil)
+}
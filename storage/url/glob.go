@@ -0,0 +1,335 @@
+package url
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxBraceExpansions is the maximum number of the patterns a brace
+// expression is expanded to.
+const maxBraceExpansions = 1024
+
+// expandBraces returns the patterns of the alternatives of the brace groups
+// of the given pattern, e.g. "a{b,c}d" is expanded to "abd" and "acd". Brace
+// groups can be nested. A '{' without a matching '}' or a group without a
+// comma is literal, as in shells.
+func expandBraces(s string) ([]string, error) {
+	var (
+		patterns []string
+		seen     = map[string]bool{}
+	)
+
+	var expand func(s string) error
+	expand = func(s string) error {
+		start, end, alternatives := findBraceGroup(s)
+		if start < 0 {
+			if !seen[s] {
+				seen[s] = true
+				patterns = append(patterns, s)
+			}
+			if len(patterns) > maxBraceExpansions {
+				return fmt.Errorf("pattern %q expands to more than %d patterns", s, maxBraceExpansions)
+			}
+			return nil
+		}
+
+		for _, alternative := range alternatives {
+			if err := expand(s[:start] + alternative + s[end+1:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := expand(s); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// findBraceGroup returns the indices of the braces and the alternatives of
+// the first brace group of the given pattern. The start index is negative if
+// there is none.
+func findBraceGroup(s string) (start, end int, alternatives []string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' {
+			continue
+		}
+
+		depth := 0
+		last := i + 1
+		var alts []string
+		for j := i + 1; j < len(s); j++ {
+			switch s[j] {
+			case '{':
+				depth++
+			case ',':
+				if depth == 0 {
+					alts = append(alts, s[last:j])
+					last = j + 1
+				}
+			case '}':
+				if depth > 0 {
+					depth--
+					continue
+				}
+				if len(alts) == 0 {
+					// a group without a comma is literal.
+					j = len(s)
+					continue
+				}
+				return i, j, append(alts, s[last:j])
+			}
+		}
+	}
+	return -1, -1, nil
+}
+
+// classEnd returns the index of the ']' closing the character class which
+// starts at the given index, or -1 if the '[' is literal. A ']' right after
+// the '[' or the negation is a member of the class.
+func classEnd(s string, start int) int {
+	i := start + 1
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		i++
+	}
+	if i < len(s) && s[i] == ']' {
+		i++
+	}
+	end := strings.IndexByte(s[i:], ']')
+	if end < 0 {
+		return -1
+	}
+	return i + end
+}
+
+// globIndex returns the index of the first wildcard of the given pattern,
+// which is a '*', a '?', a character class or a brace group, or -1 if the
+// pattern has no wildcards.
+func globIndex(s string) int {
+	brace, _, _ := findBraceGroup(s)
+	for i := 0; i < len(s); i++ {
+		if brace == i {
+			return i
+		}
+		switch s[i] {
+		case '*', '?':
+			return i
+		case '[':
+			if classEnd(s, i) > 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// globRegex returns the regular expression of the given pattern without
+// brace groups.
+func globRegex(s string) string {
+	var (
+		b       strings.Builder
+		literal strings.Builder
+	)
+	flush := func() {
+		b.WriteString(regexp.QuoteMeta(literal.String()))
+		literal.Reset()
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*':
+			flush()
+			b.WriteString(".*?")
+		case '?':
+			flush()
+			b.WriteString(".")
+		case '[':
+			end := classEnd(s, i)
+			if end < 0 {
+				literal.WriteByte(c)
+				continue
+			}
+			flush()
+			b.WriteString(classRegex(s[i+1 : end]))
+			i = end
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// classRegex returns the regular expression of the character class with the
+// given members, e.g. "!a-z".
+func classRegex(members string) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	if strings.HasPrefix(members, "!") || strings.HasPrefix(members, "^") {
+		b.WriteByte('^')
+		members = members[1:]
+	}
+	for i := 0; i < len(members); i++ {
+		switch c := members[i]; c {
+		case '\\', '[', ']', '^':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// fnmatchPattern returns the given pattern without brace groups in the
+// syntax of filepath.Match, which negates the character classes with '^'
+// only.
+func fnmatchPattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '[' && i+1 < len(s) && s[i+1] == '!' && classEnd(s, i) > 0 {
+			b.WriteString("[^")
+			i++
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// compileGlob returns the regular expression matching the given patterns.
+func compileGlob(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 1 {
+		return regexp.Compile("^" + globRegex(patterns[0]) + "$")
+	}
+
+	regexes := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		regexes = append(regexes, globRegex(pattern))
+	}
+	return regexp.Compile("^(?:" + strings.Join(regexes, "|") + ")$")
+}
+
+// literalPrefixes returns the literal prefixes of the given patterns up to
+// their first wildcard. The prefixes which start with another one are left
+// out, since their keys are listed under the shorter one.
+func literalPrefixes(patterns []string) []string {
+	prefixes := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if i := globIndex(pattern); i >= 0 {
+			pattern = pattern[:i]
+		}
+		prefixes = append(prefixes, pattern)
+	}
+	return uniquePrefixes(prefixes)
+}
+
+// uniquePrefixes returns the sorted prefixes which don't start with another
+// one of the given prefixes.
+func uniquePrefixes(prefixes []string) []string {
+	sort.Strings(prefixes)
+
+	var result []string
+	for _, prefix := range prefixes {
+		if len(result) > 0 && strings.HasPrefix(prefix, result[len(result)-1]) {
+			continue
+		}
+		result = append(result, prefix)
+	}
+	return result
+}
+
+// SegmentGlob matches the path segments of a wildcard pattern up to the last
+// '/' before its first '*', e.g. "logs/app-?/" of "logs/app-?/*.gz". Each of
+// its wildcards matches a single character, so it tells whether any key
+// under a common prefix can match the pattern, without listing the keys.
+type SegmentGlob struct {
+	// Prefix is the literal prefix of the pattern, which the listing
+	// starts with.
+	Prefix string
+
+	// tokens are the regular expressions matching each of the characters
+	// of the segments.
+	tokens  []string
+	regexes map[int]*regexp.Regexp
+}
+
+// newSegmentGlob returns the segment glob of the given pattern without brace
+// groups. It reports false if the pattern has no wildcards before its first
+// '*' and a '/' after them. The glob has no segments then, and all of the
+// keys under its prefix are listed.
+func newSegmentGlob(s string) (*SegmentGlob, bool) {
+	var (
+		tokens []string
+		end    int  // number of tokens up to the last '/'
+		wild   bool // whether a wildcard precedes the last '/'
+		seen   bool // whether a wildcard is seen
+	)
+
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case '*':
+			i = len(s)
+			continue
+		case '?':
+			tokens = append(tokens, ".")
+			seen = true
+			i++
+			continue
+		case '[':
+			if classEnd := classEnd(s, i); classEnd > 0 {
+				tokens = append(tokens, classRegex(s[i+1:classEnd]))
+				seen = true
+				i = classEnd + 1
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		tokens = append(tokens, regexp.QuoteMeta(string(r)))
+		if r == '/' {
+			end, wild = len(tokens), seen
+		}
+		i += size
+	}
+
+	if !wild {
+		end = 0
+	}
+
+	prefix := s
+	if i := globIndex(s); i >= 0 {
+		prefix = s[:i]
+	}
+	return &SegmentGlob{
+		Prefix:  prefix,
+		tokens:  tokens[:end],
+		regexes: map[int]*regexp.Regexp{},
+	}, wild
+}
+
+// Match reports whether the keys under the given common prefix can match the
+// pattern, and whether the prefix covers all of the segments of the glob, so
+// that the keys under it are listed without a delimiter.
+func (g *SegmentGlob) Match(prefix string) (ok, complete bool) {
+	runes := []rune(prefix)
+	n := len(runes)
+	if n > len(g.tokens) {
+		n = len(g.tokens)
+	}
+
+	r, found := g.regexes[n]
+	if !found {
+		r = regexp.MustCompile("^" + strings.Join(g.tokens[:n], "") + "$")
+		g.regexes[n] = r
+	}
+	if !r.MatchString(string(runes[:n])) {
+		return false, false
+	}
+	return true, len(runes) >= len(g.tokens)
+}
@@ -0,0 +1,104 @@
+package url
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"a{b,c}d", []string{"abd", "acd"}},
+		{"{a,b}{1,2}", []string{"a1", "a2", "b1", "b2"}},
+		{"a{b,{c,d}}", []string{"ab", "ac", "ad"}},
+		{"a{,b}", []string{"a", "ab"}},
+		{"a{b,b}", []string{"ab"}},
+		{"{a}", []string{"{a}"}},
+		{"a{b,c", []string{"a{b,c"}},
+		{"x}{y", []string{"x}{y"}},
+	}
+	for _, tc := range tests {
+		got, err := expandBraces(tc.input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v for input %s", err, tc.input)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("%s: expandBraces() mismatch (-want +got):\n%v", tc.input, diff)
+		}
+	}
+}
+
+func TestExpandBracesLimit(t *testing.T) {
+	// 4^6 patterns.
+	pattern := "{a,b,c,d}{a,b,c,d}{a,b,c,d}{a,b,c,d}{a,b,c,d}{a,b,c,d}"
+	if _, err := expandBraces(pattern); err == nil {
+		t.Errorf("expected error for too many patterns")
+	}
+}
+
+func TestGlobRegex(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"a/*.txt", `a/.*?\.txt`},
+		{"??", `..`},
+		{"[0-9]", `[0-9]`},
+		{"[!a-c]", `[^a-c]`},
+		{"[]a]", `[\]a]`},
+		{"a[b", `a\[b`},
+	}
+	for _, tc := range tests {
+		if got := globRegex(tc.input); got != tc.want {
+			t.Errorf("globRegex(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestFnmatchPattern(t *testing.T) {
+	if got := fnmatchPattern("dir/[!a]*[!"); got != "dir/[^a]*[!" {
+		t.Errorf("unexpected pattern %q", got)
+	}
+}
+
+func TestSegmentGlob(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		prefix       string
+		wantOK       bool
+		wantComplete bool
+	}{
+		{"logs/app-?/*.gz", "logs/", true, false},
+		{"logs/app-?/*.gz", "logs/app-1/", true, true},
+		{"logs/app-?/*.gz", "logs/app-12/", false, false},
+		{"logs/app-?/*.gz", "logs/web-1/", false, false},
+		{"[0-9][0-9]/??/x", "12/", true, false},
+		{"[0-9][0-9]/??/x", "1a/", false, false},
+		// '?' matches the delimiter too, so 1/ can be followed by 2/.
+		{"??/x", "1/", true, false},
+		{"??/x", "1//", true, true},
+		{"??/x", "1/2/", false, false},
+		{"a-ç?/x", "a-çb/", true, true},
+	}
+	for _, tc := range tests {
+		glob, ok := newSegmentGlob(tc.pattern)
+		if !ok {
+			t.Fatalf("%s: expected segment glob", tc.pattern)
+		}
+		gotOK, gotComplete := glob.Match(tc.prefix)
+		if gotOK != tc.wantOK || gotComplete != tc.wantComplete {
+			t.Errorf("%s: Match(%q) = %v, %v, want %v, %v",
+				tc.pattern, tc.prefix, gotOK, gotComplete, tc.wantOK, tc.wantComplete)
+		}
+	}
+
+	// the wildcards after the first '*' can't exclude any common prefix.
+	for _, pattern := range []string{"app-*/??/x", "logs/*", "logs/a?"} {
+		if _, ok := newSegmentGlob(pattern); ok {
+			t.Errorf("%s: unexpected segment glob", pattern)
+		}
+	}
+}
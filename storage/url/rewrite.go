@@ -0,0 +1,89 @@
+package url
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// RewriteRule maps the URLs starting with a logical prefix, e.g.
+// store://dataset/, to the concrete URLs of a storage provider.
+type RewriteRule struct {
+	From string
+	To   string
+}
+
+// rewriteRules are the rules applied to the URLs given to New. They are set
+// once before any URL is created and only read afterwards.
+var rewriteRules []RewriteRule
+
+// SetRewriteRules sets the rules to rewrite the URLs given to New.
+func SetRewriteRules(rules []RewriteRule) {
+	sorted := make([]RewriteRule, len(rules))
+	copy(sorted, rules)
+
+	// the longest matching prefix wins.
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].From) > len(sorted[j].From)
+	})
+	rewriteRules = sorted
+}
+
+// ParseRewriteRules parses the rewrite rules from the given reader. Each line
+// consists of a logical prefix and the concrete prefix it is resolved to,
+// separated by whitespace. Empty lines and lines starting with '#' are
+// ignored.
+func ParseRewriteRules(r io.Reader) ([]RewriteRule, error) {
+	var rules []RewriteRule
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid rewrite rule (line: %v): expected logical and concrete prefixes", lineno)
+		}
+
+		from, to := fields[0], fields[1]
+		if !strings.Contains(from, "://") {
+			return nil, fmt.Errorf("invalid rewrite rule (line: %v): logical prefix %q must have a scheme", lineno, from)
+		}
+
+		if hasGlobCharacter(from) || hasGlobCharacter(to) {
+			return nil, fmt.Errorf("invalid rewrite rule (line: %v): prefixes can not contain glob characters", lineno)
+		}
+
+		if seen[from] {
+			return nil, fmt.Errorf("invalid rewrite rule (line: %v): duplicate logical prefix %q", lineno, from)
+		}
+		seen[from] = true
+
+		rules = append(rules, RewriteRule{From: from, To: to})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// rewrite resolves the given URL using the rewrite rules. URLs matching no
+// rule are returned as is.
+func rewrite(s string) string {
+	for _, rule := range rewriteRules {
+		if strings.HasPrefix(s, rule.From) {
+			return rule.To + strings.TrimPrefix(s, rule.From)
+		}
+	}
+	return s
+}
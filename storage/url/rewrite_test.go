@@ -0,0 +1,76 @@
+package url
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRewriteRules(t *testing.T) {
+	input := `
+# datasets moved to the new bucket
+store://dataset/        s3://new-bucket/dataset/
+store://dataset/raw/    s3://raw-bucket/
+
+store://scratch/ /tmp/scratch/
+`
+	rules, err := ParseRewriteRules(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []RewriteRule{
+		{From: "store://dataset/", To: "s3://new-bucket/dataset/"},
+		{From: "store://dataset/raw/", To: "s3://raw-bucket/"},
+		{From: "store://scratch/", To: "/tmp/scratch/"},
+	}
+	if diff := cmp.Diff(want, rules); diff != "" {
+		t.Errorf("(-want +got):\n%v", diff)
+	}
+
+	invalid := []string{
+		"store://dataset/",
+		"store://dataset/ s3://bucket/ extra",
+		"dataset/ s3://bucket/",
+		"store://dataset/* s3://bucket/",
+		"store://dataset/ s3://bucket/\nstore://dataset/ s3://another/",
+	}
+	for _, input := range invalid {
+		if _, err := ParseRewriteRules(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestNewWithRewriteRules(t *testing.T) {
+	SetRewriteRules([]RewriteRule{
+		{From: "store://dataset/", To: "s3://new-bucket/dataset/"},
+		{From: "store://dataset/raw/", To: "s3://raw-bucket/"},
+	})
+	defer SetRewriteRules(nil)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "store://dataset/file.txt", want: "s3://new-bucket/dataset/file.txt"},
+		{input: "store://dataset/*.gz", want: "s3://new-bucket/dataset/*.gz"},
+		{input: "store://dataset/raw/2020/", want: "s3://raw-bucket/2020/"},
+		{input: "s3://bucket/dataset/", want: "s3://bucket/dataset/"},
+	}
+	for _, tc := range tests {
+		u, err := New(tc.input)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", tc.input, err)
+			continue
+		}
+		if got := u.String(); got != tc.want {
+			t.Errorf("New(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+
+	if _, err := New("unknown://dataset/file.txt"); err == nil {
+		t.Errorf("expected error for unmatched logical url")
+	}
+}
@@ -13,8 +13,6 @@ import (
 )
 
 const (
-	globCharacters string = "?*"
-
 	// s3Scheme is the schema used on s3 URLs
 	s3Scheme string = "s3://"
 
@@ -42,10 +40,32 @@ type URL struct {
 	Delimiter string
 	Prefix    string
 
+	// VersionID is the version of the remote object, if the URL refers to a
+	// specific version.
+	VersionID string
+
+	// StartAfter is the key which the listing of the remote URL starts
+	// after, in lexicographical order.
+	StartAfter string
+
 	relativePath string
 	filter       string
 	filterRegex  *regexp.Regexp
 	raw          bool
+
+	// listPrefixes are the prefixes of the alternatives of the brace groups
+	// of the path, which are listed instead of the common prefix.
+	listPrefixes []string
+
+	// bucketPatterns are the brace expansions of the bucket if it has
+	// wildcards, and bucketRegex matches the names of the buckets.
+	bucketPatterns []string
+	bucketRegex    *regexp.Regexp
+
+	// wildBucket reports whether the URL or the URL it is cloned from has a
+	// bucket with wildcards. The relative paths of the objects start with
+	// their buckets then.
+	wildBucket bool
 }
 
 type Option func(u *URL)
@@ -56,8 +76,10 @@ func WithRaw(mode bool) Option {
 	}
 }
 
-// New creates a new URL from given path string.
+// New creates a new URL from given path string. The logical URLs matching the
+// rewrite rules are resolved to their concrete URLs.
 func New(s string, opts ...Option) (*URL, error) {
+	s = rewrite(s)
 	split := strings.Split(s, "://")
 
 	if len(split) == 1 {
@@ -103,10 +125,6 @@ func New(s string, opts ...Option) (*URL, error) {
 		return nil, fmt.Errorf("s3 url should have a bucket")
 	}
 
-	if hasGlobCharacter(bucket) {
-		return nil, fmt.Errorf("bucket name cannot contain wildcards")
-	}
-
 	url := &URL{
 		Type:   remoteObject,
 		Scheme: "s3",
@@ -118,6 +136,15 @@ func New(s string, opts ...Option) (*URL, error) {
 		opt(url)
 	}
 
+	if hasGlobCharacter(bucket) {
+		if url.raw {
+			return nil, fmt.Errorf("bucket name cannot contain wildcards")
+		}
+		if err := url.setBucketPattern(); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := url.setPrefixAndFilter(); err != nil {
 		return nil, err
 	}
@@ -203,10 +230,28 @@ func (u *URL) remoteURL() string {
 	return s
 }
 
+// setBucketPattern prepares the brace expansions and the regex of the bucket
+// with wildcards.
+func (u *URL) setBucketPattern() error {
+	patterns, err := expandBraces(u.Bucket)
+	if err != nil {
+		return err
+	}
+	r, err := compileGlob(patterns)
+	if err != nil {
+		return err
+	}
+	u.bucketPatterns = patterns
+	u.bucketRegex = r
+	u.wildBucket = true
+	return nil
+}
+
 // setPrefixAndFilter creates url metadata for both wildcard and non-wildcard
 // operations.
 //
-// It converts wildcard strings to regex format
+// It converts wildcard strings, including character classes and brace
+// groups, to regex format
 // and pre-compiles it for later usage. It is default to
 // ".*" to match every key on S3.
 //
@@ -232,28 +277,35 @@ func (u *URL) remoteURL() string {
 //		delimiter: "/"
 //
 func (u *URL) setPrefixAndFilter() error {
-	if u.raw {
-		return nil
+	// the raw paths are listed as the paths without wildcards.
+	loc := -1
+	if !u.raw {
+		loc = globIndex(u.Path)
 	}
-
-	loc := strings.IndexAny(u.Path, globCharacters)
 	wildOperation := loc > -1
 	if !wildOperation {
 		u.Delimiter = s3Separator
 		u.Prefix = u.Path
-	} else {
-		u.Prefix = u.Path[:loc]
-		u.filter = u.Path[loc:]
+		r, err := regexp.Compile("^" + regexp.QuoteMeta(u.Prefix) + matchAllRe + "$")
+		if err != nil {
+			return err
+		}
+		u.filterRegex = r
+		return nil
 	}
 
-	filterRegex := matchAllRe
-	if u.filter != "" {
-		filterRegex = regexp.QuoteMeta(u.filter)
-		filterRegex = strings.Replace(filterRegex, "\\?", ".", -1)
-		filterRegex = strings.Replace(filterRegex, "\\*", ".*?", -1)
+	u.Prefix = u.Path[:loc]
+	u.filter = u.Path[loc:]
+
+	patterns, err := expandBraces(u.Path)
+	if err != nil {
+		return err
+	}
+	if len(patterns) > 1 {
+		u.listPrefixes = literalPrefixes(patterns)
 	}
-	filterRegex = regexp.QuoteMeta(u.Prefix) + filterRegex
-	r, err := regexp.Compile("^" + filterRegex + "$")
+
+	r, err := compileGlob(patterns)
 	if err != nil {
 		return err
 	}
@@ -264,16 +316,23 @@ func (u *URL) setPrefixAndFilter() error {
 // Clone creates a copy of the receiver.
 func (u *URL) Clone() *URL {
 	return &URL{
-		Type:      u.Type,
-		Scheme:    u.Scheme,
-		Bucket:    u.Bucket,
-		Delimiter: u.Delimiter,
-		Path:      u.Path,
-		Prefix:    u.Prefix,
+		Type:       u.Type,
+		Scheme:     u.Scheme,
+		Bucket:     u.Bucket,
+		Delimiter:  u.Delimiter,
+		Path:       u.Path,
+		Prefix:     u.Prefix,
+		VersionID:  u.VersionID,
+		StartAfter: u.StartAfter,
 
 		relativePath: u.relativePath,
 		filter:       u.filter,
 		filterRegex:  u.filterRegex,
+
+		listPrefixes:   u.listPrefixes,
+		bucketPatterns: u.bucketPatterns,
+		bucketRegex:    u.bucketRegex,
+		wildBucket:     u.wildBucket,
 	}
 }
 
@@ -289,18 +348,134 @@ func (u *URL) Match(key string) bool {
 		return false
 	}
 
-	isBatch := u.filter != ""
-	if isBatch {
-		v := parseBatch(u.Prefix, key)
-		u.relativePath = v
-		return true
+	// flat listings without a delimiter are relative to the directory of the
+	// prefix, like the wildcard operations.
+	var v string
+	if isBatch := u.filter != ""; isBatch || u.Delimiter == "" {
+		v = parseBatch(u.Prefix, key)
+	} else {
+		v = parseDelimited(u.Prefix, key, u.Delimiter)
 	}
 
-	v := parseNonBatch(u.Prefix, key)
+	// the objects of different buckets are told apart by their buckets.
+	if u.wildBucket {
+		v = u.Bucket + s3Separator + v
+	}
 	u.relativePath = v
 	return true
 }
 
+// IsBucketWildcard reports whether the bucket of the remote URL has
+// wildcards, e.g. s3://logs-{2023,2024}/ or s3://logs-*/.
+func (u *URL) IsBucketWildcard() bool {
+	return u.IsRemote() && u.bucketRegex != nil && hasGlobCharacter(u.Bucket)
+}
+
+// Buckets returns the names of the buckets of the URL if its bucket has only
+// brace groups, e.g. logs-2023 and logs-2024 for s3://logs-{2023,2024}/. It
+// reports false if the buckets have to be listed to be matched.
+func (u *URL) Buckets() ([]string, bool) {
+	for _, pattern := range u.bucketPatterns {
+		if hasGlobCharacter(pattern) {
+			return nil, false
+		}
+	}
+	return u.bucketPatterns, true
+}
+
+// MatchBucket reports whether the given bucket name matches the bucket of the
+// URL.
+func (u *URL) MatchBucket(name string) bool {
+	if u.bucketRegex == nil {
+		return name == u.Bucket
+	}
+	return u.bucketRegex.MatchString(name)
+}
+
+// ListPrefixes returns the prefixes to be listed to find the objects of the
+// URL. Each of the alternatives of the brace groups of the path is listed
+// under its own prefix, e.g. a/x and b/x for {a,b}/x*.
+func (u *URL) ListPrefixes() []string {
+	if len(u.listPrefixes) > 0 {
+		return u.listPrefixes
+	}
+	return []string{u.Prefix}
+}
+
+// PrefixURL returns the URL of the literal prefix of the wildcard URL, e.g.
+// s3://bucket/logs/ for s3://bucket/logs/*.gz. Its listing has the common
+// prefixes and the objects right under the prefix.
+func (u *URL) PrefixURL() (*URL, error) {
+	prefixurl := &URL{
+		Type:   u.Type,
+		Scheme: u.Scheme,
+		Bucket: u.Bucket,
+		Path:   u.Prefix,
+	}
+	if err := prefixurl.setPrefixAndFilter(); err != nil {
+		return nil, err
+	}
+	return prefixurl, nil
+}
+
+// WithListPrefix returns a copy of the wildcard URL which lists only the
+// objects under the given prefix, e.g. a common prefix of the listing of its
+// PrefixURL. The objects are matched and named as the objects of the URL.
+func (u *URL) WithListPrefix(prefix string) *URL {
+	return u.WithListPrefixes([]string{prefix})
+}
+
+// WithListPrefixes is like WithListPrefix, but lists the objects under each
+// of the given prefixes, e.g. the common prefixes matching the SegmentGlobs
+// of the URL. The prefixes which start with another one are left out.
+func (u *URL) WithListPrefixes(prefixes []string) *URL {
+	clone := u.Clone()
+	clone.listPrefixes = uniquePrefixes(append([]string(nil), prefixes...))
+	return clone
+}
+
+// SegmentGlobs returns the segment globs of the alternatives of the brace
+// groups of the wildcard path, e.g. of "logs/app-??/*.gz". The common
+// prefixes matching them are found level by level with a delimiter, instead
+// of listing all of the keys under the literal prefix. It returns nil if none
+// of the alternatives has wildcards in its segments before its first '*'.
+func (u *URL) SegmentGlobs() []*SegmentGlob {
+	if u.raw || u.filter == "" {
+		return nil
+	}
+	patterns, err := expandBraces(u.Path)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		globs []*SegmentGlob
+		wild  bool
+	)
+	for _, pattern := range patterns {
+		glob, ok := newSegmentGlob(pattern)
+		wild = wild || ok
+		globs = append(globs, glob)
+	}
+	if !wild {
+		return nil
+	}
+	return globs
+}
+
+// GlobPatterns returns the brace expansions of the local URL, to be matched
+// with filepath.Glob.
+func (u *URL) GlobPatterns() ([]string, error) {
+	patterns, err := expandBraces(u.Absolute())
+	if err != nil {
+		return nil, err
+	}
+	for i, pattern := range patterns {
+		patterns[i] = fnmatchPattern(pattern)
+	}
+	return patterns, nil
+}
+
 // String is the fmt.Stringer implementation of URL.
 func (u *URL) String() string {
 	return u.Absolute()
@@ -313,7 +488,7 @@ func (u *URL) MarshalJSON() ([]byte, error) {
 
 // IsWildcard reports whether if a string contains any wildcard chars.
 func (u *URL) IsWildcard() bool {
-	return !u.raw && hasGlobCharacter(u.Path)
+	return !u.raw && (hasGlobCharacter(u.Path) || u.IsBucketWildcard())
 }
 
 // parseBatch parses keys for wildcard operations.
@@ -345,21 +520,27 @@ func parseBatch(prefix string, key string) string {
 //		output: c/
 //
 func parseNonBatch(prefix string, key string) string {
+	return parseDelimited(prefix, key, s3Separator)
+}
+
+// parseDelimited parses keys for non-wildcard operations like parseNonBatch,
+// with the given delimiter instead of '/'.
+func parseDelimited(prefix, key, delimiter string) string {
 	if key == prefix || !strings.HasPrefix(key, prefix) {
 		return key
 	}
-	parsedKey := strings.TrimSuffix(key, s3Separator)
-	if loc := strings.LastIndex(parsedKey, s3Separator); loc < len(prefix) {
+	parsedKey := strings.TrimSuffix(key, delimiter)
+	if loc := strings.LastIndex(parsedKey, delimiter); loc < len(prefix) {
 		if loc < 0 {
 			return key
 		}
 		parsedKey = key[loc:]
-		return strings.TrimPrefix(parsedKey, s3Separator)
+		return strings.TrimPrefix(parsedKey, delimiter)
 	}
 	parsedKey = strings.TrimPrefix(key, prefix)
-	parsedKey = strings.TrimPrefix(parsedKey, s3Separator)
-	index := strings.Index(parsedKey, s3Separator) + 1
-	if index <= 0 || index >= len(parsedKey) {
+	parsedKey = strings.TrimPrefix(parsedKey, delimiter)
+	index := strings.Index(parsedKey, delimiter) + len(delimiter)
+	if index < len(delimiter) || index >= len(parsedKey) {
 		return parsedKey
 	}
 	trimmedKey := parsedKey[:index]
@@ -368,7 +549,7 @@ func parseNonBatch(prefix string, key string) string {
 
 // hasGlobCharacter reports whether if a string contains any wildcard chars.
 func hasGlobCharacter(s string) bool {
-	return strings.ContainsAny(s, globCharacters)
+	return globIndex(s) >= 0
 }
 
 func (u *URL) EscapedPath() string {
@@ -30,6 +30,21 @@ func TestHasWild(t *testing.T) {
 			s:    "s3://a/b/c",
 			want: false,
 		},
+		{
+			name: "string_has_character_class",
+			s:    "s3://a/b/[0-9]",
+			want: true,
+		},
+		{
+			name: "string_has_brace_group",
+			s:    "s3://a/{b,c}",
+			want: true,
+		},
+		{
+			name: "string_has_unclosed_bracket_and_brace_without_comma",
+			s:    "s3://a/[b/{c}",
+			want: false,
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -54,11 +69,6 @@ func TestNew(t *testing.T) {
 			object:  "s3://",
 			wantErr: true,
 		},
-		{
-			name:    "error_if_bucket_name_has_wildcard",
-			object:  "s3://a*b",
-			wantErr: true,
-		},
 		{
 			name:   "url_with_no_wildcard",
 			object: "s3://bucket/key",
@@ -253,6 +263,25 @@ func TestCheckMatch(t *testing.T) {
 				"a/b/c.csv": {},
 			},
 		},
+		{
+			name: "match_character_classes",
+			url:  "s3://bucket/log-[0-9][!a-c].txt",
+			keys: map[string]matchResult{
+				"log-1d.txt": {true, "log-1d.txt"},
+				"log-1a.txt": {},
+				"log-xd.txt": {},
+			},
+		},
+		{
+			name: "match_brace_groups",
+			url:  "s3://bucket/{app,web}-*/{2023,2024}/data.json.gz",
+			keys: map[string]matchResult{
+				"app-1/2023/data.json.gz": {true, "app-1/2023/data.json.gz"},
+				"web-2/2024/data.json.gz": {true, "web-2/2024/data.json.gz"},
+				"db-1/2023/data.json.gz":  {},
+				"app-1/2022/data.json.gz": {},
+			},
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -371,6 +400,53 @@ func TestParseNonBatch(t *testing.T) {
 	}
 }
 
+func TestParseDelimited(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefix    string
+		key       string
+		delimiter string
+		want      string
+	}{
+		{
+			name:      "parse_key_and_return_first_group_after_prefix",
+			prefix:    "logs|",
+			key:       "logs|2023|a.txt",
+			delimiter: "|",
+			want:      "2023|",
+		},
+		{
+			name:      "parse_key_and_return_asset_after_prefix",
+			prefix:    "logs|",
+			key:       "logs|a.txt",
+			delimiter: "|",
+			want:      "a.txt",
+		},
+		{
+			name:      "parse_key_with_multi_character_delimiter",
+			prefix:    "logs::",
+			key:       "logs::2023::a.txt",
+			delimiter: "::",
+			want:      "2023::",
+		},
+		{
+			name:      "return_key_if_it_does_not_include_delimiter",
+			prefix:    "a/",
+			key:       "a/b/c.txt",
+			delimiter: "|",
+			want:      "a/b/c.txt",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseDelimited(tc.prefix, tc.key, tc.delimiter); got != tc.want {
+				t.Errorf("parseDelimited() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestURLIsBucket(t *testing.T) {
 	tests := []struct {
 		input     string
@@ -403,6 +479,102 @@ func TestURLIsBucket(t *testing.T) {
 	}
 }
 
+func TestURLListPrefixes(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"s3://bucket/dir/*", []string{"dir/"}},
+		{"s3://bucket/{a,b}/x*", []string{"a/x", "b/x"}},
+		{"s3://bucket/{a,a/b}/*", []string{"a/"}},
+		{"s3://bucket/dir/{x,y}[0-9]/*", []string{"dir/x", "dir/y"}},
+	}
+	for _, tc := range tests {
+		u, err := New(tc.input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v for input %s", err, tc.input)
+		}
+		if diff := cmp.Diff(tc.want, u.ListPrefixes()); diff != "" {
+			t.Errorf("%s: ListPrefixes() mismatch (-want +got):\n%v", tc.input, diff)
+		}
+	}
+}
+
+func TestURLPrefixURL(t *testing.T) {
+	u, err := New("s3://bucket/logs/app-*/*.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prefixurl, err := u.PrefixURL()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := prefixurl.String(); got != "s3://bucket/logs/app-" {
+		t.Errorf("PrefixURL() = %v, want s3://bucket/logs/app-", got)
+	}
+	if prefixurl.IsWildcard() || prefixurl.Delimiter != "/" {
+		t.Errorf("PrefixURL() must list the common prefixes of the prefix")
+	}
+
+	partition := u.WithListPrefix("logs/app-1/")
+	if diff := cmp.Diff([]string{"logs/app-1/"}, partition.ListPrefixes()); diff != "" {
+		t.Errorf("WithListPrefix() mismatch (-want +got):\n%v", diff)
+	}
+	if !partition.Match("logs/app-1/01/data.gz") {
+		t.Errorf("WithListPrefix() must match the objects of the URL")
+	}
+	if got := partition.Relative(); got != "app-1/01/data.gz" {
+		t.Errorf("Relative() = %v, want app-1/01/data.gz", got)
+	}
+	if diff := cmp.Diff([]string{"logs/app-"}, u.ListPrefixes()); diff != "" {
+		t.Errorf("WithListPrefix() must not change the URL (-want +got):\n%v", diff)
+	}
+}
+
+func TestURLBucketWildcard(t *testing.T) {
+	u, err := New("s3://logs-{2023,2024}/app-*/data.json.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !u.IsWildcard() || !u.IsBucketWildcard() {
+		t.Errorf("expected bucket wildcard")
+	}
+
+	buckets, ok := u.Buckets()
+	if !ok {
+		t.Errorf("expected buckets of brace groups")
+	}
+	if diff := cmp.Diff([]string{"logs-2023", "logs-2024"}, buckets); diff != "" {
+		t.Errorf("Buckets() mismatch (-want +got):\n%v", diff)
+	}
+
+	// the objects of the buckets are relative to their buckets.
+	bucketurl := u.Clone()
+	bucketurl.Bucket = "logs-2024"
+	if bucketurl.IsBucketWildcard() {
+		t.Errorf("expected no bucket wildcard after the bucket is set")
+	}
+	if !bucketurl.Match("app-1/data.json.gz") || bucketurl.Relative() != "logs-2024/app-1/data.json.gz" {
+		t.Errorf("unexpected relative path %q", bucketurl.Relative())
+	}
+
+	u, err = New("s3://logs-*/*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := u.Buckets(); ok {
+		t.Errorf("expected buckets to be listed")
+	}
+	if !u.MatchBucket("logs-2023") || u.MatchBucket("metrics-2023") {
+		t.Errorf("unexpected bucket match")
+	}
+
+	if _, err := New("s3://a*b", WithRaw(true)); err == nil {
+		t.Errorf("expected error for bucket wildcard in raw mode")
+	}
+}
+
 func TestURLWithMode(t *testing.T) {
 	tests := []struct {
 		input          string
@@ -411,11 +583,11 @@ func TestURLWithMode(t *testing.T) {
 		filterExpected string
 	}{
 		{"s3://bucket/file*.txt", false, "file", "*.txt"},
-		{"s3://bucket/file*.txt", true, "", ""},
+		{"s3://bucket/file*.txt", true, "file*.txt", ""},
 		{"s3://bucket/abc/deneme*.txt", false, "abc/deneme", "*.txt"},
-		{"s3://bucket/abc/deneme*.txt", true, "", ""},
+		{"s3://bucket/abc/deneme*.txt", true, "abc/deneme*.txt", ""},
 		{"deneme*.txt", false, "deneme", "*.txt"},
-		{"deneme*.txt", true, "", ""},
+		{"deneme*.txt", true, "deneme*.txt", ""},
 	}
 	for _, tc := range tests {
 		url, err := New(tc.input, WithRaw(tc.raw))
@@ -430,5 +602,42 @@ func TestURLWithMode(t *testing.T) {
 		if url.filter != tc.filterExpected {
 			t.Errorf("%s: url filter %s does not match with expected filter %s\n", tc.input, url.Prefix, tc.filterExpected)
 		}
+
+		// the raw paths are listed literally.
+		if tc.raw && (url.IsWildcard() || !url.Match(tc.prefixExpected)) {
+			t.Errorf("%s: raw url must match the literal path", tc.input)
+		}
+	}
+}
+
+func TestURLSegmentGlobs(t *testing.T) {
+	u, err := New("s3://bucket/{logs/app-??,web}/*.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	globs := u.SegmentGlobs()
+	if len(globs) != 2 {
+		t.Fatalf("SegmentGlobs() = %v, want 2 globs", globs)
+	}
+	if globs[0].Prefix != "logs/app-" || globs[1].Prefix != "web/" {
+		t.Errorf("unexpected prefixes %q and %q", globs[0].Prefix, globs[1].Prefix)
+	}
+	if ok, complete := globs[1].Match("web/"); !ok || !complete {
+		t.Errorf("alternatives without segment wildcards must be listed under their prefixes")
+	}
+
+	for _, input := range []string{"s3://bucket/logs/app-*/??/x", "s3://bucket/logs/x"} {
+		u, err := New(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if globs := u.SegmentGlobs(); globs != nil {
+			t.Errorf("%s: unexpected segment globs", input)
+		}
+	}
+
+	partition := u.WithListPrefixes([]string{"logs/app-12/", "logs/", "web/"})
+	if diff := cmp.Diff([]string{"logs/", "web/"}, partition.ListPrefixes()); diff != "" {
+		t.Errorf("WithListPrefixes() mismatch (-want +got):\n%v", diff)
 	}
 }
@@ -0,0 +1,403 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+// copyDigest is the information of an object used to verify its copy.
+type copyDigest struct {
+	size int64
+	etag string
+
+	// checksum is the additional checksum of the object prefixed with its
+	// algorithm, e.g. "SHA256:...", if the object has one.
+	checksum string
+
+	// parts is the number of parts of multipart objects, zero otherwise.
+	parts int64
+
+	// md5 reports whether the ETag is the MD5 digest of the content, which
+	// is not the case for objects encrypted with SSE-KMS or SSE-C.
+	md5 bool
+}
+
+// VerifyCopy compares the source object with its server-side copy. The
+// source object is fetched with the given client, which may be of another
+// region. They match if their ETags or additional checksums are equal. ETags
+// and checksums of multipart objects depend on the part boundaries, so they
+// are compared only if both objects consist of parts of the same sizes. If
+// the objects can't be compared, ErrCopyNotVerified is returned.
+func (s *S3) VerifyCopy(ctx context.Context, srcClient *S3, from, to *url.URL) error {
+	if s.dryRun {
+		simulation.add("HeadObject", 2, 0)
+		return nil
+	}
+
+	src, err := srcClient.copyDigest(ctx, from)
+	if err != nil {
+		return err
+	}
+	dst, err := s.copyDigest(ctx, to)
+	if err != nil {
+		return err
+	}
+
+	if src.size != dst.size {
+		return fmt.Errorf("%w: size of the copy is %d, expected %d", ErrCopyMismatch, dst.size, src.size)
+	}
+	if src.etag == dst.etag {
+		return nil
+	}
+	if src.checksum != "" && src.checksum == dst.checksum {
+		return nil
+	}
+
+	samePartSizes, err := s.samePartSizes(ctx, srcClient, from, to, src.parts, dst.parts)
+	if err != nil {
+		return err
+	}
+	if samePartSizes {
+		if src.md5 && dst.md5 {
+			return fmt.Errorf("%w: ETag of the copy is %q, expected %q", ErrCopyMismatch, dst.etag, src.etag)
+		}
+		if src.checksum != "" && dst.checksum != "" && checksumAlgorithmOf(src.checksum) == checksumAlgorithmOf(dst.checksum) {
+			return fmt.Errorf("%w: checksum of the copy is %q, expected %q", ErrCopyMismatch, dst.checksum, src.checksum)
+		}
+		return fmt.Errorf("%w: objects are encrypted and have no comparable checksums", ErrCopyNotVerified)
+	}
+	return fmt.Errorf("%w: objects consist of parts of different sizes", ErrCopyNotVerified)
+}
+
+// VerifyObject compares the object with the given content, which is the
+// source or the downloaded copy of the object. They match if the ETag of the
+// object is the MD5 digest of the content, or if the additional checksum of
+// single part objects matches the content. ETags of multipart objects are
+// calculated over the parts of the object. If the object can't be compared,
+// ErrCopyNotVerified is returned.
+func (s *S3) VerifyObject(ctx context.Context, u *url.URL, content io.ReaderAt, size int64) error {
+	if s.dryRun {
+		simulation.add("HeadObject", 1, 0)
+		return nil
+	}
+
+	digest, err := s.copyDigest(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	if digest.size != size {
+		return fmt.Errorf("%w: size of the object is %d, expected %d", ErrCopyMismatch, digest.size, size)
+	}
+
+	if digest.md5 {
+		etag, err := s.contentETag(ctx, u, content, size, digest.parts)
+		if err != nil {
+			return err
+		}
+		if etag != digest.etag {
+			return fmt.Errorf("%w: ETag of the object is %q, expected %q", ErrCopyMismatch, digest.etag, etag)
+		}
+		return nil
+	}
+
+	if digest.checksum != "" && digest.parts == 0 {
+		algorithm := ChecksumAlgorithm(checksumAlgorithmOf(digest.checksum))
+		sum, err := algorithm.sum(io.NewSectionReader(content, 0, size))
+		if err != nil {
+			return err
+		}
+		checksum := fmt.Sprintf("%v:%v", algorithm, base64.StdEncoding.EncodeToString(sum))
+		if checksum != digest.checksum {
+			return fmt.Errorf("%w: checksum of the object is %q, expected %q", ErrCopyMismatch, digest.checksum, checksum)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: object is encrypted and has no comparable checksum", ErrCopyNotVerified)
+}
+
+// contentETag calculates the ETag of the content as S3 does for an object of
+// the given number of parts. Part sizes are fetched from the object.
+func (s *S3) contentETag(ctx context.Context, u *url.URL, content io.ReaderAt, size, parts int64) (string, error) {
+	partETag := func(offset, size int64) ([]byte, error) {
+		h := md5.New()
+		if _, err := io.Copy(h, io.NewSectionReader(content, offset, size)); err != nil {
+			return nil, err
+		}
+		return h.Sum(nil), nil
+	}
+
+	if parts == 0 {
+		sum, err := partETag(0, size)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%x", sum), nil
+	}
+
+	partSizes, err := s.partSizes(ctx, u, parts)
+	if err != nil {
+		return "", err
+	}
+
+	composite := md5.New()
+	var offset int64
+	for _, partSize := range partSizes {
+		sum, err := partETag(offset, partSize)
+		if err != nil {
+			return "", err
+		}
+		composite.Write(sum)
+		offset += partSize
+	}
+	return fmt.Sprintf("%x-%d", composite.Sum(nil), parts), nil
+}
+
+// Checksum is the ETag and the additional checksum of an object.
+type Checksum struct {
+	ETag string `json:"etag"`
+	Size int64  `json:"size"`
+
+	// Algorithm and Value are the additional checksum of the object, they
+	// are empty if the object has none.
+	Algorithm ChecksumAlgorithm `json:"checksum_algorithm,omitempty"`
+	Value     string            `json:"checksum,omitempty"`
+}
+
+// Checksum fetches the ETag and the additional checksum of the object, if it
+// has one.
+func (s *S3) Checksum(ctx context.Context, u *url.URL) (*Checksum, error) {
+	digest, err := s.copyDigest(ctx, u)
+	if err != nil {
+		if errHasCode(err, "NotFound") {
+			return nil, ErrGivenObjectNotFound
+		}
+		return nil, err
+	}
+
+	checksum := &Checksum{
+		ETag: digest.etag,
+		Size: digest.size,
+	}
+	if algorithm := checksumAlgorithmOf(digest.checksum); algorithm != "" {
+		checksum.Algorithm = ChecksumAlgorithm(algorithm)
+		checksum.Value = digest.checksum[len(algorithm)+1:]
+	}
+	return checksum, nil
+}
+
+// copyDigest fetches the information of the object to verify its copy.
+func (s *S3) copyDigest(ctx context.Context, u *url.URL) (copyDigest, error) {
+	req, output := s.api.HeadObjectRequest(&s3.HeadObjectInput{
+		Bucket:       aws.String(u.Bucket),
+		Key:          aws.String(s.objectKey(u.Path)),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	})
+	req.SetContext(ctx)
+	req.HTTPRequest.Header.Set("x-amz-checksum-mode", "ENABLED")
+	if err := req.Send(); err != nil {
+		return copyDigest{}, err
+	}
+
+	sse := aws.StringValue(output.ServerSideEncryption)
+	digest := copyDigest{
+		size: aws.Int64Value(output.ContentLength),
+		etag: strings.Trim(aws.StringValue(output.ETag), `"`),
+		md5:  !strings.HasPrefix(sse, "aws:kms") && output.SSECustomerAlgorithm == nil,
+	}
+
+	for _, algorithm := range ChecksumAlgorithms {
+		if value := req.HTTPResponse.Header.Get(algorithm.header()); value != "" {
+			digest.checksum = fmt.Sprintf("%v:%v", algorithm, value)
+			break
+		}
+	}
+
+	// ETags of multipart objects are suffixed with the number of parts.
+	if i := strings.LastIndex(digest.etag, "-"); i > 0 {
+		parts, err := strconv.ParseInt(digest.etag[i+1:], 10, 64)
+		if err != nil {
+			return copyDigest{}, fmt.Errorf("invalid ETag %q", digest.etag)
+		}
+		digest.parts = parts
+	}
+	return digest, nil
+}
+
+// samePartSizes reports whether the objects consist of the same number of
+// parts of the same sizes. The source object is fetched with the given
+// client.
+func (s *S3) samePartSizes(ctx context.Context, srcClient *S3, from, to *url.URL, srcParts, dstParts int64) (bool, error) {
+	if srcParts != dstParts {
+		return false, nil
+	}
+	if srcParts == 0 {
+		return true, nil
+	}
+
+	srcSizes, err := srcClient.partSizes(ctx, from, srcParts)
+	if err != nil {
+		return false, err
+	}
+	dstSizes, err := s.partSizes(ctx, to, dstParts)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range srcSizes {
+		if srcSizes[i] != dstSizes[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// partSizes fetches the sizes of the given number of parts of the object.
+// They are listed with GetObjectAttributes, which lists the parts of the
+// objects uploaded with additional checksums only. The sizes of the other
+// objects, and of the objects of the services which don't support it, are
+// fetched with a HEAD request per part.
+func (s *S3) partSizes(ctx context.Context, u *url.URL, parts int64) ([]int64, error) {
+	// the key has to be sent in headers to list the parts of the objects
+	// encrypted with SSE-C, which the request doesn't support.
+	if s.sseCustomerKey() == nil {
+		sizes, err := s.listPartSizes(ctx, u)
+		if err != nil && !isObjectAttributesUnsupported(err) {
+			return nil, err
+		}
+		if int64(len(sizes)) == parts {
+			return sizes, nil
+		}
+	}
+
+	sizes := make([]int64, 0, parts)
+	for partNumber := int64(1); partNumber <= parts; partNumber++ {
+		size, err := s.partSize(ctx, u, partNumber)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// objectAttributesOutput is the part of the response of GetObjectAttributes
+// which lists the parts of the object. The SDK version in use doesn't have
+// the operation.
+type objectAttributesOutput struct {
+	_ struct{} `type:"structure"`
+
+	ObjectParts *objectAttributesParts `type:"structure"`
+}
+
+type objectAttributesParts struct {
+	_ struct{} `type:"structure"`
+
+	IsTruncated          *bool                   `type:"boolean"`
+	NextPartNumberMarker *int64                  `type:"integer"`
+	Parts                []*objectAttributesPart `locationName:"Part" type:"list" flattened:"true"`
+}
+
+type objectAttributesPart struct {
+	_ struct{} `type:"structure"`
+
+	PartNumber *int64 `type:"integer"`
+	Size       *int64 `type:"long"`
+}
+
+// listPartSizes lists the sizes of the parts of the object with
+// GetObjectAttributes. No sizes are returned if the parts are not listed.
+func (s *S3) listPartSizes(ctx context.Context, u *url.URL) ([]int64, error) {
+	var (
+		sizes  []int64
+		marker int64
+	)
+	for {
+		// the request is built as GetObjectAcl, which has the same
+		// parameters, so that the bucket is addressed the same way.
+		req, _ := s.api.GetObjectAclRequest(&s3.GetObjectAclInput{
+			Bucket:       aws.String(u.Bucket),
+			Key:          aws.String(s.objectKey(u.Path)),
+			RequestPayer: s.RequestPayer(),
+		})
+		req.Operation = &request.Operation{
+			Name:       "GetObjectAttributes",
+			HTTPMethod: "GET",
+			HTTPPath:   "/{Bucket}/{Key+}?attributes",
+		}
+		req.HTTPRequest.URL.RawQuery = "attributes"
+		req.HTTPRequest.Header.Set("x-amz-object-attributes", "ObjectParts")
+		if marker > 0 {
+			req.HTTPRequest.Header.Set("x-amz-part-number-marker", strconv.FormatInt(marker, 10))
+		}
+
+		output := &objectAttributesOutput{}
+		req.Data = output
+		req.SetContext(ctx)
+		if err := req.Send(); err != nil {
+			return nil, err
+		}
+
+		parts := output.ObjectParts
+		if parts == nil {
+			return nil, nil
+		}
+		for _, part := range parts.Parts {
+			sizes = append(sizes, aws.Int64Value(part.Size))
+		}
+
+		marker = aws.Int64Value(parts.NextPartNumberMarker)
+		if !aws.BoolValue(parts.IsTruncated) || marker == 0 {
+			return sizes, nil
+		}
+	}
+}
+
+// isObjectAttributesUnsupported reports whether the error is caused by a
+// service which doesn't support GetObjectAttributes, or by a missing
+// permission for it.
+func isObjectAttributesUnsupported(err error) bool {
+	return errHasCode(err, "NotImplemented") ||
+		errHasCode(err, "MethodNotAllowed") ||
+		errHasCode(err, "AccessDenied") ||
+		errHasCode(err, request.ErrCodeSerialization)
+}
+
+// partSize fetches the size of the given part of the object.
+func (s *S3) partSize(ctx context.Context, u *url.URL, partNumber int64) (int64, error) {
+	output, err := s.api.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(u.Bucket),
+		Key:          aws.String(s.objectKey(u.Path)),
+		PartNumber:   aws.Int64(partNumber),
+		RequestPayer: s.RequestPayer(),
+
+		SSECustomerAlgorithm: s.sseCustomerAlgorithm(),
+		SSECustomerKey:       s.sseCustomerKey(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return aws.Int64Value(output.ContentLength), nil
+}
+
+// checksumAlgorithmOf returns the algorithm of the checksum of copyDigest.
+func checksumAlgorithmOf(checksum string) string {
+	if i := strings.Index(checksum, ":"); i > 0 {
+		return checksum[:i]
+	}
+	return ""
+}
@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/awstesting/unit"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gotest.tools/v3/assert"
+
+	"github.com/peak/s5cmd/storage/url"
+)
+
+type testObjectHead struct {
+	size     int64
+	etag     string
+	checksum string
+	sse      string
+	parts    []int64
+
+	// listedParts reports whether the parts are listed by
+	// GetObjectAttributes, as for the objects with additional checksums.
+	listedParts bool
+}
+
+// mockObjectHeadSend mocks HeadObject and GetObjectAttributes requests of
+// the given objects, keyed by their keys.
+func mockObjectHeadSend(t *testing.T, heads map[string]testObjectHead) func(r *request.Request) {
+	return func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+		}
+
+		if r.Operation.Name == "GetObjectAttributes" {
+			input := r.Params.(*s3.GetObjectAclInput)
+			head, ok := heads[aws.StringValue(input.Key)]
+			assert.Assert(t, ok, "unexpected key %q", aws.StringValue(input.Key))
+			if !head.listedParts {
+				return
+			}
+
+			parts := &objectAttributesParts{}
+			for i, size := range head.parts {
+				parts.Parts = append(parts.Parts, &objectAttributesPart{
+					PartNumber: aws.Int64(int64(i + 1)),
+					Size:       aws.Int64(size),
+				})
+			}
+			r.Data.(*objectAttributesOutput).ObjectParts = parts
+			return
+		}
+
+		input := r.Params.(*s3.HeadObjectInput)
+		head, ok := heads[aws.StringValue(input.Key)]
+		assert.Assert(t, ok, "unexpected key %q", aws.StringValue(input.Key))
+		if head.listedParts && input.PartNumber != nil {
+			t.Errorf("unexpected HeadObject request of part %d", aws.Int64Value(input.PartNumber))
+		}
+
+		if head.checksum != "" {
+			r.HTTPResponse.Header.Set("x-amz-checksum-crc32c", head.checksum)
+		}
+
+		output := r.Data.(*s3.HeadObjectOutput)
+		output.ContentLength = aws.Int64(head.size)
+		output.ETag = aws.String(`"` + head.etag + `"`)
+		if head.sse != "" {
+			output.ServerSideEncryption = aws.String(head.sse)
+		}
+		if partNumber := aws.Int64Value(input.PartNumber); partNumber > 0 {
+			output.ContentLength = aws.Int64(head.parts[partNumber-1])
+		}
+	}
+}
+
+// newMockObjectHeadS3 returns a client which serves the given objects.
+func newMockObjectHeadS3(t *testing.T, heads map[string]testObjectHead) *S3 {
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Unmarshal.Clear()
+	mockApi.Handlers.UnmarshalMeta.Clear()
+	mockApi.Handlers.ValidateResponse.Clear()
+	mockApi.Handlers.Send.PushBack(mockObjectHeadSend(t, heads))
+
+	return &S3{api: mockApi}
+}
+
+func TestS3VerifyCopy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		src         testObjectHead
+		dst         testObjectHead
+		expectedErr error
+	}{
+		{
+			name: "same etag",
+			src:  testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+			dst:  testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+		},
+		{
+			name:        "different size",
+			src:         testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+			dst:         testObjectHead{size: 4, etag: "5d41402abc4b2a76b9719d911017c592"},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:        "different etag",
+			src:         testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+			dst:         testObjectHead{size: 5, etag: "7d793037a0760186574b0282f2f435e7"},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name: "same checksum of encrypted objects",
+			src:  testObjectHead{size: 5, etag: "a", checksum: "mnG7TA==", sse: "aws:kms"},
+			dst:  testObjectHead{size: 5, etag: "b", checksum: "mnG7TA==", sse: "aws:kms"},
+		},
+		{
+			name:        "different checksum of encrypted objects",
+			src:         testObjectHead{size: 5, etag: "a", checksum: "mnG7TA==", sse: "aws:kms"},
+			dst:         testObjectHead{size: 5, etag: "b", checksum: "NhCmhg==", sse: "aws:kms"},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:        "encrypted objects without checksums",
+			src:         testObjectHead{size: 5, etag: "a", sse: "aws:kms"},
+			dst:         testObjectHead{size: 5, etag: "b", sse: "aws:kms"},
+			expectedErr: ErrCopyNotVerified,
+		},
+		{
+			name:        "multipart source",
+			src:         testObjectHead{size: 10, etag: "a-2", parts: []int64{5, 5}},
+			dst:         testObjectHead{size: 10, etag: "b"},
+			expectedErr: ErrCopyNotVerified,
+		},
+		{
+			name:        "different part sizes",
+			src:         testObjectHead{size: 10, etag: "a-2", parts: []int64{5, 5}},
+			dst:         testObjectHead{size: 10, etag: "b-2", parts: []int64{6, 4}},
+			expectedErr: ErrCopyNotVerified,
+		},
+		{
+			name:        "same part sizes",
+			src:         testObjectHead{size: 10, etag: "a-2", parts: []int64{5, 5}},
+			dst:         testObjectHead{size: 10, etag: "b-2", parts: []int64{5, 5}},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:        "same listed part sizes",
+			src:         testObjectHead{size: 10, etag: "a-2", parts: []int64{5, 5}, listedParts: true},
+			dst:         testObjectHead{size: 10, etag: "b-2", parts: []int64{5, 5}, listedParts: true},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:        "different listed part sizes",
+			src:         testObjectHead{size: 10, etag: "a-2", parts: []int64{5, 5}, listedParts: true},
+			dst:         testObjectHead{size: 10, etag: "b-2", parts: []int64{6, 4}},
+			expectedErr: ErrCopyNotVerified,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			from, err := url.New("s3://bucket/src")
+			assert.NilError(t, err)
+			to, err := url.New("s3://bucket/dst")
+			assert.NilError(t, err)
+
+			// the source is fetched with its own client.
+			srcClient := newMockObjectHeadS3(t, map[string]testObjectHead{"src": tc.src})
+			dstClient := newMockObjectHeadS3(t, map[string]testObjectHead{"dst": tc.dst})
+
+			err = dstClient.VerifyCopy(context.Background(), srcClient, from, to)
+			if tc.expectedErr == nil {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Assert(t, errors.Is(err, tc.expectedErr), "got %v", err)
+		})
+	}
+}
+
+func TestS3VerifyObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		content     string
+		head        testObjectHead
+		expectedErr error
+	}{
+		{
+			name:    "same etag",
+			content: "hello",
+			head:    testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+		},
+		{
+			name:        "different size",
+			content:     "hell",
+			head:        testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:        "different etag",
+			content:     "hallo",
+			head:        testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:    "same etag of multipart object",
+			content: "helloworld",
+			head:    testObjectHead{size: 10, etag: "065947336a2f2a95ba8899f3675c3be6-2", parts: []int64{5, 5}},
+		},
+		{
+			name:        "different part sizes of multipart object",
+			content:     "helloworld",
+			head:        testObjectHead{size: 10, etag: "065947336a2f2a95ba8899f3675c3be6-2", parts: []int64{6, 4}},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:    "same etag of multipart object with listed parts",
+			content: "helloworld",
+			head:    testObjectHead{size: 10, etag: "065947336a2f2a95ba8899f3675c3be6-2", parts: []int64{5, 5}, listedParts: true},
+		},
+		{
+			name:    "same checksum of encrypted object",
+			content: "hello",
+			head:    testObjectHead{size: 5, etag: "a", checksum: "mnG7TA==", sse: "aws:kms"},
+		},
+		{
+			name:        "different checksum of encrypted object",
+			content:     "hallo",
+			head:        testObjectHead{size: 5, etag: "a", checksum: "mnG7TA==", sse: "aws:kms"},
+			expectedErr: ErrCopyMismatch,
+		},
+		{
+			name:        "encrypted object without checksum",
+			content:     "hello",
+			head:        testObjectHead{size: 5, etag: "a", sse: "aws:kms"},
+			expectedErr: ErrCopyNotVerified,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			mockS3 := newMockObjectHeadS3(t, map[string]testObjectHead{"key": tc.head})
+
+			content := strings.NewReader(tc.content)
+			err = mockS3.VerifyObject(context.Background(), u, content, content.Size())
+			if tc.expectedErr == nil {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Assert(t, errors.Is(err, tc.expectedErr), "got %v", err)
+		})
+	}
+}
+
+func TestS3Checksum(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		head     testObjectHead
+		expected *Checksum
+	}{
+		{
+			name:     "etag",
+			head:     testObjectHead{size: 5, etag: "5d41402abc4b2a76b9719d911017c592"},
+			expected: &Checksum{ETag: "5d41402abc4b2a76b9719d911017c592", Size: 5},
+		},
+		{
+			name: "etag and checksum",
+			head: testObjectHead{size: 5, etag: "a", checksum: "mnG7TA==", sse: "aws:kms"},
+			expected: &Checksum{
+				ETag:      "a",
+				Size:      5,
+				Algorithm: ChecksumAlgorithmCRC32C,
+				Value:     "mnG7TA==",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.New("s3://bucket/key")
+			assert.NilError(t, err)
+
+			mockApi := s3.New(unit.Session)
+			mockApi.Handlers.Send.Clear()
+			mockApi.Handlers.Unmarshal.Clear()
+			mockApi.Handlers.UnmarshalMeta.Clear()
+			mockApi.Handlers.ValidateResponse.Clear()
+
+			mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+				head := tc.head
+
+				r.HTTPResponse = &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+				}
+				if head.checksum != "" {
+					r.HTTPResponse.Header.Set("x-amz-checksum-crc32c", head.checksum)
+				}
+
+				output := r.Data.(*s3.HeadObjectOutput)
+				output.ContentLength = aws.Int64(head.size)
+				output.ETag = aws.String(`"` + head.etag + `"`)
+				if head.sse != "" {
+					output.ServerSideEncryption = aws.String(head.sse)
+				}
+			})
+
+			mockS3 := &S3{api: mockApi}
+
+			checksum, err := mockS3.Checksum(context.Background(), u)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, checksum, tc.expected)
+		})
+	}
+}
+
+func TestS3ListPartSizes(t *testing.T) {
+	t.Parallel()
+
+	pages := map[string]string{
+		"": `<?xml version="1.0" encoding="UTF-8"?>
+<GetObjectAttributesResponse>
+  <ObjectParts>
+    <IsTruncated>true</IsTruncated>
+    <NextPartNumberMarker>2</NextPartNumberMarker>
+    <Part><PartNumber>1</PartNumber><Size>5</Size></Part>
+    <Part><PartNumber>2</PartNumber><Size>5</Size></Part>
+  </ObjectParts>
+</GetObjectAttributesResponse>`,
+		"2": `<?xml version="1.0" encoding="UTF-8"?>
+<GetObjectAttributesResponse>
+  <ObjectParts>
+    <IsTruncated>false</IsTruncated>
+    <Part><PartNumber>3</PartNumber><Size>2</Size></Part>
+  </ObjectParts>
+</GetObjectAttributesResponse>`,
+	}
+
+	u, err := url.New("s3://bucket/key")
+	assert.NilError(t, err)
+
+	mockApi := s3.New(unit.Session)
+	mockApi.Handlers.Send.Clear()
+	mockApi.Handlers.Send.PushBack(func(r *request.Request) {
+		assert.Equal(t, r.HTTPRequest.Method, http.MethodGet)
+		_, attributes := r.HTTPRequest.URL.Query()["attributes"]
+		assert.Assert(t, attributes, "query is %q", r.HTTPRequest.URL.RawQuery)
+		assert.Equal(t, r.HTTPRequest.Header.Get("x-amz-object-attributes"), "ObjectParts")
+
+		page, ok := pages[r.HTTPRequest.Header.Get("x-amz-part-number-marker")]
+		assert.Assert(t, ok)
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(page)),
+		}
+	})
+
+	mockS3 := &S3{api: mockApi}
+
+	sizes, err := mockS3.listPartSizes(context.Background(), u)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sizes, []int64{5, 5, 2})
+}
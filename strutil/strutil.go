@@ -4,25 +4,59 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
-var humanDivisors = [...]struct {
+type humanDivisor struct {
 	suffix string
 	div    int64
-}{
+}
+
+var humanDivisors = [...]humanDivisor{
 	{"K", 1 << 10},
 	{"M", 1 << 20},
 	{"G", 1 << 30},
 	{"T", 1 << 40},
+	{"P", 1 << 50},
+}
+
+var humanSIDivisors = [...]humanDivisor{
+	{"k", 1e3},
+	{"M", 1e6},
+	{"G", 1e9},
+	{"T", 1e12},
+	{"P", 1e15},
 }
 
 // HumanizeBytes takes a byte-size and returns a human-readable string
 func HumanizeBytes(b int64) string {
+	return humanize(b, humanDivisors[:], 1)
+}
+
+// HumanizeBytesSI takes a byte-size and returns a human-readable string in
+// SI units, i.e. powers of 1000 instead of 1024.
+func HumanizeBytesSI(b int64) string {
+	return humanize(b, humanSIDivisors[:], 1)
+}
+
+// HumanizeBytesPrecision is like HumanizeBytes, but with the given number of
+// decimal places.
+func HumanizeBytesPrecision(b int64, precision int) string {
+	return humanize(b, humanDivisors[:], precision)
+}
+
+// HumanizeBytesSIPrecision is like HumanizeBytesSI, but with the given number
+// of decimal places.
+func HumanizeBytesSIPrecision(b int64, precision int) string {
+	return humanize(b, humanSIDivisors[:], precision)
+}
+
+func humanize(b int64, divisors []humanDivisor, precision int) string {
 	var (
 		suffix string
 		div    int64
 	)
-	for _, f := range humanDivisors {
+	for _, f := range divisors {
 		if b > f.div {
 			suffix = f.suffix
 			div = f.div
@@ -32,7 +66,27 @@ func HumanizeBytes(b int64) string {
 		return strconv.FormatInt(b, 10)
 	}
 
-	return fmt.Sprintf("%.1f%s", float64(b)/float64(div), suffix)
+	return fmt.Sprintf("%.*f%s", precision, float64(b)/float64(div), suffix)
+}
+
+// HumanizeCount returns the given count with thousands separators, e.g.
+// 1234567 is formatted as 1,234,567.
+func HumanizeCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+
+	var sign string
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String()
 }
 
 // JSON is a helper function for creating JSON-encoded strings.
@@ -0,0 +1,77 @@
+package strutil
+
+import "testing"
+
+func TestHumanizeBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		size int64
+		iec  string
+		si   string
+	}{
+		{size: 0, iec: "0", si: "0"},
+		{size: 999, iec: "999", si: "999"},
+		{size: 1500, iec: "1.5K", si: "1.5k"},
+		{size: 5 << 20, iec: "5.0M", si: "5.2M"},
+		{size: 3e9, iec: "2.8G", si: "3.0G"},
+		{size: 2 << 40, iec: "2.0T", si: "2.2T"},
+		{size: 3 << 50, iec: "3.0P", si: "3.4P"},
+	}
+
+	for _, tc := range tests {
+		if got := HumanizeBytes(tc.size); got != tc.iec {
+			t.Errorf("HumanizeBytes(%d): expected %q, got %q", tc.size, tc.iec, got)
+		}
+		if got := HumanizeBytesSI(tc.size); got != tc.si {
+			t.Errorf("HumanizeBytesSI(%d): expected %q, got %q", tc.size, tc.si, got)
+		}
+	}
+}
+
+func TestHumanizeBytesPrecision(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		size      int64
+		precision int
+		iec       string
+		si        string
+	}{
+		{size: 999, precision: 3, iec: "999", si: "999"},
+		{size: 1500, precision: 0, iec: "1K", si: "2k"},
+		{size: 1234567, precision: 3, iec: "1.177M", si: "1.235M"},
+		{size: 3e9, precision: 4, iec: "2.7940G", si: "3.0000G"},
+	}
+
+	for _, tc := range tests {
+		if got := HumanizeBytesPrecision(tc.size, tc.precision); got != tc.iec {
+			t.Errorf("HumanizeBytesPrecision(%d, %d): expected %q, got %q", tc.size, tc.precision, tc.iec, got)
+		}
+		if got := HumanizeBytesSIPrecision(tc.size, tc.precision); got != tc.si {
+			t.Errorf("HumanizeBytesSIPrecision(%d, %d): expected %q, got %q", tc.size, tc.precision, tc.si, got)
+		}
+	}
+}
+
+func TestHumanizeCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		count    int64
+		expected string
+	}{
+		{count: 0, expected: "0"},
+		{count: 999, expected: "999"},
+		{count: 1000, expected: "1,000"},
+		{count: 123456, expected: "123,456"},
+		{count: 1234567, expected: "1,234,567"},
+		{count: -1234, expected: "-1,234"},
+	}
+
+	for _, tc := range tests {
+		if got := HumanizeCount(tc.count); got != tc.expected {
+			t.Errorf("HumanizeCount(%d): expected %q, got %q", tc.count, tc.expected, got)
+		}
+	}
+}